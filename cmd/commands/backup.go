@@ -0,0 +1,213 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/inngest/inngest/cmd/commands/internal/localconfig"
+	"github.com/inngest/inngest/pkg/backup"
+	"github.com/inngest/inngest/pkg/consts"
+	"github.com/inngest/inngest/pkg/cqrs/base_cqrs"
+	"github.com/redis/rueidis"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+func NewCmdExport() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export a self-hosted server's apps, history and queue to a backup file.",
+		Long: "Export a self-hosted server's apps, functions, events, run history, traces and queue to a backup file, " +
+			"which can be imported into another server using either SQLite or Postgres.\n\n" +
+			"Stop the server before exporting to ensure the backup is consistent.",
+		Example: "inngest export --out backup.tar.zst --sqlite-dir ./data",
+		Args:    cobra.NoArgs,
+		Run:     doExport,
+	}
+	cmd.Flags().AddFlagSet(persistenceFlags())
+	cmd.Flags().StringP("out", "o", "", "Path of the backup file to write, or - for stdout.  Defaults to inngest-backup-<timestamp>.tar.zst.")
+	return cmd
+}
+
+func NewCmdImport() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import a backup file created by `inngest export` into an empty server.",
+		Long: "Import a backup file created by `inngest export` into an empty server.  The backup's checksums and " +
+			"row counts are verified and all data is imported in a single transaction, so a failed import leaves the " +
+			"server empty.\n\n" +
+			"Stop the server before importing.  Encrypted data is imported as-is, so the server must use the same " +
+			"encryption keyfile as the exported server.",
+		Example: "inngest import backup.tar.zst --postgres-uri postgres://localhost:5432/inngest",
+		Args:    cobra.ExactArgs(1),
+		Run:     doImport,
+	}
+	cmd.Flags().AddFlagSet(persistenceFlags())
+	return cmd
+}
+
+// persistenceFlags returns the flags used to open a server's database and
+// Redis, matching `inngest start`.
+func persistenceFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("persistence", pflag.ExitOnError)
+	fs.String("config", "", "Path to an Inngest configuration file")
+	fs.String("sqlite-dir", "", "Directory containing the server's SQLite database.")
+	fs.String("postgres-uri", "", "PostgreSQL database URI of the server.  Defaults to the SQLite database.")
+	fs.String("redis-uri", "", "Redis server URI of the server.  Defaults to the in-memory Redis persisted within the database.")
+	return fs
+}
+
+// openInstance opens the database and Redis configured for the given command.
+func openInstance(cmd *cobra.Command) (backup.Instance, func(), error) {
+	if err := localconfig.InitPersistenceConfig(cmd.Context(), cmd); err != nil {
+		return backup.Instance{}, nil, err
+	}
+
+	var (
+		sqliteDir   = viper.GetString("sqlite-dir")
+		postgresURI = viper.GetString("postgres-uri")
+		redisURI    = viper.GetString("redis-uri")
+	)
+
+	db, err := base_cqrs.New(base_cqrs.BaseCQRSOptions{
+		PostgresURI: postgresURI,
+		Directory:   sqliteDir,
+	})
+	if err != nil {
+		return backup.Instance{}, nil, fmt.Errorf("error opening database: %w", err)
+	}
+
+	i := backup.Instance{DB: db, Driver: "sqlite"}
+	if postgresURI != "" {
+		i.Driver = "postgres"
+	}
+
+	if redisURI == "" {
+		dir := sqliteDir
+		if dir == "" {
+			dir = consts.DefaultInngestConfigDir
+		}
+		i.WALDir = filepath.Join(dir, consts.StartRedisWALDir)
+		return i, func() { _ = db.Close() }, nil
+	}
+
+	opt, err := rueidis.ParseURL(redisURI)
+	if err != nil {
+		_ = db.Close()
+		return backup.Instance{}, nil, fmt.Errorf("error parsing redis uri: %w", err)
+	}
+	opt.DisableCache = true
+	i.Redis, err = rueidis.NewClient(opt)
+	if err != nil {
+		_ = db.Close()
+		return backup.Instance{}, nil, fmt.Errorf("error connecting to redis: %w", err)
+	}
+	return i, func() {
+		i.Redis.Close()
+		_ = db.Close()
+	}, nil
+}
+
+func doExport(cmd *cobra.Command, args []string) {
+	src, done, err := openInstance(cmd)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer done()
+
+	out, _ := cmd.Flags().GetString("out")
+	if out == "" {
+		out = fmt.Sprintf("inngest-backup-%s.tar.zst", time.Now().UTC().Format("20060102T150405Z"))
+	}
+
+	var m *backup.Manifest
+	if out == "-" {
+		m, err = backup.Export(cmd.Context(), src, os.Stdout)
+	} else {
+		m, err = exportFile(cmd.Context(), src, out)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	// Write the summary to stderr, as the backup may be written to stdout.
+	if out != "-" {
+		fmt.Fprintf(os.Stderr, "Exported %s to %s: %s\n", m.Driver, out, summary(m))
+	} else {
+		fmt.Fprintf(os.Stderr, "Exported %s: %s\n", m.Driver, summary(m))
+	}
+}
+
+// exportFile writes a backup to a temporary file which is renamed once
+// complete, so that a failed export doesn't leave a partial backup.
+func exportFile(ctx context.Context, src backup.Instance, path string) (*backup.Manifest, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	m, err := backup.Export(ctx, src, f)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return m, os.Rename(f.Name(), path)
+}
+
+func doImport(cmd *cobra.Command, args []string) {
+	var r io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	dst, done, err := openInstance(cmd)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer done()
+
+	m, err := backup.Import(cmd.Context(), dst, r)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("Imported %s backup created %s into %s: %s\n", m.Driver, m.CreatedAt.Format(time.RFC3339), dst.Driver, summary(m))
+}
+
+// summary lists the number of rows within each table of a backup.
+func summary(m *backup.Manifest) string {
+	counts := []string{}
+	for _, e := range m.Entries {
+		if e.Rows == 0 {
+			continue
+		}
+		if table, ok := strings.CutSuffix(e.Name, ".jsonl"); ok {
+			counts = append(counts, fmt.Sprintf("%d %s", e.Rows, table))
+		} else {
+			counts = append(counts, fmt.Sprintf("%d redis keys", e.Rows))
+		}
+	}
+	if len(counts) == 0 {
+		return "no data"
+	}
+	return strings.Join(counts, ", ")
+}
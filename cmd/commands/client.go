@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/inngest/inngest/cmd/commands/internal/client"
+	"github.com/inngest/inngest/cmd/commands/internal/localconfig"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// clientFlags returns the flags used by commands which call a running server.
+// These share their names with the flags of `inngest start`, so that the same
+// config file and environment variables can be used.
+func clientFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("client", pflag.ExitOnError)
+	fs.String("config", "", "Path to an Inngest configuration file")
+	fs.String("url", "", "Inngest server URL (ex. https://inngest.example.com).  Defaults to the configured host and port.")
+	fs.String("host", "", "Inngest server hostname")
+	fs.StringP("port", "p", "8288", "Inngest server port")
+	fs.String("event-key", "", "Event key used to send events to the server.")
+	fs.String("api-token", "", "API token used to authenticate with the server's API, if auth is configured.")
+	fs.String("signing-key", "", "Signing key used to authenticate with the server's API if no API token is set.")
+	return fs
+}
+
+// newClient loads the config for the given command and returns a client for
+// the configured server.
+func newClient(cmd *cobra.Command) (*client.Client, error) {
+	if err := localconfig.InitClientConfig(cmd.Context(), cmd); err != nil {
+		return nil, err
+	}
+
+	// The "event-key" config may list many keys for `inngest start`;  any of
+	// them may be used to send events.
+	eventKey := ""
+	if keys := viper.GetStringSlice("event-key"); len(keys) > 0 {
+		eventKey = keys[0]
+	}
+	token := viper.GetString("api-token")
+	if token == "" {
+		token = viper.GetString("signing-key")
+	}
+	return client.New(localconfig.ServerURL(), eventKey, token), nil
+}
+
+// readData returns the data given via a --data flag.  Data is either inline
+// JSON, a path to a file prefixed with "@", or "@-" to read from stdin.
+func readData(data string) (io.ReadCloser, error) {
+	switch {
+	case data == "":
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	case data == "@-":
+		return io.NopCloser(os.Stdin), nil
+	case strings.HasPrefix(data, "@"):
+		f, err := os.Open(strings.TrimPrefix(data, "@"))
+		if err != nil {
+			return nil, fmt.Errorf("error reading data: %w", err)
+		}
+		return f, nil
+	default:
+		return io.NopCloser(strings.NewReader(data)), nil
+	}
+}
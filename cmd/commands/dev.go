@@ -11,6 +11,7 @@ import (
 
 	"github.com/inngest/inngest/cmd/commands/internal/localconfig"
 	"github.com/inngest/inngest/pkg/config"
+	"github.com/inngest/inngest/pkg/consts"
 	"github.com/inngest/inngest/pkg/devserver"
 	"github.com/inngest/inngest/pkg/headers"
 	itrace "github.com/inngest/inngest/pkg/telemetry/trace"
@@ -36,6 +37,9 @@ func NewCmdDev(rootCmd *cobra.Command) *cobra.Command {
 	baseFlags.String("config", "", "Path to an Inngest configuration file")
 	baseFlags.String("host", "", "Inngest server host")
 	baseFlags.StringP("port", "p", "8288", "Inngest server port")
+	baseFlags.String("persist", "", fmt.Sprintf("Persist apps, history and in-progress runs across restarts within the given directory (default %q when set without a value)", consts.DevServerPersistDir))
+	baseFlags.Lookup("persist").NoOptDefVal = consts.DevServerPersistDir
+	baseFlags.Bool("reset", false, "Remove persisted data before starting; requires --persist")
 	baseFlags.BoolP("help", "h", false, "Output this help information")
 	cmd.Flags().AddFlagSet(baseFlags)
 	groups = append(groups, FlagGroup{name: "Flags:", fs: baseFlags})
@@ -78,20 +82,6 @@ func NewCmdDev(rootCmd *cobra.Command) *cobra.Command {
 }
 
 func doDev(cmd *cobra.Command, args []string) {
-
-	go func() {
-		ctx, cleanup := signal.NotifyContext(
-			context.Background(),
-			os.Interrupt,
-			syscall.SIGTERM,
-			syscall.SIGINT,
-			syscall.SIGQUIT,
-		)
-		defer cleanup()
-		<-ctx.Done()
-		os.Exit(0)
-	}()
-
 	ctx := cmd.Context()
 	conf, err := config.Dev(ctx)
 	if err != nil {
@@ -128,6 +118,28 @@ func doDev(cmd *cobra.Command, args []string) {
 	queueWorkers := viper.GetInt("queue-workers")
 	tick := viper.GetInt("tick")
 	connectGatewayPort := viper.GetInt("connect-gateway-port")
+	persistDir := viper.GetString("persist")
+	reset := viper.GetBool("reset")
+	if reset && persistDir == "" {
+		fmt.Println("Ignoring --reset: data is only stored when using --persist")
+	}
+
+	// Persistent dev servers shut down gracefully, snapshotting the queue
+	// before exiting.
+	if persistDir == "" {
+		go func() {
+			ctx, cleanup := signal.NotifyContext(
+				context.Background(),
+				os.Interrupt,
+				syscall.SIGTERM,
+				syscall.SIGINT,
+				syscall.SIGQUIT,
+			)
+			defer cleanup()
+			<-ctx.Done()
+			os.Exit(0)
+		}()
+	}
 
 	traceEndpoint := fmt.Sprintf("localhost:%d", port)
 	if err := itrace.NewUserTracer(ctx, itrace.TracerOpts{
@@ -169,6 +181,8 @@ func doDev(cmd *cobra.Command, args []string) {
 		URLs:               urls,
 		ConnectGatewayPort: connectGatewayPort,
 		ConnectGatewayHost: conf.CoreAPI.Addr,
+		PersistDir:         persistDir,
+		Reset:              reset,
 	}
 
 	err = devserver.New(ctx, opts)
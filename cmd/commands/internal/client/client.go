@@ -0,0 +1,149 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/inngest/inngest/pkg/coreapi/apiutil"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/oklog/ulid/v2"
+)
+
+// DefaultEventKey is used when sending events to a server without any event
+// keys configured, eg. the dev server.
+const DefaultEventKey = "NO_EVENT_KEY_SET"
+
+// Client is a minimal HTTP client for a running Inngest server, used by CLI
+// commands which send events and inspect runs.
+type Client struct {
+	// URL is the base URL of the server, eg. "http://localhost:8288".
+	URL string
+	// EventKey is used to send events.
+	EventKey string
+	// Token, if set, is sent as a bearer token to authenticate API requests.
+	Token string
+
+	HTTP *http.Client
+}
+
+// New returns a client for the server at the given URL.
+func New(serverURL, eventKey, token string) *Client {
+	if eventKey == "" {
+		eventKey = DefaultEventKey
+	}
+	return &Client{
+		URL:      strings.TrimSuffix(serverURL, "/"),
+		EventKey: eventKey,
+		Token:    token,
+		HTTP:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Send sends a batch of events, returning the ID of each event in order.
+func (c *Client) Send(ctx context.Context, events []json.RawMessage) ([]string, error) {
+	resp := apiutil.EventAPIResponse{}
+	err := c.do(ctx, http.MethodPost, "/e/"+url.PathEscape(c.EventKey), events, &resp)
+	if err != nil {
+		return resp.IDs, err
+	}
+	return resp.IDs, nil
+}
+
+// Invoke invokes the function with the given slug, passing data as the
+// invocation event's data.  This returns the ID of the invocation event.
+func (c *Client) Invoke(ctx context.Context, slug string, data json.RawMessage) (string, error) {
+	body := map[string]any{}
+	if len(data) > 0 {
+		body["data"] = data
+	}
+
+	resp := struct {
+		ID string `json:"id"`
+	}{}
+	if err := c.do(ctx, http.MethodPost, "/invoke/"+url.PathEscape(slug), body, &resp); err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+// EventRuns returns all runs triggered by the given event.
+func (c *Client) EventRuns(ctx context.Context, eventID string) ([]*cqrs.FunctionRun, error) {
+	resp := struct {
+		Data []*cqrs.FunctionRun `json:"data"`
+	}{}
+	if err := c.do(ctx, http.MethodGet, "/v1/events/"+url.PathEscape(eventID)+"/runs", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// Run returns a single function run.
+func (c *Client) Run(ctx context.Context, runID ulid.ULID) (*cqrs.FunctionRun, error) {
+	resp := struct {
+		Data *cqrs.FunctionRun `json:"data"`
+	}{}
+	if err := c.do(ctx, http.MethodGet, "/v1/runs/"+runID.String(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// do sends a request with an optional JSON body, decoding the JSON response into
+// out.  Non-2xx responses are returned as errors using the API's error message.
+func (c *Client) do(ctx context.Context, method, path string, body any, out any) error {
+	var r io.Reader
+	if body != nil {
+		byt, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error encoding request: %w", err)
+		}
+		r = bytes.NewReader(byt)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.URL+path, r)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("error connecting to %s: %w", c.URL, err)
+	}
+	defer resp.Body.Close()
+
+	byt, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response: %w", err)
+	}
+
+	if resp.StatusCode > 299 {
+		apiErr := struct {
+			Error string `json:"error"`
+		}{}
+		_ = json.Unmarshal(byt, &apiErr)
+		_ = json.Unmarshal(byt, out)
+		if apiErr.Error == "" {
+			apiErr.Error = strings.TrimSpace(string(byt))
+		}
+		return fmt.Errorf("%s (status %d)", apiErr.Error, resp.StatusCode)
+	}
+
+	if out == nil || len(byt) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(byt, out); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// Run is a function run, as returned by the GraphQL API.
+type Run struct {
+	ID         ulid.ULID  `json:"id"`
+	FunctionID string     `json:"functionID"`
+	Function   Function   `json:"function"`
+	Status     string     `json:"status"`
+	EventName  *string    `json:"eventName"`
+	QueuedAt   time.Time  `json:"queuedAt"`
+	StartedAt  *time.Time `json:"startedAt"`
+	EndedAt    *time.Time `json:"endedAt"`
+	Output     *string    `json:"output"`
+	Trace      *Span      `json:"trace"`
+}
+
+// Ended returns whether the run has finished.
+func (r Run) Ended() bool {
+	return r.EndedAt != nil || r.Status == "COMPLETED" || r.Status == "FAILED" || r.Status == "CANCELLED"
+}
+
+// Function is a function registered with the server.
+type Function struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// Span is a single span within a run's trace, representing the run itself or
+// one of its steps.
+type Span struct {
+	SpanID    string     `json:"spanID"`
+	Name      string     `json:"name"`
+	Status    string     `json:"status"`
+	StepOp    *string    `json:"stepOp"`
+	Attempts  *int       `json:"attempts"`
+	Duration  *int       `json:"duration"`
+	QueuedAt  time.Time  `json:"queuedAt"`
+	StartedAt *time.Time `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt"`
+	Children  []*Span    `json:"childrenSpans"`
+}
+
+// RunsFilter filters the runs returned by Runs.
+type RunsFilter struct {
+	From        time.Time
+	Until       *time.Time
+	Status      []string
+	FunctionIDs []string
+	// Ascending orders runs by the oldest first.
+	Ascending bool
+	Limit     int
+	After     *string
+}
+
+// spanFields are the span fields queried for each level of a run's trace.
+const spanFields = `spanID name status stepOp attempts duration queuedAt startedAt endedAt`
+
+const runFields = `id functionID function { id name slug } status eventName queuedAt startedAt endedAt output`
+
+// Functions returns all functions registered with the server.
+func (c *Client) Functions(ctx context.Context) ([]Function, error) {
+	resp := struct {
+		Functions []Function `json:"functions"`
+	}{}
+	err := c.GQL(ctx, `query { functions { id name slug } }`, nil, &resp)
+	return resp.Functions, err
+}
+
+// Runs lists runs matching the given filter, returning the cursor of the last
+// run for pagination.
+func (c *Client) Runs(ctx context.Context, f RunsFilter) ([]Run, *string, error) {
+	filter := map[string]any{"from": f.From}
+	if f.Until != nil {
+		filter["until"] = f.Until
+	}
+	if len(f.Status) > 0 {
+		filter["status"] = f.Status
+	}
+	if len(f.FunctionIDs) > 0 {
+		filter["functionIDs"] = f.FunctionIDs
+	}
+
+	direction := "DESC"
+	if f.Ascending {
+		direction = "ASC"
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	resp := struct {
+		Runs struct {
+			Edges []struct {
+				Node   Run    `json:"node"`
+				Cursor string `json:"cursor"`
+			} `json:"edges"`
+		} `json:"runs"`
+	}{}
+	err := c.GQL(ctx, `
+		query Runs($first: Int!, $after: String, $filter: RunsFilterV2!, $orderBy: [RunsV2OrderBy!]!) {
+			runs(first: $first, after: $after, filter: $filter, orderBy: $orderBy) {
+				edges { cursor node { `+runFields+` } }
+			}
+		}`,
+		map[string]any{
+			"first":   limit,
+			"after":   f.After,
+			"filter":  filter,
+			"orderBy": []map[string]any{{"field": "QUEUED_AT", "direction": direction}},
+		},
+		&resp,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		runs   = make([]Run, len(resp.Runs.Edges))
+		cursor *string
+	)
+	for n, edge := range resp.Runs.Edges {
+		runs[n] = edge.Node
+		cursor = &edge.Cursor
+	}
+	return runs, cursor, nil
+}
+
+// GetRun returns a single run including its trace, or nil if the run is not
+// found.
+func (c *Client) GetRun(ctx context.Context, runID ulid.ULID) (*Run, error) {
+	// GraphQL doesn't support recursive fragments, so query a fixed depth of
+	// nested spans.
+	span := spanFields
+	for n := 0; n < 3; n++ {
+		span = spanFields + ` childrenSpans { ` + span + ` }`
+	}
+
+	resp := struct {
+		Run *Run `json:"run"`
+	}{}
+	err := c.GQL(ctx, `
+		query Run($runID: String!) {
+			run(runID: $runID) { `+runFields+` trace { `+span+` } }
+		}`,
+		map[string]any{"runID": runID.String()},
+		&resp,
+	)
+	return resp.Run, err
+}
+
+// CancelRun cancels a run.
+func (c *Client) CancelRun(ctx context.Context, runID ulid.ULID) error {
+	return c.GQL(ctx, `
+		mutation CancelRun($runID: ULID!) {
+			cancelRun(runID: $runID) { id }
+		}`,
+		map[string]any{"runID": runID},
+		nil,
+	)
+}
+
+// Rerun reruns a run from the start, returning the ID of the new run.
+func (c *Client) Rerun(ctx context.Context, runID ulid.ULID) (ulid.ULID, error) {
+	resp := struct {
+		Rerun ulid.ULID `json:"rerun"`
+	}{}
+	err := c.GQL(ctx, `
+		mutation Rerun($runID: ULID!) {
+			rerun(runID: $runID)
+		}`,
+		map[string]any{"runID": runID},
+		&resp,
+	)
+	return resp.Rerun, err
+}
+
+// gqlResponse is a GraphQL response.  Error paths are ignored as they may
+// contain both strings and indexes.
+type gqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// GQL runs a query against the server's GraphQL API, decoding the response's
+// data into out.
+func (c *Client) GQL(ctx context.Context, query string, vars map[string]any, out any) error {
+	resp := gqlResponse{}
+	err := c.do(ctx, http.MethodPost, "/v0/gql", map[string]any{"query": query, "variables": vars}, &resp)
+	if err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		msgs := make([]string, len(resp.Errors))
+		for n, e := range resp.Errors {
+			msgs[n] = e.Message
+		}
+		return fmt.Errorf("%s", strings.Join(msgs, ", "))
+	}
+	if out == nil || len(resp.Data) == 0 || string(resp.Data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Data, out); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
@@ -2,12 +2,16 @@ package localconfig
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 
 	"github.com/inngest/inngest/pkg/logger"
 	"github.com/spf13/cobra"
@@ -34,6 +38,50 @@ func InitStartConfig(ctx context.Context, cmd *cobra.Command) error {
 	return nil
 }
 
+// InitClientConfig loads the config for commands which call a running server,
+// such as `inngest send`.  This reads the same config file and environment
+// variables as `inngest start`.
+func InitClientConfig(ctx context.Context, cmd *cobra.Command) error {
+	if err := mapClientFlags(cmd); err != nil {
+		return err
+	}
+
+	loadConfigFile(ctx, cmd)
+
+	return nil
+}
+
+// InitPersistenceConfig loads the config for commands which open a server's
+// database and Redis directly, such as `inngest export`.  This reads the same
+// config file and environment variables as `inngest start`.
+func InitPersistenceConfig(ctx context.Context, cmd *cobra.Command) error {
+	if err := mapPersistenceFlags(cmd); err != nil {
+		return err
+	}
+
+	loadConfigFile(ctx, cmd)
+
+	return nil
+}
+
+// ServerURL returns the base URL of the server configured via the "url" key, or
+// via the "host" and "port" keys used by `inngest start`.
+func ServerURL() string {
+	if u := viper.GetString("url"); u != "" {
+		return strings.TrimSuffix(u, "/")
+	}
+
+	host := viper.GetString("host")
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	port := viper.GetString("port")
+	if port == "" {
+		port = "8288"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
 func loadConfigFile(ctx context.Context, cmd *cobra.Command) {
 	l := logger.From(ctx).With().Logger()
 
@@ -86,6 +134,50 @@ func loadConfigFile(ctx context.Context, cmd *cobra.Command) {
 	}
 }
 
+// UnmarshalKey decodes the value of the given config key into v using v's JSON
+// struct tags.  This is a no-op if the key isn't set.
+func UnmarshalKey(key string, v any) error {
+	if !viper.IsSet(key) {
+		return nil
+	}
+	byt, err := json.Marshal(viper.Get(key))
+	if err != nil {
+		return fmt.Errorf("error reading %s config: %w", key, err)
+	}
+	if err := json.Unmarshal(byt, v); err != nil {
+		return fmt.Errorf("error parsing %s config: %w", key, err)
+	}
+	return nil
+}
+
+// ReloadOnSignal re-reads the config file each time the process receives a
+// SIGHUP, calling f after every reload.  Environment variables and flags are
+// re-read as well, though only the config file is expected to change.  This
+// blocks until the context is cancelled.
+func ReloadOnSignal(ctx context.Context, f func()) {
+	l := logger.From(ctx).With().Logger()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	defer signal.Stop(sig)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sig:
+			if viper.ConfigFileUsed() != "" {
+				if err := viper.ReadInConfig(); err != nil {
+					l.Error().Err(err).Msg("error reloading config file")
+					continue
+				}
+			}
+			l.Info().Str("config", viper.ConfigFileUsed()).Msg("reloaded config")
+			f()
+		}
+	}
+}
+
 // mapDevFlags binds the command line flags to the viper configuration
 func mapDevFlags(cmd *cobra.Command) error {
 	var err error
@@ -99,6 +191,8 @@ func mapDevFlags(cmd *cobra.Command) error {
 	err = errors.Join(err, viper.BindPFlag("tick", cmd.Flags().Lookup("tick")))
 	err = errors.Join(err, viper.BindPFlag("sdk-url", cmd.Flags().Lookup("sdk-url")))
 	err = errors.Join(err, viper.BindPFlag("connect-gateway-port", cmd.Flags().Lookup("connect-gateway-port")))
+	err = errors.Join(err, viper.BindPFlag("persist", cmd.Flags().Lookup("persist")))
+	err = errors.Join(err, viper.BindPFlag("reset", cmd.Flags().Lookup("reset")))
 
 	return err
 }
@@ -109,9 +203,15 @@ func mapStartFlags(cmd *cobra.Command) error {
 	err = errors.Join(err, viper.BindPFlag("host", cmd.Flags().Lookup("host")))
 	err = errors.Join(err, viper.BindPFlag("port", cmd.Flags().Lookup("port")))
 	err = errors.Join(err, viper.BindPFlag("signing-key", cmd.Flags().Lookup("signing-key")))
+	err = errors.Join(err, viper.BindPFlag("signing-key-fallback", cmd.Flags().Lookup("signing-key-fallback")))
 	err = errors.Join(err, viper.BindPFlag("event-key", cmd.Flags().Lookup("event-key")))
+	err = errors.Join(err, viper.BindPFlag("realtime-secret", cmd.Flags().Lookup("realtime-secret")))
+	err = errors.Join(err, viper.BindPFlag("connect-secret", cmd.Flags().Lookup("connect-secret")))
 	err = errors.Join(err, viper.BindPFlag("redis-uri", cmd.Flags().Lookup("redis-uri")))
+	err = errors.Join(err, viper.BindPFlag("redis-wal-fsync", cmd.Flags().Lookup("redis-wal-fsync")))
 	err = errors.Join(err, viper.BindPFlag("postgres-uri", cmd.Flags().Lookup("postgres-uri")))
+	err = errors.Join(err, viper.BindPFlag("encryption-keyfile", cmd.Flags().Lookup("encryption-keyfile")))
+	err = errors.Join(err, viper.BindPFlag("encryption-reencrypt", cmd.Flags().Lookup("encryption-reencrypt")))
 	err = errors.Join(err, viper.BindPFlag("poll-interval", cmd.Flags().Lookup("poll-interval")))
 	err = errors.Join(err, viper.BindPFlag("retry-interval", cmd.Flags().Lookup("retry-interval")))
 	err = errors.Join(err, viper.BindPFlag("queue-workers", cmd.Flags().Lookup("queue-workers")))
@@ -119,6 +219,33 @@ func mapStartFlags(cmd *cobra.Command) error {
 	err = errors.Join(err, viper.BindPFlag("sqlite-dir", cmd.Flags().Lookup("sqlite-dir")))
 	err = errors.Join(err, viper.BindPFlag("tick", cmd.Flags().Lookup("tick")))
 	err = errors.Join(err, viper.BindPFlag("connect-gateway-port", cmd.Flags().Lookup("connect-gateway-port")))
+	err = errors.Join(err, viper.BindPFlag("enable-debug", cmd.Flags().Lookup("enable-debug")))
+	err = errors.Join(err, viper.BindPFlag("role", cmd.Flags().Lookup("role")))
+	err = errors.Join(err, viper.BindPFlag("drain-period", cmd.Flags().Lookup("drain-period")))
+	err = errors.Join(err, viper.BindPFlag("api-url", cmd.Flags().Lookup("api-url")))
+
+	return err
+}
+
+// mapClientFlags binds the command line flags to the viper configuration
+func mapClientFlags(cmd *cobra.Command) error {
+	var err error
+	err = errors.Join(err, viper.BindPFlag("url", cmd.Flags().Lookup("url")))
+	err = errors.Join(err, viper.BindPFlag("host", cmd.Flags().Lookup("host")))
+	err = errors.Join(err, viper.BindPFlag("port", cmd.Flags().Lookup("port")))
+	err = errors.Join(err, viper.BindPFlag("signing-key", cmd.Flags().Lookup("signing-key")))
+	err = errors.Join(err, viper.BindPFlag("event-key", cmd.Flags().Lookup("event-key")))
+	err = errors.Join(err, viper.BindPFlag("api-token", cmd.Flags().Lookup("api-token")))
+
+	return err
+}
+
+// mapPersistenceFlags binds the command line flags to the viper configuration
+func mapPersistenceFlags(cmd *cobra.Command) error {
+	var err error
+	err = errors.Join(err, viper.BindPFlag("sqlite-dir", cmd.Flags().Lookup("sqlite-dir")))
+	err = errors.Join(err, viper.BindPFlag("postgres-uri", cmd.Flags().Lookup("postgres-uri")))
+	err = errors.Join(err, viper.BindPFlag("redis-uri", cmd.Flags().Lookup("redis-uri")))
 
 	return err
 }
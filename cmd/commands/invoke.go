@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/inngest/inngest/cmd/commands/internal/client"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/spf13/cobra"
+)
+
+// invokePollInterval is the interval between checks of an invoked run's status.
+const invokePollInterval = 500 * time.Millisecond
+
+func NewCmdInvoke() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "invoke <function-slug>",
+		Short: "Invoke a function, optionally waiting for the run to finish.",
+		Example: "inngest invoke my-app-send-email --data '{\"to\": \"test@example.com\"}'\n" +
+			"inngest invoke my-app-send-email --data @payload.json --wait",
+		Args: cobra.ExactArgs(1),
+		Run:  doInvoke,
+	}
+	cmd.Flags().AddFlagSet(clientFlags())
+	cmd.Flags().StringP("data", "d", "", "JSON event data, a file prefixed with @, or @- to read from stdin.")
+	cmd.Flags().BoolP("wait", "w", false, "Wait for the run to finish, printing its status and output.")
+	cmd.Flags().Duration("timeout", 0, "Maximum time to wait for the run to finish, eg. 5m.  Defaults to no timeout.")
+	return cmd
+}
+
+func doInvoke(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+
+	c, err := newClient(cmd)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	data, _ := cmd.Flags().GetString("data")
+	r, err := readData(data)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	byt, err := io.ReadAll(r)
+	_ = r.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	if len(byt) > 0 && !isJSONObject(byt) {
+		fmt.Println("Invoke data must be a JSON object")
+		os.Exit(1)
+	}
+
+	eventID, err := c.Invoke(ctx, args[0], byt)
+	if err != nil {
+		fmt.Printf("Error invoking %s: %s\n", args[0], err)
+		os.Exit(1)
+	}
+
+	if wait, _ := cmd.Flags().GetBool("wait"); !wait {
+		fmt.Println(eventID)
+		return
+	}
+
+	if timeout, _ := cmd.Flags().GetDuration("timeout"); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	run, err := waitForRun(ctx, c, eventID, func(run *cqrs.FunctionRun) {
+		fmt.Fprintf(os.Stderr, "%s\t%s\n", run.RunID, run.Status)
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if len(run.Output) > 0 {
+		fmt.Println(string(run.Output))
+	}
+	if run.Status != enums.RunStatusCompleted {
+		os.Exit(1)
+	}
+}
+
+// waitForRun polls the run triggered by the given event until it ends, calling
+// onStatus each time the run's status changes.
+func waitForRun(ctx context.Context, c *client.Client, eventID string, onStatus func(*cqrs.FunctionRun)) (*cqrs.FunctionRun, error) {
+	t := time.NewTicker(invokePollInterval)
+	defer t.Stop()
+
+	var (
+		run  *cqrs.FunctionRun
+		last = enums.RunStatusUnknown
+	)
+
+	for {
+		var err error
+		if run == nil {
+			var runs []*cqrs.FunctionRun
+			runs, err = c.EventRuns(ctx, eventID)
+			if err == nil && len(runs) > 0 {
+				run = runs[0]
+			}
+		} else {
+			var updated *cqrs.FunctionRun
+			updated, err = c.Run(ctx, run.RunID)
+			if err == nil && updated != nil {
+				run = updated
+			}
+		}
+		if err != nil && ctx.Err() == nil {
+			return nil, fmt.Errorf("error checking run status: %w", err)
+		}
+
+		if run != nil {
+			if run.Status != last {
+				last = run.Status
+				onStatus(run)
+			}
+			if enums.RunStatusEnded(run.Status) {
+				return run, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if run == nil {
+				return nil, fmt.Errorf("timed out waiting for run of event %s to start", eventID)
+			}
+			return run, fmt.Errorf("timed out waiting for run %s to finish", run.RunID)
+		case <-t.C:
+		}
+	}
+}
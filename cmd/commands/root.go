@@ -71,6 +71,12 @@ func Execute() {
 	rootCmd.AddCommand(NewCmdDev(rootCmd))
 	rootCmd.AddCommand(NewCmdVersion())
 	rootCmd.AddCommand(NewCmdStart(rootCmd))
+	rootCmd.AddCommand(NewCmdSend())
+	rootCmd.AddCommand(NewCmdInvoke())
+	rootCmd.AddCommand(NewCmdRuns())
+	rootCmd.AddCommand(NewCmdExport())
+	rootCmd.AddCommand(NewCmdImport())
+	rootCmd.AddCommand(NewCmdValidate())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -0,0 +1,370 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/inngest/inngest/cmd/commands/internal/client"
+	"github.com/inngest/inngest/cmd/commands/internal/table"
+	"github.com/inngest/inngest/pkg/cli"
+	"github.com/oklog/ulid/v2"
+	"github.com/spf13/cobra"
+	"github.com/xhit/go-str2duration/v2"
+)
+
+func NewCmdRuns() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "runs",
+		Short: "List, inspect, tail and cancel function runs on an Inngest server.",
+	}
+
+	list := &cobra.Command{
+		Use:     "list",
+		Short:   "List recent runs.",
+		Example: "inngest runs list --function my-app-send-email --status failed --since 1h",
+		Args:    cobra.NoArgs,
+		Run:     doRunsList,
+	}
+	list.Flags().AddFlagSet(clientFlags())
+	list.Flags().StringSlice("function", []string{}, "Only list runs of the given function slugs.")
+	list.Flags().StringSlice("status", []string{}, "Only list runs with the given statuses: queued, running, completed, failed or cancelled.")
+	list.Flags().String("since", "24h", "Only list runs queued since a duration ago (eg. 30m) or a RFC3339 timestamp.")
+	list.Flags().Int("limit", 50, "Maximum number of runs to list.")
+
+	get := &cobra.Command{
+		Use:   "get <run-id>",
+		Short: "Show a run and the timeline of its steps.",
+		Args:  cobra.ExactArgs(1),
+		Run:   doRunsGet,
+	}
+	get.Flags().AddFlagSet(clientFlags())
+
+	tail := &cobra.Command{
+		Use:   "tail",
+		Short: "Follow new runs and their step transitions live.",
+		Args:  cobra.NoArgs,
+		Run:   doRunsTail,
+	}
+	tail.Flags().AddFlagSet(clientFlags())
+	tail.Flags().StringSlice("function", []string{}, "Only follow runs of the given function slugs.")
+	tail.Flags().Duration("interval", time.Second, "Interval between checks for new runs and steps.")
+
+	cancel := &cobra.Command{
+		Use:   "cancel <run-id>...",
+		Short: "Cancel one or more runs.",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   doRunsCancel,
+	}
+	cancel.Flags().AddFlagSet(clientFlags())
+
+	rerun := &cobra.Command{
+		Use:   "rerun <run-id>",
+		Short: "Rerun a run from the start with the same event.",
+		Args:  cobra.ExactArgs(1),
+		Run:   doRunsRerun,
+	}
+	rerun.Flags().AddFlagSet(clientFlags())
+
+	cmd.AddCommand(list, get, tail, cancel, rerun)
+	return cmd
+}
+
+func doRunsList(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	c := mustClient(cmd)
+
+	since, _ := cmd.Flags().GetString("since")
+	from, err := parseSince(since)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	fnIDs, err := functionIDs(ctx, c, cmd)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	status, _ := cmd.Flags().GetStringSlice("status")
+	for n, s := range status {
+		status[n] = strings.ToUpper(s)
+	}
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	runs, _, err := c.Runs(ctx, client.RunsFilter{
+		From:        from,
+		Status:      status,
+		FunctionIDs: fnIDs,
+		Limit:       limit,
+	})
+	if err != nil {
+		fmt.Printf("Error listing runs: %s\n", err)
+		os.Exit(1)
+	}
+
+	t := table.New(table.Row{"Run ID", "Function", "Status", "Trigger", "Queued", "Duration"})
+	for _, run := range runs {
+		t.AppendRow(table.Row{
+			run.ID,
+			run.Function.Slug,
+			run.Status,
+			stringOr(run.EventName, "-"),
+			run.QueuedAt.Local().Format(time.DateTime),
+			runDuration(run.StartedAt, run.EndedAt),
+		})
+	}
+	t.Render()
+}
+
+func doRunsGet(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	c := mustClient(cmd)
+	runID := mustRunID(args[0])
+
+	run, err := c.GetRun(ctx, runID)
+	if err != nil {
+		fmt.Printf("Error loading run: %s\n", err)
+		os.Exit(1)
+	}
+	if run == nil {
+		fmt.Printf("Run %s not found\n", runID)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Run:       %s\n", run.ID)
+	fmt.Printf("Function:  %s\n", run.Function.Slug)
+	fmt.Printf("Status:    %s\n", run.Status)
+	fmt.Printf("Trigger:   %s\n", stringOr(run.EventName, "-"))
+	fmt.Printf("Queued:    %s\n", run.QueuedAt.Local().Format(time.DateTime))
+	fmt.Printf("Duration:  %s\n", runDuration(run.StartedAt, run.EndedAt))
+	if run.Output != nil {
+		fmt.Printf("Output:    %s\n", *run.Output)
+	}
+
+	if run.Trace == nil {
+		return
+	}
+
+	t := table.New(table.Row{"Step", "Op", "Status", "Attempts", "Started", "Duration"})
+	var add func(spans []*client.Span, depth int)
+	add = func(spans []*client.Span, depth int) {
+		for _, s := range spans {
+			attempts := "-"
+			if s.Attempts != nil {
+				attempts = fmt.Sprintf("%d", *s.Attempts)
+			}
+			started := "-"
+			if s.StartedAt != nil {
+				started = s.StartedAt.Local().Format(time.TimeOnly)
+			}
+			t.AppendRow(table.Row{
+				strings.Repeat("  ", depth) + s.Name,
+				stringOr(s.StepOp, "-"),
+				s.Status,
+				attempts,
+				started,
+				runDuration(s.StartedAt, s.EndedAt),
+			})
+			add(s.Children, depth+1)
+		}
+	}
+	add(run.Trace.Children, 0)
+	t.Render()
+}
+
+func doRunsTail(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	c := mustClient(cmd)
+
+	fnIDs, err := functionIDs(ctx, c, cmd)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	interval, _ := cmd.Flags().GetDuration("interval")
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	var (
+		from   = time.Now()
+		cursor *string
+		seen   = map[ulid.ULID]bool{}
+		// active stores the last known status of each step of in-progress
+		// runs, keyed by span ID.
+		active = map[ulid.ULID]map[string]string{}
+	)
+
+	fmt.Println(cli.FeintStyle.Render("Following runs.  Press Ctrl+C to stop."))
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		runs, next, err := c.Runs(ctx, client.RunsFilter{
+			From:        from,
+			FunctionIDs: fnIDs,
+			Ascending:   true,
+			After:       cursor,
+		})
+		if err != nil && ctx.Err() == nil {
+			fmt.Printf("Error listing runs: %s\n", err)
+		}
+		if next != nil {
+			cursor = next
+		}
+
+		for _, run := range runs {
+			if seen[run.ID] {
+				continue
+			}
+			seen[run.ID] = true
+			active[run.ID] = map[string]string{}
+			fmt.Printf("%s\t%s\t%s\tqueued\t%s\n", run.QueuedAt.Local().Format(time.TimeOnly), run.ID, run.Function.Slug, stringOr(run.EventName, ""))
+		}
+
+		for runID, steps := range active {
+			run, err := c.GetRun(ctx, runID)
+			if err != nil || run == nil {
+				continue
+			}
+			if run.Trace != nil {
+				printStepTransitions(run, run.Trace.Children, steps)
+			}
+			if run.Ended() {
+				fmt.Printf("%s\t%s\t%s\t%s\n", time.Now().Format(time.TimeOnly), run.ID, run.Function.Slug, strings.ToLower(run.Status))
+				delete(active, runID)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// printStepTransitions prints each step whose status has changed since the
+// last check, updating the known statuses in steps.
+func printStepTransitions(run *client.Run, spans []*client.Span, steps map[string]string) {
+	for _, s := range spans {
+		if steps[s.SpanID] != s.Status {
+			steps[s.SpanID] = s.Status
+			fmt.Printf("%s\t%s\t%s\t  step %q %s\n", time.Now().Format(time.TimeOnly), run.ID, run.Function.Slug, s.Name, strings.ToLower(s.Status))
+		}
+		printStepTransitions(run, s.Children, steps)
+	}
+}
+
+func doRunsCancel(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	c := mustClient(cmd)
+
+	failed := false
+	for _, arg := range args {
+		runID := mustRunID(arg)
+		if err := c.CancelRun(ctx, runID); err != nil {
+			fmt.Printf("Error cancelling run %s: %s\n", runID, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Cancelled run %s\n", runID)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func doRunsRerun(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	c := mustClient(cmd)
+
+	newID, err := c.Rerun(ctx, mustRunID(args[0]))
+	if err != nil {
+		fmt.Printf("Error rerunning run: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(newID)
+}
+
+func mustClient(cmd *cobra.Command) *client.Client {
+	c, err := newClient(cmd)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	return c
+}
+
+func mustRunID(s string) ulid.ULID {
+	runID, err := ulid.Parse(s)
+	if err != nil {
+		fmt.Printf("Invalid run ID: %s\n", s)
+		os.Exit(1)
+	}
+	return runID
+}
+
+// functionIDs resolves the slugs given via the --function flag to function IDs.
+func functionIDs(ctx context.Context, c *client.Client, cmd *cobra.Command) ([]string, error) {
+	slugs, _ := cmd.Flags().GetStringSlice("function")
+	if len(slugs) == 0 {
+		return nil, nil
+	}
+
+	fns, err := c.Functions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error loading functions: %w", err)
+	}
+
+	ids := make([]string, 0, len(slugs))
+	for _, slug := range slugs {
+		found := false
+		for _, fn := range fns {
+			if fn.Slug == slug {
+				ids = append(ids, fn.ID)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Function not found: %s", slug)
+		}
+	}
+	return ids, nil
+}
+
+// parseSince parses either a duration before now (eg. "1h" or "2d") or a
+// RFC3339 timestamp.
+func parseSince(since string) (time.Time, error) {
+	if ts, err := time.Parse(time.RFC3339, since); err == nil {
+		return ts, nil
+	}
+	dur, err := str2duration.ParseDuration(since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid --since value '%s': must be a duration or RFC3339 timestamp", since)
+	}
+	return time.Now().Add(-dur), nil
+}
+
+func runDuration(started, ended *time.Time) string {
+	if started == nil {
+		return "-"
+	}
+	end := time.Now()
+	if ended != nil {
+		end = *ended
+	}
+	return end.Sub(*started).Round(time.Millisecond).String()
+}
+
+func stringOr(s *string, def string) string {
+	if s == nil || *s == "" {
+		return def
+	}
+	return *s
+}
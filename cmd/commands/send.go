@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/inngest/inngest/cmd/commands/internal/client"
+	"github.com/spf13/cobra"
+)
+
+// sendBatchSize is the maximum number of events sent in a single request when
+// streaming events.
+const sendBatchSize = 100
+
+func NewCmdSend() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send one or more events to an Inngest server.",
+		Long: "Send one or more events to an Inngest server.\n\n" +
+			"If --name is given, --data is used as the event's data.  Otherwise --data must\n" +
+			"contain full events: a single event, an array of events, or newline-delimited\n" +
+			"JSON with one event or data payload per line.",
+		Example: "inngest send --name app/user.created --data '{\"id\": 1}'\n" +
+			"inngest send --name app/user.created --data @users.ndjson\n" +
+			"cat events.ndjson | inngest send --data @-",
+		Args: cobra.NoArgs,
+		Run:  doSend,
+	}
+	cmd.Flags().AddFlagSet(clientFlags())
+	cmd.Flags().StringP("name", "n", "", "Event name.  If set, --data is used as the event's data.")
+	cmd.Flags().StringP("data", "d", "", "JSON to send, a file prefixed with @, or @- to read from stdin.")
+	return cmd
+}
+
+func doSend(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+
+	c, err := newClient(cmd)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	name, _ := cmd.Flags().GetString("name")
+	data, _ := cmd.Flags().GetString("data")
+	if name == "" && data == "" {
+		fmt.Println("Either --name or --data must be specified")
+		os.Exit(1)
+	}
+
+	r, err := readData(data)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer r.Close()
+
+	ids, err := sendEvents(ctx, c, name, r)
+	for _, id := range ids {
+		fmt.Println(id)
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+// sendEvents reads a stream of JSON values from r and sends them as events in
+// batches, returning the IDs of all sent events.
+//
+// If name is set each value is used as the data for an event with that name,
+// else each value must be an event or an array of events.
+func sendEvents(ctx context.Context, c *client.Client, name string, r io.Reader) ([]string, error) {
+	var (
+		ids   []string
+		batch []json.RawMessage
+	)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		sent, err := c.Send(ctx, batch)
+		ids = append(ids, sent...)
+		batch = batch[:0]
+		if err != nil {
+			return fmt.Errorf("error sending events: %w", err)
+		}
+		return nil
+	}
+
+	add := func(evt json.RawMessage) error {
+		batch = append(batch, evt)
+		if len(batch) >= sendBatchSize {
+			return flush()
+		}
+		return nil
+	}
+
+	dec := json.NewDecoder(r)
+	n := 0
+	for {
+		var val json.RawMessage
+		err := dec.Decode(&val)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return ids, fmt.Errorf("error reading event data: %w", err)
+		}
+		n++
+
+		if name != "" {
+			if !isJSONObject(val) {
+				return ids, fmt.Errorf("event data must be a JSON object")
+			}
+			evt, err := json.Marshal(map[string]any{"name": name, "data": val})
+			if err != nil {
+				return ids, err
+			}
+			if err := add(evt); err != nil {
+				return ids, err
+			}
+			continue
+		}
+
+		// Without a name, each value is either an event or an array of
+		// events.
+		evts := []json.RawMessage{val}
+		if !isJSONObject(val) {
+			if err := json.Unmarshal(val, &evts); err != nil {
+				return ids, fmt.Errorf("events must be a JSON object or an array of objects")
+			}
+		}
+		for _, evt := range evts {
+			if err := add(evt); err != nil {
+				return ids, err
+			}
+		}
+	}
+
+	// Allow sending an event without any data.
+	if n == 0 && name != "" {
+		evt, _ := json.Marshal(map[string]any{"name": name, "data": map[string]any{}})
+		batch = append(batch, evt)
+	}
+
+	return ids, flush()
+}
+
+func isJSONObject(val json.RawMessage) bool {
+	for _, b := range val {
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b == '{'
+	}
+	return false
+}
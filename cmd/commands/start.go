@@ -9,7 +9,13 @@ import (
 	"github.com/inngest/inngest/cmd/commands/internal/localconfig"
 	"github.com/inngest/inngest/pkg/config"
 	"github.com/inngest/inngest/pkg/devserver"
+	"github.com/inngest/inngest/pkg/devserver/wal"
+	"github.com/inngest/inngest/pkg/environment"
+	"github.com/inngest/inngest/pkg/execution/driver/httpdriver"
+	"github.com/inngest/inngest/pkg/keyring"
 	"github.com/inngest/inngest/pkg/lite"
+	"github.com/inngest/inngest/pkg/logger"
+	"github.com/inngest/inngest/pkg/rbac"
 	itrace "github.com/inngest/inngest/pkg/telemetry/trace"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -38,14 +44,20 @@ func NewCmdStart(rootCmd *cobra.Command) *cobra.Command {
 	baseFlags.StringP("port", "p", "8288", "Inngest server port")
 	baseFlags.StringSliceP("sdk-url", "u", []string{}, "App serve URLs to sync (ex. http://localhost:3000/api/inngest)")
 	baseFlags.String("signing-key", "", "Signing key used to sign and validate data between the server and apps.")
+	baseFlags.StringSlice("signing-key-fallback", []string{}, "Previous signing key(s) still accepted when validating apps and workers, allowing the signing key to be rotated.")
 	baseFlags.StringSlice("event-key", []string{}, "Event key(s) that will be used by apps to send events to the server.")
+	baseFlags.String("realtime-secret", "", "Secret used to sign realtime subscription tokens.  Defaults to a secret derived from the signing key.")
+	baseFlags.String("connect-secret", "", "Secret used to sign connect worker session tokens.  Defaults to a secret derived from the signing key.")
 	cmd.Flags().AddFlagSet(baseFlags)
 	groups = append(groups, FlagGroup{name: "Flags:", fs: baseFlags})
 
 	persistenceFlags := pflag.NewFlagSet("persistence", pflag.ExitOnError)
 	persistenceFlags.String("sqlite-dir", "", "Directory for where to write SQLite database.")
 	persistenceFlags.String("redis-uri", "", "Redis server URI for external queue and run state. Defaults to self-contained, in-memory Redis server with periodic snapshot backups.")
+	persistenceFlags.String("redis-wal-fsync", string(wal.FsyncEverySec), "How often the in-memory Redis server's write-ahead log is synced to disk: always, everysec or no. Unused with --redis-uri.")
 	persistenceFlags.String("postgres-uri", "", "[Experimental] PostgreSQL database URI for configuration and history persistence. Defaults to SQLite database.")
+	persistenceFlags.String("encryption-keyfile", "", "Path to a JSON keyfile used to encrypt event and step data at rest. Defaults to no encryption.")
+	persistenceFlags.Bool("encryption-reencrypt", false, "Re-encrypt all stored history with the keyfile's primary key on startup, eg. after rotating keys.")
 	cmd.Flags().AddFlagSet(persistenceFlags)
 	groups = append(groups, FlagGroup{name: "Persistence Flags:", fs: persistenceFlags})
 
@@ -55,9 +67,17 @@ func NewCmdStart(rootCmd *cobra.Command) *cobra.Command {
 	advancedFlags.Int("queue-workers", devserver.DefaultQueueWorkers, "Number of executor workers to execute steps from the queue")
 	advancedFlags.Int("tick", devserver.DefaultTick, "The interval (in milliseconds) at which the executor polls the queue")
 	advancedFlags.Int("connect-gateway-port", devserver.DefaultConnectGatewayPort, "Port to expose connect gateway endpoint")
+	advancedFlags.Bool("enable-debug", false, "Expose pprof profiling endpoints at /debug.  These require the admin role when auth is configured.")
 	cmd.Flags().AddFlagSet(advancedFlags)
 	groups = append(groups, FlagGroup{name: "Advanced Flags:", fs: advancedFlags})
 
+	scalingFlags := pflag.NewFlagSet("scaling", pflag.ExitOnError)
+	scalingFlags.String("role", string(lite.RoleAll), "Services to run in this process: api, runner, executor, connect-gateway or all.  Split roles require --redis-uri and an external event stream.")
+	scalingFlags.Int("drain-period", 0, "Seconds to keep serving requests after a shutdown signal while /ready reports draining, allowing load balancers to remove this process")
+	scalingFlags.String("api-url", "", "URL of the API, used by the connect gateway when running with --role connect-gateway.  Defaults to this process's host and port.")
+	cmd.Flags().AddFlagSet(scalingFlags)
+	groups = append(groups, FlagGroup{name: "Scaling Flags:", fs: scalingFlags})
+
 	// Also add global flags
 	groups = append(groups, FlagGroup{name: "Global Flags:", fs: rootCmd.PersistentFlags()})
 
@@ -141,15 +161,92 @@ func doStart(cmd *cobra.Command, args []string) {
 		_ = itrace.CloseSystemTracer(ctx)
 	}()
 
+	// Configure connection pooling, TLS, and proxies for requests to apps,
+	// either for all apps via "http.transport" or for individual apps via
+	// "http.apps.<host>" within the config file.
+	if driver, ok := conf.Execution.Drivers["http"].(*httpdriver.Config); ok {
+		if err := localconfig.UnmarshalKey("http", driver); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	// Configure API tokens, users, and OIDC login for the UI and APIs via
+	// "auth" within the config file.
+	authConf := rbac.Config{}
+	if err := localconfig.UnmarshalKey("auth", &authConf); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	// Configure the event stream which connects the API to runners via
+	// "event_stream" within the config file.  This is required when running
+	// split roles, as the default in-memory event stream is local to a single
+	// process.
+	if err := localconfig.UnmarshalKey("event_stream", &conf.EventStream.Service); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	role := lite.Role(viper.GetString("role"))
+	if !role.Runs(lite.RoleExecutor) {
+		// Queue workers and the poll tick only apply to executors.
+		for _, name := range []string{"queue-workers", "tick"} {
+			if cmd.Flags().Changed(name) {
+				fmt.Printf("Ignoring --%s: role '%s' doesn't run executors\n", name, role)
+			}
+		}
+	}
+
 	tick := viper.GetInt("tick")
 	if tick < 1 {
 		tick = devserver.DefaultTick
 	}
 
+	// Keys can be rotated without a restart by updating the config file and
+	// sending SIGHUP.
+	kr := keyring.New(
+		viper.GetString("signing-key"),
+		viper.GetStringSlice("signing-key-fallback"),
+		viper.GetStringSlice("event-key"),
+	)
+
+	// Named environments, each with their own keys, are configured via
+	// "environments" within the config file and are reloaded with the keys.
+	envConf := []environment.Config{}
+	if err := localconfig.UnmarshalKey("environments", &envConf); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	envs, err := environment.New(kr, envConf)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	go localconfig.ReloadOnSignal(ctx, func() {
+		kr.Update(
+			ctx,
+			viper.GetString("signing-key"),
+			viper.GetStringSlice("signing-key-fallback"),
+			viper.GetStringSlice("event-key"),
+		)
+
+		envConf := []environment.Config{}
+		if err := localconfig.UnmarshalKey("environments", &envConf); err != nil {
+			logger.StdlibLogger(ctx).Error("error reloading environments", "error", err)
+			return
+		}
+		if err := envs.Update(ctx, envConf); err != nil {
+			logger.StdlibLogger(ctx).Error("error reloading environments", "error", err)
+		}
+	})
+
 	opts := lite.StartOpts{
 		Config:             *conf,
 		PollInterval:       viper.GetInt("poll-interval"),
 		RedisURI:           viper.GetString("redis-uri"),
+		RedisWALFsync:      viper.GetString("redis-wal-fsync"),
 		PostgresURI:        viper.GetString("postgres-uri"),
 		RetryInterval:      viper.GetInt("retry-interval"),
 		QueueWorkers:       viper.GetInt("queue-workers"),
@@ -157,8 +254,21 @@ func doStart(cmd *cobra.Command, args []string) {
 		URLs:               viper.GetStringSlice("sdk-url"),
 		SQLiteDir:          viper.GetString("sqlite-dir"),
 		SigningKey:         viper.GetString("signing-key"),
+		SigningKeyFallback: viper.GetStringSlice("signing-key-fallback"),
 		EventKey:           viper.GetStringSlice("event-key"),
+		RealtimeSecret:     viper.GetString("realtime-secret"),
+		ConnectSecret:      viper.GetString("connect-secret"),
 		ConnectGatewayPort: viper.GetInt("connect-gateway-port"),
+		Keyring:            kr,
+		Environments:       envs,
+		Auth:               authConf,
+		EnableDebug:        viper.GetBool("enable-debug"),
+		Role:               role,
+		DrainPeriod:        time.Duration(viper.GetInt("drain-period")) * time.Second,
+		APIURL:             viper.GetString("api-url"),
+
+		EncryptionKeyfile:   viper.GetString("encryption-keyfile"),
+		EncryptionReencrypt: viper.GetBool("encryption-reencrypt"),
 	}
 
 	err = lite.New(ctx, opts)
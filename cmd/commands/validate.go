@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/inngest/inngest/cmd/commands/internal/table"
+	"github.com/inngest/inngest/pkg/deploy"
+	"github.com/inngest/inngest/pkg/sdk"
+	"github.com/inngest/inngest/pkg/validate"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdValidate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate and lint an app's function configuration without a running server.",
+		Long: "Validate and lint an app's function configuration without a running server, exiting non-zero if the app " +
+			"would fail to sync, or if any lint rule fails with --strict.\n\n" +
+			"The configuration is fetched from a running app via an in-band sync using --url, or read from a file " +
+			"containing the app's registration using --file.  Apps which don't support in-band syncs are never asked " +
+			"to sync, as they would register with a server.",
+		Example: "inngest validate --url http://localhost:3000/api/inngest --hot-event 'api/*' --strict",
+		Args:    cobra.NoArgs,
+		Run:     doValidate,
+	}
+	cmd.Flags().StringP("url", "u", "", "URL of the app to validate (ex. http://localhost:3000/api/inngest)")
+	cmd.Flags().StringP("file", "f", "", "Path to a JSON file containing the app's registration, or - for stdin")
+	cmd.Flags().String("signing-key", os.Getenv("INNGEST_SIGNING_KEY"), "Signing key used to sign requests to the app, which SDKs require before listing whether they support in-band syncs.  Defaults to $INNGEST_SIGNING_KEY.")
+	cmd.Flags().StringSlice("hot-event", []string{}, "Events sent at a high volume, which functions should limit concurrency by key.  Supports patterns such as 'api/*'.")
+	cmd.Flags().Float64("max-cron-per-minute", validate.DefaultMaxCronPerMinute, "Maximum number of times a cron may fire per minute, averaged over its busiest hour.")
+	cmd.Flags().StringSlice("disable", []string{}, "IDs of lint rules to disable.")
+	cmd.Flags().Bool("strict", false, "Exit non-zero if any lint rule fails.")
+	cmd.Flags().String("format", "text", "Output format: text or json.")
+	cmd.MarkFlagsOneRequired("url", "file")
+	cmd.MarkFlagsMutuallyExclusive("url", "file")
+	return cmd
+}
+
+func doValidate(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+
+	format, _ := cmd.Flags().GetString("format")
+	if format != "text" && format != "json" {
+		fmt.Printf("Invalid format '%s': must be text or json\n", format)
+		os.Exit(1)
+	}
+
+	r, err := loadRegistration(cmd)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	opts := validate.Options{}
+	opts.HotEvents, _ = cmd.Flags().GetStringSlice("hot-event")
+	opts.MaxCronPerMinute, _ = cmd.Flags().GetFloat64("max-cron-per-minute")
+	opts.Disabled, _ = cmd.Flags().GetStringSlice("disable")
+	for _, id := range opts.Disabled {
+		if !validRule(id) {
+			fmt.Printf("Unknown lint rule '%s'\n", id)
+			os.Exit(1)
+		}
+	}
+
+	report := validate.Validate(ctx, *r, opts)
+
+	if format == "json" {
+		byt, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(byt))
+	} else {
+		printReport(report)
+	}
+
+	strict, _ := cmd.Flags().GetBool("strict")
+	if report.Failed(strict) {
+		os.Exit(1)
+	}
+}
+
+func loadRegistration(cmd *cobra.Command) (*sdk.RegisterRequest, error) {
+	if url, _ := cmd.Flags().GetString("url"); url != "" {
+		signingKey, _ := cmd.Flags().GetString("signing-key")
+		r, err := deploy.FetchRegistration(cmd.Context(), url, signingKey)
+		if err == deploy.ErrOutOfBandSync {
+			return nil, fmt.Errorf("%w: upgrade the SDK and allow in-band syncs, or save the app's registration and use --file", err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error fetching registration from %s: %w", url, err)
+		}
+		return r, nil
+	}
+
+	file, _ := cmd.Flags().GetString("file")
+	var (
+		byt []byte
+		err error
+	)
+	if file == "-" {
+		byt, err = io.ReadAll(os.Stdin)
+	} else {
+		byt, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r, err := sdk.ParseRegistration(byt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", file, err)
+	}
+	return &r, nil
+}
+
+func validRule(id string) bool {
+	for _, r := range validate.Rules {
+		if r.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func printReport(r validate.Report) {
+	if len(r.Findings) > 0 {
+		t := table.New(table.Row{"Function", "Severity", "Rule", "Message"})
+		for _, f := range r.Findings {
+			t.AppendRow(table.Row{stringOr(&f.Function, "-"), f.Severity, f.Rule, f.Message})
+		}
+		t.Render()
+	}
+	fmt.Printf("Validated %d functions in %s: %d errors, %d warnings\n", r.Functions, stringOr(&r.App, "app"), r.Errors, r.Warnings)
+}
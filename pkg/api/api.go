@@ -10,12 +10,16 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
+	"github.com/inngest/inngest/pkg/audit"
 	"github.com/inngest/inngest/pkg/config"
 	"github.com/inngest/inngest/pkg/consts"
 	"github.com/inngest/inngest/pkg/coreapi/apiutil"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/environment"
 	"github.com/inngest/inngest/pkg/event"
 	"github.com/inngest/inngest/pkg/eventstream"
 	"github.com/inngest/inngest/pkg/headers"
+	"github.com/inngest/inngest/pkg/keyring"
 	"github.com/inngest/inngest/pkg/publicerr"
 	itrace "github.com/inngest/inngest/pkg/telemetry/trace"
 	"github.com/rs/zerolog"
@@ -37,23 +41,44 @@ type Options struct {
 	// will be accepted.
 	LocalEventKeys []string
 
+	// Keyring, if set, supplies the accepted event keys, allowing keys to be
+	// rotated without a restart.  This takes precedence over LocalEventKeys.
+	Keyring *keyring.Keyring
+
+	// Environments, if set, routes each event to the environment which owns
+	// its event key.  Keys for every environment are accepted.
+	Environments *environment.Registry
+
 	// RequireKeys defines whether event and signing keys are required for the
 	// server to function. If this is true and signing keys are not defined,
 	// the server will still boot but core actions such as syncing, runs, and
 	// ingesting events will not work.
 	RequireKeys bool
+
+	// AuthMiddleware, if set, authenticates requests to invoke functions.
+	AuthMiddleware func(http.Handler) http.Handler
+
+	// AuditLog, if set, records functions invoked via the API.
+	AuditLog cqrs.AuditLogWriter
 }
 
 func NewAPI(o Options) (chi.Router, error) {
 	logger := o.Logger.With().Str("caller", "api").Logger()
 
+	keys := o.Keyring
+	if keys == nil {
+		keys = keyring.New("", nil, o.LocalEventKeys)
+	}
+
 	api := &API{
-		Router:         chi.NewMux(),
-		config:         o.Config,
-		handler:        o.EventHandler,
-		log:            &logger,
-		localEventKeys: o.LocalEventKeys,
-		requireKeys:    o.RequireKeys,
+		Router:      chi.NewMux(),
+		config:      o.Config,
+		handler:     o.EventHandler,
+		log:         &logger,
+		eventKeys:   keys,
+		envs:        o.Environments,
+		requireKeys: o.RequireKeys,
+		auditLog:    o.AuditLog,
 	}
 
 	cors := cors.New(cors.Options{
@@ -69,7 +94,17 @@ func NewAPI(o Options) (chi.Router, error) {
 
 	api.Get("/health", api.HealthCheck)
 	api.Post("/e/{key}", api.ReceiveEvent)
-	api.Post("/invoke/{slug}", api.Invoke)
+	api.Group(func(r chi.Router) {
+		if o.AuthMiddleware != nil {
+			r.Use(o.AuthMiddleware)
+		}
+		if o.Environments != nil {
+			// Invoke functions within the environment of the request's
+			// signing key or "X-Inngest-Env" header.
+			r.Use(o.Environments.Middleware)
+		}
+		r.Post("/invoke/{slug}", api.Invoke)
+	})
 
 	return api, nil
 }
@@ -84,16 +119,22 @@ type API struct {
 
 	server *http.Server
 
-	// localEventKeys are the keys used to send events to the local event API
-	// from an app. If this is set, only keys that match one of these values
-	// will be accepted.
-	localEventKeys []string
+	// eventKeys stores the keys used to send events to the local event API
+	// from an app. If any keys are set, only keys that match one of these
+	// values will be accepted.
+	eventKeys *keyring.Keyring
+
+	// envs, if set, stores the environments which events may be sent to.
+	envs *environment.Registry
 
 	// requireKeys defines whether event and signing keys are required for the
 	// server to function. If this is true and signing keys are not defined,
 	// the server will still boot but core actions such as syncing, runs, and
 	// ingesting events will not work.
 	requireKeys bool
+
+	// auditLog, if set, records functions invoked via the API.
+	auditLog cqrs.AuditLogWriter
 }
 
 func (a *API) AddRoutes() {
@@ -142,7 +183,7 @@ func (a API) ReceiveEvent(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	// If self hosting and keys are not defined, error.
-	if a.requireKeys && len(a.localEventKeys) == 0 {
+	if a.requireKeys && !a.eventKeys.HasEventKeys() {
 		a.log.Error().Msg("rejecting event; event keys are required to process events securely")
 		w.Header().Add("Content-Type", "application/json")
 		a.writeResponse(w, apiResponse{
@@ -164,16 +205,10 @@ func (a API) ReceiveEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(a.localEventKeys) > 0 {
-		var found bool
-		for _, k := range a.localEventKeys {
-			if k == key {
-				found = true
-				break
-			}
-		}
-
-		if !found {
+	if env := a.eventKeyEnvironment(key); env != nil {
+		ctx = environment.WithEnvironment(ctx, env)
+	} else if a.eventKeys.HasEventKeys() {
+		if !a.eventKeys.ValidEventKey(key) {
 			a.log.Error().Msg("rejecting event; event key not recognized")
 			w.Header().Add("Content-Type", "application/json")
 			a.writeResponse(w, apiResponse{
@@ -303,10 +338,19 @@ func (a API) ReceiveEvent(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// eventKeyEnvironment returns the environment owning the given event key, or
+// nil if environments aren't configured or no environment owns the key.
+func (a API) eventKeyEnvironment(key string) *environment.Environment {
+	if a.envs == nil {
+		return nil
+	}
+	return a.envs.ByEventKey(key)
+}
+
 // Invoke creates an event to invoke a specific function.
+//
+// Requests are authenticated via the API's auth middleware, if configured.
 func (a API) Invoke(w http.ResponseWriter, r *http.Request) {
-	// XXX: In OSS self hosting, check signing keys here.
-
 	// Get the function slug from the route parameter.   This is the function
 	// we'll invoke.  Any request is passed as the event data to the function.
 	slug := chi.URLParam(r, "slug")
@@ -343,6 +387,14 @@ func (a API) Invoke(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// TODO: If await is true as a query parameter, await the data from the function.
+	audit.Record(r.Context(), a.auditLog, cqrs.AuditEntry{
+		AccountID:   consts.DevServerAccountID,
+		WorkspaceID: environment.IDFromContext(r.Context()),
+		Source:      audit.SourceREST,
+		Action:      cqrs.AuditActionFunctionInvoke,
+		TargetIDs:   []string{slug, evtID},
+	}, rawEvt.Data)
+
 	_ = json.NewEncoder(w).Encode(apiutil.InvokeAPIResponse{
 		ID:     evtID,
 		Status: 200,
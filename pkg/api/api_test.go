@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/consts"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/environment"
+	"github.com/inngest/inngest/pkg/event"
+	"github.com/inngest/inngest/pkg/headers"
+	"github.com/inngest/inngest/pkg/keyring"
+	"github.com/inngest/inngest/pkg/rbac"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvokeAuth(t *testing.T) {
+	authn, err := rbac.New(rbac.Config{
+		Tokens: []rbac.TokenConfig{
+			{Name: "ci", Token: "viewer-token"},
+			{Name: "ops", Token: "operator-token", Role: rbac.RoleOperator},
+		},
+	})
+	require.NoError(t, err)
+
+	var invoked []string
+	audit := &auditLog{}
+	l := zerolog.Nop()
+	api, err := NewAPI(Options{
+		Logger: &l,
+		EventHandler: func(ctx context.Context, evt *event.Event, seed *event.SeededID) (string, error) {
+			invoked = append(invoked, evt.Name)
+			return "id", nil
+		},
+		AuthMiddleware: authn.Middleware,
+		AuditLog:       audit,
+	})
+	require.NoError(t, err)
+
+	invoke := func(token string) int {
+		r := httptest.NewRequest(http.MethodPost, "/invoke/app-fn", strings.NewReader(`{"data":{}}`))
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	require.Equal(t, 401, invoke(""))
+	require.Equal(t, 401, invoke("nope"))
+	require.Equal(t, 403, invoke("viewer-token"))
+	require.Empty(t, invoked)
+	require.Empty(t, audit.entries)
+
+	require.Equal(t, 200, invoke("operator-token"))
+	require.Equal(t, []string{event.InvokeFnName}, invoked)
+
+	// Successful invokes are recorded against the invoking principal.
+	require.Len(t, audit.entries, 1)
+	require.Equal(t, cqrs.AuditActionFunctionInvoke, audit.entries[0].Action)
+	require.Equal(t, "ops", audit.entries[0].Actor)
+	require.Equal(t, consts.DevServerEnvID, audit.entries[0].WorkspaceID)
+	require.Equal(t, []string{"app-fn", "id"}, audit.entries[0].TargetIDs)
+}
+
+type auditLog struct {
+	entries []*cqrs.AuditEntry
+}
+
+func (a *auditLog) InsertAuditEntry(ctx context.Context, e *cqrs.AuditEntry) error {
+	a.entries = append(a.entries, e)
+	return nil
+}
+
+func TestInvokeEnvironment(t *testing.T) {
+	const stagingKey = "signkey-test-bbbb"
+
+	envs, err := environment.New(keyring.New("signkey-prod-aaaa", nil, nil), []environment.Config{
+		{Name: "staging", SigningKey: stagingKey},
+	})
+	require.NoError(t, err)
+	hashed, err := keyring.HashSigningKey(stagingKey)
+	require.NoError(t, err)
+
+	var envID uuid.UUID
+	l := zerolog.Nop()
+	api, err := NewAPI(Options{
+		Logger: &l,
+		EventHandler: func(ctx context.Context, evt *event.Event, seed *event.SeededID) (string, error) {
+			envID = environment.IDFromContext(ctx)
+			return "id", nil
+		},
+		Environments: envs,
+	})
+	require.NoError(t, err)
+
+	invoke := func(header, value string) int {
+		envID = uuid.Nil
+		r := httptest.NewRequest(http.MethodPost, "/invoke/app-fn", strings.NewReader(`{"data":{}}`))
+		if header != "" {
+			r.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	require.Equal(t, 200, invoke("", ""))
+	require.Equal(t, consts.DevServerEnvID, envID)
+
+	require.Equal(t, 200, invoke(headers.HeaderKeyEnv, "staging"))
+	require.Equal(t, environment.ID("staging"), envID)
+
+	require.Equal(t, 200, invoke("Authorization", "Bearer "+hashed))
+	require.Equal(t, environment.ID("staging"), envID)
+
+	require.Equal(t, 400, invoke(headers.HeaderKeyEnv, "nope"))
+	require.Equal(t, uuid.Nil, envID)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/inngest/inngest/pkg/api/apiv1/apiv1auth"
 	"github.com/inngest/inngest/pkg/cqrs"
 	"github.com/inngest/inngest/pkg/execution"
+	"github.com/inngest/inngest/pkg/execution/batch"
 	"github.com/inngest/inngest/pkg/execution/queue"
 	"github.com/inngest/inngest/pkg/execution/realtime"
 	"github.com/inngest/inngest/pkg/execution/state/redis_state"
@@ -28,6 +29,8 @@ type Opts struct {
 	AuthFinder apiv1auth.AuthFinder
 	// Executor is required to cancel and manage function executions.
 	Executor execution.Executor
+	// BatchManager is used to inspect, flush, and discard pending event batches.
+	BatchManager batch.BatchManager
 	// EventReader allows reading of events from storage.
 	EventReader EventReader
 	// FunctionReader reads functions from a backing store.
@@ -38,6 +41,9 @@ type Opts struct {
 	JobQueueReader queue.JobQueueReader
 	// CancellationReadWriter reads and writes cancellations to/from a backing store.
 	CancellationReadWriter cqrs.CancellationReadWriter
+	// AuditLog records administrative actions, such as cancelling runs, and
+	// allows the audit log to be queried.
+	AuditLog cqrs.AuditLogReadWriter
 	// QueueShardSelector determines the queue shard to use
 	QueueShardSelector redis_state.ShardSelector
 	// Broadcaster is used to handle realtime via APIv1
@@ -111,10 +117,20 @@ func (a *router) setup() {
 
 			r.Get("/apps/{appName}/functions", a.GetAppFunctions) // Returns an app and all of its functions.
 
+			r.Get("/functions/{functionID}/batches", a.getBatches)
+			r.Post("/functions/{functionID}/batches/{batchID}/flush", a.flushBatch)
+			r.Delete("/functions/{functionID}/batches/{batchID}", a.discardBatch)
+
 			r.Post("/cancellations", a.createCancellation)
 			r.Get("/cancellations", a.getCancellations)
 			r.Delete("/cancellations/{id}", a.deleteCancellation)
 
+			r.Post("/signals/{signal}", a.sendSignal)
+
+			r.Get("/audit", a.getAuditEntries)
+
+			r.Post("/expressions/evaluate", a.evaluateExpression)
+
 			r.Get("/prom/{env}", a.promScrape)
 		})
 	})
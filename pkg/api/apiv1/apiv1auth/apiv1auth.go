@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/inngest/inngest/pkg/consts"
+	"github.com/inngest/inngest/pkg/environment"
 )
 
 // AuthFinder returns auth information from the current context.
@@ -16,17 +17,20 @@ type V1Auth interface {
 	WorkspaceID() uuid.UUID
 }
 
-// NilAuthFinder is used in the dev server, returning zero auth.
+// NilAuthFinder is used in the dev server, returning zero auth within the
+// request's environment.
 func NilAuthFinder(ctx context.Context) (V1Auth, error) {
-	return nilAuth{}, nil
+	return nilAuth{envID: environment.IDFromContext(ctx)}, nil
 }
 
-type nilAuth struct{}
+type nilAuth struct {
+	envID uuid.UUID
+}
 
 func (nilAuth) AccountID() uuid.UUID {
 	return consts.DevServerAccountID
 }
 
-func (nilAuth) WorkspaceID() uuid.UUID {
-	return consts.DevServerEnvID
+func (a nilAuth) WorkspaceID() uuid.UUID {
+	return a.envID
 }
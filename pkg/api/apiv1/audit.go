@@ -0,0 +1,161 @@
+package apiv1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/inngest/inngest/pkg/api/apiv1/apiv1auth"
+	"github.com/inngest/inngest/pkg/audit"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/dateutil"
+	"github.com/inngest/inngest/pkg/logger"
+	"github.com/inngest/inngest/pkg/publicerr"
+	"github.com/inngest/inngest/pkg/util"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	DefaultAuditEntries = 100
+	MaxAuditEntries     = 1_000
+
+	// auditExportPageSize is the number of entries read per query when
+	// exporting the audit log.
+	auditExportPageSize = 500
+)
+
+// GetAuditEntries returns audit log entries for the current workspace.
+func (a API) GetAuditEntries(ctx context.Context, opts cqrs.GetAuditEntriesOpt) ([]*cqrs.AuditEntry, error) {
+	auth, err := a.opts.AuthFinder(ctx)
+	if err != nil {
+		return nil, publicerr.Wrap(err, 401, "No auth found")
+	}
+	if a.opts.AuditLog == nil {
+		return nil, publicerr.Errorf(500, "The audit log is not available")
+	}
+
+	opts.WorkspaceID = auth.WorkspaceID()
+	entries, err := a.opts.AuditLog.GetAuditEntries(ctx, opts)
+	if err != nil {
+		logger.StdlibLogger(ctx).Error("error querying audit log", "error", err)
+		return nil, publicerr.Wrap(err, 500, "Unable to query audit log")
+	}
+	return entries, nil
+}
+
+// getAuditEntries returns audit log entries, newest first.  Entries are
+// exported as NDJSON, oldest first, if the "format=ndjson" query parameter or
+// an "application/x-ndjson" Accept header is given.
+func (a router) getAuditEntries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	opts := cqrs.GetAuditEntriesOpt{
+		Actor:    r.FormValue("actor"),
+		TargetID: r.FormValue("target"),
+	}
+	for _, action := range r.Form["action"] {
+		for _, a := range strings.Split(action, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				opts.Actions = append(opts.Actions, cqrs.AuditAction(a))
+			}
+		}
+	}
+
+	if from := r.FormValue("from"); from != "" {
+		parsed, err := dateutil.Parse(from)
+		if err != nil {
+			_ = publicerr.WriteHTTP(w, publicerr.Wrap(err, 400, "Invalid from query parameter"))
+			return
+		}
+		opts.From = parsed
+	}
+	if until := r.FormValue("until"); until != "" {
+		parsed, err := dateutil.Parse(until)
+		if err != nil {
+			_ = publicerr.WriteHTTP(w, publicerr.Wrap(err, 400, "Invalid until query parameter"))
+			return
+		}
+		opts.Until = parsed
+	}
+	if cursor := r.FormValue("cursor"); cursor != "" {
+		parsed, err := ulid.Parse(cursor)
+		if err != nil {
+			_ = publicerr.WriteHTTP(w, publicerr.Wrap(err, 400, "Invalid cursor query parameter"))
+			return
+		}
+		opts.Cursor = &parsed
+	}
+
+	if r.FormValue("format") == "ndjson" || strings.Contains(r.Header.Get("Accept"), "application/x-ndjson") {
+		a.exportAuditEntries(ctx, w, opts)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.FormValue("limit"))
+	if limit == 0 {
+		limit = DefaultAuditEntries
+	}
+	opts.Items = uint(util.Bound(limit, 1, MaxAuditEntries))
+
+	entries, err := a.API.GetAuditEntries(ctx, opts)
+	if err != nil {
+		_ = publicerr.WriteHTTP(w, err)
+		return
+	}
+	_ = WriteResponse(w, entries)
+}
+
+// exportAuditEntries streams all matching entries as NDJSON, oldest first.
+func (a router) exportAuditEntries(ctx context.Context, w http.ResponseWriter, opts cqrs.GetAuditEntriesOpt) {
+	opts.Ascending = true
+	opts.Items = auditExportPageSize
+
+	// Fetch the first page before writing headers, so that errors are
+	// reported with the correct status.
+	entries, err := a.API.GetAuditEntries(ctx, opts)
+	if err != nil {
+		_ = publicerr.WriteHTTP(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.ndjson"`)
+	enc := json.NewEncoder(w)
+	for len(entries) > 0 {
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				logger.StdlibLogger(ctx).Warn("error writing audit export", "error", err)
+				return
+			}
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		if len(entries) < auditExportPageSize {
+			return
+		}
+
+		opts.Cursor = &entries[len(entries)-1].ID
+		if entries, err = a.API.GetAuditEntries(ctx, opts); err != nil {
+			// Headers have been written, so the export is truncated.
+			logger.StdlibLogger(ctx).Error("error exporting audit log", "error", err)
+			return
+		}
+	}
+}
+
+// recordAudit records an administrative action performed via the API.
+func (a API) recordAudit(ctx context.Context, auth apiv1auth.V1Auth, action cqrs.AuditAction, targets []string, payload any) {
+	if a.opts.AuditLog == nil {
+		return
+	}
+	audit.Record(ctx, a.opts.AuditLog, cqrs.AuditEntry{
+		AccountID:   auth.AccountID(),
+		WorkspaceID: auth.WorkspaceID(),
+		Source:      audit.SourceREST,
+		Action:      action,
+		TargetIDs:   targets,
+	}, payload)
+}
@@ -0,0 +1,194 @@
+package apiv1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/execution/batch"
+	"github.com/inngest/inngest/pkg/inngest"
+	"github.com/inngest/inngest/pkg/logger"
+	"github.com/inngest/inngest/pkg/publicerr"
+	"github.com/oklog/ulid/v2"
+)
+
+// GetBatches lists the open batches for a function, allowing pending events
+// to be inspected before the batch runs.
+func (a API) GetBatches(ctx context.Context, fnID uuid.UUID) ([]batch.BatchInfo, error) {
+	fn, _, err := a.batchFunction(ctx, fnID)
+	if err != nil {
+		return nil, err
+	}
+
+	batches, err := a.opts.BatchManager.ListBatches(ctx, *fn)
+	if err != nil {
+		return nil, publicerr.Wrap(err, 500, "Error listing batches")
+	}
+	return batches, nil
+}
+
+// FlushBatch schedules a function run for an open batch immediately, without
+// waiting for the batch to fill up or time out.
+func (a API) FlushBatch(ctx context.Context, fnID uuid.UUID, batchID ulid.ULID) error {
+	fn, appID, err := a.batchFunction(ctx, fnID)
+	if err != nil {
+		return err
+	}
+	if a.opts.Executor == nil {
+		return publicerr.Errorf(500, "Batches cannot be flushed")
+	}
+
+	auth, err := a.opts.AuthFinder(ctx)
+	if err != nil {
+		return publicerr.Wrap(err, 401, "No auth found")
+	}
+
+	info, err := a.startBatch(ctx, *fn, batchID)
+	if err != nil {
+		return err
+	}
+
+	err = a.opts.Executor.RetrieveAndScheduleBatch(ctx, *fn, batch.ScheduleBatchPayload{
+		BatchID:         batchID,
+		BatchPointer:    info.Pointer,
+		AccountID:       auth.AccountID(),
+		WorkspaceID:     auth.WorkspaceID(),
+		AppID:           appID,
+		FunctionID:      fn.ID,
+		FunctionVersion: fn.FunctionVersion,
+	}, nil)
+	if err != nil {
+		// Revert the batch so that it isn't orphaned, allowing it to be
+		// flushed again or to run via its scheduled job.
+		if rerr := a.opts.BatchManager.RevertExecution(ctx, fn.ID, batchID); rerr != nil {
+			logger.StdlibLogger(ctx).Error("error reverting batch", "error", rerr, "batch_id", batchID)
+		}
+		return publicerr.Wrap(err, 500, "Error flushing batch")
+	}
+	a.recordAudit(ctx, auth, cqrs.AuditActionBatchFlush, []string{batchID.String(), fn.ID.String()}, nil)
+	return nil
+}
+
+// DiscardBatch deletes an open batch without running the function.
+func (a API) DiscardBatch(ctx context.Context, fnID uuid.UUID, batchID ulid.ULID) error {
+	fn, _, err := a.batchFunction(ctx, fnID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.startBatch(ctx, *fn, batchID); err != nil {
+		return err
+	}
+
+	if err := a.opts.BatchManager.DeleteKeys(ctx, fn.ID, batchID); err != nil {
+		return publicerr.Wrap(err, 500, "Error discarding batch")
+	}
+	if auth, err := a.opts.AuthFinder(ctx); err == nil {
+		a.recordAudit(ctx, auth, cqrs.AuditActionBatchDelete, []string{batchID.String(), fn.ID.String()}, nil)
+	}
+	return nil
+}
+
+// batchFunction returns the function with the given internal ID and its app ID.
+func (a API) batchFunction(ctx context.Context, fnID uuid.UUID) (*inngest.Function, uuid.UUID, error) {
+	if a.opts.BatchManager == nil || a.opts.FunctionReader == nil {
+		return nil, uuid.Nil, publicerr.Errorf(500, "Batches are not available")
+	}
+
+	auth, err := a.opts.AuthFinder(ctx)
+	if err != nil {
+		return nil, uuid.Nil, publicerr.Wrap(err, 401, "No auth found")
+	}
+
+	f, err := a.opts.FunctionReader.GetFunctionByInternalUUID(ctx, auth.WorkspaceID(), fnID)
+	if err != nil || f == nil {
+		return nil, uuid.Nil, publicerr.Wrap(err, 404, "Function not found")
+	}
+	fn, err := f.InngestFunction()
+	if err != nil {
+		return nil, uuid.Nil, publicerr.Wrap(err, 500, "Error loading function")
+	}
+	fn.ID = f.ID
+	return fn, f.AppID, nil
+}
+
+// startBatch marks an open batch as started, ensuring that the batch no longer
+// accepts events and that the scheduled batch job no longer runs the batch.
+func (a API) startBatch(ctx context.Context, fn inngest.Function, batchID ulid.ULID) (*batch.BatchInfo, error) {
+	info, err := a.opts.BatchManager.GetBatch(ctx, fn, batchID)
+	if errors.Is(err, batch.ErrBatchNotFound) {
+		return nil, publicerr.Wrap(err, 404, "Batch not found")
+	}
+	if err != nil {
+		return nil, publicerr.Wrap(err, 500, "Error loading batch")
+	}
+
+	status, err := a.opts.BatchManager.StartExecution(ctx, fn.ID, batchID, info.Pointer)
+	if err != nil {
+		return nil, publicerr.Wrap(err, 500, "Error starting batch")
+	}
+	switch status {
+	case enums.BatchStatusStarted.String():
+		return nil, publicerr.Errorf(404, "Batch not found")
+	case enums.BatchStatusAbsent.String():
+		_ = a.opts.BatchManager.DeleteKeys(ctx, fn.ID, batchID)
+		return nil, publicerr.Errorf(404, "Batch not found")
+	}
+	return info, nil
+}
+
+func (a router) getBatches(w http.ResponseWriter, r *http.Request) {
+	fnID, err := uuid.Parse(chi.URLParam(r, "functionID"))
+	if err != nil {
+		_ = publicerr.WriteHTTP(w, publicerr.Wrap(err, 400, "Invalid function ID"))
+		return
+	}
+	batches, err := a.API.GetBatches(r.Context(), fnID)
+	if err != nil {
+		_ = publicerr.WriteHTTP(w, err)
+		return
+	}
+	_ = WriteResponse(w, batches)
+}
+
+func (a router) flushBatch(w http.ResponseWriter, r *http.Request) {
+	fnID, batchID, err := batchParams(r)
+	if err != nil {
+		_ = publicerr.WriteHTTP(w, err)
+		return
+	}
+	if err := a.API.FlushBatch(r.Context(), fnID, batchID); err != nil {
+		_ = publicerr.WriteHTTP(w, err)
+		return
+	}
+	_ = WriteResponse(w, map[string]any{"ok": true})
+}
+
+func (a router) discardBatch(w http.ResponseWriter, r *http.Request) {
+	fnID, batchID, err := batchParams(r)
+	if err != nil {
+		_ = publicerr.WriteHTTP(w, err)
+		return
+	}
+	if err := a.API.DiscardBatch(r.Context(), fnID, batchID); err != nil {
+		_ = publicerr.WriteHTTP(w, err)
+		return
+	}
+	_ = WriteResponse(w, map[string]any{"ok": true})
+}
+
+func batchParams(r *http.Request) (uuid.UUID, ulid.ULID, error) {
+	fnID, err := uuid.Parse(chi.URLParam(r, "functionID"))
+	if err != nil {
+		return uuid.Nil, ulid.ULID{}, publicerr.Wrap(err, 400, "Invalid function ID")
+	}
+	batchID, err := ulid.Parse(chi.URLParam(r, "batchID"))
+	if err != nil {
+		return uuid.Nil, ulid.ULID{}, publicerr.Wrap(err, 400, "Invalid batch ID")
+	}
+	return fnID, batchID, nil
+}
@@ -36,6 +36,7 @@ func (a API) DeleteCancellation(ctx context.Context, cancellationID ulid.ULID) e
 			if err != nil {
 				return publicerr.Wrap(err, 500, "Error deleting cancellation")
 			}
+			a.recordAudit(ctx, auth, cqrs.AuditActionCancellationDelete, []string{c.ID.String(), c.FunctionID.String()}, nil)
 			if err == nil {
 				return nil
 			}
@@ -145,6 +146,7 @@ func (a API) CreateCancellation(ctx context.Context, opts CreateCancellationBody
 		}
 		return nil, publicerr.Wrap(err, 500, "Error creating cancellation")
 	}
+	a.recordAudit(ctx, auth, cqrs.AuditActionCancellationCreate, []string{cancel.ID.String(), fn.ID.String()}, opts)
 	return &cancel, nil
 }
 
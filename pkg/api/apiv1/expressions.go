@@ -0,0 +1,88 @@
+package apiv1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/inngest/inngest/pkg/expressions"
+	"github.com/inngest/inngest/pkg/publicerr"
+	"github.com/oklog/ulid/v2"
+)
+
+type EvaluateExpressionBody struct {
+	// Expression is the expression to evaluate, eg. a function's `if` or
+	// concurrency `key` expression.
+	Expression string `json:"expression"`
+	// Event is a sample event to evaluate the expression against.
+	Event map[string]any `json:"event,omitempty"`
+	// EventID is the internal ID of a stored event to evaluate the expression
+	// against, used if Event is not provided.
+	EventID *ulid.ULID `json:"event_id,omitempty"`
+	// Async is an optional event available as `async` within the expression,
+	// eg. when testing a waitForEvent or cancellation `if` expression.
+	Async map[string]any `json:"async,omitempty"`
+}
+
+func (e EvaluateExpressionBody) Validate() error {
+	var err error
+	if e.Expression == "" {
+		err = errors.Join(err, errors.New("expression is required"))
+	}
+	if e.Event != nil && e.EventID != nil {
+		err = errors.Join(err, errors.New("only one of event or event_id may be provided"))
+	}
+	return err
+}
+
+// EvaluateExpression evaluates an expression against a sample or stored event,
+// allowing expressions to be debugged before deploying.
+func (a API) EvaluateExpression(ctx context.Context, opts EvaluateExpressionBody) (*expressions.Explanation, error) {
+	data := map[string]any{}
+	if opts.Event != nil {
+		data["event"] = opts.Event
+	}
+	if opts.EventID != nil {
+		evt, err := a.GetEvent(ctx, *opts.EventID)
+		if err != nil {
+			return nil, err
+		}
+		data["event"] = evt.GetEvent().Map()
+	}
+	if opts.Async != nil {
+		data["async"] = opts.Async
+	}
+
+	ex, err := expressions.Explain(ctx, opts.Expression, data)
+	if err != nil {
+		var compileError *expressions.CompileError
+		if errors.As(err, &compileError) {
+			return nil, publicerr.Wrap(err, 400, fmt.Sprintf("invalid expression: %s", compileError.Message()))
+		}
+		return nil, publicerr.Wrap(err, 400, err.Error())
+	}
+	return ex, nil
+}
+
+func (a router) evaluateExpression(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	opts := EvaluateExpressionBody{}
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		_ = publicerr.WriteHTTP(w, publicerr.Wrap(err, 400, "Invalid expression request"))
+		return
+	}
+	if err := opts.Validate(); err != nil {
+		_ = publicerr.WriteHTTP(w, publicerr.Wrap(err, 400, err.Error()))
+		return
+	}
+
+	ex, err := a.API.EvaluateExpression(ctx, opts)
+	if err != nil {
+		_ = publicerr.WriteHTTP(w, err)
+		return
+	}
+	_ = WriteResponse(w, ex)
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/inngest/inngest/pkg/cqrs"
 	"github.com/inngest/inngest/pkg/execution"
 	"github.com/inngest/inngest/pkg/execution/queue"
 	"github.com/inngest/inngest/pkg/execution/state/v2"
@@ -70,6 +71,7 @@ func (a API) CancelFunctionRun(ctx context.Context, runID ulid.ULID) error {
 	if err := a.opts.Executor.Cancel(ctx, id, execution.CancelRequest{}); err != nil {
 		return publicerr.Wrapf(err, 500, "Unable to cancel function run: %s", err)
 	}
+	a.recordAudit(ctx, auth, cqrs.AuditActionRunCancel, []string{runID.String()}, nil)
 	return nil
 }
 
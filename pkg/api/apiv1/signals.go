@@ -0,0 +1,74 @@
+package apiv1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/execution/state"
+	"github.com/inngest/inngest/pkg/publicerr"
+	"github.com/oklog/ulid/v2"
+)
+
+// SignalResponse is returned after resuming a run waiting for a signal.
+type SignalResponse struct {
+	// RunID is the ID of the run that was resumed.
+	RunID ulid.ULID `json:"run_id"`
+}
+
+// SendSignal resumes the run waiting for the given signal, passing data as the
+// result of the run's wait for signal step.
+func (a API) SendSignal(ctx context.Context, signal string, data any) (*SignalResponse, error) {
+	if a.opts.Executor == nil {
+		return nil, publicerr.Errorf(500, "Signals are not available")
+	}
+
+	auth, err := a.opts.AuthFinder(ctx)
+	if err != nil {
+		return nil, publicerr.Wrap(err, 401, "No auth found")
+	}
+
+	runID, err := a.opts.Executor.ResumeSignal(ctx, auth.WorkspaceID(), signal, data)
+	if errors.Is(err, state.ErrSignalPauseNotFound) {
+		return nil, publicerr.Wrap(err, 404, fmt.Sprintf("No run is waiting for signal %q", signal))
+	}
+	if err != nil {
+		return nil, publicerr.Wrap(err, 500, "Error sending signal")
+	}
+	a.recordAudit(ctx, auth, cqrs.AuditActionSignalSend, []string{signal, runID.String()}, data)
+	return &SignalResponse{RunID: *runID}, nil
+}
+
+func (a router) sendSignal(w http.ResponseWriter, r *http.Request) {
+	signal := chi.URLParam(r, "signal")
+	if signal == "" {
+		_ = publicerr.WriteHTTP(w, publicerr.Errorf(400, "A signal name is required"))
+		return
+	}
+
+	byt, err := io.ReadAll(r.Body)
+	if err != nil {
+		_ = publicerr.WriteHTTP(w, publicerr.Wrap(err, 400, "Error reading signal payload"))
+		return
+	}
+
+	var data any
+	if len(byt) > 0 {
+		if err := json.Unmarshal(byt, &data); err != nil {
+			_ = publicerr.WriteHTTP(w, publicerr.Wrap(err, 400, "Invalid signal payload"))
+			return
+		}
+	}
+
+	resp, err := a.API.SendSignal(r.Context(), signal, data)
+	if err != nil {
+		_ = publicerr.WriteHTTP(w, err)
+		return
+	}
+	_ = WriteResponse(w, resp)
+}
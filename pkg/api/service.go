@@ -10,7 +10,10 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/inngest/inngest/pkg/config"
 	"github.com/inngest/inngest/pkg/consts"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/environment"
 	"github.com/inngest/inngest/pkg/event"
+	"github.com/inngest/inngest/pkg/keyring"
 	"github.com/inngest/inngest/pkg/logger"
 	"github.com/inngest/inngest/pkg/pubsub"
 	"github.com/inngest/inngest/pkg/service"
@@ -40,11 +43,25 @@ type APIServiceOptions struct {
 	// will be accepted.
 	LocalEventKeys []string
 
+	// Keyring, if set, supplies the accepted event keys, allowing keys to be
+	// rotated without a restart.  This takes precedence over LocalEventKeys.
+	Keyring *keyring.Keyring
+
+	// Environments, if set, routes each event to the environment which owns
+	// its event key.
+	Environments *environment.Registry
+
 	// requireKeys defines whether event and signing keys are required for the
 	// server to function. If this is true and signing keys are not defined,
 	// the server will still boot but core actions such as syncing, runs, and
 	// ingesting events will not work.
 	RequireKeys bool
+
+	// AuthMiddleware, if set, authenticates requests to invoke functions.
+	AuthMiddleware func(http.Handler) http.Handler
+
+	// AuditLog, if set, records functions invoked via the API.
+	AuditLog cqrs.AuditLogWriter
 }
 
 func NewService(opts APIServiceOptions) service.Service {
@@ -52,7 +69,11 @@ func NewService(opts APIServiceOptions) service.Service {
 		config:         opts.Config,
 		mounts:         opts.Mounts,
 		localEventKeys: opts.LocalEventKeys,
+		keyring:        opts.Keyring,
+		envs:           opts.Environments,
 		requireKeys:    opts.RequireKeys,
+		authMiddleware: opts.AuthMiddleware,
+		auditLog:       opts.AuditLog,
 	}
 }
 
@@ -68,11 +89,23 @@ type apiServer struct {
 	// will be accepted.
 	localEventKeys []string
 
+	// keyring, if set, supplies the accepted event keys.
+	keyring *keyring.Keyring
+
+	// envs, if set, stores the environments which events may be sent to.
+	envs *environment.Registry
+
 	// requireKeys defines whether event and signing keys are required for the
 	// server to function. If this is true and signing keys are not defined,
 	// the server will still boot but core actions such as syncing, runs, and
 	// ingesting events will not work.
 	requireKeys bool
+
+	// authMiddleware, if set, authenticates requests to invoke functions.
+	authMiddleware func(http.Handler) http.Handler
+
+	// auditLog, if set, records functions invoked via the API.
+	auditLog cqrs.AuditLogWriter
 }
 
 func (a *apiServer) Name() string {
@@ -87,7 +120,11 @@ func (a *apiServer) Pre(ctx context.Context) error {
 		Logger:         logger.From(ctx),
 		EventHandler:   a.handleEvent,
 		LocalEventKeys: a.localEventKeys,
+		Keyring:        a.keyring,
+		Environments:   a.envs,
 		RequireKeys:    a.requireKeys,
+		AuthMiddleware: a.authMiddleware,
+		AuditLog:       a.auditLog,
 	})
 	if err != nil {
 		return err
@@ -131,7 +168,8 @@ func (a *apiServer) handleEvent(
 
 	l.Debug().Str("event", e.Name).Msg("handling event")
 
-	trackedEvent := event.NewOSSTrackedEvent(
+	trackedEvent := event.NewOSSTrackedEventInWorkspace(
+		environment.IDFromContext(ctx),
 		*e,
 		seed,
 	)
@@ -0,0 +1,83 @@
+// Package audit records administrative actions, such as cancelling runs or
+// deleting apps, to the append-only audit log.
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/logger"
+	"github.com/inngest/inngest/pkg/rbac"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	// SourceREST is used for actions performed via the REST API.
+	SourceREST = "rest"
+	// SourceGraphQL is used for actions performed via GraphQL, eg. the UI.
+	SourceGraphQL = "graphql"
+	// SourceDevServer is used for actions performed via the dev server API.
+	SourceDevServer = "devserver"
+
+	// ActorAnonymous is recorded as the actor when auth is disabled.
+	ActorAnonymous = "anonymous"
+)
+
+// Record appends the given entry to the audit log.  The entry's ID, creation
+// time and actor are set automatically, with the actor taken from the
+// principal authenticated for the current request.  The payload, if non-nil,
+// is hashed and the hash stored in place of the payload.
+//
+// Record is called after the action succeeds.  Failing to write the entry
+// doesn't undo the action, so errors are logged rather than returned.
+func Record(ctx context.Context, w cqrs.AuditLogWriter, e cqrs.AuditEntry, payload any) {
+	if w == nil {
+		return
+	}
+
+	now := time.Now()
+	e.ID = ulid.MustNew(ulid.Timestamp(now), rand.Reader)
+	e.CreatedAt = now
+	e.Actor, e.ActorMethod = ActorAnonymous, "none"
+	if p := rbac.PrincipalFrom(ctx); p != nil {
+		e.Actor, e.ActorMethod = p.Name, p.Method
+	}
+	e.PayloadHash = HashPayload(payload)
+
+	if err := w.InsertAuditEntry(ctx, &e); err != nil {
+		logger.StdlibLogger(ctx).Error(
+			"error writing audit log entry",
+			"error", err,
+			"action", e.Action,
+			"actor", e.Actor,
+			"targets", e.TargetIDs,
+		)
+	}
+}
+
+// HashPayload returns the hex-encoded SHA-256 hash of the given payload.  Byte
+// slices are hashed as-is and all other values are JSON encoded.  An empty
+// string is returned for nil payloads.
+func HashPayload(payload any) string {
+	var byt []byte
+	switch v := payload.(type) {
+	case nil:
+		return ""
+	case []byte:
+		byt = v
+	case json.RawMessage:
+		byt = v
+	default:
+		var err error
+		if byt, err = json.Marshal(v); err != nil {
+			return ""
+		}
+	}
+	sum := sha256.Sum256(byt)
+	return hex.EncodeToString(sum[:])
+}
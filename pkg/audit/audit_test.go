@@ -0,0 +1,37 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/rbac"
+	"github.com/stretchr/testify/require"
+)
+
+type writer struct {
+	entries []*cqrs.AuditEntry
+}
+
+func (w *writer) InsertAuditEntry(ctx context.Context, e *cqrs.AuditEntry) error {
+	w.entries = append(w.entries, e)
+	return nil
+}
+
+func TestRecord(t *testing.T) {
+	w := &writer{}
+
+	Record(context.Background(), w, cqrs.AuditEntry{Action: cqrs.AuditActionRunCancel}, nil)
+	require.Len(t, w.entries, 1)
+	require.Equal(t, ActorAnonymous, w.entries[0].Actor)
+	require.Empty(t, w.entries[0].PayloadHash)
+	require.False(t, w.entries[0].CreatedAt.IsZero())
+
+	ctx := rbac.WithPrincipal(context.Background(), &rbac.Principal{Name: "ci", Role: rbac.RoleOperator, Method: "token"})
+	Record(ctx, w, cqrs.AuditEntry{Action: cqrs.AuditActionFunctionInvoke}, map[string]any{"foo": "bar"})
+	require.Len(t, w.entries, 2)
+	require.Equal(t, "ci", w.entries[1].Actor)
+	require.Equal(t, "token", w.entries[1].ActorMethod)
+	require.Equal(t, HashPayload([]byte(`{"foo":"bar"}`)), w.entries[1].PayloadHash)
+	require.NotEqual(t, w.entries[0].ID, w.entries[1].ID)
+}
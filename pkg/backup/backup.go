@@ -0,0 +1,571 @@
+// Package backup exports and imports the persisted state of a self-hosted
+// instance, including apps, functions, events, run history, traces and the
+// queue.
+//
+// Backups are zstd-compressed tar archives.  The archive begins with a
+// manifest listing each entry along with its row count and checksum, followed
+// by one JSON lines file per table and a JSON snapshot of Redis.  Rows are
+// encoded independently of the database driver, so that a backup taken from
+// SQLite may be imported into Postgres and vice versa.
+//
+// Data encrypted at rest is copied as-is, so an instance importing a backup
+// must use the same encryption keys as the instance which exported it.
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/cqrs/base_cqrs"
+	"github.com/inngest/inngest/pkg/devserver"
+	"github.com/inngest/inngest/pkg/devserver/wal"
+	"github.com/klauspost/compress/zstd"
+	"github.com/redis/rueidis"
+)
+
+const (
+	// Version is the version of the archive format written by Export.
+	Version = 1
+
+	manifestName = "manifest.json"
+	redisName    = "redis.json"
+	tableExt     = ".jsonl"
+
+	// RedisEmbedded indicates that the instance uses the in-memory Redis,
+	// persisted via snapshots and a write-ahead log.
+	RedisEmbedded = "embedded"
+	// RedisExternal indicates that the instance uses an external Redis.
+	RedisExternal = "external"
+)
+
+// Manifest describes the contents of a backup.
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// Driver is the database driver of the exported instance.
+	Driver string `json:"driver"`
+	// Redis is either RedisEmbedded or RedisExternal.
+	Redis   string  `json:"redis"`
+	Entries []Entry `json:"entries"`
+}
+
+// Entry describes a single file within a backup.
+type Entry struct {
+	Name string `json:"name"`
+	// Rows is the number of rows within a table, or the number of keys within
+	// the Redis snapshot.
+	Rows   int    `json:"rows"`
+	SHA256 string `json:"sha256"`
+}
+
+// Instance is the persistence layer of a self-hosted instance.
+type Instance struct {
+	DB *sql.DB
+	// Driver is the database driver, either "sqlite" or "postgres".
+	Driver string
+	// Redis is the external Redis used by the instance, or nil if the instance
+	// uses the in-memory Redis.
+	Redis rueidis.Client
+	// WALDir is the directory containing the in-memory Redis' write-ahead
+	// log.  This is ignored when using an external Redis.
+	WALDir string
+}
+
+func (i Instance) redisKind() string {
+	if i.Redis != nil {
+		return RedisExternal
+	}
+	return RedisEmbedded
+}
+
+// entryNames returns the name of every entry within a backup, in order.
+func entryNames() []string {
+	names := []string{}
+	for _, table := range base_cqrs.BackupTables() {
+		names = append(names, table+tableExt)
+	}
+	return append(names, redisName)
+}
+
+// Export writes a backup of the given instance to w.
+//
+// Queue state held within the in-memory Redis is read from its latest snapshot
+// and write-ahead log, so the instance should be stopped beforehand to ensure
+// that the backup is consistent.
+func Export(ctx context.Context, src Instance, w io.Writer) (*Manifest, error) {
+	dir, err := os.MkdirTemp("", "inngest-export")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	m := &Manifest{
+		Version:   Version,
+		CreatedAt: time.Now().UTC(),
+		Driver:    src.Driver,
+		Redis:     src.redisKind(),
+	}
+
+	// Write each entry to a temporary file first, so that the manifest
+	// containing each entry's checksum can be written at the start of the
+	// archive.
+	for _, table := range base_cqrs.BackupTables() {
+		entry, err := writeEntry(dir, table+tableExt, func(w io.Writer) (int, error) {
+			n := 0
+			err := base_cqrs.ExportTable(ctx, src.DB, src.Driver, table, func(row []byte) error {
+				n++
+				_, err := w.Write(append(row, '\n'))
+				return err
+			})
+			return n, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		m.Entries = append(m.Entries, entry)
+	}
+
+	snapshot, err := dumpRedis(ctx, src)
+	if err != nil {
+		return nil, fmt.Errorf("error exporting Redis: %w", err)
+	}
+	entry, err := writeEntry(dir, redisName, func(w io.Writer) (int, error) {
+		return len(snapshot), json.NewEncoder(w).Encode(snapshot)
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.Entries = append(m.Entries, entry)
+
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return nil, err
+	}
+	tw := tar.NewWriter(zw)
+
+	byt, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeTarFile(tw, manifestName, m.CreatedAt, int64(len(byt)), strings.NewReader(string(byt))); err != nil {
+		return nil, err
+	}
+	for _, e := range m.Entries {
+		if err := copyToTar(tw, filepath.Join(dir, e.Name), e.Name, m.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// writeEntry writes an entry to a file within dir, returning its row count and
+// checksum.
+func writeEntry(dir, name string, write func(w io.Writer) (int, error)) (Entry, error) {
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return Entry{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	bw := bufio.NewWriter(io.MultiWriter(f, h))
+	n, err := write(bw)
+	if err != nil {
+		return Entry{}, fmt.Errorf("error exporting %s: %w", name, err)
+	}
+	if err := bw.Flush(); err != nil {
+		return Entry{}, err
+	}
+	return Entry{Name: name, Rows: n, SHA256: hex.EncodeToString(h.Sum(nil))}, f.Close()
+}
+
+func copyToTar(tw *tar.Writer, path, name string, modTime time.Time) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return writeTarFile(tw, name, modTime, info.Size(), f)
+}
+
+func writeTarFile(tw *tar.Writer, name string, modTime time.Time, size int64, r io.Reader) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o600,
+		Size:    size,
+		ModTime: modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, r)
+	return err
+}
+
+// dumpRedis returns a snapshot of the instance's Redis.
+func dumpRedis(ctx context.Context, src Instance) (cqrs.QueueSnapshot, error) {
+	if src.Redis != nil {
+		return devserver.DumpRedis(ctx, src.Redis)
+	}
+
+	// Rebuild the in-memory Redis as it would be on start: restore the
+	// snapshot referenced by the write-ahead log, then replay the log.
+	m := miniredis.NewMiniRedis()
+	if err := m.Start(); err != nil {
+		return nil, fmt.Errorf("error starting in-memory redis: %w", err)
+	}
+	defer m.Close()
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:  []string{m.Addr()},
+		DisableCache: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	tmp, err := os.MkdirTemp("", "inngest-wal")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	log, err := openWALCopy(m, src.WALDir, tmp)
+	if err != nil {
+		return nil, err
+	}
+	if log != nil {
+		defer log.Close()
+	}
+
+	var (
+		data     = base_cqrs.NewCQRS(src.DB, src.Driver)
+		snapshot *cqrs.QueueSnapshot
+	)
+	if id, ok, err := walSnapshotID(log); err != nil {
+		return nil, err
+	} else if ok {
+		snapshot, err = data.GetQueueSnapshot(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("error loading snapshot %s referenced by the write-ahead log: %w", id, err)
+		}
+	} else {
+		snapshot, err = data.GetLatestQueueSnapshot(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error loading latest queue snapshot: %w", err)
+		}
+	}
+	if snapshot != nil {
+		if err := devserver.RestoreRedis(ctx, rc, *snapshot); err != nil {
+			return nil, err
+		}
+	}
+
+	if log != nil {
+		if _, err := log.Replay(ctx); err != nil {
+			return nil, fmt.Errorf("error replaying write-ahead log: %w", err)
+		}
+	}
+	return devserver.DumpRedis(ctx, rc)
+}
+
+// openWALCopy copies the write-ahead log within dir to tmp and opens the copy,
+// as replaying the log may truncate incomplete records which are still being
+// written.  This returns nil if no log exists.
+func openWALCopy(m *miniredis.Miniredis, dir, tmp string) (*wal.Log, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	segments, err := walSegments(dir)
+	if err != nil || len(segments) == 0 {
+		return nil, err
+	}
+
+	for _, name := range segments {
+		byt, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("error reading write-ahead log: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(tmp, name), byt, 0o600); err != nil {
+			return nil, err
+		}
+	}
+	return wal.Open(m, wal.Options{Dir: tmp})
+}
+
+func walSnapshotID(log *wal.Log) (cqrs.SnapshotID, bool, error) {
+	if log == nil {
+		return cqrs.SnapshotID{}, false, nil
+	}
+	return log.SnapshotID()
+}
+
+// walSegments returns the name of each write-ahead log segment within dir.
+func walSegments(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading write-ahead log directory: %w", err)
+	}
+	names := []string{}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".wal") {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+// Import restores a backup read from r into the given instance, which must be
+// empty.
+//
+// Every row is inserted within a single transaction, which is only committed
+// once each entry's checksum and row count match the manifest.  Queue state is
+// stored as a snapshot which the in-memory Redis restores on start, or written
+// to the external Redis prior to committing.
+func Import(ctx context.Context, dst Instance, r io.Reader) (*Manifest, error) {
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+
+	m, err := readManifest(tr)
+	if err != nil {
+		return nil, err
+	}
+
+	restore, err := base_cqrs.NewRestore(ctx, dst.DB, dst.Driver)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = restore.Rollback() }()
+
+	if err := checkEmpty(ctx, dst, restore); err != nil {
+		return nil, err
+	}
+
+	var snapshot cqrs.QueueSnapshot
+	for _, e := range m.Entries {
+		hdr, err := tr.Next()
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", e.Name, err)
+		}
+		if hdr.Name != e.Name {
+			return nil, fmt.Errorf("unexpected entry %s: expected %s", hdr.Name, e.Name)
+		}
+
+		h := sha256.New()
+		body := io.TeeReader(tr, h)
+
+		var n int
+		if e.Name == redisName {
+			if err := json.NewDecoder(body).Decode(&snapshot); err != nil {
+				return nil, fmt.Errorf("error reading %s: %w", e.Name, err)
+			}
+			// Consume any trailing whitespace so that it's hashed.
+			if _, err := io.Copy(io.Discard, body); err != nil {
+				return nil, err
+			}
+			n = len(snapshot)
+		} else if n, err = importTable(ctx, restore, strings.TrimSuffix(e.Name, tableExt), body); err != nil {
+			return nil, err
+		}
+
+		if err := verify(e, n, h); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkImported(ctx, m, restore); err != nil {
+		return nil, err
+	}
+
+	if dst.Redis == nil {
+		if len(snapshot) > 0 {
+			if _, err := restore.InsertQueueSnapshot(ctx, snapshot); err != nil {
+				return nil, err
+			}
+		}
+		if err := restore.Commit(); err != nil {
+			return nil, fmt.Errorf("error committing import: %w", err)
+		}
+		return m, nil
+	}
+
+	// Write to the external Redis before committing, removing the keys again
+	// if either fails.  The Redis instance was checked to be empty.
+	err = devserver.RestoreRedis(ctx, dst.Redis, snapshot)
+	if err == nil {
+		err = restore.Commit()
+	}
+	if err != nil {
+		_ = dst.Redis.Do(ctx, dst.Redis.B().Flushdb().Build()).Error()
+		return nil, fmt.Errorf("error importing Redis: %w", err)
+	}
+	return m, nil
+}
+
+// ReadManifest returns the manifest of the backup read from r.
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return readManifest(tar.NewReader(zr))
+}
+
+func readManifest(tr *tar.Reader) (*Manifest, error) {
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("error reading backup: %w", err)
+	}
+	if hdr.Name != manifestName {
+		return nil, fmt.Errorf("invalid backup: expected %s, found %s", manifestName, hdr.Name)
+	}
+
+	m := &Manifest{}
+	if err := json.NewDecoder(tr).Decode(m); err != nil {
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+	if m.Version < 1 || m.Version > Version {
+		return nil, fmt.Errorf("unsupported backup version %d: this version of inngest supports version %d", m.Version, Version)
+	}
+
+	names := make([]string, len(m.Entries))
+	for n, e := range m.Entries {
+		names[n] = e.Name
+	}
+	if !slices.Equal(names, entryNames()) {
+		return nil, fmt.Errorf("invalid backup: expected entries %s, found %s", strings.Join(entryNames(), ", "), strings.Join(names, ", "))
+	}
+	return m, nil
+}
+
+// checkEmpty ensures that the instance has no existing data, so that the
+// import doesn't conflict with or silently merge into existing data.
+func checkEmpty(ctx context.Context, dst Instance, restore *base_cqrs.Restore) error {
+	for _, table := range base_cqrs.BackupTables() {
+		n, err := restore.Count(ctx, table)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return fmt.Errorf("cannot import into a non-empty database: %s contains %d rows", table, n)
+		}
+	}
+	n, err := restore.QueueSnapshots(ctx)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("cannot import into a non-empty database: %d queue snapshots exist", n)
+	}
+
+	if dst.Redis != nil {
+		size, err := dst.Redis.Do(ctx, dst.Redis.B().Dbsize().Build()).AsInt64()
+		if err != nil {
+			return fmt.Errorf("error checking Redis: %w", err)
+		}
+		if size > 0 {
+			return fmt.Errorf("cannot import into a non-empty Redis: %d keys exist", size)
+		}
+		return nil
+	}
+
+	segments, err := walSegments(dst.WALDir)
+	if err != nil {
+		return err
+	}
+	if len(segments) > 0 {
+		// The log would be replayed on top of the imported snapshot.
+		return fmt.Errorf("cannot import while a Redis write-ahead log exists in %s", dst.WALDir)
+	}
+	return nil
+}
+
+func importTable(ctx context.Context, restore *base_cqrs.Restore, table string, r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+	n := 0
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 1 {
+			if err := restore.Insert(ctx, table, line); err != nil {
+				return n, fmt.Errorf("error importing row %d: %w", n+1, err)
+			}
+			n++
+		}
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, fmt.Errorf("error reading %s: %w", table, err)
+		}
+	}
+}
+
+func verify(e Entry, rows int, h hash.Hash) error {
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != e.SHA256 {
+		return fmt.Errorf("checksum mismatch for %s: the backup is corrupt", e.Name)
+	}
+	if rows != e.Rows {
+		return fmt.Errorf("row count mismatch for %s: expected %d, found %d", e.Name, e.Rows, rows)
+	}
+	return nil
+}
+
+// checkImported ensures that the imported data is consistent.
+func checkImported(ctx context.Context, m *Manifest, restore *base_cqrs.Restore) error {
+	for _, e := range m.Entries {
+		table, ok := strings.CutSuffix(e.Name, tableExt)
+		if !ok {
+			continue
+		}
+		n, err := restore.Count(ctx, table)
+		if err != nil {
+			return err
+		}
+		if n != e.Rows {
+			return fmt.Errorf("imported %d rows into %s: expected %d", n, table, e.Rows)
+		}
+	}
+
+	orphaned, err := restore.OrphanedFunctions(ctx)
+	if err != nil {
+		return err
+	}
+	if len(orphaned) > 0 {
+		return fmt.Errorf("backup contains %d functions without an app, eg. %s", len(orphaned), orphaned[0])
+	}
+	return nil
+}
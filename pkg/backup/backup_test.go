@@ -0,0 +1,184 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/cqrs/base_cqrs"
+	sqlc "github.com/inngest/inngest/pkg/cqrs/base_cqrs/sqlc/sqlite"
+	"github.com/inngest/inngest/pkg/devserver/wal"
+	"github.com/klauspost/compress/zstd"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/require"
+)
+
+func newClient(t *testing.T, m *miniredis.Miniredis) rueidis.Client {
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:       []string{m.Addr()},
+		DisableCache:      true,
+		ForceSingleClient: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+func newDB(t *testing.T) *sql.DB {
+	db, err := base_cqrs.NewIsolated(t.Name() + "-" + uuid.NewString())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// newSource returns an instance with an app, a function, a queue snapshot and
+// a write-ahead log containing a write made after the snapshot.
+func newSource(t *testing.T) Instance {
+	ctx := context.Background()
+	db := newDB(t)
+
+	q := sqlc.New(db)
+	appID := uuid.New()
+	_, err := q.UpsertApp(ctx, sqlc.UpsertAppParams{ID: appID, Name: "app", Url: "http://localhost:3000/api/inngest"})
+	require.NoError(t, err)
+	_, err = q.InsertFunction(ctx, sqlc.InsertFunctionParams{
+		ID:        uuid.New(),
+		AppID:     appID,
+		Name:      "fn",
+		Slug:      "app-fn",
+		Config:    "{}",
+		CreatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	data := base_cqrs.NewCQRS(db, "sqlite")
+	id, err := data.InsertQueueSnapshot(ctx, cqrs.InsertQueueSnapshotParams{
+		Snapshot: cqrs.QueueSnapshot{"snapshot": {Type: "string", Value: "value"}},
+	})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	m := miniredis.RunT(t)
+	l, err := wal.Open(m, wal.Options{Dir: dir, Fsync: wal.FsyncAlways})
+	require.NoError(t, err)
+	require.NoError(t, l.Compact(ctx, func(ctx context.Context) (ulid.ULID, error) { return id, nil }))
+	require.NoError(t, l.Attach(ctx))
+	rc := newClient(t, m)
+	require.NoError(t, rc.Do(ctx, rc.B().Set().Key("wal").Value("value").Build()).Error())
+	require.NoError(t, l.Close())
+
+	return Instance{DB: db, Driver: "sqlite", WALDir: dir}
+}
+
+func export(t *testing.T, src Instance) []byte {
+	buf := &bytes.Buffer{}
+	_, err := Export(context.Background(), src, buf)
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	byt := export(t, newSource(t))
+
+	m, err := ReadManifest(bytes.NewReader(byt))
+	require.NoError(t, err)
+	require.Equal(t, Version, m.Version)
+	require.Equal(t, RedisEmbedded, m.Redis)
+	require.Equal(t, entryNames(), func() []string {
+		names := []string{}
+		for _, e := range m.Entries {
+			names = append(names, e.Name)
+		}
+		return names
+	}())
+	require.Equal(t, 1, m.Entries[0].Rows)
+	require.Equal(t, 2, m.Entries[len(m.Entries)-1].Rows)
+
+	t.Run("embedded Redis", func(t *testing.T) {
+		dst := Instance{DB: newDB(t), Driver: "sqlite", WALDir: t.TempDir()}
+		_, err := Import(ctx, dst, bytes.NewReader(byt))
+		require.NoError(t, err)
+
+		apps, err := sqlc.New(dst.DB).GetApps(ctx, uuid.Nil)
+		require.NoError(t, err)
+		require.Len(t, apps, 1)
+
+		// The snapshot contains keys from both the snapshot and the log.
+		snapshot, err := base_cqrs.NewCQRS(dst.DB, "sqlite").GetLatestQueueSnapshot(ctx)
+		require.NoError(t, err)
+		require.Len(t, *snapshot, 2)
+
+		// Importing into a non-empty instance fails.
+		_, err = Import(ctx, dst, bytes.NewReader(byt))
+		require.ErrorContains(t, err, "non-empty database")
+	})
+
+	t.Run("external Redis", func(t *testing.T) {
+		r := miniredis.RunT(t)
+		dst := Instance{DB: newDB(t), Driver: "sqlite", Redis: newClient(t, r)}
+		_, err := Import(ctx, dst, bytes.NewReader(byt))
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"snapshot", "wal"}, r.Keys())
+
+		snapshot, err := base_cqrs.NewCQRS(dst.DB, "sqlite").GetLatestQueueSnapshot(ctx)
+		require.NoError(t, err)
+		require.Nil(t, snapshot)
+	})
+
+	t.Run("corrupt", func(t *testing.T) {
+		corrupt := rewrite(t, byt, func(name string, body []byte) []byte {
+			if name == "apps.jsonl" {
+				return bytes.Replace(body, []byte(`"app"`), []byte(`"ppa"`), 1)
+			}
+			return body
+		})
+
+		dst := Instance{DB: newDB(t), Driver: "sqlite", WALDir: t.TempDir()}
+		_, err := Import(ctx, dst, bytes.NewReader(corrupt))
+		require.ErrorContains(t, err, "checksum mismatch for apps.jsonl")
+
+		// Nothing is imported.
+		apps, err := sqlc.New(dst.DB).GetApps(ctx, uuid.Nil)
+		require.NoError(t, err)
+		require.Empty(t, apps)
+	})
+}
+
+// rewrite rewrites each file within a backup.
+func rewrite(t *testing.T, byt []byte, f func(name string, body []byte) []byte) []byte {
+	zr, err := zstd.NewReader(bytes.NewReader(byt))
+	require.NoError(t, err)
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+
+	buf := &bytes.Buffer{}
+	zw, err := zstd.NewWriter(buf)
+	require.NoError(t, err)
+	tw := tar.NewWriter(zw)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		body = f(hdr.Name, body)
+		hdr.Size = int64(len(body))
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err = tw.Write(body)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
@@ -26,6 +26,8 @@ config.#Config & {
 			}
 			connect: config.#ConnectDriver & {
 			}
+			wasm: config.#WasmDriver & {
+			}
 		}
 	}
 }
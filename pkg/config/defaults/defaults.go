@@ -5,5 +5,6 @@ import (
 	_ "github.com/inngest/inngest/pkg/execution/driver/connectdriver"
 	_ "github.com/inngest/inngest/pkg/execution/driver/httpdriver"
 	_ "github.com/inngest/inngest/pkg/execution/driver/mockdriver"
+	_ "github.com/inngest/inngest/pkg/execution/driver/wasmdriver"
 	_ "github.com/inngest/inngest/pkg/execution/state/redis_state"
 )
@@ -2,12 +2,14 @@ package registration
 
 import (
 	"context"
+	"github.com/google/uuid"
 	"github.com/inngest/inngest/pkg/connect/pubsub"
 	"github.com/inngest/inngest/pkg/telemetry/trace"
 
 	"github.com/inngest/inngest/pkg/execution/driver"
 	"github.com/inngest/inngest/pkg/execution/queue"
 	"github.com/inngest/inngest/pkg/execution/state"
+	"github.com/inngest/inngest/pkg/keyring"
 )
 
 var (
@@ -60,6 +62,13 @@ func RegisterState(f func() any) {
 type NewDriverOpts struct {
 	LocalSigningKey        *string
 	RequireLocalSigningKey bool
+	// Keyring, if set, supplies the current signing keys in place of
+	// LocalSigningKey, allowing keys to be rotated without a restart.
+	Keyring *keyring.Keyring
+	// EnvKeyring, if set, returns the keyring for the given environment,
+	// such that each function is signed with its environment's keys.  A nil
+	// keyring falls back to Keyring.
+	EnvKeyring func(envID uuid.UUID) *keyring.Keyring
 
 	ConnectForwarder  pubsub.RequestForwarder
 	ConditionalTracer trace.ConditionalTracer
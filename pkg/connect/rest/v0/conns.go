@@ -5,7 +5,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/inngest/inngest/pkg/connect/rest"
-	"github.com/inngest/inngest/pkg/consts"
+	"github.com/inngest/inngest/pkg/environment"
 	"github.com/inngest/inngest/pkg/publicerr"
 	connpb "github.com/inngest/inngest/proto/gen/connect/v1"
 	"net/http"
@@ -24,7 +24,7 @@ func (c *connectApiRouter) showConnections(w http.ResponseWriter, r *http.Reques
 	)
 	switch c.Dev {
 	case true:
-		envID = consts.DevServerEnvID
+		envID = environment.IDFromContext(ctx)
 
 	case false:
 		// Expect UUID
@@ -5,7 +5,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/inngest/inngest/pkg/connect/rest"
-	"github.com/inngest/inngest/pkg/consts"
+	"github.com/inngest/inngest/pkg/environment"
 	"github.com/inngest/inngest/pkg/publicerr"
 	"net/http"
 )
@@ -16,7 +16,7 @@ func (c *connectApiRouter) showWorkerGroup(w http.ResponseWriter, r *http.Reques
 	var envID uuid.UUID
 	switch c.Dev {
 	case true:
-		envID = consts.DevServerEnvID
+		envID = environment.IDFromContext(ctx)
 
 	case false:
 		// Expect UUID
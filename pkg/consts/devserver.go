@@ -13,11 +13,20 @@ const (
 	// StartDefaultPersistenceInterval is the default interval at which the
 	// queue will be snapshotted and persisted to disk.
 	StartDefaultPersistenceInterval = time.Second * 60
+	// DevServerPersistenceInterval is the interval at which the queue is
+	// snapshotted when running `inngest dev --persist`.
+	DevServerPersistenceInterval = time.Second * 10
+	// DevServerPersistDir is the default directory used to store data when
+	// running `inngest dev --persist`.
+	DevServerPersistDir = ".inngest/dev"
 	// StartMaxQueueChunkSize is the default maximum size of a queue chunk.
 	// This is set to be comfortably within the 1GB limit of SQLite.
 	StartMaxQueueChunkSize = 1024 * 1024 * 800 // 800MB
 	// StartMaxQueueSnapshots is the maximum number of snapshots we keep.
-	StartMaxQueueSnapshots  = 5
+	StartMaxQueueSnapshots = 5
+	// StartRedisWALDir is the directory, within the SQLite directory, which
+	// stores the in-memory Redis's write-ahead log.
+	StartRedisWALDir        = "redis-wal"
 	DefaultInngestConfigDir = ".inngest"
 	SQLiteDbFileName        = "main.db"
 	// DevServerHistoryFile is the file where the history is stored.
@@ -25,6 +34,13 @@ const (
 	// @deprecated Used in the in-memory writer when persiting, though this
 	// should not be actively used any more.
 	DevServerHistoryFile = "dev_history.json"
+	// StartEncryptionKeyReloadInterval is the interval at which the encryption
+	// keyfile is checked for changes, allowing keys to be rotated without a
+	// restart.
+	StartEncryptionKeyReloadInterval = time.Second * 30
+	// StartRealtimeHistorySize is the number of realtime messages retained for
+	// each topic, allowing subscribers to replay messages after reconnecting.
+	StartRealtimeHistorySize = 100
 )
 
 var (
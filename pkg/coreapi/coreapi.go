@@ -12,6 +12,7 @@ import (
 	"github.com/go-chi/cors"
 	"github.com/inngest/inngest/pkg/api"
 	"github.com/inngest/inngest/pkg/api/tel"
+	"github.com/inngest/inngest/pkg/audit"
 	"github.com/inngest/inngest/pkg/config"
 	connectv0 "github.com/inngest/inngest/pkg/connect/rest/v0"
 	"github.com/inngest/inngest/pkg/consts"
@@ -20,12 +21,14 @@ import (
 	loader "github.com/inngest/inngest/pkg/coreapi/graph/loaders"
 	"github.com/inngest/inngest/pkg/coreapi/graph/resolvers"
 	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/environment"
 	"github.com/inngest/inngest/pkg/execution"
 	"github.com/inngest/inngest/pkg/execution/queue"
 	"github.com/inngest/inngest/pkg/execution/runner"
 	"github.com/inngest/inngest/pkg/execution/state"
 	"github.com/inngest/inngest/pkg/headers"
 	"github.com/inngest/inngest/pkg/history_reader"
+	"github.com/inngest/inngest/pkg/keyring"
 	"github.com/inngest/inngest/pkg/logger"
 	"github.com/inngest/inngest/pkg/publicerr"
 	"github.com/oklog/ulid/v2"
@@ -48,6 +51,10 @@ type Options struct {
 	// LocalSigningKey is the key used to sign events for self-hosted services.
 	LocalSigningKey string
 
+	// Keyring, if set, supplies the current signing key in place of
+	// LocalSigningKey, allowing keys to be rotated without a restart.
+	Keyring *keyring.Keyring
+
 	// RequireKeys defines whether event and signing keys are required for the
 	// server to function. If this is true and signing keys are not defined,
 	// the server will still boot but core actions such as syncing, runs, and
@@ -97,6 +104,7 @@ func NewCoreApi(o Options) (*CoreAPI, error) {
 		Executor:        o.Executor,
 		ServerKind:      o.Config.GetServerKind(),
 		LocalSigningKey: o.LocalSigningKey,
+		Keyring:         o.Keyring,
 		RequireKeys:     o.RequireKeys,
 	}}))
 
@@ -258,6 +266,13 @@ func (a CoreAPI) CancelRun(w http.ResponseWriter, r *http.Request) {
 		_ = publicerr.WriteHTTP(w, err)
 		return
 	}
+	audit.Record(ctx, a.data, cqrs.AuditEntry{
+		AccountID:   consts.DevServerAccountID,
+		WorkspaceID: environment.IDFromContext(ctx),
+		Source:      audit.SourceREST,
+		Action:      cqrs.AuditActionRunCancel,
+		TargetIDs:   []string{runID.String()},
+	}, nil)
 
 	w.WriteHeader(204)
 }
@@ -259,6 +259,7 @@ type ComplexityRoot struct {
 	RunHistoryCancel struct {
 		EventID    func(childComplexity int) int
 		Expression func(childComplexity int) int
+		Reason     func(childComplexity int) int
 		UserID     func(childComplexity int) int
 	}
 
@@ -1658,6 +1659,13 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.RunHistoryCancel.Expression(childComplexity), true
 
+	case "RunHistoryCancel.reason":
+		if e.complexity.RunHistoryCancel.Reason == nil {
+			break
+		}
+
+		return e.complexity.RunHistoryCancel.Reason(childComplexity), true
+
 	case "RunHistoryCancel.userID":
 		if e.complexity.RunHistoryCancel.UserID == nil {
 			break
@@ -2811,6 +2819,9 @@ type RunHistoryCancel {
   eventID: ULID
   expression: String
   userID: UUID
+  # The reason for cancellations made by the system, eg. "singleton" when a
+  # run is replaced by a new run of a singleton function.
+  reason: String
 }
 
 type RunHistoryResult {
@@ -11201,6 +11212,47 @@ func (ec *executionContext) fieldContext_RunHistoryCancel_userID(ctx context.Con
 	return fc, nil
 }
 
+func (ec *executionContext) _RunHistoryCancel_reason(ctx context.Context, field graphql.CollectedField, obj *history_reader.RunHistoryCancel) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_RunHistoryCancel_reason(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Reason, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_RunHistoryCancel_reason(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "RunHistoryCancel",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _RunHistoryInvokeFunction_eventID(ctx context.Context, field graphql.CollectedField, obj *history_reader.RunHistoryInvokeFunction) (ret graphql.Marshaler) {
 	fc, err := ec.fieldContext_RunHistoryInvokeFunction_eventID(ctx, field)
 	if err != nil {
@@ -11589,6 +11641,8 @@ func (ec *executionContext) fieldContext_RunHistoryItem_cancel(ctx context.Conte
 				return ec.fieldContext_RunHistoryCancel_expression(ctx, field)
 			case "userID":
 				return ec.fieldContext_RunHistoryCancel_userID(ctx, field)
+			case "reason":
+				return ec.fieldContext_RunHistoryCancel_reason(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type RunHistoryCancel", field.Name)
 		},
@@ -19897,6 +19951,10 @@ func (ec *executionContext) _RunHistoryCancel(ctx context.Context, sel ast.Selec
 
 			out.Values[i] = ec._RunHistoryCancel_userID(ctx, field, obj)
 
+		case "reason":
+
+			out.Values[i] = ec._RunHistoryCancel_reason(ctx, field, obj)
+
 		default:
 			panic("unknown field " + strconv.Quote(field.Name))
 		}
@@ -6,10 +6,10 @@ import (
 	"github.com/google/uuid"
 	"github.com/inngest/inngest/pkg/enums"
 
-	"github.com/inngest/inngest/pkg/consts"
 	"github.com/inngest/inngest/pkg/coreapi/graph/models"
 	"github.com/inngest/inngest/pkg/cqrs"
 	"github.com/inngest/inngest/pkg/devserver/discovery"
+	"github.com/inngest/inngest/pkg/environment"
 )
 
 func (a queryResolver) Apps(ctx context.Context, filter *models.AppsFilterV1) ([]*cqrs.App, error) {
@@ -17,7 +17,7 @@ func (a queryResolver) Apps(ctx context.Context, filter *models.AppsFilterV1) ([
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse filter: %w", err)
 	}
-	return a.Data.GetApps(ctx, consts.DevServerEnvID, cqrsFilter)
+	return a.Data.GetApps(ctx, environment.IDFromContext(ctx), cqrsFilter)
 }
 
 func (a queryResolver) App(ctx context.Context, id uuid.UUID) (*cqrs.App, error) {
@@ -66,7 +66,7 @@ func (a appResolver) Functions(ctx context.Context, obj *cqrs.App) ([]*models.Fu
 		return nil, fmt.Errorf("no app defined")
 	}
 	// Local dev doesn't have a workspace ID.
-	funcs, err := a.Data.GetFunctionsByAppInternalID(ctx, consts.DevServerEnvID, obj.ID)
+	funcs, err := a.Data.GetFunctionsByAppInternalID(ctx, environment.IDFromContext(ctx), obj.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -92,7 +92,7 @@ func (a appResolver) Autodiscovered(ctx context.Context, obj *cqrs.App) (bool, e
 }
 
 func (a appResolver) FunctionCount(ctx context.Context, obj *cqrs.App) (int, error) {
-	funcs, err := a.Data.GetFunctionsByAppInternalID(ctx, consts.DevServerEnvID, obj.ID)
+	funcs, err := a.Data.GetFunctionsByAppInternalID(ctx, environment.IDFromContext(ctx), obj.ID)
 	if err != nil {
 		return 0, err
 	}
@@ -12,6 +12,7 @@ import (
 	"github.com/inngest/inngest/pkg/coreapi/graph/models"
 	"github.com/inngest/inngest/pkg/cqrs"
 	"github.com/inngest/inngest/pkg/deploy"
+	"github.com/inngest/inngest/pkg/environment"
 	"github.com/inngest/inngest/pkg/event"
 	"github.com/inngest/inngest/pkg/inngest"
 	"github.com/inngest/inngest/pkg/run"
@@ -25,10 +26,13 @@ func (r *mutationResolver) CreateApp(ctx context.Context, input models.CreateApp
 		input.URL = "http://" + input.URL
 	}
 
-	// Create a new app which holds the error message.
+	// Create a new app which holds the error message, using the same ID as
+	// the app will be synced with within the environment.
+	envID := environment.IDFromContext(ctx)
 	params := cqrs.UpsertAppParams{
-		ID:  inngest.DeterministicAppUUID(input.URL),
-		Url: input.URL,
+		ID:    environment.ScopedID(envID, inngest.DeterministicAppUUID(input.URL)),
+		Url:   input.URL,
+		EnvID: envID,
 		Error: sql.NullString{
 			Valid:  true,
 			String: deploy.DeployErrUnreachable.Error(),
@@ -36,17 +40,18 @@ func (r *mutationResolver) CreateApp(ctx context.Context, input models.CreateApp
 	}
 	app, _ := r.Data.UpsertApp(ctx, params)
 
-	if res := deploy.Ping(ctx, input.URL, r.ServerKind, r.LocalSigningKey, r.RequireKeys); res.Err != nil {
+	if res := deploy.Ping(ctx, input.URL, r.ServerKind, r.signingKey(ctx), r.RequireKeys); res.Err != nil {
 		return app, res.Err
 	}
 
 	<-time.After(100 * time.Millisecond)
-	apps, err := r.Data.GetAllApps(ctx, consts.DevServerEnvID)
+	apps, err := r.Data.GetAllApps(ctx, envID)
 	if err != nil {
 		return nil, err
 	}
 	for _, app := range apps {
 		if app.Url == input.URL {
+			r.recordAudit(ctx, cqrs.AuditActionAppCreate, []string{app.ID.String()}, input)
 			return app, nil
 		}
 	}
@@ -54,10 +59,15 @@ func (r *mutationResolver) CreateApp(ctx context.Context, input models.CreateApp
 }
 
 func (r *mutationResolver) UpdateApp(ctx context.Context, input models.UpdateAppInput) (*cqrs.App, error) {
-	return r.Data.UpdateAppURL(ctx, cqrs.UpdateAppURLParams{
+	app, err := r.Data.UpdateAppURL(ctx, cqrs.UpdateAppURLParams{
 		ID:  uuid.MustParse(input.ID),
 		Url: input.URL,
 	})
+	if err != nil {
+		return nil, err
+	}
+	r.recordAudit(ctx, cqrs.AuditActionAppUpdate, []string{input.ID}, input)
+	return app, nil
 }
 
 func (r *mutationResolver) DeleteApp(ctx context.Context, idstr string) (string, error) {
@@ -68,6 +78,7 @@ func (r *mutationResolver) DeleteApp(ctx context.Context, idstr string) (string,
 	if err = r.Data.DeleteApp(ctx, id); err != nil {
 		return "", err
 	}
+	r.recordAudit(ctx, cqrs.AuditActionAppDelete, []string{idstr}, nil)
 	return idstr, nil
 }
 
@@ -75,14 +86,18 @@ func (r *mutationResolver) DeleteAppByName(
 	ctx context.Context,
 	name string,
 ) (bool, error) {
-	apps, err := r.Data.GetApps(ctx, consts.DevServerEnvID, nil)
+	apps, err := r.Data.GetApps(ctx, environment.IDFromContext(ctx), nil)
 	if err != nil {
 		return false, err
 	}
 
 	for _, app := range apps {
 		if app.Name == name {
-			return true, r.Data.DeleteApp(ctx, app.ID)
+			if err := r.Data.DeleteApp(ctx, app.ID); err != nil {
+				return true, err
+			}
+			r.recordAudit(ctx, cqrs.AuditActionAppDelete, []string{app.ID.String()}, nil)
+			return true, nil
 		}
 	}
 
@@ -114,10 +129,11 @@ func (r *mutationResolver) InvokeFunction(
 	defer span.End()
 
 	sent := false
-	_, err := r.EventHandler(ctx, &evt, nil)
+	id, err := r.EventHandler(ctx, &evt, nil)
 	if err != nil {
 		return &sent, err
 	}
+	r.recordAudit(ctx, cqrs.AuditActionFunctionInvoke, []string{functionSlug, id}, data)
 
 	sent = true
 	return &sent, nil
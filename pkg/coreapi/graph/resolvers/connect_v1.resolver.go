@@ -7,6 +7,7 @@ import (
 	"github.com/inngest/inngest/pkg/coreapi/graph/models"
 	"github.com/inngest/inngest/pkg/cqrs"
 	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/environment"
 	connpb "github.com/inngest/inngest/proto/gen/connect/v1"
 	"github.com/oklog/ulid/v2"
 	"time"
@@ -26,7 +27,7 @@ func (r *connectV1workerConnectionConnResolver) App(ctx context.Context, obj *mo
 }
 
 func (r *connectV1workerConnectionResolver) TotalCount(ctx context.Context, obj *models.WorkerConnectionsConnection) (int, error) {
-	opts := toWorkerConnectionsQueryOpt(0, obj.After, obj.OrderBy, obj.Filter)
+	opts := toWorkerConnectionsQueryOpt(ctx, 0, obj.After, obj.OrderBy, obj.Filter)
 	count, err := r.Data.GetWorkerConnectionsCount(ctx, opts)
 	if err != nil {
 		return 0, fmt.Errorf("error retrieving count for worker connections: %w", err)
@@ -36,7 +37,7 @@ func (r *connectV1workerConnectionResolver) TotalCount(ctx context.Context, obj
 }
 
 func (r *queryResolver) WorkerConnections(ctx context.Context, first int, after *string, orderBy []*models.ConnectV1WorkerConnectionsOrderBy, filter models.ConnectV1WorkerConnectionsFilter) (*models.WorkerConnectionsConnection, error) {
-	opts := toWorkerConnectionsQueryOpt(first, after, orderBy, filter)
+	opts := toWorkerConnectionsQueryOpt(ctx, first, after, orderBy, filter)
 	workerConns, err := r.Data.GetWorkerConnections(ctx, opts)
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving worker connections: %w", err)
@@ -143,7 +144,7 @@ func connToNode(conn *cqrs.WorkerConnection) *models.ConnectV1WorkerConnection {
 func (r *queryResolver) WorkerConnection(ctx context.Context, connectionID ulid.ULID) (*models.ConnectV1WorkerConnection, error) {
 	conn, err := r.Data.GetWorkerConnection(ctx, cqrs.WorkerConnectionIdentifier{
 		AccountID:    consts.DevServerAccountID,
-		WorkspaceID:  consts.DevServerEnvID,
+		WorkspaceID:  environment.IDFromContext(ctx),
 		ConnectionID: connectionID,
 	})
 	if err != nil {
@@ -154,6 +155,7 @@ func (r *queryResolver) WorkerConnection(ctx context.Context, connectionID ulid.
 }
 
 func toWorkerConnectionsQueryOpt(
+	ctx context.Context,
 	num int,
 	cur *string,
 	order []*models.ConnectV1WorkerConnectionsOrderBy,
@@ -243,7 +245,7 @@ func toWorkerConnectionsQueryOpt(
 	return cqrs.GetWorkerConnectionOpt{
 		Filter: cqrs.GetWorkerConnectionFilter{
 			AccountID:   consts.DevServerAccountID,
-			WorkspaceID: consts.DevServerEnvID,
+			WorkspaceID: environment.IDFromContext(ctx),
 			AppID:       filter.AppIDs,
 			TimeField:   tsfield,
 			From:        from,
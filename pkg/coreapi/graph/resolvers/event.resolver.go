@@ -7,12 +7,13 @@ import (
 	"github.com/inngest/inngest/pkg/consts"
 	"github.com/inngest/inngest/pkg/coreapi/graph/models"
 	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/environment"
 	"github.com/oklog/ulid/v2"
 )
 
 // TODO Duplicate code. Move to field-level resolvers and add dataloaders.
 func (r *eventResolver) FunctionRuns(ctx context.Context, obj *models.Event) ([]*models.FunctionRun, error) {
-	runs, err := r.Data.GetFunctionRunsFromEvents(ctx, consts.DevServerAccountID, consts.DevServerEnvID, []ulid.ULID{obj.ID})
+	runs, err := r.Data.GetFunctionRunsFromEvents(ctx, consts.DevServerAccountID, environment.IDFromContext(ctx), []ulid.ULID{obj.ID})
 	if err != nil {
 		return nil, err
 	}
@@ -11,7 +11,9 @@ import (
 	"github.com/google/uuid"
 	"github.com/inngest/inngest/pkg/consts"
 	"github.com/inngest/inngest/pkg/coreapi/graph/models"
+	"github.com/inngest/inngest/pkg/cqrs"
 	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/environment"
 	"github.com/inngest/inngest/pkg/event"
 	"github.com/inngest/inngest/pkg/execution"
 	statev1 "github.com/inngest/inngest/pkg/execution/state"
@@ -44,7 +46,7 @@ func (r *functionRunResolver) PendingSteps(ctx context.Context, obj *models.Func
 }
 
 func (r *functionRunResolver) Function(ctx context.Context, obj *models.FunctionRun) (*models.Function, error) {
-	fn, err := r.Data.GetFunctionByInternalUUID(ctx, uuid.UUID{}, uuid.MustParse(obj.FunctionID))
+	fn, err := r.Data.GetFunctionByInternalUUID(ctx, environment.IDFromContext(ctx), uuid.MustParse(obj.FunctionID))
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +91,7 @@ func (r *functionRunResolver) HistoryItemOutput(
 			// TODO: Where should we get this?
 			WorkflowID: uuid.New(),
 
-			WorkspaceID: consts.DevServerEnvID,
+			WorkspaceID: environment.IDFromContext(ctx),
 		},
 	)
 }
@@ -190,7 +192,7 @@ func (r *mutationResolver) CancelRun(
 	runID ulid.ULID,
 ) (*models.FunctionRun, error) {
 	accountID := consts.DevServerAccountID
-	workspaceID := consts.DevServerEnvID
+	workspaceID := environment.IDFromContext(ctx)
 	run, err := r.HistoryReader.GetFunctionRun(
 		ctx,
 		accountID,
@@ -218,6 +220,7 @@ func (r *mutationResolver) CancelRun(
 	if err != nil {
 		return nil, err
 	}
+	r.recordAudit(ctx, cqrs.AuditActionRunCancel, []string{runID.String()}, nil)
 
 	// Wait an arbitrary amount of time to give the history store enough time to
 	// reflect the cancellation
@@ -266,7 +269,7 @@ func (r *mutationResolver) Rerun(
 ) (ulid.ULID, error) {
 	zero := ulid.ULID{}
 	accountID := consts.DevServerAccountID
-	workspaceID := consts.DevServerEnvID
+	workspaceID := environment.IDFromContext(ctx)
 
 	fnrun, err := r.Data.GetFunctionRun(
 		ctx,
@@ -337,11 +340,12 @@ func (r *mutationResolver) Rerun(
 		OriginalRunID: &fnrun.RunID,
 		AccountID:     consts.DevServerAccountID,
 		FromStep:      fromStepReq,
-		WorkspaceID:   consts.DevServerEnvID,
+		WorkspaceID:   environment.IDFromContext(ctx),
 	})
 	if err != nil {
 		return zero, err
 	}
+	r.recordAudit(ctx, cqrs.AuditActionRunRerun, []string{runID.String(), identifier.ID.RunID.String()}, fromStep)
 
 	return identifier.ID.RunID, nil
 }
@@ -0,0 +1,108 @@
+package resolvers
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/consts"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/cqrs/base_cqrs"
+	sqlc "github.com/inngest/inngest/pkg/cqrs/base_cqrs/sqlc/sqlite"
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/environment"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunsScopedToEnvironment(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := base_cqrs.NewIsolated("resolvers-env-" + uuid.NewString())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	data := base_cqrs.NewCQRS(db, "sqlite")
+	r := &mutationResolver{Resolver: &Resolver{
+		Data:          data,
+		HistoryReader: base_cqrs.NewHistoryReader(db, "sqlite"),
+	}}
+
+	prod := &environment.Environment{ID: consts.DevServerEnvID, Name: environment.DefaultName}
+	staging := &environment.Environment{ID: environment.ID("staging"), Name: "staging"}
+
+	// Create a finished run within each environment.
+	runs := map[uuid.UUID]ulid.ULID{}
+	for _, env := range []*environment.Environment{prod, staging} {
+		app, err := data.UpsertApp(ctx, cqrs.UpsertAppParams{
+			ID:    environment.ScopedID(env.ID, uuid.New()),
+			Name:  "app",
+			Url:   "http://localhost:3000/" + env.Name,
+			EnvID: env.ID,
+		})
+		require.NoError(t, err)
+		fn, err := data.InsertFunction(ctx, cqrs.InsertFunctionParams{
+			ID:        environment.ScopedID(env.ID, uuid.New()),
+			AppID:     app.ID,
+			Name:      "fn",
+			Slug:      "app-fn",
+			Config:    "{}",
+			CreatedAt: time.Now(),
+		})
+		require.NoError(t, err)
+
+		runID := ulid.Make()
+		require.NoError(t, data.InsertFunctionRun(ctx, cqrs.FunctionRun{
+			RunID:        runID,
+			RunStartedAt: time.Now(),
+			FunctionID:   fn.ID,
+			WorkspaceID:  env.ID,
+			EventID:      ulid.Make(),
+		}))
+		require.NoError(t, base_cqrs.NewQueries(db, "sqlite").InsertFunctionFinish(ctx, sqlc.InsertFunctionFinishParams{
+			RunID:              runID,
+			Status:             sql.NullString{String: enums.RunStatusCompleted.String(), Valid: true},
+			Output:             sql.NullString{String: "{}", Valid: true},
+			CompletedStepCount: sql.NullInt64{Valid: true},
+			CreatedAt:          sql.NullTime{Time: time.Now(), Valid: true},
+		}))
+		runs[env.ID] = runID
+
+		// Functions are only visible within their own environment.
+		_, err = data.GetFunctionByInternalUUID(ctx, env.ID, fn.ID)
+		require.NoError(t, err)
+		fns, err := data.GetFunctionsByAppInternalID(ctx, env.ID, app.ID)
+		require.NoError(t, err)
+		require.Len(t, fns, 1)
+		other := prod.ID
+		if env == prod {
+			other = staging.ID
+		}
+		_, err = data.GetFunctionByInternalUUID(ctx, other, fn.ID)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+		fns, err = data.GetFunctionsByAppInternalID(ctx, other, app.ID)
+		require.NoError(t, err)
+		require.Empty(t, fns)
+	}
+
+	prodCtx := environment.WithEnvironment(ctx, prod)
+	stagingCtx := environment.WithEnvironment(ctx, staging)
+
+	// Runs within the caller's environment are found; this one has ended.
+	_, err = r.CancelRun(prodCtx, runs[prod.ID])
+	require.ErrorContains(t, err, "cannot cancel an ended run")
+
+	// Runs within another environment are never loaded or acted upon.
+	_, err = r.CancelRun(stagingCtx, runs[prod.ID])
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "ended run")
+	_, err = r.CancelRun(prodCtx, runs[staging.ID])
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "ended run")
+
+	_, err = r.Rerun(stagingCtx, runs[prod.ID], nil)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = r.Rerun(prodCtx, runs[staging.ID], nil)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -4,10 +4,10 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/inngest/inngest/pkg/consts"
 	loader "github.com/inngest/inngest/pkg/coreapi/graph/loaders"
 	"github.com/inngest/inngest/pkg/coreapi/graph/models"
 	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/environment"
 )
 
 func (r *functionRunV2Resolver) App(
@@ -18,7 +18,7 @@ func (r *functionRunV2Resolver) App(
 }
 
 func (r *functionRunV2Resolver) Function(ctx context.Context, fn *models.FunctionRunV2) (*models.Function, error) {
-	fun, err := r.Data.GetFunctionByInternalUUID(ctx, consts.DevServerEnvID, fn.FunctionID)
+	fun, err := r.Data.GetFunctionByInternalUUID(ctx, environment.IDFromContext(ctx), fn.FunctionID)
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving function: %w", err)
 	}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/inngest/inngest/pkg/coreapi/graph/models"
+	"github.com/inngest/inngest/pkg/environment"
 	"github.com/inngest/inngest/pkg/history_reader"
 	"github.com/oklog/ulid/v2"
 )
@@ -34,10 +35,11 @@ func (r *queryResolver) FunctionRun(ctx context.Context, query models.FunctionRu
 		return nil, fmt.Errorf("Invalid run ID: %w", err)
 	}
 
+	envID := environment.IDFromContext(ctx)
 	run, err := r.HistoryReader.GetRun(
 		ctx,
 		runID,
-		history_reader.GetRunOpts{},
+		history_reader.GetRunOpts{WorkspaceID: &envID},
 	)
 	if err != nil {
 		return nil, err
@@ -3,13 +3,19 @@ package resolvers
 // THIS CODE IS A STARTING POINT ONLY. IT WILL NOT BE UPDATED WITH SCHEMA CHANGES.
 
 import (
+	"context"
+
 	"github.com/inngest/inngest/pkg/api"
+	"github.com/inngest/inngest/pkg/audit"
+	"github.com/inngest/inngest/pkg/consts"
 	"github.com/inngest/inngest/pkg/coreapi/generated"
 	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/environment"
 	"github.com/inngest/inngest/pkg/execution"
 	"github.com/inngest/inngest/pkg/execution/queue"
 	"github.com/inngest/inngest/pkg/execution/runner"
 	"github.com/inngest/inngest/pkg/history_reader"
+	"github.com/inngest/inngest/pkg/keyring"
 )
 
 type Resolver struct {
@@ -24,6 +30,10 @@ type Resolver struct {
 	// LocalSigningKey is the key used to sign events for self-hosted services.
 	LocalSigningKey string
 
+	// Keyring, if set, supplies the current signing key in place of
+	// LocalSigningKey, allowing keys to be rotated without a restart.
+	Keyring *keyring.Keyring
+
 	// RequireKeys defines whether event and signing keys are required for the
 	// server to function. If this is true and signing keys are not defined,
 	// the server will still boot but core actions such as syncing, runs, and
@@ -31,6 +41,32 @@ type Resolver struct {
 	RequireKeys bool
 }
 
+// signingKey returns the current signing key used to sign requests to apps
+// within the request's environment.
+func (r *Resolver) signingKey(ctx context.Context) string {
+	if e := environment.FromContext(ctx); e != nil && e.Keyring != nil {
+		return e.Keyring.SigningKey()
+	}
+	if r.Keyring != nil {
+		return r.Keyring.SigningKey()
+	}
+	return r.LocalSigningKey
+}
+
+// recordAudit records an administrative action performed via GraphQL.
+func (r *Resolver) recordAudit(ctx context.Context, action cqrs.AuditAction, targets []string, payload any) {
+	if r.Data == nil {
+		return
+	}
+	audit.Record(ctx, r.Data, cqrs.AuditEntry{
+		AccountID:   consts.DevServerAccountID,
+		WorkspaceID: environment.IDFromContext(ctx),
+		Source:      audit.SourceGraphQL,
+		Action:      action,
+		TargetIDs:   targets,
+	}, payload)
+}
+
 // Query returns generated.QueryResolver implementation.
 func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 
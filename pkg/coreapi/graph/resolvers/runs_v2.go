@@ -10,6 +10,7 @@ import (
 	"github.com/inngest/inngest/pkg/coreapi/graph/models"
 	"github.com/inngest/inngest/pkg/cqrs"
 	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/environment"
 	"github.com/inngest/inngest/pkg/inngest/log"
 	"github.com/oklog/ulid/v2"
 )
@@ -20,7 +21,7 @@ const (
 )
 
 func (r *queryResolver) Runs(ctx context.Context, num int, cur *string, order []*models.RunsV2OrderBy, filter models.RunsFilterV2) (*models.RunsV2Connection, error) {
-	opts := toRunsQueryOpt(num, cur, order, filter)
+	opts := toRunsQueryOpt(ctx, num, cur, order, filter)
 	runs, err := r.Data.GetTraceRuns(ctx, opts)
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving runs: %w", err)
@@ -361,7 +362,7 @@ func (r *queryResolver) RunTrigger(ctx context.Context, runID string) (*models.R
 }
 
 func (r *runsV2ConnResolver) TotalCount(ctx context.Context, obj *models.RunsV2Connection) (int, error) {
-	opts := toRunsQueryOpt(0, obj.After, obj.OrderBy, obj.Filter)
+	opts := toRunsQueryOpt(ctx, 0, obj.After, obj.OrderBy, obj.Filter)
 	count, err := r.Data.GetTraceRunsCount(ctx, opts)
 	if err != nil {
 		return 0, fmt.Errorf("error retrieving count for runs: %w", err)
@@ -371,6 +372,7 @@ func (r *runsV2ConnResolver) TotalCount(ctx context.Context, obj *models.RunsV2C
 }
 
 func toRunsQueryOpt(
+	ctx context.Context,
 	num int,
 	cur *string,
 	order []*models.RunsV2OrderBy,
@@ -459,13 +461,14 @@ func toRunsQueryOpt(
 
 	return cqrs.GetTraceRunOpt{
 		Filter: cqrs.GetTraceRunFilter{
-			AppID:      filter.AppIDs,
-			FunctionID: filter.FunctionIDs,
-			TimeField:  tsfield,
-			From:       filter.From,
-			Until:      until,
-			Status:     statuses,
-			CEL:        cel,
+			WorkspaceID: environment.IDFromContext(ctx),
+			AppID:       filter.AppIDs,
+			FunctionID:  filter.FunctionIDs,
+			TimeField:   tsfield,
+			From:        filter.From,
+			Until:       until,
+			Status:      statuses,
+			CEL:         cel,
 		},
 		Order:  orderBy,
 		Cursor: cursor,
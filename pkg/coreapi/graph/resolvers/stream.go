@@ -10,6 +10,7 @@ import (
 	"github.com/inngest/inngest/pkg/consts"
 	"github.com/inngest/inngest/pkg/coreapi/graph/models"
 	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/environment"
 	"github.com/oklog/ulid/v2"
 )
 
@@ -39,6 +40,7 @@ func (r *queryResolver) Stream(ctx context.Context, q models.StreamQuery) ([]*mo
 
 	evts, err := r.Data.GetEventsIDbound(
 		ctx,
+		environment.IDFromContext(ctx),
 		bound,
 		q.Limit,
 		includeInternalEvents,
@@ -53,7 +55,7 @@ func (r *queryResolver) Stream(ctx context.Context, q models.StreamQuery) ([]*mo
 	}
 
 	accountID := consts.DevServerAccountID
-	workspaceID := consts.DevServerEnvID
+	workspaceID := environment.IDFromContext(ctx)
 
 	fns, err := r.HistoryReader.GetFunctionRunsFromEvents(
 		ctx,
@@ -67,7 +69,7 @@ func (r *queryResolver) Stream(ctx context.Context, q models.StreamQuery) ([]*mo
 	fnsByID := map[ulid.ULID][]*models.FunctionRun{}
 	for _, fn := range fns {
 		run := models.MakeFunctionRun(fn)
-		_, err := r.Data.GetFunctionByInternalUUID(ctx, consts.DevServerEnvID, uuid.MustParse(run.FunctionID))
+		_, err := r.Data.GetFunctionByInternalUUID(ctx, environment.IDFromContext(ctx), uuid.MustParse(run.FunctionID))
 		if err == sql.ErrNoRows {
 			// Skip run since its function doesn't exist. This can happen when
 			// deleting a function or changing its ID.
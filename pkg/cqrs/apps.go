@@ -24,6 +24,8 @@ type App struct {
 	Url         string
 	Method      string
 	AppVersion  string
+	// EnvID is the environment the app is registered within.
+	EnvID uuid.UUID
 }
 
 type AppManager interface {
@@ -71,6 +73,7 @@ type UpsertAppParams struct {
 	Url         string
 	Method      string
 	AppVersion  string
+	EnvID       uuid.UUID
 }
 
 type UpdateAppErrorParams struct {
@@ -0,0 +1,92 @@
+package cqrs
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+)
+
+// AuditAction is an administrative action recorded in the audit log.
+type AuditAction string
+
+const (
+	AuditActionRunCancel          AuditAction = "run.cancel"
+	AuditActionRunRerun           AuditAction = "run.rerun"
+	AuditActionFunctionInvoke     AuditAction = "function.invoke"
+	AuditActionCancellationCreate AuditAction = "cancellation.create"
+	AuditActionCancellationDelete AuditAction = "cancellation.delete"
+	AuditActionAppCreate          AuditAction = "app.create"
+	AuditActionAppUpdate          AuditAction = "app.update"
+	AuditActionAppDelete          AuditAction = "app.delete"
+	AuditActionBatchFlush         AuditAction = "batch.flush"
+	AuditActionBatchDelete        AuditAction = "batch.delete"
+	AuditActionSignalSend         AuditAction = "signal.send"
+)
+
+// AuditEntry records a single administrative action, eg. cancelling a run.
+// Entries are append-only and are never updated or deleted.
+type AuditEntry struct {
+	ID          ulid.ULID `json:"id"`
+	AccountID   uuid.UUID `json:"account_id"`
+	WorkspaceID uuid.UUID `json:"environment_id"`
+
+	// Actor is the principal which performed the action, eg. an API token name
+	// or a user's email, or "anonymous" if auth is disabled.
+	Actor string `json:"actor"`
+	// ActorMethod is how the actor authenticated, eg. "token" or "oidc".
+	ActorMethod string `json:"actor_method"`
+	// Source is the API used to perform the action: "rest", "graphql" or
+	// "devserver".
+	Source string `json:"source"`
+
+	Action AuditAction `json:"action"`
+	// TargetIDs are the IDs of the resources acted upon, eg. run IDs.
+	TargetIDs []string `json:"target_ids"`
+	// PayloadHash is the hex-encoded SHA-256 hash of the request payload,
+	// allowing payloads to be verified without storing potentially sensitive
+	// data.
+	PayloadHash string `json:"payload_hash"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AuditLogReadWriter interface {
+	AuditLogWriter
+	AuditLogReader
+}
+
+type AuditLogWriter interface {
+	// InsertAuditEntry appends an entry to the audit log.
+	InsertAuditEntry(ctx context.Context, e *AuditEntry) error
+}
+
+type AuditLogReader interface {
+	// GetAuditEntries returns audit log entries matching the given options.
+	GetAuditEntries(ctx context.Context, opt GetAuditEntriesOpt) ([]*AuditEntry, error)
+}
+
+// GetAuditEntriesOpt filters and paginates audit log entries.
+type GetAuditEntriesOpt struct {
+	WorkspaceID uuid.UUID
+
+	// Actor, if set, only returns entries performed by the given actor.
+	Actor string
+	// Actions, if set, only returns entries with the given actions.
+	Actions []AuditAction
+	// TargetID, if set, only returns entries acting upon the given ID.
+	TargetID string
+	// From and Until bound the entries' creation time.  Until is exclusive.
+	From  time.Time
+	Until time.Time
+
+	// Cursor, if set, only returns entries after the given entry ID, in the
+	// order of the results.
+	Cursor *ulid.ULID
+	// Ascending returns the oldest entries first.  By default, the newest
+	// entries are returned first.
+	Ascending bool
+	// Items is the maximum number of entries to return.
+	Items uint
+}
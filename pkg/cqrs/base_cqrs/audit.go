@@ -0,0 +1,218 @@
+package base_cqrs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	sq "github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/oklog/ulid/v2"
+)
+
+//
+// Audit log
+//
+
+func (w wrapper) InsertAuditEntry(ctx context.Context, e *cqrs.AuditEntry) error {
+	targets := e.TargetIDs
+	if targets == nil {
+		targets = []string{}
+	}
+	byt, err := json.Marshal(targets)
+	if err != nil {
+		return fmt.Errorf("error marshalling audit targets: %w", err)
+	}
+
+	query, args, err := sq.Dialect(w.dialect()).
+		Insert("audit_log").
+		Rows(sq.Record{
+			"id":           e.ID.String(),
+			"account_id":   e.AccountID.String(),
+			"workspace_id": e.WorkspaceID.String(),
+			"actor":        e.Actor,
+			"actor_method": e.ActorMethod,
+			"source":       e.Source,
+			"action":       string(e.Action),
+			"target_ids":   string(byt),
+			"payload_hash": e.PayloadHash,
+			"created_at":   e.CreatedAt.UnixMilli(),
+		}).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	if w.tx != nil {
+		_, err = w.tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = w.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		return fmt.Errorf("error inserting audit entry: %w", err)
+	}
+	return nil
+}
+
+// auditTargetPageSize is the number of entries read at a time when filtering
+// by target without a limit.
+const auditTargetPageSize = 100
+
+// likeEscaper escapes LIKE wildcards, using backslash as the escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (w wrapper) GetAuditEntries(ctx context.Context, opt cqrs.GetAuditEntriesOpt) ([]*cqrs.AuditEntry, error) {
+	filter := []sq.Expression{
+		sq.C("workspace_id").Eq(opt.WorkspaceID.String()),
+	}
+	if opt.Actor != "" {
+		filter = append(filter, sq.C("actor").Eq(opt.Actor))
+	}
+	if len(opt.Actions) > 0 {
+		actions := make([]string, len(opt.Actions))
+		for n, a := range opt.Actions {
+			actions[n] = string(a)
+		}
+		filter = append(filter, sq.C("action").In(actions))
+	}
+	if opt.TargetID != "" {
+		// Targets are stored as a JSON array, so match the JSON-encoded
+		// target.  This is refined below to exclude any partial matches.
+		enc, err := json.Marshal(opt.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		pattern := "%" + likeEscaper.Replace(string(enc)) + "%"
+		filter = append(filter, sq.L(`target_ids LIKE ? ESCAPE '\'`, pattern))
+	}
+	if !opt.From.IsZero() {
+		filter = append(filter, sq.C("created_at").Gte(opt.From.UnixMilli()))
+	}
+	if !opt.Until.IsZero() {
+		filter = append(filter, sq.C("created_at").Lt(opt.Until.UnixMilli()))
+	}
+
+	// Filtering by target may discard rows, so read pages until enough
+	// entries match or there are no more rows.
+	limit := opt.Items
+	if limit == 0 && opt.TargetID != "" {
+		limit = auditTargetPageSize
+	}
+
+	res := []*cqrs.AuditEntry{}
+	cursor := opt.Cursor
+	for {
+		page, err := w.auditPage(ctx, filter, cursor, opt.Ascending, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range page {
+			if opt.TargetID != "" && !slices.Contains(e.TargetIDs, opt.TargetID) {
+				continue
+			}
+			res = append(res, e)
+			if opt.Items > 0 && uint(len(res)) >= opt.Items {
+				return res, nil
+			}
+		}
+		if limit == 0 || uint(len(page)) < limit {
+			return res, nil
+		}
+		cursor = &page[len(page)-1].ID
+	}
+}
+
+// auditPage returns up to limit entries matching filter after the given
+// cursor, or all entries if limit is zero.
+func (w wrapper) auditPage(ctx context.Context, filter []sq.Expression, cursor *ulid.ULID, ascending bool, limit uint) ([]*cqrs.AuditEntry, error) {
+	// IDs are ULIDs, so ordering by ID orders entries by creation time.
+	order := sq.C("id").Desc()
+	if ascending {
+		order = sq.C("id").Asc()
+	}
+	if cursor != nil {
+		if ascending {
+			filter = append(slices.Clip(filter), sq.C("id").Gt(cursor.String()))
+		} else {
+			filter = append(slices.Clip(filter), sq.C("id").Lt(cursor.String()))
+		}
+	}
+
+	builder := sq.Dialect(w.dialect()).
+		From("audit_log").
+		Select(
+			"id",
+			"account_id",
+			"workspace_id",
+			"actor",
+			"actor_method",
+			"source",
+			"action",
+			"target_ids",
+			"payload_hash",
+			"created_at",
+		).
+		Where(filter...).
+		Order(order)
+	if limit > 0 {
+		builder = builder.Limit(limit)
+	}
+
+	query, args, err := builder.Prepared(true).ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := w.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []*cqrs.AuditEntry{}
+	for rows.Next() {
+		var (
+			id, accountID, workspaceID, action, targets string
+			createdAt                                   int64
+			e                                           = &cqrs.AuditEntry{}
+		)
+		err := rows.Scan(
+			&id,
+			&accountID,
+			&workspaceID,
+			&e.Actor,
+			&e.ActorMethod,
+			&e.Source,
+			&action,
+			&targets,
+			&e.PayloadHash,
+			&createdAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if e.ID, err = ulid.Parse(id); err != nil {
+			return nil, fmt.Errorf("invalid audit entry id: %w", err)
+		}
+		if e.AccountID, err = uuid.Parse(accountID); err != nil {
+			return nil, fmt.Errorf("invalid audit entry account id: %w", err)
+		}
+		if e.WorkspaceID, err = uuid.Parse(workspaceID); err != nil {
+			return nil, fmt.Errorf("invalid audit entry workspace id: %w", err)
+		}
+		if err := json.Unmarshal([]byte(targets), &e.TargetIDs); err != nil {
+			return nil, fmt.Errorf("invalid audit entry targets: %w", err)
+		}
+		e.Action = cqrs.AuditAction(action)
+		e.CreatedAt = time.UnixMilli(createdAt)
+
+		res = append(res, e)
+	}
+	return res, rows.Err()
+}
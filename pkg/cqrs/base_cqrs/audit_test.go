@@ -0,0 +1,128 @@
+package base_cqrs
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := New(BaseCQRSOptions{InMemory: true})
+	require.NoError(t, err)
+	mgr := NewCQRS(db, "sqlite")
+
+	wsID := uuid.New()
+	now := time.Now().Truncate(time.Millisecond)
+
+	entries := []*cqrs.AuditEntry{
+		{Actor: "ci", Action: cqrs.AuditActionRunCancel, TargetIDs: []string{"run-1"}},
+		{Actor: "jane@example.com", Action: cqrs.AuditActionAppDelete, TargetIDs: []string{"app-1"}},
+		{Actor: "ci", Action: cqrs.AuditActionRunRerun, TargetIDs: []string{"run-10", "run-11"}},
+	}
+	for n, e := range entries {
+		ts := now.Add(time.Duration(n) * time.Second)
+		e.ID = ulid.MustNew(ulid.Timestamp(ts), rand.Reader)
+		e.AccountID = uuid.New()
+		e.WorkspaceID = wsID
+		e.ActorMethod = "token"
+		e.Source = "rest"
+		e.PayloadHash = "abc"
+		e.CreatedAt = ts
+		require.NoError(t, mgr.InsertAuditEntry(ctx, e))
+	}
+
+	t.Run("newest first", func(t *testing.T) {
+		res, err := mgr.GetAuditEntries(ctx, cqrs.GetAuditEntriesOpt{WorkspaceID: wsID})
+		require.NoError(t, err)
+		require.Len(t, res, 3)
+		require.Equal(t, entries[2], res[0])
+		require.Equal(t, entries[0], res[2])
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		res, err := mgr.GetAuditEntries(ctx, cqrs.GetAuditEntriesOpt{WorkspaceID: wsID, Actor: "ci"})
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+
+		res, err = mgr.GetAuditEntries(ctx, cqrs.GetAuditEntriesOpt{WorkspaceID: wsID, Actions: []cqrs.AuditAction{cqrs.AuditActionAppDelete}})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Equal(t, entries[1].ID, res[0].ID)
+
+		// Target IDs must match exactly.
+		res, err = mgr.GetAuditEntries(ctx, cqrs.GetAuditEntriesOpt{WorkspaceID: wsID, TargetID: "run-1"})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Equal(t, entries[0].ID, res[0].ID)
+
+		res, err = mgr.GetAuditEntries(ctx, cqrs.GetAuditEntriesOpt{WorkspaceID: wsID, From: now.Add(time.Second), Until: now.Add(2 * time.Second)})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Equal(t, entries[1].ID, res[0].ID)
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		res, err := mgr.GetAuditEntries(ctx, cqrs.GetAuditEntriesOpt{WorkspaceID: wsID, Ascending: true, Items: 2})
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+		require.Equal(t, entries[0].ID, res[0].ID)
+
+		res, err = mgr.GetAuditEntries(ctx, cqrs.GetAuditEntriesOpt{WorkspaceID: wsID, Ascending: true, Cursor: &res[1].ID})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Equal(t, entries[2].ID, res[0].ID)
+	})
+
+	t.Run("targets containing wildcards", func(t *testing.T) {
+		wsID := uuid.New()
+		targets := [][]string{{"a_b"}, {"axb"}, {"a%b"}, {"axb", "a_b"}}
+		for n, ids := range targets {
+			ts := now.Add(time.Duration(n) * time.Second)
+			require.NoError(t, mgr.InsertAuditEntry(ctx, &cqrs.AuditEntry{
+				ID:          ulid.MustNew(ulid.Timestamp(ts), rand.Reader),
+				AccountID:   uuid.New(),
+				WorkspaceID: wsID,
+				Actor:       "ci",
+				Action:      cqrs.AuditActionRunCancel,
+				TargetIDs:   ids,
+				CreatedAt:   ts,
+			}))
+		}
+
+		res, err := mgr.GetAuditEntries(ctx, cqrs.GetAuditEntriesOpt{WorkspaceID: wsID, TargetID: "a_b", Ascending: true})
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+		require.Equal(t, []string{"a_b"}, res[0].TargetIDs)
+		require.Equal(t, []string{"axb", "a_b"}, res[1].TargetIDs)
+
+		res, err = mgr.GetAuditEntries(ctx, cqrs.GetAuditEntriesOpt{WorkspaceID: wsID, TargetID: "a%b"})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+
+		// Limits apply when filtering by target.
+		res, err = mgr.GetAuditEntries(ctx, cqrs.GetAuditEntriesOpt{WorkspaceID: wsID, TargetID: "axb", Items: 1})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Equal(t, []string{"axb", "a_b"}, res[0].TargetIDs)
+
+		res, err = mgr.GetAuditEntries(ctx, cqrs.GetAuditEntriesOpt{WorkspaceID: wsID, TargetID: "axb", Items: 1, Cursor: &res[0].ID})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Equal(t, []string{"axb"}, res[0].TargetIDs)
+	})
+
+	t.Run("append only", func(t *testing.T) {
+		_, err := db.ExecContext(ctx, "UPDATE audit_log SET actor = 'mallory' WHERE workspace_id = ?", wsID.String())
+		require.ErrorContains(t, err, "append-only")
+		_, err = db.ExecContext(ctx, "DELETE FROM audit_log WHERE workspace_id = ?", wsID.String())
+		require.ErrorContains(t, err, "append-only")
+	})
+}
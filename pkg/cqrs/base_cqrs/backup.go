@@ -0,0 +1,535 @@
+package base_cqrs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	sq "github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/inngest/inngest/pkg/cqrs"
+	sqlc_postgres "github.com/inngest/inngest/pkg/cqrs/base_cqrs/sqlc/postgres"
+	sqlc "github.com/inngest/inngest/pkg/cqrs/base_cqrs/sqlc/sqlite"
+)
+
+// backupPageSize is the number of rows loaded at once when exporting.
+const backupPageSize = 500
+
+// backupTable is a table included within backups.  Rows are exported as the
+// SQLite models returned by the normalized queries regardless of the driver,
+// and are restored via the same queries, so that backups are portable between
+// SQLite and Postgres.
+type backupTable struct {
+	name string
+	// order lists the columns used to order rows when paginating.
+	order []string
+	// export loads a page of rows, returning each row as its SQLite model.
+	export exportFunc
+	// restore inserts a single exported row.
+	restore func(ctx context.Context, r *Restore, row []byte) error
+}
+
+// exportFunc loads a page of rows from the given table, ordered by the given
+// columns.
+type exportFunc func(ctx context.Context, db *sql.DB, dialect, table string, order []string, offset uint) ([]any, error)
+
+// auditRow is a row within the audit log, which is stored identically by each
+// driver.
+type auditRow struct {
+	ID          string
+	AccountID   string
+	WorkspaceID string
+	Actor       string
+	ActorMethod string
+	Source      string
+	Action      string
+	TargetIds   string
+	PayloadHash string
+	CreatedAt   int64
+}
+
+// backupTables lists every table included within backups, in the order in
+// which they're restored.  Queue snapshots are excluded:  queue and run state
+// is backed up separately, as it may be stored in an external Redis.
+var backupTables = []backupTable{
+	{
+		name:   "apps",
+		order:  []string{"id"},
+		export: exportRows((*sqlc_postgres.App).ToSQLite),
+		restore: restoreRow(func(ctx context.Context, r *Restore, a *sqlc.App) error {
+			_, err := r.q.UpsertApp(ctx, sqlc.UpsertAppParams{
+				ID:          a.ID,
+				Name:        a.Name,
+				SdkLanguage: a.SdkLanguage,
+				SdkVersion:  a.SdkVersion,
+				Framework:   a.Framework,
+				Metadata:    a.Metadata,
+				Status:      a.Status,
+				Error:       a.Error,
+				Checksum:    a.Checksum,
+				Url:         a.Url,
+				Method:      a.Method,
+				AppVersion:  a.AppVersion,
+				EnvID:       a.EnvID,
+			})
+			if err != nil {
+				return err
+			}
+			// Upserting an app doesn't set its creation or archival time.
+			return r.update(ctx, "apps", sq.Ex{"id": a.ID}, sq.Record{
+				"created_at":  a.CreatedAt,
+				"archived_at": a.ArchivedAt,
+			})
+		}),
+	},
+	{
+		name:   "functions",
+		order:  []string{"id"},
+		export: exportRows((*sqlc_postgres.Function).ToSQLite),
+		restore: restoreRow(func(ctx context.Context, r *Restore, f *sqlc.Function) error {
+			_, err := r.q.InsertFunction(ctx, sqlc.InsertFunctionParams{
+				ID:        f.ID,
+				AppID:     f.AppID,
+				Name:      f.Name,
+				Slug:      f.Slug,
+				Config:    f.Config,
+				CreatedAt: f.CreatedAt,
+			})
+			if err != nil || !f.ArchivedAt.Valid {
+				return err
+			}
+			return r.update(ctx, "functions", sq.Ex{"id": f.ID}, sq.Record{"archived_at": f.ArchivedAt})
+		}),
+	},
+	{
+		name:   "events",
+		order:  []string{"internal_id"},
+		export: exportRows((*sqlc_postgres.Event).ToSQLite),
+		restore: restoreRow(func(ctx context.Context, r *Restore, e *sqlc.Event) error {
+			return r.q.InsertEvent(ctx, sqlc.InsertEventParams{
+				InternalID:  e.InternalID,
+				ReceivedAt:  e.ReceivedAt,
+				EventID:     e.EventID,
+				EventName:   e.EventName,
+				EventData:   e.EventData,
+				EventUser:   e.EventUser,
+				EventV:      e.EventV,
+				EventTs:     e.EventTs,
+				AccountID:   e.AccountID,
+				WorkspaceID: e.WorkspaceID,
+			})
+		}),
+	},
+	{
+		name:   "event_batches",
+		order:  []string{"id"},
+		export: exportRows((*sqlc_postgres.EventBatch).ToSQLite),
+		restore: restoreRow(func(ctx context.Context, r *Restore, b *sqlc.EventBatch) error {
+			return r.q.InsertEventBatch(ctx, sqlc.InsertEventBatchParams(*b))
+		}),
+	},
+	{
+		name:   "function_runs",
+		order:  []string{"run_id"},
+		export: exportRows((*sqlc_postgres.FunctionRun).ToSQLite),
+		restore: restoreRow(func(ctx context.Context, r *Restore, run *sqlc.FunctionRun) error {
+			return r.q.InsertFunctionRun(ctx, sqlc.InsertFunctionRunParams(*run))
+		}),
+	},
+	{
+		name:   "function_finishes",
+		order:  []string{"run_id"},
+		export: exportRows((*sqlc_postgres.FunctionFinish).ToSQLite),
+		restore: restoreRow(func(ctx context.Context, r *Restore, f *sqlc.FunctionFinish) error {
+			return r.q.InsertFunctionFinish(ctx, sqlc.InsertFunctionFinishParams(*f))
+		}),
+	},
+	{
+		name:   "history",
+		order:  []string{"id"},
+		export: exportRows((*sqlc_postgres.History).ToSQLite),
+		restore: restoreRow(func(ctx context.Context, r *Restore, h *sqlc.History) error {
+			return r.q.InsertHistory(ctx, sqlc.InsertHistoryParams(*h))
+		}),
+	},
+	{
+		name:   "trace_runs",
+		order:  []string{"run_id"},
+		export: exportRows((*sqlc_postgres.TraceRun).ToSQLite),
+		restore: restoreRow(func(ctx context.Context, r *Restore, t *sqlc.TraceRun) error {
+			return r.q.InsertTraceRun(ctx, sqlc.InsertTraceRunParams{
+				RunID:        t.RunID,
+				AccountID:    t.AccountID,
+				WorkspaceID:  t.WorkspaceID,
+				AppID:        t.AppID,
+				FunctionID:   t.FunctionID,
+				TraceID:      t.TraceID,
+				QueuedAt:     t.QueuedAt,
+				StartedAt:    t.StartedAt,
+				EndedAt:      t.EndedAt,
+				Status:       t.Status,
+				SourceID:     t.SourceID,
+				TriggerIds:   t.TriggerIds,
+				Output:       t.Output,
+				BatchID:      t.BatchID,
+				IsDebounce:   t.IsDebounce,
+				CronSchedule: t.CronSchedule,
+				HasAi:        t.HasAi,
+			})
+		}),
+	},
+	{
+		name:   "traces",
+		order:  []string{"timestamp_unix_ms", "trace_id", "span_id"},
+		export: exportRows((*sqlc_postgres.Trace).ToSQLite),
+		restore: restoreRow(func(ctx context.Context, r *Restore, t *sqlc.Trace) error {
+			return r.q.InsertTrace(ctx, sqlc.InsertTraceParams(*t))
+		}),
+	},
+	{
+		name:   "worker_connections",
+		order:  []string{"id", "app_name"},
+		export: exportRows((*sqlc_postgres.WorkerConnection).ToSQLite),
+		restore: restoreRow(func(ctx context.Context, r *Restore, wc *sqlc.WorkerConnection) error {
+			return r.q.InsertWorkerConnection(ctx, sqlc.InsertWorkerConnectionParams(*wc))
+		}),
+	},
+	{
+		name:  "audit_log",
+		order: []string{"id"},
+		export: func(ctx context.Context, db *sql.DB, dialect, table string, order []string, offset uint) ([]any, error) {
+			rows, err := selectRows[auditRow](ctx, db, dialect, table, order, offset)
+			return toAny(rows), err
+		},
+		restore: restoreRow(func(ctx context.Context, r *Restore, a *auditRow) error {
+			query, args, err := sq.Dialect(r.dialect).
+				Insert("audit_log").
+				Rows(recordOf(a)).
+				Prepared(true).
+				ToSQL()
+			if err != nil {
+				return err
+			}
+			_, err = r.tx.ExecContext(ctx, query, args...)
+			return err
+		}),
+	},
+}
+
+// BackupTables returns the name of every table included within backups, in the
+// order in which they're restored.
+func BackupTables() []string {
+	names := make([]string, len(backupTables))
+	for n, t := range backupTables {
+		names[n] = t.name
+	}
+	return names
+}
+
+func findBackupTable(name string) (backupTable, error) {
+	for _, t := range backupTables {
+		if t.name == name {
+			return t, nil
+		}
+	}
+	return backupTable{}, fmt.Errorf("unknown table: %s", name)
+}
+
+// ExportTable calls f with each row within the given table, encoded as JSON.
+//
+// Data encrypted at rest is exported as-is, and can only be read using the same
+// encryption keys.
+func ExportTable(ctx context.Context, db *sql.DB, driver, table string, f func(row []byte) error) error {
+	t, err := findBackupTable(table)
+	if err != nil {
+		return err
+	}
+
+	for offset := uint(0); ; offset += backupPageSize {
+		rows, err := t.export(ctx, db, dialect(driver), t.name, t.order, offset)
+		if err != nil {
+			return fmt.Errorf("error exporting %s: %w", table, err)
+		}
+		for _, row := range rows {
+			byt, err := json.Marshal(row)
+			if err != nil {
+				return fmt.Errorf("error marshalling %s row: %w", table, err)
+			}
+			if err := f(byt); err != nil {
+				return err
+			}
+		}
+		if len(rows) < backupPageSize {
+			return nil
+		}
+	}
+}
+
+// Restore imports rows exported via ExportTable within a single transaction,
+// which must be committed via Commit.
+type Restore struct {
+	tx      *sql.Tx
+	q       sqlc.Querier
+	dialect string
+}
+
+// NewRestore starts a transaction used to restore rows into the given database.
+func NewRestore(ctx context.Context, db *sql.DB, driver string) (*Restore, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+
+	var q sqlc.Querier
+	if driver == "postgres" {
+		q = sqlc_postgres.NewNormalized(tx)
+	} else {
+		q = sqlc.New(tx)
+	}
+	return &Restore{tx: tx, q: q, dialect: dialect(driver)}, nil
+}
+
+// Insert inserts a single row exported from the given table.
+func (r *Restore) Insert(ctx context.Context, table string, row []byte) error {
+	t, err := findBackupTable(table)
+	if err != nil {
+		return err
+	}
+	if err := t.restore(ctx, r, row); err != nil {
+		return fmt.Errorf("error restoring %s row: %w", table, err)
+	}
+	return nil
+}
+
+// Count returns the number of rows within the given table.
+func (r *Restore) Count(ctx context.Context, table string) (int, error) {
+	query, args, err := sq.Dialect(r.dialect).From(table).Select(sq.COUNT(sq.Star())).ToSQL()
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	if err := r.tx.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("error counting %s: %w", table, err)
+	}
+	return n, nil
+}
+
+// QueueSnapshots returns the number of queue snapshots stored.
+func (r *Restore) QueueSnapshots(ctx context.Context) (int, error) {
+	query, args, err := sq.Dialect(r.dialect).
+		From("queue_snapshot_chunks").
+		Select(sq.COUNT(sq.DISTINCT("snapshot_id"))).
+		ToSQL()
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	if err := r.tx.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("error counting queue snapshots: %w", err)
+	}
+	return n, nil
+}
+
+// OrphanedFunctions returns the IDs of functions whose app doesn't exist.
+func (r *Restore) OrphanedFunctions(ctx context.Context) ([]string, error) {
+	query, args, err := sq.Dialect(r.dialect).
+		From(sq.T("functions").As("f")).
+		LeftJoin(sq.T("apps").As("a"), sq.On(sq.I("a.id").Eq(sq.I("f.app_id")))).
+		Select(sq.I("f.id")).
+		Where(sq.I("a.id").IsNull()).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error finding orphaned functions: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// InsertQueueSnapshot stores the given snapshot, which is restored into the
+// in-memory Redis when starting.
+func (r *Restore) InsertQueueSnapshot(ctx context.Context, snapshot cqrs.QueueSnapshot) (cqrs.SnapshotID, error) {
+	snapshotID, chunks, err := queueSnapshotChunks(snapshot)
+	if err != nil {
+		return snapshotID, err
+	}
+	for i, chunk := range chunks {
+		err := r.q.InsertQueueSnapshotChunk(ctx, sqlc.InsertQueueSnapshotChunkParams{
+			SnapshotID: snapshotID.String(),
+			ChunkID:    int64(i),
+			Data:       chunk,
+		})
+		if err != nil {
+			return snapshotID, fmt.Errorf("error inserting queue snapshot chunk: %w", err)
+		}
+	}
+	return snapshotID, nil
+}
+
+// Commit commits every restored row.
+func (r *Restore) Commit() error {
+	return r.tx.Commit()
+}
+
+// Rollback discards every restored row.  This is a no-op after Commit.
+func (r *Restore) Rollback() error {
+	err := r.tx.Rollback()
+	if err == sql.ErrTxDone {
+		return nil
+	}
+	return err
+}
+
+func (r *Restore) update(ctx context.Context, table string, where sq.Ex, record sq.Record) error {
+	query, args, err := sq.Dialect(r.dialect).
+		Update(table).
+		Set(record).
+		Where(where).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+	_, err = r.tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func dialect(driver string) string {
+	if driver == "postgres" {
+		return "postgres"
+	}
+	return "sqlite3"
+}
+
+// exportRows returns an export func which loads rows into the driver's model,
+// converting Postgres models to their SQLite equivalent.
+func exportRows[S any, P any](toSQLite func(*P) (*S, error)) exportFunc {
+	return func(ctx context.Context, db *sql.DB, dialect, table string, order []string, offset uint) ([]any, error) {
+		if dialect != "postgres" {
+			rows, err := selectRows[S](ctx, db, dialect, table, order, offset)
+			return toAny(rows), err
+		}
+
+		rows, err := selectRows[P](ctx, db, dialect, table, order, offset)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]any, len(rows))
+		for n, row := range rows {
+			if result[n], err = toSQLite(row); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+}
+
+// restoreRow returns a restore func which unmarshals each row into T.
+func restoreRow[T any](f func(ctx context.Context, r *Restore, row *T) error) func(context.Context, *Restore, []byte) error {
+	return func(ctx context.Context, r *Restore, byt []byte) error {
+		row := new(T)
+		if err := json.Unmarshal(byt, row); err != nil {
+			return err
+		}
+		return f(ctx, r, row)
+	}
+}
+
+// selectRows loads a page of rows into T, whose fields must name the table's
+// columns as with sqlc's generated models.
+func selectRows[T any](ctx context.Context, db *sql.DB, dialect, table string, order []string, offset uint) ([]*T, error) {
+	typ := reflect.TypeFor[T]()
+	cols := make([]any, typ.NumField())
+	for n := range cols {
+		cols[n] = columnName(typ.Field(n).Name)
+	}
+	ordered := make([]exp.OrderedExpression, len(order))
+	for n, c := range order {
+		ordered[n] = sq.C(c).Asc()
+	}
+
+	query, args, err := sq.Dialect(dialect).
+		From(table).
+		Select(cols...).
+		Order(ordered...).
+		Limit(backupPageSize).
+		Offset(offset).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*T{}
+	for rows.Next() {
+		row := new(T)
+		v := reflect.ValueOf(row).Elem()
+		dest := make([]any, v.NumField())
+		for n := range dest {
+			dest[n] = v.Field(n).Addr().Interface()
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// recordOf returns a record containing each of the row's fields, keyed by
+// column.
+func recordOf[T any](row *T) sq.Record {
+	v := reflect.ValueOf(row).Elem()
+	record := sq.Record{}
+	for n := 0; n < v.NumField(); n++ {
+		record[columnName(v.Type().Field(n).Name)] = v.Field(n).Interface()
+	}
+	return record
+}
+
+// columnName returns the column for a model's field, eg. "internal_id" for
+// "InternalID".
+func columnName(field string) string {
+	runes := []rune(field)
+	b := strings.Builder{}
+	for n, r := range runes {
+		if unicode.IsUpper(r) && n > 0 {
+			prev := runes[n-1]
+			nextLower := n+1 < len(runes) && unicode.IsLower(runes[n+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+func toAny[T any](rows []*T) []any {
+	result := make([]any, len(rows))
+	for n, row := range rows {
+		result[n] = row
+	}
+	return result
+}
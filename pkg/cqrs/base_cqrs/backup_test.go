@@ -0,0 +1,188 @@
+package base_cqrs
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/cqrs"
+	sqlc "github.com/inngest/inngest/pkg/cqrs/base_cqrs/sqlc/sqlite"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func exportAll(t *testing.T, db *sql.DB, driver string) map[string][]string {
+	exported := map[string][]string{}
+	for _, table := range BackupTables() {
+		err := ExportTable(context.Background(), db, driver, table, func(row []byte) error {
+			exported[table] = append(exported[table], string(row))
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	return exported
+}
+
+// seedBackup inserts a row into every table included within backups, with
+// enough events to span more than a single page.
+func seedBackup(t *testing.T, src *sql.DB) {
+	ctx := context.Background()
+	q := sqlc.New(src)
+
+	now := time.Now().Truncate(time.Millisecond).UTC()
+	appID, fnID, envID := uuid.New(), uuid.New(), uuid.New()
+	_, err := q.UpsertApp(ctx, sqlc.UpsertAppParams{
+		ID:    appID,
+		Name:  "app",
+		Url:   "http://localhost:3000/api/inngest",
+		EnvID: envID,
+	})
+	require.NoError(t, err)
+	_, err = q.InsertFunction(ctx, sqlc.InsertFunctionParams{
+		ID:        fnID,
+		AppID:     appID,
+		Name:      "fn",
+		Slug:      "app-fn",
+		Config:    "{}",
+		CreatedAt: now,
+	})
+	require.NoError(t, err)
+
+	// Insert enough events to span more than a single page.
+	var eventID ulid.ULID
+	for n := 0; n < backupPageSize+10; n++ {
+		eventID = ulid.MustNew(ulid.Timestamp(now), rand.Reader)
+		require.NoError(t, q.InsertEvent(ctx, sqlc.InsertEventParams{
+			InternalID: eventID,
+			ReceivedAt: now,
+			EventID:    fmt.Sprintf("evt-%d", n),
+			EventName:  "test/event",
+			EventData:  "{}",
+			EventUser:  "{}",
+			EventTs:    now,
+		}))
+	}
+
+	runID := ulid.MustNew(ulid.Timestamp(now), rand.Reader)
+	require.NoError(t, q.InsertFunctionRun(ctx, sqlc.InsertFunctionRunParams{
+		RunID:        runID,
+		RunStartedAt: now,
+		FunctionID:   fnID,
+		TriggerType:  "event",
+		EventID:      eventID,
+	}))
+	require.NoError(t, q.InsertTraceRun(ctx, sqlc.InsertTraceRunParams{
+		RunID:      runID,
+		AppID:      appID,
+		FunctionID: fnID,
+		TraceID:    []byte("trace"),
+		QueuedAt:   now.UnixMilli(),
+		Status:     200,
+		TriggerIds: []byte(eventID.String()),
+	}))
+	require.NoError(t, q.InsertTrace(ctx, sqlc.InsertTraceParams{
+		Timestamp:          now,
+		TimestampUnixMs:    now.UnixMilli(),
+		TraceID:            "trace",
+		SpanID:             "span",
+		SpanName:           "function.run",
+		ResourceAttributes: []byte("{}"),
+		SpanAttributes:     []byte("{}"),
+		Events:             []byte("[]"),
+		Links:              []byte("[]"),
+		RunID:              runID,
+	}))
+	require.NoError(t, NewCQRS(src, "sqlite").InsertAuditEntry(ctx, &cqrs.AuditEntry{
+		ID:        ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+		Actor:     "ci",
+		Action:    cqrs.AuditActionRunCancel,
+		TargetIDs: []string{runID.String()},
+		CreatedAt: now,
+	}))
+	_, err = src.ExecContext(ctx, "UPDATE apps SET archived_at = ? WHERE id = ?", now, appID)
+	require.NoError(t, err)
+}
+
+// restoreAll restores every exported row, and a queue snapshot, into dst.
+func restoreAll(t *testing.T, dst *sql.DB, driver string, exported map[string][]string) {
+	ctx := context.Background()
+
+	r, err := NewRestore(ctx, dst, driver)
+	require.NoError(t, err)
+	for _, table := range BackupTables() {
+		for _, row := range exported[table] {
+			require.NoError(t, r.Insert(ctx, table, []byte(row)))
+		}
+		n, err := r.Count(ctx, table)
+		require.NoError(t, err)
+		require.Equal(t, len(exported[table]), n, table)
+	}
+	orphaned, err := r.OrphanedFunctions(ctx)
+	require.NoError(t, err)
+	require.Empty(t, orphaned)
+
+	_, err = r.InsertQueueSnapshot(ctx, cqrs.QueueSnapshot{"key": {Type: "string", Value: "value"}})
+	require.NoError(t, err)
+	snapshots, err := r.QueueSnapshots(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, snapshots)
+	require.NoError(t, r.Commit())
+
+	snapshot, err := NewCQRS(dst, driver).GetLatestQueueSnapshot(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "value", (*snapshot)["key"].Value)
+}
+
+func TestBackupRoundtrip(t *testing.T) {
+	src, err := NewIsolated("backup-src")
+	require.NoError(t, err)
+	seedBackup(t, src)
+
+	exported := exportAll(t, src, "sqlite")
+	require.Len(t, exported["events"], backupPageSize+10)
+
+	dst, err := NewIsolated("backup-dst")
+	require.NoError(t, err)
+	restoreAll(t, dst, "sqlite", exported)
+
+	require.Equal(t, exported, exportAll(t, dst, "sqlite"))
+}
+
+// TestBackupRoundtripPostgres restores a SQLite backup into Postgres, then
+// exports it again.  It only runs when INNGEST_POSTGRES_URI is set, and
+// removes all data within the database.
+func TestBackupRoundtripPostgres(t *testing.T) {
+	uri := os.Getenv("INNGEST_POSTGRES_URI")
+	if uri == "" {
+		t.Skip("INNGEST_POSTGRES_URI not set")
+	}
+	ctx := context.Background()
+
+	src, err := NewIsolated("backup-src-postgres")
+	require.NoError(t, err)
+	seedBackup(t, src)
+	exported := exportAll(t, src, "sqlite")
+
+	dst, err := NewUnshared(BaseCQRSOptions{PostgresURI: uri})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dst.Close() })
+	truncate := func() {
+		tables := append(BackupTables(), "queue_snapshot_chunks")
+		_, err := dst.ExecContext(ctx, "TRUNCATE "+strings.Join(tables, ", "))
+		require.NoError(t, err)
+	}
+	truncate()
+	t.Cleanup(truncate)
+
+	restoreAll(t, dst, "postgres", exported)
+
+	// Postgres rows are exported as their SQLite equivalent, so the backup is
+	// unchanged.
+	require.Equal(t, exported, exportAll(t, dst, "postgres"))
+}
@@ -43,57 +43,109 @@ func New(opts BaseCQRSOptions) (*sql.DB, error) {
 		if !strings.HasPrefix(opts.PostgresURI, "postgres://") && !strings.HasPrefix(opts.PostgresURI, "postgresql://") {
 			return nil, fmt.Errorf("unsupported database URL: %s", opts.PostgresURI)
 		}
+	}
 
-		o.Do(func() {
-			db, err = sql.Open("pgx", opts.PostgresURI)
-		})
-	} else if opts.InMemory {
-		o.Do(func() {
-			db, err = sql.Open("sqlite", "file:inngest?mode=memory&cache=shared")
-		})
-	} else {
-		o.Do(func() {
-			// make the dir if it doesn't exist
-			dir := consts.DefaultInngestConfigDir
-			if opts.Directory != "" {
-				dir = opts.Directory
-				if !filepath.IsAbs(opts.Directory) {
-					wd, err := os.Getwd()
-					if err != nil {
-						return
-					}
-
-					dir = filepath.Join(wd, opts.Directory)
-				}
-			}
+	o.Do(func() {
+		db, err = open(opts)
+	})
+	if err != nil {
+		return nil, err
+	}
 
-			if _, err := os.Stat(dir); os.IsNotExist(err) {
-				err = os.MkdirAll(dir, 0750)
-				if err != nil {
-					return
-				}
-			}
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
 
-			file := filepath.Join(dir, consts.SQLiteDbFileName)
+	// Run migrations.
+	if err := up(db, opts); err != nil {
+		return nil, err
+	}
 
-			db, err = sql.Open("sqlite", fmt.Sprintf("file:%s?cache=shared", file))
-		})
+	return db, err
+}
+
+// NewUnshared opens a database using the given options and runs all
+// migrations.  Unlike New, each call opens a new connection pool which must
+// be closed by the caller, allowing a database to be closed and reopened
+// within a single process.
+func NewUnshared(opts BaseCQRSOptions) (*sql.DB, error) {
+	if opts.PostgresURI != "" {
+		if !strings.HasPrefix(opts.PostgresURI, "postgres://") && !strings.HasPrefix(opts.PostgresURI, "postgresql://") {
+			return nil, fmt.Errorf("unsupported database URL: %s", opts.PostgresURI)
+		}
 	}
 
+	db, err := open(opts)
 	if err != nil {
 		return nil, err
 	}
 
 	if err := db.Ping(); err != nil {
+		_ = db.Close()
 		return nil, err
 	}
 
-	// Run migrations.
 	if err := up(db, opts); err != nil {
+		_ = db.Close()
 		return nil, err
 	}
 
-	return db, err
+	return db, nil
+}
+
+// open opens the database for the given options, creating the SQLite
+// database's directory if necessary.
+func open(opts BaseCQRSOptions) (*sql.DB, error) {
+	if opts.PostgresURI != "" {
+		return sql.Open("pgx", opts.PostgresURI)
+	}
+	if opts.InMemory {
+		return sql.Open("sqlite", "file:inngest?mode=memory&cache=shared")
+	}
+
+	// make the dir if it doesn't exist
+	dir := consts.DefaultInngestConfigDir
+	if opts.Directory != "" {
+		dir = opts.Directory
+		if !filepath.IsAbs(opts.Directory) {
+			wd, err := os.Getwd()
+			if err != nil {
+				return nil, err
+			}
+
+			dir = filepath.Join(wd, opts.Directory)
+		}
+	}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return nil, err
+		}
+	}
+
+	file := filepath.Join(dir, consts.SQLiteDbFileName)
+
+	return sql.Open("sqlite", fmt.Sprintf("file:%s?cache=shared", file))
+}
+
+// NewIsolated opens a new in-memory SQLite database with the given name and
+// runs all migrations.  Unlike New, each name opens a separate database,
+// allowing many instances within a single process, eg. in tests.
+func NewIsolated(name string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?mode=memory&cache=shared", name))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+
+	if err := up(db, BaseCQRSOptions{InMemory: true}); err != nil {
+		return nil, err
+	}
+
+	return db, nil
 }
 
 // FS contains the filesystem of the stdlib, containing all migrations in subdirs
@@ -2,7 +2,6 @@ package base_cqrs
 
 import (
 	"context"
-	"crypto/rand"
 	"database/sql"
 	"encoding/base64"
 	"encoding/json"
@@ -54,21 +53,33 @@ func NewQueries(db *sql.DB, driver string) (q sqlc.Querier) {
 	return q
 }
 
-func NewCQRS(db *sql.DB, driver string) cqrs.Manager {
+func NewCQRS(db *sql.DB, driver string, opts ...Opt) cqrs.Manager {
 	return wrapper{
-		driver: driver,
-		q:      NewQueries(db, driver),
-		db:     db,
+		driver:  driver,
+		q:       NewQueries(db, driver),
+		db:      db,
+		crypter: newCrypter(opts),
 	}
 }
 
 type wrapper struct {
+	crypter
+
 	driver string
 	q      sqlc.Querier
 	db     *sql.DB
 	tx     *sql.Tx
 }
 
+// envOrDefault returns the given environment ID, or the default environment's
+// ID if none is set.
+func envOrDefault(envID uuid.UUID) uuid.UUID {
+	if envID == uuid.Nil {
+		return consts.DevServerEnvID
+	}
+	return envID
+}
+
 func (w wrapper) isPostgres() bool {
 	return w.driver == "postgres"
 }
@@ -117,8 +128,9 @@ func (w wrapper) WithTx(ctx context.Context) (cqrs.TxManager, error) {
 	}
 
 	return &wrapper{
-		q:  q,
-		tx: tx,
+		q:       q,
+		tx:      tx,
+		crypter: w.crypter,
 	}, nil
 }
 
@@ -154,7 +166,7 @@ func (w wrapper) GetLatestQueueSnapshot(ctx context.Context) (*cqrs.QueueSnapsho
 }
 
 func (w wrapper) GetQueueSnapshot(ctx context.Context, snapshotID cqrs.SnapshotID) (*cqrs.QueueSnapshot, error) {
-	chunks, err := w.q.GetQueueSnapshotChunks(ctx, snapshotID)
+	chunks, err := w.q.GetQueueSnapshotChunks(ctx, snapshotID.String())
 	if err != nil {
 		return nil, fmt.Errorf("error getting queue snapshot: %w", err)
 	}
@@ -177,26 +189,11 @@ func (w wrapper) GetQueueSnapshot(ctx context.Context, snapshotID cqrs.SnapshotI
 }
 
 func (w wrapper) InsertQueueSnapshot(ctx context.Context, params cqrs.InsertQueueSnapshotParams) (cqrs.SnapshotID, error) {
-	var snapshotID cqrs.SnapshotID
-
-	byt, err := json.Marshal(params.Snapshot)
+	snapshotID, chunks, err := queueSnapshotChunks(params.Snapshot)
 	if err != nil {
-		return snapshotID, fmt.Errorf("error marshalling snapshot: %w", err)
-	}
-
-	var chunks [][]byte
-	for len(byt) > 0 {
-		if len(byt) > consts.StartMaxQueueChunkSize {
-			chunks = append(chunks, byt[:consts.StartMaxQueueChunkSize])
-			byt = byt[consts.StartMaxQueueChunkSize:]
-		} else {
-			chunks = append(chunks, byt)
-			break
-		}
+		return snapshotID, err
 	}
 
-	snapshotID = ulid.MustNew(ulid.Timestamp(time.Now()), rand.Reader)
-
 	tx, err := w.WithTx(ctx)
 	if err != nil {
 		return snapshotID, fmt.Errorf("error starting transaction: %w", err)
@@ -227,6 +224,33 @@ func (w wrapper) InsertQueueSnapshot(ctx context.Context, params cqrs.InsertQueu
 	return snapshotID, nil
 }
 
+// queueSnapshotChunks creates a new snapshot ID and splits the encoded snapshot
+// into chunks of at most consts.StartMaxQueueChunkSize bytes.
+func queueSnapshotChunks(snapshot cqrs.QueueSnapshot) (cqrs.SnapshotID, [][]byte, error) {
+	var snapshotID cqrs.SnapshotID
+
+	byt, err := json.Marshal(snapshot)
+	if err != nil {
+		return snapshotID, nil, fmt.Errorf("error marshalling snapshot: %w", err)
+	}
+
+	var chunks [][]byte
+	for len(byt) > 0 {
+		if len(byt) > consts.StartMaxQueueChunkSize {
+			chunks = append(chunks, byt[:consts.StartMaxQueueChunkSize])
+			byt = byt[consts.StartMaxQueueChunkSize:]
+		} else {
+			chunks = append(chunks, byt)
+			break
+		}
+	}
+
+	// Snapshots are ordered by ID, so IDs use monotonic entropy to ensure
+	// that snapshots taken within the same millisecond remain in order.
+	snapshotID = ulid.Make()
+	return snapshotID, chunks, nil
+}
+
 func (w wrapper) InsertQueueSnapshotChunk(ctx context.Context, params cqrs.InsertQueueSnapshotChunkParams) error {
 	err := w.q.InsertQueueSnapshotChunk(ctx, sqlc.InsertQueueSnapshotChunkParams{
 		SnapshotID: params.SnapshotID.String(),
@@ -247,7 +271,7 @@ func (w wrapper) InsertQueueSnapshotChunk(ctx context.Context, params cqrs.Inser
 // GetApps returns apps that have not been deleted.
 func (w wrapper) GetApps(ctx context.Context, envID uuid.UUID, filter *cqrs.FilterAppParam) ([]*cqrs.App, error) {
 	f := func(ctx context.Context) ([]*sqlc.App, error) {
-		return w.q.GetApps(ctx)
+		return w.q.GetApps(ctx, envOrDefault(envID))
 	}
 
 	apps, err := copyInto(ctx, f, []*cqrs.App{})
@@ -268,7 +292,7 @@ func (w wrapper) GetApps(ctx context.Context, envID uuid.UUID, filter *cqrs.Filt
 
 func (w wrapper) GetAppByChecksum(ctx context.Context, envID uuid.UUID, checksum string) (*cqrs.App, error) {
 	f := func(ctx context.Context) (*sqlc.App, error) {
-		return w.q.GetAppByChecksum(ctx, checksum)
+		return w.q.GetAppByChecksum(ctx, sqlc.GetAppByChecksumParams{EnvID: envOrDefault(envID), Checksum: checksum})
 	}
 	return copyInto(ctx, f, &cqrs.App{})
 }
@@ -285,21 +309,24 @@ func (w wrapper) GetAppByURL(ctx context.Context, envID uuid.UUID, url string) (
 	url = util.NormalizeAppURL(url, forceHTTPS)
 
 	f := func(ctx context.Context) (*sqlc.App, error) {
-		return w.q.GetAppByURL(ctx, url)
+		return w.q.GetAppByURL(ctx, sqlc.GetAppByURLParams{EnvID: envOrDefault(envID), Url: url})
 	}
 	return copyInto(ctx, f, &cqrs.App{})
 }
 
 func (w wrapper) GetAppByName(ctx context.Context, envID uuid.UUID, name string) (*cqrs.App, error) {
 	f := func(ctx context.Context) (*sqlc.App, error) {
-		return w.q.GetAppByName(ctx, name)
+		return w.q.GetAppByName(ctx, sqlc.GetAppByNameParams{EnvID: envOrDefault(envID), Name: name})
 	}
 	return copyInto(ctx, f, &cqrs.App{})
 }
 
 // GetAllApps returns all apps.
 func (w wrapper) GetAllApps(ctx context.Context, envID uuid.UUID) ([]*cqrs.App, error) {
-	return copyInto(ctx, w.q.GetAllApps, []*cqrs.App{})
+	f := func(ctx context.Context) ([]*sqlc.App, error) {
+		return w.q.GetAllApps(ctx, envOrDefault(envID))
+	}
+	return copyInto(ctx, f, []*cqrs.App{})
 }
 
 // InsertApp creates a new app.
@@ -310,6 +337,7 @@ func (w wrapper) UpsertApp(ctx context.Context, arg cqrs.UpsertAppParams) (*cqrs
 	if arg.Method == "" {
 		arg.Method = enums.AppMethodServe.String()
 	}
+	arg.EnvID = envOrDefault(arg.EnvID)
 
 	return copyWriter(
 		ctx,
@@ -395,14 +423,14 @@ func (w wrapper) GetAppFunctions(ctx context.Context, appID uuid.UUID) ([]*cqrs.
 
 func (w wrapper) GetFunctionByExternalID(ctx context.Context, wsID uuid.UUID, appID, fnSlug string) (*cqrs.Function, error) {
 	f := func(ctx context.Context) (*sqlc.Function, error) {
-		return w.q.GetFunctionBySlug(ctx, fnSlug)
+		return w.q.GetFunctionBySlug(ctx, sqlc.GetFunctionBySlugParams{EnvID: envOrDefault(wsID), Slug: fnSlug})
 	}
 	return copyInto(ctx, f, &cqrs.Function{})
 }
 
 func (w wrapper) GetFunctionByInternalUUID(ctx context.Context, wsID, fnID uuid.UUID) (*cqrs.Function, error) {
 	f := func(ctx context.Context) (*sqlc.Function, error) {
-		return w.q.GetFunctionByID(ctx, fnID)
+		return w.q.GetFunctionByID(ctx, sqlc.GetFunctionByIDParams{EnvID: envOrDefault(wsID), ID: fnID})
 	}
 	return copyInto(ctx, f, &cqrs.Function{})
 }
@@ -413,16 +441,14 @@ func (w wrapper) GetFunctions(ctx context.Context) ([]*cqrs.Function, error) {
 
 func (w wrapper) GetFunctionsByAppInternalID(ctx context.Context, workspaceID, appID uuid.UUID) ([]*cqrs.Function, error) {
 	f := func(ctx context.Context) ([]*sqlc.Function, error) {
-		// Ingore the workspace ID for now.
-		return w.q.GetAppFunctions(ctx, appID)
+		return w.q.GetAppFunctionsByEnv(ctx, sqlc.GetAppFunctionsByEnvParams{EnvID: envOrDefault(workspaceID), AppID: appID})
 	}
 	return copyInto(ctx, f, []*cqrs.Function{})
 }
 
 func (w wrapper) GetFunctionsByAppExternalID(ctx context.Context, workspaceID uuid.UUID, appID string) ([]*cqrs.Function, error) {
 	f := func(ctx context.Context) ([]*sqlc.Function, error) {
-		// Ingore the workspace ID for now.
-		return w.q.GetAppFunctionsBySlug(ctx, appID)
+		return w.q.GetAppFunctionsBySlug(ctx, sqlc.GetAppFunctionsBySlugParams{EnvID: envOrDefault(workspaceID), Name: appID})
 	}
 	return copyInto(ctx, f, []*cqrs.Function{})
 }
@@ -468,13 +494,21 @@ func (w wrapper) InsertEvent(ctx context.Context, e cqrs.Event) error {
 	if err != nil {
 		return err
 	}
+	if data, err = w.encrypt(ctx, data); err != nil {
+		return fmt.Errorf("error encrypting event data: %w", err)
+	}
+	if user, err = w.encrypt(ctx, user); err != nil {
+		return fmt.Errorf("error encrypting event user: %w", err)
+	}
 	evt := sqlc.InsertEventParams{
-		InternalID: e.ID,
-		ReceivedAt: time.Now(),
-		EventID:    e.EventID,
-		EventName:  e.EventName,
-		EventData:  string(data),
-		EventUser:  string(user),
+		InternalID:  e.ID,
+		AccountID:   consts.DevServerAccountID,
+		WorkspaceID: envOrDefault(e.WorkspaceID),
+		ReceivedAt:  time.Now(),
+		EventID:     e.EventID,
+		EventName:   e.EventName,
+		EventData:   string(data),
+		EventUser:   string(user),
 		EventV: sql.NullString{
 			Valid:  e.EventVersion != "",
 			String: e.EventVersion,
@@ -510,6 +544,9 @@ func (w wrapper) GetEventByInternalID(ctx context.Context, internalID ulid.ULID)
 	if err != nil {
 		return nil, err
 	}
+	if err := w.decryptEvent(ctx, obj); err != nil {
+		return nil, err
+	}
 	evt := convertEvent(obj)
 	return &evt, nil
 }
@@ -546,6 +583,9 @@ func (w wrapper) GetEventsByInternalIDs(ctx context.Context, ids []ulid.ULID) ([
 
 	evts := make([]*cqrs.Event, len(objs))
 	for i, o := range objs {
+		if err := w.decryptEvent(ctx, o); err != nil {
+			return nil, err
+		}
 		evt := convertEvent(o)
 		evts[i] = &evt
 	}
@@ -564,6 +604,11 @@ func (w wrapper) GetEventsByExpressions(ctx context.Context, cel []string) ([]*c
 	if err != nil {
 		return nil, err
 	}
+	if w.encrypted() {
+		// Encrypted event data can't be filtered within SQL, so every event
+		// is decrypted and matched below.
+		prefilters = nil
+	}
 
 	sql, args, err := sq.Dialect(w.dialect()).
 		From("events").
@@ -612,6 +657,9 @@ func (w wrapper) GetEventsByExpressions(ctx context.Context, cel []string) ([]*c
 		); err != nil {
 			return nil, err
 		}
+		if err := w.decryptEvent(ctx, &data); err != nil {
+			return nil, err
+		}
 
 		evt, err := data.ToCQRS()
 		if err != nil {
@@ -649,19 +697,21 @@ func (w wrapper) WorkspaceEvents(ctx context.Context, workspaceID uuid.UUID, opt
 
 	if opts.Name == nil {
 		params := sqlc.WorkspaceEventsParams{
-			Cursor: *opts.Cursor,
-			Before: opts.Newest,
-			After:  opts.Oldest,
-			Limit:  int64(opts.Limit),
+			WorkspaceID: envOrDefault(workspaceID),
+			Cursor:      *opts.Cursor,
+			Before:      opts.Newest,
+			After:       opts.Oldest,
+			Limit:       int64(opts.Limit),
 		}
 		evts, err = w.q.WorkspaceEvents(ctx, params)
 	} else {
 		params := sqlc.WorkspaceNamedEventsParams{
-			Name:   *opts.Name,
-			Cursor: *opts.Cursor,
-			Before: opts.Newest,
-			After:  opts.Oldest,
-			Limit:  int64(opts.Limit),
+			WorkspaceID: envOrDefault(workspaceID),
+			Name:        *opts.Name,
+			Cursor:      *opts.Cursor,
+			Before:      opts.Newest,
+			After:       opts.Oldest,
+			Limit:       int64(opts.Limit),
 		}
 		evts, err = w.q.WorkspaceNamedEvents(ctx, params)
 	}
@@ -671,6 +721,9 @@ func (w wrapper) WorkspaceEvents(ctx context.Context, workspaceID uuid.UUID, opt
 	}
 	out := make([]cqrs.Event, len(evts))
 	for n, evt := range evts {
+		if err := w.decryptEvent(ctx, evt); err != nil {
+			return nil, err
+		}
 		out[n] = convertEvent(evt)
 	}
 	return out, nil
@@ -678,6 +731,7 @@ func (w wrapper) WorkspaceEvents(ctx context.Context, workspaceID uuid.UUID, opt
 
 func (w wrapper) GetEventsIDbound(
 	ctx context.Context,
+	workspaceID uuid.UUID,
 	ids cqrs.IDBound,
 	limit int,
 	includeInternal bool,
@@ -694,6 +748,7 @@ func (w wrapper) GetEventsIDbound(
 	evts, err := w.q.GetEventsIDbound(ctx, sqlc.GetEventsIDboundParams{
 		After:           *ids.After,
 		Before:          *ids.Before,
+		WorkspaceID:     envOrDefault(workspaceID),
 		IncludeInternal: strconv.FormatBool(includeInternal),
 		Limit:           int64(limit),
 	})
@@ -703,6 +758,9 @@ func (w wrapper) GetEventsIDbound(
 
 	var res = make([]*cqrs.Event, len(evts))
 	for n, i := range evts {
+		if err := w.decryptEvent(ctx, i); err != nil {
+			return nil, err
+		}
 		e := convertEvent(i)
 		res[n] = &e
 	}
@@ -712,6 +770,8 @@ func (w wrapper) GetEventsIDbound(
 func convertEvent(obj *sqlc.Event) cqrs.Event {
 	evt := &cqrs.Event{
 		ID:           obj.InternalID,
+		AccountID:    obj.AccountID,
+		WorkspaceID:  obj.WorkspaceID,
 		ReceivedAt:   obj.ReceivedAt,
 		EventID:      obj.EventID,
 		EventName:    obj.EventName,
@@ -777,6 +837,9 @@ func (w wrapper) GetFunctionRunsFromEvents(
 	}
 	result := []*cqrs.FunctionRun{}
 	for _, item := range runs {
+		if err := w.decryptFinish(ctx, &item.FunctionFinish); err != nil {
+			return nil, err
+		}
 		result = append(result, toCQRSRun(item.FunctionRun, item.FunctionFinish))
 	}
 	return result, nil
@@ -792,6 +855,12 @@ func (w wrapper) GetFunctionRun(
 	if err != nil {
 		return nil, err
 	}
+	if envOrDefault(item.FunctionRun.WorkspaceID) != envOrDefault(workspaceID) {
+		return nil, sql.ErrNoRows
+	}
+	if err := w.decryptFinish(ctx, &item.FunctionFinish); err != nil {
+		return nil, err
+	}
 	return toCQRSRun(item.FunctionRun, item.FunctionFinish), nil
 }
 
@@ -815,6 +884,9 @@ func (w wrapper) GetFunctionRunsTimebound(ctx context.Context, t cqrs.Timebound,
 	}
 	result := []*cqrs.FunctionRun{}
 	for _, item := range runs {
+		if err := w.decryptFinish(ctx, &item.FunctionFinish); err != nil {
+			return nil, err
+		}
 		result = append(result, toCQRSRun(item.FunctionRun, item.FunctionFinish))
 	}
 	return result, nil
@@ -827,7 +899,16 @@ func (w wrapper) GetFunctionRunFinishesByRunIDs(
 	runIDs []ulid.ULID,
 ) ([]*cqrs.FunctionRunFinish, error) {
 	return copyInto(ctx, func(ctx context.Context) ([]*sqlc.FunctionFinish, error) {
-		return w.q.GetFunctionRunFinishesByRunIDs(ctx, runIDs)
+		finishes, err := w.q.GetFunctionRunFinishesByRunIDs(ctx, runIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, finish := range finishes {
+			if err := w.decryptFinish(ctx, finish); err != nil {
+				return nil, err
+			}
+		}
+		return finishes, nil
 	}, []*cqrs.FunctionRunFinish{})
 }
 
@@ -840,6 +921,9 @@ func (w wrapper) InsertHistory(ctx context.Context, h history.History) error {
 	if err != nil {
 		return err
 	}
+	if err := w.encryptHistory(ctx, params); err != nil {
+		return err
+	}
 	return w.q.InsertHistory(ctx, *params)
 }
 
@@ -910,7 +994,9 @@ func (w wrapper) InsertSpan(ctx context.Context, span *cqrs.Span) error {
 		params.SpanAttributes = byt
 	}
 	if byt, err := json.Marshal(span.Events); err == nil {
-		params.Events = byt
+		if params.Events, err = w.encrypt(ctx, byt); err != nil {
+			return fmt.Errorf("error encrypting span events: %w", err)
+		}
 	}
 	if byt, err := json.Marshal(span.Links); err == nil {
 		params.Links = byt
@@ -955,6 +1041,9 @@ func (w wrapper) InsertTraceRun(ctx context.Context, run *cqrs.TraceRun) error {
 	if len(run.TriggerIDs) > 0 {
 		params.TriggerIds = []byte(strings.Join(run.TriggerIDs, ","))
 	}
+	if params.Output, err = w.encrypt(ctx, params.Output); err != nil {
+		return fmt.Errorf("error encrypting run output: %w", err)
+	}
 
 	return w.q.InsertTraceRun(ctx, params)
 }
@@ -1059,6 +1148,9 @@ func (w wrapper) GetTraceRun(ctx context.Context, id cqrs.TraceRunIdentifier) (*
 	if err != nil {
 		return nil, err
 	}
+	if run.Output, err = w.decrypt(ctx, run.Output); err != nil {
+		return nil, fmt.Errorf("error decrypting run output: %w", err)
+	}
 
 	start := time.UnixMilli(run.StartedAt)
 	end := time.UnixMilli(run.EndedAt)
@@ -1121,8 +1213,13 @@ func (w wrapper) GetSpanOutput(ctx context.Context, opts cqrs.SpanIdentifier) (*
 	}
 
 	for _, s := range spans {
+		byt, err := w.decrypt(ctx, s.Events)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting span outputs: %w", err)
+		}
+
 		var evts []cqrs.SpanEvent
-		err := json.Unmarshal(s.Events, &evts)
+		err = json.Unmarshal(byt, &evts)
 		if err != nil {
 			return nil, fmt.Errorf("error parsing span outputs: %w", err)
 		}
@@ -1190,8 +1287,13 @@ func (w wrapper) GetSpanStack(ctx context.Context, opts cqrs.SpanIdentifier) ([]
 	}
 
 	for _, s := range spans {
+		byt, err := w.decrypt(ctx, s.Events)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting span outputs: %w", err)
+		}
+
 		var evts []cqrs.SpanEvent
-		err := json.Unmarshal(s.Events, &evts)
+		err = json.Unmarshal(byt, &evts)
 		if err != nil {
 			return nil, fmt.Errorf("error parsing span outputs: %w", err)
 		}
@@ -1217,6 +1319,9 @@ type runsQueryBuilder struct {
 func newRunsQueryBuilder(ctx context.Context, opt cqrs.GetTraceRunOpt) *runsQueryBuilder {
 	// filters
 	filter := []sq.Expression{}
+	if opt.Filter.WorkspaceID != uuid.Nil {
+		filter = append(filter, sq.C("workspace_id").Eq(opt.Filter.WorkspaceID.String()))
+	}
 	if len(opt.Filter.AppID) > 0 {
 		filter = append(filter, sq.C("app_id").In(opt.Filter.AppID))
 	}
@@ -1434,6 +1539,9 @@ func (w wrapper) GetTraceRuns(ctx context.Context, opt cqrs.GetTraceRunOpt) ([]*
 		if err != nil {
 			return nil, err
 		}
+		if data.Output, err = w.decrypt(ctx, data.Output); err != nil {
+			return nil, fmt.Errorf("error decrypting run output: %w", err)
+		}
 
 		// filter out runs that doesn't have the event IDs
 		if len(evtIDs) > 0 && !data.HasEventIDs(evtIDs) {
@@ -1678,6 +1786,9 @@ type workerConnectionsQueryBuilder struct {
 func newWorkerConnectionsQueryBuilder(ctx context.Context, opt cqrs.GetWorkerConnectionOpt) *workerConnectionsQueryBuilder {
 	// filters
 	filter := []sq.Expression{}
+	if opt.Filter.WorkspaceID != uuid.Nil {
+		filter = append(filter, sq.C("workspace_id").Eq(opt.Filter.WorkspaceID.String()))
+	}
 	if len(opt.Filter.AppID) > 0 {
 		filter = append(filter, sq.C("app_id").In(opt.Filter.AppID))
 	}
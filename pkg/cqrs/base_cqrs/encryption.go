@@ -0,0 +1,114 @@
+package base_cqrs
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	sqlc "github.com/inngest/inngest/pkg/cqrs/base_cqrs/sqlc/sqlite"
+	"github.com/inngest/inngest/pkg/encryption"
+)
+
+// Opt configures the CQRS manager, history driver and history reader.
+type Opt func(o *crypter)
+
+// WithEncryptor encrypts event payloads, history results, run outputs and
+// trace span outputs at rest, decrypting them when read.
+func WithEncryptor(enc encryption.Encryptor) Opt {
+	return func(c *crypter) {
+		c.enc = enc
+	}
+}
+
+func newCrypter(opts []Opt) crypter {
+	c := crypter{}
+	for _, o := range opts {
+		o(&c)
+	}
+	return c
+}
+
+// crypter encrypts and decrypts columns stored at rest.  If no encryptor is
+// configured data is stored and read as-is.
+type crypter struct {
+	enc encryption.Encryptor
+}
+
+func (c crypter) encrypted() bool {
+	return c.enc != nil
+}
+
+func (c crypter) encrypt(ctx context.Context, byt []byte) ([]byte, error) {
+	if c.enc == nil || len(byt) == 0 {
+		return byt, nil
+	}
+	return c.enc.Encrypt(ctx, byt)
+}
+
+func (c crypter) encryptString(ctx context.Context, str string) (string, error) {
+	byt, err := c.encrypt(ctx, []byte(str))
+	return string(byt), err
+}
+
+func (c crypter) encryptNullString(ctx context.Context, str sql.NullString) (sql.NullString, error) {
+	if !str.Valid {
+		return str, nil
+	}
+	var err error
+	str.String, err = c.encryptString(ctx, str.String)
+	return str, err
+}
+
+func (c crypter) decrypt(ctx context.Context, byt []byte) ([]byte, error) {
+	if c.enc == nil || len(byt) == 0 {
+		return byt, nil
+	}
+	return c.enc.Decrypt(ctx, byt)
+}
+
+func (c crypter) decryptString(ctx context.Context, str string) (string, error) {
+	byt, err := c.decrypt(ctx, []byte(str))
+	return string(byt), err
+}
+
+func (c crypter) decryptNullString(ctx context.Context, str *sql.NullString) error {
+	if !str.Valid {
+		return nil
+	}
+	var err error
+	str.String, err = c.decryptString(ctx, str.String)
+	return err
+}
+
+func (c crypter) decryptEvent(ctx context.Context, evt *sqlc.Event) error {
+	var err error
+	if evt.EventData, err = c.decryptString(ctx, evt.EventData); err != nil {
+		return fmt.Errorf("error decrypting event data: %w", err)
+	}
+	if evt.EventUser, err = c.decryptString(ctx, evt.EventUser); err != nil {
+		return fmt.Errorf("error decrypting event user: %w", err)
+	}
+	return nil
+}
+
+func (c crypter) decryptFinish(ctx context.Context, finish *sqlc.FunctionFinish) error {
+	if err := c.decryptNullString(ctx, &finish.Output); err != nil {
+		return fmt.Errorf("error decrypting run output: %w", err)
+	}
+	return nil
+}
+
+func (c crypter) decryptHistory(ctx context.Context, h *sqlc.History) error {
+	if err := c.decryptNullString(ctx, &h.Result); err != nil {
+		return fmt.Errorf("error decrypting history result: %w", err)
+	}
+	return nil
+}
+
+func (c crypter) encryptHistory(ctx context.Context, params *sqlc.InsertHistoryParams) error {
+	var err error
+	if params.Result, err = c.encryptNullString(ctx, params.Result); err != nil {
+		return fmt.Errorf("error encrypting history result: %w", err)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/inngest/inngest/pkg/inngest"
 )
 
@@ -20,6 +21,30 @@ func (w wrapper) Functions(ctx context.Context) ([]inngest.Function, error) {
 	return funcs, nil
 }
 
+// FunctionsByEnv returns all functions within the given environment.
+func (w wrapper) FunctionsByEnv(ctx context.Context, envID uuid.UUID) ([]inngest.Function, error) {
+	apps, err := w.GetAllApps(ctx, envID)
+	if err != nil {
+		return nil, err
+	}
+	appIDs := map[uuid.UUID]bool{}
+	for _, app := range apps {
+		appIDs[app.ID] = true
+	}
+
+	all, _ := w.GetFunctions(ctx)
+	funcs := []inngest.Function{}
+	for _, i := range all {
+		if !appIDs[i.AppID] {
+			continue
+		}
+		f := inngest.Function{}
+		_ = json.Unmarshal([]byte(i.Config), &f)
+		funcs = append(funcs, f)
+	}
+	return funcs, nil
+}
+
 // FunctionsScheduled returns all scheduled functions available.
 func (w wrapper) FunctionsScheduled(ctx context.Context) ([]inngest.Function, error) {
 	// TODO: Make less naive by storing triggers and caching.
@@ -39,13 +64,14 @@ func (w wrapper) FunctionsScheduled(ctx context.Context) ([]inngest.Function, er
 	return all, nil
 }
 
-// FunctionsByTrigger returns functions for the given trigger by event name.
-func (w wrapper) FunctionsByTrigger(ctx context.Context, eventName string) ([]inngest.Function, error) {
+// FunctionsByTrigger returns functions within the given environment for the
+// given trigger by event name.
+func (w wrapper) FunctionsByTrigger(ctx context.Context, envID uuid.UUID, eventName string) ([]inngest.Function, error) {
 
 	matchingTriggers := matchingTriggerNames(eventName)
 
 	// TODO: Make less naive by storing triggers and caching.
-	fns, err := w.Functions(ctx)
+	fns, err := w.FunctionsByEnv(ctx, envID)
 	if err != nil {
 		return nil, err
 	}
@@ -12,13 +12,16 @@ import (
 	"github.com/oklog/ulid/v2"
 )
 
-func NewHistoryDriver(db *sql.DB, driver string) history.Driver {
+func NewHistoryDriver(db *sql.DB, driver string, opts ...Opt) history.Driver {
 	return historyDriver{
-		q: NewQueries(db, driver),
+		q:       NewQueries(db, driver),
+		crypter: newCrypter(opts),
 	}
 }
 
 type historyDriver struct {
+	crypter
+
 	q sqlc.Querier
 }
 
@@ -104,6 +107,10 @@ func (d historyDriver) Write(ctx context.Context, h history.History) (err error)
 		return err
 	}
 
+	if err := d.encryptHistory(ctx, &params); err != nil {
+		return err
+	}
+
 	if err := d.q.InsertHistory(context.Background(), params); err != nil {
 		return err
 	}
@@ -135,6 +142,9 @@ func (d historyDriver) Write(ctx context.Context, h history.History) (err error)
 			marshalled, _ := marshalJSONAsString(h.Result.Output)
 			end.Output = sql.NullString{String: marshalled, Valid: true}
 		}
+		if end.Output, err = d.encryptNullString(ctx, end.Output); err != nil {
+			return err
+		}
 		return d.q.InsertFunctionFinish(context.Background(), end)
 	default:
 		return nil
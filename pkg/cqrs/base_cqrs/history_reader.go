@@ -18,13 +18,16 @@ import (
 	"github.com/oklog/ulid/v2"
 )
 
-func NewHistoryReader(db *sql.DB, driver string) history_reader.Reader {
+func NewHistoryReader(db *sql.DB, driver string, opts ...Opt) history_reader.Reader {
 	return &reader{
-		q: NewQueries(db, driver),
+		q:       NewQueries(db, driver),
+		crypter: newCrypter(opts),
 	}
 }
 
 type reader struct {
+	crypter
+
 	q sqlc.Querier
 }
 
@@ -53,6 +56,12 @@ func (r *reader) GetRun(
 
 		return history_reader.Run{}, fmt.Errorf("failed to get run: %w", err)
 	}
+	if opts.WorkspaceID != nil && envOrDefault(rawRun.FunctionRun.WorkspaceID) != envOrDefault(*opts.WorkspaceID) {
+		return history_reader.Run{}, history_reader.ErrNotFound
+	}
+	if err := r.decryptFinish(ctx, &rawRun.FunctionFinish); err != nil {
+		return history_reader.Run{}, err
+	}
 
 	run, err := sqlToRun(&rawRun.FunctionRun, &rawRun.FunctionFinish)
 	if err != nil {
@@ -92,6 +101,9 @@ func (r *reader) GetFunctionRunsFromEvents(
 
 	result := []*cqrs.FunctionRun{}
 	for _, rawRun := range runs {
+		if err := r.decryptFinish(ctx, &rawRun.FunctionFinish); err != nil {
+			return nil, err
+		}
 		run, err := sqlToRun(&rawRun.FunctionRun, &rawRun.FunctionFinish)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert run: %w", err)
@@ -119,6 +131,9 @@ func (r *reader) GetRunHistory(
 
 	var items []*history_reader.RunHistory
 	for _, row := range rows {
+		if err := r.decryptHistory(ctx, row); err != nil {
+			return nil, err
+		}
 		historyItem, err := sqlToRunHistory(row)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert history item: %w", err)
@@ -150,6 +165,9 @@ func (r *reader) GetRunHistoryItemOutput(
 	if !item.Result.Valid {
 		return nil, history_reader.ErrNotFound
 	}
+	if err := r.decryptHistory(ctx, item); err != nil {
+		return nil, err
+	}
 
 	var (
 		result            *string
@@ -174,6 +192,9 @@ func (r *reader) GetRuns(
 
 	var result []history_reader.Run
 	for _, run := range runs {
+		if err := r.decryptFinish(ctx, &run.FunctionFinish); err != nil {
+			return nil, err
+		}
 		r, err := sqlToRun(&run.FunctionRun, &run.FunctionFinish)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert run: %w", err)
@@ -197,6 +218,9 @@ func (r *reader) GetRunsByEventID(
 
 	var result []history_reader.Run
 	for _, run := range runs {
+		if err := r.decryptFinish(ctx, &run.FunctionFinish); err != nil {
+			return nil, err
+		}
 		r, err := sqlToRun(&run.FunctionRun, &run.FunctionFinish)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert run: %w", err)
@@ -0,0 +1,26 @@
+CREATE TABLE audit_log (
+    id CHAR(26) PRIMARY KEY,
+    account_id UUID NOT NULL,
+    workspace_id UUID NOT NULL,
+
+    actor VARCHAR NOT NULL,
+    actor_method VARCHAR NOT NULL,
+    source VARCHAR NOT NULL,
+    action VARCHAR NOT NULL,
+    target_ids VARCHAR NOT NULL,
+    payload_hash VARCHAR NOT NULL,
+
+    created_at BIGINT NOT NULL
+);
+
+CREATE INDEX idx_audit_log_workspace_created_at ON audit_log(workspace_id, created_at);
+
+-- The audit log is append-only.
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,2 @@
+ALTER TABLE "apps" ADD COLUMN IF NOT EXISTS "env_id" CHAR(36) NOT NULL DEFAULT '00000000-0000-4000-b000-000000000000';
+UPDATE "events" SET "account_id" = '00000000-0000-4000-a000-000000000000', "workspace_id" = '00000000-0000-4000-b000-000000000000' WHERE "workspace_id" IS NULL;
@@ -0,0 +1,27 @@
+CREATE TABLE audit_log (
+    id CHAR(26) PRIMARY KEY,
+    account_id CHAR(36) NOT NULL,
+    workspace_id CHAR(36) NOT NULL,
+
+    actor VARCHAR NOT NULL,
+    actor_method VARCHAR NOT NULL,
+    source VARCHAR NOT NULL,
+    action VARCHAR NOT NULL,
+    target_ids VARCHAR NOT NULL,
+    payload_hash VARCHAR NOT NULL,
+
+    created_at INT NOT NULL
+);
+
+CREATE INDEX idx_audit_log_workspace_created_at ON audit_log(workspace_id, created_at);
+
+-- The audit log is append-only.
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
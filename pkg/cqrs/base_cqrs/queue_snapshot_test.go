@@ -0,0 +1,48 @@
+package base_cqrs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetQueueSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	// Each run uses its own database so that snapshots from other tests, or
+	// previous runs, never appear as the latest snapshot.
+	db, err := NewIsolated("queue-snapshot-" + uuid.NewString())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	mgr := NewCQRS(db, "sqlite")
+
+	first, err := mgr.InsertQueueSnapshot(ctx, cqrs.InsertQueueSnapshotParams{
+		Snapshot: cqrs.QueueSnapshot{"key": {Type: "string", Value: "first"}},
+	})
+	require.NoError(t, err)
+
+	// Snapshots taken within the same millisecond must still be ordered.
+	prev := first
+	for i := 0; i < 3; i++ {
+		id, err := mgr.InsertQueueSnapshot(ctx, cqrs.InsertQueueSnapshotParams{
+			Snapshot: cqrs.QueueSnapshot{"key": {Type: "string", Value: fmt.Sprintf("next-%d", i)}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, id.Compare(prev))
+		prev = id
+	}
+
+	snapshot, err := mgr.GetQueueSnapshot(ctx, first)
+	require.NoError(t, err)
+	require.NotNil(t, snapshot)
+	require.Equal(t, "first", (*snapshot)["key"].Value)
+
+	latest, err := mgr.GetLatestQueueSnapshot(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	require.Equal(t, "next-2", (*latest)["key"].Value)
+}
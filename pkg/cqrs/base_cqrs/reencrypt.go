@@ -0,0 +1,167 @@
+package base_cqrs
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	sq "github.com/doug-martin/goqu/v9"
+	sqexp "github.com/doug-martin/goqu/v9/exp"
+	"github.com/inngest/inngest/pkg/encryption"
+	"github.com/inngest/inngest/pkg/logger"
+)
+
+// reencryptPageSize is the number of rows loaded at once when re-encrypting.
+const reencryptPageSize = 500
+
+// reencryptTarget is a table containing columns which are encrypted at rest.
+type reencryptTarget struct {
+	table string
+	// keys are the columns which uniquely identify a row.
+	keys []string
+	// columns are the encrypted columns.
+	columns []string
+	// binary indicates whether the encrypted columns are stored as blobs,
+	// rather than strings.
+	binary bool
+}
+
+var reencryptTargets = []reencryptTarget{
+	{table: "events", keys: []string{"internal_id"}, columns: []string{"event_data", "event_user"}},
+	{table: "history", keys: []string{"id"}, columns: []string{"result"}},
+	{table: "function_finishes", keys: []string{"run_id"}, columns: []string{"output"}},
+	{table: "traces", keys: []string{"trace_id", "span_id", "timestamp_unix_ms"}, columns: []string{"events"}, binary: true},
+	{table: "trace_runs", keys: []string{"run_id"}, columns: []string{"output"}, binary: true},
+}
+
+// Reencrypt rewrites all data encrypted at rest so that it's encrypted with the
+// encryptor's current primary key.  Plaintext data written before encryption
+// was enabled is encrypted.  This returns the number of rows updated.
+//
+// This is safe to run while the server is running, and can be re-run after a
+// failure:  rows already encrypted with the primary key are skipped.
+func Reencrypt(ctx context.Context, db *sql.DB, driver string, enc encryption.Encryptor) (int, error) {
+	dialect := "sqlite3"
+	if driver == "postgres" {
+		dialect = "postgres"
+	}
+
+	total := 0
+	for _, t := range reencryptTargets {
+		n, err := reencryptTable(ctx, db, dialect, enc, t)
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("error re-encrypting %s: %w", t.table, err)
+		}
+		logger.From(ctx).Info().Str("table", t.table).Int("rows", n).Msg("re-encrypted table")
+	}
+	return total, nil
+}
+
+func reencryptTable(ctx context.Context, db *sql.DB, dialect string, enc encryption.Encryptor, t reencryptTarget) (int, error) {
+	cols := []any{}
+	order := []sqexp.OrderedExpression{}
+	for _, k := range t.keys {
+		cols = append(cols, k)
+		order = append(order, sq.C(k).Asc())
+	}
+	for _, c := range t.columns {
+		cols = append(cols, c)
+	}
+
+	updated := 0
+	for offset := uint(0); ; offset += reencryptPageSize {
+		query, args, err := sq.Dialect(dialect).
+			From(t.table).
+			Select(cols...).
+			Order(order...).
+			Limit(reencryptPageSize).
+			Offset(offset).
+			ToSQL()
+		if err != nil {
+			return updated, err
+		}
+
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return updated, err
+		}
+
+		// Load the entire page before updating rows, ensuring that we don't
+		// hold a read cursor open while writing.
+		page := [][]any{}
+		for rows.Next() {
+			keys := make([]any, len(t.keys))
+			values := make([][]byte, len(t.columns))
+			dest := []any{}
+			for n := range keys {
+				dest = append(dest, &keys[n])
+			}
+			for n := range values {
+				dest = append(dest, &values[n])
+			}
+			if err := rows.Scan(dest...); err != nil {
+				_ = rows.Close()
+				return updated, err
+			}
+			row := keys
+			for _, v := range values {
+				row = append(row, v)
+			}
+			page = append(page, row)
+		}
+		if err := rows.Close(); err != nil {
+			return updated, err
+		}
+		if err := rows.Err(); err != nil {
+			return updated, err
+		}
+
+		for _, row := range page {
+			record := sq.Record{}
+			for n, c := range t.columns {
+				value := row[len(t.keys)+n].([]byte)
+				if len(value) == 0 {
+					continue
+				}
+				encrypted, changed, err := enc.Reencrypt(ctx, value)
+				if err != nil {
+					return updated, err
+				}
+				if !changed {
+					continue
+				}
+				if t.binary {
+					record[c] = encrypted
+				} else {
+					record[c] = string(encrypted)
+				}
+			}
+			if len(record) == 0 {
+				continue
+			}
+
+			where := sq.Ex{}
+			for n, k := range t.keys {
+				where[k] = row[n]
+			}
+			query, args, err := sq.Dialect(dialect).
+				Update(t.table).
+				Set(record).
+				Where(where).
+				Prepared(true).
+				ToSQL()
+			if err != nil {
+				return updated, err
+			}
+			if _, err := db.ExecContext(ctx, query, args...); err != nil {
+				return updated, err
+			}
+			updated++
+		}
+
+		if len(page) < reencryptPageSize {
+			return updated, nil
+		}
+	}
+}
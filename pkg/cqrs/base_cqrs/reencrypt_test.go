@@ -0,0 +1,93 @@
+package base_cqrs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/encryption"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/require"
+)
+
+type staticKeys struct {
+	primary string
+	keys    map[string][]byte
+}
+
+func (s *staticKeys) Primary(ctx context.Context) (encryption.Key, error) {
+	return s.Key(ctx, s.primary)
+}
+
+func (s *staticKeys) Key(ctx context.Context, id string) (encryption.Key, error) {
+	if k, ok := s.keys[id]; ok {
+		return encryption.Key{ID: id, Material: k}, nil
+	}
+	return encryption.Key{}, encryption.ErrKeyNotFound
+}
+
+func newKey(t *testing.T) []byte {
+	encoded, err := encryption.GenerateKey()
+	require.NoError(t, err)
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+	return key
+}
+
+func TestReencrypt(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := New(BaseCQRSOptions{InMemory: true})
+	require.NoError(t, err)
+
+	keys := &staticKeys{primary: "k1", keys: map[string][]byte{"k1": newKey(t)}}
+	enc := encryption.NewEnvelopeEncryptor(keys)
+
+	plain := NewCQRS(db, "sqlite")
+	encrypted := NewCQRS(db, "sqlite", WithEncryptor(enc))
+
+	// Write one plaintext event and one event encrypted with the old key.
+	plainID := ulid.MustNew(ulid.Now(), rand.Reader)
+	require.NoError(t, plain.InsertEvent(ctx, cqrs.Event{
+		ID:        plainID,
+		EventID:   "plain",
+		EventName: "test/event",
+		EventData: map[string]any{"secret": "one"},
+		EventTS:   time.Now().UnixMilli(),
+	}))
+	encID := ulid.MustNew(ulid.Now(), rand.Reader)
+	require.NoError(t, encrypted.InsertEvent(ctx, cqrs.Event{
+		ID:        encID,
+		EventID:   "encrypted",
+		EventName: "test/event",
+		EventData: map[string]any{"secret": "two"},
+		EventTS:   time.Now().UnixMilli(),
+	}))
+
+	keys.keys["k2"] = newKey(t)
+	keys.primary = "k2"
+
+	n, err := Reencrypt(ctx, db, "sqlite", enc)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	for id, secret := range map[ulid.ULID]string{plainID: "one", encID: "two"} {
+		var data string
+		require.NoError(t, db.QueryRowContext(ctx, "SELECT event_data FROM events WHERE internal_id = ?", id).Scan(&data))
+		kid, ok := encryption.KeyID([]byte(data))
+		require.True(t, ok)
+		require.Equal(t, "k2", kid)
+
+		evt, err := encrypted.GetEventByInternalID(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, secret, evt.EventData["secret"])
+	}
+
+	// Re-running is a no-op.
+	n, err = Reencrypt(ctx, db, "sqlite", enc)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/google/uuid"
 	"github.com/inngest/inngest/pkg/cqrs"
 )
 
@@ -38,26 +37,13 @@ func (tr *TraceRun) HasEventIDs(ids []string) bool {
 
 func (e *Event) ToCQRS() (*cqrs.Event, error) {
 	evt := &cqrs.Event{
-		ID:         e.InternalID,
-		ReceivedAt: e.ReceivedAt,
-		EventID:    e.EventID,
-		EventName:  e.EventName,
-		EventTS:    e.EventTs.UnixMilli(),
-	}
-
-	if e.AccountID.Valid {
-		id, err := uuid.Parse(e.AccountID.String)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing account ID: %w", err)
-		}
-		evt.AccountID = id
-	}
-	if e.WorkspaceID.Valid {
-		id, err := uuid.Parse(e.WorkspaceID.String)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing environment ID: %w", err)
-		}
-		evt.WorkspaceID = id
+		ID:          e.InternalID,
+		AccountID:   e.AccountID,
+		WorkspaceID: e.WorkspaceID,
+		ReceivedAt:  e.ReceivedAt,
+		EventID:     e.EventID,
+		EventName:   e.EventName,
+		EventTS:     e.EventTs.UnixMilli(),
 	}
 
 	// Event data
@@ -94,8 +94,8 @@ func (q NormalizedQueries) UpdateAppURL(ctx context.Context, arg sqlc_sqlite.Upd
 	return app.ToSQLite()
 }
 
-func (q NormalizedQueries) GetAppByName(ctx context.Context, name string) (*sqlc_sqlite.App, error) {
-	app, err := q.db.GetAppByName(ctx, name)
+func (q NormalizedQueries) GetAppByName(ctx context.Context, params sqlc_sqlite.GetAppByNameParams) (*sqlc_sqlite.App, error) {
+	app, err := q.db.GetAppByName(ctx, GetAppByNameParams{EnvID: params.EnvID, Name: params.Name})
 	if err != nil {
 		return nil, err
 	}
@@ -154,8 +154,8 @@ func (q NormalizedQueries) InsertQueueSnapshotChunk(ctx context.Context, params
 	})
 }
 
-func (q NormalizedQueries) GetApps(ctx context.Context) ([]*sqlc_sqlite.App, error) {
-	apps, err := q.db.GetApps(ctx)
+func (q NormalizedQueries) GetApps(ctx context.Context, envID uuid.UUID) ([]*sqlc_sqlite.App, error) {
+	apps, err := q.db.GetApps(ctx, envID)
 	if err != nil {
 		return nil, err
 	}
@@ -168,8 +168,8 @@ func (q NormalizedQueries) GetApps(ctx context.Context) ([]*sqlc_sqlite.App, err
 	return sqliteApps, nil
 }
 
-func (q NormalizedQueries) GetAppByChecksum(ctx context.Context, checksum string) (*sqlc_sqlite.App, error) {
-	app, err := q.db.GetAppByChecksum(ctx, checksum)
+func (q NormalizedQueries) GetAppByChecksum(ctx context.Context, params sqlc_sqlite.GetAppByChecksumParams) (*sqlc_sqlite.App, error) {
+	app, err := q.db.GetAppByChecksum(ctx, GetAppByChecksumParams{EnvID: params.EnvID, Checksum: params.Checksum})
 	if err != nil {
 		return nil, err
 	}
@@ -186,8 +186,8 @@ func (q NormalizedQueries) GetAppByID(ctx context.Context, id uuid.UUID) (*sqlc_
 	return app.ToSQLite()
 }
 
-func (q NormalizedQueries) GetAppByURL(ctx context.Context, url string) (*sqlc_sqlite.App, error) {
-	app, err := q.db.GetAppByURL(ctx, url)
+func (q NormalizedQueries) GetAppByURL(ctx context.Context, params sqlc_sqlite.GetAppByURLParams) (*sqlc_sqlite.App, error) {
+	app, err := q.db.GetAppByURL(ctx, GetAppByURLParams{EnvID: params.EnvID, Url: params.Url})
 	if err != nil {
 		return nil, err
 	}
@@ -195,8 +195,8 @@ func (q NormalizedQueries) GetAppByURL(ctx context.Context, url string) (*sqlc_s
 	return app.ToSQLite()
 }
 
-func (q NormalizedQueries) GetAllApps(ctx context.Context) ([]*sqlc_sqlite.App, error) {
-	apps, err := q.db.GetAllApps(ctx)
+func (q NormalizedQueries) GetAllApps(ctx context.Context, envID uuid.UUID) ([]*sqlc_sqlite.App, error) {
+	apps, err := q.db.GetAllApps(ctx, envID)
 	if err != nil {
 		return nil, err
 	}
@@ -223,6 +223,7 @@ func (q NormalizedQueries) UpsertApp(ctx context.Context, params sqlc_sqlite.Ups
 		Url:         params.Url,
 		Method:      params.Method,
 		AppVersion:  params.AppVersion,
+		EnvID:       params.EnvID,
 	}
 
 	app, err := q.db.UpsertApp(ctx, pgParams)
@@ -260,8 +261,8 @@ func (q NormalizedQueries) GetApp(ctx context.Context, id uuid.UUID) (*sqlc_sqli
 	return app.ToSQLite()
 }
 
-func (q NormalizedQueries) GetFunctionBySlug(ctx context.Context, slug string) (*sqlc_sqlite.Function, error) {
-	function, err := q.db.GetFunctionBySlug(ctx, slug)
+func (q NormalizedQueries) GetFunctionBySlug(ctx context.Context, params sqlc_sqlite.GetFunctionBySlugParams) (*sqlc_sqlite.Function, error) {
+	function, err := q.db.GetFunctionBySlug(ctx, GetFunctionBySlugParams{EnvID: params.EnvID, Slug: params.Slug})
 	if err != nil {
 		return nil, err
 	}
@@ -269,8 +270,8 @@ func (q NormalizedQueries) GetFunctionBySlug(ctx context.Context, slug string) (
 	return function.ToSQLite()
 }
 
-func (q NormalizedQueries) GetFunctionByID(ctx context.Context, id uuid.UUID) (*sqlc_sqlite.Function, error) {
-	function, err := q.db.GetFunctionByID(ctx, id)
+func (q NormalizedQueries) GetFunctionByID(ctx context.Context, params sqlc_sqlite.GetFunctionByIDParams) (*sqlc_sqlite.Function, error) {
+	function, err := q.db.GetFunctionByID(ctx, GetFunctionByIDParams{EnvID: params.EnvID, ID: params.ID})
 	if err != nil {
 		return nil, err
 	}
@@ -292,8 +293,22 @@ func (q NormalizedQueries) GetFunctions(ctx context.Context) ([]*sqlc_sqlite.Fun
 	return sqliteFunctions, nil
 }
 
-func (q NormalizedQueries) GetAppFunctionsBySlug(ctx context.Context, slug string) ([]*sqlc_sqlite.Function, error) {
-	functions, err := q.db.GetAppFunctionsBySlug(ctx, slug)
+func (q NormalizedQueries) GetAppFunctionsByEnv(ctx context.Context, params sqlc_sqlite.GetAppFunctionsByEnvParams) ([]*sqlc_sqlite.Function, error) {
+	functions, err := q.db.GetAppFunctionsByEnv(ctx, GetAppFunctionsByEnvParams{EnvID: params.EnvID, AppID: params.AppID})
+	if err != nil {
+		return nil, err
+	}
+
+	sqliteFunctions := make([]*sqlc_sqlite.Function, len(functions))
+	for i, function := range functions {
+		sqliteFunctions[i], _ = function.ToSQLite()
+	}
+
+	return sqliteFunctions, nil
+}
+
+func (q NormalizedQueries) GetAppFunctionsBySlug(ctx context.Context, params sqlc_sqlite.GetAppFunctionsBySlugParams) ([]*sqlc_sqlite.Function, error) {
+	functions, err := q.db.GetAppFunctionsBySlug(ctx, GetAppFunctionsBySlugParams{EnvID: params.EnvID, Name: params.Name})
 	if err != nil {
 		return nil, err
 	}
@@ -348,14 +363,16 @@ func (q NormalizedQueries) UpdateFunctionConfig(ctx context.Context, arg sqlc_sq
 
 func (q NormalizedQueries) InsertEvent(ctx context.Context, e sqlc_sqlite.InsertEventParams) error {
 	pgParams := InsertEventParams{
-		InternalID: e.InternalID,
-		ReceivedAt: e.ReceivedAt,
-		EventID:    e.EventID,
-		EventName:  e.EventName,
-		EventData:  e.EventData,
-		EventUser:  e.EventUser,
-		EventV:     e.EventV,
-		EventTs:    e.EventTs,
+		InternalID:  e.InternalID,
+		ReceivedAt:  e.ReceivedAt,
+		EventID:     e.EventID,
+		EventName:   e.EventName,
+		EventData:   e.EventData,
+		EventUser:   e.EventUser,
+		EventV:      e.EventV,
+		EventTs:     e.EventTs,
+		AccountID:   e.AccountID,
+		WorkspaceID: e.WorkspaceID,
 	}
 
 	return q.db.InsertEvent(ctx, pgParams)
@@ -439,6 +456,7 @@ func (q NormalizedQueries) WorkspaceEvents(ctx context.Context, params sqlc_sqli
 	}
 
 	pgParams := WorkspaceEventsParams{
+		WorkspaceID:  params.WorkspaceID,
 		InternalID:   params.Cursor,
 		ReceivedAt:   params.Before,
 		ReceivedAt_2: params.After,
@@ -465,6 +483,7 @@ func (q NormalizedQueries) WorkspaceNamedEvents(ctx context.Context, params sqlc
 	}
 
 	pgParams := WorkspaceNamedEventsParams{
+		WorkspaceID:  params.WorkspaceID,
 		InternalID:   params.Cursor,
 		ReceivedAt:   params.Before,
 		ReceivedAt_2: params.After,
@@ -491,6 +510,7 @@ func (q NormalizedQueries) GetEventsIDbound(ctx context.Context, params sqlc_sql
 		InternalID_2: params.Before,
 		EventName:    params.IncludeInternal,
 		Limit:        int32(params.Limit),
+		WorkspaceID:  params.WorkspaceID,
 	}
 
 	events, err := q.db.GetEventsIDbound(ctx, pgParams)
@@ -27,12 +27,13 @@ type App struct {
 	Url         string
 	Method      string
 	AppVersion  sql.NullString
+	EnvID       uuid.UUID
 }
 
 type Event struct {
 	InternalID  ulid.ULID
-	AccountID   sql.NullString
-	WorkspaceID sql.NullString
+	AccountID   uuid.UUID
+	WorkspaceID uuid.UUID
 	Source      sql.NullString
 	SourceID    sql.NullString
 	ReceivedAt  time.Time
@@ -21,6 +21,7 @@ func (a *App) ToSQLite() (*sqlc.App, error) {
 		Url:         a.Url,
 		Method:      a.Method,
 		AppVersion:  a.AppVersion,
+		EnvID:       a.EnvID,
 	}, nil
 }
 
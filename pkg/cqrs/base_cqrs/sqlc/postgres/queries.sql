@@ -1,6 +1,6 @@
 -- name: UpsertApp :one
-INSERT INTO apps (id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, url, method, app_version)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+INSERT INTO apps (id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, url, method, app_version, env_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 ON CONFLICT(id) DO UPDATE SET
     name = excluded.name,
     sdk_language = excluded.sdk_language,
@@ -19,22 +19,22 @@ RETURNING *;
 SELECT * FROM apps WHERE id = $1;
 
 -- name: GetApps :many
-SELECT * FROM apps WHERE archived_at IS NULL;
+SELECT * FROM apps WHERE env_id = $1 AND archived_at IS NULL;
 
 -- name: GetAppByChecksum :one
-SELECT * FROM apps WHERE checksum = $1 AND archived_at IS NULL LIMIT 1;
+SELECT * FROM apps WHERE env_id = $1 AND checksum = $2 AND archived_at IS NULL LIMIT 1;
 
 -- name: GetAppByID :one
 SELECT * FROM apps WHERE id = $1 LIMIT 1;
 
 -- name: GetAppByURL :one
-SELECT * FROM apps WHERE url = $1 AND archived_at IS NULL LIMIT 1;
+SELECT * FROM apps WHERE env_id = $1 AND url = $2 AND archived_at IS NULL LIMIT 1;
 
 -- name: GetAppByName :one
-SELECT * FROM apps WHERE name = $1 AND archived_at IS NULL LIMIT 1;
+SELECT * FROM apps WHERE env_id = $1 AND name = $2 AND archived_at IS NULL LIMIT 1;
 
 -- name: GetAllApps :many
-SELECT * FROM apps WHERE archived_at IS NULL;
+SELECT * FROM apps WHERE env_id = $1 AND archived_at IS NULL;
 
 -- name: DeleteApp :exec
 UPDATE apps SET archived_at = CURRENT_TIMESTAMP WHERE id = $1;
@@ -67,13 +67,16 @@ AND apps.archived_at IS NULL;
 SELECT * FROM functions WHERE app_id = $1 AND archived_at IS NULL;
 
 -- name: GetAppFunctionsBySlug :many
-SELECT functions.* FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.name = $1 AND functions.archived_at IS NULL;
+SELECT functions.* FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.env_id = $1 AND apps.name = $2 AND functions.archived_at IS NULL;
+
+-- name: GetAppFunctionsByEnv :many
+SELECT functions.* FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.env_id = $1 AND functions.app_id = $2 AND functions.archived_at IS NULL;
 
 -- name: GetFunctionByID :one
-SELECT * FROM functions WHERE id = $1;
+SELECT functions.* FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.env_id = $1 AND functions.id = $2;
 
 -- name: GetFunctionBySlug :one
-SELECT * FROM functions WHERE slug = $1 AND archived_at IS NULL;
+SELECT functions.* FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.env_id = $1 AND functions.slug = $2 AND functions.archived_at IS NULL;
 
 -- name: UpdateFunctionConfig :one
 UPDATE functions SET config = $1, archived_at = NULL WHERE id = $2 RETURNING *;
@@ -138,8 +141,8 @@ SELECT * FROM function_finishes WHERE run_id IN (sqlc.slice('run_ids'));
 
 -- name: InsertEvent :exec
 INSERT INTO events
-    (internal_id, received_at, event_id, event_name, event_data, event_user, event_v, event_ts) VALUES
-    ($1, $2, $3, $4, $5, $6, $7, $8);
+    (internal_id, received_at, event_id, event_name, event_data, event_user, event_v, event_ts, account_id, workspace_id) VALUES
+    ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
 
 -- name: InsertEventBatch :exec
 INSERT INTO event_batches
@@ -165,6 +168,7 @@ LEFT OUTER JOIN function_runs AS r ON r.event_id = e.internal_id
 WHERE
     e.internal_id > $1
     AND e.internal_id < $2
+    AND e.workspace_id = $5
     AND (
         r.run_id IS NOT NULL
         OR CASE WHEN e.event_name LIKE 'inngest/%' THEN TRUE ELSE FALSE END = $3
@@ -173,10 +177,10 @@ ORDER BY e.internal_id DESC
 LIMIT $4;
 
 -- name: WorkspaceEvents :many
-SELECT * FROM events WHERE internal_id < $1 AND received_at <= $2 AND received_at >= $3 ORDER BY internal_id DESC LIMIT $4;
+SELECT * FROM events WHERE workspace_id = $1 AND internal_id < $2 AND received_at <= $3 AND received_at >= $4 ORDER BY internal_id DESC LIMIT $5;
 
 -- name: WorkspaceNamedEvents :many
-SELECT * FROM events WHERE internal_id < $1 AND received_at <= $2 AND received_at >= $3 AND event_name = $4 ORDER BY internal_id DESC LIMIT $5;
+SELECT * FROM events WHERE workspace_id = $1 AND internal_id < $2 AND received_at <= $3 AND received_at >= $4 AND event_name = $5 ORDER BY internal_id DESC LIMIT $6;
 
 
 --
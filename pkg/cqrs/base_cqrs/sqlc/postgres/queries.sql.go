@@ -72,11 +72,11 @@ func (q *Queries) DeleteOldQueueSnapshots(ctx context.Context, limit int32) (int
 }
 
 const getAllApps = `-- name: GetAllApps :many
-SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version FROM apps WHERE archived_at IS NULL
+SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id FROM apps WHERE env_id = $1 AND archived_at IS NULL
 `
 
-func (q *Queries) GetAllApps(ctx context.Context) ([]*App, error) {
-	rows, err := q.db.QueryContext(ctx, getAllApps)
+func (q *Queries) GetAllApps(ctx context.Context, envID uuid.UUID) ([]*App, error) {
+	rows, err := q.db.QueryContext(ctx, getAllApps, envID)
 	if err != nil {
 		return nil, err
 	}
@@ -99,6 +99,7 @@ func (q *Queries) GetAllApps(ctx context.Context) ([]*App, error) {
 			&i.Url,
 			&i.Method,
 			&i.AppVersion,
+			&i.EnvID,
 		); err != nil {
 			return nil, err
 		}
@@ -114,7 +115,7 @@ func (q *Queries) GetAllApps(ctx context.Context) ([]*App, error) {
 }
 
 const getApp = `-- name: GetApp :one
-SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version FROM apps WHERE id = $1
+SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id FROM apps WHERE id = $1
 `
 
 func (q *Queries) GetApp(ctx context.Context, id uuid.UUID) (*App, error) {
@@ -135,16 +136,22 @@ func (q *Queries) GetApp(ctx context.Context, id uuid.UUID) (*App, error) {
 		&i.Url,
 		&i.Method,
 		&i.AppVersion,
+		&i.EnvID,
 	)
 	return &i, err
 }
 
 const getAppByChecksum = `-- name: GetAppByChecksum :one
-SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version FROM apps WHERE checksum = $1 AND archived_at IS NULL LIMIT 1
+SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id FROM apps WHERE env_id = $1 AND checksum = $2 AND archived_at IS NULL LIMIT 1
 `
 
-func (q *Queries) GetAppByChecksum(ctx context.Context, checksum string) (*App, error) {
-	row := q.db.QueryRowContext(ctx, getAppByChecksum, checksum)
+type GetAppByChecksumParams struct {
+	EnvID    uuid.UUID
+	Checksum string
+}
+
+func (q *Queries) GetAppByChecksum(ctx context.Context, arg GetAppByChecksumParams) (*App, error) {
+	row := q.db.QueryRowContext(ctx, getAppByChecksum, arg.EnvID, arg.Checksum)
 	var i App
 	err := row.Scan(
 		&i.ID,
@@ -161,12 +168,13 @@ func (q *Queries) GetAppByChecksum(ctx context.Context, checksum string) (*App,
 		&i.Url,
 		&i.Method,
 		&i.AppVersion,
+		&i.EnvID,
 	)
 	return &i, err
 }
 
 const getAppByID = `-- name: GetAppByID :one
-SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version FROM apps WHERE id = $1 LIMIT 1
+SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id FROM apps WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetAppByID(ctx context.Context, id uuid.UUID) (*App, error) {
@@ -187,16 +195,22 @@ func (q *Queries) GetAppByID(ctx context.Context, id uuid.UUID) (*App, error) {
 		&i.Url,
 		&i.Method,
 		&i.AppVersion,
+		&i.EnvID,
 	)
 	return &i, err
 }
 
 const getAppByName = `-- name: GetAppByName :one
-SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version FROM apps WHERE name = $1 AND archived_at IS NULL LIMIT 1
+SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id FROM apps WHERE env_id = $1 AND name = $2 AND archived_at IS NULL LIMIT 1
 `
 
-func (q *Queries) GetAppByName(ctx context.Context, name string) (*App, error) {
-	row := q.db.QueryRowContext(ctx, getAppByName, name)
+type GetAppByNameParams struct {
+	EnvID uuid.UUID
+	Name  string
+}
+
+func (q *Queries) GetAppByName(ctx context.Context, arg GetAppByNameParams) (*App, error) {
+	row := q.db.QueryRowContext(ctx, getAppByName, arg.EnvID, arg.Name)
 	var i App
 	err := row.Scan(
 		&i.ID,
@@ -213,16 +227,22 @@ func (q *Queries) GetAppByName(ctx context.Context, name string) (*App, error) {
 		&i.Url,
 		&i.Method,
 		&i.AppVersion,
+		&i.EnvID,
 	)
 	return &i, err
 }
 
 const getAppByURL = `-- name: GetAppByURL :one
-SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version FROM apps WHERE url = $1 AND archived_at IS NULL LIMIT 1
+SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id FROM apps WHERE env_id = $1 AND url = $2 AND archived_at IS NULL LIMIT 1
 `
 
-func (q *Queries) GetAppByURL(ctx context.Context, url string) (*App, error) {
-	row := q.db.QueryRowContext(ctx, getAppByURL, url)
+type GetAppByURLParams struct {
+	EnvID uuid.UUID
+	Url   string
+}
+
+func (q *Queries) GetAppByURL(ctx context.Context, arg GetAppByURLParams) (*App, error) {
+	row := q.db.QueryRowContext(ctx, getAppByURL, arg.EnvID, arg.Url)
 	var i App
 	err := row.Scan(
 		&i.ID,
@@ -239,6 +259,7 @@ func (q *Queries) GetAppByURL(ctx context.Context, url string) (*App, error) {
 		&i.Url,
 		&i.Method,
 		&i.AppVersion,
+		&i.EnvID,
 	)
 	return &i, err
 }
@@ -278,12 +299,57 @@ func (q *Queries) GetAppFunctions(ctx context.Context, appID uuid.UUID) ([]*Func
 	return items, nil
 }
 
+const getAppFunctionsByEnv = `-- name: GetAppFunctionsByEnv :many
+SELECT functions.id, functions.app_id, functions.name, functions.slug, functions.config, functions.created_at, functions.archived_at FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.env_id = $1 AND functions.app_id = $2 AND functions.archived_at IS NULL
+`
+
+type GetAppFunctionsByEnvParams struct {
+	EnvID uuid.UUID
+	AppID uuid.UUID
+}
+
+func (q *Queries) GetAppFunctionsByEnv(ctx context.Context, arg GetAppFunctionsByEnvParams) ([]*Function, error) {
+	rows, err := q.db.QueryContext(ctx, getAppFunctionsByEnv, arg.EnvID, arg.AppID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Function
+	for rows.Next() {
+		var i Function
+		if err := rows.Scan(
+			&i.ID,
+			&i.AppID,
+			&i.Name,
+			&i.Slug,
+			&i.Config,
+			&i.CreatedAt,
+			&i.ArchivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAppFunctionsBySlug = `-- name: GetAppFunctionsBySlug :many
-SELECT functions.id, functions.app_id, functions.name, functions.slug, functions.config, functions.created_at, functions.archived_at FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.name = $1 AND functions.archived_at IS NULL
+SELECT functions.id, functions.app_id, functions.name, functions.slug, functions.config, functions.created_at, functions.archived_at FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.env_id = $1 AND apps.name = $2 AND functions.archived_at IS NULL
 `
 
-func (q *Queries) GetAppFunctionsBySlug(ctx context.Context, name string) ([]*Function, error) {
-	rows, err := q.db.QueryContext(ctx, getAppFunctionsBySlug, name)
+type GetAppFunctionsBySlugParams struct {
+	EnvID uuid.UUID
+	Name  string
+}
+
+func (q *Queries) GetAppFunctionsBySlug(ctx context.Context, arg GetAppFunctionsBySlugParams) ([]*Function, error) {
+	rows, err := q.db.QueryContext(ctx, getAppFunctionsBySlug, arg.EnvID, arg.Name)
 	if err != nil {
 		return nil, err
 	}
@@ -314,11 +380,11 @@ func (q *Queries) GetAppFunctionsBySlug(ctx context.Context, name string) ([]*Fu
 }
 
 const getApps = `-- name: GetApps :many
-SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version FROM apps WHERE archived_at IS NULL
+SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id FROM apps WHERE env_id = $1 AND archived_at IS NULL
 `
 
-func (q *Queries) GetApps(ctx context.Context) ([]*App, error) {
-	rows, err := q.db.QueryContext(ctx, getApps)
+func (q *Queries) GetApps(ctx context.Context, envID uuid.UUID) ([]*App, error) {
+	rows, err := q.db.QueryContext(ctx, getApps, envID)
 	if err != nil {
 		return nil, err
 	}
@@ -341,6 +407,7 @@ func (q *Queries) GetApps(ctx context.Context) ([]*App, error) {
 			&i.Url,
 			&i.Method,
 			&i.AppVersion,
+			&i.EnvID,
 		); err != nil {
 			return nil, err
 		}
@@ -484,6 +551,7 @@ LEFT OUTER JOIN function_runs AS r ON r.event_id = e.internal_id
 WHERE
     e.internal_id > $1
     AND e.internal_id < $2
+    AND e.workspace_id = $5
     AND (
         r.run_id IS NOT NULL
         OR CASE WHEN e.event_name LIKE 'inngest/%' THEN TRUE ELSE FALSE END = $3
@@ -497,6 +565,7 @@ type GetEventsIDboundParams struct {
 	InternalID_2 ulid.ULID
 	EventName    string
 	Limit        int32
+	WorkspaceID  uuid.UUID
 }
 
 func (q *Queries) GetEventsIDbound(ctx context.Context, arg GetEventsIDboundParams) ([]*Event, error) {
@@ -505,6 +574,7 @@ func (q *Queries) GetEventsIDbound(ctx context.Context, arg GetEventsIDboundPara
 		arg.InternalID_2,
 		arg.EventName,
 		arg.Limit,
+		arg.WorkspaceID,
 	)
 	if err != nil {
 		return nil, err
@@ -541,11 +611,16 @@ func (q *Queries) GetEventsIDbound(ctx context.Context, arg GetEventsIDboundPara
 }
 
 const getFunctionByID = `-- name: GetFunctionByID :one
-SELECT id, app_id, name, slug, config, created_at, archived_at FROM functions WHERE id = $1
+SELECT functions.id, functions.app_id, functions.name, functions.slug, functions.config, functions.created_at, functions.archived_at FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.env_id = $1 AND functions.id = $2
 `
 
-func (q *Queries) GetFunctionByID(ctx context.Context, id uuid.UUID) (*Function, error) {
-	row := q.db.QueryRowContext(ctx, getFunctionByID, id)
+type GetFunctionByIDParams struct {
+	EnvID uuid.UUID
+	ID    uuid.UUID
+}
+
+func (q *Queries) GetFunctionByID(ctx context.Context, arg GetFunctionByIDParams) (*Function, error) {
+	row := q.db.QueryRowContext(ctx, getFunctionByID, arg.EnvID, arg.ID)
 	var i Function
 	err := row.Scan(
 		&i.ID,
@@ -560,11 +635,16 @@ func (q *Queries) GetFunctionByID(ctx context.Context, id uuid.UUID) (*Function,
 }
 
 const getFunctionBySlug = `-- name: GetFunctionBySlug :one
-SELECT id, app_id, name, slug, config, created_at, archived_at FROM functions WHERE slug = $1 AND archived_at IS NULL
+SELECT functions.id, functions.app_id, functions.name, functions.slug, functions.config, functions.created_at, functions.archived_at FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.env_id = $1 AND functions.slug = $2 AND functions.archived_at IS NULL
 `
 
-func (q *Queries) GetFunctionBySlug(ctx context.Context, slug string) (*Function, error) {
-	row := q.db.QueryRowContext(ctx, getFunctionBySlug, slug)
+type GetFunctionBySlugParams struct {
+	EnvID uuid.UUID
+	Slug  string
+}
+
+func (q *Queries) GetFunctionBySlug(ctx context.Context, arg GetFunctionBySlugParams) (*Function, error) {
+	row := q.db.QueryRowContext(ctx, getFunctionBySlug, arg.EnvID, arg.Slug)
 	var i Function
 	err := row.Scan(
 		&i.ID,
@@ -1209,19 +1289,21 @@ const insertEvent = `-- name: InsertEvent :exec
 
 
 INSERT INTO events
-    (internal_id, received_at, event_id, event_name, event_data, event_user, event_v, event_ts) VALUES
-    ($1, $2, $3, $4, $5, $6, $7, $8)
+    (internal_id, received_at, event_id, event_name, event_data, event_user, event_v, event_ts, account_id, workspace_id) VALUES
+    ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 `
 
 type InsertEventParams struct {
-	InternalID ulid.ULID
-	ReceivedAt time.Time
-	EventID    string
-	EventName  string
-	EventData  string
-	EventUser  string
-	EventV     sql.NullString
-	EventTs    time.Time
+	InternalID  ulid.ULID
+	ReceivedAt  time.Time
+	EventID     string
+	EventName   string
+	EventData   string
+	EventUser   string
+	EventV      sql.NullString
+	EventTs     time.Time
+	AccountID   uuid.UUID
+	WorkspaceID uuid.UUID
 }
 
 // Events
@@ -1235,6 +1317,8 @@ func (q *Queries) InsertEvent(ctx context.Context, arg InsertEventParams) error
 		arg.EventUser,
 		arg.EventV,
 		arg.EventTs,
+		arg.AccountID,
+		arg.WorkspaceID,
 	)
 	return err
 }
@@ -1711,7 +1795,7 @@ func (q *Queries) InsertWorkerConnection(ctx context.Context, arg InsertWorkerCo
 }
 
 const updateAppError = `-- name: UpdateAppError :one
-UPDATE apps SET error = $1 WHERE id = $2 RETURNING id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version
+UPDATE apps SET error = $1 WHERE id = $2 RETURNING id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id
 `
 
 type UpdateAppErrorParams struct {
@@ -1737,12 +1821,13 @@ func (q *Queries) UpdateAppError(ctx context.Context, arg UpdateAppErrorParams)
 		&i.Url,
 		&i.Method,
 		&i.AppVersion,
+		&i.EnvID,
 	)
 	return &i, err
 }
 
 const updateAppURL = `-- name: UpdateAppURL :one
-UPDATE apps SET url = $1 WHERE id = $2 RETURNING id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version
+UPDATE apps SET url = $1 WHERE id = $2 RETURNING id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id
 `
 
 type UpdateAppURLParams struct {
@@ -1768,6 +1853,7 @@ func (q *Queries) UpdateAppURL(ctx context.Context, arg UpdateAppURLParams) (*Ap
 		&i.Url,
 		&i.Method,
 		&i.AppVersion,
+		&i.EnvID,
 	)
 	return &i, err
 }
@@ -1797,8 +1883,8 @@ func (q *Queries) UpdateFunctionConfig(ctx context.Context, arg UpdateFunctionCo
 }
 
 const upsertApp = `-- name: UpsertApp :one
-INSERT INTO apps (id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, url, method, app_version)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+INSERT INTO apps (id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, url, method, app_version, env_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 ON CONFLICT(id) DO UPDATE SET
     name = excluded.name,
     sdk_language = excluded.sdk_language,
@@ -1811,7 +1897,7 @@ ON CONFLICT(id) DO UPDATE SET
     archived_at = NULL,
     "method" = excluded.method,
     app_version = excluded.app_version
-RETURNING id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version
+RETURNING id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id
 `
 
 type UpsertAppParams struct {
@@ -1827,6 +1913,7 @@ type UpsertAppParams struct {
 	Url         string
 	Method      string
 	AppVersion  sql.NullString
+	EnvID       uuid.UUID
 }
 
 func (q *Queries) UpsertApp(ctx context.Context, arg UpsertAppParams) (*App, error) {
@@ -1843,6 +1930,7 @@ func (q *Queries) UpsertApp(ctx context.Context, arg UpsertAppParams) (*App, err
 		arg.Url,
 		arg.Method,
 		arg.AppVersion,
+		arg.EnvID,
 	)
 	var i App
 	err := row.Scan(
@@ -1860,15 +1948,17 @@ func (q *Queries) UpsertApp(ctx context.Context, arg UpsertAppParams) (*App, err
 		&i.Url,
 		&i.Method,
 		&i.AppVersion,
+		&i.EnvID,
 	)
 	return &i, err
 }
 
 const workspaceEvents = `-- name: WorkspaceEvents :many
-SELECT internal_id, account_id, workspace_id, source, source_id, received_at, event_id, event_name, event_data, event_user, event_v, event_ts FROM events WHERE internal_id < $1 AND received_at <= $2 AND received_at >= $3 ORDER BY internal_id DESC LIMIT $4
+SELECT internal_id, account_id, workspace_id, source, source_id, received_at, event_id, event_name, event_data, event_user, event_v, event_ts FROM events WHERE workspace_id = $1 AND internal_id < $2 AND received_at <= $3 AND received_at >= $4 ORDER BY internal_id DESC LIMIT $5
 `
 
 type WorkspaceEventsParams struct {
+	WorkspaceID  uuid.UUID
 	InternalID   ulid.ULID
 	ReceivedAt   time.Time
 	ReceivedAt_2 time.Time
@@ -1877,6 +1967,7 @@ type WorkspaceEventsParams struct {
 
 func (q *Queries) WorkspaceEvents(ctx context.Context, arg WorkspaceEventsParams) ([]*Event, error) {
 	rows, err := q.db.QueryContext(ctx, workspaceEvents,
+		arg.WorkspaceID,
 		arg.InternalID,
 		arg.ReceivedAt,
 		arg.ReceivedAt_2,
@@ -1917,10 +2008,11 @@ func (q *Queries) WorkspaceEvents(ctx context.Context, arg WorkspaceEventsParams
 }
 
 const workspaceNamedEvents = `-- name: WorkspaceNamedEvents :many
-SELECT internal_id, account_id, workspace_id, source, source_id, received_at, event_id, event_name, event_data, event_user, event_v, event_ts FROM events WHERE internal_id < $1 AND received_at <= $2 AND received_at >= $3 AND event_name = $4 ORDER BY internal_id DESC LIMIT $5
+SELECT internal_id, account_id, workspace_id, source, source_id, received_at, event_id, event_name, event_data, event_user, event_v, event_ts FROM events WHERE workspace_id = $1 AND internal_id < $2 AND received_at <= $3 AND received_at >= $4 AND event_name = $5 ORDER BY internal_id DESC LIMIT $6
 `
 
 type WorkspaceNamedEventsParams struct {
+	WorkspaceID  uuid.UUID
 	InternalID   ulid.ULID
 	ReceivedAt   time.Time
 	ReceivedAt_2 time.Time
@@ -1930,6 +2022,7 @@ type WorkspaceNamedEventsParams struct {
 
 func (q *Queries) WorkspaceNamedEvents(ctx context.Context, arg WorkspaceNamedEventsParams) ([]*Event, error) {
 	rows, err := q.db.QueryContext(ctx, workspaceNamedEvents,
+		arg.WorkspaceID,
 		arg.InternalID,
 		arg.ReceivedAt,
 		arg.ReceivedAt_2,
@@ -12,7 +12,8 @@ CREATE TABLE apps (
 	archived_at TIMESTAMP,
 	url VARCHAR NOT NULL,
     method VARCHAR(32) NOT NULL DEFAULT 'serve',
-    app_version VARCHAR(128)
+    app_version VARCHAR(128),
+    env_id CHAR(36) NOT NULL DEFAULT '00000000-0000-4000-b000-000000000000'
 );
 
 -- XXX: - this is very basic right now.  it does not conform to the cloud.
@@ -191,3 +192,18 @@ CREATE TABLE worker_connections (
 
     PRIMARY KEY(id, app_name)
 );
+
+CREATE TABLE audit_log (
+    id CHAR(26) PRIMARY KEY,
+    account_id UUID NOT NULL,
+    workspace_id UUID NOT NULL,
+
+    actor VARCHAR NOT NULL,
+    actor_method VARCHAR NOT NULL,
+    source VARCHAR NOT NULL,
+    action VARCHAR NOT NULL,
+    target_ids VARCHAR NOT NULL,
+    payload_hash VARCHAR NOT NULL,
+
+    created_at BIGINT NOT NULL
+);
@@ -27,12 +27,13 @@ type App struct {
 	Url         string
 	Method      string
 	AppVersion  sql.NullString
+	EnvID       uuid.UUID
 }
 
 type Event struct {
 	InternalID  ulid.ULID
-	AccountID   interface{}
-	WorkspaceID interface{}
+	AccountID   uuid.UUID
+	WorkspaceID uuid.UUID
 	Source      sql.NullString
 	SourceID    interface{}
 	ReceivedAt  time.Time
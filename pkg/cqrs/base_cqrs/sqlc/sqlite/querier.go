@@ -16,22 +16,23 @@ type Querier interface {
 	DeleteFunctionsByAppID(ctx context.Context, appID uuid.UUID) error
 	DeleteFunctionsByIDs(ctx context.Context, ids []uuid.UUID) error
 	DeleteOldQueueSnapshots(ctx context.Context, limit int64) (int64, error)
-	GetAllApps(ctx context.Context) ([]*App, error)
+	GetAllApps(ctx context.Context, envID uuid.UUID) ([]*App, error)
 	GetApp(ctx context.Context, id uuid.UUID) (*App, error)
-	GetAppByChecksum(ctx context.Context, checksum string) (*App, error)
+	GetAppByChecksum(ctx context.Context, arg GetAppByChecksumParams) (*App, error)
 	GetAppByID(ctx context.Context, id uuid.UUID) (*App, error)
-	GetAppByName(ctx context.Context, name string) (*App, error)
-	GetAppByURL(ctx context.Context, url string) (*App, error)
+	GetAppByName(ctx context.Context, arg GetAppByNameParams) (*App, error)
+	GetAppByURL(ctx context.Context, arg GetAppByURLParams) (*App, error)
 	GetAppFunctions(ctx context.Context, appID uuid.UUID) ([]*Function, error)
-	GetAppFunctionsBySlug(ctx context.Context, name string) ([]*Function, error)
-	GetApps(ctx context.Context) ([]*App, error)
+	GetAppFunctionsByEnv(ctx context.Context, arg GetAppFunctionsByEnvParams) ([]*Function, error)
+	GetAppFunctionsBySlug(ctx context.Context, arg GetAppFunctionsBySlugParams) ([]*Function, error)
+	GetApps(ctx context.Context, envID uuid.UUID) ([]*App, error)
 	GetEventBatchByRunID(ctx context.Context, runID ulid.ULID) (*EventBatch, error)
 	GetEventBatchesByEventID(ctx context.Context, instr string) ([]*EventBatch, error)
 	GetEventByInternalID(ctx context.Context, internalID ulid.ULID) (*Event, error)
 	GetEventsByInternalIDs(ctx context.Context, ids []ulid.ULID) ([]*Event, error)
 	GetEventsIDbound(ctx context.Context, arg GetEventsIDboundParams) ([]*Event, error)
-	GetFunctionByID(ctx context.Context, id uuid.UUID) (*Function, error)
-	GetFunctionBySlug(ctx context.Context, slug string) (*Function, error)
+	GetFunctionByID(ctx context.Context, arg GetFunctionByIDParams) (*Function, error)
+	GetFunctionBySlug(ctx context.Context, arg GetFunctionBySlugParams) (*Function, error)
 	GetFunctionRun(ctx context.Context, runID ulid.ULID) (*GetFunctionRunRow, error)
 	GetFunctionRunFinishesByRunIDs(ctx context.Context, runIds []ulid.ULID) ([]*FunctionFinish, error)
 	GetFunctionRunHistory(ctx context.Context, runID ulid.ULID) ([]*History, error)
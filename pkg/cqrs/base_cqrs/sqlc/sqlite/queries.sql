@@ -1,6 +1,6 @@
 -- name: UpsertApp :one
-INSERT INTO apps (id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, url, method, app_version)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+INSERT INTO apps (id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, url, method, app_version, env_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON CONFLICT(id) DO UPDATE SET
     name = excluded.name,
     sdk_language = excluded.sdk_language,
@@ -19,22 +19,22 @@ RETURNING *;
 SELECT * FROM apps WHERE id = ?;
 
 -- name: GetApps :many
-SELECT * FROM apps WHERE archived_at IS NULL;
+SELECT * FROM apps WHERE env_id = ? AND archived_at IS NULL;
 
 -- name: GetAppByChecksum :one
-SELECT * FROM apps WHERE checksum = ? AND archived_at IS NULL LIMIT 1;
+SELECT * FROM apps WHERE env_id = ? AND checksum = ? AND archived_at IS NULL LIMIT 1;
 
 -- name: GetAppByID :one
 SELECT * FROM apps WHERE id = ? LIMIT 1;
 
 -- name: GetAppByURL :one
-SELECT * FROM apps WHERE url = ? AND archived_at IS NULL LIMIT 1;
+SELECT * FROM apps WHERE env_id = ? AND url = ? AND archived_at IS NULL LIMIT 1;
 
 -- name: GetAppByName :one
-SELECT * FROM apps WHERE name = ? AND archived_at IS NULL LIMIT 1;
+SELECT * FROM apps WHERE env_id = ? AND name = ? AND archived_at IS NULL LIMIT 1;
 
 -- name: GetAllApps :many
-SELECT * FROM apps WHERE archived_at IS NULL;
+SELECT * FROM apps WHERE env_id = ? AND archived_at IS NULL;
 
 -- name: DeleteApp :exec
 UPDATE apps SET archived_at = datetime('now') WHERE id = ?;
@@ -68,13 +68,16 @@ AND apps.archived_at IS NULL;
 SELECT * FROM functions WHERE app_id = ? AND archived_at IS NULL;
 
 -- name: GetAppFunctionsBySlug :many
-SELECT functions.* FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.name = ? AND functions.archived_at IS NULL;
+SELECT functions.* FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.env_id = ? AND apps.name = ? AND functions.archived_at IS NULL;
+
+-- name: GetAppFunctionsByEnv :many
+SELECT functions.* FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.env_id = ? AND functions.app_id = ? AND functions.archived_at IS NULL;
 
 -- name: GetFunctionByID :one
-SELECT * FROM functions WHERE id = ?;
+SELECT functions.* FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.env_id = ? AND functions.id = ?;
 
 -- name: GetFunctionBySlug :one
-SELECT * FROM functions WHERE slug = ? AND archived_at IS NULL;
+SELECT functions.* FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.env_id = ? AND functions.slug = ? AND functions.archived_at IS NULL;
 
 -- name: UpdateFunctionConfig :one
 UPDATE functions SET config = ?, archived_at = NULL WHERE id = ? RETURNING *;
@@ -130,8 +133,8 @@ SELECT * FROM function_finishes WHERE run_id IN (sqlc.slice('run_ids'));
 
 -- name: InsertEvent :exec
 INSERT INTO events
-	(internal_id, received_at, event_id, event_name, event_data, event_user, event_v, event_ts) VALUES
-	(?, ?, ?, ?, ?, ?, ?, ?);
+	(internal_id, received_at, event_id, event_name, event_data, event_user, event_v, event_ts, account_id, workspace_id) VALUES
+	(?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
 
 -- name: InsertEventBatch :exec
 INSERT INTO event_batches
@@ -157,6 +160,7 @@ LEFT OUTER JOIN function_runs AS r ON r.event_id = e.internal_id
 WHERE
 	e.internal_id > @after
 	AND e.internal_id < @before
+	AND e.workspace_id = @workspace_id
 	AND (
 		-- Include internal events that triggered a run (e.g. an onFailure
 		-- handler)
@@ -171,10 +175,10 @@ ORDER BY e.internal_id DESC
 LIMIT ?;
 
 -- name: WorkspaceEvents :many
-SELECT * FROM events WHERE internal_id < @cursor AND received_at <= @before AND received_at >= @after ORDER BY internal_id DESC LIMIT ?;
+SELECT * FROM events WHERE workspace_id = @workspace_id AND internal_id < @cursor AND received_at <= @before AND received_at >= @after ORDER BY internal_id DESC LIMIT ?;
 
 -- name: WorkspaceNamedEvents :many
-SELECT * FROM events WHERE internal_id < @cursor AND received_at <= @before AND received_at >= @after AND event_name = @name ORDER BY internal_id DESC LIMIT ?;
+SELECT * FROM events WHERE workspace_id = @workspace_id AND internal_id < @cursor AND received_at <= @before AND received_at >= @after AND event_name = @name ORDER BY internal_id DESC LIMIT ?;
 
 --
 -- History
@@ -71,11 +71,11 @@ func (q *Queries) DeleteOldQueueSnapshots(ctx context.Context, limit int64) (int
 }
 
 const getAllApps = `-- name: GetAllApps :many
-SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version FROM apps WHERE archived_at IS NULL
+SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id FROM apps WHERE env_id = ? AND archived_at IS NULL
 `
 
-func (q *Queries) GetAllApps(ctx context.Context) ([]*App, error) {
-	rows, err := q.db.QueryContext(ctx, getAllApps)
+func (q *Queries) GetAllApps(ctx context.Context, envID uuid.UUID) ([]*App, error) {
+	rows, err := q.db.QueryContext(ctx, getAllApps, envID)
 	if err != nil {
 		return nil, err
 	}
@@ -98,6 +98,7 @@ func (q *Queries) GetAllApps(ctx context.Context) ([]*App, error) {
 			&i.Url,
 			&i.Method,
 			&i.AppVersion,
+			&i.EnvID,
 		); err != nil {
 			return nil, err
 		}
@@ -113,7 +114,7 @@ func (q *Queries) GetAllApps(ctx context.Context) ([]*App, error) {
 }
 
 const getApp = `-- name: GetApp :one
-SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version FROM apps WHERE id = ?
+SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id FROM apps WHERE id = ?
 `
 
 func (q *Queries) GetApp(ctx context.Context, id uuid.UUID) (*App, error) {
@@ -134,16 +135,22 @@ func (q *Queries) GetApp(ctx context.Context, id uuid.UUID) (*App, error) {
 		&i.Url,
 		&i.Method,
 		&i.AppVersion,
+		&i.EnvID,
 	)
 	return &i, err
 }
 
 const getAppByChecksum = `-- name: GetAppByChecksum :one
-SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version FROM apps WHERE checksum = ? AND archived_at IS NULL LIMIT 1
+SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id FROM apps WHERE env_id = ? AND checksum = ? AND archived_at IS NULL LIMIT 1
 `
 
-func (q *Queries) GetAppByChecksum(ctx context.Context, checksum string) (*App, error) {
-	row := q.db.QueryRowContext(ctx, getAppByChecksum, checksum)
+type GetAppByChecksumParams struct {
+	EnvID    uuid.UUID
+	Checksum string
+}
+
+func (q *Queries) GetAppByChecksum(ctx context.Context, arg GetAppByChecksumParams) (*App, error) {
+	row := q.db.QueryRowContext(ctx, getAppByChecksum, arg.EnvID, arg.Checksum)
 	var i App
 	err := row.Scan(
 		&i.ID,
@@ -160,12 +167,13 @@ func (q *Queries) GetAppByChecksum(ctx context.Context, checksum string) (*App,
 		&i.Url,
 		&i.Method,
 		&i.AppVersion,
+		&i.EnvID,
 	)
 	return &i, err
 }
 
 const getAppByID = `-- name: GetAppByID :one
-SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version FROM apps WHERE id = ? LIMIT 1
+SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id FROM apps WHERE id = ? LIMIT 1
 `
 
 func (q *Queries) GetAppByID(ctx context.Context, id uuid.UUID) (*App, error) {
@@ -186,16 +194,22 @@ func (q *Queries) GetAppByID(ctx context.Context, id uuid.UUID) (*App, error) {
 		&i.Url,
 		&i.Method,
 		&i.AppVersion,
+		&i.EnvID,
 	)
 	return &i, err
 }
 
 const getAppByName = `-- name: GetAppByName :one
-SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version FROM apps WHERE name = ? AND archived_at IS NULL LIMIT 1
+SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id FROM apps WHERE env_id = ? AND name = ? AND archived_at IS NULL LIMIT 1
 `
 
-func (q *Queries) GetAppByName(ctx context.Context, name string) (*App, error) {
-	row := q.db.QueryRowContext(ctx, getAppByName, name)
+type GetAppByNameParams struct {
+	EnvID uuid.UUID
+	Name  string
+}
+
+func (q *Queries) GetAppByName(ctx context.Context, arg GetAppByNameParams) (*App, error) {
+	row := q.db.QueryRowContext(ctx, getAppByName, arg.EnvID, arg.Name)
 	var i App
 	err := row.Scan(
 		&i.ID,
@@ -212,16 +226,22 @@ func (q *Queries) GetAppByName(ctx context.Context, name string) (*App, error) {
 		&i.Url,
 		&i.Method,
 		&i.AppVersion,
+		&i.EnvID,
 	)
 	return &i, err
 }
 
 const getAppByURL = `-- name: GetAppByURL :one
-SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version FROM apps WHERE url = ? AND archived_at IS NULL LIMIT 1
+SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id FROM apps WHERE env_id = ? AND url = ? AND archived_at IS NULL LIMIT 1
 `
 
-func (q *Queries) GetAppByURL(ctx context.Context, url string) (*App, error) {
-	row := q.db.QueryRowContext(ctx, getAppByURL, url)
+type GetAppByURLParams struct {
+	EnvID uuid.UUID
+	Url   string
+}
+
+func (q *Queries) GetAppByURL(ctx context.Context, arg GetAppByURLParams) (*App, error) {
+	row := q.db.QueryRowContext(ctx, getAppByURL, arg.EnvID, arg.Url)
 	var i App
 	err := row.Scan(
 		&i.ID,
@@ -238,6 +258,7 @@ func (q *Queries) GetAppByURL(ctx context.Context, url string) (*App, error) {
 		&i.Url,
 		&i.Method,
 		&i.AppVersion,
+		&i.EnvID,
 	)
 	return &i, err
 }
@@ -277,12 +298,57 @@ func (q *Queries) GetAppFunctions(ctx context.Context, appID uuid.UUID) ([]*Func
 	return items, nil
 }
 
+const getAppFunctionsByEnv = `-- name: GetAppFunctionsByEnv :many
+SELECT functions.id, functions.app_id, functions.name, functions.slug, functions.config, functions.created_at, functions.archived_at FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.env_id = ? AND functions.app_id = ? AND functions.archived_at IS NULL
+`
+
+type GetAppFunctionsByEnvParams struct {
+	EnvID uuid.UUID
+	AppID uuid.UUID
+}
+
+func (q *Queries) GetAppFunctionsByEnv(ctx context.Context, arg GetAppFunctionsByEnvParams) ([]*Function, error) {
+	rows, err := q.db.QueryContext(ctx, getAppFunctionsByEnv, arg.EnvID, arg.AppID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Function
+	for rows.Next() {
+		var i Function
+		if err := rows.Scan(
+			&i.ID,
+			&i.AppID,
+			&i.Name,
+			&i.Slug,
+			&i.Config,
+			&i.CreatedAt,
+			&i.ArchivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAppFunctionsBySlug = `-- name: GetAppFunctionsBySlug :many
-SELECT functions.id, functions.app_id, functions.name, functions.slug, functions.config, functions.created_at, functions.archived_at FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.name = ? AND functions.archived_at IS NULL
+SELECT functions.id, functions.app_id, functions.name, functions.slug, functions.config, functions.created_at, functions.archived_at FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.env_id = ? AND apps.name = ? AND functions.archived_at IS NULL
 `
 
-func (q *Queries) GetAppFunctionsBySlug(ctx context.Context, name string) ([]*Function, error) {
-	rows, err := q.db.QueryContext(ctx, getAppFunctionsBySlug, name)
+type GetAppFunctionsBySlugParams struct {
+	EnvID uuid.UUID
+	Name  string
+}
+
+func (q *Queries) GetAppFunctionsBySlug(ctx context.Context, arg GetAppFunctionsBySlugParams) ([]*Function, error) {
+	rows, err := q.db.QueryContext(ctx, getAppFunctionsBySlug, arg.EnvID, arg.Name)
 	if err != nil {
 		return nil, err
 	}
@@ -313,11 +379,11 @@ func (q *Queries) GetAppFunctionsBySlug(ctx context.Context, name string) ([]*Fu
 }
 
 const getApps = `-- name: GetApps :many
-SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version FROM apps WHERE archived_at IS NULL
+SELECT id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id FROM apps WHERE env_id = ? AND archived_at IS NULL
 `
 
-func (q *Queries) GetApps(ctx context.Context) ([]*App, error) {
-	rows, err := q.db.QueryContext(ctx, getApps)
+func (q *Queries) GetApps(ctx context.Context, envID uuid.UUID) ([]*App, error) {
+	rows, err := q.db.QueryContext(ctx, getApps, envID)
 	if err != nil {
 		return nil, err
 	}
@@ -340,6 +406,7 @@ func (q *Queries) GetApps(ctx context.Context) ([]*App, error) {
 			&i.Url,
 			&i.Method,
 			&i.AppVersion,
+			&i.EnvID,
 		); err != nil {
 			return nil, err
 		}
@@ -493,6 +560,7 @@ LEFT OUTER JOIN function_runs AS r ON r.event_id = e.internal_id
 WHERE
 	e.internal_id > ?
 	AND e.internal_id < ?
+	AND e.workspace_id = ?
 	AND (
 		-- Include internal events that triggered a run (e.g. an onFailure
 		-- handler)
@@ -510,6 +578,7 @@ LIMIT ?
 type GetEventsIDboundParams struct {
 	After           ulid.ULID
 	Before          ulid.ULID
+	WorkspaceID     uuid.UUID
 	IncludeInternal string
 	Limit           int64
 }
@@ -518,6 +587,7 @@ func (q *Queries) GetEventsIDbound(ctx context.Context, arg GetEventsIDboundPara
 	rows, err := q.db.QueryContext(ctx, getEventsIDbound,
 		arg.After,
 		arg.Before,
+		arg.WorkspaceID,
 		arg.IncludeInternal,
 		arg.Limit,
 	)
@@ -556,11 +626,16 @@ func (q *Queries) GetEventsIDbound(ctx context.Context, arg GetEventsIDboundPara
 }
 
 const getFunctionByID = `-- name: GetFunctionByID :one
-SELECT id, app_id, name, slug, config, created_at, archived_at FROM functions WHERE id = ?
+SELECT functions.id, functions.app_id, functions.name, functions.slug, functions.config, functions.created_at, functions.archived_at FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.env_id = ? AND functions.id = ?
 `
 
-func (q *Queries) GetFunctionByID(ctx context.Context, id uuid.UUID) (*Function, error) {
-	row := q.db.QueryRowContext(ctx, getFunctionByID, id)
+type GetFunctionByIDParams struct {
+	EnvID uuid.UUID
+	ID    uuid.UUID
+}
+
+func (q *Queries) GetFunctionByID(ctx context.Context, arg GetFunctionByIDParams) (*Function, error) {
+	row := q.db.QueryRowContext(ctx, getFunctionByID, arg.EnvID, arg.ID)
 	var i Function
 	err := row.Scan(
 		&i.ID,
@@ -575,11 +650,16 @@ func (q *Queries) GetFunctionByID(ctx context.Context, id uuid.UUID) (*Function,
 }
 
 const getFunctionBySlug = `-- name: GetFunctionBySlug :one
-SELECT id, app_id, name, slug, config, created_at, archived_at FROM functions WHERE slug = ? AND archived_at IS NULL
+SELECT functions.id, functions.app_id, functions.name, functions.slug, functions.config, functions.created_at, functions.archived_at FROM functions JOIN apps ON apps.id = functions.app_id WHERE apps.env_id = ? AND functions.slug = ? AND functions.archived_at IS NULL
 `
 
-func (q *Queries) GetFunctionBySlug(ctx context.Context, slug string) (*Function, error) {
-	row := q.db.QueryRowContext(ctx, getFunctionBySlug, slug)
+type GetFunctionBySlugParams struct {
+	EnvID uuid.UUID
+	Slug  string
+}
+
+func (q *Queries) GetFunctionBySlug(ctx context.Context, arg GetFunctionBySlugParams) (*Function, error) {
+	row := q.db.QueryRowContext(ctx, getFunctionBySlug, arg.EnvID, arg.Slug)
 	var i Function
 	err := row.Scan(
 		&i.ID,
@@ -1231,19 +1311,21 @@ func (q *Queries) HistoryCountRuns(ctx context.Context) (int64, error) {
 const insertEvent = `-- name: InsertEvent :exec
 
 INSERT INTO events
-	(internal_id, received_at, event_id, event_name, event_data, event_user, event_v, event_ts) VALUES
-	(?, ?, ?, ?, ?, ?, ?, ?)
+	(internal_id, received_at, event_id, event_name, event_data, event_user, event_v, event_ts, account_id, workspace_id) VALUES
+	(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type InsertEventParams struct {
-	InternalID ulid.ULID
-	ReceivedAt time.Time
-	EventID    string
-	EventName  string
-	EventData  string
-	EventUser  string
-	EventV     sql.NullString
-	EventTs    time.Time
+	InternalID  ulid.ULID
+	ReceivedAt  time.Time
+	EventID     string
+	EventName   string
+	EventData   string
+	EventUser   string
+	EventV      sql.NullString
+	EventTs     time.Time
+	AccountID   uuid.UUID
+	WorkspaceID uuid.UUID
 }
 
 // Events
@@ -1257,6 +1339,8 @@ func (q *Queries) InsertEvent(ctx context.Context, arg InsertEventParams) error
 		arg.EventUser,
 		arg.EventV,
 		arg.EventTs,
+		arg.AccountID,
+		arg.WorkspaceID,
 	)
 	return err
 }
@@ -1712,7 +1796,7 @@ func (q *Queries) InsertWorkerConnection(ctx context.Context, arg InsertWorkerCo
 }
 
 const updateAppError = `-- name: UpdateAppError :one
-UPDATE apps SET error = ? WHERE id = ? RETURNING id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version
+UPDATE apps SET error = ? WHERE id = ? RETURNING id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id
 `
 
 type UpdateAppErrorParams struct {
@@ -1738,12 +1822,13 @@ func (q *Queries) UpdateAppError(ctx context.Context, arg UpdateAppErrorParams)
 		&i.Url,
 		&i.Method,
 		&i.AppVersion,
+		&i.EnvID,
 	)
 	return &i, err
 }
 
 const updateAppURL = `-- name: UpdateAppURL :one
-UPDATE apps SET url = ? WHERE id = ? RETURNING id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version
+UPDATE apps SET url = ? WHERE id = ? RETURNING id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id
 `
 
 type UpdateAppURLParams struct {
@@ -1769,6 +1854,7 @@ func (q *Queries) UpdateAppURL(ctx context.Context, arg UpdateAppURLParams) (*Ap
 		&i.Url,
 		&i.Method,
 		&i.AppVersion,
+		&i.EnvID,
 	)
 	return &i, err
 }
@@ -1798,8 +1884,8 @@ func (q *Queries) UpdateFunctionConfig(ctx context.Context, arg UpdateFunctionCo
 }
 
 const upsertApp = `-- name: UpsertApp :one
-INSERT INTO apps (id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, url, method, app_version)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+INSERT INTO apps (id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, url, method, app_version, env_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON CONFLICT(id) DO UPDATE SET
     name = excluded.name,
     sdk_language = excluded.sdk_language,
@@ -1812,7 +1898,7 @@ ON CONFLICT(id) DO UPDATE SET
     archived_at = NULL,
     "method" = excluded.method,
     app_version = excluded.app_version
-RETURNING id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version
+RETURNING id, name, sdk_language, sdk_version, framework, metadata, status, error, checksum, created_at, archived_at, url, method, app_version, env_id
 `
 
 type UpsertAppParams struct {
@@ -1828,6 +1914,7 @@ type UpsertAppParams struct {
 	Url         string
 	Method      string
 	AppVersion  sql.NullString
+	EnvID       uuid.UUID
 }
 
 func (q *Queries) UpsertApp(ctx context.Context, arg UpsertAppParams) (*App, error) {
@@ -1844,6 +1931,7 @@ func (q *Queries) UpsertApp(ctx context.Context, arg UpsertAppParams) (*App, err
 		arg.Url,
 		arg.Method,
 		arg.AppVersion,
+		arg.EnvID,
 	)
 	var i App
 	err := row.Scan(
@@ -1861,23 +1949,26 @@ func (q *Queries) UpsertApp(ctx context.Context, arg UpsertAppParams) (*App, err
 		&i.Url,
 		&i.Method,
 		&i.AppVersion,
+		&i.EnvID,
 	)
 	return &i, err
 }
 
 const workspaceEvents = `-- name: WorkspaceEvents :many
-SELECT internal_id, account_id, workspace_id, source, source_id, received_at, event_id, event_name, event_data, event_user, event_v, event_ts FROM events WHERE internal_id < ? AND received_at <= ? AND received_at >= ? ORDER BY internal_id DESC LIMIT ?
+SELECT internal_id, account_id, workspace_id, source, source_id, received_at, event_id, event_name, event_data, event_user, event_v, event_ts FROM events WHERE workspace_id = ? AND internal_id < ? AND received_at <= ? AND received_at >= ? ORDER BY internal_id DESC LIMIT ?
 `
 
 type WorkspaceEventsParams struct {
-	Cursor ulid.ULID
-	Before time.Time
-	After  time.Time
-	Limit  int64
+	WorkspaceID uuid.UUID
+	Cursor      ulid.ULID
+	Before      time.Time
+	After       time.Time
+	Limit       int64
 }
 
 func (q *Queries) WorkspaceEvents(ctx context.Context, arg WorkspaceEventsParams) ([]*Event, error) {
 	rows, err := q.db.QueryContext(ctx, workspaceEvents,
+		arg.WorkspaceID,
 		arg.Cursor,
 		arg.Before,
 		arg.After,
@@ -1918,19 +2009,21 @@ func (q *Queries) WorkspaceEvents(ctx context.Context, arg WorkspaceEventsParams
 }
 
 const workspaceNamedEvents = `-- name: WorkspaceNamedEvents :many
-SELECT internal_id, account_id, workspace_id, source, source_id, received_at, event_id, event_name, event_data, event_user, event_v, event_ts FROM events WHERE internal_id < ? AND received_at <= ? AND received_at >= ? AND event_name = ? ORDER BY internal_id DESC LIMIT ?
+SELECT internal_id, account_id, workspace_id, source, source_id, received_at, event_id, event_name, event_data, event_user, event_v, event_ts FROM events WHERE workspace_id = ? AND internal_id < ? AND received_at <= ? AND received_at >= ? AND event_name = ? ORDER BY internal_id DESC LIMIT ?
 `
 
 type WorkspaceNamedEventsParams struct {
-	Cursor ulid.ULID
-	Before time.Time
-	After  time.Time
-	Name   string
-	Limit  int64
+	WorkspaceID uuid.UUID
+	Cursor      ulid.ULID
+	Before      time.Time
+	After       time.Time
+	Name        string
+	Limit       int64
 }
 
 func (q *Queries) WorkspaceNamedEvents(ctx context.Context, arg WorkspaceNamedEventsParams) ([]*Event, error) {
 	rows, err := q.db.QueryContext(ctx, workspaceNamedEvents,
+		arg.WorkspaceID,
 		arg.Cursor,
 		arg.Before,
 		arg.After,
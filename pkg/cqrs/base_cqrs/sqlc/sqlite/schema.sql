@@ -12,7 +12,8 @@ CREATE TABLE apps (
 	archived_at TIMESTAMP,
 	url VARCHAR NOT NULL,
     method VARCHAR NOT NULL DEFAULT 'serve',
-    app_version VARCHAR
+    app_version VARCHAR,
+    env_id CHAR(36) NOT NULL DEFAULT '00000000-0000-4000-b000-000000000000'
 );
 
 CREATE TABLE events (
@@ -188,3 +189,18 @@ CREATE TABLE worker_connections (
 
     PRIMARY KEY(id, app_name)
 );
+
+CREATE TABLE audit_log (
+    id CHAR(26) PRIMARY KEY,
+    account_id CHAR(36) NOT NULL,
+    workspace_id CHAR(36) NOT NULL,
+
+    actor VARCHAR NOT NULL,
+    actor_method VARCHAR NOT NULL,
+    source VARCHAR NOT NULL,
+    action VARCHAR NOT NULL,
+    target_ids VARCHAR NOT NULL,
+    payload_hash VARCHAR NOT NULL,
+
+    created_at INT NOT NULL
+);
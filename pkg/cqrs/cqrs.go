@@ -36,6 +36,9 @@ type Manager interface {
 	// Connection history
 	ConnectionHistoryReadWriter
 
+	// Audit log of administrative actions
+	AuditLogReadWriter
+
 	// Scoped allows creating a new manager using a transaction.
 	WithTx(ctx context.Context) (TxManager, error)
 }
@@ -119,6 +119,7 @@ type EventReader interface {
 	GetEventBatchByRunID(ctx context.Context, runID ulid.ULID) (*EventBatch, error)
 	GetEventsIDbound(
 		ctx context.Context,
+		workspaceID uuid.UUID,
 		ids IDBound,
 		limit int,
 		includeInternal bool,
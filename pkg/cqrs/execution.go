@@ -3,6 +3,7 @@ package cqrs
 import (
 	"context"
 
+	"github.com/google/uuid"
 	"github.com/inngest/inngest/pkg/inngest"
 )
 
@@ -13,6 +14,9 @@ type ExecutionLoader interface {
 	Functions(ctx context.Context) ([]inngest.Function, error)
 	// FunctionsScheduled returns all scheduled functions available.
 	FunctionsScheduled(ctx context.Context) ([]inngest.Function, error)
-	// FunctionsByTrigger returns functions for the given trigger by event name.
-	FunctionsByTrigger(ctx context.Context, eventName string) ([]inngest.Function, error)
+	// FunctionsByEnv returns all functions within the given environment.
+	FunctionsByEnv(ctx context.Context, envID uuid.UUID) ([]inngest.Function, error)
+	// FunctionsByTrigger returns functions within the given environment for
+	// the given trigger by event name.
+	FunctionsByTrigger(ctx context.Context, envID uuid.UUID, eventName string) ([]inngest.Function, error)
 }
@@ -43,6 +43,7 @@ package config
 		} | *{
 			http: #HTTPDriver
 			connect: #ConnectDriver
+			wasm: #WasmDriver
 		}
 
 		// logOutput logs output from steps within logs.  This may
@@ -205,7 +206,7 @@ package config
 }
 
 // Drivers handle execution of each step within a function.
-#Driver: #MockDriver | #HTTPDriver | #ConnectDriver
+#Driver: #MockDriver | #HTTPDriver | #ConnectDriver | #WasmDriver
 
 // MockDriver is used in testing to mock and stub function executions.  You
 // almost certainly do not need to include this in your config.
@@ -218,8 +219,39 @@ package config
 	name:        "http"
 	timeout?:    int | *7200 // 2 hours
 	signingKey?: string
+	// transport configures the transport used to call every app.
+	transport?: #HTTPTransport
+	// apps configures the transport for individual apps, keyed by the host of
+	// the app's URL.
+	apps?: [string]: #HTTPTransport
+}
+
+// HTTPTransport configures connection pooling, TLS, and proxies for requests
+// made by the HTTP driver.
+#HTTPTransport: {
+	maxIdleConns?:        int
+	maxIdleConnsPerHost?: int
+	// idleTimeout is the number of seconds an idle connection is kept open.
+	idleTimeout?:       int
+	disableKeepAlives?: bool
+	disableHTTP2?:      bool
+	http2Cleartext?:    bool
+	caCertFile?:        string
+	clientCertFile?:    string
+	clientKeyFile?:     string
+	proxy?:             string
+	headers?: [string]: string
 }
 
 #ConnectDriver: {
 	name:        "connect"
 }
+
+// WasmDriver executes WASI modules in-process for steps with wasm:// URIs.
+#WasmDriver: {
+	name:         "wasm"
+	maxMemoryMB?: int | *128
+	timeout?:     int | *30
+	moduleDir?:   string
+	allowRemote?: bool | *false
+}
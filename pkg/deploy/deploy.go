@@ -15,6 +15,7 @@ import (
 	"github.com/inngest/inngest/pkg/execution/driver/httpdriver"
 	"github.com/inngest/inngest/pkg/headers"
 	"github.com/inngest/inngest/pkg/publicerr"
+	"github.com/inngest/inngest/pkg/sdk"
 	"github.com/inngest/inngestgo"
 )
 
@@ -36,8 +37,25 @@ var (
 		Timeout:       10 * time.Second,
 		CheckRedirect: httpdriver.CheckRedirect,
 	}
+
+	// Clients, if set, supplies the client used to call each app in place of
+	// Client, so that syncs use the same per-app transport config (eg. mTLS)
+	// as function calls.
+	Clients *httpdriver.ClientPool
 )
 
+// do sends the request using the client for the request's app.
+func do(req *http.Request) (*http.Response, error) {
+	if Clients == nil {
+		return Client.Do(req)
+	}
+	c, err := Clients.Client(*req.URL)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
 type pingResult struct {
 	Err error
 
@@ -88,7 +106,7 @@ func Ping(ctx context.Context, url string, serverKind string, signingKey string,
 		req.Header.Set(headers.HeaderKeySignature, reqSig)
 	}
 
-	resp, err := Client.Do(req)
+	resp, err := do(req)
 	if err != nil {
 		err = handlePingError(err)
 		return pingResult{
@@ -178,3 +196,130 @@ func GetDeployError(resp *http.Response) error {
 	}
 	return nil
 }
+
+// ErrOutOfBandSync is returned by FetchRegistration when the SDK doesn't
+// support in-band syncs, and instead sends its registration to a server.
+var ErrOutOfBandSync = fmt.Errorf("the app doesn't support in-band syncs")
+
+// fetchServerKind is sent as the server kind when fetching registrations.
+// SDKs which list in-band support may still have in-band syncs disabled, and
+// sync out-of-band instead, forwarding the server kind as the kind they
+// expect to register with.  No server has this kind, so such registrations
+// are refused.
+const fetchServerKind = "registration-fetch"
+
+// FetchRegistration returns the registration of the app at the given URL
+// without registering it with any server.
+//
+// The app's capabilities are first read via its introspection endpoint, and
+// the registration is only requested if the app supports in-band syncs;
+// otherwise ErrOutOfBandSync is returned.  Requesting a sync from SDKs without
+// in-band support would register the app with the server the SDK is
+// configured to use.  Most SDKs only list their capabilities to signed
+// requests, so the signing key should be given.
+func FetchRegistration(ctx context.Context, url string, signingKey string) (*sdk.RegisterRequest, error) {
+	capabilities, err := introspectCapabilities(ctx, url, signingKey)
+	if err != nil {
+		return nil, err
+	}
+	if capabilities.InBandSync != sdk.InBandSyncV1 {
+		if signingKey == "" {
+			return nil, fmt.Errorf("%w: the app only lists whether it supports in-band syncs to signed requests", DeployErrNoSigningKey)
+		}
+		return nil, ErrOutOfBandSync
+	}
+
+	reqByt, err := json.Marshal(map[string]string{"url": url})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(reqByt))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set(headers.HeaderKeySyncKind, sdk.SyncKindInBand)
+	req.Header.Set(headers.HeaderKeyServerKind, fetchServerKind)
+	if signingKey != "" {
+		reqSig, err := inngestgo.Sign(ctx, time.Now(), []byte(signingKey), reqByt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+		req.Header.Set(headers.HeaderKeySignature, reqSig)
+	}
+
+	resp, err := do(req)
+	if err != nil {
+		return nil, handlePingError(err)
+	}
+	defer resp.Body.Close()
+
+	inBand := resp.Header.Get(headers.HeaderKeySyncKind) == sdk.SyncKindInBand
+	if !inBand && resp.StatusCode >= http.StatusInternalServerError {
+		// The SDK attempted an out-of-band sync, which was refused.
+		return nil, ErrOutOfBandSync
+	}
+	if err := GetDeployError(resp); err != nil {
+		return nil, err
+	}
+	if !inBand {
+		return nil, ErrOutOfBandSync
+	}
+
+	byt, err := io.ReadAll(io.LimitReader(resp.Body, 10*1024*1024))
+	if err != nil {
+		return nil, err
+	}
+	r, err := sdk.ParseRegistration(byt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// introspectCapabilities returns the capabilities listed by the app's
+// introspection endpoint.  Capabilities are empty if the app doesn't list
+// them, eg. as the SDK predates them.
+func introspectCapabilities(ctx context.Context, url string, signingKey string) (sdk.Capabilities, error) {
+	var capabilities sdk.Capabilities
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return capabilities, err
+	}
+	if signingKey != "" {
+		reqSig, err := inngestgo.Sign(ctx, time.Now(), []byte(signingKey), nil)
+		if err != nil {
+			return capabilities, fmt.Errorf("failed to sign request: %w", err)
+		}
+		req.Header.Set(headers.HeaderKeySignature, reqSig)
+	}
+
+	resp, err := do(req)
+	if err != nil {
+		return capabilities, handlePingError(err)
+	}
+	defer resp.Body.Close()
+
+	if err := GetDeployError(resp); err != nil {
+		return capabilities, err
+	}
+
+	inspection := struct {
+		AuthenticationSucceeded *bool            `json:"authentication_succeeded"`
+		Capabilities            sdk.Capabilities `json:"capabilities"`
+	}{}
+	byt, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return capabilities, err
+	}
+	if err := json.Unmarshal(byt, &inspection); err != nil {
+		// The app doesn't support introspection.
+		return capabilities, nil
+	}
+	if inspection.AuthenticationSucceeded != nil && !*inspection.AuthenticationSucceeded {
+		return capabilities, DeployErrInvalidSigningKey
+	}
+	return inspection.Capabilities, nil
+}
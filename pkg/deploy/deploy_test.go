@@ -3,12 +3,20 @@ package deploy
 import (
 	"context"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/inngest/inngest/pkg/execution/driver/httpdriver"
+	"github.com/inngest/inngest/pkg/headers"
+	"github.com/inngest/inngest/pkg/sdk"
 	"github.com/stretchr/testify/require"
 )
 
@@ -68,3 +76,139 @@ func TestPing(t *testing.T) {
 		r.NotEmpty(reqHeader.Get("x-inngest-signature"))
 	})
 }
+
+func TestFetchRegistration(t *testing.T) {
+	ctx := context.Background()
+
+	// newApp returns an app listing the given capabilities to signed
+	// introspection requests, recording each request's method.
+	newApp := func(t *testing.T, capabilities sdk.Capabilities, methods *[]string) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*methods = append(*methods, r.Method)
+			if r.Method == http.MethodGet {
+				inspection := map[string]any{"function_count": 1}
+				if r.Header.Get(headers.HeaderKeySignature) != "" {
+					inspection["authentication_succeeded"] = true
+					inspection["capabilities"] = capabilities
+				}
+				_ = json.NewEncoder(w).Encode(inspection)
+				return
+			}
+			require.Equal(t, sdk.SyncKindInBand, r.Header.Get(headers.HeaderKeySyncKind))
+			require.Equal(t, fetchServerKind, r.Header.Get(headers.HeaderKeyServerKind))
+			require.NotEmpty(t, r.Header.Get(headers.HeaderKeySignature))
+			w.Header().Set(headers.HeaderKeySyncKind, sdk.SyncKindInBand)
+			_, _ = w.Write([]byte(`{"app_id":"app","sdk_language":"js","sdk_version":"3.30.0","functions":[{"id":"app-fn","name":"fn"}]}`))
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+
+	t.Run("in band", func(t *testing.T) {
+		var methods []string
+		srv := newApp(t, sdk.Capabilities{InBandSync: sdk.InBandSyncV1}, &methods)
+
+		r, err := FetchRegistration(ctx, srv.URL, "deadbeef")
+		require.NoError(t, err)
+		require.Equal(t, "app", r.AppName)
+		require.Len(t, r.Functions, 1)
+		require.Equal(t, []string{http.MethodGet, http.MethodPut}, methods)
+	})
+
+	t.Run("out of band", func(t *testing.T) {
+		var methods []string
+		srv := newApp(t, sdk.Capabilities{TrustProbe: sdk.TrustProbeV1}, &methods)
+
+		_, err := FetchRegistration(ctx, srv.URL, "deadbeef")
+		require.ErrorIs(t, err, ErrOutOfBandSync)
+		// The app must never be asked to sync, as it would register itself.
+		require.Equal(t, []string{http.MethodGet}, methods)
+	})
+
+	t.Run("in band disabled", func(t *testing.T) {
+		// SDKs list in-band support even when in-band syncs are disabled,
+		// then fail to register out-of-band as the server kind doesn't match.
+		var serverKind string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				_ = json.NewEncoder(w).Encode(map[string]any{
+					"authentication_succeeded": true,
+					"capabilities":             sdk.Capabilities{InBandSync: sdk.InBandSyncV1},
+				})
+				return
+			}
+			serverKind = r.Header.Get(headers.HeaderKeyServerKind)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer srv.Close()
+
+		_, err := FetchRegistration(ctx, srv.URL, "deadbeef")
+		require.ErrorIs(t, err, ErrOutOfBandSync)
+		require.Equal(t, fetchServerKind, serverKind)
+	})
+
+	t.Run("without introspection", func(t *testing.T) {
+		var methods []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			methods = append(methods, r.Method)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer srv.Close()
+
+		_, err := FetchRegistration(ctx, srv.URL, "deadbeef")
+		require.ErrorIs(t, err, ErrOutOfBandSync)
+		require.Equal(t, []string{http.MethodGet}, methods)
+	})
+
+	t.Run("without a signing key", func(t *testing.T) {
+		var methods []string
+		srv := newApp(t, sdk.Capabilities{InBandSync: sdk.InBandSyncV1}, &methods)
+
+		_, err := FetchRegistration(ctx, srv.URL, "")
+		require.ErrorIs(t, err, DeployErrNoSigningKey)
+		require.Equal(t, []string{http.MethodGet}, methods)
+	})
+
+	t.Run("invalid signing key", func(t *testing.T) {
+		var methods []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			methods = append(methods, r.Method)
+			_, _ = w.Write([]byte(`{"authentication_succeeded":false}`))
+		}))
+		defer srv.Close()
+
+		_, err := FetchRegistration(ctx, srv.URL, "deadbeef")
+		require.ErrorIs(t, err, DeployErrInvalidSigningKey)
+		require.Equal(t, []string{http.MethodGet}, methods)
+	})
+}
+
+func TestPingUsesClientPool(t *testing.T) {
+	var pinged bool
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pinged = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: srv.Certificate().Raw,
+	}), 0600))
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	prev := Clients
+	defer func() { Clients = prev }()
+	// Allow local requests, as self-hosted services do.
+	def := &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+	Clients = httpdriver.NewClientPool(def, httpdriver.TransportConfig{}, map[string]httpdriver.TransportConfig{
+		u.Host: {CACertFile: ca},
+	})
+
+	res := Ping(context.Background(), srv.URL, "self-hosted", "", false)
+	require.NoError(t, res.Err)
+	require.True(t, pinged)
+}
@@ -19,8 +19,10 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/inngest/inngest/pkg/api/tel"
+	"github.com/inngest/inngest/pkg/audit"
 	"github.com/inngest/inngest/pkg/consts"
 	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/environment"
 	"github.com/inngest/inngest/pkg/headers"
 	"github.com/inngest/inngest/pkg/inngest"
 	"github.com/inngest/inngest/pkg/inngest/log"
@@ -65,6 +67,8 @@ func (a *devapi) addRoutes() {
 
 	a.Get("/dev", a.Info)
 	a.Post("/dev/traces", a.OTLPTrace)
+	a.Get("/dev/envs", a.Environments)
+	a.Get("/dev/envs/{name}", a.SwitchEnvironment)
 	a.Post("/fn/register", a.Register)
 	// This allows tests to remove apps by URL
 	a.Delete("/fn/remove", a.RemoveApp)
@@ -122,13 +126,7 @@ func (a devapi) Info(w http.ResponseWriter, r *http.Request) {
 	a.devserver.handlerLock.Lock()
 	defer a.devserver.handlerLock.Unlock()
 
-	all, _ := a.devserver.Data.GetFunctions(r.Context())
-	funcs := make([]inngest.Function, len(all))
-	for n, i := range all {
-		f := inngest.Function{}
-		_ = json.Unmarshal([]byte(i.Config), &f)
-		funcs[n] = f
-	}
+	funcs, _ := a.devserver.Data.FunctionsByEnv(r.Context(), environment.IDFromContext(r.Context()))
 
 	features := map[string]bool{}
 	for _, flag := range featureFlags {
@@ -151,6 +149,52 @@ func (a devapi) Info(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(byt)
 }
 
+// Environments lists each environment, marking the environment used by the
+// current request.
+func (a devapi) Environments(w http.ResponseWriter, r *http.Request) {
+	current := environment.IDFromContext(r.Context())
+
+	type env struct {
+		ID      uuid.UUID `json:"id"`
+		Name    string    `json:"name"`
+		Current bool      `json:"current"`
+	}
+	envs := []env{}
+	for _, e := range a.devserver.environments() {
+		envs = append(envs, env{ID: e.ID, Name: e.Name, Current: e.ID == current})
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	byt, _ := json.MarshalIndent(envs, "", "  ")
+	_, _ = w.Write(byt)
+}
+
+// SwitchEnvironment selects the environment shown within the UI by setting the
+// environment cookie, then redirects to the UI.
+func (a devapi) SwitchEnvironment(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	found := false
+	for _, e := range a.devserver.environments() {
+		if e.Name == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		_ = publicerr.WriteHTTP(w, publicerr.Errorf(404, "environment %q not found", name))
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     environment.CookieName,
+		Value:    name,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
 // Register regsters functions served via SDKs
 func (a devapi) Register(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
@@ -174,7 +218,7 @@ func (a devapi) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	reply, err := a.register(ctx, req)
+	reply, err := SyncApp(ctx, a.devserver.Data, req)
 	if err != nil {
 		logger.From(ctx).Warn().Msgf("Error registering functions:\n%s", err)
 		_ = publicerr.WriteHTTP(w, err)
@@ -197,7 +241,9 @@ func (a devapi) Register(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(resp)
 }
 
-func (a devapi) register(ctx context.Context, r sdk.RegisterRequest) (*sync.Reply, error) {
+// SyncApp stores the app and functions within the given request, within the
+// environment of ctx.  Functions no longer served by the app are removed.
+func SyncApp(ctx context.Context, data cqrs.Manager, r sdk.RegisterRequest) (*sync.Reply, error) {
 	sum, err := r.Checksum()
 	if err != nil {
 		return nil, publicerr.Wrap(err, 400, "Invalid request")
@@ -206,7 +252,11 @@ func (a devapi) register(ctx context.Context, r sdk.RegisterRequest) (*sync.Repl
 	// TODO Retrieve same syncID for connect, if r.IdempotencyKey is the same
 	syncID := uuid.New()
 
-	if app, err := a.devserver.Data.GetAppByChecksum(ctx, consts.DevServerEnvID, sum); err == nil {
+	// Apps and functions are registered within the environment of the
+	// request, eg. as selected by the SDK's signing key.
+	envID := environment.IDFromContext(ctx)
+
+	if app, err := data.GetAppByChecksum(ctx, envID, sum); err == nil {
 		if !app.Error.Valid {
 			// Skip registration since the app was already successfully
 			// registered.
@@ -218,7 +268,7 @@ func (a devapi) register(ctx context.Context, r sdk.RegisterRequest) (*sync.Repl
 		}
 
 		// Clear app error.
-		_, err = a.devserver.Data.UpdateAppError(
+		_, err = data.UpdateAppError(
 			ctx,
 			cqrs.UpdateAppErrorParams{
 				ID:    app.ID,
@@ -235,12 +285,12 @@ func (a devapi) register(ctx context.Context, r sdk.RegisterRequest) (*sync.Repl
 	//
 	// We need to do this as we always create an app when entering the URL
 	// via the UI.  This is a dev-server specific quirk.
-	appID := inngest.DeterministicAppUUID(r.URL)
+	appID := environment.ScopedID(envID, inngest.DeterministicAppUUID(r.URL))
 	if r.IsConnect() {
 		appID = uuid.New()
 	}
 
-	tx, err := a.devserver.Data.WithTx(ctx)
+	tx, err := data.WithTx(ctx)
 	if err != nil {
 		return nil, publicerr.Wrap(err, 500, "Error starting registration tx")
 	}
@@ -265,6 +315,7 @@ func (a devapi) register(ctx context.Context, r sdk.RegisterRequest) (*sync.Repl
 			Checksum:   sum,
 			Method:     method.String(),
 			AppVersion: r.AppVersion,
+			EnvID:      envID,
 		}
 
 		// We want to save an app at the end, after handling each error.
@@ -282,7 +333,7 @@ func (a devapi) register(ctx context.Context, r sdk.RegisterRequest) (*sync.Repl
 	}()
 
 	// Get a list of all functions
-	existing, _ := tx.GetFunctionsByAppInternalID(ctx, consts.DevServerEnvID, appID)
+	existing, _ := tx.GetFunctionsByAppInternalID(ctx, envID, appID)
 	// And get a list of functions that we've upserted.  We'll delete all existing functions not in
 	// this set.
 	seen := map[uuid.UUID]struct{}{}
@@ -297,7 +348,7 @@ func (a devapi) register(ctx context.Context, r sdk.RegisterRequest) (*sync.Repl
 	// For each function,
 	for _, fn := range funcs {
 		// Create a new UUID for the function.
-		fn.ID = fn.DeterministicUUID()
+		fn.ID = environment.ScopedID(envID, fn.DeterministicUUID())
 
 		// Mark as seen.
 		seen[fn.ID] = struct{}{}
@@ -307,7 +358,7 @@ func (a devapi) register(ctx context.Context, r sdk.RegisterRequest) (*sync.Repl
 			return nil, publicerr.Wrap(err, 500, "Error marshalling function")
 		}
 
-		if _, err := tx.GetFunctionByInternalUUID(ctx, consts.DevServerEnvID, fn.ID); err == nil {
+		if _, err := tx.GetFunctionByInternalUUID(ctx, envID, fn.ID); err == nil {
 			// Update the function config.
 			_, err = tx.UpdateFunctionConfig(ctx, cqrs.UpdateFunctionConfigParams{
 				ID:     fn.ID,
@@ -531,7 +582,7 @@ func (a devapi) RemoveApp(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	url := r.FormValue("url")
 
-	app, err := a.devserver.Data.GetAppByURL(ctx, consts.DevServerEnvID, url)
+	app, err := a.devserver.Data.GetAppByURL(ctx, environment.IDFromContext(ctx), url)
 	if err != nil {
 		_ = publicerr.WriteHTTP(w, publicerr.Wrapf(err, 404, "App not found: %s", url))
 		return
@@ -546,6 +597,14 @@ func (a devapi) RemoveApp(w http.ResponseWriter, r *http.Request) {
 		_ = publicerr.WriteHTTP(w, publicerr.Wrap(err, 500, "Error deleting app"))
 		return
 	}
+
+	audit.Record(ctx, a.devserver.Data, cqrs.AuditEntry{
+		AccountID:   consts.DevServerAccountID,
+		WorkspaceID: environment.IDFromContext(ctx),
+		Source:      audit.SourceDevServer,
+		Action:      cqrs.AuditActionAppDelete,
+		TargetIDs:   []string{app.ID.String()},
+	}, map[string]string{"url": url})
 }
 
 func (a devapi) SetStepLimit(w http.ResponseWriter, r *http.Request) {
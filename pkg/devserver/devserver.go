@@ -2,8 +2,11 @@ package devserver
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
@@ -30,6 +33,7 @@ import (
 	"github.com/inngest/inngest/pkg/cqrs/base_cqrs"
 	"github.com/inngest/inngest/pkg/deploy"
 	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/environment"
 	"github.com/inngest/inngest/pkg/event"
 	"github.com/inngest/inngest/pkg/execution"
 	"github.com/inngest/inngest/pkg/execution/batch"
@@ -42,12 +46,14 @@ import (
 	"github.com/inngest/inngest/pkg/execution/ratelimit"
 	"github.com/inngest/inngest/pkg/execution/realtime"
 	"github.com/inngest/inngest/pkg/execution/runner"
+	"github.com/inngest/inngest/pkg/execution/singleton"
 	"github.com/inngest/inngest/pkg/execution/state"
 	"github.com/inngest/inngest/pkg/execution/state/redis_state"
 	sv2 "github.com/inngest/inngest/pkg/execution/state/v2"
 	"github.com/inngest/inngest/pkg/expressions"
 	"github.com/inngest/inngest/pkg/history_drivers/memory_reader"
 	"github.com/inngest/inngest/pkg/history_drivers/memory_writer"
+	"github.com/inngest/inngest/pkg/keyring"
 	"github.com/inngest/inngest/pkg/logger"
 	"github.com/inngest/inngest/pkg/pubsub"
 	"github.com/inngest/inngest/pkg/run"
@@ -88,6 +94,16 @@ type StartOpts struct {
 	// given key.
 	EventKeys []string `json:"-"`
 
+	// Keyring, if set, supplies the active signing and event keys in place of
+	// SigningKey and EventKeys, allowing keys to be rotated without a restart.
+	// Workers are authenticated against every active signing key.
+	Keyring *keyring.Keyring `json:"-"`
+
+	// Environments, if set, stores the named environments which apps may be
+	// registered within.  Workers connect to the environment owning their
+	// signing key.
+	Environments *environment.Registry `json:"-"`
+
 	// RequireKeys defines whether event and signing keys are required for the
 	// server to function. If this is true and signing keys are not defined,
 	// the server will still boot but core actions such as syncing, runs, and
@@ -96,6 +112,13 @@ type StartOpts struct {
 
 	ConnectGatewayPort int    `json:"connectGatewayPort"`
 	ConnectGatewayHost string `json:"connectGatewayHost"`
+
+	// PersistDir, if set, stores the database and queue snapshots within
+	// the given directory, restoring apps, history and in-progress runs on
+	// the next start.
+	PersistDir string `json:"persistDir,omitempty"`
+	// Reset removes any data stored within PersistDir before starting.
+	Reset bool `json:"-"`
 }
 
 // Create and start a new dev server.  The dev server is used during (surprise surprise)
@@ -115,16 +138,38 @@ func New(ctx context.Context, opts StartOpts) error {
 	//
 	// We also make sure to allow local requests.
 	httpdriver.DefaultTransport.DialContext = httpdriver.Dialer.DialContext
+	httpdriver.ProxyHostValidator = nil
 	httpdriver.DefaultExecutor.Client.Transport = awsgateway.NewTransformTripper(httpdriver.DefaultExecutor.Client.Transport)
 	deploy.Client.Transport = awsgateway.NewTransformTripper(deploy.Client.Transport)
+	httpdriver.WrapTransport = awsgateway.NewTransformTripper
 
 	return start(ctx, opts)
 }
 
 func start(ctx context.Context, opts StartOpts) error {
-	db, err := base_cqrs.New(base_cqrs.BaseCQRSOptions{InMemory: true})
-	if err != nil {
-		return err
+	if opts.PersistDir != "" && opts.Reset {
+		if err := resetPersistDir(opts.PersistDir); err != nil {
+			return err
+		}
+	}
+
+	var (
+		db  *sql.DB
+		err error
+	)
+	if opts.PersistDir != "" {
+		// Persistent dev servers read their data back from disk each time
+		// they start.
+		db, err = base_cqrs.NewUnshared(base_cqrs.BaseCQRSOptions{Directory: opts.PersistDir})
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+	} else {
+		db, err = base_cqrs.New(base_cqrs.BaseCQRSOptions{InMemory: true})
+		if err != nil {
+			return err
+		}
 	}
 
 	if opts.Tick == 0 {
@@ -145,9 +190,14 @@ func start(ctx context.Context, opts StartOpts) error {
 		return err
 	}
 
-	unshardedRc, err := createInmemoryRedis(ctx, opts.Tick)
-	if err != nil {
-		return err
+	// Snapshots are taken from a single Redis instance, so persistent dev
+	// servers store run state alongside the queue.
+	unshardedRc := shardedRc
+	if opts.PersistDir == "" {
+		unshardedRc, err = createInmemoryRedis(ctx, opts.Tick)
+		if err != nil {
+			return err
+		}
 	}
 
 	connectRc, err := createInmemoryRedis(ctx, opts.Tick)
@@ -306,6 +356,11 @@ func start(ctx context.Context, opts StartOpts) error {
 			return err
 		}
 		drivers = append(drivers, d)
+
+		// Sync apps using the same transport config as function calls.
+		if c, ok := driverConfig.(*httpdriver.Config); ok {
+			deploy.Clients = c.ClientPool(&deploy.Client)
+		}
 	}
 	pb, err := pubsub.NewPublisher(ctx, opts.Config.EventStream.Service)
 	if err != nil {
@@ -358,6 +413,7 @@ func start(ctx context.Context, opts StartOpts) error {
 		executor.WithSendingEventHandler(getSendingEventHandler(ctx, pb, opts.Config.EventStream.Service.Concrete.TopicName())),
 		executor.WithDebouncer(debouncer),
 		executor.WithBatcher(batcher),
+		executor.WithSingletonLocker(singleton.New(unshardedRc, "{singleton}:")),
 		executor.WithAssignedQueueShard(queueShard),
 		executor.WithShardSelector(shardSelector),
 		executor.WithTraceReader(dbcqrs),
@@ -415,7 +471,9 @@ func start(ctx context.Context, opts StartOpts) error {
 			FunctionRunReader:  ds.Data,
 			JobQueueReader:     ds.Queue.(queue.JobQueueReader),
 			Executor:           ds.Executor,
+			BatchManager:       batcher,
 			QueueShardSelector: shardSelector,
+			AuditLog:           ds.Data,
 			Broadcaster:        broadcaster,
 			RealtimeJWTSecret:  consts.DevServerRealtimeJWTSecret,
 		})
@@ -498,17 +556,33 @@ func start(ctx context.Context, opts StartOpts) error {
 		Config:         ds.Opts.Config,
 		Mounts:         mounts,
 		LocalEventKeys: opts.EventKeys,
+		AuditLog:       ds.Data,
 	})
 
 	return service.StartAll(ctx, ds, runner, executorSvc, ds.Apiservice, connGateway)
 }
 
+// resetPersistDir removes the database, including queue snapshots, from the
+// given persistence directory.
+func resetPersistDir(dir string) error {
+	for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+		err := os.Remove(filepath.Join(dir, consts.SQLiteDbFileName+suffix))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error resetting persisted data: %w", err)
+		}
+	}
+	return nil
+}
+
 func createInmemoryRedis(ctx context.Context, tick time.Duration) (rueidis.Client, error) {
 	r := miniredis.NewMiniRedis()
 	_ = r.Start()
 	rc, err := rueidis.NewClient(rueidis.ClientOption{
 		InitAddress:  []string{r.Addr()},
 		DisableCache: true,
+		// miniredis is a single node, and dedicated clients used when
+		// snapshotting can't be used in cluster mode.
+		ForceSingleClient: true,
 	})
 	if err != nil {
 		return nil, err
@@ -540,7 +614,7 @@ func createConnectPubSubRedis() rueidis.ClientOption {
 
 func getSendingEventHandler(ctx context.Context, pb pubsub.Publisher, topic string) execution.HandleSendingEvent {
 	return func(ctx context.Context, evt event.Event, item queue.Item) error {
-		trackedEvent := event.NewOSSTrackedEvent(evt, nil)
+		trackedEvent := event.NewOSSTrackedEventInWorkspace(item.WorkspaceID, evt, nil)
 		byt, err := json.Marshal(trackedEvent)
 		if err != nil {
 			return fmt.Errorf("error marshalling invocation event: %w", err)
@@ -576,7 +650,7 @@ func getInvokeFailHandler(ctx context.Context, pb pubsub.Publisher, topic string
 		for _, e := range evts {
 			evt := e
 			eg.Go(func() error {
-				trackedEvent := event.NewOSSTrackedEvent(evt, nil)
+				trackedEvent := event.NewOSSTrackedEventInWorkspace(opts.OriginalEvent.GetWorkspaceID(), evt, nil)
 				byt, err := json.Marshal(trackedEvent)
 				if err != nil {
 					return fmt.Errorf("error marshalling function finished event: %w", err)
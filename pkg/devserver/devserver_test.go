@@ -0,0 +1,184 @@
+package devserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/config"
+	"github.com/inngest/inngest/pkg/consts"
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/execution/state"
+	"github.com/inngest/inngest/pkg/headers"
+	"github.com/inngest/inngest/pkg/inngest"
+	"github.com/inngest/inngest/pkg/sdk"
+	"github.com/stretchr/testify/require"
+)
+
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// startPersisted starts a dev server storing its data within dir, returning
+// its URL and a func which gracefully stops the server.
+func startPersisted(t *testing.T, dir string) (string, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	conf, err := config.Dev(ctx)
+	require.NoError(t, err)
+	port := freePort(t)
+	conf.EventAPI.Addr, conf.EventAPI.Port = "127.0.0.1", port
+	conf.CoreAPI.Addr, conf.CoreAPI.Port = "127.0.0.1", port
+	conf.EventStream.Service.Set(config.InMemoryMessaging{Topic: uuid.NewString()})
+
+	done := make(chan error)
+	go func() {
+		done <- New(ctx, StartOpts{
+			Config:             *conf,
+			Tick:               10 * time.Millisecond,
+			QueueWorkers:       DefaultQueueWorkers,
+			ConnectGatewayPort: freePort(t),
+			ConnectGatewayHost: "127.0.0.1",
+			PersistDir:         dir,
+		})
+	}()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d", port)
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(url + "/dev")
+		if err != nil {
+			return false
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode == 200
+	}, 10*time.Second, 50*time.Millisecond)
+
+	return url, func() {
+		cancel()
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(30 * time.Second):
+			t.Fatal("dev server did not stop")
+		}
+	}
+}
+
+func TestPersistRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	// The SDK sleeps once, then completes.
+	var calls, completed atomic.Int32
+	sdkSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		req := struct {
+			Steps map[string]json.RawMessage `json:"steps"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		var (
+			status = 206
+			body   any
+		)
+		body = []state.GeneratorOpcode{{Op: enums.OpcodeSleep, ID: "s", Name: "2s"}}
+		if _, ok := req.Steps["s"]; ok {
+			status, body = 200, "done"
+			completed.Add(1)
+		}
+		byt, _ := json.Marshal(body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(headers.HeaderKeySDK, "go:v0.0.1")
+		w.WriteHeader(status)
+		_, _ = w.Write(byt)
+	}))
+	defer sdkSrv.Close()
+
+	url, stop := startPersisted(t, dir)
+
+	byt, err := json.Marshal(sdk.RegisterRequest{
+		URL:     sdkSrv.URL + "/",
+		V:       "1",
+		SDK:     "go:v0.0.1",
+		AppName: "app",
+		Functions: []sdk.SDKFunction{{
+			Name:     "sleeper",
+			Slug:     "app-sleeper",
+			Triggers: []inngest.Trigger{{EventTrigger: &inngest.EventTrigger{Event: "test/sleep"}}},
+			Steps: map[string]sdk.SDKStep{
+				"step": {
+					ID:      "step",
+					Name:    "step",
+					Runtime: map[string]any{"type": "http", "url": sdkSrv.URL + "/?fnId=app-sleeper&step=step"},
+				},
+			},
+		}},
+	})
+	require.NoError(t, err)
+	resp, err := http.Post(url+"/fn/register", "application/json", bytes.NewReader(byt))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, 200, resp.StatusCode)
+
+	resp, err = http.Post(url+"/e/test-key", "application/json", bytes.NewReader([]byte(`{"name":"test/sleep","data":{}}`)))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, 200, resp.StatusCode)
+
+	// Stop while the run is sleeping, snapshotting the queue.
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, 10*time.Second, 10*time.Millisecond)
+	time.Sleep(250 * time.Millisecond)
+	stop()
+	require.EqualValues(t, 0, completed.Load())
+
+	url, stop = startPersisted(t, dir)
+	defer stop()
+
+	resp, err = http.Get(url + "/dev")
+	require.NoError(t, err)
+	info := struct {
+		StartOpts StartOpts `json:"startOpts"`
+		Functions []struct {
+			Slug string `json:"slug"`
+		} `json:"functions"`
+	}{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+	_ = resp.Body.Close()
+	require.Equal(t, dir, info.StartOpts.PersistDir)
+	require.Len(t, info.Functions, 1)
+	require.Equal(t, "app-sleeper", info.Functions[0].Slug)
+
+	// The sleep is restored from the snapshot, resuming the run.
+	require.Eventually(t, func() bool { return completed.Load() == 1 }, 15*time.Second, 50*time.Millisecond)
+}
+
+func TestResetPersistDir(t *testing.T) {
+	dir := t.TempDir()
+	other := filepath.Join(dir, "other")
+	for _, name := range []string{"", "-wal", "-shm", "-journal"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, consts.SQLiteDbFileName+name), []byte("x"), 0600))
+	}
+	require.NoError(t, os.WriteFile(other, []byte("x"), 0600))
+
+	require.NoError(t, resetPersistDir(dir))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "other", entries[0].Name())
+
+	// Resetting an empty directory is a no-op.
+	require.NoError(t, resetPersistDir(dir))
+}
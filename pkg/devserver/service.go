@@ -8,12 +8,12 @@ import (
 	"net"
 	"net/url"
 	"os"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	v0 "github.com/inngest/inngest/pkg/connect/rest/v0"
+	"github.com/inngest/inngest/pkg/devserver/wal"
 	"github.com/inngest/inngest/pkg/enums"
 
 	"github.com/inngest/inngest/pkg/connect/auth"
@@ -23,6 +23,7 @@ import (
 	"github.com/inngest/inngest/pkg/cqrs"
 	"github.com/inngest/inngest/pkg/deploy"
 	"github.com/inngest/inngest/pkg/devserver/discovery"
+	"github.com/inngest/inngest/pkg/environment"
 	"github.com/inngest/inngest/pkg/event"
 	"github.com/inngest/inngest/pkg/execution"
 	"github.com/inngest/inngest/pkg/execution/history"
@@ -55,6 +56,14 @@ func NewService(opts StartOpts, runner runner.Runner, data cqrs.Manager, pb pubs
 		}
 	}
 
+	// Persistent dev servers snapshot the queue in the same way as
+	// single-node services, without otherwise behaving as one.
+	snapshots := snso
+	if snapshots == nil && opts.PersistDir != "" {
+		pi := consts.DevServerPersistenceInterval
+		snapshots = &SingleNodeServiceOpts{PersistenceInterval: &pi, snapshotLock: &sync.Mutex{}}
+	}
+
 	return &devserver{
 		Data:                    data,
 		Runner:                  runner,
@@ -66,6 +75,7 @@ func NewService(opts StartOpts, runner runner.Runner, data cqrs.Manager, pb pubs
 		redisClient:             rc,
 		historyWriter:           hw,
 		singleNodeServiceOpts:   snso,
+		snapshotOpts:            snapshots,
 	}
 }
 
@@ -103,6 +113,9 @@ type devserver struct {
 	// These options are used to configure the server's behaviour as a
 	// single-node service instead of a dev environment.
 	singleNodeServiceOpts *SingleNodeServiceOpts
+	// snapshotOpts configures queue snapshots, and is set for single-node
+	// services and persistent dev servers.
+	snapshotOpts *SingleNodeServiceOpts
 }
 
 type SingleNodeServiceOpts struct {
@@ -111,6 +124,11 @@ type SingleNodeServiceOpts struct {
 	// instance.
 	PersistenceInterval *time.Duration
 
+	// WAL, if set, logs each write to the in-memory Redis instance between
+	// snapshots, such that writes aren't lost if the process crashes.  Each
+	// snapshot compacts the log.
+	WAL *wal.Log
+
 	// Used to lock the snapshotting process.
 	snapshotLock *sync.Mutex
 }
@@ -136,17 +154,45 @@ func (d *devserver) IsSingleNodeService() bool {
 }
 
 func (d *devserver) HasSigningKey() bool {
-	return d.Opts.SigningKey != nil && *d.Opts.SigningKey != ""
+	return d.signingKey() != ""
 }
 
 func (d *devserver) HasEventKeys() bool {
+	if d.Opts.Keyring != nil {
+		return d.Opts.Keyring.HasEventKeys()
+	}
 	return len(d.Opts.EventKeys) > 0
 }
 
+// signingKey returns the current primary signing key, if any.
+func (d *devserver) signingKey() string {
+	if d.Opts.Keyring != nil {
+		return d.Opts.Keyring.SigningKey()
+	}
+	if d.Opts.SigningKey != nil {
+		return *d.Opts.SigningKey
+	}
+	return ""
+}
+
+// environments returns each environment which apps may be registered within.
+func (d *devserver) environments() []*environment.Environment {
+	if d.Opts.Environments != nil {
+		return d.Opts.Environments.All()
+	}
+	return []*environment.Environment{{ID: consts.DevServerEnvID, Name: environment.DefaultName, Keyring: d.Opts.Keyring}}
+}
+
 func (d *devserver) Pre(ctx context.Context) error {
 	// Import Redis if we can and have persistence enabled
 	if d.HasRedisSnapshotsEnabled() {
-		_, _ = d.importRedisSnapshot(ctx)
+		if d.snapshotOpts.WAL != nil {
+			if err := d.restoreWAL(ctx); err != nil {
+				return err
+			}
+		} else {
+			_, _ = d.importRedisSnapshot(ctx, nil)
+		}
 	}
 
 	// Autodiscover the URLs that are hosting Inngest SDKs on the local machine.
@@ -215,16 +261,59 @@ func (d *devserver) Run(ctx context.Context) error {
 
 func (d *devserver) Stop(ctx context.Context) error {
 	if d.HasRedisSnapshotsEnabled() {
-		return d.exportRedisSnapshot(ctx)
+		if err := d.snapshot(ctx); err != nil {
+			return err
+		}
+		if d.snapshotOpts.WAL != nil {
+			return d.snapshotOpts.WAL.Close()
+		}
 	}
 
 	return nil
 }
 
+// restoreWAL imports the snapshot referenced by the write-ahead log, replays
+// each write logged since, then starts logging writes.
+func (d *devserver) restoreWAL(ctx context.Context) error {
+	w := d.snapshotOpts.WAL
+	id, ok, err := w.SnapshotID()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// The log is new, so restore the latest snapshot, if any.
+		_, _ = d.importRedisSnapshot(ctx, nil)
+	} else if _, err := d.importRedisSnapshot(ctx, &id); err != nil {
+		return fmt.Errorf("error restoring snapshot %s referenced by the write-ahead log: %w", id, err)
+	}
+
+	n, err := w.Replay(ctx)
+	if err != nil {
+		return fmt.Errorf("error replaying write-ahead log: %w", err)
+	}
+	logger.From(ctx).Info().Int("commands", n).Msg("replayed Redis write-ahead log")
+
+	// Compact the log, so that replayed writes aren't replayed again.
+	if err := d.snapshot(ctx); err != nil {
+		return err
+	}
+	return w.Attach(ctx)
+}
+
+// snapshot exports the in-memory Redis instance, compacting the write-ahead
+// log if enabled.
+func (d *devserver) snapshot(ctx context.Context) error {
+	if w := d.snapshotOpts.WAL; w != nil {
+		return w.Compact(ctx, d.exportRedisSnapshot)
+	}
+	_, err := d.exportRedisSnapshot(ctx)
+	return err
+}
+
 // HasRedisSnapshotsEnabled returns true if Redis is persisted via snapshots to the database.
 // External redis-servers do not require snapshots.
 func (d *devserver) HasRedisSnapshotsEnabled() bool {
-	return d.singleNodeServiceOpts != nil && d.singleNodeServiceOpts.PersistenceInterval != nil
+	return d.snapshotOpts != nil && d.snapshotOpts.PersistenceInterval != nil
 }
 
 func (d *devserver) startPersistenceRoutine(ctx context.Context) {
@@ -232,12 +321,12 @@ func (d *devserver) startPersistenceRoutine(ctx context.Context) {
 		return
 	}
 
-	ticker := time.NewTicker(*d.singleNodeServiceOpts.PersistenceInterval)
+	ticker := time.NewTicker(*d.snapshotOpts.PersistenceInterval)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
-			if err := d.exportRedisSnapshot(ctx); err != nil {
+			if err := d.snapshot(ctx); err != nil {
 				logger.From(ctx).Error().Err(err).Msg("error exporting Redis snapshot")
 			}
 		case <-ctx.Done():
@@ -275,11 +364,6 @@ func (d *devserver) runDiscovery(ctx context.Context) {
 func (d *devserver) pollSDKs(ctx context.Context) {
 	pollInterval := time.Duration(d.Opts.PollInterval) * time.Second
 
-	sk := ""
-	if d.Opts.SigningKey != nil {
-		sk = *d.Opts.SigningKey
-	}
-
 	// Initially, add every app started with the `-u` flag
 	for _, url := range d.Opts.URLs {
 		// URLs must contain a protocol. If not, add http since very few apps
@@ -309,8 +393,22 @@ func (d *devserver) pollSDKs(ctx context.Context) {
 			return
 		}
 
+		// Load the signing key on each poll, as it may have been rotated.
+		sk := d.signingKey()
+
 		urls := map[string]struct{}{}
-		if apps, err := d.Data.GetApps(ctx, consts.DevServerEnvID, nil); err == nil {
+		for _, env := range d.environments() {
+			// Apps within each environment are pinged using the environment's
+			// signing key, so that they sync to the same environment.
+			envKey := sk
+			if !env.IsDefault() {
+				envKey = env.Keyring.SigningKey()
+			}
+
+			apps, err := d.Data.GetApps(ctx, env.ID, nil)
+			if err != nil {
+				continue
+			}
 			for _, app := range apps {
 				if app.Method == enums.AppMethodConnect.String() {
 					continue
@@ -325,7 +423,7 @@ func (d *devserver) pollSDKs(ctx context.Context) {
 
 				// Make a new PUT request to each app, indicating that the
 				// SDK should push functions to the dev server.
-				res := deploy.Ping(ctx, app.Url, d.Opts.Config.ServerKind, sk, d.Opts.RequireKeys)
+				res := deploy.Ping(ctx, app.Url, d.Opts.Config.ServerKind, envKey, d.Opts.RequireKeys)
 				if res.Err != nil {
 					_, _ = d.Data.UpdateAppError(ctx, cqrs.UpdateAppErrorParams{
 						ID: app.ID,
@@ -368,7 +466,7 @@ func (d *devserver) HandleEvent(ctx context.Context, e *event.Event, seed *event
 
 	l.Debug().Str("event", e.Name).Msg("handling event")
 
-	trackedEvent := event.NewOSSTrackedEvent(*e, seed)
+	trackedEvent := event.NewOSSTrackedEventInWorkspace(environment.IDFromContext(ctx), *e, seed)
 
 	byt, err := json.Marshal(trackedEvent)
 	if err != nil {
@@ -402,14 +500,13 @@ func (d *devserver) HandleEvent(ctx context.Context, e *event.Event, seed *event
 	return trackedEvent.GetInternalID().String(), err
 }
 
-func (d *devserver) exportRedisSnapshot(ctx context.Context) (err error) {
-	d.singleNodeServiceOpts.snapshotLock.Lock()
-	defer d.singleNodeServiceOpts.snapshotLock.Unlock()
+func (d *devserver) exportRedisSnapshot(ctx context.Context) (snapshotID cqrs.SnapshotID, err error) {
+	d.snapshotOpts.snapshotLock.Lock()
+	defer d.snapshotOpts.snapshotLock.Unlock()
 
 	var (
-		snapshotID cqrs.SnapshotID
-		snapshot   = make(map[string]cqrs.SnapshotValue)
-		l          = logger.From(ctx).With().Str("caller", d.Name()).Logger()
+		snapshot cqrs.QueueSnapshot
+		l        = logger.From(ctx).With().Str("caller", d.Name()).Logger()
 	)
 
 	l.Info().Msg("exporting Redis snapshot")
@@ -436,98 +533,11 @@ func (d *devserver) exportRedisSnapshot(ctx context.Context) (err error) {
 	// Give an arbitrary amount of time to allow for any writes to finish
 	<-time.After(150 * time.Millisecond)
 
-	cmd := rc.B().Keys().Pattern("*").Build()
-	keys, err := rc.Do(ctx, cmd).AsStrSlice()
+	snapshot, err = DumpRedis(ctx, rc)
 	if err != nil {
-		err = fmt.Errorf("error getting keys: %w", err)
 		return
 	}
 
-	for _, key := range keys {
-		typeCmd := rc.B().Type().Key(key).Build()
-		var typ string
-		typ, err = rc.Do(ctx, typeCmd).ToString()
-		if err != nil {
-			err = fmt.Errorf("error getting type for key %s: %w", key, err)
-			return
-		}
-
-		switch typ {
-		case "string":
-			getCmd := rc.B().Get().Key(key).Build()
-			var val string
-			val, err = rc.Do(ctx, getCmd).ToString()
-			if err != nil {
-				err = fmt.Errorf("error getting value for string key %s: %w", key, err)
-				return
-			}
-			snapshot[key] = cqrs.SnapshotValue{
-				Type:  typ,
-				Value: val,
-			}
-		case "list":
-			lrangeCmd := rc.B().Lrange().Key(key).Start(0).Stop(-1).Build()
-			var vals []string
-			vals, err = rc.Do(ctx, lrangeCmd).AsStrSlice()
-			if err != nil {
-				err = fmt.Errorf("error getting values for list key %s: %w", key, err)
-				return
-			}
-			snapshot[key] = cqrs.SnapshotValue{
-				Type:  typ,
-				Value: vals,
-			}
-		case "set":
-			smembersCmd := rc.B().Smembers().Key(key).Build()
-			var vals []string
-			vals, err = rc.Do(ctx, smembersCmd).AsStrSlice()
-			if err != nil {
-				err = fmt.Errorf("error getting values for set key %s: %w", key, err)
-				return
-			}
-			snapshot[key] = cqrs.SnapshotValue{
-				Type:  typ,
-				Value: vals,
-			}
-		case "zset":
-			zrangeCmd := rc.B().Zrange().Key(key).Min("-inf").Max("+inf").Byscore().Withscores().Build()
-			var vals []string
-			vals, err = rc.Do(ctx, zrangeCmd).AsStrSlice()
-			if err != nil {
-				err = fmt.Errorf("error getting values for zset key %s: %w", key, err)
-				return
-			}
-			snapshot[key] = cqrs.SnapshotValue{
-				Type:  typ,
-				Value: vals,
-			}
-		case "hash":
-			hgetallCmd := rc.B().Hgetall().Key(key).Build()
-			var rawVals map[string]rueidis.RedisMessage
-			rawVals, err = rc.Do(ctx, hgetallCmd).AsMap()
-			if err != nil {
-				err = fmt.Errorf("error getting values for hash key %s: %w", key, err)
-				return
-			}
-			vals := make(map[string]string, len(rawVals))
-			for k, v := range rawVals {
-				strVal, _ := v.ToString()
-				vals[k] = strVal
-			}
-			snapshot[key] = cqrs.SnapshotValue{
-				Type:  typ,
-				Value: vals,
-			}
-		case "none":
-			// the key was deleted between fetching keys and fetching its
-			// type. For now we continue and ignore it; we should make sure
-			// the client is read-only before we try to dump.
-		default:
-			err = fmt.Errorf("unsupported type: %s", typ)
-			return
-		}
-	}
-
 	snapshotID, err = d.Data.InsertQueueSnapshot(ctx, cqrs.InsertQueueSnapshotParams{
 		Snapshot: snapshot,
 	})
@@ -539,14 +549,21 @@ func (d *devserver) exportRedisSnapshot(ctx context.Context) (err error) {
 	return
 }
 
-func (d *devserver) importRedisSnapshot(ctx context.Context) (imported bool, err error) {
-	d.singleNodeServiceOpts.snapshotLock.Lock()
-	defer d.singleNodeServiceOpts.snapshotLock.Unlock()
+// importRedisSnapshot imports the snapshot with the given ID, or the latest
+// snapshot if the ID is nil.
+func (d *devserver) importRedisSnapshot(ctx context.Context, id *cqrs.SnapshotID) (imported bool, err error) {
+	d.snapshotOpts.snapshotLock.Lock()
+	defer d.snapshotOpts.snapshotLock.Unlock()
 
 	l := logger.From(ctx).With().Str("caller", d.Name()).Logger()
 	l.Info().Msg("importing Redis snapshot")
 
-	snapshot, err := d.Data.GetLatestQueueSnapshot(ctx)
+	var snapshot *cqrs.QueueSnapshot
+	if id != nil {
+		snapshot, err = d.Data.GetQueueSnapshot(ctx, *id)
+	} else {
+		snapshot, err = d.Data.GetLatestQueueSnapshot(ctx)
+	}
 	defer func() {
 		if err != nil {
 			l.Error().Err(err).Msg("error importing Redis snapshot")
@@ -569,80 +586,35 @@ func (d *devserver) importRedisSnapshot(ctx context.Context) (imported bool, err
 	rc, done := d.redisClient.Dedicate()
 	defer done()
 
-	for key, data := range *snapshot {
-		switch data.Type {
-		case "string":
-			strVal := data.Value.(string)
-			setCmd := rc.B().Set().Key(key).Value(strVal).Build()
-			err = rc.Do(ctx, setCmd).Error()
-			if err != nil {
-				err = fmt.Errorf("error setting string key %s: %w", key, err)
-				return
-			}
-
-		case "list":
-			vals := data.Value.([]interface{})
-			strValues := make([]string, len(vals))
-			for i, v := range vals {
-				strVal, _ := v.(string)
-				strValues[i] = strVal
-			}
-			rpushCmd := rc.B().Rpush().Key(key).Element(strValues...).Build()
-			err = rc.Do(ctx, rpushCmd).Error()
-			if err != nil {
-				err = fmt.Errorf("error pushing to list key %s: %w", key, err)
-				return
-			}
+	if err = RestoreRedis(ctx, rc, *snapshot); err != nil {
+		return
+	}
 
-		case "set":
-			strValues := data.Value.([]string)
-			// err = rc.SAdd(ctx, key, strValues...).Err()
-			saddCmd := rc.B().Sadd().Key(key).Member(strValues...).Build()
-			err = rc.Do(ctx, saddCmd).Error()
-			if err != nil {
-				err = fmt.Errorf("error adding to set key %s: %w", key, err)
-				return
-			}
+	imported = true
 
-		case "zset":
-			vals := data.Value.([]interface{})
-			zaddCmd := rc.B().Zadd().Key(key).ScoreMember()
-			for i := 0; i < len(vals); i += 2 {
-				member := vals[i].(string)
-				score, _ := strconv.ParseFloat(vals[i+1].(string), 64)
-				zaddCmd = zaddCmd.ScoreMember(score, member)
-			}
-			err = rc.Do(ctx, zaddCmd.Build()).Error()
-			if err != nil {
-				err = fmt.Errorf("error adding to zset key %s: %w", key, err)
-				return
-			}
+	return
+}
 
-		case "hash":
-			values := data.Value.(map[string]interface{})
-			hmsetCmd := rc.B().Hmset().Key(key).FieldValue()
-			for k, v := range values {
-				strVal, _ := v.(string)
-				hmsetCmd = hmsetCmd.FieldValue(k, strVal)
-			}
-			err = rc.Do(ctx, hmsetCmd.Build()).Error()
-			if err != nil {
-				err = fmt.Errorf("error setting hash key %s: %w", key, err)
-				return
+func (d *devserver) AuthenticateRequest(_ context.Context, hashedSigningKey, envOverride string) (*auth.Response, error) {
+	// Workers connect to the environment owning their signing key.
+	if d.Opts.Environments != nil {
+		if env := d.Opts.Environments.ByHashedSigningKey(hashedSigningKey); env != nil {
+			if envOverride != "" && envOverride != env.Name {
+				return nil, nil
 			}
-
-		default:
-			err = fmt.Errorf("unsupported key type: %s", data.Type)
-			return
+			return &auth.Response{
+				AccountID: consts.DevServerAccountID,
+				EnvID:     env.ID,
+			}, nil
 		}
 	}
 
-	imported = true
-
-	return
-}
+	// When keys are required, workers must authenticate using any active
+	// signing key.  Returning a nil response rejects the connection.
+	if d.Opts.RequireKeys && d.Opts.Keyring != nil && !d.Opts.Keyring.ValidHashedSigningKey(hashedSigningKey) {
+		return nil, nil
+	}
 
-func (d *devserver) AuthenticateRequest(_ context.Context, _, _ string) (*auth.Response, error) {
 	return &auth.Response{
 		AccountID: consts.DevServerAccountID,
 		EnvID:     consts.DevServerEnvID,
@@ -0,0 +1,106 @@
+package devserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/cqrs/base_cqrs"
+	"github.com/inngest/inngest/pkg/devserver/wal"
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/require"
+)
+
+// newSnapshotServer returns a devserver which snapshots the given in-memory
+// Redis instance to the given database.
+func newSnapshotServer(t *testing.T, data cqrs.Manager, r *miniredis.Miniredis, opts SingleNodeServiceOpts) *devserver {
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:       []string{r.Addr()},
+		DisableCache:      true,
+		ForceSingleClient: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(rc.Close)
+
+	if opts.PersistenceInterval == nil {
+		interval := time.Hour
+		opts.PersistenceInterval = &interval
+	}
+	return NewService(StartOpts{}, nil, data, nil, nil, nil, rc, nil, &opts)
+}
+
+func newSnapshotData(t *testing.T) cqrs.Manager {
+	db, err := base_cqrs.NewIsolated(t.Name() + "-" + uuid.NewString())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return base_cqrs.NewCQRS(db, "sqlite")
+}
+
+func TestImportRedisSnapshotSet(t *testing.T) {
+	ctx := context.Background()
+	data := newSnapshotData(t)
+
+	src := miniredis.RunT(t)
+	_, err := src.SetAdd("set", "a", "b")
+	require.NoError(t, err)
+	_, err = newSnapshotServer(t, data, src, SingleNodeServiceOpts{}).exportRedisSnapshot(ctx)
+	require.NoError(t, err)
+
+	// Snapshots are read back from JSON, which previously failed to restore
+	// sets.
+	dst := miniredis.RunT(t)
+	imported, err := newSnapshotServer(t, data, dst, SingleNodeServiceOpts{}).importRedisSnapshot(ctx, nil)
+	require.NoError(t, err)
+	require.True(t, imported)
+
+	members, err := dst.Members("set")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"a", "b"}, members)
+}
+
+func TestRestoreWALAfterCrash(t *testing.T) {
+	ctx := context.Background()
+	data := newSnapshotData(t)
+	dir := t.TempDir()
+
+	set := func(d *devserver, key, val string) {
+		rc := d.redisClient
+		require.NoError(t, rc.Do(ctx, rc.B().Set().Key(key).Value(val).Build()).Error())
+	}
+
+	src := miniredis.RunT(t)
+	w, err := wal.Open(src, wal.Options{Dir: dir, Fsync: wal.FsyncAlways})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+	d := newSnapshotServer(t, data, src, SingleNodeServiceOpts{WAL: w})
+	require.NoError(t, d.restoreWAL(ctx))
+
+	set(d, "snapshotted", "a")
+	require.NoError(t, d.snapshot(ctx))
+	set(d, "logged", "b")
+
+	// A newer snapshot which isn't referenced by the log must not be
+	// restored.
+	_, err = data.InsertQueueSnapshot(ctx, cqrs.InsertQueueSnapshotParams{
+		Snapshot: cqrs.QueueSnapshot{"unreferenced": {Type: "string", Value: "c"}},
+	})
+	require.NoError(t, err)
+
+	// Restart without stopping, as if the process had crashed.
+	dst := miniredis.RunT(t)
+	restored, err := wal.Open(dst, wal.Options{Dir: dir, Fsync: wal.FsyncAlways})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = restored.Close() })
+	require.NoError(t, newSnapshotServer(t, data, dst, SingleNodeServiceOpts{WAL: restored}).restoreWAL(ctx))
+
+	require.Equal(t, []string{"logged", "snapshotted"}, dst.Keys())
+	val, err := dst.Get("snapshotted")
+	require.NoError(t, err)
+	require.Equal(t, "a", val)
+	val, err = dst.Get("logged")
+	require.NoError(t, err)
+	require.Equal(t, "b", val)
+}
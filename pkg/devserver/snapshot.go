@@ -0,0 +1,148 @@
+package devserver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/redis/rueidis"
+)
+
+// DumpRedis returns a snapshot of every key within the given Redis instance.
+// Writes should be paused while dumping, as keys are read individually.
+func DumpRedis(ctx context.Context, rc rueidis.CoreClient) (cqrs.QueueSnapshot, error) {
+	snapshot := cqrs.QueueSnapshot{}
+
+	cmd := rc.B().Keys().Pattern("*").Build()
+	keys, err := rc.Do(ctx, cmd).AsStrSlice()
+	if err != nil {
+		return nil, fmt.Errorf("error getting keys: %w", err)
+	}
+
+	for _, key := range keys {
+		typeCmd := rc.B().Type().Key(key).Build()
+		typ, err := rc.Do(ctx, typeCmd).ToString()
+		if err != nil {
+			return nil, fmt.Errorf("error getting type for key %s: %w", key, err)
+		}
+
+		switch typ {
+		case "string":
+			getCmd := rc.B().Get().Key(key).Build()
+			val, err := rc.Do(ctx, getCmd).ToString()
+			if err != nil {
+				return nil, fmt.Errorf("error getting value for string key %s: %w", key, err)
+			}
+			snapshot[key] = cqrs.SnapshotValue{Type: typ, Value: val}
+		case "list":
+			lrangeCmd := rc.B().Lrange().Key(key).Start(0).Stop(-1).Build()
+			vals, err := rc.Do(ctx, lrangeCmd).AsStrSlice()
+			if err != nil {
+				return nil, fmt.Errorf("error getting values for list key %s: %w", key, err)
+			}
+			snapshot[key] = cqrs.SnapshotValue{Type: typ, Value: vals}
+		case "set":
+			smembersCmd := rc.B().Smembers().Key(key).Build()
+			vals, err := rc.Do(ctx, smembersCmd).AsStrSlice()
+			if err != nil {
+				return nil, fmt.Errorf("error getting values for set key %s: %w", key, err)
+			}
+			snapshot[key] = cqrs.SnapshotValue{Type: typ, Value: vals}
+		case "zset":
+			zrangeCmd := rc.B().Zrange().Key(key).Min("-inf").Max("+inf").Byscore().Withscores().Build()
+			vals, err := rc.Do(ctx, zrangeCmd).AsStrSlice()
+			if err != nil {
+				return nil, fmt.Errorf("error getting values for zset key %s: %w", key, err)
+			}
+			snapshot[key] = cqrs.SnapshotValue{Type: typ, Value: vals}
+		case "hash":
+			hgetallCmd := rc.B().Hgetall().Key(key).Build()
+			vals, err := rc.Do(ctx, hgetallCmd).AsStrMap()
+			if err != nil {
+				return nil, fmt.Errorf("error getting values for hash key %s: %w", key, err)
+			}
+			snapshot[key] = cqrs.SnapshotValue{Type: typ, Value: vals}
+		case "none":
+			// the key was deleted between fetching keys and fetching its
+			// type. For now we continue and ignore it; we should make sure
+			// the client is read-only before we try to dump.
+		default:
+			return nil, fmt.Errorf("unsupported type: %s", typ)
+		}
+	}
+
+	return snapshot, nil
+}
+
+// RestoreRedis writes every key within the snapshot to the given Redis
+// instance.  Snapshots may either be returned from DumpRedis or decoded from
+// JSON.
+func RestoreRedis(ctx context.Context, rc rueidis.CoreClient, snapshot cqrs.QueueSnapshot) error {
+	for key, data := range snapshot {
+		var cmd rueidis.Completed
+
+		switch data.Type {
+		case "string":
+			strVal, _ := data.Value.(string)
+			cmd = rc.B().Set().Key(key).Value(strVal).Build()
+		case "list":
+			cmd = rc.B().Rpush().Key(key).Element(snapshotStrings(data.Value)...).Build()
+		case "set":
+			cmd = rc.B().Sadd().Key(key).Member(snapshotStrings(data.Value)...).Build()
+		case "zset":
+			vals := snapshotStrings(data.Value)
+			zaddCmd := rc.B().Zadd().Key(key).ScoreMember()
+			for i := 0; i+1 < len(vals); i += 2 {
+				score, _ := strconv.ParseFloat(vals[i+1], 64)
+				zaddCmd = zaddCmd.ScoreMember(score, vals[i])
+			}
+			cmd = zaddCmd.Build()
+		case "hash":
+			hmsetCmd := rc.B().Hmset().Key(key).FieldValue()
+			for k, v := range snapshotHash(data.Value) {
+				hmsetCmd = hmsetCmd.FieldValue(k, v)
+			}
+			cmd = hmsetCmd.Build()
+		default:
+			return fmt.Errorf("unsupported key type: %s", data.Type)
+		}
+
+		if err := rc.Do(ctx, cmd).Error(); err != nil {
+			return fmt.Errorf("error restoring %s key %s: %w", data.Type, key, err)
+		}
+	}
+	return nil
+}
+
+// snapshotStrings returns a snapshot's list, set or zset values, which are
+// decoded as []interface{} when read from JSON.
+func snapshotStrings(v any) []string {
+	switch vals := v.(type) {
+	case []string:
+		return vals
+	case []interface{}:
+		strValues := make([]string, len(vals))
+		for i, v := range vals {
+			strValues[i], _ = v.(string)
+		}
+		return strValues
+	}
+	return nil
+}
+
+// snapshotHash returns a snapshot's hash values, which are decoded as
+// map[string]interface{} when read from JSON.
+func snapshotHash(v any) map[string]string {
+	switch vals := v.(type) {
+	case map[string]string:
+		return vals
+	case map[string]interface{}:
+		strValues := make(map[string]string, len(vals))
+		for k, v := range vals {
+			strValues[k], _ = v.(string)
+		}
+		return strValues
+	}
+	return nil
+}
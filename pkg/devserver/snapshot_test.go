@@ -0,0 +1,55 @@
+package devserver
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/require"
+)
+
+func newRedis(t *testing.T) (*miniredis.Miniredis, rueidis.Client) {
+	r := miniredis.RunT(t)
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:  []string{r.Addr()},
+		DisableCache: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(rc.Close)
+	return r, rc
+}
+
+func TestDumpRestoreRedis(t *testing.T) {
+	ctx := context.Background()
+
+	src, srcClient := newRedis(t)
+	require.NoError(t, src.Set("string", "value"))
+	_, err := src.Push("list", "a", "b")
+	require.NoError(t, err)
+	_, err = src.SetAdd("set", "a", "b")
+	require.NoError(t, err)
+	_, err = src.ZAdd("zset", 1.5, "a")
+	require.NoError(t, err)
+	src.HSet("hash", "field", "value")
+
+	snapshot, err := DumpRedis(ctx, srcClient)
+	require.NoError(t, err)
+	require.Len(t, snapshot, 5)
+
+	// Snapshots are stored as JSON, so restore from the decoded snapshot.
+	byt, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	decoded := cqrs.QueueSnapshot{}
+	require.NoError(t, json.Unmarshal(byt, &decoded))
+
+	dst, dstClient := newRedis(t)
+	require.NoError(t, RestoreRedis(ctx, dstClient, decoded))
+
+	require.Equal(t, src.Dump(), dst.Dump())
+	score, err := dst.ZScore("zset", "a")
+	require.NoError(t, err)
+	require.Equal(t, 1.5, score)
+}
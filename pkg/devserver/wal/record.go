@@ -0,0 +1,214 @@
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strings"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// Each record is framed as a 4 byte length and a 4 byte CRC of the payload,
+// followed by the payload.  The first byte of the payload is the record type.
+const (
+	recordHeader   byte = 'H'
+	recordCommands byte = 'C'
+
+	frameSize = 8
+	// maxRecordSize bounds allocations when reading corrupt records.
+	maxRecordSize = 1 << 30
+)
+
+var errCorrupt = errors.New("corrupt write-ahead log record")
+
+// header is the first record of each segment.
+type header struct {
+	// snapshotID is the snapshot which the segment's commands are applied to.
+	snapshotID ulid.ULID
+	// scripts lists every script loaded prior to the segment.
+	scripts []string
+}
+
+// writeCommands lists commands which modify the keyspace or script cache.
+// Scripts are always logged, as they may write.
+var writeCommands = map[string]bool{}
+
+func init() {
+	for _, cmd := range strings.Fields(`
+		APPEND COPY DECR DECRBY DEL EXPIRE EXPIREAT FLUSHALL FLUSHDB GETDEL
+		GETEX GETSET HDEL HINCRBY HINCRBYFLOAT HMSET HSET HSETNX INCR INCRBY
+		INCRBYFLOAT LINSERT LMOVE LPOP LPUSH LPUSHX LREM LSET LTRIM MOVE MSET
+		MSETNX PERSIST PEXPIRE PEXPIREAT PSETEX RENAME RENAMENX RPOP RPOPLPUSH
+		RPUSH RPUSHX SADD SDIFFSTORE SET SETEX SETNX SETRANGE SINTERSTORE SMOVE
+		SPOP SREM SUNIONSTORE SWAPDB UNLINK XADD XDEL XGROUP XTRIM ZADD ZINCRBY
+		ZINTERSTORE ZPOPMAX ZPOPMIN ZREM ZREMRANGEBYLEX ZREMRANGEBYRANK
+		ZREMRANGEBYSCORE ZUNIONSTORE EVAL EVALSHA PFADD PFMERGE GEOADD
+		GEORADIUS GEORADIUSBYMEMBER`) {
+		writeCommands[cmd] = true
+	}
+}
+
+// writes returns whether the command may modify the keyspace.
+func writes(cmd string, args []string) bool {
+	switch cmd {
+	case "SCRIPT":
+		return len(args) > 0 && (strings.EqualFold(args[0], "LOAD") || strings.EqualFold(args[0], "FLUSH"))
+	case "SORT":
+		for _, a := range args {
+			if strings.EqualFold(a, "STORE") {
+				return true
+			}
+		}
+		return false
+	}
+	return writeCommands[cmd]
+}
+
+func encodeRecord(payload []byte) []byte {
+	out := make([]byte, frameSize, frameSize+len(payload))
+	binary.LittleEndian.PutUint32(out[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(out[4:8], crc32.ChecksumIEEE(payload))
+	return append(out, payload...)
+}
+
+// readRecord reads a single record's payload, returning io.EOF if there are no
+// more records.
+func readRecord(r io.Reader) ([]byte, error) {
+	frame := make([]byte, frameSize)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, io.ErrUnexpectedEOF
+	}
+	size := binary.LittleEndian.Uint32(frame[0:4])
+	if size == 0 || size > maxRecordSize {
+		return nil, errCorrupt
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(frame[4:8]) {
+		return nil, errCorrupt
+	}
+	return payload, nil
+}
+
+func encodeHeader(h header) []byte {
+	out := []byte{recordHeader}
+	out = appendString(out, h.snapshotID.String())
+	out = binary.AppendUvarint(out, uint64(len(h.scripts)))
+	for _, s := range h.scripts {
+		out = appendString(out, s)
+	}
+	return out
+}
+
+func decodeHeader(payload []byte) (header, error) {
+	d := decoder{b: payload}
+	if d.byte() != recordHeader {
+		return header{}, fmt.Errorf("write-ahead log segment has no header")
+	}
+	id, err := ulid.Parse(d.string())
+	if err != nil {
+		return header{}, fmt.Errorf("invalid write-ahead log snapshot ID: %w", err)
+	}
+	h := header{snapshotID: id}
+	for n := d.uvarint(); n > 0 && d.err == nil; n-- {
+		h.scripts = append(h.scripts, d.string())
+	}
+	return h, d.err
+}
+
+func encodeCommands(cmds [][]string) []byte {
+	out := []byte{recordCommands}
+	out = binary.AppendUvarint(out, uint64(len(cmds)))
+	for _, args := range cmds {
+		out = binary.AppendUvarint(out, uint64(len(args)))
+		for _, a := range args {
+			out = appendString(out, a)
+		}
+	}
+	return out
+}
+
+func decodeCommands(payload []byte) ([][]string, error) {
+	d := decoder{b: payload}
+	if d.byte() != recordCommands {
+		return nil, errCorrupt
+	}
+	var cmds [][]string
+	for n := d.uvarint(); n > 0 && d.err == nil; n-- {
+		args := []string{}
+		for m := d.uvarint(); m > 0 && d.err == nil; m-- {
+			args = append(args, d.string())
+		}
+		if len(args) == 0 {
+			return nil, errCorrupt
+		}
+		cmds = append(cmds, args)
+	}
+	return cmds, d.err
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// decoder reads values from a payload, recording the first error.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) byte() byte {
+	if len(d.b) == 0 {
+		d.err = errCorrupt
+		return 0
+	}
+	v := d.b[0]
+	d.b = d.b[1:]
+	return v
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 || v > uint64(len(d.b)) {
+		d.err = errCorrupt
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) string() string {
+	n := d.uvarint()
+	if d.err != nil || n > uint64(len(d.b)) {
+		d.err = errCorrupt
+		return ""
+	}
+	s := string(d.b[:n])
+	d.b = d.b[n:]
+	return s
+}
+
+// countingReader counts the bytes read, used to find the offset of the last
+// complete record.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,483 @@
+// Package wal implements a write-ahead log for the in-memory Redis used when
+// self-hosting without an external Redis.
+//
+// Each command which modifies the keyspace is appended to the log before it's
+// executed.  The log is compacted by snapshotting the keyspace and starting a
+// new segment which references the snapshot.  On restore, the referenced
+// snapshot is loaded and every command logged since is replayed, so that work
+// isn't lost between snapshots.
+package wal
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+	"github.com/inngest/inngest/pkg/logger"
+	"github.com/oklog/ulid/v2"
+)
+
+// FsyncPolicy configures how often the log is flushed to disk, matching
+// Redis' appendfsync setting.
+type FsyncPolicy string
+
+const (
+	// FsyncAlways syncs the log before each command is executed.  No
+	// acknowledged writes are lost on a crash, at the cost of throughput.
+	FsyncAlways FsyncPolicy = "always"
+	// FsyncEverySec syncs the log every second.  Up to a second of writes
+	// may be lost if the host crashes, though not if only the process does.
+	FsyncEverySec FsyncPolicy = "everysec"
+	// FsyncNo leaves syncing to the operating system.
+	FsyncNo FsyncPolicy = "no"
+)
+
+// ParseFsyncPolicy parses an fsync policy, defaulting to FsyncEverySec.
+func ParseFsyncPolicy(s string) (FsyncPolicy, error) {
+	switch p := FsyncPolicy(strings.ToLower(s)); p {
+	case "":
+		return FsyncEverySec, nil
+	case FsyncAlways, FsyncEverySec, FsyncNo:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid fsync policy %q: must be one of always, everysec or no", s)
+	}
+}
+
+const segmentExt = ".wal"
+
+// Options configures the log.
+type Options struct {
+	// Dir is the directory in which log segments are stored.
+	Dir string
+	// Fsync configures how often the log is synced to disk.
+	Fsync FsyncPolicy
+}
+
+// SnapshotFunc snapshots the keyspace, returning the ID of the snapshot.
+type SnapshotFunc func(ctx context.Context) (ulid.ULID, error)
+
+// Log is a write-ahead log of commands executed by a miniredis instance.
+type Log struct {
+	m    *miniredis.Miniredis
+	opts Options
+
+	// mu serializes appending to the log with executing each logged
+	// command, so that commands are replayed in the order they ran.
+	mu sync.Mutex
+	f  *os.File
+	// seq is the sequence number of the latest segment.
+	seq uint64
+	// scripts stores the body of each script run, keyed by SHA, as scripts
+	// run via EVALSHA must be loaded before they're replayed.
+	scripts map[string]string
+	// dirty records writes which haven't been synced.
+	dirty bool
+
+	// executing is the peer whose command is being executed under mu.
+	executing atomic.Pointer[server.Peer]
+
+	// tx buffers the commands queued within each peer's transaction, which
+	// are logged when the transaction is executed.
+	txLock sync.Mutex
+	tx     map[*server.Peer][][]string
+
+	stop chan struct{}
+}
+
+// Open opens the log stored within the given directory, for the given
+// miniredis instance.
+func Open(m *miniredis.Miniredis, opts Options) (*Log, error) {
+	if opts.Fsync == "" {
+		opts.Fsync = FsyncEverySec
+	}
+	if err := os.MkdirAll(opts.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("error creating write-ahead log directory: %w", err)
+	}
+
+	l := &Log{
+		m:       m,
+		opts:    opts,
+		scripts: map[string]string{},
+		tx:      map[*server.Peer][][]string{},
+		stop:    make(chan struct{}),
+	}
+
+	segments, err := l.segments()
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) > 0 {
+		l.seq = segments[len(segments)-1]
+	}
+	return l, nil
+}
+
+// SnapshotID returns the snapshot which should be restored prior to calling
+// Replay, or false if no log exists.
+func (l *Log) SnapshotID() (ulid.ULID, bool, error) {
+	if l.seq == 0 {
+		return ulid.ULID{}, false, nil
+	}
+	f, err := os.Open(l.path(l.seq))
+	if err != nil {
+		return ulid.ULID{}, false, fmt.Errorf("error opening write-ahead log: %w", err)
+	}
+	defer f.Close()
+
+	rec, err := readRecord(bufio.NewReader(f))
+	if err != nil {
+		return ulid.ULID{}, false, fmt.Errorf("error reading write-ahead log header: %w", err)
+	}
+	h, err := decodeHeader(rec)
+	if err != nil {
+		return ulid.ULID{}, false, err
+	}
+	return h.snapshotID, true, nil
+}
+
+// Replay executes each command logged within the latest segment, returning the
+// number of commands replayed.  This must be called after restoring the
+// snapshot returned by SnapshotID, and prior to Attach.  A partially written
+// record at the end of the log, eg. after a crash, is discarded.
+func (l *Log) Replay(ctx context.Context) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.seq == 0 {
+		return 0, nil
+	}
+
+	path := l.path(l.seq)
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("error opening write-ahead log: %w", err)
+	}
+	defer f.Close()
+
+	r := &countingReader{r: bufio.NewReader(f)}
+	rec, err := readRecord(r)
+	if err != nil {
+		return 0, fmt.Errorf("error reading write-ahead log header: %w", err)
+	}
+	h, err := decodeHeader(rec)
+	if err != nil {
+		return 0, err
+	}
+	peer := server.NewPeer(bufio.NewWriter(io.Discard))
+	for _, body := range h.scripts {
+		l.trackScript("SCRIPT", []string{"LOAD", body})
+		l.m.Server().Dispatch(peer, []string{"SCRIPT", "LOAD", body})
+	}
+
+	n := 0
+	for {
+		valid := r.n
+		rec, err := readRecord(r)
+		if err == io.EOF {
+			break
+		}
+		var cmds [][]string
+		if err == nil {
+			cmds, err = decodeCommands(rec)
+		}
+		if err != nil {
+			// Discard the torn or corrupt record, and anything after it,
+			// so that new records aren't appended after it.
+			logger.StdlibLogger(ctx).Warn("discarding incomplete write-ahead log record", "path", path, "offset", valid, "error", err)
+			if err := os.Truncate(path, valid); err != nil {
+				return n, fmt.Errorf("error truncating write-ahead log: %w", err)
+			}
+			break
+		}
+		for _, args := range cmds {
+			l.trackScript(args[0], args[1:])
+			l.m.Server().Dispatch(peer, args)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Compact snapshots the keyspace and starts a new segment which references the
+// snapshot, removing older segments.  Writes are blocked while the snapshot is
+// taken.
+func (l *Log) Compact(ctx context.Context, snapshot SnapshotFunc) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	id, err := snapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Write the new segment to a temporary file, so that the latest segment
+	// always has a complete header.
+	seq := l.seq + 1
+	tmp := l.path(seq) + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("error creating write-ahead log segment: %w", err)
+	}
+	if _, err := f.Write(encodeRecord(encodeHeader(header{snapshotID: id, scripts: l.scriptBodies()}))); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing write-ahead log header: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("error syncing write-ahead log segment: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error closing write-ahead log segment: %w", err)
+	}
+	if err := os.Rename(tmp, l.path(seq)); err != nil {
+		return fmt.Errorf("error renaming write-ahead log segment: %w", err)
+	}
+	syncDir(l.opts.Dir)
+
+	f, err = os.OpenFile(l.path(seq), os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("error opening write-ahead log segment: %w", err)
+	}
+	if l.f != nil {
+		_ = l.f.Close()
+	}
+	l.f, l.seq, l.dirty = f, seq, false
+
+	segments, err := l.segments()
+	if err != nil {
+		return err
+	}
+	for _, s := range segments {
+		if s < seq {
+			_ = os.Remove(l.path(s))
+		}
+	}
+	return nil
+}
+
+// Attach starts logging commands executed by the miniredis instance.  Compact
+// must be called first to start a segment.
+func (l *Log) Attach(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return fmt.Errorf("write-ahead log must be compacted before it's attached")
+	}
+
+	l.m.Server().SetPreHook(l.hook)
+	if l.opts.Fsync == FsyncEverySec {
+		go l.syncEverySec(ctx)
+	}
+	return nil
+}
+
+// Close stops logging commands and syncs the log to disk.
+func (l *Log) Close() error {
+	l.m.Server().SetPreHook(nil)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-l.stop:
+	default:
+		close(l.stop)
+	}
+	if l.f == nil {
+		return nil
+	}
+	err := errors.Join(l.f.Sync(), l.f.Close())
+	l.f = nil
+	return err
+}
+
+// hook is called by miniredis prior to executing each command.  Commands which
+// modify the keyspace are logged and executed while holding the lock,
+// returning true to prevent miniredis from executing them again.
+func (l *Log) hook(c *server.Peer, cmd string, args ...string) bool {
+	// Commands called from scripts are replayed by replaying the script, and
+	// the command being logged is executed via Dispatch, re-entering the hook.
+	if l.executing.Load() == c || nested(c) {
+		return false
+	}
+
+	l.txLock.Lock()
+	queued, inTx := l.tx[c]
+	switch {
+	case cmd == "MULTI":
+		l.tx[c] = [][]string{}
+		l.txLock.Unlock()
+		return false
+	case cmd == "DISCARD":
+		delete(l.tx, c)
+		l.txLock.Unlock()
+		return false
+	case cmd == "EXEC":
+		delete(l.tx, c)
+		l.txLock.Unlock()
+		if len(queued) == 0 {
+			return false
+		}
+		return l.execute(c, queued, cmd)
+	case inTx:
+		if writes(cmd, args) {
+			l.tx[c] = append(queued, append([]string{cmd}, args...))
+		}
+		l.txLock.Unlock()
+		return false
+	}
+	l.txLock.Unlock()
+
+	if !writes(cmd, args) {
+		return false
+	}
+	return l.execute(c, [][]string{append([]string{cmd}, args...)}, cmd, args...)
+}
+
+// execute logs the given commands, then dispatches cmd for the peer.
+func (l *Log) execute(c *server.Peer, logged [][]string, cmd string, args ...string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f == nil {
+		// The log was closed while waiting for the lock, after the final
+		// snapshot was taken.
+		return false
+	}
+	if err := l.append(logged); err != nil {
+		logger.StdlibLogger(context.Background()).Error("error writing to write-ahead log", "error", err)
+		c.WriteError("ERR error writing to write-ahead log: " + err.Error())
+		return true
+	}
+	for _, args := range logged {
+		l.trackScript(args[0], args[1:])
+	}
+
+	l.executing.Store(c)
+	defer l.executing.Store(nil)
+	l.m.Server().Dispatch(c, append([]string{cmd}, args...))
+	return true
+}
+
+func (l *Log) append(cmds [][]string) error {
+	if _, err := l.f.Write(encodeRecord(encodeCommands(cmds))); err != nil {
+		return err
+	}
+	if l.opts.Fsync == FsyncAlways {
+		return l.f.Sync()
+	}
+	l.dirty = true
+	return nil
+}
+
+func (l *Log) syncEverySec(ctx context.Context) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-l.stop:
+			return
+		case <-t.C:
+			l.mu.Lock()
+			if l.dirty && l.f != nil {
+				if err := l.f.Sync(); err != nil {
+					logger.StdlibLogger(ctx).Error("error syncing write-ahead log", "error", err)
+				}
+				l.dirty = false
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+// trackScript records the body of scripts loaded by the given command.
+func (l *Log) trackScript(cmd string, args []string) {
+	switch strings.ToUpper(cmd) {
+	case "EVAL":
+		if len(args) > 0 {
+			l.scripts[sha(args[0])] = args[0]
+		}
+	case "SCRIPT":
+		if len(args) > 1 && strings.EqualFold(args[0], "LOAD") {
+			l.scripts[sha(args[1])] = args[1]
+		}
+		if len(args) > 0 && strings.EqualFold(args[0], "FLUSH") {
+			l.scripts = map[string]string{}
+		}
+	}
+}
+
+func (l *Log) scriptBodies() []string {
+	bodies := make([]string, 0, len(l.scripts))
+	for _, body := range l.scripts {
+		bodies = append(bodies, body)
+	}
+	slices.Sort(bodies)
+	return bodies
+}
+
+func (l *Log) path(seq uint64) string {
+	return filepath.Join(l.opts.Dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
+
+// segments returns the sequence number of each segment, in order.
+func (l *Log) segments() ([]uint64, error) {
+	entries, err := os.ReadDir(l.opts.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading write-ahead log directory: %w", err)
+	}
+	var seqs []uint64
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), segmentExt)
+		if !ok {
+			continue
+		}
+		seq, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	slices.Sort(seqs)
+	return seqs, nil
+}
+
+// nested returns whether the peer is executing commands called from a Lua
+// script.  miniredis records this within the peer's unexported context.
+func nested(c *server.Peer) bool {
+	v := reflect.ValueOf(c.Ctx)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	f := v.Elem().FieldByName("nested")
+	return f.IsValid() && f.Kind() == reflect.Bool && f.Bool()
+}
+
+func sha(body string) string {
+	h := sha1.Sum([]byte(body))
+	return hex.EncodeToString(h[:])
+}
+
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+}
@@ -0,0 +1,175 @@
+package wal
+
+import (
+	"context"
+	"crypto/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/require"
+)
+
+func newClient(t *testing.T, m *miniredis.Miniredis) rueidis.Client {
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:       []string{m.Addr()},
+		DisableCache:      true,
+		ForceSingleClient: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+func TestReplay(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	id := ulid.MustNew(ulid.Now(), rand.Reader)
+	snapshot := func(ctx context.Context) (ulid.ULID, error) { return id, nil }
+
+	m := miniredis.RunT(t)
+	rc := newClient(t, m)
+	require.NoError(t, rc.Do(ctx, rc.B().Set().Key("before").Value("snapshot").Build()).Error())
+
+	l, err := Open(m, Options{Dir: dir, Fsync: FsyncAlways})
+	require.NoError(t, err)
+	require.NoError(t, l.Compact(ctx, snapshot))
+	require.NoError(t, l.Attach(ctx))
+
+	script := rueidis.NewLuaScript(`redis.call("INCR", KEYS[1]); return redis.call("RPUSH", KEYS[2], ARGV[1])`)
+	require.NoError(t, rc.Do(ctx, rc.B().Incr().Key("counter").Build()).Error())
+	require.NoError(t, rc.Do(ctx, rc.B().Zadd().Key("zset").ScoreMember().ScoreMember(1, "a").Build()).Error())
+	require.NoError(t, script.Exec(ctx, rc, []string{"counter", "list"}, []string{"x"}).Error())
+	require.NoError(t, script.Exec(ctx, rc, []string{"counter", "list"}, []string{"y"}).Error())
+	require.NoError(t, rc.Do(ctx, rc.B().Get().Key("counter").Build()).Error())
+
+	dedicated, cancel := rc.Dedicate()
+	resps := dedicated.DoMulti(ctx,
+		dedicated.B().Multi().Build(),
+		dedicated.B().Hset().Key("hash").FieldValue().FieldValue("f", "v").Build(),
+		dedicated.B().Exec().Build(),
+	)
+	for _, resp := range resps {
+		require.NoError(t, resp.Error())
+	}
+	cancel()
+	require.NoError(t, l.Close())
+
+	// Restore into a new instance, as if the process restarted.
+	restored := miniredis.RunT(t)
+	l, err = Open(restored, Options{Dir: dir})
+	require.NoError(t, err)
+	got, ok, err := l.SnapshotID()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, id, got)
+
+	n, err := l.Replay(ctx)
+	require.NoError(t, err)
+	// The first EVALSHA fails as the script isn't loaded, and is logged
+	// prior to EVAL.  It fails again when replayed.
+	require.Equal(t, 6, n)
+
+	// Keys from the snapshot aren't replayed.
+	require.False(t, restored.Exists("before"))
+	v, err := restored.Get("counter")
+	require.NoError(t, err)
+	require.Equal(t, "3", v)
+	list, err := restored.List("list")
+	require.NoError(t, err)
+	require.Equal(t, []string{"x", "y"}, list)
+	require.Equal(t, "v", restored.HGet("hash", "f"))
+	members, err := restored.ZMembers("zset")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, members)
+
+	// Scripts are loaded, and carried into new segments on compaction.
+	require.NoError(t, l.Compact(ctx, snapshot))
+	require.NoError(t, l.Close())
+
+	segments, err := l.segments()
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	f, err := os.Open(l.path(segments[0]))
+	require.NoError(t, err)
+	defer f.Close()
+	rec, err := readRecord(f)
+	require.NoError(t, err)
+	h, err := decodeHeader(rec)
+	require.NoError(t, err)
+	require.Len(t, h.scripts, 1)
+}
+
+func TestReplayTornRecord(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	snapshot := func(ctx context.Context) (ulid.ULID, error) { return ulid.Make(), nil }
+
+	m := miniredis.RunT(t)
+	rc := newClient(t, m)
+	l, err := Open(m, Options{Dir: dir, Fsync: FsyncNo})
+	require.NoError(t, err)
+	require.NoError(t, l.Compact(ctx, snapshot))
+	require.NoError(t, l.Attach(ctx))
+	require.NoError(t, rc.Do(ctx, rc.B().Set().Key("a").Value("1").Build()).Error())
+	require.NoError(t, l.Close())
+
+	// Simulate a crash partway through writing a record.
+	path := l.path(l.seq)
+	valid, err := os.Stat(path)
+	require.NoError(t, err)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o640)
+	require.NoError(t, err)
+	_, err = f.Write(encodeRecord(encodeCommands([][]string{{"SET", "b", "2"}}))[:12])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	restored := miniredis.RunT(t)
+	l, err = Open(restored, Options{Dir: dir})
+	require.NoError(t, err)
+	n, err := l.Replay(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.True(t, restored.Exists("a"))
+	require.False(t, restored.Exists("b"))
+
+	truncated, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, valid.Size(), truncated.Size())
+}
+
+func TestFsyncEverySec(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := miniredis.RunT(t)
+	rc := newClient(t, m)
+	l, err := Open(m, Options{Dir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, l.Compact(ctx, func(ctx context.Context) (ulid.ULID, error) { return ulid.Make(), nil }))
+	require.NoError(t, l.Attach(ctx))
+	defer l.Close()
+
+	require.NoError(t, rc.Do(ctx, rc.B().Set().Key("a").Value("1").Build()).Error())
+	require.Eventually(t, func() bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return !l.dirty
+	}, 3*time.Second, 50*time.Millisecond)
+}
+
+func TestParseFsyncPolicy(t *testing.T) {
+	p, err := ParseFsyncPolicy("")
+	require.NoError(t, err)
+	require.Equal(t, FsyncEverySec, p)
+
+	p, err = ParseFsyncPolicy("Always")
+	require.NoError(t, err)
+	require.Equal(t, FsyncAlways, p)
+
+	_, err = ParseFsyncPolicy("sometimes")
+	require.Error(t, err)
+}
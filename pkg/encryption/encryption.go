@@ -0,0 +1,271 @@
+// Package encryption provides envelope encryption for data stored at rest, such
+// as event payloads, step outputs and trace span outputs.
+//
+// Each value is encrypted with a random data encryption key (DEK), which is
+// then wrapped by a key encryption key (KEK) loaded from a KeyProvider.  The
+// encrypted value is stored as a JSON envelope containing the KEK ID, the
+// wrapped DEK and the ciphertext, so that data remains valid JSON in every
+// store and can be decrypted after the primary KEK is rotated.  Data is only
+// treated as an envelope if it is a JSON object with exactly the envelope's
+// fields, so user data which happens to contain an envelope field is never
+// mistaken for ciphertext.
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// envelopeVersion is the current version of the envelope format.
+	envelopeVersion = 1
+
+	// KeySize is the size, in bytes, of key encryption keys and data
+	// encryption keys.  All keys are AES-256 keys.
+	KeySize = 32
+)
+
+var (
+	// ErrKeyNotFound is returned when a key with the given ID does not exist
+	// within a KeyProvider.
+	ErrKeyNotFound = errors.New("encryption key not found")
+
+	// ErrInvalidKey is returned when a key has the wrong size.
+	ErrInvalidKey = fmt.Errorf("encryption keys must be %d bytes", KeySize)
+)
+
+const (
+	// nonceSize and tagSize are the sizes of the AES-GCM nonce prefixed to
+	// all ciphertext and of the authentication tag appended to it.
+	nonceSize = 12
+	tagSize   = 16
+
+	// wrappedKeySize is the size of a data encryption key wrapped by seal.
+	wrappedKeySize = nonceSize + KeySize + tagSize
+)
+
+// Key is a key encryption key.
+type Key struct {
+	// ID uniquely identifies the key and is stored alongside encrypted data
+	// so that the correct key can be used for decryption.
+	ID string
+	// Material is the raw AES-256 key.
+	Material []byte
+}
+
+// KeyProvider loads key encryption keys.  The local keyfile provider is the
+// default implementation;  KMS-backed providers can implement this interface
+// to wrap keys remotely.
+type KeyProvider interface {
+	// Primary returns the key used to encrypt new data.
+	Primary(ctx context.Context) (Key, error)
+	// Key returns the key with the given ID, used to decrypt existing data.
+	Key(ctx context.Context, id string) (Key, error)
+}
+
+// Encryptor encrypts and decrypts data stored at rest.
+type Encryptor interface {
+	// Encrypt encrypts the given plaintext using the primary key.
+	Encrypt(ctx context.Context, plaintext []byte) ([]byte, error)
+	// Decrypt decrypts the given data.  Data which is not encrypted is
+	// returned as-is, allowing plaintext and encrypted data to coexist.
+	Decrypt(ctx context.Context, data []byte) ([]byte, error)
+	// Reencrypt re-encrypts data using the current primary key, returning
+	// whether the data changed.  Data already encrypted with the primary key
+	// is returned unchanged.
+	Reencrypt(ctx context.Context, data []byte) ([]byte, bool, error)
+}
+
+// envelope is the stored representation of encrypted data.
+type envelope struct {
+	Version int    `json:"__inngest_enc"`
+	KeyID   string `json:"kid"`
+	DEK     []byte `json:"dek"`
+	Data    []byte `json:"data"`
+}
+
+// NewEnvelopeEncryptor returns an Encryptor which uses envelope encryption with
+// keys from the given provider.
+func NewEnvelopeEncryptor(kp KeyProvider) Encryptor {
+	return envelopeEncryptor{kp: kp}
+}
+
+type envelopeEncryptor struct {
+	kp KeyProvider
+}
+
+func (e envelopeEncryptor) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	kek, err := e.kp.Primary(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error loading primary encryption key: %w", err)
+	}
+
+	dek := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return nil, fmt.Errorf("error generating data key: %w", err)
+	}
+
+	// Bind the wrapped DEK to the KEK ID so that envelopes can't be tampered
+	// with to reference another key.
+	wrapped, err := seal(kek.Material, dek, []byte(kek.ID))
+	if err != nil {
+		return nil, fmt.Errorf("error wrapping data key: %w", err)
+	}
+	ciphertext, err := seal(dek, plaintext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting data: %w", err)
+	}
+
+	return json.Marshal(envelope{
+		Version: envelopeVersion,
+		KeyID:   kek.ID,
+		DEK:     wrapped,
+		Data:    ciphertext,
+	})
+}
+
+func (e envelopeEncryptor) Decrypt(ctx context.Context, data []byte) ([]byte, error) {
+	env, ok := parse(data)
+	if !ok {
+		return data, nil
+	}
+
+	kek, err := e.kp.Key(ctx, env.KeyID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading encryption key %q: %w", env.KeyID, err)
+	}
+
+	dek, err := open(kek.Material, env.DEK, []byte(env.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("error unwrapping data key: %w", err)
+	}
+	plaintext, err := open(dek, env.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting data: %w", err)
+	}
+	return plaintext, nil
+}
+
+func (e envelopeEncryptor) Reencrypt(ctx context.Context, data []byte) ([]byte, bool, error) {
+	if env, ok := parse(data); ok {
+		primary, err := e.kp.Primary(ctx)
+		if err != nil {
+			return nil, false, fmt.Errorf("error loading primary encryption key: %w", err)
+		}
+		if env.KeyID == primary.ID {
+			return data, false, nil
+		}
+	}
+
+	plaintext, err := e.Decrypt(ctx, data)
+	if err != nil {
+		return nil, false, err
+	}
+	encrypted, err := e.Encrypt(ctx, plaintext)
+	if err != nil {
+		return nil, false, err
+	}
+	return encrypted, true, nil
+}
+
+// IsEncrypted returns whether the given data is an encrypted envelope.
+func IsEncrypted(data []byte) bool {
+	_, ok := parse(data)
+	return ok
+}
+
+// KeyID returns the ID of the key used to encrypt the given data, if the data
+// is encrypted.
+func KeyID(data []byte) (string, bool) {
+	env, ok := parse(data)
+	if !ok {
+		return "", false
+	}
+	return env.KeyID, true
+}
+
+// PlaintextSize returns the size of the plaintext within the given data,
+// without decrypting it.  The size of data which is not encrypted is returned
+// as-is.
+func PlaintextSize(data []byte) int {
+	env, ok := parse(data)
+	if !ok {
+		return len(data)
+	}
+	return len(env.Data) - nonceSize - tagSize
+}
+
+// parse decodes the given data if it is exactly an envelope:  a JSON object
+// containing only the envelope's fields, with a known version and a
+// correctly sized wrapped key and ciphertext.
+func parse(data []byte) (envelope, bool) {
+	env := envelope{}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return env, false
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil || len(fields) != 4 {
+		return env, false
+	}
+	for _, f := range []string{"__inngest_enc", "kid", "dek", "data"} {
+		if _, ok := fields[f]; !ok {
+			return env, false
+		}
+	}
+
+	if err := json.Unmarshal(data, &env); err != nil {
+		return env, false
+	}
+	if env.Version != envelopeVersion || env.KeyID == "" || len(env.DEK) != wrappedKeySize || len(env.Data) < nonceSize+tagSize {
+		return env, false
+	}
+	return env, true
+}
+
+// seal encrypts the plaintext using AES-GCM, prefixing the ciphertext with the
+// generated nonce.
+func seal(key, plaintext, additional []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+// open decrypts nonce-prefixed ciphertext created via seal.
+func open(key, ciphertext, additional []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, additional)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,114 @@
+package encryption
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeKeyfile(t *testing.T, path string, kf Keyfile, mod time.Time) {
+	byt, err := json.Marshal(kf)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, byt, 0600))
+	require.NoError(t, os.Chtimes(path, mod, mod))
+}
+
+func TestEnvelopeEncryptor(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys.json")
+
+	k1, err := GenerateKey()
+	require.NoError(t, err)
+	k2, err := GenerateKey()
+	require.NoError(t, err)
+
+	writeKeyfile(t, path, Keyfile{Primary: "k1", Keys: map[string]string{"k1": k1}}, time.Now().Add(-time.Minute))
+
+	kp, err := NewKeyfileProvider(ctx, path)
+	require.NoError(t, err)
+	enc := NewEnvelopeEncryptor(kp)
+
+	t.Run("it round trips data", func(t *testing.T) {
+		plaintext := []byte(`{"data":{"email":"test@example.com"}}`)
+		encrypted, err := enc.Encrypt(ctx, plaintext)
+		require.NoError(t, err)
+		require.NotContains(t, string(encrypted), "test@example.com")
+		require.True(t, IsEncrypted(encrypted))
+		require.True(t, json.Valid(encrypted))
+
+		decrypted, err := enc.Decrypt(ctx, encrypted)
+		require.NoError(t, err)
+		require.Equal(t, plaintext, decrypted)
+	})
+
+	t.Run("it passes plaintext through on decrypt", func(t *testing.T) {
+		plaintext := []byte(`{"data":{}}`)
+		decrypted, err := enc.Decrypt(ctx, plaintext)
+		require.NoError(t, err)
+		require.Equal(t, plaintext, decrypted)
+	})
+
+	t.Run("it passes through plaintext containing envelope fields", func(t *testing.T) {
+		for _, plaintext := range []string{
+			`{"__inngest_enc":1,"kid":"k1","dek":"","data":""}`,
+			`{"__inngest_enc":1,"kid":"k1","dek":"AAAA","data":"AAAA","user":"x"}`,
+			`{"event":{"__inngest_enc":1,"kid":"k1"}}`,
+			`"__inngest_enc"`,
+		} {
+			require.False(t, IsEncrypted([]byte(plaintext)), plaintext)
+			decrypted, err := enc.Decrypt(ctx, []byte(plaintext))
+			require.NoError(t, err)
+			require.Equal(t, plaintext, string(decrypted))
+		}
+	})
+
+	t.Run("it returns the plaintext size", func(t *testing.T) {
+		plaintext := []byte(`{"data":{"email":"test@example.com"}}`)
+		encrypted, err := enc.Encrypt(ctx, plaintext)
+		require.NoError(t, err)
+		require.Equal(t, len(plaintext), PlaintextSize(encrypted))
+		require.Equal(t, len(plaintext), PlaintextSize(plaintext))
+	})
+
+	t.Run("it decrypts and re-encrypts after rotation", func(t *testing.T) {
+		plaintext := []byte(`"hello"`)
+		old, err := enc.Encrypt(ctx, plaintext)
+		require.NoError(t, err)
+
+		writeKeyfile(t, path, Keyfile{Primary: "k2", Keys: map[string]string{"k1": k1, "k2": k2}}, time.Now())
+		changed, err := kp.Reload(ctx)
+		require.NoError(t, err)
+		require.True(t, changed)
+
+		decrypted, err := enc.Decrypt(ctx, old)
+		require.NoError(t, err)
+		require.Equal(t, plaintext, decrypted)
+
+		rotated, changed, err := enc.Reencrypt(ctx, old)
+		require.NoError(t, err)
+		require.True(t, changed)
+		id, _ := KeyID(rotated)
+		require.Equal(t, "k2", id)
+
+		_, changed, err = enc.Reencrypt(ctx, rotated)
+		require.NoError(t, err)
+		require.False(t, changed)
+	})
+
+	t.Run("it fails with unknown keys", func(t *testing.T) {
+		encrypted, err := enc.Encrypt(ctx, []byte(`1`))
+		require.NoError(t, err)
+
+		writeKeyfile(t, path, Keyfile{Primary: "k1", Keys: map[string]string{"k1": k1}}, time.Now().Add(time.Minute))
+		_, err = kp.Reload(ctx)
+		require.NoError(t, err)
+
+		_, err = enc.Decrypt(ctx, encrypted)
+		require.ErrorIs(t, err, ErrKeyNotFound)
+	})
+}
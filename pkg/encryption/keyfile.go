@@ -0,0 +1,156 @@
+package encryption
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/inngest/inngest/pkg/logger"
+)
+
+// Keyfile is the on-disk format of a local keyfile, eg:
+//
+//	{
+//	  "primary": "2025-02",
+//	  "keys": {
+//	    "2025-02": "<base64-encoded 32 byte key>",
+//	    "2024-11": "<base64-encoded 32 byte key>"
+//	  }
+//	}
+//
+// Rotating keys is done by adding a new key and changing the primary key ID.
+// Older keys must be kept until all data has been re-encrypted.
+type Keyfile struct {
+	Primary string            `json:"primary"`
+	Keys    map[string]string `json:"keys"`
+}
+
+// GenerateKey returns a new random base64-encoded key suitable for use within
+// a keyfile.
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// NewKeyfileProvider returns a KeyProvider which loads keys from a local JSON
+// keyfile.  The keyfile can be reloaded without a restart via Reload or Watch.
+func NewKeyfileProvider(ctx context.Context, path string) (*KeyfileProvider, error) {
+	kp := &KeyfileProvider{path: path}
+	if _, err := kp.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return kp, nil
+}
+
+// KeyfileProvider loads key encryption keys from a local keyfile.
+type KeyfileProvider struct {
+	path string
+
+	mu      sync.RWMutex
+	modTime time.Time
+	primary string
+	keys    map[string][]byte
+}
+
+func (k *KeyfileProvider) Primary(ctx context.Context) (Key, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.key(k.primary)
+}
+
+func (k *KeyfileProvider) Key(ctx context.Context, id string) (Key, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.key(id)
+}
+
+func (k *KeyfileProvider) key(id string) (Key, error) {
+	material, ok := k.keys[id]
+	if !ok {
+		return Key{}, ErrKeyNotFound
+	}
+	return Key{ID: id, Material: material}, nil
+}
+
+// Reload re-reads the keyfile if it has changed since it was last read,
+// returning whether any keys changed.
+func (k *KeyfileProvider) Reload(ctx context.Context) (bool, error) {
+	info, err := os.Stat(k.path)
+	if err != nil {
+		return false, fmt.Errorf("error reading keyfile: %w", err)
+	}
+
+	k.mu.RLock()
+	unchanged := info.ModTime().Equal(k.modTime)
+	k.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	byt, err := os.ReadFile(k.path)
+	if err != nil {
+		return false, fmt.Errorf("error reading keyfile: %w", err)
+	}
+	kf := Keyfile{}
+	if err := json.Unmarshal(byt, &kf); err != nil {
+		return false, fmt.Errorf("error parsing keyfile: %w", err)
+	}
+
+	keys := make(map[string][]byte, len(kf.Keys))
+	for id, encoded := range kf.Keys {
+		material, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return false, fmt.Errorf("error decoding key %q: %w", id, err)
+		}
+		if len(material) != KeySize {
+			return false, fmt.Errorf("invalid key %q: %w", id, ErrInvalidKey)
+		}
+		keys[id] = material
+	}
+	if _, ok := keys[kf.Primary]; !ok {
+		return false, fmt.Errorf("primary key %q not found in keyfile", kf.Primary)
+	}
+
+	k.mu.Lock()
+	previous := k.primary
+	k.modTime = info.ModTime()
+	k.primary = kf.Primary
+	k.keys = keys
+	k.mu.Unlock()
+
+	l := logger.From(ctx).Info().Str("keyfile", k.path).Str("primary", kf.Primary).Int("keys", len(keys))
+	switch previous {
+	case "":
+		l.Msg("loaded encryption keys")
+	case kf.Primary:
+		l.Msg("reloaded encryption keys")
+	default:
+		l.Str("previous", previous).Msg("rotated primary encryption key")
+	}
+	return true, nil
+}
+
+// Watch reloads the keyfile every interval until the context is cancelled.
+func (k *KeyfileProvider) Watch(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if _, err := k.Reload(ctx); err != nil {
+				logger.From(ctx).Error().Err(err).Str("keyfile", k.path).Msg("error reloading encryption keys")
+			}
+		}
+	}
+}
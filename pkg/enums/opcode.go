@@ -14,6 +14,8 @@ const (
 	OpcodeSleep
 	OpcodeWaitForEvent
 	OpcodeInvokeFunction
-	OpcodeAIGateway // AI gateway inference call
-	OpcodeGateway   // Gateway call
+	OpcodeAIGateway     // AI gateway inference call
+	OpcodeGateway       // Gateway call
+	OpcodeWaitForSignal // Wait for a named signal
+	OpcodeWaitForEvents // Wait for multiple events
 )
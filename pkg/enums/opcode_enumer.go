@@ -8,11 +8,11 @@ import (
 	"strings"
 )
 
-const _OpcodeName = "NoneStepStepRunStepErrorStepPlannedSleepWaitForEventInvokeFunctionAIGatewayGateway"
+const _OpcodeName = "NoneStepStepRunStepErrorStepPlannedSleepWaitForEventInvokeFunctionAIGatewayGatewayWaitForSignalWaitForEvents"
 
-var _OpcodeIndex = [...]uint8{0, 4, 8, 15, 24, 35, 40, 52, 66, 75, 82}
+var _OpcodeIndex = [...]uint8{0, 4, 8, 15, 24, 35, 40, 52, 66, 75, 82, 95, 108}
 
-const _OpcodeLowerName = "nonestepsteprunsteperrorstepplannedsleepwaitforeventinvokefunctionaigatewaygateway"
+const _OpcodeLowerName = "nonestepsteprunsteperrorstepplannedsleepwaitforeventinvokefunctionaigatewaygatewaywaitforsignalwaitforevents"
 
 func (i Opcode) String() string {
 	if i < 0 || i >= Opcode(len(_OpcodeIndex)-1) {
@@ -35,31 +35,37 @@ func _OpcodeNoOp() {
 	_ = x[OpcodeInvokeFunction-(7)]
 	_ = x[OpcodeAIGateway-(8)]
 	_ = x[OpcodeGateway-(9)]
+	_ = x[OpcodeWaitForSignal-(10)]
+	_ = x[OpcodeWaitForEvents-(11)]
 }
 
-var _OpcodeValues = []Opcode{OpcodeNone, OpcodeStep, OpcodeStepRun, OpcodeStepError, OpcodeStepPlanned, OpcodeSleep, OpcodeWaitForEvent, OpcodeInvokeFunction, OpcodeAIGateway, OpcodeGateway}
+var _OpcodeValues = []Opcode{OpcodeNone, OpcodeStep, OpcodeStepRun, OpcodeStepError, OpcodeStepPlanned, OpcodeSleep, OpcodeWaitForEvent, OpcodeInvokeFunction, OpcodeAIGateway, OpcodeGateway, OpcodeWaitForSignal, OpcodeWaitForEvents}
 
 var _OpcodeNameToValueMap = map[string]Opcode{
-	_OpcodeName[0:4]:        OpcodeNone,
-	_OpcodeLowerName[0:4]:   OpcodeNone,
-	_OpcodeName[4:8]:        OpcodeStep,
-	_OpcodeLowerName[4:8]:   OpcodeStep,
-	_OpcodeName[8:15]:       OpcodeStepRun,
-	_OpcodeLowerName[8:15]:  OpcodeStepRun,
-	_OpcodeName[15:24]:      OpcodeStepError,
-	_OpcodeLowerName[15:24]: OpcodeStepError,
-	_OpcodeName[24:35]:      OpcodeStepPlanned,
-	_OpcodeLowerName[24:35]: OpcodeStepPlanned,
-	_OpcodeName[35:40]:      OpcodeSleep,
-	_OpcodeLowerName[35:40]: OpcodeSleep,
-	_OpcodeName[40:52]:      OpcodeWaitForEvent,
-	_OpcodeLowerName[40:52]: OpcodeWaitForEvent,
-	_OpcodeName[52:66]:      OpcodeInvokeFunction,
-	_OpcodeLowerName[52:66]: OpcodeInvokeFunction,
-	_OpcodeName[66:75]:      OpcodeAIGateway,
-	_OpcodeLowerName[66:75]: OpcodeAIGateway,
-	_OpcodeName[75:82]:      OpcodeGateway,
-	_OpcodeLowerName[75:82]: OpcodeGateway,
+	_OpcodeName[0:4]:         OpcodeNone,
+	_OpcodeLowerName[0:4]:    OpcodeNone,
+	_OpcodeName[4:8]:         OpcodeStep,
+	_OpcodeLowerName[4:8]:    OpcodeStep,
+	_OpcodeName[8:15]:        OpcodeStepRun,
+	_OpcodeLowerName[8:15]:   OpcodeStepRun,
+	_OpcodeName[15:24]:       OpcodeStepError,
+	_OpcodeLowerName[15:24]:  OpcodeStepError,
+	_OpcodeName[24:35]:       OpcodeStepPlanned,
+	_OpcodeLowerName[24:35]:  OpcodeStepPlanned,
+	_OpcodeName[35:40]:       OpcodeSleep,
+	_OpcodeLowerName[35:40]:  OpcodeSleep,
+	_OpcodeName[40:52]:       OpcodeWaitForEvent,
+	_OpcodeLowerName[40:52]:  OpcodeWaitForEvent,
+	_OpcodeName[52:66]:       OpcodeInvokeFunction,
+	_OpcodeLowerName[52:66]:  OpcodeInvokeFunction,
+	_OpcodeName[66:75]:       OpcodeAIGateway,
+	_OpcodeLowerName[66:75]:  OpcodeAIGateway,
+	_OpcodeName[75:82]:       OpcodeGateway,
+	_OpcodeLowerName[75:82]:  OpcodeGateway,
+	_OpcodeName[82:95]:       OpcodeWaitForSignal,
+	_OpcodeLowerName[82:95]:  OpcodeWaitForSignal,
+	_OpcodeName[95:108]:      OpcodeWaitForEvents,
+	_OpcodeLowerName[95:108]: OpcodeWaitForEvents,
 }
 
 var _OpcodeNames = []string{
@@ -73,6 +79,8 @@ var _OpcodeNames = []string{
 	_OpcodeName[52:66],
 	_OpcodeName[66:75],
 	_OpcodeName[75:82],
+	_OpcodeName[82:95],
+	_OpcodeName[95:108],
 }
 
 // OpcodeString retrieves an enum value from the enum constants string name.
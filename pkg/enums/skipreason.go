@@ -10,4 +10,12 @@ const (
 
 	// SkipReasonFunctionPaused indicates that the function was paused.
 	SkipReasonFunctionPaused
+
+	// SkipReasonIdempotency indicates that the function already ran for the
+	// event's idempotency key within the function's idempotency period.
+	SkipReasonIdempotency
+
+	// SkipReasonSingleton indicates that a run of the singleton function was
+	// already in progress for the same singleton key.
+	SkipReasonSingleton
 )
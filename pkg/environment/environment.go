@@ -0,0 +1,302 @@
+// Package environment manages named environments, eg. production, staging or
+// per-branch environments, within a single self-hosted server.
+//
+// Each environment has its own signing and event keys, apps, functions and
+// history.  Requests are scoped to an environment using the keys they're
+// authenticated with, or using the "X-Inngest-Env" header.  The default
+// environment uses the server's top-level keys and the fixed environment ID
+// used before environments were introduced, so that existing data remains
+// visible.
+package environment
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/consts"
+	"github.com/inngest/inngest/pkg/headers"
+	"github.com/inngest/inngest/pkg/keyring"
+	"github.com/inngest/inngest/pkg/logger"
+	"github.com/inngest/inngest/pkg/publicerr"
+)
+
+const (
+	// DefaultName is the name of the default environment.
+	DefaultName = "production"
+
+	// CookieName is the cookie used to select an environment within the UI,
+	// which can't set headers on each request.
+	CookieName = "inngest_env"
+)
+
+var nameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// Config configures a single environment.
+type Config struct {
+	// Name is the environment's name, eg. "staging" or "branch-123".
+	Name string `json:"name"`
+	// SigningKey is the environment's primary signing key.
+	SigningKey string `json:"signing_key"`
+	// SigningKeyFallback lists previous signing keys which are still accepted.
+	SigningKeyFallback []string `json:"signing_key_fallback"`
+	// EventKeys lists the event keys used to send events to the environment.
+	EventKeys []string `json:"event_keys"`
+}
+
+// Environment is a single named environment.
+type Environment struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	// Keyring stores the environment's signing and event keys.
+	Keyring *keyring.Keyring `json:"-"`
+}
+
+// IsDefault returns whether this is the default environment.
+func (e *Environment) IsDefault() bool {
+	return e.ID == consts.DevServerEnvID
+}
+
+// ID returns the ID of the environment with the given name.  IDs are
+// deterministic, so that each process sharing the same config uses the same
+// IDs.
+func ID(name string) uuid.UUID {
+	if name == DefaultName {
+		return consts.DevServerEnvID
+	}
+	return uuid.NewSHA1(consts.DevServerEnvID, []byte(name))
+}
+
+// ScopedID returns an ID unique to the given environment, derived from an ID
+// such as an app or function's deterministic ID.  IDs within the default
+// environment are unchanged.
+func ScopedID(envID, id uuid.UUID) uuid.UUID {
+	if envID == consts.DevServerEnvID || envID == uuid.Nil {
+		return id
+	}
+	return uuid.NewSHA1(envID, id[:])
+}
+
+// Registry stores all configured environments.  It's safe for concurrent use,
+// and environments may be updated at any time via Update.
+type Registry struct {
+	mu   sync.RWMutex
+	envs []*Environment
+}
+
+// New returns a registry containing the default environment, using the given
+// keyring, plus each configured environment.
+func New(defaultKeys *keyring.Keyring, configs []Config) (*Registry, error) {
+	envs, err := build(defaultKeys, configs)
+	if err != nil {
+		return nil, err
+	}
+	return &Registry{envs: envs}, nil
+}
+
+// build creates and validates environments from the given config.
+func build(defaultKeys *keyring.Keyring, configs []Config) ([]*Environment, error) {
+	envs := []*Environment{{ID: consts.DevServerEnvID, Name: DefaultName, Keyring: defaultKeys}}
+	for n, c := range configs {
+		if !nameRegexp.MatchString(c.Name) {
+			return nil, fmt.Errorf("environment %d has an invalid name %q: names must be lowercase letters, numbers, '.', '_' or '-'", n, c.Name)
+		}
+		for _, e := range envs {
+			if e.Name == c.Name {
+				return nil, fmt.Errorf("environment %q is configured more than once", c.Name)
+			}
+		}
+		envs = append(envs, &Environment{
+			ID:      ID(c.Name),
+			Name:    c.Name,
+			Keyring: keyring.New(c.SigningKey, c.SigningKeyFallback, c.EventKeys),
+		})
+	}
+
+	// Keys identify the environment for each request, so they must be unique.
+	signing, event := map[string]string{}, map[string]string{}
+	for _, e := range envs {
+		for _, k := range e.Keyring.SigningKeys() {
+			if other, ok := signing[k]; ok {
+				return nil, fmt.Errorf("environments %q and %q share a signing key", other, e.Name)
+			}
+			signing[k] = e.Name
+		}
+		for _, k := range e.Keyring.EventKeys() {
+			if other, ok := event[k]; ok {
+				return nil, fmt.Errorf("environments %q and %q share an event key", other, e.Name)
+			}
+			event[k] = e.Name
+		}
+	}
+	return envs, nil
+}
+
+// Update replaces the configured environments, eg. after the config file is
+// reloaded.  Keys for existing environments are rotated in place.  The
+// default environment's keys are managed by its own keyring.
+func (r *Registry) Update(ctx context.Context, configs []Config) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	envs, err := build(r.envs[0].Keyring, configs)
+	if err != nil {
+		return err
+	}
+	l := logger.StdlibLogger(ctx)
+	for n, e := range envs[1:] {
+		idx := slices.IndexFunc(r.envs, func(p *Environment) bool { return p.ID == e.ID })
+		if idx < 0 {
+			l.Info("added environment", "env", e.Name)
+			continue
+		}
+		// Rotate keys in place, so that existing references to the keyring
+		// use the new keys.
+		c := configs[n]
+		r.envs[idx].Keyring.Update(ctx, c.SigningKey, c.SigningKeyFallback, c.EventKeys)
+		e.Keyring = r.envs[idx].Keyring
+	}
+	for _, p := range r.envs {
+		if !slices.ContainsFunc(envs, func(e *Environment) bool { return p.ID == e.ID }) {
+			l.Info("removed environment", "env", p.Name)
+		}
+	}
+	r.envs = envs
+	return nil
+}
+
+// Default returns the default environment.
+func (r *Registry) Default() *Environment {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.envs[0]
+}
+
+// All returns every environment, with the default environment first.
+func (r *Registry) All() []*Environment {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.envs)
+}
+
+// Get returns the environment with the given name, or nil if no environment
+// exists.
+func (r *Registry) Get(name string) *Environment {
+	return r.find(func(e *Environment) bool { return e.Name == name })
+}
+
+// ByID returns the environment with the given ID, or nil if no environment
+// exists.
+func (r *Registry) ByID(id uuid.UUID) *Environment {
+	return r.find(func(e *Environment) bool { return e.ID == id })
+}
+
+// ByEventKey returns the environment which accepts the given event key, or nil
+// if the key isn't valid for any environment.
+func (r *Registry) ByEventKey(key string) *Environment {
+	return r.find(func(e *Environment) bool { return e.Keyring.ValidEventKey(key) })
+}
+
+// ByHashedSigningKey returns the environment which accepts the given hashed
+// signing key, as sent by SDKs, or nil if the key isn't valid for any
+// environment.
+func (r *Registry) ByHashedSigningKey(hashed string) *Environment {
+	return r.find(func(e *Environment) bool { return e.Keyring.ValidHashedSigningKey(hashed) })
+}
+
+// ValidHashedSigningKey returns whether the hashed signing key is valid for
+// any environment.
+func (r *Registry) ValidHashedSigningKey(hashed string) bool {
+	return r.ByHashedSigningKey(hashed) != nil
+}
+
+// Keyring returns the keyring for the given environment, falling back to the
+// default environment's keyring if the environment doesn't exist.
+func (r *Registry) Keyring(envID uuid.UUID) *keyring.Keyring {
+	if e := r.ByID(envID); e != nil {
+		return e.Keyring
+	}
+	return r.Default().Keyring
+}
+
+func (r *Registry) find(f func(e *Environment) bool) *Environment {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, e := range r.envs {
+		if f(e) {
+			return e
+		}
+	}
+	return nil
+}
+
+// Resolve returns the environment for the given request.  Requests
+// authenticated with a signing key use the key's environment.  Otherwise, the
+// environment is selected by name via the "X-Inngest-Env" header or the UI's
+// environment cookie, defaulting to the default environment.
+func (r *Registry) Resolve(req *http.Request) (*Environment, error) {
+	if bearer, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok {
+		if e := r.ByHashedSigningKey(bearer); e != nil {
+			if name := req.Header.Get(headers.HeaderKeyEnv); name != "" && name != e.Name {
+				return nil, fmt.Errorf("the signing key is not valid for environment %q", name)
+			}
+			return e, nil
+		}
+	}
+
+	if name := req.Header.Get(headers.HeaderKeyEnv); name != "" {
+		if e := r.Get(name); e != nil {
+			return e, nil
+		}
+		return nil, fmt.Errorf("environment %q not found", name)
+	}
+
+	// The cookie may refer to an environment which has since been removed,
+	// in which case the UI falls back to the default environment.
+	if c, err := req.Cookie(CookieName); err == nil {
+		if e := r.Get(c.Value); e != nil {
+			return e, nil
+		}
+	}
+	return r.Default(), nil
+}
+
+// Middleware resolves the environment for each request, storing it within the
+// request's context.
+func (r *Registry) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		e, err := r.Resolve(req)
+		if err != nil {
+			_ = publicerr.WriteHTTP(w, publicerr.Wrap(err, 400, err.Error()))
+			return
+		}
+		next.ServeHTTP(w, req.WithContext(WithEnvironment(req.Context(), e)))
+	})
+}
+
+type envCtxKey struct{}
+
+// WithEnvironment returns a context storing the given environment.
+func WithEnvironment(ctx context.Context, e *Environment) context.Context {
+	return context.WithValue(ctx, envCtxKey{}, e)
+}
+
+// FromContext returns the environment stored within the context, or nil if no
+// environment is set.
+func FromContext(ctx context.Context) *Environment {
+	e, _ := ctx.Value(envCtxKey{}).(*Environment)
+	return e
+}
+
+// IDFromContext returns the ID of the environment stored within the context,
+// defaulting to the default environment's ID.
+func IDFromContext(ctx context.Context) uuid.UUID {
+	if e := FromContext(ctx); e != nil {
+		return e.ID
+	}
+	return consts.DevServerEnvID
+}
@@ -0,0 +1,125 @@
+package environment
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/inngest/inngest/pkg/consts"
+	"github.com/inngest/inngest/pkg/headers"
+	"github.com/inngest/inngest/pkg/keyring"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	prodKey    = "signkey-prod-aaaa"
+	stagingKey = "signkey-test-bbbb"
+)
+
+func TestNew(t *testing.T) {
+	r, err := New(keyring.New(prodKey, nil, []string{"prod-event"}), []Config{
+		{Name: "staging", SigningKey: stagingKey, EventKeys: []string{"staging-event"}},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, r.All(), 2)
+	require.Equal(t, consts.DevServerEnvID, r.Default().ID)
+	require.True(t, r.Default().IsDefault())
+
+	staging := r.Get("staging")
+	require.NotNil(t, staging)
+	require.Equal(t, ID("staging"), staging.ID)
+	require.NotEqual(t, consts.DevServerEnvID, staging.ID)
+	require.Equal(t, staging, r.ByID(staging.ID))
+	require.Equal(t, staging, r.ByEventKey("staging-event"))
+	require.Equal(t, r.Default(), r.ByEventKey("prod-event"))
+	require.Nil(t, r.ByEventKey("nope"))
+
+	hashed, err := keyring.HashSigningKey(stagingKey)
+	require.NoError(t, err)
+	require.Equal(t, staging, r.ByHashedSigningKey(hashed))
+	require.True(t, r.ValidHashedSigningKey(hashed))
+	require.Equal(t, staging.Keyring, r.Keyring(staging.ID))
+
+	_, err = New(keyring.New("", nil, nil), []Config{{Name: "Staging"}})
+	require.ErrorContains(t, err, "invalid name")
+	_, err = New(keyring.New("", nil, nil), []Config{{Name: DefaultName}})
+	require.ErrorContains(t, err, "more than once")
+	_, err = New(keyring.New(prodKey, nil, nil), []Config{{Name: "staging", SigningKey: prodKey}})
+	require.ErrorContains(t, err, "share a signing key")
+}
+
+func TestScopedID(t *testing.T) {
+	id := ID("app")
+	require.Equal(t, id, ScopedID(consts.DevServerEnvID, id))
+	require.NotEqual(t, id, ScopedID(ID("staging"), id))
+	require.Equal(t, ScopedID(ID("staging"), id), ScopedID(ID("staging"), id))
+	require.NotEqual(t, ScopedID(ID("staging"), id), ScopedID(ID("branch-1"), id))
+}
+
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+	r, err := New(keyring.New("", nil, nil), []Config{{Name: "staging", EventKeys: []string{"a"}}})
+	require.NoError(t, err)
+	kr := r.Get("staging").Keyring
+
+	require.NoError(t, r.Update(ctx, []Config{
+		{Name: "staging", EventKeys: []string{"b"}},
+		{Name: "branch-1"},
+	}))
+	require.Len(t, r.All(), 3)
+	// Keys are rotated in place.
+	require.Same(t, kr, r.Get("staging").Keyring)
+	require.True(t, kr.ValidEventKey("b"))
+	require.False(t, kr.ValidEventKey("a"))
+
+	require.Error(t, r.Update(ctx, []Config{{Name: "x"}, {Name: "x"}}))
+	require.Len(t, r.All(), 3)
+}
+
+func TestResolve(t *testing.T) {
+	r, err := New(keyring.New(prodKey, nil, nil), []Config{{Name: "staging", SigningKey: stagingKey}})
+	require.NoError(t, err)
+
+	var got *Environment
+	h := r.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = FromContext(req.Context())
+		require.Equal(t, got.ID, IDFromContext(req.Context()))
+	}))
+	do := func(f func(req *http.Request)) int {
+		got = nil
+		req := httptest.NewRequest(http.MethodGet, "/v1/events", nil)
+		f(req)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, 200, do(func(req *http.Request) {}))
+	require.Equal(t, DefaultName, got.Name)
+
+	require.Equal(t, 200, do(func(req *http.Request) { req.Header.Set(headers.HeaderKeyEnv, "staging") }))
+	require.Equal(t, "staging", got.Name)
+
+	require.Equal(t, 200, do(func(req *http.Request) { req.AddCookie(&http.Cookie{Name: CookieName, Value: "staging"}) }))
+	require.Equal(t, "staging", got.Name)
+
+	hashed, err := keyring.HashSigningKey(stagingKey)
+	require.NoError(t, err)
+	require.Equal(t, 200, do(func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+hashed) }))
+	require.Equal(t, "staging", got.Name)
+
+	// Signing keys can't be used with other environments.
+	require.Equal(t, 400, do(func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+hashed)
+		req.Header.Set(headers.HeaderKeyEnv, DefaultName)
+	}))
+	require.Equal(t, 400, do(func(req *http.Request) { req.Header.Set(headers.HeaderKeyEnv, "nope") }))
+
+	// Cookies for removed environments fall back to the default.
+	require.Equal(t, 200, do(func(req *http.Request) { req.AddCookie(&http.Cookie{Name: CookieName, Value: "nope"}) }))
+	require.Equal(t, DefaultName, got.Name)
+
+	require.Equal(t, consts.DevServerEnvID, IDFromContext(context.Background()))
+}
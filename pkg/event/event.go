@@ -309,6 +309,14 @@ func NewOSSTrackedEventWithID(e Event, id ulid.ULID) TrackedEvent {
 	}
 }
 
+// NewOSSTrackedEventInWorkspace returns a tracked event belonging to the given
+// workspace, eg. a named environment within a self-hosted server.
+func NewOSSTrackedEventInWorkspace(wsID uuid.UUID, e Event, seed *SeededID) TrackedEvent {
+	evt := NewOSSTrackedEvent(e, seed).(ossTrackedEvent)
+	evt.WorkspaceID = wsID
+	return evt
+}
+
 func NewOSSTrackedEventFromString(data string) (*ossTrackedEvent, error) {
 	evt := &ossTrackedEvent{}
 	if err := json.Unmarshal([]byte(data), evt); err != nil {
@@ -319,8 +327,9 @@ func NewOSSTrackedEventFromString(data string) (*ossTrackedEvent, error) {
 }
 
 type ossTrackedEvent struct {
-	Id    ulid.ULID `json:"internal_id"`
-	Event Event     `json:"event"`
+	Id          ulid.ULID `json:"internal_id"`
+	Event       Event     `json:"event"`
+	WorkspaceID uuid.UUID `json:"workspace_id,omitempty"`
 }
 
 func (o ossTrackedEvent) GetEvent() Event {
@@ -332,8 +341,11 @@ func (o ossTrackedEvent) GetInternalID() ulid.ULID {
 }
 
 func (o ossTrackedEvent) GetWorkspaceID() uuid.UUID {
-	// There are no workspaces in OSS yet.
-	return consts.DevServerEnvID
+	if o.WorkspaceID == uuid.Nil {
+		// Events without a workspace belong to the default environment.
+		return consts.DevServerEnvID
+	}
+	return o.WorkspaceID
 }
 
 type NewInvocationEventOpts struct {
@@ -34,10 +34,20 @@ type BatchManager interface {
 	Append(ctx context.Context, bi BatchItem, fn inngest.Function) (*BatchAppendResult, error)
 	RetrieveItems(ctx context.Context, functionId uuid.UUID, batchID ulid.ULID) ([]BatchItem, error)
 	StartExecution(ctx context.Context, functionId uuid.UUID, batchID ulid.ULID, batchPointer string) (string, error)
+	// RevertExecution reverts a batch started via StartExecution, eg. if the
+	// batch couldn't be scheduled.
+	RevertExecution(ctx context.Context, functionId uuid.UUID, batchID ulid.ULID) error
 	ScheduleExecution(ctx context.Context, opts ScheduleBatchOpts) error
 	DeleteKeys(ctx context.Context, functionId uuid.UUID, batchID ulid.ULID) error
+	// ListBatches returns all open batches for the given function.
+	ListBatches(ctx context.Context, fn inngest.Function) ([]BatchInfo, error)
+	// GetBatch returns an open batch for the given function, or ErrBatchNotFound.
+	GetBatch(ctx context.Context, fn inngest.Function, batchID ulid.ULID) (*BatchInfo, error)
 }
 
+// ErrBatchNotFound is returned when a batch doesn't exist or has already started.
+var ErrBatchNotFound = fmt.Errorf("batch not found")
+
 // BatchItem represents the item that are being batched.
 type BatchItem struct {
 	AccountID       uuid.UUID   `json:"acctID"`
@@ -71,6 +81,30 @@ type BatchAppendResult struct {
 	Status          enums.Batch `json:"status"`
 	BatchID         string      `json:"batchID,omitempty"`
 	BatchPointerKey string      `json:"batchPointerKey"`
+	// Overflow is set when the batch is full because the event would exceed
+	// the batch's byte limit.  The event is not appended, and must be
+	// appended again to add it to a new batch.
+	Overflow bool `json:"overflow,omitempty"`
+}
+
+// BatchInfo represents an open batch which has not yet started, used to inspect
+// pending batches.
+type BatchInfo struct {
+	ID         ulid.ULID `json:"id"`
+	FunctionID uuid.UUID `json:"function_id"`
+	// Key is the evaluated batch key, or "default" if the function has no
+	// batch key.
+	Key string `json:"key"`
+	// Items is the number of events within the batch.
+	Items int `json:"items"`
+	// Bytes is the total size of events within the batch.
+	Bytes int `json:"bytes"`
+	// CreatedAt is the time the first event was appended to the batch.
+	CreatedAt time.Time `json:"created_at"`
+	// FlushAt is the time the batch is scheduled to run if it doesn't fill up.
+	FlushAt time.Time `json:"flush_at"`
+	// Pointer is the batch pointer key, used when starting the batch.
+	Pointer string `json:"-"`
 }
 
 type ScheduleBatchOpts struct {
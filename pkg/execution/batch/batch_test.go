@@ -3,16 +3,20 @@ package batch
 import (
 	"context"
 	"crypto/rand"
+	"encoding/json"
 	"github.com/alicebob/miniredis/v2"
 	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/encryption"
 	"github.com/inngest/inngest/pkg/enums"
 	"github.com/inngest/inngest/pkg/event"
 	"github.com/inngest/inngest/pkg/execution/state/redis_state"
 	"github.com/inngest/inngest/pkg/inngest"
+	"github.com/inngest/inngest/pkg/util"
 	"github.com/oklog/ulid/v2"
 	"github.com/redis/rueidis"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestBatchCleanup(t *testing.T) {
@@ -54,7 +58,8 @@ func TestBatchCleanup(t *testing.T) {
 	require.True(t, r.Exists(bc.KeyGenerator().Batch(context.Background(), fnId, ulid.MustParse(res.BatchID))))
 	require.True(t, r.Exists(bc.KeyGenerator().BatchMetadata(context.Background(), fnId, ulid.MustParse(res.BatchID))))
 	require.True(t, r.Exists(bc.KeyGenerator().BatchPointer(context.Background(), fnId)))
-	require.Equal(t, 3, len(r.Keys()))
+	require.True(t, r.Exists(bc.KeyGenerator().BatchIndex(context.Background(), fnId)))
+	require.Equal(t, 4, len(r.Keys()))
 
 	err = bm.DeleteKeys(context.Background(), fnId, ulid.MustParse(res.BatchID))
 	require.NoError(t, err)
@@ -64,3 +69,216 @@ func TestBatchCleanup(t *testing.T) {
 	require.True(t, r.Exists(bc.KeyGenerator().BatchPointer(context.Background(), fnId)))
 	require.Equal(t, 1, len(r.Keys()))
 }
+
+func TestBatchInspection(t *testing.T) {
+	ctx := context.Background()
+	r := miniredis.RunT(t)
+
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:  []string{r.Addr()},
+		DisableCache: true,
+	})
+	require.NoError(t, err)
+	defer rc.Close()
+
+	bc := redis_state.NewBatchClient(rc, redis_state.QueueDefaultKey)
+	bm := NewRedisBatchManager(bc, nil)
+
+	fn := inngest.Function{
+		ID: uuid.New(),
+		EventBatch: &inngest.EventBatchConfig{
+			Key:     util.StrPtr("event.data.user"),
+			MaxSize: 10,
+			Timeout: "60s",
+		},
+	}
+
+	appendEvent := func(t *testing.T, fn inngest.Function, user string) *BatchAppendResult {
+		res, err := bm.Append(ctx, BatchItem{
+			FunctionID: fn.ID,
+			EventID:    ulid.MustNew(ulid.Now(), rand.Reader),
+			Event: event.Event{
+				Name: "test/event",
+				Data: map[string]any{"user": user},
+			},
+		}, fn)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("it lists open batches", func(t *testing.T) {
+		appendEvent(t, fn, "a")
+		appendEvent(t, fn, "a")
+		appendEvent(t, fn, "b")
+
+		batches, err := bm.ListBatches(ctx, fn)
+		require.NoError(t, err)
+		require.Len(t, batches, 2)
+
+		byKey := map[string]BatchInfo{}
+		for _, b := range batches {
+			byKey[b.Key] = b
+		}
+
+		require.Equal(t, 2, byKey["a"].Items)
+		require.Greater(t, byKey["a"].Bytes, 0)
+		require.Equal(t, byKey["a"].CreatedAt.Add(time.Minute), byKey["a"].FlushAt)
+		require.NotEmpty(t, byKey["a"].Pointer)
+		require.Equal(t, 1, byKey["b"].Items)
+	})
+
+	t.Run("started batches are not listed", func(t *testing.T) {
+		batches, err := bm.ListBatches(ctx, fn)
+		require.NoError(t, err)
+		require.Len(t, batches, 2)
+
+		status, err := bm.StartExecution(ctx, fn.ID, batches[0].ID, batches[0].Pointer)
+		require.NoError(t, err)
+		require.Equal(t, enums.BatchStatusReady.String(), status)
+
+		_, err = bm.GetBatch(ctx, fn, batches[0].ID)
+		require.ErrorIs(t, err, ErrBatchNotFound)
+
+		remaining, err := bm.ListBatches(ctx, fn)
+		require.NoError(t, err)
+		require.Len(t, remaining, 1)
+		require.Equal(t, batches[1].ID, remaining[0].ID)
+
+		t.Run("reverted batches are listed again", func(t *testing.T) {
+			err := bm.RevertExecution(ctx, fn.ID, batches[0].ID)
+			require.NoError(t, err)
+
+			info, err := bm.GetBatch(ctx, fn, batches[0].ID)
+			require.NoError(t, err)
+			require.Equal(t, batches[0].Items, info.Items)
+
+			listed, err := bm.ListBatches(ctx, fn)
+			require.NoError(t, err)
+			require.Len(t, listed, 2)
+
+			// The batch can be started again.
+			status, err := bm.StartExecution(ctx, fn.ID, batches[0].ID, batches[0].Pointer)
+			require.NoError(t, err)
+			require.Equal(t, enums.BatchStatusReady.String(), status)
+		})
+
+		t.Run("reverting missing batches fails", func(t *testing.T) {
+			err := bm.RevertExecution(ctx, fn.ID, ulid.MustNew(ulid.Now(), rand.Reader))
+			require.ErrorIs(t, err, ErrBatchNotFound)
+		})
+	})
+
+	t.Run("batches are full when reaching the max bytes", func(t *testing.T) {
+		fn := inngest.Function{
+			ID: uuid.New(),
+			EventBatch: &inngest.EventBatchConfig{
+				MaxSize:  10,
+				MaxBytes: 300,
+				Timeout:  "60s",
+			},
+		}
+
+		require.Equal(t, enums.BatchNew, appendEvent(t, fn, "a").Status)
+		require.Equal(t, enums.BatchFull, appendEvent(t, fn, "a").Status)
+
+		batches, err := bm.ListBatches(ctx, fn)
+		require.NoError(t, err)
+		require.Len(t, batches, 0)
+	})
+
+	t.Run("events exceeding the max bytes are not appended", func(t *testing.T) {
+		fn := inngest.Function{
+			ID: uuid.New(),
+			EventBatch: &inngest.EventBatchConfig{
+				MaxSize:  10,
+				MaxBytes: 350,
+				Timeout:  "60s",
+			},
+		}
+
+		first := appendEvent(t, fn, "a")
+		require.Equal(t, enums.BatchNew, first.Status)
+
+		res := appendEvent(t, fn, "a")
+		require.Equal(t, enums.BatchFull, res.Status)
+		require.True(t, res.Overflow)
+		require.Equal(t, first.BatchID, res.BatchID)
+
+		items, err := bm.RetrieveItems(ctx, fn.ID, ulid.MustParse(res.BatchID))
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+
+		// Appending again starts a new batch.
+		res = appendEvent(t, fn, "a")
+		require.Equal(t, enums.BatchNew, res.Status)
+		require.NotEqual(t, first.BatchID, res.BatchID)
+	})
+}
+
+// testKeys is a key provider with a single, static key.
+type testKeys struct{ key encryption.Key }
+
+func (k testKeys) Primary(ctx context.Context) (encryption.Key, error) { return k.key, nil }
+
+func (k testKeys) Key(ctx context.Context, id string) (encryption.Key, error) {
+	if id != k.key.ID {
+		return encryption.Key{}, encryption.ErrKeyNotFound
+	}
+	return k.key, nil
+}
+
+func TestBatchEncryption(t *testing.T) {
+	ctx := context.Background()
+	r := miniredis.RunT(t)
+
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:  []string{r.Addr()},
+		DisableCache: true,
+	})
+	require.NoError(t, err)
+	defer rc.Close()
+
+	material := make([]byte, encryption.KeySize)
+	_, err = rand.Read(material)
+	require.NoError(t, err)
+	enc := encryption.NewEnvelopeEncryptor(testKeys{key: encryption.Key{ID: "test", Material: material}})
+
+	bc := redis_state.NewBatchClient(rc, redis_state.QueueDefaultKey)
+	bm := NewRedisBatchManager(bc, nil, WithEncryptor(enc))
+
+	fn := inngest.Function{
+		ID: uuid.New(),
+		EventBatch: &inngest.EventBatchConfig{
+			MaxSize: 10,
+			Timeout: "60s",
+		},
+	}
+	item := BatchItem{
+		FunctionID: fn.ID,
+		EventID:    ulid.MustNew(ulid.Now(), rand.Reader),
+		Event: event.Event{
+			Name: "test/event",
+			Data: map[string]any{"secret": "plaintext-value"},
+		},
+	}
+	res, err := bm.Append(ctx, item, fn)
+	require.NoError(t, err)
+	batchID := ulid.MustParse(res.BatchID)
+
+	stored, err := r.List(bc.KeyGenerator().Batch(ctx, fn.ID, batchID))
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	require.NotContains(t, stored[0], "plaintext-value")
+
+	items, err := bm.RetrieveItems(ctx, fn.ID, batchID)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "plaintext-value", items[0].Event.Data["secret"])
+
+	// The batch's size is the size of the plaintext.
+	info, err := bm.GetBatch(ctx, fn, batchID)
+	require.NoError(t, err)
+	byt, err := json.Marshal(item)
+	require.NoError(t, err)
+	require.Equal(t, len(byt), info.Bytes)
+}
@@ -3,6 +3,7 @@
 --
 
 local batchPointerKey = KEYS[1]      -- key to the batch pointer
+local batchIndexKey = KEYS[2]        -- key to the sorted set of open batches for the function
 
 local batchLimit = tonumber(ARGV[1]) -- max size configured for this batch
 local event = ARGV[2]                -- event to be appended to the batch
@@ -11,6 +12,10 @@ local prefix = ARGV[4]               -- the prefix used for redis
 
 local batchStatusAppending = ARGV[5]
 local batchStatusStarted = ARGV[6]
+local batchByteLimit = tonumber(ARGV[7]) -- max size in bytes configured for this batch, or 0 if unlimited
+local batchKeyValue = ARGV[8]            -- the evaluated batch key, stored for inspection
+local nowMS = tonumber(ARGV[9])          -- current time in milliseconds
+local eventSize = tonumber(ARGV[10])     -- size of the event before encryption
 
 -- helper functions
 -- $include(helpers.lua)
@@ -45,22 +50,39 @@ if is_status_empty(batchMetadataKey) then
   set_batch_status(batchMetadataKey, batchStatusAppending)
 end
 
+-- if the event would take the batch over its byte limit, start the batch
+-- without the event.  the caller appends the event again, to a new batch.
+if batchByteLimit > 0 and redis.call("LLEN", batchKey) > 0 then
+  local current = tonumber(redis.call("HGET", batchMetadataKey, "bytes")) or 0
+  if current + eventSize > batchByteLimit then
+    set_batch_status(batchMetadataKey, batchStatusStarted)
+    update_pointer(batchPointerKey, newULID)
+    redis.call("ZREM", batchIndexKey, batchID)
+    return cjson.encode({ status = "full", batchID = batchID, batchPointerKey = batchPointerKey, overflow = true })
+  end
+end
+
 -- append event to batch
 local len = redis.call("RPUSH", batchKey, event)
 
 if len == 1 then
   -- newly started batch
   resp = { status = "new", batchID = batchID, batchPointerKey = batchPointerKey }
+  redis.call("HSET", batchMetadataKey, "key", batchKeyValue, "pointer", batchPointerKey, "createdAt", nowMS)
+  redis.call("ZADD", batchIndexKey, nowMS, batchID)
 end
 
+local bytes = redis.call("HINCRBY", batchMetadataKey, "bytes", eventSize)
+
 -- if batch is full
-if len >= batchLimit then
+if len >= batchLimit or (batchByteLimit > 0 and bytes >= batchByteLimit) then
   if not is_status_empty(batchMetadataKey) then
     set_batch_status(batchMetadataKey, batchStatusStarted)
   end
 
   -- change poiner so following ops don't append to this batch anymore
   update_pointer(batchPointerKey, newULID)
+  redis.call("ZREM", batchIndexKey, batchID)
   resp = { status = "full", batchID = batchID, batchPointerKey = batchPointerKey }
 end
 
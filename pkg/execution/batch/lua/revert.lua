@@ -0,0 +1,29 @@
+--
+-- Reverts a batch which was started, allowing the batch to start again.
+--
+-- Return values:
+--   -1: The batch doesn't exist
+--    0: Reverted
+--
+local batchMetadataKey = KEYS[1] -- key for batch metadata
+local batchIndexKey = KEYS[2]    -- key for the sorted set of open batches
+
+local batchStatusPending = ARGV[1]
+local batchID = ARGV[2] -- the ULID of the batch being reverted
+
+-- $include(helpers.lua)
+
+if is_status_empty(batchMetadataKey) then
+  return -1
+end
+
+set_batch_status(batchMetadataKey, batchStatusPending)
+
+-- list the batch again.  the batch's pointer isn't restored, as events may
+-- have been appended to a new batch since starting.
+local createdAt = redis.call("HGET", batchMetadataKey, "createdAt")
+if not is_empty(createdAt) then
+  redis.call("ZADD", batchIndexKey, createdAt, batchID)
+end
+
+return 0
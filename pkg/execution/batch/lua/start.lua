@@ -7,9 +7,11 @@
 --
 local batchMetadataKey = KEYS[1] -- key for batch metadata
 local batchPointerKey = KEYS[2]  -- key for pointer
+local batchIndexKey = KEYS[3]    -- key for the sorted set of open batches
 
 local batchStatusStarted = ARGV[1]
 local newBatchID = ARGV[2] -- the ULID for a new batch
+local batchID = ARGV[3]    -- the ULID of the batch being started
 
 -- $include(helpers.lua)
 
@@ -21,6 +23,7 @@ if status == batchStatusStarted then
 end
 
 update_pointer(batchPointerKey, newBatchID)
+redis.call("ZREM", batchIndexKey, batchID)
 
 if is_status_empty(batchMetadataKey) then
   -- status doesn't exist, something is wrong, abort
@@ -7,10 +7,12 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/inngest/inngest/pkg/consts"
+	"github.com/inngest/inngest/pkg/encryption"
 	"github.com/inngest/inngest/pkg/enums"
 	"github.com/inngest/inngest/pkg/event"
 	"github.com/inngest/inngest/pkg/execution/queue"
@@ -20,18 +22,34 @@ import (
 	"github.com/inngest/inngest/pkg/inngest"
 	"github.com/inngest/inngest/pkg/inngest/log"
 	"github.com/oklog/ulid/v2"
+	"github.com/redis/rueidis"
 )
 
-func NewRedisBatchManager(b *redis_state.BatchClient, q redis_state.QueueManager) BatchManager {
-	return redisBatchManager{
+// RedisBatchManagerOpt configures a batch manager created via NewRedisBatchManager.
+type RedisBatchManagerOpt func(m *redisBatchManager)
+
+// WithEncryptor encrypts batched events at rest using the given encryptor.
+func WithEncryptor(enc encryption.Encryptor) RedisBatchManagerOpt {
+	return func(m *redisBatchManager) {
+		m.enc = enc
+	}
+}
+
+func NewRedisBatchManager(b *redis_state.BatchClient, q redis_state.QueueManager, opts ...RedisBatchManagerOpt) BatchManager {
+	m := redisBatchManager{
 		b: b,
 		q: q,
 	}
+	for _, opt := range opts {
+		opt(&m)
+	}
+	return m
 }
 
 type redisBatchManager struct {
-	b *redis_state.BatchClient
-	q redis_state.QueueManager
+	b   *redis_state.BatchClient
+	q   redis_state.QueueManager
+	enc encryption.Encryptor
 }
 
 func (b redisBatchManager) batchKey(ctx context.Context, evt event.Event, fn inngest.Function) (string, error) {
@@ -53,22 +71,25 @@ func (b redisBatchManager) batchKey(ctx context.Context, evt event.Event, fn inn
 	return fmt.Sprintf("%v", out), nil
 }
 
-func (b redisBatchManager) batchPointer(ctx context.Context, fn inngest.Function, evt event.Event) (string, error) {
+// batchPointer returns the batch pointer key and the evaluated batch key for the
+// given event.
+func (b redisBatchManager) batchPointer(ctx context.Context, fn inngest.Function, evt event.Event) (string, string, error) {
 	batchPointer := b.b.KeyGenerator().BatchPointer(ctx, fn.ID)
 
-	if fn.EventBatch.Key != nil {
-		batchKey, err := b.batchKey(ctx, evt, fn)
-		if err != nil {
-			return "", fmt.Errorf("could not retrieve batch key: %w", err)
-		}
-
-		hashedBatchKey := sha256.Sum256([]byte(batchKey))
-		encodedBatchKey := base64.StdEncoding.EncodeToString(hashedBatchKey[:])
+	if fn.EventBatch.Key == nil {
+		return batchPointer, "default", nil
+	}
 
-		batchPointer = b.b.KeyGenerator().BatchPointerWithKey(ctx, fn.ID, encodedBatchKey)
+	batchKey, err := b.batchKey(ctx, evt, fn)
+	if err != nil {
+		return "", "", fmt.Errorf("could not retrieve batch key: %w", err)
 	}
 
-	return batchPointer, nil
+	hashedBatchKey := sha256.Sum256([]byte(batchKey))
+	encodedBatchKey := base64.StdEncoding.EncodeToString(hashedBatchKey[:])
+
+	batchPointer = b.b.KeyGenerator().BatchPointerWithKey(ctx, fn.ID, encodedBatchKey)
+	return batchPointer, batchKey, nil
 }
 
 // Append add an item to a batch, and handle things slightly differently based on the batch sitation after
@@ -90,7 +111,7 @@ func (b redisBatchManager) Append(ctx context.Context, bi BatchItem, fn inngest.
 		return nil, fmt.Errorf("no batch config found for for function: %s", fn.Slug)
 	}
 
-	batchPointer, err := b.batchPointer(ctx, fn, bi.Event)
+	batchPointer, batchKey, err := b.batchPointer(ctx, fn, bi.Event)
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve batch pointer: %w", err)
 	}
@@ -98,18 +119,36 @@ func (b redisBatchManager) Append(ctx context.Context, bi BatchItem, fn inngest.
 	// script keys
 	keys := []string{
 		batchPointer,
+		b.b.KeyGenerator().BatchIndex(ctx, fn.ID),
+	}
+
+	item, err := json.Marshal(bi)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling batch item: %w", err)
+	}
+	// Batch size limits apply to the plaintext, so that enabling encryption
+	// doesn't change when batches are full.
+	itemSize := len(item)
+	if b.enc != nil {
+		if item, err = b.enc.Encrypt(ctx, item); err != nil {
+			return nil, fmt.Errorf("error encrypting batch item: %w", err)
+		}
 	}
 
 	// script args
 	newULID := ulid.MustNew(uint64(time.Now().UnixMilli()), rand.Reader)
 	args, err := redis_state.StrSlice([]any{
 		config.MaxSize,
-		bi,
+		string(item),
 		newULID,
 		// This is used within the Lua script to create the batch metadata key
 		b.b.KeyGenerator().QueuePrefix(ctx, bi.FunctionID),
 		enums.BatchStatusPending,
 		enums.BatchStatusStarted,
+		config.MaxBytes,
+		batchKey,
+		time.Now().UnixMilli(),
+		itemSize,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error preparing batch: %w", err)
@@ -149,8 +188,14 @@ func (b redisBatchManager) RetrieveItems(ctx context.Context, functionId uuid.UU
 
 	items := []BatchItem{}
 	for _, str := range itemStrList {
+		byt := []byte(str)
+		if b.enc != nil {
+			if byt, err = b.enc.Decrypt(ctx, byt); err != nil {
+				return empty, fmt.Errorf("failed to decrypt item for batch '%s': %w", batchID, err)
+			}
+		}
 		item := &BatchItem{}
-		if err := json.Unmarshal([]byte(str), &item); err != nil {
+		if err := json.Unmarshal(byt, &item); err != nil {
 			return empty, fmt.Errorf("failed to decode item for batch '%s': %v", batchID, err)
 		}
 		items = append(items, *item)
@@ -165,10 +210,12 @@ func (b redisBatchManager) StartExecution(ctx context.Context, functionId uuid.U
 	keys := []string{
 		b.b.KeyGenerator().BatchMetadata(ctx, functionId, batchID),
 		batchPointer,
+		b.b.KeyGenerator().BatchIndex(ctx, functionId),
 	}
 	args := []string{
 		enums.BatchStatusStarted.String(),
 		ulid.Make().String(),
+		batchID.String(),
 	}
 
 	status, err := retriableScripts["start"].Exec(
@@ -196,6 +243,34 @@ func (b redisBatchManager) StartExecution(ctx context.Context, functionId uuid.U
 	}
 }
 
+// RevertExecution reverts a batch started via StartExecution, eg. if the batch
+// couldn't be scheduled.  The batch is listed again and runs when its scheduled
+// job runs.  New events are not appended to the batch.
+func (b redisBatchManager) RevertExecution(ctx context.Context, functionId uuid.UUID, batchID ulid.ULID) error {
+	keys := []string{
+		b.b.KeyGenerator().BatchMetadata(ctx, functionId, batchID),
+		b.b.KeyGenerator().BatchIndex(ctx, functionId),
+	}
+	args := []string{
+		enums.BatchStatusPending.String(),
+		batchID.String(),
+	}
+
+	status, err := retriableScripts["revert"].Exec(
+		ctx,
+		b.b.Client(),
+		keys,
+		args,
+	).AsInt64()
+	if err != nil {
+		return fmt.Errorf("failed to revert batch execution: %w", err)
+	}
+	if status == -1 {
+		return ErrBatchNotFound
+	}
+	return nil
+}
+
 // ScheduleExecution enqueues a job to run the batch job after the specified duration.
 func (b redisBatchManager) ScheduleExecution(ctx context.Context, opts ScheduleBatchOpts) error {
 	jobID := opts.JobID()
@@ -255,5 +330,81 @@ func (b redisBatchManager) DeleteKeys(ctx context.Context, functionId uuid.UUID,
 		return fmt.Errorf("failed to delete batch '%s' related keys: %v", batchID, err)
 	}
 
+	err = b.b.Client().Do(ctx, func(client rueidis.Client) rueidis.Completed {
+		return client.B().Zrem().Key(b.b.KeyGenerator().BatchIndex(ctx, functionId)).Member(batchID.String()).Build()
+	}).Error()
+	if err != nil {
+		return fmt.Errorf("failed to remove batch '%s' from index: %v", batchID, err)
+	}
+
 	return nil
 }
+
+// ListBatches returns all open batches for the given function, ordered by
+// creation time.
+func (b redisBatchManager) ListBatches(ctx context.Context, fn inngest.Function) ([]BatchInfo, error) {
+	ids, err := b.b.Client().Do(ctx, func(client rueidis.Client) rueidis.Completed {
+		return client.B().Zrange().Key(b.b.KeyGenerator().BatchIndex(ctx, fn.ID)).Min("0").Max("-1").Build()
+	}).AsStrSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list batches: %w", err)
+	}
+
+	batches := []BatchInfo{}
+	for _, str := range ids {
+		batchID, err := ulid.Parse(str)
+		if err != nil {
+			continue
+		}
+		info, err := b.GetBatch(ctx, fn, batchID)
+		if err == ErrBatchNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, *info)
+	}
+	return batches, nil
+}
+
+// GetBatch returns the given open batch, or ErrBatchNotFound if the batch
+// doesn't exist or has already started.
+func (b redisBatchManager) GetBatch(ctx context.Context, fn inngest.Function, batchID ulid.ULID) (*BatchInfo, error) {
+	meta, err := b.b.Client().Do(ctx, func(client rueidis.Client) rueidis.Completed {
+		return client.B().Hgetall().Key(b.b.KeyGenerator().BatchMetadata(ctx, fn.ID, batchID)).Build()
+	}).AsStrMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve batch '%s' metadata: %w", batchID, err)
+	}
+	items, err := b.b.Client().Do(ctx, func(client rueidis.Client) rueidis.Completed {
+		return client.B().Llen().Key(b.b.KeyGenerator().Batch(ctx, fn.ID, batchID)).Build()
+	}).AsInt64()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve batch '%s' size: %w", batchID, err)
+	}
+	if items == 0 || meta["status"] == enums.BatchStatusStarted.String() {
+		return nil, ErrBatchNotFound
+	}
+
+	info := &BatchInfo{
+		ID:         batchID,
+		FunctionID: fn.ID,
+		Key:        meta["key"],
+		Items:      int(items),
+		Pointer:    meta["pointer"],
+		CreatedAt:  ulid.Time(batchID.Time()),
+	}
+	if bytes, err := strconv.Atoi(meta["bytes"]); err == nil {
+		info.Bytes = bytes
+	}
+	if ms, err := strconv.ParseInt(meta["createdAt"], 10, 64); err == nil {
+		info.CreatedAt = time.UnixMilli(ms)
+	}
+	if fn.EventBatch != nil {
+		if dur, err := time.ParseDuration(fn.EventBatch.Timeout); err == nil {
+			info.FlushAt = info.CreatedAt.Add(dur)
+		}
+	}
+	return info, nil
+}
@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/encryption"
 	"github.com/inngest/inngest/pkg/event"
 	"github.com/inngest/inngest/pkg/execution/queue"
 	"github.com/inngest/inngest/pkg/execution/state"
@@ -42,6 +43,10 @@ var (
 	ErrDebounceNotFound   = fmt.Errorf("debounce not found")
 	ErrDebounceInProgress = fmt.Errorf("debounce is in progress")
 	ErrDebounceMigrating  = fmt.Errorf("debounce is migrating")
+	// ErrDebounceLeading is returned when a new debounce is created for a function
+	// which runs on the leading edge of the debounce period, indicating that the
+	// function must be scheduled immediately.
+	ErrDebounceLeading = fmt.Errorf("debounce started on the leading edge")
 )
 
 var (
@@ -89,6 +94,10 @@ type DebounceItem struct {
 	Timeout int64 `json:"t,omitempty"`
 	// FunctionPausedAt indicates whether the function is paused.
 	FunctionPausedAt *time.Time `json:"fpAt,omitempty"`
+	// Leading indicates that the stored event has already run on the leading edge
+	// of the debounce period, and that no run should be scheduled when the
+	// debounce times out.
+	Leading bool `json:"l,omitempty"`
 
 	// While we're migrating, it is possible for the debounce timeout to elapse before
 	// an old debounce is migrated, and so the debounce will still reside on the secondary cluster.
@@ -175,6 +184,9 @@ type DebouncerOpts struct {
 	ShouldMigrate func(ctx context.Context, accountID uuid.UUID) bool
 
 	Clock clockwork.Clock
+
+	// Encryptor, if set, encrypts the data of debounced events at rest.
+	Encryptor encryption.Encryptor
 }
 
 func NewRedisDebouncerWithMigration(o DebouncerOpts) (Debouncer, error) {
@@ -200,6 +212,8 @@ func NewRedisDebouncerWithMigration(o DebouncerOpts) (Debouncer, error) {
 		secondaryQueueShard:     o.SecondaryQueueShard,
 
 		shouldMigrate: o.ShouldMigrate,
+
+		enc: o.Encryptor,
 	}, nil
 }
 
@@ -217,6 +231,68 @@ type debouncer struct {
 
 	// shouldMigrate determines if old debounces should be migrated to new cluster on the fly
 	shouldMigrate func(ctx context.Context, accountID uuid.UUID) bool
+
+	// enc encrypts the data of debounced events at rest, if set.
+	enc encryption.Encryptor
+}
+
+// storedDebounceItem is the stored representation of a debounce item.  When
+// encryption is enabled, the event's data and user are sealed within Sealed,
+// leaving the fields read by Lua scripts in plaintext.
+type storedDebounceItem struct {
+	DebounceItem
+	Sealed []byte `json:"enc,omitempty"`
+}
+
+// sealedEventData is the event data sealed within a stored debounce item.
+type sealedEventData struct {
+	Data map[string]any `json:"data,omitempty"`
+	User map[string]any `json:"user,omitempty"`
+}
+
+// marshalItem marshals a debounce item for storage, sealing the event's data
+// if an encryptor is set.
+func (d debouncer) marshalItem(ctx context.Context, di DebounceItem) ([]byte, error) {
+	if d.enc == nil {
+		return json.Marshal(di)
+	}
+
+	byt, err := json.Marshal(sealedEventData{Data: di.Event.Data, User: di.Event.User})
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := d.enc.Encrypt(ctx, byt)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting debounce: %w", err)
+	}
+	di.Event.Data, di.Event.User = nil, nil
+	return json.Marshal(storedDebounceItem{DebounceItem: di, Sealed: sealed})
+}
+
+// unmarshalItem unmarshals a stored debounce item, unsealing the event's
+// data.
+func (d debouncer) unmarshalItem(ctx context.Context, byt []byte) (*DebounceItem, error) {
+	stored := storedDebounceItem{}
+	if err := json.Unmarshal(byt, &stored); err != nil {
+		return nil, err
+	}
+	if len(stored.Sealed) == 0 {
+		return &stored.DebounceItem, nil
+	}
+	if d.enc == nil {
+		return nil, fmt.Errorf("debounce is encrypted, but no encryption keys are configured")
+	}
+
+	byt, err := d.enc.Decrypt(ctx, stored.Sealed)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting debounce: %w", err)
+	}
+	data := sealedEventData{}
+	if err := json.Unmarshal(byt, &data); err != nil {
+		return nil, err
+	}
+	stored.Event.Data, stored.Event.User = data.Data, data.User
+	return &stored.DebounceItem, nil
 }
 
 func (d debouncer) usePrimary(shouldMigrate bool) bool {
@@ -353,8 +429,8 @@ func (d debouncer) GetDebounceItem(ctx context.Context, debounceID ulid.ULID, ac
 			return nil, ErrDebounceNotFound
 		}
 
-		di := &DebounceItem{}
-		if err := json.Unmarshal(byt, &di); err != nil {
+		di, err := d.unmarshalItem(ctx, byt)
+		if err != nil {
 			return nil, fmt.Errorf("error unmarshalling debounce item: %w", err)
 		}
 
@@ -602,11 +678,21 @@ func (d debouncer) debounce(ctx context.Context, di DebounceItem, fn inngest.Fun
 		}
 	}
 
+	// Functions debounced on the leading edge run immediately when a new debounce
+	// is created.  Debounces found during migration are existing debounces, so
+	// their leading run has already happened.
+	mode := fn.Debounce.GetMode()
+	created := di
+	created.Leading = mode == inngest.DebounceModeLeading || (mode == inngest.DebounceModeBoth && !foundDebounce)
+
 	// Call new debounce immediately.  If this returns ErrDebounceExists then
 	// update the debounce.  This ensures that checking and creating a debounce
 	// is atomic, and two individual threads/workers cannot create debounces simultaneously.
-	existingDebounceID, err := d.newDebounce(ctx, di, fn, ttl, shouldMigrate, newDebounceID)
+	existingDebounceID, err := d.newDebounce(ctx, created, fn, ttl, shouldMigrate, newDebounceID)
 	if err == nil {
+		if mode != inngest.DebounceModeTrailing && !foundDebounce {
+			return ErrDebounceLeading
+		}
 		return nil
 	}
 	if err != ErrDebounceExists {
@@ -702,7 +788,7 @@ func (d debouncer) newDebounce(ctx context.Context, di DebounceItem, fn inngest.
 	keyPtr := client.KeyGenerator().DebouncePointer(ctx, fn.ID, key)
 	keyDbc := client.KeyGenerator().Debounce(ctx)
 
-	byt, err := json.Marshal(di)
+	byt, err := d.marshalItem(ctx, di)
 	if err != nil {
 		return nil, fmt.Errorf("error marshalling debounce: %w", err)
 	}
@@ -865,7 +951,7 @@ func (d debouncer) updateDebounce(ctx context.Context, di DebounceItem, fn innge
 
 	keyPtr := client.KeyGenerator().DebouncePointer(ctx, fn.ID, key)
 	keyDbc := client.KeyGenerator().Debounce(ctx)
-	byt, err := json.Marshal(di)
+	byt, err := d.marshalItem(ctx, di)
 	if err != nil {
 		return fmt.Errorf("error marshalling debounce: %w", err)
 	}
@@ -885,6 +971,8 @@ func (d debouncer) updateDebounce(ctx context.Context, di DebounceItem, fn innge
 			queue.HashID(ctx, debounceID.String()),
 			strconv.Itoa(int(now.UnixMilli())),
 			strconv.Itoa(int(di.Event.Timestamp)),
+			string(fn.Debounce.GetMode()),
+			strconv.FormatBool(fn.Debounce.UseFirstEvent),
 		},
 	).AsInt64()
 	if err != nil {
@@ -903,10 +991,15 @@ func (d debouncer) updateDebounce(ctx context.Context, di DebounceItem, fn innge
 		// enqueue a new item
 		qi := d.queueItem(ctx, di, debounceID)
 
-		return queueManager.Enqueue(ctx, qi, now.Add(ttl).Add(buffer).Add(time.Second), queue.EnqueueOpts{
+		err := queueManager.Enqueue(ctx, qi, now.Add(ttl).Add(buffer).Add(time.Second), queue.EnqueueOpts{
 			// Debounce timeout items must live on the same Redis instance as the state.
 			ForceQueueShardName: queueShard.Name,
 		})
+		if err == nil && fn.Debounce.GetMode() != inngest.DebounceModeTrailing {
+			// This creates a new debounce, so the event runs on the leading edge.
+			return ErrDebounceLeading
+		}
+		return err
 	default:
 		// Debounces should have a maximum timeout;  updating the debounce returns
 		// the timeout to use.
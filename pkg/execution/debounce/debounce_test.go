@@ -7,6 +7,7 @@ import (
 	"github.com/alicebob/miniredis/v2"
 	"github.com/google/uuid"
 	"github.com/inngest/inngest/pkg/consts"
+	"github.com/inngest/inngest/pkg/encryption"
 	"github.com/inngest/inngest/pkg/enums"
 	"github.com/inngest/inngest/pkg/event"
 	"github.com/inngest/inngest/pkg/execution/queue"
@@ -1807,3 +1808,249 @@ func TestDebounceExecutionShouldNotRaceMigration(t *testing.T) {
 		require.False(t, unshardedCluster.Exists(unshardedDebounceClient.KeyGenerator().DebounceMigrating(ctx)))
 	})
 }
+
+// TestDebounceModes ensures leading, both, and first-event debounces store and
+// run the expected events.
+func TestDebounceModes(t *testing.T) {
+	ctx := context.Background()
+	accountId, workspaceId, appId := uuid.New(), uuid.New(), uuid.New()
+
+	setup := func(t *testing.T) (debouncer, *miniredis.Miniredis, *redis_state.DebounceClient, clockwork.FakeClock) {
+		r := miniredis.RunT(t)
+		rc, err := rueidis.NewClient(rueidis.ClientOption{
+			InitAddress:  []string{r.Addr()},
+			DisableCache: true,
+		})
+		require.NoError(t, err)
+		t.Cleanup(rc.Close)
+
+		unshardedClient := redis_state.NewUnshardedClient(rc, redis_state.StateDefaultKey, redis_state.QueueDefaultKey)
+		shard := redis_state.QueueShard{Name: consts.DefaultQueueShardName, RedisClient: unshardedClient.Queue(), Kind: string(enums.QueueShardKindRedis)}
+		q := redis_state.NewQueue(
+			shard,
+			redis_state.WithQueueShardClients(map[string]redis_state.QueueShard{shard.Name: shard}),
+			redis_state.WithShardSelector(func(ctx context.Context, accountId uuid.UUID, queueName *string) (redis_state.QueueShard, error) {
+				return shard, nil
+			}),
+			redis_state.WithKindToQueueMapping(map[string]string{
+				queue.KindDebounce: queue.KindDebounce,
+			}),
+		)
+
+		fakeClock := clockwork.NewFakeClock()
+		d := NewRedisDebouncer(unshardedClient.Debounce(), shard, q).(debouncer)
+		d.c = fakeClock
+		return d, r, unshardedClient.Debounce(), fakeClock
+	}
+
+	item := func(fn inngest.Function, ts time.Time) DebounceItem {
+		eventId := ulid.MustNew(ulid.Timestamp(ts), rand.Reader)
+		return DebounceItem{
+			AccountID:   accountId,
+			WorkspaceID: workspaceId,
+			AppID:       appId,
+			FunctionID:  fn.ID,
+			EventID:     eventId,
+			Event: event.Event{
+				Name:      "test-data",
+				ID:        eventId.String(),
+				Timestamp: ts.UnixMilli(),
+			},
+		}
+	}
+
+	stored := func(t *testing.T, r *miniredis.Miniredis, dc *redis_state.DebounceClient) DebounceItem {
+		debounceIds, err := r.HKeys(dc.KeyGenerator().Debounce(ctx))
+		require.NoError(t, err)
+		require.Len(t, debounceIds, 1)
+
+		var di DebounceItem
+		err = json.Unmarshal([]byte(r.HGet(dc.KeyGenerator().Debounce(ctx), debounceIds[0])), &di)
+		require.NoError(t, err)
+		return di
+	}
+
+	t.Run("leading runs the first event and suppresses the rest", func(t *testing.T) {
+		d, r, dc, clock := setup(t)
+		fn := inngest.Function{
+			ID:       uuid.New(),
+			Debounce: &inngest.Debounce{Period: "10s", Mode: inngest.DebounceModeLeading},
+		}
+
+		first := item(fn, clock.Now())
+		err := d.Debounce(ctx, first, fn)
+		require.ErrorIs(t, err, ErrDebounceLeading)
+
+		di := stored(t, r, dc)
+		require.True(t, di.Leading)
+		require.Equal(t, first.EventID, di.EventID)
+
+		r.FastForward(5 * time.Second)
+		clock.Advance(5 * time.Second)
+
+		err = d.Debounce(ctx, item(fn, clock.Now()), fn)
+		require.NoError(t, err)
+
+		// The leading event is kept, and the debounce period is extended.
+		di = stored(t, r, dc)
+		require.True(t, di.Leading)
+		require.Equal(t, first.EventID, di.EventID)
+		require.Equal(t, 10*time.Second, r.TTL(dc.KeyGenerator().DebouncePointer(ctx, fn.ID, fn.ID.String())))
+	})
+
+	t.Run("both runs the first event and the last event", func(t *testing.T) {
+		d, r, dc, clock := setup(t)
+		fn := inngest.Function{
+			ID:       uuid.New(),
+			Debounce: &inngest.Debounce{Period: "10s", Mode: inngest.DebounceModeBoth},
+		}
+
+		err := d.Debounce(ctx, item(fn, clock.Now()), fn)
+		require.ErrorIs(t, err, ErrDebounceLeading)
+		require.True(t, stored(t, r, dc).Leading)
+
+		r.FastForward(5 * time.Second)
+		clock.Advance(5 * time.Second)
+
+		last := item(fn, clock.Now())
+		err = d.Debounce(ctx, last, fn)
+		require.NoError(t, err)
+
+		// The trailing event is stored to run once the debounce times out.
+		di := stored(t, r, dc)
+		require.False(t, di.Leading)
+		require.Equal(t, last.EventID, di.EventID)
+	})
+
+	t.Run("trailing with the first event keeps the first event", func(t *testing.T) {
+		d, r, dc, clock := setup(t)
+		fn := inngest.Function{
+			ID:       uuid.New(),
+			Debounce: &inngest.Debounce{Period: "10s", UseFirstEvent: true},
+		}
+
+		start := clock.Now()
+		first := item(fn, start)
+		err := d.Debounce(ctx, first, fn)
+		require.NoError(t, err)
+
+		r.FastForward(5 * time.Second)
+		clock.Advance(5 * time.Second)
+
+		err = d.Debounce(ctx, item(fn, clock.Now()), fn)
+		require.NoError(t, err)
+
+		di := stored(t, r, dc)
+		require.False(t, di.Leading)
+		require.Equal(t, first.EventID, di.EventID)
+		require.Equal(t, 10*time.Second, r.TTL(dc.KeyGenerator().DebouncePointer(ctx, fn.ID, fn.ID.String())))
+
+		// Events received out of order which occurred before the stored event
+		// replace the stored event.
+		earlier := item(fn, start.Add(-time.Second))
+		err = d.Debounce(ctx, earlier, fn)
+		require.NoError(t, err)
+		require.Equal(t, earlier.EventID, stored(t, r, dc).EventID)
+	})
+}
+
+// testKeys is a key provider with a single, static key.
+type testKeys struct{ key encryption.Key }
+
+func (k testKeys) Primary(ctx context.Context) (encryption.Key, error) { return k.key, nil }
+
+func (k testKeys) Key(ctx context.Context, id string) (encryption.Key, error) {
+	if id != k.key.ID {
+		return encryption.Key{}, encryption.ErrKeyNotFound
+	}
+	return k.key, nil
+}
+
+func TestDebounceEncryption(t *testing.T) {
+	ctx := context.Background()
+	r := miniredis.RunT(t)
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:  []string{r.Addr()},
+		DisableCache: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(rc.Close)
+
+	unshardedClient := redis_state.NewUnshardedClient(rc, redis_state.StateDefaultKey, redis_state.QueueDefaultKey)
+	shard := redis_state.QueueShard{Name: consts.DefaultQueueShardName, RedisClient: unshardedClient.Queue(), Kind: string(enums.QueueShardKindRedis)}
+	q := redis_state.NewQueue(
+		shard,
+		redis_state.WithQueueShardClients(map[string]redis_state.QueueShard{shard.Name: shard}),
+		redis_state.WithShardSelector(func(ctx context.Context, accountId uuid.UUID, queueName *string) (redis_state.QueueShard, error) {
+			return shard, nil
+		}),
+		redis_state.WithKindToQueueMapping(map[string]string{
+			queue.KindDebounce: queue.KindDebounce,
+		}),
+	)
+
+	material := make([]byte, encryption.KeySize)
+	_, err = rand.Read(material)
+	require.NoError(t, err)
+
+	clock := clockwork.NewFakeClock()
+	d, err := NewRedisDebouncerWithMigration(DebouncerOpts{
+		PrimaryDebounceClient: unshardedClient.Debounce(),
+		PrimaryQueue:          q,
+		PrimaryQueueShard:     shard,
+		ShouldMigrate: func(ctx context.Context, accountID uuid.UUID) bool {
+			return false
+		},
+		Clock:     clock,
+		Encryptor: encryption.NewEnvelopeEncryptor(testKeys{key: encryption.Key{ID: "test", Material: material}}),
+	})
+	require.NoError(t, err)
+
+	fn := inngest.Function{
+		ID:       uuid.New(),
+		Debounce: &inngest.Debounce{Period: "10s", Timeout: util.StrPtr("60s")},
+	}
+	item := func(secret string) DebounceItem {
+		eventId := ulid.MustNew(ulid.Timestamp(clock.Now()), rand.Reader)
+		return DebounceItem{
+			AccountID:  uuid.New(),
+			FunctionID: fn.ID,
+			EventID:    eventId,
+			Event: event.Event{
+				Name:      "test-data",
+				ID:        eventId.String(),
+				Data:      map[string]any{"secret": secret},
+				Timestamp: clock.Now().UnixMilli(),
+			},
+		}
+	}
+
+	stored := func(t *testing.T) (ulid.ULID, string) {
+		ids, err := r.HKeys(unshardedClient.Debounce().KeyGenerator().Debounce(ctx))
+		require.NoError(t, err)
+		require.Len(t, ids, 1)
+		return ulid.MustParse(ids[0]), r.HGet(unshardedClient.Debounce().KeyGenerator().Debounce(ctx), ids[0])
+	}
+
+	first := item("first-secret")
+	require.NoError(t, d.Debounce(ctx, first, fn))
+
+	id, raw := stored(t)
+	require.NotContains(t, raw, "first-secret")
+	di, err := d.GetDebounceItem(ctx, id, first.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, "first-secret", di.Event.Data["secret"])
+
+	// Updating the debounce re-encodes the item within Lua, keeping the sealed
+	// data and the timeout.
+	clock.Advance(time.Second)
+	r.FastForward(time.Second)
+	require.NoError(t, d.Debounce(ctx, item("second-secret"), fn))
+
+	id, raw = stored(t)
+	require.NotContains(t, raw, "second-secret")
+	di, err = d.GetDebounceItem(ctx, id, first.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, "second-secret", di.Event.Data["secret"])
+	require.Equal(t, first.Event.Timestamp+60_000, di.Timeout)
+}
@@ -22,6 +22,8 @@ local queueJobID  = ARGV[4]
 local currentTime = tonumber(ARGV[5]) -- in ms
 local eventTime   = tonumber(ARGV[6]) -- The `event.ts` value.  If this is less than the event stored in the debounce, we
                                       -- will not update the debounce as it violates the debounce order.
+local mode        = ARGV[7] -- The debounce mode:  "leading", "trailing", or "both".
+local useFirst    = ARGV[8] == "true" -- Whether to keep the first event within the debounce.
 
 -- This table is used when decoding ulid timestamps.
 local ulidMap = { ["0"] = 0, ["1"] = 1, ["2"] = 2, ["3"] = 3, ["4"] = 4, ["5"] = 5, ["6"] = 6, ["7"] = 7, ["8"] = 8, ["9"] = 9, ["A"] = 10, ["B"] = 11, ["C"] = 12, ["D"] = 13, ["E"] = 14, ["F"] = 15, ["G"] = 16, ["H"] = 17, ["J"] = 18, ["K"] = 19, ["M"] = 20, ["N"] = 21, ["P"] = 22, ["Q"] = 23, ["R"] = 24, ["S"] = 25, ["T"] = 26, ["V"] = 27, ["W"] = 28, ["X"] = 29, ["Y"] = 30, ["Z"] = 31 }
@@ -57,6 +59,12 @@ local item = get_queue_item(keyQueueHash, queueJobID)
 if item == nil then
 	-- The queue item was not found. return not found but set the debounce in the hash map
   -- for lookup
+  if mode == "leading" or mode == "both" then
+    -- This starts a new debounce, so the event runs on the leading edge.
+    local next = cjson.decode(debounce)
+    next.l = true
+    debounce = cjson.encode(next)
+  end
   redis.call("SETEX", keyPtr, ttl, debounceID)
   redis.call("HSET", keyDbc, debounceID, debounce)
   return -3
@@ -73,7 +81,17 @@ if existing ~= false then
 	-- Decode the debounce, and check whether the existing event ID is > the current event ID.  If so,
 	-- don't update the debounce.
 	local item = cjson.decode(existing)
-	if item ~= nil and item.e ~= nil and item.e.ts > eventTime then
+	if item ~= nil and item.l == true and mode == "leading" then
+		-- The stored event already ran on the leading edge.  Suppress this event,
+		-- only extending the debounce period.
+		debounce = existing
+	elseif item ~= nil and item.l ~= true and useFirst then
+		-- Keep the first event within the debounce unless this event occurs before
+		-- it, only extending the debounce period.
+		if item.e == nil or item.e.ts <= eventTime then
+			debounce = existing
+		end
+	elseif item ~= nil and item.l ~= true and item.e ~= nil and item.e.ts > eventTime then
 		-- The stored event occurs after the event we're updating, so do nothing.
 		return -2
 	end
@@ -1,8 +1,12 @@
 package httpdriver
 
 import (
+	"net/http"
+
+	"github.com/google/uuid"
 	"github.com/inngest/inngest/pkg/config/registration"
 	"github.com/inngest/inngest/pkg/execution/driver"
+	"github.com/inngest/inngest/pkg/keyring"
 )
 
 func init() {
@@ -14,6 +18,14 @@ func init() {
 type Config struct {
 	SigningKey string
 	Timeout    int
+
+	// Transport configures connection pooling, TLS, and proxies for requests
+	// to every app.
+	Transport TransportConfig
+	// Apps configures the transport for individual apps, keyed by the host
+	// of the app's URL (eg. "app.example.com" or "app.example.com:8443").
+	// This is applied on top of Transport.
+	Apps map[string]TransportConfig
 }
 
 // RuntimeName returns the runtime field that should invoke this driver.
@@ -22,10 +34,21 @@ func (Config) RuntimeName() string { return "http" }
 // DriverName returns the name of this driver
 func (Config) DriverName() string { return "http" }
 
+// ClientPool returns a ClientPool applying the configured transports on top of
+// def, allowing other requests to apps (eg. syncs) to share the same config.
+func (c Config) ClientPool(def *http.Client) *ClientPool {
+	return NewClientPool(def, c.Transport, c.Apps)
+}
+
 func (c Config) NewDriver(opts ...registration.NewDriverOpts) (driver.Driver, error) {
 	var skey []byte
 	requireLocalSigningKey := false
+	var kr *keyring.Keyring
+	var envKeyring func(uuid.UUID) *keyring.Keyring
 	if len(opts) > 0 {
+		kr = opts[0].Keyring
+		envKeyring = opts[0].EnvKeyring
+
 		if opts[0].LocalSigningKey != nil {
 			skey = []byte(*opts[0].LocalSigningKey)
 		}
@@ -37,7 +60,10 @@ func (c Config) NewDriver(opts ...registration.NewDriverOpts) (driver.Driver, er
 
 	return &executor{
 		Client:                 DefaultClient,
+		clients:                c.ClientPool(DefaultClient),
 		localSigningKey:        skey,
 		requireLocalSigningKey: requireLocalSigningKey,
+		keyring:                kr,
+		envKeyring:             envKeyring,
 	}, nil
 }
@@ -65,27 +65,45 @@ func SecureDialer(o SecureDialerOpts) DialFunc {
 		o.dial = Dialer.DialContext
 	}
 
+	validate := SecureHostValidator(o)
+
 	return func(ctx context.Context, network, addr string) (net.Conn, error) {
 		// network will be one of the well defined networks as per
 		// https://pkg.go.dev/net#Dial, eg "tcp", "tcp4", "tcp6", etc.
-		//
+		if err := validate(ctx, addr); err != nil {
+			return nil, err
+		}
+
+		// Return the default dialer in the http package
+		return o.dial(ctx, network, addr)
+	}
+}
+
+// HostValidator checks whether requests may be made to the given address.
+type HostValidator = func(ctx context.Context, addr string) error
+
+// SecureHostValidator returns a HostValidator which applies the same checks as
+// SecureDialer, without dialing.  This is used to validate the targets of
+// proxied requests, where the proxy rather than the target is dialed.
+func SecureHostValidator(o SecureDialerOpts) HostValidator {
+	return func(ctx context.Context, addr string) error {
 		// addr may be a domain or ip and port: "example.com:443", "192.0.2.1:http",
 		// "[fe80::1%lo0]:53".
 		//
 		// We always want to ensure we translate the domains to IP addresses.
 		host, _, err := net.SplitHostPort(addr)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		if !o.AllowHostDocker && isDockerHost(host) {
-			return nil, fmt.Errorf("Unable to make request to %s at IP %s: accessing docker host", addr, host)
+			return fmt.Errorf("Unable to make request to %s at IP %s: accessing docker host", addr, host)
 		}
 
 		// Ensure that the current hostname is not a domain name.
 		addrs, err := net.DefaultResolver.LookupHost(ctx, host)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		if o.log {
@@ -97,15 +115,13 @@ func SecureDialer(o SecureDialerOpts) DialFunc {
 
 		for _, a := range addrs {
 			if !o.AllowPrivate && isPrivateHost(a) {
-				return nil, fmt.Errorf("Unable to make request to %s at IP %s: private IP range", addr, a)
+				return fmt.Errorf("Unable to make request to %s at IP %s: private IP range", addr, a)
 			}
 			if !o.AllowNAT64 && isNat64(a) {
-				return nil, fmt.Errorf("Unable to make request to %s at IP %s: NAT64 address", addr, a)
+				return fmt.Errorf("Unable to make request to %s at IP %s: NAT64 address", addr, a)
 			}
 		}
-
-		// Return the default dialer in the http package
-		return o.dial(ctx, network, addr)
+		return nil
 	}
 }
 
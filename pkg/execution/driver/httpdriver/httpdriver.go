@@ -24,6 +24,7 @@ import (
 	headerspkg "github.com/inngest/inngest/pkg/headers"
 	"github.com/inngest/inngest/pkg/inngest"
 	"github.com/inngest/inngest/pkg/inngest/log"
+	"github.com/inngest/inngest/pkg/keyring"
 	"github.com/inngest/inngest/pkg/syscode"
 	itrace "github.com/inngest/inngest/pkg/telemetry/trace"
 	"github.com/inngest/inngest/pkg/util"
@@ -34,6 +35,11 @@ import (
 var (
 	Dialer = &net.Dialer{KeepAlive: 15 * time.Second}
 
+	// ProxyHostValidator validates the target of requests sent via a proxy,
+	// applying the same SSRF protections as DefaultTransport's dialer to hosts
+	// which aren't dialed directly.  If nil, proxied targets aren't validated.
+	ProxyHostValidator HostValidator = SecureHostValidator(SecureDialerOpts{})
+
 	DefaultTransport = func() *http.Transport {
 		t := &http.Transport{
 			DialContext: SecureDialer(SecureDialerOpts{
@@ -42,11 +48,11 @@ var (
 				AllowNAT64:      false,
 			}),
 			ForceAttemptHTTP2:     true,
-			MaxIdleConns:          5,
-			IdleConnTimeout:       2 * time.Second,
+			MaxIdleConns:          DefaultMaxIdleConns,
+			MaxIdleConnsPerHost:   DefaultMaxIdleConnsPerHost,
+			IdleConnTimeout:       DefaultIdleConnTimeout,
 			TLSHandshakeTimeout:   10 * time.Second,
 			ExpectContinueTimeout: 1 * time.Second,
-			DisableKeepAlives:     true,
 			// New, ensuring that services can take their time before
 			// responding with headers as they process long running
 			// jobs.
@@ -56,6 +62,7 @@ var (
 		if util.InTestMode() {
 			// Allow local requests during testing
 			t.DialContext = Dialer.DialContext
+			ProxyHostValidator = nil
 		}
 
 		return t
@@ -71,6 +78,8 @@ var (
 	ErrEmptyResponse = fmt.Errorf("no response data")
 	ErrNoRetryAfter  = fmt.Errorf("no retry after present")
 	ErrNotSDK        = syscode.Error{Code: syscode.CodeNotSDK}
+
+	ErrInvalidResponseSignature = fmt.Errorf("invalid response signature")
 )
 
 type HTTPDoer interface {
@@ -81,6 +90,21 @@ type executor struct {
 	Client                 *http.Client
 	localSigningKey        []byte
 	requireLocalSigningKey bool
+	// keyring, if set, supplies the signing key in place of localSigningKey,
+	// allowing keys to be rotated without a restart.
+	keyring *keyring.Keyring
+	// envKeyring, if set, supplies the keyring for each function's
+	// environment in place of keyring.
+	envKeyring func(uuid.UUID) *keyring.Keyring
+	// clients, if set, supplies per-app clients in place of Client.
+	clients *ClientPool
+}
+
+// NewExecutor returns a driver which sends every request using the given
+// client, signing requests with signingKey if set.  This allows requests to
+// local apps, eg. when running functions within tests.
+func NewExecutor(c *http.Client, signingKey []byte) driver.Driver {
+	return &executor{Client: c, localSigningKey: signingKey}
 }
 
 // RuntimeType fulfiils the inngest.Runtime interface.
@@ -89,7 +113,19 @@ func (e executor) RuntimeType() string {
 }
 
 func (e executor) Execute(ctx context.Context, sl sv2.StateLoader, s sv2.Metadata, item queue.Item, edge inngest.Edge, step inngest.Step, idx, attempt int) (*state.DriverResponse, error) {
-	if e.requireLocalSigningKey && len(e.localSigningKey) == 0 {
+	kr := e.keyring
+	if e.envKeyring != nil {
+		if envKeys := e.envKeyring(s.ID.Tenant.EnvID); envKeys != nil {
+			kr = envKeys
+		}
+	}
+
+	skey := e.localSigningKey
+	if kr != nil {
+		skey = []byte(kr.SigningKey())
+	}
+
+	if e.requireLocalSigningKey && len(skey) == 0 {
 		return nil, fmt.Errorf("server requires that a signing key is set to run functions")
 	}
 
@@ -103,8 +139,16 @@ func (e executor) Execute(ctx context.Context, sl sv2.StateLoader, s sv2.Metadat
 		return nil, err
 	}
 
-	dr, _, err := DoRequest(ctx, e.Client, Request{
-		SigningKey: e.localSigningKey,
+	var client HTTPDoer = e.Client
+	if e.clients != nil {
+		if client, err = e.clients.Client(*uri); err != nil {
+			return nil, err
+		}
+	}
+
+	dr, _, err := DoRequest(ctx, client, Request{
+		SigningKey: skey,
+		Keyring:    kr,
 		URL:        *uri,
 		Input:      input,
 		Edge:       edge,
@@ -128,6 +172,10 @@ type Request struct {
 	Input      []byte
 	Edge       inngest.Edge
 	Step       inngest.Step
+
+	// Keyring, if set, validates signed responses against every active
+	// signing key.  Unsigned responses are accepted.
+	Keyring *keyring.Keyring
 }
 
 // DoRequest executes the HTTP request with the given input.
@@ -387,6 +435,12 @@ func do(ctx context.Context, c HTTPDoer, r Request) (*Response, *httpstat.Result
 		}
 	}
 
+	if sig := headers[strings.ToLower(headerspkg.HeaderKeySignature)]; r.Keyring != nil && sig != "" && sysErr == nil {
+		if !r.Keyring.ValidResponseSignature(ctx, sig, body) {
+			return nil, tracking, ErrInvalidResponseSignature
+		}
+	}
+
 	if statusCode == 0 {
 		// Unreachable
 		log.From(ctx).Error().Err(err).
@@ -0,0 +1,343 @@
+package httpdriver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxIdleConns is the maximum number of idle keep-alive
+	// connections kept open across all apps.
+	DefaultMaxIdleConns = 100
+	// DefaultMaxIdleConnsPerHost is the maximum number of idle keep-alive
+	// connections kept open to each app host.
+	DefaultMaxIdleConnsPerHost = 10
+	// DefaultIdleConnTimeout is the time an idle connection is kept open.
+	// This is lower than the keep-alive timeout of common SDK runtimes (eg.
+	// 5 seconds in Node) so that we close idle connections before the app,
+	// preventing requests from being sent on connections as they close.
+	DefaultIdleConnTimeout = 4 * time.Second
+)
+
+// TransportConfig configures the HTTP transport used to call apps.  Zero values
+// use the defaults of DefaultTransport.
+type TransportConfig struct {
+	// MaxIdleConns limits the number of idle keep-alive connections across
+	// all hosts.
+	MaxIdleConns int `json:"maxIdleConns"`
+	// MaxIdleConnsPerHost limits the number of idle keep-alive connections
+	// to each host.
+	MaxIdleConnsPerHost int `json:"maxIdleConnsPerHost"`
+	// IdleTimeout is the number of seconds an idle connection is kept open.
+	IdleTimeout int `json:"idleTimeout"`
+	// DisableKeepAlives opens a new connection for every request.
+	DisableKeepAlives bool `json:"disableKeepAlives"`
+
+	// DisableHTTP2 disables HTTP/2, using HTTP/1.1 for every request.
+	DisableHTTP2 bool `json:"disableHTTP2"`
+	// HTTP2Cleartext multiplexes requests to plain http:// URLs over
+	// unencrypted HTTP/2 (h2c) with prior knowledge.  The app must support
+	// h2c, as HTTP/1.1 is not used.
+	HTTP2Cleartext bool `json:"http2Cleartext"`
+
+	// CACertFile is the path to a PEM encoded CA bundle used to verify the
+	// app's certificate, in place of the system roots.
+	CACertFile string `json:"caCertFile"`
+	// ClientCertFile and ClientKeyFile are the paths to a PEM encoded
+	// certificate and key presented to the app for mutual TLS.
+	ClientCertFile string `json:"clientCertFile"`
+	ClientKeyFile  string `json:"clientKeyFile"`
+
+	// Proxy is the URL of an outbound proxy used for requests, eg.
+	// "http://proxy.internal:3128".
+	Proxy string `json:"proxy"`
+
+	// Headers are static headers added to every request.  Headers already
+	// set on a request, such as the request signature, are not overridden.
+	Headers map[string]string `json:"headers"`
+}
+
+// Merge returns the config with any non-zero fields in o applied on top.
+// Headers are merged, with headers in o taking precedence.
+func (c TransportConfig) Merge(o TransportConfig) TransportConfig {
+	if o.MaxIdleConns != 0 {
+		c.MaxIdleConns = o.MaxIdleConns
+	}
+	if o.MaxIdleConnsPerHost != 0 {
+		c.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+	}
+	if o.IdleTimeout != 0 {
+		c.IdleTimeout = o.IdleTimeout
+	}
+	c.DisableKeepAlives = c.DisableKeepAlives || o.DisableKeepAlives
+	c.DisableHTTP2 = c.DisableHTTP2 || o.DisableHTTP2
+	c.HTTP2Cleartext = c.HTTP2Cleartext || o.HTTP2Cleartext
+	if o.CACertFile != "" {
+		c.CACertFile = o.CACertFile
+	}
+	if o.ClientCertFile != "" {
+		c.ClientCertFile = o.ClientCertFile
+		c.ClientKeyFile = o.ClientKeyFile
+	}
+	if o.Proxy != "" {
+		c.Proxy = o.Proxy
+	}
+	if len(o.Headers) > 0 {
+		headers := maps.Clone(c.Headers)
+		if headers == nil {
+			headers = map[string]string{}
+		}
+		maps.Copy(headers, o.Headers)
+		c.Headers = headers
+	}
+	return c
+}
+
+// IsZero returns whether the config uses the default transport unchanged.
+func (c TransportConfig) IsZero() bool {
+	return c.MaxIdleConns == 0 &&
+		c.MaxIdleConnsPerHost == 0 &&
+		c.IdleTimeout == 0 &&
+		!c.DisableKeepAlives &&
+		!c.DisableHTTP2 &&
+		!c.HTTP2Cleartext &&
+		c.CACertFile == "" &&
+		c.ClientCertFile == "" &&
+		c.Proxy == "" &&
+		len(c.Headers) == 0
+}
+
+// WrapTransport, if set, wraps each transport created by ClientPool.  This
+// should match any wrapping applied to DefaultClient's transport, so that apps
+// with specific config are called in the same way as every other app.
+var WrapTransport func(http.RoundTripper) http.RoundTripper
+
+// NewTransport returns a new transport cloned from base with the given config
+// applied.  The base transport's dialer is retained, so that any SSRF
+// protections still apply.  A configured proxy is dialed directly, as it's set
+// by the operator and is commonly within a private network.  Proxied request
+// targets are checked using ProxyHostValidator instead.
+func NewTransport(base *http.Transport, c TransportConfig) (http.RoundTripper, error) {
+	t := base.Clone()
+
+	if c.MaxIdleConns > 0 {
+		t.MaxIdleConns = c.MaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	}
+	if c.IdleTimeout > 0 {
+		t.IdleConnTimeout = time.Duration(c.IdleTimeout) * time.Second
+	}
+	t.DisableKeepAlives = c.DisableKeepAlives
+
+	switch {
+	case c.DisableHTTP2:
+		t.ForceAttemptHTTP2 = false
+		t.Protocols = new(http.Protocols)
+		t.Protocols.SetHTTP1(true)
+	case c.HTTP2Cleartext:
+		t.Protocols = new(http.Protocols)
+		t.Protocols.SetHTTP2(true)
+		t.Protocols.SetUnencryptedHTTP2(true)
+	}
+
+	if c.CACertFile != "" || c.ClientCertFile != "" {
+		tlsConfig, err := c.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig = tlsConfig
+	}
+
+	if c.Proxy != "" {
+		proxy, err := url.Parse(c.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		validate := ProxyHostValidator
+		t.Proxy = func(r *http.Request) (*url.URL, error) {
+			// The proxy is dialed by us, whereas the target is dialed by the
+			// proxy.  Validate the target here.
+			if validate != nil {
+				if err := validate(r.Context(), hostPort(r.URL)); err != nil {
+					return nil, err
+				}
+			}
+			return proxy, nil
+		}
+
+		dial := t.DialContext
+		if dial == nil {
+			dial = Dialer.DialContext
+		}
+		addr := proxyAddr(proxy)
+		t.DialContext = func(ctx context.Context, network, a string) (net.Conn, error) {
+			if a == addr {
+				return Dialer.DialContext(ctx, network, a)
+			}
+			return dial(ctx, network, a)
+		}
+	}
+
+	if len(c.Headers) == 0 {
+		return t, nil
+	}
+	return headerTripper{RoundTripper: t, headers: c.Headers}, nil
+}
+
+func (c TransportConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if c.CACertFile != "" {
+		pem, err := os.ReadFile(c.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", c.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.ClientCertFile, c.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// headerTripper adds static headers to every request.
+type headerTripper struct {
+	http.RoundTripper
+	headers map[string]string
+}
+
+func (h headerTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	for k, v := range h.headers {
+		if r.Header.Get(k) == "" {
+			r.Header.Set(k, v)
+		}
+	}
+	return h.RoundTripper.RoundTrip(r)
+}
+
+// NewClientPool returns a ClientPool which uses def for all apps without
+// specific transport config.  If config is non-zero it is applied to every app,
+// and apps, keyed by host, are applied on top for individual apps.
+func NewClientPool(def *http.Client, config TransportConfig, apps map[string]TransportConfig) *ClientPool {
+	normalized := make(map[string]TransportConfig, len(apps))
+	for host, app := range apps {
+		normalized[strings.ToLower(host)] = app
+	}
+	return &ClientPool{
+		def:     def,
+		config:  config,
+		apps:    normalized,
+		clients: map[string]*http.Client{},
+	}
+}
+
+// ClientPool returns HTTP clients for individual apps, lazily creating one
+// transport for each configured app.  Each transport pools keep-alive
+// connections per host.
+type ClientPool struct {
+	def    *http.Client
+	config TransportConfig
+	apps   map[string]TransportConfig
+
+	mu      sync.Mutex
+	clients map[string]*http.Client
+}
+
+// Client returns the HTTP client used to call the app at the given URL.
+func (p *ClientPool) Client(u url.URL) (*http.Client, error) {
+	if p == nil {
+		return DefaultClient, nil
+	}
+
+	key, config := p.configFor(u)
+	if key == "" && config.IsZero() {
+		return p.def, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if c, ok := p.clients[key]; ok {
+		return c, nil
+	}
+
+	base := DefaultTransport
+	if t, ok := p.def.Transport.(*http.Transport); ok {
+		base = t
+	}
+	rt, err := NewTransport(base, config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating transport for %s: %w", u.Host, err)
+	}
+	if WrapTransport != nil {
+		rt = WrapTransport(rt)
+	}
+	c := &http.Client{
+		Timeout:       p.def.Timeout,
+		CheckRedirect: p.def.CheckRedirect,
+		Transport:     rt,
+	}
+	p.clients[key] = c
+	return c, nil
+}
+
+// configFor returns the config key and transport config for the given URL.  The
+// key is empty if no app-specific config exists.
+func (p *ClientPool) configFor(u url.URL) (string, TransportConfig) {
+	for _, key := range []string{strings.ToLower(u.Host), strings.ToLower(u.Hostname())} {
+		if app, ok := p.apps[key]; ok {
+			return key, p.config.Merge(app)
+		}
+	}
+	return "", p.config
+}
+
+// hostPort returns the host and port of the URL, adding the default port for
+// the scheme if none is set.
+func hostPort(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	port := "80"
+	if u.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// proxyAddr returns the address dialed when connecting to the given proxy.
+func proxyAddr(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	switch u.Scheme {
+	case "https":
+		return net.JoinHostPort(u.Hostname(), "443")
+	case "socks5", "socks5h":
+		return net.JoinHostPort(u.Hostname(), "1080")
+	}
+	return net.JoinHostPort(u.Hostname(), "80")
+}
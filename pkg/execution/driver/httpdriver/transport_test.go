@@ -0,0 +1,217 @@
+package httpdriver
+
+import (
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// localTransport returns a transport which allows local requests, as used by
+// self-hosted services.
+func localTransport() *http.Transport {
+	t := DefaultTransport.Clone()
+	t.DialContext = Dialer.DialContext
+	return t
+}
+
+func TestTransportKeepAlive(t *testing.T) {
+	var conns int32
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	ts.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	rt, err := NewTransport(localTransport(), TransportConfig{})
+	require.NoError(t, err)
+	c := &http.Client{Transport: rt}
+
+	for i := 0; i < 5; i++ {
+		resp, err := c.Get(ts.URL)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+	require.EqualValues(t, 1, atomic.LoadInt32(&conns))
+}
+
+func TestTransportTLSAndHeaders(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "static", r.Header.Get("X-Static"))
+		require.Equal(t, "set", r.Header.Get("X-Inngest-Signature"))
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	defer ts.Close()
+
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: ts.Certificate().Raw,
+	}), 0600))
+
+	t.Run("it fails without the CA bundle", func(t *testing.T) {
+		rt, err := NewTransport(localTransport(), TransportConfig{})
+		require.NoError(t, err)
+		_, err = (&http.Client{Transport: rt}).Get(ts.URL)
+		require.Error(t, err)
+	})
+
+	t.Run("it verifies using the CA bundle and adds headers", func(t *testing.T) {
+		rt, err := NewTransport(localTransport(), TransportConfig{
+			CACertFile: ca,
+			Headers: map[string]string{
+				"X-Static":            "static",
+				"X-Inngest-Signature": "overridden",
+			},
+		})
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Inngest-Signature", "set")
+		resp, err := (&http.Client{Transport: rt}).Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("it fails with an invalid CA bundle", func(t *testing.T) {
+		_, err := NewTransport(localTransport(), TransportConfig{CACertFile: filepath.Join(t.TempDir(), "missing.pem")})
+		require.Error(t, err)
+	})
+}
+
+func TestTransportHTTP2Cleartext(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	ts.Config.Protocols = new(http.Protocols)
+	ts.Config.Protocols.SetHTTP1(true)
+	ts.Config.Protocols.SetUnencryptedHTTP2(true)
+	ts.Start()
+	defer ts.Close()
+
+	rt, err := NewTransport(localTransport(), TransportConfig{HTTP2Cleartext: true})
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: rt}).Get(ts.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, 2, resp.ProtoMajor)
+}
+
+func TestTransportProxyValidatesTarget(t *testing.T) {
+	prev := ProxyHostValidator
+	ProxyHostValidator = SecureHostValidator(SecureDialerOpts{})
+	defer func() { ProxyHostValidator = prev }()
+
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&proxied, 1)
+	}))
+	defer proxy.Close()
+
+	rt, err := NewTransport(localTransport(), TransportConfig{Proxy: proxy.URL})
+	require.NoError(t, err)
+
+	_, err = (&http.Client{Transport: rt}).Get("http://127.0.0.1:1234/api/inngest")
+	require.ErrorContains(t, err, "private IP range")
+	require.EqualValues(t, 0, atomic.LoadInt32(&proxied))
+}
+
+func TestClientPool(t *testing.T) {
+	def := &http.Client{Transport: localTransport()}
+	pool := NewClientPool(def, TransportConfig{}, map[string]TransportConfig{
+		"App.Example.com": {Headers: map[string]string{"X-App": "1"}},
+		"other.com:8443":  {DisableHTTP2: true},
+	})
+
+	c, err := pool.Client(parseURL("https://unconfigured.com/api"))
+	require.NoError(t, err)
+	require.Same(t, def, c)
+
+	app, err := pool.Client(parseURL("https://app.example.com/api"))
+	require.NoError(t, err)
+	require.NotSame(t, def, app)
+
+	again, err := pool.Client(parseURL("https://app.example.com/other"))
+	require.NoError(t, err)
+	require.Same(t, app, again)
+
+	other, err := pool.Client(parseURL("https://other.com:8443/api"))
+	require.NoError(t, err)
+	require.NotSame(t, app, other)
+
+	c, err = pool.Client(parseURL("https://other.com/api"))
+	require.NoError(t, err)
+	require.Same(t, def, c)
+}
+
+func TestTransportProxyDialedDirectly(t *testing.T) {
+	prev := ProxyHostValidator
+	ProxyHostValidator = nil
+	defer func() { ProxyHostValidator = prev }()
+
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&proxied, 1)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer proxy.Close()
+
+	// The base transport blocks private IPs, including the local proxy.
+	base := DefaultTransport.Clone()
+	base.DialContext = SecureDialer(SecureDialerOpts{})
+	rt, err := NewTransport(base, TransportConfig{Proxy: proxy.URL})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "http://app.example.com/api/inngest", nil)
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: rt}).Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.EqualValues(t, 1, atomic.LoadInt32(&proxied))
+}
+
+func TestClientPoolWrapsTransport(t *testing.T) {
+	prev := WrapTransport
+	defer func() { WrapTransport = prev }()
+
+	var wrapped int32
+	WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&wrapped, 1)
+			return rt.RoundTrip(r)
+		})
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	u := parseURL(ts.URL)
+	pool := NewClientPool(&http.Client{Transport: localTransport()}, TransportConfig{}, map[string]TransportConfig{
+		u.Host: {Headers: map[string]string{"X-App": "1"}},
+	})
+	c, err := pool.Client(u)
+	require.NoError(t, err)
+	resp, err := c.Get(ts.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.EqualValues(t, 1, atomic.LoadInt32(&wrapped))
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,53 @@
+package wasmdriver
+
+import (
+	"time"
+
+	"github.com/inngest/inngest/pkg/config/registration"
+	"github.com/inngest/inngest/pkg/execution/driver"
+)
+
+const (
+	// DefaultMaxMemoryMB is the default memory limit for each module instance.
+	DefaultMaxMemoryMB = 128
+	// DefaultTimeout is the default maximum duration of each execution.
+	DefaultTimeout = 30 * time.Second
+)
+
+func init() {
+	registration.RegisterDriver(func() any { return &Config{} })
+}
+
+// Config represents driver configuration for use when configuring hosted
+// services via config.cue
+type Config struct {
+	// MaxMemoryMB limits the memory available to each module instance.
+	MaxMemoryMB int
+	// Timeout is the maximum number of seconds each execution may run for.
+	Timeout int
+	// ModuleDir is the directory local modules are loaded from.  Local
+	// modules outside of this directory are rejected, and local modules are
+	// disabled if unset.
+	ModuleDir string
+	// AllowRemote allows modules to be loaded from wasm+http:// and
+	// wasm+https:// URIs.
+	AllowRemote bool
+}
+
+// RuntimeName returns the runtime field that should invoke this driver.
+func (Config) RuntimeName() string { return "wasm" }
+
+// DriverName returns the name of this driver
+func (Config) DriverName() string { return "wasm" }
+
+func (c Config) NewDriver(opts ...registration.NewDriverOpts) (driver.Driver, error) {
+	mem := c.MaxMemoryMB
+	if mem <= 0 {
+		mem = DefaultMaxMemoryMB
+	}
+	timeout := DefaultTimeout
+	if c.Timeout > 0 {
+		timeout = time.Duration(c.Timeout) * time.Second
+	}
+	return New(mem, timeout, c.ModuleDir, c.AllowRemote), nil
+}
@@ -0,0 +1,256 @@
+package wasmdriver
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/simplelru"
+	"github.com/inngest/inngest/pkg/execution/driver/httpdriver"
+	"github.com/tetratelabs/wazero"
+)
+
+const (
+	// maxModuleSize is the maximum size of a wasm module.
+	maxModuleSize = 64 * 1024 * 1024
+	// remoteModuleTTL is how long remote modules are cached before being
+	// downloaded again.
+	remoteModuleTTL = time.Minute
+	// maxCompiledModules is the maximum number of compiled modules kept in
+	// memory.  The least recently used module is evicted when exceeded, and
+	// closed once no executions are using it.
+	maxCompiledModules = 64
+	// maxModuleSources is the maximum number of module locations cached.
+	maxModuleSources = 1024
+)
+
+func newLoader(moduleDir string, allowRemote bool, size int) *loader {
+	if moduleDir != "" {
+		// Resolve the directory once so that module paths, which have their
+		// symlinks evaluated, can be compared against it.
+		if abs, err := filepath.Abs(moduleDir); err == nil {
+			moduleDir = abs
+		}
+		if resolved, err := filepath.EvalSymlinks(moduleDir); err == nil {
+			moduleDir = resolved
+		}
+	}
+
+	sources, _ := simplelru.NewLRU[string, source](maxModuleSources, nil)
+	// Evictions happen within Add, while the loader's lock is held.
+	compiled, _ := simplelru.NewLRU(size, func(_ [32]byte, mod *compiledModule) {
+		mod.evicted = true
+		if mod.refs == 0 {
+			_ = mod.Close(context.Background())
+		}
+	})
+	return &loader{
+		moduleDir:   moduleDir,
+		allowRemote: allowRemote,
+		sources:     sources,
+		compiled:    compiled,
+	}
+}
+
+// loader loads and compiles modules, caching compiled modules by their
+// content so that each module is only compiled once.
+type loader struct {
+	// moduleDir is the directory local modules are loaded from.  Local
+	// modules are disabled if empty.
+	moduleDir   string
+	allowRemote bool
+
+	mu sync.Mutex
+	// sources caches the hash of the module at each location.
+	sources *simplelru.LRU[string, source]
+	// compiled caches compiled modules by hash.
+	compiled *simplelru.LRU[[32]byte, *compiledModule]
+}
+
+// compiledModule is a cached compiled module, counting the executions using
+// it so that it's only closed once evicted and unused.  Fields are guarded by
+// the loader's lock.
+type compiledModule struct {
+	wazero.CompiledModule
+	refs    int
+	evicted bool
+}
+
+// source records the module last loaded from a location.
+type source struct {
+	hash [32]byte
+	// version is the file's modification time and size for local files, or
+	// the time the module was downloaded for remote modules.
+	version string
+	fetched time.Time
+}
+
+// load returns the compiled module at the given URI.  Callers must call
+// release once finished with the module.
+func (l *loader) load(ctx context.Context, rt wazero.Runtime, uri url.URL) (*compiledModule, error) {
+	location, remote, err := Location(uri)
+	if err != nil {
+		return nil, err
+	}
+	if remote && !l.allowRemote {
+		return nil, ErrRemoteNotAllowed
+	}
+
+	version := ""
+	if !remote {
+		location, err = l.localPath(location)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(location)
+		if err != nil {
+			return nil, fmt.Errorf("error reading wasm module: %w", err)
+		}
+		version = fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+	}
+
+	l.mu.Lock()
+	src, ok := l.sources.Get(location)
+	if ok && ((remote && time.Since(src.fetched) < remoteModuleTTL) || (!remote && src.version == version)) {
+		if mod, ok := l.compiled.Get(src.hash); ok {
+			mod.refs++
+			l.mu.Unlock()
+			return mod, nil
+		}
+	}
+	l.mu.Unlock()
+
+	var bin []byte
+	if remote {
+		bin, err = fetch(ctx, location)
+	} else {
+		bin, err = readFile(location)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(bin)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sources.Add(location, source{hash: hash, version: version, fetched: time.Now()})
+	if mod, ok := l.compiled.Get(hash); ok {
+		mod.refs++
+		return mod, nil
+	}
+	compiled, err := rt.CompileModule(ctx, bin)
+	if err != nil {
+		return nil, fmt.Errorf("error compiling wasm module: %w", err)
+	}
+	mod := &compiledModule{CompiledModule: compiled, refs: 1}
+	l.compiled.Add(hash, mod)
+	return mod, nil
+}
+
+// release marks an execution as finished with a module, closing the module if
+// it has been evicted and is no longer used.
+func (l *loader) release(mod *compiledModule) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	mod.refs--
+	if mod.evicted && mod.refs == 0 {
+		_ = mod.Close(context.Background())
+	}
+}
+
+// localPath returns the path of a local module, resolving relative paths
+// against the module directory.  This errors if local modules are disabled or
+// if the module is outside of the module directory, including via symlinks.
+func (l *loader) localPath(path string) (string, error) {
+	if l.moduleDir == "" {
+		return "", ErrLocalNotAllowed
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(l.moduleDir, path)
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(path))
+	if err != nil {
+		return "", fmt.Errorf("error reading wasm module: %w", err)
+	}
+	rel, err := filepath.Rel(l.moduleDir, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", ErrOutsideModuleDir, path)
+	}
+	return resolved, nil
+}
+
+// Location returns the file path or URL of the module referenced by a step
+// URI, and whether the module is remote.
+func Location(uri url.URL) (string, bool, error) {
+	switch uri.Scheme {
+	case "wasm":
+		if uri.Opaque != "" {
+			// A relative path, eg. wasm:fn.wasm
+			return uri.Opaque, false, nil
+		}
+		if uri.Host != "" {
+			return "", false, fmt.Errorf("invalid wasm uri %q: use wasm:///path for files or wasm+https://host/path for remote modules", uri.String())
+		}
+		if uri.Path == "" {
+			return "", false, fmt.Errorf("invalid wasm uri %q: missing path", uri.String())
+		}
+		return uri.Path, false, nil
+	case "wasm+http", "wasm+https":
+		remote := url.URL{
+			Scheme: strings.TrimPrefix(uri.Scheme, "wasm+"),
+			Host:   uri.Host,
+			Path:   uri.Path,
+		}
+		return remote.String(), true, nil
+	default:
+		return "", false, fmt.Errorf("unsupported wasm uri scheme: %s", uri.Scheme)
+	}
+}
+
+func readFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading wasm module: %w", err)
+	}
+	defer f.Close()
+	return readModule(f)
+}
+
+// fetch downloads a remote module using the HTTP driver's client, ensuring
+// that the same SSRF protections apply.
+func fetch(ctx context.Context, location string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpdriver.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading wasm module: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error downloading wasm module: status %d", resp.StatusCode)
+	}
+	return readModule(resp.Body)
+}
+
+func readModule(r io.Reader) ([]byte, error) {
+	bin, err := io.ReadAll(io.LimitReader(r, maxModuleSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading wasm module: %w", err)
+	}
+	if len(bin) > maxModuleSize {
+		return nil, fmt.Errorf("wasm module is larger than %d bytes", maxModuleSize)
+	}
+	return bin, nil
+}
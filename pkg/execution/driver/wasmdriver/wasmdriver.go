@@ -0,0 +1,267 @@
+// Package wasmdriver executes functions compiled to WebAssembly (WASI) modules
+// in-process, without deploying a separate HTTP service.
+//
+// Steps using this driver have a URI of:
+//
+//   - wasm:///path/to/fn.wasm, or wasm:fn.wasm for a path relative to the
+//     module directory.  Local modules must be within the module directory,
+//     and are disabled unless one is configured.
+//   - wasm+https://example.com/fn.wasm to download the module, if remote
+//     modules are allowed.
+//
+// Each execution instantiates a fresh module in a sandbox with no filesystem or
+// network access, and with memory and time limits.  The module is invoked via
+// its WASI "_start" function using the same protocol as SDKs served over HTTP:
+//
+//   - The SDK request is written to stdin as JSON.
+//   - The INNGEST_STEP_ID environment variable contains the step to run, as
+//     with the "stepId" query parameter over HTTP.  Every query parameter of
+//     the URI is also available as an INNGEST_<NAME> variable, eg. fnId as
+//     INNGEST_FNID.
+//   - The module writes a streamed SDK response to stdout, eg.
+//     {"status": 206, "body": [...opcodes], "headers": {...}}.
+//
+// Exiting with a non-zero code is treated as a retryable error, with any
+// output written to stderr included in the error.  Writing more than
+// consts.MaxSDKResponseBodySize bytes to stdout fails the step without
+// retrying, as with oversized HTTP responses.
+package wasmdriver
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inngest/inngest/pkg/consts"
+	"github.com/inngest/inngest/pkg/execution/driver"
+	"github.com/inngest/inngest/pkg/execution/driver/httpdriver"
+	"github.com/inngest/inngest/pkg/execution/queue"
+	"github.com/inngest/inngest/pkg/execution/state"
+	sv2 "github.com/inngest/inngest/pkg/execution/state/v2"
+	"github.com/inngest/inngest/pkg/inngest"
+	"github.com/inngest/inngest/pkg/syscode"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+const (
+	// wasmPageSize is the size of a single page of WebAssembly memory.
+	wasmPageSize = 64 * 1024
+	// maxStderr is the maximum number of bytes of stderr included in errors.
+	maxStderr = 4 * 1024
+)
+
+var (
+	ErrRemoteNotAllowed = fmt.Errorf("loading remote wasm modules is not enabled")
+	ErrLocalNotAllowed  = fmt.Errorf("loading local wasm modules is not enabled")
+	ErrOutsideModuleDir = fmt.Errorf("wasm module is outside of the module directory")
+	ErrTimeout          = fmt.Errorf("wasm module exceeded the execution timeout")
+	ErrOutputTooLarge   = fmt.Errorf("wasm module output is greater than the limit")
+)
+
+// New returns a new WebAssembly driver with the given per-instance memory limit
+// and execution timeout.  Local modules are loaded from moduleDir, and are
+// disabled if moduleDir is empty.
+func New(maxMemoryMB int, timeout time.Duration, moduleDir string, allowRemote bool) driver.Driver {
+	return &executor{
+		maxMemoryMB: maxMemoryMB,
+		maxOutput:   consts.MaxSDKResponseBodySize,
+		timeout:     timeout,
+		loader:      newLoader(moduleDir, allowRemote, maxCompiledModules),
+	}
+}
+
+type executor struct {
+	maxMemoryMB int
+	// maxOutput is the maximum number of bytes a module may write to stdout.
+	maxOutput int
+	timeout   time.Duration
+	loader    *loader
+
+	// runtime is lazily created on first use, as it's only required if a
+	// function uses a wasm URI.
+	init    sync.Once
+	runtime wazero.Runtime
+	initErr error
+}
+
+// RuntimeType fulfils the inngest.Runtime interface.
+func (e *executor) RuntimeType() string {
+	return "wasm"
+}
+
+func (e *executor) Execute(ctx context.Context, sl sv2.StateLoader, s sv2.Metadata, item queue.Item, edge inngest.Edge, step inngest.Step, idx, attempt int) (*state.DriverResponse, error) {
+	uri, err := url.Parse(step.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := driver.MarshalV1(ctx, sl, s, step, idx, "", attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	env := map[string]string{}
+	for k, v := range uri.Query() {
+		if len(v) > 0 {
+			env["INNGEST_"+strings.ToUpper(k)] = v[0]
+		}
+	}
+	env["INNGEST_STEP_ID"] = edge.Incoming
+	if edge.IncomingGeneratorStep != "" {
+		env["INNGEST_STEP_ID"] = edge.IncomingGeneratorStep
+	}
+	env["INNGEST_RUN_ID"] = s.ID.RunID.String()
+	env["INNGEST_ATTEMPT"] = strconv.Itoa(attempt)
+
+	resp, err := e.run(ctx, *uri, input, env)
+	if errors.Is(err, ErrOutputTooLarge) {
+		// Retrying won't shrink the output, so fail the step immediately.
+		return &state.DriverResponse{Step: step, NoRetry: true}, syscode.Error{
+			Code:    syscode.CodeOutputTooLarge,
+			Message: err.Error(),
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return httpdriver.HandleHttpResponse(ctx, httpdriver.Request{
+		WorkflowID: s.ID.FunctionID,
+		RunID:      s.ID.RunID,
+		URL:        *uri,
+		Input:      input,
+		Edge:       edge,
+		Step:       step,
+	}, resp)
+}
+
+// run executes the module at the given URI, returning its response.
+func (e *executor) run(ctx context.Context, uri url.URL, input []byte, env map[string]string) (*httpdriver.Response, error) {
+	rt, err := e.wasmRuntime()
+	if err != nil {
+		return nil, err
+	}
+
+	mod, err := e.loader.load(ctx, rt, uri)
+	if err != nil {
+		return nil, err
+	}
+	defer e.loader.release(mod)
+
+	stdout := &limitedBuffer{max: e.maxOutput}
+	stderr := &limitedBuffer{max: maxStderr}
+	cfg := wazero.NewModuleConfig().
+		// Use an anonymous instance, allowing concurrent executions of the
+		// same module.
+		WithName("").
+		WithArgs(uri.String()).
+		WithStdin(bytes.NewReader(input)).
+		WithStdout(stdout).
+		WithStderr(stderr).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithSysNanosleep().
+		WithRandSource(rand.Reader)
+	for k, v := range env {
+		cfg = cfg.WithEnv(k, v)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	start := time.Now()
+	instance, err := rt.InstantiateModule(ctx, mod.CompiledModule, cfg)
+	dur := time.Since(start)
+	if instance != nil {
+		_ = instance.Close(context.WithoutCancel(ctx))
+	}
+
+	var exit *sys.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exit) && exit.ExitCode() == 0:
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return nil, ErrTimeout
+	case errors.As(err, &exit):
+		return nil, fmt.Errorf("wasm module exited with code %d: %s", exit.ExitCode(), strings.TrimSpace(stderr.String()))
+	default:
+		return nil, fmt.Errorf("error running wasm module: %w", err)
+	}
+	if stdout.truncated {
+		return nil, ErrOutputTooLarge
+	}
+
+	stream, err := httpdriver.ParseStream(stdout.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid wasm module output: %w", err)
+	}
+	if stream.StatusCode == 0 {
+		stream.StatusCode = 200
+	}
+
+	headers := map[string]string{}
+	for k, v := range stream.Headers {
+		headers[strings.ToLower(k)] = v
+	}
+
+	var retryAt *time.Time
+	if after := headers["retry-after"]; after != "" {
+		if at, err := httpdriver.ParseRetry(after); err == nil {
+			retryAt = &at
+		}
+	}
+	rv, _ := strconv.Atoi(headers["x-inngest-req-version"])
+
+	return &httpdriver.Response{
+		Body:           stream.Body,
+		StatusCode:     stream.StatusCode,
+		Duration:       dur,
+		RetryAt:        retryAt,
+		NoRetry:        !httpdriver.ShouldRetry(stream.StatusCode, headers["x-inngest-no-retry"], headers["x-inngest-sdk"]),
+		RequestVersion: rv,
+		IsSDK:          true,
+		Sdk:            headers["x-inngest-sdk"],
+	}, nil
+}
+
+func (e *executor) wasmRuntime() (wazero.Runtime, error) {
+	e.init.Do(func() {
+		ctx := context.Background()
+		e.runtime = wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+			WithMemoryLimitPages(uint32(e.maxMemoryMB*1024*1024/wasmPageSize)).
+			WithCloseOnContextDone(true),
+		)
+		if _, err := wasi_snapshot_preview1.Instantiate(ctx, e.runtime); err != nil {
+			e.initErr = fmt.Errorf("error initializing wasi: %w", err)
+		}
+	})
+	return e.runtime, e.initErr
+}
+
+// limitedBuffer stores up to max bytes, discarding the rest.
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+	// truncated records whether any bytes were discarded.
+	truncated bool
+}
+
+func (l *limitedBuffer) Write(p []byte) (int, error) {
+	remaining := l.max - l.Len()
+	if remaining > 0 {
+		l.Buffer.Write(p[:min(len(p), remaining)])
+	}
+	if len(p) > remaining {
+		l.truncated = true
+	}
+	return len(p), nil
+}
@@ -0,0 +1,247 @@
+package wasmdriver
+
+import (
+	"context"
+	"encoding/binary"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tetratelabs/wazero"
+)
+
+// module assembles a minimal WASI module whose _start function runs body,
+// with data loaded into memory at offset 0.  Function 0 is fd_write.
+func module(body []byte, data []byte) []byte {
+	section := func(id byte, contents ...byte) []byte {
+		return append(append([]byte{id}, uleb(len(contents))...), contents...)
+	}
+	str := func(s string) []byte {
+		return append(uleb(len(s)), s...)
+	}
+
+	bin := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	// types: (i32, i32, i32, i32) -> i32, and () -> ()
+	bin = append(bin, section(1, 0x02, 0x60, 0x04, 0x7f, 0x7f, 0x7f, 0x7f, 0x01, 0x7f, 0x60, 0x00, 0x00)...)
+	// import wasi_snapshot_preview1.fd_write
+	imports := []byte{0x01}
+	imports = append(imports, str("wasi_snapshot_preview1")...)
+	imports = append(imports, str("fd_write")...)
+	imports = append(imports, 0x00, 0x00)
+	bin = append(bin, section(2, imports...)...)
+	// one function of type 1
+	bin = append(bin, section(3, 0x01, 0x01)...)
+	// one page of memory
+	bin = append(bin, section(5, 0x01, 0x00, 0x01)...)
+	// export memory and _start
+	exports := []byte{0x02}
+	exports = append(exports, str("memory")...)
+	exports = append(exports, 0x02, 0x00)
+	exports = append(exports, str("_start")...)
+	exports = append(exports, 0x00, 0x01)
+	bin = append(bin, section(7, exports...)...)
+	// code
+	fn := append([]byte{0x00}, body...)
+	code := append([]byte{0x01}, uleb(len(fn))...)
+	bin = append(bin, section(10, append(code, fn...)...)...)
+	// data at offset 0
+	seg := []byte{0x01, 0x00, 0x41, 0x00, 0x0b}
+	seg = append(seg, uleb(len(data))...)
+	bin = append(bin, section(11, append(seg, data...)...)...)
+	return bin
+}
+
+// writer returns a module which writes the given output to stdout.
+func writer(output string) []byte {
+	// iovec { ptr: 16, len: len(output) } at offset 0, output at 16.
+	data := make([]byte, 16)
+	binary.LittleEndian.PutUint32(data[0:], 16)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(output)))
+	data = append(data, output...)
+	body := []byte{
+		0x41, 0x01, // i32.const 1 (stdout)
+		0x41, 0x00, // i32.const 0 (iovec)
+		0x41, 0x01, // i32.const 1 (iovec count)
+		0x41, 0x08, // i32.const 8 (nwritten)
+		0x10, 0x00, // call fd_write
+		0x1a, // drop
+		0x0b, // end
+	}
+	return module(body, data)
+}
+
+func uleb(n int) []byte {
+	out := []byte{}
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n != 0 {
+			out = append(out, b|0x80)
+			continue
+		}
+		return append(out, b)
+	}
+}
+
+func write(t *testing.T, dir string, bin []byte) url.URL {
+	f, err := os.CreateTemp(dir, "*.wasm")
+	require.NoError(t, err)
+	_, err = f.Write(bin)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	return url.URL{Scheme: "wasm", Path: f.Name()}
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	e := New(16, time.Second, dir, false).(*executor)
+
+	t.Run("it returns the streamed response", func(t *testing.T) {
+		uri := write(t, dir, writer(`{"status":206,"body":[{"op":"Step","id":"a"}],"headers":{"X-Inngest-SDK":"wasm:v1"}}`))
+
+		resp, err := e.run(ctx, uri, []byte(`{}`), nil)
+		require.NoError(t, err)
+		require.Equal(t, 206, resp.StatusCode)
+		require.JSONEq(t, `[{"op":"Step","id":"a"}]`, string(resp.Body))
+		require.Equal(t, "wasm:v1", resp.Sdk)
+		require.True(t, resp.IsSDK)
+
+		// Running again uses the compiled module.
+		_, err = e.run(ctx, uri, []byte(`{}`), nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, e.loader.compiled.Len())
+	})
+
+	t.Run("it errors with invalid output", func(t *testing.T) {
+		_, err := e.run(ctx, write(t, dir, writer(`not json`)), nil, nil)
+		require.ErrorContains(t, err, "invalid wasm module output")
+	})
+
+	t.Run("it errors when output exceeds the limit", func(t *testing.T) {
+		e := New(16, time.Second, dir, false).(*executor)
+		e.maxOutput = 16
+		_, err := e.run(ctx, write(t, dir, writer(`{"status":200,"body":"too large"}`)), nil, nil)
+		require.ErrorIs(t, err, ErrOutputTooLarge)
+	})
+
+	t.Run("it errors on traps", func(t *testing.T) {
+		// unreachable
+		_, err := e.run(ctx, write(t, dir, module([]byte{0x00, 0x0b}, nil)), nil, nil)
+		require.ErrorContains(t, err, "error running wasm module")
+	})
+
+	t.Run("it enforces timeouts", func(t *testing.T) {
+		e := New(16, 100*time.Millisecond, dir, false).(*executor)
+		// loop { br 0 }
+		_, err := e.run(ctx, write(t, dir, module([]byte{0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b}, nil)), nil, nil)
+		require.ErrorIs(t, err, ErrTimeout)
+	})
+
+	t.Run("it rejects remote modules unless allowed", func(t *testing.T) {
+		uri, _ := url.Parse("wasm+https://example.com/fn.wasm")
+		_, err := e.run(ctx, *uri, nil, nil)
+		require.ErrorIs(t, err, ErrRemoteNotAllowed)
+	})
+}
+
+func TestLocalModules(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	bin := writer(`{"status":200,"body":"ok"}`)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fn.wasm"), bin, 0600))
+
+	outside := write(t, t.TempDir(), bin)
+
+	t.Run("it rejects local modules unless a module dir is set", func(t *testing.T) {
+		e := New(16, time.Second, "", false).(*executor)
+		_, err := e.run(ctx, url.URL{Scheme: "wasm", Path: filepath.Join(dir, "fn.wasm")}, nil, nil)
+		require.ErrorIs(t, err, ErrLocalNotAllowed)
+	})
+
+	e := New(16, time.Second, dir, false).(*executor)
+
+	t.Run("it resolves relative paths against the module dir", func(t *testing.T) {
+		resp, err := e.run(ctx, url.URL{Scheme: "wasm", Opaque: "fn.wasm"}, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("it rejects paths outside of the module dir", func(t *testing.T) {
+		_, err := e.run(ctx, outside, nil, nil)
+		require.ErrorIs(t, err, ErrOutsideModuleDir)
+
+		_, err = e.run(ctx, url.URL{Scheme: "wasm", Opaque: "../" + filepath.Base(filepath.Dir(outside.Path)) + "/" + filepath.Base(outside.Path)}, nil, nil)
+		require.ErrorIs(t, err, ErrOutsideModuleDir)
+	})
+
+	t.Run("it rejects symlinks outside of the module dir", func(t *testing.T) {
+		link := filepath.Join(dir, "link.wasm")
+		require.NoError(t, os.Symlink(outside.Path, link))
+		_, err := e.run(ctx, url.URL{Scheme: "wasm", Path: link}, nil, nil)
+		require.ErrorIs(t, err, ErrOutsideModuleDir)
+	})
+}
+
+func TestLoaderEvicts(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	e := New(16, time.Second, dir, false).(*executor)
+	e.loader = newLoader(dir, false, 2)
+
+	uris := []url.URL{}
+	for _, body := range []string{`"a"`, `"b"`, `"c"`} {
+		uris = append(uris, write(t, dir, writer(`{"status":200,"body":`+body+`}`)))
+	}
+	for _, uri := range uris {
+		_, err := e.run(ctx, uri, nil, nil)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, e.loader.compiled.Len())
+
+	// The evicted module is compiled again.
+	resp, err := e.run(ctx, uris[0], nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "a", string(resp.Body))
+	require.Equal(t, 2, e.loader.compiled.Len())
+
+	// Modules in use aren't closed when evicted.
+	rt, err := e.wasmRuntime()
+	require.NoError(t, err)
+	held, err := e.loader.load(ctx, rt, uris[0])
+	require.NoError(t, err)
+	for _, uri := range uris[1:] {
+		_, err := e.run(ctx, uri, nil, nil)
+		require.NoError(t, err)
+	}
+	require.True(t, held.evicted)
+	instance, err := rt.InstantiateModule(ctx, held.CompiledModule, wazero.NewModuleConfig().WithName(""))
+	require.NoError(t, err)
+	require.NoError(t, instance.Close(ctx))
+
+	// Releasing the last reference closes the module.
+	e.loader.release(held)
+	_, err = rt.InstantiateModule(ctx, held.CompiledModule, wazero.NewModuleConfig().WithName(""))
+	require.Error(t, err)
+}
+
+func TestLocation(t *testing.T) {
+	for uri, expected := range map[string]string{
+		"wasm:///srv/fn.wasm":                "/srv/fn.wasm",
+		"wasm:fn.wasm":                       "fn.wasm",
+		"wasm+https://example.com/fn.wasm?x": "https://example.com/fn.wasm",
+	} {
+		u, err := url.Parse(uri)
+		require.NoError(t, err)
+		loc, _, err := Location(*u)
+		require.NoError(t, err)
+		require.Equal(t, expected, loc)
+	}
+
+	u, _ := url.Parse("wasm://host/fn.wasm")
+	_, _, err := Location(*u)
+	require.Error(t, err)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/inngest/inngest/pkg/execution/batch"
 
 	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/enums"
 	"github.com/inngest/inngest/pkg/event"
 	"github.com/inngest/inngest/pkg/execution/queue"
 	"github.com/inngest/inngest/pkg/execution/state"
@@ -93,6 +94,10 @@ type Executor interface {
 	// HandleInvokeFinish handles the invoke pauses from an incoming event. This delegates to Cancel and
 	// Resume where necessary
 	HandleInvokeFinish(ctx context.Context, event event.TrackedEvent) error
+	// ResumeSignal resumes the run waiting for the given signal with the given
+	// data, returning the ID of the resumed run.  This returns
+	// state.ErrSignalPauseNotFound if no run is waiting for the signal.
+	ResumeSignal(ctx context.Context, wsID uuid.UUID, signal string, data any) (*ulid.ULID, error)
 	// Cancel cancels an in-progress function run, preventing any enqueued or future steps from running.
 	Cancel(ctx context.Context, id sv2.ID, r CancelRequest) error
 	// Resume resumes an in-progress function run from the given waitForEvent pause.
@@ -180,6 +185,9 @@ type ScheduleRequest struct {
 	PreventDebounce bool
 	// FunctionPausedAt indicates whether the function is paused.
 	FunctionPausedAt *time.Time
+	// SkipReason, if set, skips the run immediately and records the skip with the
+	// given reason, eg. when the run is a duplicate of an idempotent function.
+	SkipReason enums.SkipReason
 }
 
 type ScheduleRequestFromStep struct {
@@ -191,6 +199,15 @@ type ScheduleRequestFromStep struct {
 	Input json.RawMessage
 }
 
+// CancelReason represents the reason for a system-initiated cancellation.
+type CancelReason string
+
+const (
+	// CancelReasonSingleton indicates that a singleton function's run was
+	// replaced by a new run for the same singleton key.
+	CancelReasonSingleton CancelReason = "singleton"
+)
+
 // CancelRequest stores information about the incoming cancellation request within
 // history.
 type CancelRequest struct {
@@ -198,6 +215,9 @@ type CancelRequest struct {
 	Expression     *string
 	UserID         *uuid.UUID
 	CancellationID *ulid.ULID
+	// Reason is set for cancellations made by the system rather than by an
+	// event, API request, or cancellation.
+	Reason CancelReason
 
 	// ForceLifecycleHook is used to force the OnFunctionCancelled lifecycle
 	// hook to run even if the function is already finalized. This is useful
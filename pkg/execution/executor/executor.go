@@ -32,6 +32,7 @@ import (
 	"github.com/inngest/inngest/pkg/execution/driver/httpdriver"
 	"github.com/inngest/inngest/pkg/execution/queue"
 	"github.com/inngest/inngest/pkg/execution/realtime"
+	"github.com/inngest/inngest/pkg/execution/singleton"
 	"github.com/inngest/inngest/pkg/execution/state"
 	"github.com/inngest/inngest/pkg/execution/state/redis_state"
 	sv2 "github.com/inngest/inngest/pkg/execution/state/v2"
@@ -211,6 +212,13 @@ func WithDebouncer(d debounce.Debouncer) ExecutorOpt {
 	}
 }
 
+func WithSingletonLocker(l singleton.Locker) ExecutorOpt {
+	return func(e execution.Executor) error {
+		e.(*executor).singletons = l
+		return nil
+	}
+}
+
 func WithBatcher(b batch.BatchManager) ExecutorOpt {
 	return func(e execution.Executor) error {
 		e.(*executor).batcher = b
@@ -294,6 +302,7 @@ type executor struct {
 	queue               queue.Queue
 	debouncer           debounce.Debouncer
 	batcher             batch.BatchManager
+	singletons          singleton.Locker
 	fl                  state.FunctionLoader
 	evalFactory         func(ctx context.Context, expr string) (expressions.Evaluator, error)
 	runtimeDrivers      map[string]driver.Driver
@@ -388,7 +397,8 @@ func idempotencyKey(req execution.ScheduleRequest, runID ulid.ULID) string {
 // function's step via the necessary driver.
 //
 // If this function has a debounce config, this will return ErrFunctionDebounced instead
-// of an identifier as the function is not scheduled immediately.
+// of an identifier as the function is not scheduled immediately, unless the function
+// is debounced on the leading edge and this event starts a new debounce.
 func (e *executor) Schedule(ctx context.Context, req execution.ScheduleRequest) (*sv2.Metadata, error) {
 	if req.AppID == uuid.Nil {
 		return nil, fmt.Errorf("app ID is required to schedule a run")
@@ -405,10 +415,14 @@ func (e *executor) Schedule(ctx context.Context, req execution.ScheduleRequest)
 			Event:            req.Events[0].GetEvent(),
 			FunctionPausedAt: req.FunctionPausedAt,
 		}, req.Function)
-		if err != nil {
+		if err == nil {
+			return nil, ErrFunctionDebounced
+		}
+		// Functions debounced on the leading edge are scheduled immediately when
+		// the debounce is created.
+		if !errors.Is(err, debounce.ErrDebounceLeading) {
 			return nil, err
 		}
-		return nil, ErrFunctionDebounced
 	}
 
 	// Run IDs are created embedding the timestamp now, when the function is being scheduled.
@@ -487,13 +501,17 @@ func (e *executor) Schedule(ctx context.Context, req execution.ScheduleRequest)
 		Config: config,
 	}
 
-	// If this is paused, immediately end just before creating state.
+	// If this is paused or skipped, immediately end just before creating state.
+	skipReason := req.SkipReason
 	isPaused := req.FunctionPausedAt != nil && req.FunctionPausedAt.Before(time.Now())
 	if isPaused {
+		skipReason = enums.SkipReasonFunctionPaused
+	}
+	if skipReason != enums.SkipReasonNone {
 		for _, e := range e.lifecycles {
 			go e.OnFunctionSkipped(context.WithoutCancel(ctx), metadata, execution.SkipState{
 				CronSchedule: req.Events[0].GetEvent().CronSchedule(),
-				Reason:       enums.SkipReasonFunctionPaused,
+				Reason:       skipReason,
 				Events:       evts,
 			})
 		}
@@ -558,6 +576,16 @@ func (e *executor) Schedule(ctx context.Context, req execution.ScheduleRequest)
 		}
 	}
 
+	//
+	// Ensure that only one run of singleton functions is in progress for the
+	// singleton key.
+	//
+	if req.Function.Singleton != nil && e.singletons != nil {
+		if err := e.acquireSingleton(ctx, req, &metadata, evtMap, evts); err != nil {
+			return nil, err
+		}
+	}
+
 	//
 	// Create the run state.
 	//
@@ -575,6 +603,9 @@ func (e *executor) Schedule(ctx context.Context, req execution.ScheduleRequest)
 	}
 
 	err := e.smv2.Create(ctx, newState)
+	if err != nil {
+		e.releaseSingleton(ctx, metadata)
+	}
 	if err == state.ErrIdentifierExists {
 		// This function was already created.
 		return nil, state.ErrIdentifierExists
@@ -1069,6 +1100,9 @@ func (e *executor) finalize(ctx context.Context, md sv2.Metadata, evts []json.Ra
 		logger.StdlibLogger(ctx).Error("error deleting state in finalize", "error", err)
 	}
 
+	// Allow new runs of singleton functions.
+	e.releaseSingleton(ctx, md)
+
 	// We may be cancelling an in-progress run.  If that's the case, we want to delete any
 	// outstanding jobs from the queue, if possible.
 	//
@@ -1266,7 +1300,8 @@ func (e *executor) executeDriverForStep(ctx context.Context, i *runInstance) (*s
 	//
 	// TODO: Refactor response.Err
 	if len(response.Generator) == 1 && response.Generator[0].Op == enums.OpcodeStepError {
-		if !queue.ShouldRetry(nil, i.item.Attempt, step.RetryCount()+1) {
+		policy := retryPolicy(i.f, response.Generator[0])
+		if !queue.ShouldRetry(nil, i.item.Attempt, step.RetryCount()+1) || (policy != nil && policy.Exhausted(i.item.Attempt)) {
 			response.NoRetry = true
 		}
 	}
@@ -1278,9 +1313,33 @@ func (e *executor) executeDriverForStep(ctx context.Context, i *runInstance) (*s
 		response.NoRetry = true
 	}
 
+	// Schedule retries using the function's retry policy, unless the SDK specified
+	// when to retry via Retry-After.
+	if policy := i.f.RetryPolicy; response.Err != nil && policy != nil && !response.NoRetry {
+		if policy.Exhausted(i.item.Attempt) {
+			response.NoRetry = true
+		} else if response.RetryAt == nil {
+			at := policy.NextRetryAt(i.item.Attempt)
+			response.RetryAt = &at
+		}
+	}
+
 	return response, err
 }
 
+// retryPolicy returns the retry policy for a step, preferring the policy set
+// within the step's opcode options over the function's retry policy.  Invalid
+// step policies are ignored, as their durations would parse as zero and retry
+// the step immediately.
+func retryPolicy(f inngest.Function, gen *state.GeneratorOpcode) *inngest.RetryPolicy {
+	if gen != nil {
+		if opts, err := gen.RunOpts(); err == nil && opts.RetryPolicy != nil && opts.RetryPolicy.IsValid() == nil {
+			return opts.RetryPolicy
+		}
+	}
+	return f.RetryPolicy
+}
+
 // HandlePauses handles pauses loaded from an incoming event.
 func (e *executor) HandlePauses(ctx context.Context, iter state.PauseIterator, evt event.TrackedEvent) (execution.HandlePauseResult, error) {
 	// Use the aggregator for all funciton finished events, if there are more than
@@ -1558,6 +1617,10 @@ func (e *executor) handlePause(
 			return fmt.Errorf("error consuming pause after cancel: %w", err)
 		}
 
+		if pause.MultiWait != nil && pause.MultiWait.ParentID != nil {
+			return e.handleMultiWaitPause(ctx, evt, evtID, pause, res)
+		}
+
 		resumeData := pause.GetResumeData(evt.GetEvent())
 
 		err := e.Resume(ctx, *pause, execution.ResumeRequest{
@@ -1584,6 +1647,69 @@ func (e *executor) handlePause(
 	})
 }
 
+// handleMultiWaitPause adds an event matched by a child pause of a wait for
+// events step to the step's parent pause, resuming the parent pause once enough
+// events have been collected.
+func (e *executor) handleMultiWaitPause(
+	ctx context.Context,
+	evt event.TrackedEvent,
+	evtID ulid.ULID,
+	pause *state.Pause,
+	res *execution.HandlePauseResult,
+) error {
+	parent, err := e.pm.AddMultiWaitEvent(ctx, *pause.MultiWait.ParentID, state.MultiWaitEvent{
+		Index: pause.MultiWait.Index,
+		ID:    evtID,
+		Data:  evt.GetEvent().Map(),
+	})
+	if errors.Is(err, state.ErrPauseNotFound) {
+		// The step has already resumed or timed out.
+		_ = e.pm.DeletePause(context.Background(), *pause)
+		_ = e.exprAggregator.RemovePause(ctx, pause)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error adding event to wait for events pause: %w", err)
+	}
+	if !parent.MultiWait.Done() {
+		return nil
+	}
+
+	err = e.Resume(ctx, *parent, execution.ResumeRequest{
+		With:     parent.MultiWait.Result(),
+		EventID:  &evtID,
+		StepName: parent.StepName,
+	})
+	if errors.Is(err, state.ErrPauseLeased) ||
+		errors.Is(err, state.ErrPauseNotFound) ||
+		errors.Is(err, state.ErrRunNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error resuming wait for events pause: %w", err)
+	}
+
+	atomic.AddInt32(&res[1], 1)
+	return nil
+}
+
+// deleteMultiWaitChildren deletes the child pauses of a wait for events step
+// once the step's parent pause is consumed.
+func (e *executor) deleteMultiWaitChildren(ctx context.Context, parent state.Pause) {
+	for _, id := range parent.MultiWait.Children {
+		child, err := e.pm.PauseByID(ctx, id)
+		if err != nil {
+			continue
+		}
+		if err := e.pm.DeletePause(ctx, *child); err != nil {
+			logger.StdlibLogger(ctx).Warn("error deleting wait for events pause", "error", err, "pause_id", id)
+		}
+		if e.exprAggregator != nil {
+			_ = e.exprAggregator.RemovePause(ctx, child)
+		}
+	}
+}
+
 func (e *executor) HandleInvokeFinish(ctx context.Context, evt event.TrackedEvent) error {
 	evtID := evt.GetInternalID()
 
@@ -1648,6 +1774,105 @@ func (e *executor) HandleInvokeFinish(ctx context.Context, evt event.TrackedEven
 	})
 }
 
+// acquireSingleton acquires the singleton lock for a new run, storing the lock
+// key in the run's metadata so that it's released when the run finishes.  If a
+// run is already in progress for the singleton key, this either skips the new run
+// by returning ErrFunctionSkipped or cancels the in-progress run, depending on
+// the singleton mode.
+func (e *executor) acquireSingleton(ctx context.Context, req execution.ScheduleRequest, md *sv2.Metadata, evtMap map[string]any, evts []json.RawMessage) error {
+	key, err := req.Function.Singleton.Evaluate(ctx, req.Function.ID, evtMap)
+	if err != nil {
+		return err
+	}
+
+	// Retry a small number of times in case a concurrent run acquires the lock
+	// after an in-progress run is cancelled.
+	for i := 0; i < 3; i++ {
+		held, err := e.singletons.Acquire(ctx, key, md.ID.RunID)
+		if err != nil {
+			return err
+		}
+		if held == nil {
+			md.Config.SetSingletonKey(key)
+			return nil
+		}
+
+		id := sv2.ID{
+			RunID:      *held,
+			FunctionID: md.ID.FunctionID,
+			Tenant:     md.ID.Tenant,
+		}
+		exists, err := e.smv2.Exists(ctx, id)
+		if err != nil {
+			return fmt.Errorf("error checking singleton run: %w", err)
+		}
+
+		if exists && req.Function.Singleton.GetMode() == inngest.SingletonModeSkip {
+			for _, l := range e.lifecycles {
+				go l.OnFunctionSkipped(context.WithoutCancel(ctx), *md, execution.SkipState{
+					CronSchedule: req.Events[0].GetEvent().CronSchedule(),
+					Reason:       enums.SkipReasonSingleton,
+					Events:       evts,
+				})
+			}
+			return ErrFunctionSkipped
+		}
+
+		if exists {
+			// Cancelling the run releases its lock as the run is finalized.
+			if err := e.Cancel(ctx, id, execution.CancelRequest{Reason: execution.CancelReasonSingleton}); err != nil {
+				return fmt.Errorf("error cancelling singleton run: %w", err)
+			}
+		}
+
+		// Take over the lock from the cancelled or missing run.
+		ok, err := e.singletons.Replace(ctx, key, *held, md.ID.RunID)
+		if err != nil {
+			return err
+		}
+		if ok {
+			md.Config.SetSingletonKey(key)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unable to acquire singleton lock")
+}
+
+// releaseSingleton releases the singleton lock held by the run, if any.
+func (e *executor) releaseSingleton(ctx context.Context, md sv2.Metadata) {
+	key := md.Config.SingletonKey()
+	if key == nil || e.singletons == nil {
+		return
+	}
+	if err := e.singletons.Release(ctx, *key, md.ID.RunID); err != nil {
+		logger.StdlibLogger(ctx).Error("error releasing singleton lock", "error", err, "run_id", md.ID.RunID)
+	}
+}
+
+// ResumeSignal resumes the run waiting for the given signal, storing data as
+// the output of the run's wait for signal step.
+func (e *executor) ResumeSignal(ctx context.Context, wsID uuid.UUID, signal string, data any) (*ulid.ULID, error) {
+	pause, err := e.pm.PauseBySignalID(ctx, wsID, signal)
+	if err != nil {
+		return nil, err
+	}
+
+	if pause.Expires.Time().Before(time.Now()) {
+		// The pause timeout job will consume this pause shortly.
+		return nil, state.ErrSignalPauseNotFound
+	}
+
+	err = e.Resume(ctx, *pause, execution.ResumeRequest{
+		With:     data,
+		StepName: pause.StepName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pause.Identifier.RunID, nil
+}
+
 // Cancel cancels an in-progress function.
 func (e *executor) Cancel(ctx context.Context, id sv2.ID, r execution.CancelRequest) error {
 	l := logger.StdlibLogger(ctx).With(
@@ -1810,6 +2035,10 @@ func (e *executor) Resume(ctx context.Context, pause state.Pause, r execution.Re
 		return err
 	}
 
+	if pause.IsMultiWait() {
+		e.deleteMultiWaitChildren(ctx, pause)
+	}
+
 	if pause.IsInvoke() {
 		for _, e := range e.lifecycles {
 			go e.OnInvokeFunctionResumed(context.WithoutCancel(ctx), md, pause, r)
@@ -1958,6 +2187,10 @@ func (e *executor) handleGenerator(ctx context.Context, i *runInstance, gen stat
 		return e.handleGeneratorWaitForEvent(ctx, i, gen, edge)
 	case enums.OpcodeInvokeFunction:
 		return e.handleGeneratorInvokeFunction(ctx, i, gen, edge)
+	case enums.OpcodeWaitForSignal:
+		return e.handleGeneratorWaitForSignal(ctx, i, gen, edge)
+	case enums.OpcodeWaitForEvents:
+		return e.handleGeneratorWaitForEvents(ctx, i, gen, edge)
 	case enums.OpcodeAIGateway:
 		return e.handleGeneratorAIGateway(ctx, i, gen, edge)
 	case enums.OpcodeGateway:
@@ -2084,13 +2317,28 @@ func (e *executor) handleStepError(ctx context.Context, i *runInstance, gen stat
 		// means we've failed N times, and so it is not retryable.
 		retryable = false
 	}
+	policy := retryPolicy(i.f, &gen)
+	if policy != nil && policy.Exhausted(i.item.Attempt) {
+		// Retrying would exceed the max total retry duration.
+		retryable = false
+	}
 
 	if retryable {
+		var at *time.Time
+		if policy != nil {
+			next := policy.NextRetryAt(i.item.Attempt)
+			at = &next
+		}
 		// Return an error to trigger standard queue retries.
 		for _, l := range e.lifecycles {
 			i.item.Attempt += 1
 			go l.OnStepScheduled(ctx, i.md, i.item, &gen.Name)
 		}
+		if at != nil {
+			// Any Retry-After specified by the SDK wraps this error within
+			// handleGeneratorGroup, taking precedence.
+			return queue.RetryAtError(ErrHandledStepError, at)
+		}
 		return ErrHandledStepError
 	}
 
@@ -2651,31 +2899,9 @@ func (e *executor) handleGeneratorWaitForEvent(ctx context.Context, i *runInstan
 
 	expr := opts.If
 	if expr != nil && strings.Contains(*expr, "event.") {
-		// Remove `event` data from the expression and replace with actual event
-		// data as values, now that we have the event.
-		//
-		// This improves performance in matching, as we can then use the values within
-		// aggregate trees.
-		evt := event.Event{}
-		if err := json.Unmarshal(i.events[0], &evt); err != nil {
-			logger.StdlibLogger(ctx).Error("error unmarshalling trigger event in waitForEvent op", "error", err)
-		}
-
-		interpolated, err := expressions.Interpolate(ctx, *opts.If, map[string]any{
-			"event": evt.Map(),
-		})
+		interpolated, err := interpolateTriggerEvent(ctx, i, *expr)
 		if err != nil {
-			var compileError *expressions.CompileError
-			if errors.As(err, &compileError) {
-				return fmt.Errorf("error interpolating wait for event expression: %w", state.WrapInStandardError(
-					compileError,
-					"CompileError",
-					"Could not compile expression",
-					compileError.Message(),
-				))
-			}
-
-			return fmt.Errorf("error interpolating wait for event expression: %w", err)
+			return err
 		}
 		expr = &interpolated
 
@@ -2756,6 +2982,253 @@ func (e *executor) handleGeneratorWaitForEvent(ctx context.Context, i *runInstan
 	return err
 }
 
+func (e *executor) handleGeneratorWaitForSignal(ctx context.Context, i *runInstance, gen state.GeneratorOpcode, edge queue.PayloadEdge) error {
+	opts, err := gen.WaitForSignalOpts()
+	if err != nil {
+		return fmt.Errorf("unable to parse wait for signal opts: %w", err)
+	}
+	expires, err := opts.Expires()
+	if err != nil {
+		return fmt.Errorf("unable to parse wait for signal expires: %w", err)
+	}
+
+	pauseID := inngest.DeterministicSha1UUID(i.md.ID.RunID.String() + gen.ID)
+	opcode := gen.Op.String()
+
+	sid := run.NewSpanID(ctx)
+	carrier := itrace.NewTraceCarrier(
+		itrace.WithTraceCarrierTimestamp(time.Now()),
+		itrace.WithTraceCarrierSpanID(&sid),
+	)
+	itrace.UserTracer().Propagator().Inject(ctx, propagation.MapCarrier(carrier.Context))
+
+	pause := state.Pause{
+		ID:          pauseID,
+		WorkspaceID: i.md.ID.Tenant.EnvID,
+		Identifier:  i.item.Identifier,
+		GroupID:     i.item.GroupID,
+		Outgoing:    gen.ID,
+		Incoming:    edge.Edge.Incoming,
+		StepName:    gen.UserDefinedName(),
+		Opcode:      &opcode,
+		Expires:     state.Time(expires),
+		DataKey:     gen.ID,
+		SignalID:    &opts.Signal,
+		MaxAttempts: i.item.MaxAttempts,
+		Metadata: map[string]any{
+			consts.OtelPropagationKey: carrier,
+		},
+	}
+	err = e.pm.SavePause(ctx, pause)
+	if err == state.ErrPauseAlreadyExists {
+		return nil
+	}
+	if err == state.ErrSignalConflict {
+		// Retrying can't succeed while the other run waits, so fail the step
+		// without retrying, allowing the function to handle the error.
+		gen.Op = enums.OpcodeStepError
+		gen.Error = &state.UserError{
+			Name:    "SignalConflictError",
+			Message: fmt.Sprintf("another run is already waiting for signal %q", opts.Signal),
+			NoRetry: true,
+		}
+		i.resp.UpdateOpcodeError(&gen, *gen.Error)
+		return e.handleStepError(ctx, i, gen, edge)
+	}
+	if err != nil {
+		return fmt.Errorf("error waiting for signal %q: %w", opts.Signal, err)
+	}
+
+	// Enqueue a job that will timeout the pause.  Signals are resolved
+	// directly via ResumeSignal, so the pause is never matched against events.
+	jobID := fmt.Sprintf("%s-%s", i.md.IdempotencyKey(), gen.ID)
+	err = e.queue.Enqueue(ctx, queue.Item{
+		JobID:       &jobID,
+		WorkspaceID: i.md.ID.Tenant.EnvID,
+		// Use the same group ID, allowing us to track the cancellation of
+		// the step correctly.
+		GroupID:               i.item.GroupID,
+		Kind:                  queue.KindPause,
+		Identifier:            i.item.Identifier,
+		PriorityFactor:        i.item.PriorityFactor,
+		CustomConcurrencyKeys: i.item.CustomConcurrencyKeys,
+		Payload: queue.PayloadPauseTimeout{
+			PauseID:   pauseID,
+			OnTimeout: true,
+		},
+	}, expires, queue.EnqueueOpts{})
+	if err == redis_state.ErrQueueItemExists {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, e := range e.lifecycles {
+		go e.OnWaitForEvent(context.WithoutCancel(ctx), i.md, i.item, gen, pause)
+	}
+
+	return nil
+}
+
+// interpolateTriggerEvent removes `event` data from an async expression and
+// replaces it with the run's triggering event data as values.
+//
+// This improves performance in matching, as we can then use the values within
+// aggregate trees.
+func interpolateTriggerEvent(ctx context.Context, i *runInstance, expr string) (string, error) {
+	evt := event.Event{}
+	if err := json.Unmarshal(i.events[0], &evt); err != nil {
+		logger.StdlibLogger(ctx).Error("error unmarshalling trigger event in waitForEvent op", "error", err)
+	}
+
+	interpolated, err := expressions.Interpolate(ctx, expr, map[string]any{
+		"event": evt.Map(),
+	})
+	if err != nil {
+		var compileError *expressions.CompileError
+		if errors.As(err, &compileError) {
+			return "", fmt.Errorf("error interpolating wait for event expression: %w", state.WrapInStandardError(
+				compileError,
+				"CompileError",
+				"Could not compile expression",
+				compileError.Message(),
+			))
+		}
+
+		return "", fmt.Errorf("error interpolating wait for event expression: %w", err)
+	}
+	return interpolated, nil
+}
+
+func (e *executor) handleGeneratorWaitForEvents(ctx context.Context, i *runInstance, gen state.GeneratorOpcode, edge queue.PayloadEdge) error {
+	opts, err := gen.WaitForEventsOpts()
+	if err != nil {
+		return fmt.Errorf("unable to parse wait for events opts: %w", err)
+	}
+
+	expires, err := opts.Expires()
+	if err != nil {
+		return fmt.Errorf("unable to parse wait for events expires: %w", err)
+	}
+
+	parentID := inngest.DeterministicSha1UUID(i.md.ID.RunID.String() + gen.ID)
+	opcode := gen.Op.String()
+
+	children := make([]state.Pause, len(opts.Events))
+	childIDs := make([]uuid.UUID, len(opts.Events))
+	for n, m := range opts.Events {
+		expr := m.If
+		if expr != nil {
+			if err := expressions.Validate(ctx, *expr); err != nil {
+				return state.WrapInStandardError(
+					err,
+					"InvalidExpression",
+					"Wait for events expression is invalid",
+					err.Error(),
+				)
+			}
+			if strings.Contains(*expr, "event.") {
+				interpolated, err := interpolateTriggerEvent(ctx, i, *expr)
+				if err != nil {
+					return err
+				}
+				expr = &interpolated
+			}
+		}
+
+		childIDs[n] = inngest.DeterministicSha1UUID(i.md.ID.RunID.String() + gen.ID + strconv.Itoa(n))
+		children[n] = state.Pause{
+			ID:          childIDs[n],
+			WorkspaceID: i.md.ID.Tenant.EnvID,
+			Identifier:  i.item.Identifier,
+			GroupID:     i.item.GroupID,
+			Outgoing:    gen.ID,
+			Incoming:    edge.Edge.Incoming,
+			StepName:    gen.UserDefinedName(),
+			Opcode:      &opcode,
+			Expires:     state.Time(expires),
+			Event:       &opts.Events[n].Event,
+			Expression:  expr,
+			DataKey:     gen.ID,
+			MaxAttempts: i.item.MaxAttempts,
+			MultiWait: &state.MultiWait{
+				ParentID: &parentID,
+				Index:    n,
+			},
+		}
+	}
+
+	sid := run.NewSpanID(ctx)
+	carrier := itrace.NewTraceCarrier(
+		itrace.WithTraceCarrierTimestamp(time.Now()),
+		itrace.WithTraceCarrierSpanID(&sid),
+	)
+	itrace.UserTracer().Propagator().Inject(ctx, propagation.MapCarrier(carrier.Context))
+
+	// The parent pause isn't indexed by event, and collects events matched by
+	// each child pause until the step can be resumed.
+	pause := state.Pause{
+		ID:          parentID,
+		WorkspaceID: i.md.ID.Tenant.EnvID,
+		Identifier:  i.item.Identifier,
+		GroupID:     i.item.GroupID,
+		Outgoing:    gen.ID,
+		Incoming:    edge.Edge.Incoming,
+		StepName:    gen.UserDefinedName(),
+		Opcode:      &opcode,
+		Expires:     state.Time(expires),
+		DataKey:     gen.ID,
+		MaxAttempts: i.item.MaxAttempts,
+		MultiWait: &state.MultiWait{
+			Mode:     opts.GetMode(),
+			Count:    opts.Count,
+			Children: childIDs,
+		},
+		Metadata: map[string]any{
+			consts.OtelPropagationKey: carrier,
+		},
+	}
+	err = e.pm.SavePause(ctx, pause)
+	if err != nil && err != state.ErrPauseAlreadyExists {
+		return err
+	}
+
+	// Always save child pauses, even if the parent exists, in case a previous
+	// attempt failed before saving every child.
+	for _, child := range children {
+		if err := e.pm.SavePause(ctx, child); err != nil && err != state.ErrPauseAlreadyExists {
+			return err
+		}
+	}
+
+	jobID := fmt.Sprintf("%s-%s", i.md.IdempotencyKey(), gen.ID)
+	err = e.queue.Enqueue(ctx, queue.Item{
+		JobID:       &jobID,
+		WorkspaceID: i.md.ID.Tenant.EnvID,
+		// Use the same group ID, allowing us to track the cancellation of
+		// the step correctly.
+		GroupID:               i.item.GroupID,
+		Kind:                  queue.KindPause,
+		Identifier:            i.item.Identifier,
+		PriorityFactor:        i.item.PriorityFactor,
+		CustomConcurrencyKeys: i.item.CustomConcurrencyKeys,
+		Payload: queue.PayloadPauseTimeout{
+			PauseID:   parentID,
+			OnTimeout: true,
+		},
+	}, expires, queue.EnqueueOpts{})
+	if err == redis_state.ErrQueueItemExists {
+		return nil
+	}
+
+	for _, e := range e.lifecycles {
+		go e.OnWaitForEvent(context.WithoutCancel(ctx), i.md, i.item, gen, pause)
+	}
+
+	return err
+}
+
 func (e *executor) newExpressionEvaluator(ctx context.Context, expr string) (expressions.Evaluator, error) {
 	if e.evalFactory != nil {
 		return e.evalFactory(ctx, expr)
@@ -2824,6 +3297,12 @@ func (e *executor) AppendAndScheduleBatch(ctx context.Context, fn inngest.Functi
 			return fmt.Errorf("could not retrieve and schedule batch items: %w", err)
 		}
 
+		if result.Overflow {
+			// The event didn't fit in the full batch, so append it to a new
+			// batch.
+			return e.AppendAndScheduleBatch(ctx, fn, bi, opts)
+		}
+
 	default:
 		return fmt.Errorf("invalid status of batch append ops: %d", result.Status)
 	}
@@ -0,0 +1,354 @@
+package executor_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/event"
+	"github.com/inngest/inngest/pkg/execution"
+	"github.com/inngest/inngest/pkg/execution/state"
+	"github.com/inngest/inngest/pkg/headers"
+	"github.com/inngest/inngest/pkg/inngest"
+	"github.com/inngest/inngest/pkg/sdk"
+	"github.com/inngest/inngest/pkg/testkit"
+	"github.com/stretchr/testify/require"
+)
+
+// handler runs a function given the triggering event and the memoized output
+// of each step, returning the status code and response body.
+type handler func(evt event.Event, steps map[string]json.RawMessage) (int, any)
+
+// app is a minimal SDK, serving each function via its handler.
+type app struct {
+	kit       *testkit.Kit
+	functions []sdk.SDKFunction
+	handlers  map[string]handler
+}
+
+func newApp(k *testkit.Kit) *app {
+	return &app{kit: k, handlers: map[string]handler{}}
+}
+
+func (a *app) add(fn sdk.SDKFunction, h handler) {
+	a.functions = append(a.functions, fn)
+	a.handlers[fn.Slug] = h
+}
+
+func (a *app) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPut {
+		a.register(w, r)
+		return
+	}
+
+	req := struct {
+		Event event.Event                `json:"event"`
+		Steps map[string]json.RawMessage `json:"steps"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(400)
+		return
+	}
+	status, body := a.handlers[r.URL.Query().Get("fnId")](req.Event, req.Steps)
+	byt, _ := json.Marshal(body)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(headers.HeaderKeySDK, "go:v0.0.1")
+	w.WriteHeader(status)
+	_, _ = w.Write(byt)
+}
+
+func (a *app) register(w http.ResponseWriter, r *http.Request) {
+	url := "http://" + r.Host + "/"
+	req := sdk.RegisterRequest{
+		URL:     url,
+		V:       "1",
+		SDK:     "go:v0.0.1",
+		AppName: "app",
+	}
+	for _, fn := range a.functions {
+		fn.Steps = map[string]sdk.SDKStep{
+			"step": {
+				ID:   "step",
+				Name: "step",
+				Runtime: map[string]any{
+					"type": "http",
+					"url":  fmt.Sprintf("%s?fnId=%s&step=step", url, fn.Slug),
+				},
+			},
+		}
+		req.Functions = append(req.Functions, fn)
+	}
+
+	byt, _ := json.Marshal(req)
+	resp, err := http.Post(a.kit.URL()+"/fn/register", "application/json", bytes.NewReader(byt))
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+	_ = resp.Body.Close()
+	w.WriteHeader(resp.StatusCode)
+}
+
+func opcodes(ops ...state.GeneratorOpcode) (int, any) {
+	return 206, ops
+}
+
+// sleeper returns a handler which sleeps for an hour before completing.
+func sleeper(evt event.Event, steps map[string]json.RawMessage) (int, any) {
+	if _, ok := steps["s"]; !ok {
+		return opcodes(state.GeneratorOpcode{Op: enums.OpcodeSleep, ID: "s", Name: "1h"})
+	}
+	return 200, "done"
+}
+
+func TestSingleton(t *testing.T) {
+	ctx := context.Background()
+
+	for _, mode := range []inngest.SingletonMode{inngest.SingletonModeSkip, inngest.SingletonModeCancel} {
+		t.Run(string(mode), func(t *testing.T) {
+			k := testkit.New(t)
+			key := "event.data.user"
+
+			a := newApp(k)
+			a.add(sdk.SDKFunction{
+				Name:      "singleton",
+				Slug:      "app-singleton",
+				Singleton: &inngest.Singleton{Key: &key, Mode: mode},
+				Triggers:  []inngest.Trigger{{EventTrigger: &inngest.EventTrigger{Event: "test/singleton"}}},
+			}, sleeper)
+			require.NoError(t, k.RegisterHandler(ctx, a))
+
+			ids, err := k.Send(ctx, event.Event{Name: "test/singleton", Data: map[string]any{"user": "a"}})
+			require.NoError(t, err)
+			runs, err := k.WaitForRuns(ctx, ids[0], 1)
+			require.NoError(t, err)
+			first, err := k.WaitForStep(ctx, runs[0].ID, "1h", enums.HistoryTypeStepSleeping)
+			require.NoError(t, err)
+
+			// A run for another key is unaffected.
+			ids, err = k.Send(ctx, event.Event{Name: "test/singleton", Data: map[string]any{"user": "b"}})
+			require.NoError(t, err)
+			runs, err = k.WaitForRuns(ctx, ids[0], 1)
+			require.NoError(t, err)
+			other, err := k.WaitForStep(ctx, runs[0].ID, "1h", enums.HistoryTypeStepSleeping)
+			require.NoError(t, err)
+
+			ids, err = k.Send(ctx, event.Event{Name: "test/singleton", Data: map[string]any{"user": "a"}})
+			require.NoError(t, err)
+			runs, err = k.WaitForRuns(ctx, ids[0], 1)
+			require.NoError(t, err)
+
+			switch mode {
+			case inngest.SingletonModeSkip:
+				second, err := k.WaitForEnd(ctx, runs[0].ID)
+				require.NoError(t, err)
+				require.Equal(t, enums.RunStatusSkipped, second.Status)
+				require.Equal(t, enums.SkipReasonSingleton, second.SkipReason)
+
+				first, _ = k.Run(first.ID)
+				require.False(t, first.Ended())
+			case inngest.SingletonModeCancel:
+				first, err = k.WaitForEnd(ctx, first.ID)
+				require.NoError(t, err)
+				require.Equal(t, enums.RunStatusCancelled, first.Status)
+				require.Equal(t, execution.CancelReasonSingleton, first.CancelReason)
+
+				_, err = k.WaitForStep(ctx, runs[0].ID, "1h", enums.HistoryTypeStepSleeping)
+				require.NoError(t, err)
+			}
+
+			other, _ = k.Run(other.ID)
+			require.False(t, other.Ended())
+
+			// Runs complete once the sleep ends, releasing the lock.
+			k.FastForward(time.Hour)
+			other, err = k.WaitForEnd(ctx, other.ID)
+			require.NoError(t, err)
+			require.Equal(t, enums.RunStatusCompleted, other.Status)
+		})
+	}
+}
+
+func TestWaitForSignal(t *testing.T) {
+	ctx := context.Background()
+	k := testkit.New(t)
+
+	a := newApp(k)
+	a.add(sdk.SDKFunction{
+		Name:     "signal",
+		Slug:     "app-signal",
+		Triggers: []inngest.Trigger{{EventTrigger: &inngest.EventTrigger{Event: "test/signal"}}},
+	}, func(evt event.Event, steps map[string]json.RawMessage) (int, any) {
+		if out, ok := steps["sig"]; ok {
+			return 200, out
+		}
+		return opcodes(state.GeneratorOpcode{
+			Op:   enums.OpcodeWaitForSignal,
+			ID:   "sig",
+			Name: "wait",
+			Opts: map[string]any{
+				"signal":  fmt.Sprintf("signal-%s", evt.Data["user"]),
+				"timeout": "1h",
+			},
+		})
+	})
+	require.NoError(t, k.RegisterHandler(ctx, a))
+
+	wait := func(t *testing.T, user string) testkit.Run {
+		ids, err := k.Send(ctx, event.Event{Name: "test/signal", Data: map[string]any{"user": user}})
+		require.NoError(t, err)
+		runs, err := k.WaitForRuns(ctx, ids[0], 1)
+		require.NoError(t, err)
+		run, err := k.WaitForStep(ctx, runs[0].ID, "wait", enums.HistoryTypeStepWaiting)
+		require.NoError(t, err)
+		return run
+	}
+
+	t.Run("it resumes with the signal's data", func(t *testing.T) {
+		run := wait(t, "a")
+
+		runID, err := k.Signal(ctx, "signal-a", map[string]any{"ok": true})
+		require.NoError(t, err)
+		require.Equal(t, run.ID, runID)
+
+		run, err = k.WaitForEnd(ctx, run.ID)
+		require.NoError(t, err)
+		require.Equal(t, enums.RunStatusCompleted, run.Status)
+		require.JSONEq(t, `{"ok":true}`, string(run.Output))
+
+		step, ok := run.Step("wait")
+		require.True(t, ok)
+		require.Equal(t, enums.HistoryTypeStepCompleted, step.Status)
+		require.False(t, step.Timeout)
+	})
+
+	t.Run("it fails when another run is waiting for the signal", func(t *testing.T) {
+		first := wait(t, "c")
+
+		ids, err := k.Send(ctx, event.Event{Name: "test/signal", Data: map[string]any{"user": "c"}})
+		require.NoError(t, err)
+		runs, err := k.WaitForRuns(ctx, ids[0], 1)
+		require.NoError(t, err)
+
+		// The step errors without retrying, returning the error to the SDK.
+		second, err := k.WaitForEnd(ctx, runs[0].ID)
+		require.NoError(t, err)
+		require.Contains(t, string(second.Output), `another run is already waiting for signal \"signal-c\"`)
+
+		runID, err := k.Signal(ctx, "signal-c", nil)
+		require.NoError(t, err)
+		require.Equal(t, first.ID, runID)
+	})
+
+	t.Run("it resumes on timeout", func(t *testing.T) {
+		run := wait(t, "b")
+
+		k.FastForward(time.Hour)
+		run, err := k.WaitForEnd(ctx, run.ID)
+		require.NoError(t, err)
+		require.Equal(t, enums.RunStatusCompleted, run.Status)
+
+		step, ok := run.Step("wait")
+		require.True(t, ok)
+		require.Equal(t, enums.HistoryTypeStepCompleted, step.Status)
+		require.True(t, step.Timeout)
+
+		_, err = k.Signal(ctx, "signal-b", nil)
+		require.ErrorIs(t, err, state.ErrSignalPauseNotFound)
+	})
+}
+
+func TestWaitForEvents(t *testing.T) {
+	ctx := context.Background()
+	k := testkit.New(t)
+
+	expr := "async.data.user == event.data.user"
+
+	a := newApp(k)
+	a.add(sdk.SDKFunction{
+		Name:     "events",
+		Slug:     "app-events",
+		Triggers: []inngest.Trigger{{EventTrigger: &inngest.EventTrigger{Event: "test/start"}}},
+	}, func(evt event.Event, steps map[string]json.RawMessage) (int, any) {
+		if out, ok := steps["evts"]; ok {
+			return 200, out
+		}
+		return opcodes(state.GeneratorOpcode{
+			Op:   enums.OpcodeWaitForEvents,
+			ID:   "evts",
+			Name: "wait",
+			Opts: map[string]any{
+				"timeout": "1h",
+				"events": []map[string]any{
+					{"event": "test/a", "if": expr},
+					{"event": "test/b", "if": expr},
+				},
+			},
+		})
+	})
+	require.NoError(t, k.RegisterHandler(ctx, a))
+
+	wait := func(t *testing.T, user string) testkit.Run {
+		ids, err := k.Send(ctx, event.Event{Name: "test/start", Data: map[string]any{"user": user}})
+		require.NoError(t, err)
+		runs, err := k.WaitForRuns(ctx, ids[0], 1)
+		require.NoError(t, err)
+		run, err := k.WaitForStep(ctx, runs[0].ID, "wait", enums.HistoryTypeStepWaiting)
+		require.NoError(t, err)
+		return run
+	}
+
+	send := func(t *testing.T, name, user string) {
+		_, err := k.Send(ctx, event.Event{Name: name, Data: map[string]any{"user": user}})
+		require.NoError(t, err)
+	}
+
+	t.Run("it resumes once every event matches", func(t *testing.T) {
+		run := wait(t, "a")
+
+		// A partial match leaves the run waiting.
+		send(t, "test/a", "a")
+		send(t, "test/b", "other")
+		run, _ = k.Run(run.ID)
+		require.False(t, run.Ended())
+		step, ok := run.Step("wait")
+		require.True(t, ok)
+		require.Equal(t, enums.HistoryTypeStepWaiting, step.Status)
+
+		send(t, "test/b", "a")
+		run, err := k.WaitForEnd(ctx, run.ID)
+		require.NoError(t, err)
+		require.Equal(t, enums.RunStatusCompleted, run.Status)
+
+		evts := []event.Event{}
+		require.NoError(t, json.Unmarshal(run.Output, &evts))
+		require.Len(t, evts, 2)
+		require.Equal(t, "test/a", evts[0].Name)
+		require.Equal(t, "test/b", evts[1].Name)
+
+		step, ok = run.Step("wait")
+		require.True(t, ok)
+		require.Equal(t, enums.HistoryTypeStepCompleted, step.Status)
+		require.False(t, step.Timeout)
+	})
+
+	t.Run("it resumes on timeout", func(t *testing.T) {
+		run := wait(t, "b")
+		send(t, "test/a", "b")
+
+		k.FastForward(time.Hour)
+		run, err := k.WaitForEnd(ctx, run.ID)
+		require.NoError(t, err)
+		require.Equal(t, enums.RunStatusCompleted, run.Status)
+
+		step, ok := run.Step("wait")
+		require.True(t, ok)
+		require.Equal(t, enums.HistoryTypeStepCompleted, step.Status)
+		require.True(t, step.Timeout)
+	})
+}
@@ -142,7 +142,7 @@ func (s *svc) getFinishHandler(ctx context.Context) (func(context.Context, sv2.I
 		for _, e := range events {
 			evt := e
 			eg.Go(func() error {
-				trackedEvent := event.NewOSSTrackedEvent(evt, nil)
+				trackedEvent := event.NewOSSTrackedEventInWorkspace(id.Tenant.EnvID, evt, nil)
 				byt, err := json.Marshal(trackedEvent)
 				if err != nil {
 					return fmt.Errorf("error marshalling event: %w", err)
@@ -254,12 +254,19 @@ func (s *svc) handleQueueItem(ctx context.Context, item queue.Item) (bool, error
 
 		// If the error is not of type response error, we assume the step is
 		// always retryable.
-		if resp == nil || err != nil {
+		if resp == nil {
 			return false, err
 		}
 
 		// Always retry; non-retryable is covered above.
-		return false, fmt.Errorf("%s", resp.Error())
+		if err == nil {
+			err = fmt.Errorf("%s", resp.Error())
+		}
+		// Retry at the time specified by the SDK or the function's retry policy.
+		if resp.RetryAt != nil && queue.AsRetryAtError(err) == nil {
+			err = queue.RetryAtError(err, resp.RetryAt)
+		}
+		return false, err
 	}
 
 	if resp != nil && len(resp.Generator) > 0 {
@@ -387,6 +394,13 @@ func (s *svc) handleDebounce(ctx context.Context, item queue.Item) error {
 				return err
 			}
 
+			if di.Leading {
+				// The debounced event already ran on the leading edge, and no
+				// further events were received to run on the trailing edge.
+				_ = s.debouncer.DeleteDebounceItem(ctx, d.DebounceID, *di, d.AccountID)
+				continue
+			}
+
 			ctx, span := run.NewSpan(ctx,
 				run.WithScope(consts.OtelScopeDebounce),
 				run.WithName(consts.OtelSpanDebounce),
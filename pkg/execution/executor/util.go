@@ -52,7 +52,7 @@ func opGroups(opcodes []*state.GeneratorOpcode) OpcodeGroups {
 	}
 
 	for _, op := range opcodes {
-		if op.Op == enums.OpcodeWaitForEvent {
+		if op.Op == enums.OpcodeWaitForEvent || op.Op == enums.OpcodeWaitForSignal || op.Op == enums.OpcodeWaitForEvents {
 			groups.PriorityGroup.Opcodes = append(groups.PriorityGroup.Opcodes, op)
 		} else {
 			groups.OtherGroup.Opcodes = append(groups.OtherGroup.Opcodes, op)
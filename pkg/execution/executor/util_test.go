@@ -5,6 +5,8 @@ import (
 
 	"github.com/inngest/inngest/pkg/enums"
 	"github.com/inngest/inngest/pkg/execution/state"
+	"github.com/inngest/inngest/pkg/inngest"
+	"github.com/inngest/inngest/pkg/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -143,3 +145,15 @@ func TestOpcodeGroupsAllWithEmptyInput(t *testing.T) {
 
 	require.EqualValues(t, expected, actual)
 }
+
+func TestRetryPolicy(t *testing.T) {
+	fn := inngest.Function{RetryPolicy: &inngest.RetryPolicy{Strategy: inngest.RetryStrategyFixed, Base: util.StrPtr("1m")}}
+
+	step := &inngest.RetryPolicy{Strategy: inngest.RetryStrategyFixed, Base: util.StrPtr("10s")}
+	gen := &state.GeneratorOpcode{Op: enums.OpcodeStepError, Opts: map[string]any{"retryPolicy": step}}
+	require.Equal(t, step, retryPolicy(fn, gen))
+
+	// Invalid step policies fall back to the function's policy.
+	gen.Opts = map[string]any{"retryPolicy": inngest.RetryPolicy{Strategy: inngest.RetryStrategyFixed, Base: util.StrPtr("soon")}}
+	require.Equal(t, fn.RetryPolicy, retryPolicy(fn, gen))
+}
@@ -467,7 +467,7 @@ func (l lifecycle) OnWaitForEvent(
 	md sv2.Metadata,
 	item queue.Item,
 	op state.GeneratorOpcode,
-	_ state.Pause,
+	pause state.Pause,
 ) {
 	groupID, err := toUUID(item.GroupID)
 	if err != nil {
@@ -479,8 +479,7 @@ func (l lifecycle) OnWaitForEvent(
 		)
 	}
 
-	opts, _ := op.WaitForEventOpts()
-	expires, _ := opts.Expires()
+	eventName, expr, _ := op.WaitTarget()
 	stepName := op.UserDefinedName()
 	// nothing right now.
 	h := History{
@@ -499,9 +498,9 @@ func (l lifecycle) OnWaitForEvent(
 		StepName:        &stepName,
 		StepID:          &op.ID,
 		WaitForEvent: &WaitForEvent{
-			EventName:  opts.Event,
-			Expression: opts.If,
-			Timeout:    expires,
+			EventName:  eventName,
+			Expression: expr,
+			Timeout:    pause.Expires.Time(),
 		},
 		BatchID: md.Config.BatchID,
 	}
@@ -538,6 +537,14 @@ func (l lifecycle) OnWaitForEventResumed(
 	var stepName *string
 	if req.StepName != "" {
 		stepName = &req.StepName
+	} else if pause.StepName != "" {
+		// Timeouts don't include the step name.
+		stepName = &pause.StepName
+	}
+
+	var stepID *string
+	if pause.DataKey != "" {
+		stepID = &pause.DataKey
 	}
 
 	h := History{
@@ -554,9 +561,12 @@ func (l lifecycle) OnWaitForEventResumed(
 		EventID:         md.Config.EventID(),
 		WaitResult: &WaitResult{
 			EventID: req.EventID,
-			Timeout: req.EventID == nil,
+			// Signals resume without an event, so timeouts are only set
+			// via the pause's timeout job.
+			Timeout: req.IsTimeout,
 		},
 		BatchID:  md.Config.BatchID,
+		StepID:   stepID,
 		StepName: stepName,
 	}
 	for _, d := range l.drivers {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"time"
@@ -62,6 +63,7 @@ func (a *api) setup() {
 		r.Use(realtimeAuthMW(a.opts.JWTSecret, a.opts.AuthMiddleware))
 
 		r.Get("/realtime/connect", a.GetWebsocketUpgrade)
+		r.Get("/realtime/sse", a.GetSSE)
 		r.Post("/realtime/token", a.PostCreateJWT)
 	})
 
@@ -94,6 +96,10 @@ func (a *api) PostCreateJWT(w http.ResponseWriter, r *http.Request) {
 		_ = publicerr.WriteHTTP(w, publicerr.Wrapf(err, 400, "Invalid request: must provide a list of topics"))
 		return
 	}
+	if err := ValidateTopics(topics); err != nil {
+		_ = publicerr.WriteHTTP(w, publicerr.Wrapf(err, 400, "Invalid topics: %s", err))
+		return
+	}
 
 	// Set the env ID from the authentication context.
 	for n := range topics {
@@ -175,6 +181,107 @@ func (a *api) GetWebsocketUpgrade(w http.ResponseWriter, r *http.Request) {
 	_ = ws.CloseNow()
 }
 
+// GetSSE subscribes to the topics within the realtime JWT using server-sent events.
+//
+// Clients reconnecting with a Last-Event-ID header or a ?since cursor, either a
+// message ID or an RFC3339 timestamp, receive any retained messages published
+// after the cursor before receiving live messages.
+func (a *api) GetSSE(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	auth, err := realtimeAuth(ctx)
+	if err != nil {
+		w.Header().Add("content-type", "application/json")
+		_ = publicerr.WriteHTTP(w, publicerr.Wrapf(err, 401, "Not authenticated"))
+		return
+	}
+
+	cursor, err := sseCursor(r)
+	if err != nil {
+		w.Header().Add("content-type", "application/json")
+		_ = publicerr.WriteHTTP(w, publicerr.Wrapf(err, 400, "Invalid cursor"))
+		return
+	}
+
+	replayer, canReplay := a.opts.Broadcaster.(Replayer)
+	replay := cursor != nil && canReplay
+
+	sub, err := NewSSESubscription(w, replay)
+	if err != nil {
+		w.Header().Add("content-type", "application/json")
+		_ = publicerr.WriteHTTP(w, publicerr.Wrapf(err, 500, "Streaming is not supported"))
+		return
+	}
+
+	w.Header().Set("content-type", "text/event-stream")
+	w.Header().Set("cache-control", "no-cache")
+	w.Header().Set("connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	logger.StdlibLogger(ctx).Info(
+		"new realtime sse connection",
+		"acct_id", auth.AccountID(),
+		"env_id", auth.Env,
+		"topics", auth.Topics,
+	)
+
+	// Subscribe before loading history, so that messages published whilst
+	// replaying are buffered instead of lost.
+	if err := a.opts.Broadcaster.Subscribe(ctx, sub, auth.Topics); err != nil {
+		logger.StdlibLogger(ctx).Error("error creating sse subscription", "error", err)
+		return
+	}
+	defer func() {
+		_ = a.opts.Broadcaster.CloseSubscription(context.Background(), sub.ID())
+	}()
+
+	if replay {
+		msgs, err := replayer.Replay(ctx, auth.Topics, *cursor)
+		if err != nil {
+			logger.StdlibLogger(ctx).Warn("error loading realtime history", "error", err)
+		}
+		if err := sub.Replay(msgs); err != nil {
+			return
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-sub.Done():
+	}
+}
+
+// sseCursor returns the replay cursor from the Last-Event-ID header or the
+// since query parameter, if either is present.
+func sseCursor(r *http.Request) (*ulid.ULID, error) {
+	since := r.Header.Get("Last-Event-ID")
+	if since == "" {
+		since = r.URL.Query().Get("since")
+	}
+	if since == "" {
+		return nil, nil
+	}
+
+	if id, err := ulid.Parse(since); err == nil {
+		return &id, nil
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, since)
+	if err != nil {
+		return nil, fmt.Errorf("cursor must be a message ID or an RFC3339 timestamp")
+	}
+	// Messages are ordered by ID, so use the smallest ID at the given timestamp
+	// to replay messages published at or after the timestamp.
+	id := ulid.ULID{}
+	if err := id.SetTime(ulid.Timestamp(ts)); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
 func (a *api) PostPublish(w http.ResponseWriter, r *http.Request) {
 	// Allow publishing of arbitrary data using the environment signing
 	// key as the auth token.
@@ -3,17 +3,40 @@ package realtime
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/inngest/inngest/pkg/logger"
+	"github.com/oklog/ulid/v2"
 	"github.com/redis/rueidis"
 )
 
 const (
 	redisPublishAttempts = 3
 	redisRetryInterval   = 2 * time.Second
+
+	// DefaultHistoryTTL is the default duration that message history is retained
+	// for each topic after the last message is published.
+	DefaultHistoryTTL = 10 * time.Minute
 )
 
+// RedisBroadcasterOpt configures a Redis broadcaster.
+type RedisBroadcasterOpt func(b *redisBroadcaster)
+
+// WithHistory retains the last size messages published to each topic within a
+// ring buffer in Redis, allowing subscribers to replay messages published whilst
+// they were disconnected.  History expires after the given TTL of inactivity.
+func WithHistory(size int, ttl time.Duration) RedisBroadcasterOpt {
+	return func(b *redisBroadcaster) {
+		if ttl <= 0 {
+			ttl = DefaultHistoryTTL
+		}
+		b.historySize = size
+		b.historyTTL = ttl
+	}
+}
+
 // NewRedisBroadcaster implements a decentralized broadcaster that allows publishing and fanout of
 // messages from any internal service to clients connected via separate gateways.
 //
@@ -23,12 +46,16 @@ const (
 //
 // The messages pass from executors (calling .Publish) to gateways (susbcribed to redis pub/sub via
 // .Subscribe calls), being sent to all interested subscribers.
-func NewRedisBroadcaster(pubc, subc rueidis.Client) Broadcaster {
-	return &redisBroadcaster{
+func NewRedisBroadcaster(pubc, subc rueidis.Client, opts ...RedisBroadcasterOpt) Broadcaster {
+	b := &redisBroadcaster{
 		broadcaster: newBroadcaster(),
 		pubc:        pubc,
 		subc:        subc,
 	}
+	for _, o := range opts {
+		o(b)
+	}
+	return b
 }
 
 type redisBroadcaster struct {
@@ -40,11 +67,21 @@ type redisBroadcaster struct {
 	pubc rueidis.Client
 	// subc is the raw client connected to Redis, allowing us to subscribe to pub-sub streams.
 	subc rueidis.Client
+
+	// historySize is the number of messages retained for each topic.  History
+	// is disabled if this is zero.
+	historySize int
+	// historyTTL is the duration that history is retained after the last message.
+	historyTTL time.Duration
 }
 
 // Publish publishes a message to Redis' pub-sub.  This is then caught by any subscribers
 // to the same Redis pub-sub channels, which push the message to any connected Subscriptions.
 func (b *redisBroadcaster) Publish(ctx context.Context, m Message) {
+	if b.historySize > 0 && m.ID == (ulid.ULID{}) {
+		m.ID = ulid.Make()
+	}
+
 	// Push the message to Redis' pub/sub so that all other replicas of the
 	// broadcaster receive the same content.  This ensures that every subscription
 	// publishes message data.
@@ -61,11 +98,76 @@ func (b *redisBroadcaster) Publish(ctx context.Context, m Message) {
 
 	for _, t := range m.Topics() {
 		go func(t Topic) {
+			if b.historySize > 0 {
+				b.retain(pubCtx, t, content)
+			}
 			b.publish(pubCtx, t.String(), string(content))
 		}(t)
 	}
 }
 
+// retain stores the message in the topic's ring buffer, trimming the oldest
+// messages once the buffer is full.
+func (b *redisBroadcaster) retain(ctx context.Context, t Topic, content []byte) {
+	key := historyKey(t)
+	cmds := rueidis.Commands{
+		b.pubc.B().Lpush().Key(key).Element(string(content)).Build(),
+		b.pubc.B().Ltrim().Key(key).Start(0).Stop(int64(b.historySize - 1)).Build(),
+		b.pubc.B().Expire().Key(key).Seconds(int64(b.historyTTL.Seconds())).Build(),
+	}
+	for _, resp := range b.pubc.DoMulti(ctx, cmds...) {
+		if err := resp.Error(); err != nil {
+			logger.StdlibLogger(ctx).Warn(
+				"error storing realtime message history",
+				"topic", t.String(),
+				"error", err,
+			)
+			return
+		}
+	}
+}
+
+// Replay returns messages retained for the given topics that were published after
+// the given message ID.  Messages published to more than one of the topics are
+// only returned once.
+func (b *redisBroadcaster) Replay(ctx context.Context, topics []Topic, after ulid.ULID) ([]Message, error) {
+	if b.historySize == 0 {
+		return nil, nil
+	}
+
+	seen := map[ulid.ULID]struct{}{}
+	msgs := []Message{}
+	for _, t := range topics {
+		items, err := b.pubc.Do(ctx, b.pubc.B().Lrange().Key(historyKey(t)).Start(0).Stop(-1).Build()).AsStrSlice()
+		if err != nil {
+			return nil, fmt.Errorf("error loading realtime message history: %w", err)
+		}
+		for _, item := range items {
+			m := Message{}
+			if err := json.Unmarshal([]byte(item), &m); err != nil {
+				continue
+			}
+			if m.ID.Compare(after) <= 0 {
+				continue
+			}
+			if _, ok := seen[m.ID]; ok {
+				continue
+			}
+			seen[m.ID] = struct{}{}
+			msgs = append(msgs, m)
+		}
+	}
+
+	sort.Slice(msgs, func(i, j int) bool {
+		return msgs[i].ID.Compare(msgs[j].ID) < 0
+	})
+	return msgs, nil
+}
+
+func historyKey(t Topic) string {
+	return fmt.Sprintf("realtime:history:%s", t.String())
+}
+
 func (b *redisBroadcaster) PublishStream(ctx context.Context, m Message, data string) {
 	for _, t := range m.Topics() {
 		go func(t Topic) {
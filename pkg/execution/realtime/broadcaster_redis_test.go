@@ -113,3 +113,57 @@ func TestRedisBroadcaster(t *testing.T) {
 		require.Equal(t, msg2, m2[1])
 	})
 }
+
+func TestRedisBroadcasterHistory(t *testing.T) {
+	ctx := context.Background()
+
+	r := miniredis.RunT(t)
+	pubc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:  []string{r.Addr()},
+		DisableCache: true,
+	})
+	require.NoError(t, err)
+	subc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:  []string{r.Addr()},
+		DisableCache: true,
+	})
+	require.NoError(t, err)
+
+	b := NewRedisBroadcaster(pubc, subc, WithHistory(2, time.Minute))
+	replayer, ok := b.(Replayer)
+	require.True(t, ok)
+
+	channel := ulid.MustNew(ulid.Now(), rand.Reader).String()
+	topics := []Topic{{Kind: streamingtypes.TopicKindRun, Channel: channel, Name: streamingtypes.TopicNameRun}}
+
+	for _, data := range []string{"a", "b", "c"} {
+		msg := streamingtypes.NewMessage(streamingtypes.MessageKindRun, data)
+		msg.Channel = channel
+		b.Publish(ctx, msg)
+
+		// Wait for the message to be retained, preserving publish order.
+		require.Eventually(t, func() bool {
+			msgs, err := replayer.Replay(ctx, topics, ulid.ULID{})
+			require.NoError(t, err)
+			return len(msgs) > 0 && string(msgs[len(msgs)-1].Data) == `"`+data+`"`
+		}, time.Second, 5*time.Millisecond)
+	}
+
+	t.Run("only the most recent messages are retained", func(t *testing.T) {
+		msgs, err := replayer.Replay(ctx, topics, ulid.ULID{})
+		require.NoError(t, err)
+		require.Len(t, msgs, 2)
+		require.Equal(t, `"b"`, string(msgs[0].Data))
+		require.Equal(t, `"c"`, string(msgs[1].Data))
+	})
+
+	t.Run("messages are replayed after the cursor", func(t *testing.T) {
+		all, err := replayer.Replay(ctx, topics, ulid.ULID{})
+		require.NoError(t, err)
+
+		msgs, err := replayer.Replay(ctx, topics, all[0].ID)
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		require.Equal(t, all[1], msgs[0])
+	})
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/inngest/inngest/pkg/execution/realtime/streamingtypes"
+	"github.com/oklog/ulid/v2"
 )
 
 type Message = streamingtypes.Message
@@ -68,6 +69,14 @@ type Broadcaster interface {
 	Close(context.Context) error
 }
 
+// Replayer is implemented by broadcasters which retain recent messages for each topic,
+// allowing subscribers to replay messages published whilst they were disconnected.
+type Replayer interface {
+	// Replay returns messages published to any of the given topics after the given
+	// message ID, ordered from oldest to newest.
+	Replay(ctx context.Context, topics []Topic, after ulid.ULID) ([]Message, error)
+}
+
 // Subscription represents a subscription to a specific set of channels, via a given protocol.
 // This may be backed by websockets, server-sent-events, and so on.
 type Subscription interface {
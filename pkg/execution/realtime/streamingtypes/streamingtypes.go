@@ -140,6 +140,10 @@ func (t Topic) String() string {
 
 // Message represents a single message sent on realtime topics.
 type Message struct {
+	// ID is a unique, time-ordered ID for the message.  This is only set by
+	// broadcasters that retain message history, and is used as a cursor when
+	// replaying messages.
+	ID ulid.ULID `json:"id,omitempty,omitzero"`
 	// Kind represents the message kind.
 	Kind MessageKind `json:"kind"`
 	// Data represents the data in the message.
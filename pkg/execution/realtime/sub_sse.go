@@ -0,0 +1,193 @@
+package realtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/execution/realtime/streamingtypes"
+	"github.com/oklog/ulid/v2"
+)
+
+// NewSSESubscription creates a new server-sent-events subscription which writes
+// messages to the given response writer.  The response writer must implement
+// http.Flusher.
+//
+// If replaying is true, live messages are buffered until Replay is called, allowing
+// missed messages to be sent before any live messages.
+func NewSSESubscription(w http.ResponseWriter, replaying bool) (*SubscriptionSSE, error) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("response writer does not support streaming")
+	}
+	return &SubscriptionSSE{
+		id:        uuid.New(),
+		w:         w,
+		f:         f,
+		replaying: replaying,
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// SubscriptionSSE represents a server-sent-events subscription.  Subscriptions are
+// read-only;  the topics are fixed when the connection is opened.
+type SubscriptionSSE struct {
+	id uuid.UUID
+
+	w http.ResponseWriter
+	f http.Flusher
+
+	// l serializes writes to the response, and guards replay state.
+	l sync.Mutex
+	// replaying indicates that missed messages are being replayed, and that
+	// live messages should be buffered in pending.
+	replaying bool
+	pending   []func() error
+	// replayed stores the IDs of replayed messages, ensuring that live messages
+	// received during the replay are not sent twice.
+	replayed map[ulid.ULID]struct{}
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *SubscriptionSSE) ID() uuid.UUID {
+	return s.id
+}
+
+func (s *SubscriptionSSE) Protocol() string {
+	return "sse"
+}
+
+// Done returns a channel which is closed when the subscription is closed.
+func (s *SubscriptionSSE) Done() <-chan struct{} {
+	return s.done
+}
+
+// Replay writes the given missed messages, followed by any live messages
+// received whilst replaying.
+func (s *SubscriptionSSE) Replay(msgs []Message) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.replayed = map[ulid.ULID]struct{}{}
+	for _, m := range msgs {
+		if err := s.writeMessage(m); err != nil {
+			return err
+		}
+		s.replayed[m.ID] = struct{}{}
+	}
+
+	s.replaying = false
+	for _, f := range s.pending {
+		if err := f(); err != nil {
+			return err
+		}
+	}
+	s.pending = nil
+	return nil
+}
+
+func (s *SubscriptionSSE) WriteMessage(m Message) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if s.replaying {
+		s.pending = append(s.pending, func() error { return s.writeMessage(m) })
+		return nil
+	}
+	return s.writeMessage(m)
+}
+
+func (s *SubscriptionSSE) WriteChunk(c Chunk) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	write := func() error {
+		byt, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		return s.write("", string(streamingtypes.MessageKindDataStreamChunk), byt)
+	}
+	if s.replaying {
+		s.pending = append(s.pending, write)
+		return nil
+	}
+	return write()
+}
+
+func (s *SubscriptionSSE) SendKeepalive(m Message) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	// Comments are ignored by SSE clients, keeping the connection open.
+	if _, err := s.w.Write([]byte(": ping\n\n")); err != nil {
+		return err
+	}
+	s.f.Flush()
+	return nil
+}
+
+func (s *SubscriptionSSE) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	return nil
+}
+
+// writeMessage writes a message as an SSE event, using the message ID as the
+// event ID so that clients reconnect with a Last-Event-ID header.
+//
+// NOTE: this must be called with the lock held.
+func (s *SubscriptionSSE) writeMessage(m Message) error {
+	if _, ok := s.replayed[m.ID]; ok && m.ID != (ulid.ULID{}) {
+		return nil
+	}
+
+	// Ensure that the data is valid JSON.  Note that sometimes
+	// m.Data is set as a raw string - eg. the channel ID.
+	if !json.Valid(m.Data) {
+		enc, err := json.Marshal(string(m.Data))
+		if err != nil {
+			return err
+		}
+		m.Data = enc
+	}
+
+	byt, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	id := ""
+	if m.ID != (ulid.ULID{}) {
+		id = m.ID.String()
+	}
+	return s.write(id, string(m.Kind), byt)
+}
+
+// write writes a single SSE event and flushes the response.
+//
+// NOTE: this must be called with the lock held.
+func (s *SubscriptionSSE) write(id, event string, data []byte) error {
+	buf := &bytes.Buffer{}
+	if id != "" {
+		fmt.Fprintf(buf, "id: %s\n", id)
+	}
+	fmt.Fprintf(buf, "event: %s\n", event)
+	// Data containing newlines must be split across multiple data fields.
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		fmt.Fprintf(buf, "data: %s\n", line)
+	}
+	buf.WriteString("\n")
+
+	if _, err := s.w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	s.f.Flush()
+	return nil
+}
@@ -0,0 +1,170 @@
+package realtime
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/inngest/inngest/pkg/consts"
+	"github.com/inngest/inngest/pkg/execution/realtime/streamingtypes"
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSEMessage(t *testing.T) {
+	ctx := context.Background()
+
+	b := NewInProcessBroadcaster()
+	s := httptest.NewServer(NewAPI(APIOpts{
+		JWTSecret:   []byte("foo"),
+		Broadcaster: b,
+	}))
+	t.Cleanup(s.Close)
+
+	events := sseConnect(t, s, "", Topic{
+		Kind:    streamingtypes.TopicKindRun,
+		Channel: "user:123",
+		Name:    "ai",
+	})
+
+	<-time.After(100 * time.Millisecond)
+
+	send := Message{
+		Kind:      streamingtypes.MessageKindRun,
+		Data:      json.RawMessage(`"foo"`),
+		CreatedAt: time.Now().Truncate(time.Millisecond).UTC(),
+		Channel:   "user:123",
+		Topic:     "ai",
+		EnvID:     consts.DevServerEnvID,
+	}
+	b.Publish(ctx, send)
+
+	evt := readEventWithin(t, events, time.Second)
+	require.Equal(t, string(streamingtypes.MessageKindRun), evt.event)
+	require.EqualValues(t, send, evt.msg)
+}
+
+func TestSSEReplay(t *testing.T) {
+	ctx := context.Background()
+
+	r := miniredis.RunT(t)
+	pubc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:  []string{r.Addr()},
+		DisableCache: true,
+	})
+	require.NoError(t, err)
+	subc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:  []string{r.Addr()},
+		DisableCache: true,
+	})
+	require.NoError(t, err)
+
+	b := NewRedisBroadcaster(pubc, subc, WithHistory(10, time.Minute))
+	s := httptest.NewServer(NewAPI(APIOpts{
+		JWTSecret:   []byte("foo"),
+		Broadcaster: b,
+	}))
+	t.Cleanup(s.Close)
+
+	topic := Topic{
+		Kind:    streamingtypes.TopicKindRun,
+		Channel: "user:123",
+		Name:    "ai",
+	}
+	publish := func(data string) {
+		msg := streamingtypes.NewMessage(streamingtypes.MessageKindData, data)
+		msg.Channel = "user:123"
+		msg.Topic = "ai"
+		msg.EnvID = consts.DevServerEnvID
+		b.Publish(ctx, msg)
+	}
+
+	// Publish two messages whilst no clients are connected.
+	publish("missed-1")
+	<-time.After(50 * time.Millisecond)
+	publish("missed-2")
+	<-time.After(50 * time.Millisecond)
+
+	since := time.Now().Add(-time.Minute).Format(time.RFC3339Nano)
+	events := sseConnect(t, s, "?since="+since, topic)
+
+	first := readEventWithin(t, events, time.Second)
+	require.Equal(t, `"missed-1"`, string(first.msg.Data))
+	require.Equal(t, first.msg.ID.String(), first.id)
+	second := readEventWithin(t, events, time.Second)
+	require.Equal(t, `"missed-2"`, string(second.msg.Data))
+
+	<-time.After(100 * time.Millisecond)
+	publish("live")
+	live := readEventWithin(t, events, time.Second)
+	require.Equal(t, `"live"`, string(live.msg.Data))
+
+	t.Run("reconnecting with Last-Event-ID replays only later messages", func(t *testing.T) {
+		events := sseConnectWithHeader(t, s, "", http.Header{"Last-Event-ID": []string{second.id}}, topic)
+		evt := readEventWithin(t, events, time.Second)
+		require.Equal(t, `"live"`, string(evt.msg.Data))
+	})
+}
+
+type sseEvent struct {
+	id    string
+	event string
+	msg   Message
+}
+
+func sseConnect(t *testing.T, s *httptest.Server, query string, topic Topic) chan sseEvent {
+	return sseConnectWithHeader(t, s, query, http.Header{}, topic)
+}
+
+func sseConnectWithHeader(t *testing.T, s *httptest.Server, query string, header http.Header, topic Topic) chan sseEvent {
+	jwt, err := newToken(t, s.URL, topic)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+"/realtime/sse"+query, nil)
+	require.NoError(t, err)
+	req.Header = header
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("content-type"))
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	events := make(chan sseEvent, 10)
+	go func() {
+		evt := sseEvent{}
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				evt.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				evt.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				_ = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &evt.msg)
+			case line == "" && evt.event != "":
+				events <- evt
+				evt = sseEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+func readEventWithin(t *testing.T, events chan sseEvent, dur time.Duration) sseEvent {
+	select {
+	case <-time.After(dur):
+		t.Fatalf("didnt receive event within timeout")
+	case evt := <-events:
+		return evt
+	}
+	return sseEvent{}
+}
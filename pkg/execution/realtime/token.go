@@ -8,14 +8,45 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/execution/realtime/streamingtypes"
 	"github.com/oklog/ulid/v2"
 )
 
 const (
 	Issuer        = "rt.inngest.com"
 	DefaultExpiry = time.Minute
+
+	// MaxTokenTopics is the maximum number of topics a single token may grant
+	// access to.
+	MaxTokenTopics = 100
 )
 
+// ValidateTopics ensures that each topic requested for a token is scoped to a
+// specific channel and topic name, so that tokens never grant access to more
+// data than requested.  Topics without a kind default to run topics.
+func ValidateTopics(topics []Topic) error {
+	if len(topics) == 0 {
+		return fmt.Errorf("at least one topic is required")
+	}
+	if len(topics) > MaxTokenTopics {
+		return fmt.Errorf("tokens may only include up to %d topics", MaxTokenTopics)
+	}
+	for n, t := range topics {
+		if t.Kind == "" {
+			topics[n].Kind = streamingtypes.TopicKindRun
+		} else if t.Kind != streamingtypes.TopicKindRun && t.Kind != streamingtypes.TopicKindEvent {
+			return fmt.Errorf("topic %d has an invalid kind: %s", n, t.Kind)
+		}
+		if t.Channel == "" {
+			return fmt.Errorf("topic %d must specify a channel", n)
+		}
+		if t.Name == "" {
+			return fmt.Errorf("topic %d must specify a name", n)
+		}
+	}
+	return nil
+}
+
 type JWTClaims struct {
 	jwt.RegisteredClaims
 	Env    uuid.UUID `json:"env"`
@@ -0,0 +1,26 @@
+package realtime
+
+import (
+	"testing"
+
+	"github.com/inngest/inngest/pkg/execution/realtime/streamingtypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTopics(t *testing.T) {
+	require.ErrorContains(t, ValidateTopics(nil), "at least one topic")
+
+	topics := []Topic{{Channel: "user:1", Name: "updates"}}
+	require.NoError(t, ValidateTopics(topics))
+	require.Equal(t, streamingtypes.TopicKindRun, topics[0].Kind)
+
+	require.ErrorContains(t, ValidateTopics([]Topic{{Name: "updates"}}), "must specify a channel")
+	require.ErrorContains(t, ValidateTopics([]Topic{{Channel: "user:1"}}), "must specify a name")
+	require.ErrorContains(t, ValidateTopics([]Topic{{Kind: "all", Channel: "user:1", Name: "updates"}}), "invalid kind")
+
+	many := make([]Topic, MaxTokenTopics+1)
+	for n := range many {
+		many[n] = Topic{Channel: "user:1", Name: "updates"}
+	}
+	require.ErrorContains(t, ValidateTopics(many), "up to")
+}
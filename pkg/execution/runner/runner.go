@@ -14,6 +14,8 @@ import (
 	"github.com/inngest/inngest/pkg/config"
 	"github.com/inngest/inngest/pkg/consts"
 	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/environment"
 	"github.com/inngest/inngest/pkg/event"
 	"github.com/inngest/inngest/pkg/execution"
 	"github.com/inngest/inngest/pkg/execution/batch"
@@ -114,6 +116,14 @@ func WithPublisher(p pubsub.Publisher) func(s *svc) {
 	}
 }
 
+// WithPublishSubscriber subscribes to events using the given PublishSubscriber,
+// instead of creating one from the event stream config.
+func WithPublishSubscriber(ps pubsub.PublishSubscriber) func(s *svc) {
+	return func(s *svc) {
+		s.pubsub = ps
+	}
+}
+
 func NewService(c config.Config, opts ...Opt) Runner {
 	svc := &svc{config: c}
 	for _, o := range opts {
@@ -157,9 +167,11 @@ func (s *svc) Pre(ctx context.Context) error {
 	var err error
 
 	logger.From(ctx).Info().Str("backend", s.config.Queue.Service.Backend).Msg("starting event stream")
-	s.pubsub, err = pubsub.NewPublishSubscriber(ctx, s.config.EventStream.Service)
-	if err != nil {
-		return err
+	if s.pubsub == nil {
+		s.pubsub, err = pubsub.NewPublishSubscriber(ctx, s.config.EventStream.Service)
+		if err != nil {
+			return err
+		}
 	}
 
 	if s.state == nil {
@@ -261,7 +273,7 @@ func (s *svc) InitializeCrons(ctx context.Context) error {
 					))
 				defer span.End()
 
-				trackedEvent := event.NewOSSTrackedEvent(event.Event{
+				trackedEvent := event.NewOSSTrackedEventInWorkspace(s.functionEnvID(ctx, fn.ID), event.Event{
 					Data: map[string]any{
 						"cron": cron,
 					},
@@ -321,9 +333,10 @@ func (s *svc) StateManager() state.Manager {
 }
 
 func (s *svc) Events(ctx context.Context, eventId string) ([]event.Event, error) {
+	envID := environment.IDFromContext(ctx)
 	if eventId != "" {
 		evt := s.em.EventById(eventId)
-		if evt != nil {
+		if evt != nil && evt.GetWorkspaceID() == envID {
 			return []event.Event{evt.GetEvent()}, nil
 		}
 
@@ -331,9 +344,12 @@ func (s *svc) Events(ctx context.Context, eventId string) ([]event.Event, error)
 	}
 
 	trackedEvents := s.em.Events()
-	evts := make([]event.Event, len(trackedEvents))
-	for i, evt := range trackedEvents {
-		evts[i] = evt.GetEvent()
+	evts := make([]event.Event, 0, len(trackedEvents))
+	for _, evt := range trackedEvents {
+		if evt.GetWorkspaceID() != envID {
+			continue
+		}
+		evts = append(evts, evt.GetEvent())
 	}
 
 	return evts, nil
@@ -366,10 +382,9 @@ func (s *svc) handleMessage(ctx context.Context, m pubsub.Message) error {
 	}
 
 	// Write the event to our CQRS manager for long-term storage.
-	err = s.cqrs.InsertEvent(
-		ctx,
-		cqrs.ConvertFromEvent(tracked.GetInternalID(), tracked.GetEvent()),
-	)
+	evt := cqrs.ConvertFromEvent(tracked.GetInternalID(), tracked.GetEvent())
+	evt.WorkspaceID = tracked.GetWorkspaceID()
+	err = s.cqrs.InsertEvent(ctx, evt)
 	if err != nil {
 		return err
 	}
@@ -428,6 +443,27 @@ func (s *svc) handleMessage(ctx context.Context, m pubsub.Message) error {
 	return errs
 }
 
+// functionEnvID returns the ID of the environment containing the given
+// function, defaulting to the default environment.
+func (s *svc) functionEnvID(ctx context.Context, fnID uuid.UUID) uuid.UUID {
+	fns, err := s.cqrs.GetFunctions(ctx)
+	if err != nil {
+		return consts.DevServerEnvID
+	}
+	var appID uuid.UUID
+	for _, fn := range fns {
+		if fn.ID == fnID {
+			appID = fn.AppID
+			break
+		}
+	}
+	app, err := s.cqrs.GetAppByID(ctx, appID)
+	if err != nil || app.EnvID == uuid.Nil {
+		return consts.DevServerEnvID
+	}
+	return app.EnvID
+}
+
 // FindInvokedFunction is a helper method which loads all available functions, checks
 // the incoming event and returns the function to be invoked via the RPC invoke event,
 // or nil if a function is not being invoked.
@@ -438,7 +474,7 @@ func FindInvokedFunction(ctx context.Context, tracked event.TrackedEvent, fl cqr
 		return nil, nil
 	}
 
-	fns, err := fl.Functions(ctx)
+	fns, err := fl.FunctionsByEnv(ctx, tracked.GetWorkspaceID())
 	if err != nil {
 		return nil, err
 	}
@@ -513,7 +549,7 @@ func (s *svc) functions(ctx context.Context, tracked event.TrackedEvent) error {
 	}()
 
 	// Look up all functions have a trigger that matches the event name, including wildcards.
-	fns, err := s.data.FunctionsByTrigger(ctx, evt.Name)
+	fns, err := s.data.FunctionsByTrigger(ctx, tracked.GetWorkspaceID(), evt.Name)
 	if err != nil {
 		return fmt.Errorf("error loading functions by trigger: %w", err)
 	}
@@ -692,12 +728,53 @@ func (s *svc) initialize(ctx context.Context, fn inngest.Function, evt event.Tra
 		}
 	}
 
+	// Skip duplicate runs of idempotent functions.  This uses the rate limiter
+	// with a limit of one run per idempotency period, recording the skip with
+	// a distinct reason in history.
+	skipReason := enums.SkipReasonNone
+	if s.rl != nil && fn.Idempotency != nil {
+		rl := fn.Idempotency.RateLimit()
+		key, err := ratelimit.RateLimitKey(ctx, fn.ID, rl, evt.GetEvent().Map())
+		switch err {
+		case nil:
+			// Ensure that idempotency keys never conflict with the function's
+			// rate limit keys.
+			duplicate, _, err := s.rl.RateLimit(ctx, "idempotency-"+key, rl)
+			if err != nil {
+				return err
+			}
+			if duplicate {
+				l.Info().Msg("skipping duplicate run of idempotent fn")
+				skipReason = enums.SkipReasonIdempotency
+
+				if evt.GetEvent().IsInvokeEvent() {
+					// Fail the invoker, as with rate limiting, so that it
+					// doesn't wait for a run which never starts.
+					if err := s.executor.InvokeFailHandler(ctx, execution.InvokeFailHandlerOpts{
+						OriginalEvent: evt,
+						Err: map[string]any{
+							"name":    "Error",
+							"message": "invoked function was skipped as a duplicate of an idempotent run",
+						},
+					}); err != nil {
+						l.Error().Err(err).Msg("error handling invoke idempotency skip")
+					}
+				}
+			}
+		case ratelimit.ErrNotRateLimited:
+			// no-op: the key evaluated to false, so the run is not idempotent.
+		default:
+			return err
+		}
+	}
+
 	l.Info().Msg("initializing fn")
 	_, err := Initialize(ctx, InitOpts{
-		appID: appID,
-		fn:    fn,
-		evt:   evt,
-		exec:  s.executor,
+		appID:      appID,
+		fn:         fn,
+		evt:        evt,
+		exec:       s.executor,
+		skipReason: skipReason,
 	})
 	if err == state.ErrIdentifierExists {
 		// This run exists;  do not attempt to recreate it.
@@ -714,6 +791,8 @@ type InitOpts struct {
 	fn    inngest.Function
 	evt   event.TrackedEvent
 	exec  execution.Executor
+	// skipReason skips the run, recording the reason in history.
+	skipReason enums.SkipReason
 }
 
 // Initialize creates a new funciton run identifier for the given workflow and
@@ -748,6 +827,9 @@ func Initialize(ctx context.Context, opts InitOpts) (*sv2.Metadata, error) {
 		Events:         []event.TrackedEvent{tracked},
 		IdempotencyKey: &idempotencyKey,
 		AccountID:      consts.DevServerAccountID,
+		SkipReason:     opts.skipReason,
+		// Skipped runs must be recorded immediately, without debouncing.
+		PreventDebounce: opts.skipReason != enums.SkipReasonNone,
 	})
 
 	switch err {
@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/event"
+	"github.com/inngest/inngest/pkg/execution"
+	"github.com/inngest/inngest/pkg/execution/executor"
+	sv2 "github.com/inngest/inngest/pkg/execution/state/v2"
+	"github.com/inngest/inngest/pkg/inngest"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExecutor records scheduled runs and invoke failures.
+type fakeExecutor struct {
+	execution.Executor
+
+	scheduled []execution.ScheduleRequest
+	failed    []execution.InvokeFailHandlerOpts
+}
+
+func (f *fakeExecutor) Schedule(ctx context.Context, req execution.ScheduleRequest) (*sv2.Metadata, error) {
+	f.scheduled = append(f.scheduled, req)
+	if req.SkipReason != enums.SkipReasonNone {
+		return nil, executor.ErrFunctionSkipped
+	}
+	return &sv2.Metadata{}, nil
+}
+
+func (f *fakeExecutor) InvokeFailHandler(ctx context.Context, opts execution.InvokeFailHandlerOpts) error {
+	f.failed = append(f.failed, opts)
+	return nil
+}
+
+// fakeFunctions returns every function as belonging to the same app.
+type fakeFunctions struct {
+	cqrs.Manager
+	appID uuid.UUID
+}
+
+func (f fakeFunctions) GetFunctionByInternalUUID(ctx context.Context, wsID uuid.UUID, fnID uuid.UUID) (*cqrs.Function, error) {
+	return &cqrs.Function{ID: fnID, AppID: f.appID}, nil
+}
+
+// fakeRateLimiter limits every key after its first use.
+type fakeRateLimiter struct {
+	seen map[string]bool
+}
+
+func (f *fakeRateLimiter) RateLimit(ctx context.Context, key string, c inngest.RateLimit) (bool, time.Duration, error) {
+	limited := f.seen[key]
+	f.seen[key] = true
+	return limited, 0, nil
+}
+
+func TestInitializeIdempotentInvoke(t *testing.T) {
+	ctx := context.Background()
+
+	fn := inngest.Function{
+		ID:          uuid.New(),
+		Name:        "fn",
+		Slug:        "app-fn",
+		Idempotency: &inngest.Idempotency{Key: "event.data.id"},
+	}
+
+	t.Run("it fails the invoker when skipping a duplicate invoke", func(t *testing.T) {
+		exec := &fakeExecutor{}
+		s := &svc{
+			cqrs:     fakeFunctions{appID: uuid.New()},
+			executor: exec,
+			rl:       &fakeRateLimiter{seen: map[string]bool{}},
+		}
+
+		evt := event.NewInvocationEvent(event.NewInvocationEventOpts{
+			Event: event.Event{Data: map[string]any{"id": "1"}},
+			FnID:  fn.Slug,
+		})
+
+		require.NoError(t, s.initialize(ctx, fn, event.NewOSSTrackedEvent(evt, nil)))
+		require.Empty(t, exec.failed)
+
+		require.NoError(t, s.initialize(ctx, fn, event.NewOSSTrackedEvent(evt, nil)))
+		require.Len(t, exec.failed, 1)
+		require.Equal(t, "Error", exec.failed[0].Err["name"])
+
+		// Both runs are still scheduled, with the duplicate recorded as skipped.
+		require.Len(t, exec.scheduled, 2)
+		require.Equal(t, enums.SkipReasonNone, exec.scheduled[0].SkipReason)
+		require.Equal(t, enums.SkipReasonIdempotency, exec.scheduled[1].SkipReason)
+	})
+
+	t.Run("it doesn't fail anything when skipping a duplicate event", func(t *testing.T) {
+		exec := &fakeExecutor{}
+		s := &svc{
+			cqrs:     fakeFunctions{appID: uuid.New()},
+			executor: exec,
+			rl:       &fakeRateLimiter{seen: map[string]bool{}},
+		}
+
+		evt := event.Event{Name: "test/event", Data: map[string]any{"id": "1"}}
+		require.NoError(t, s.initialize(ctx, fn, event.NewOSSTrackedEvent(evt, nil)))
+		require.NoError(t, s.initialize(ctx, fn, event.NewOSSTrackedEvent(evt, nil)))
+		require.Empty(t, exec.failed)
+		require.Equal(t, enums.SkipReasonIdempotency, exec.scheduled[1].SkipReason)
+	})
+}
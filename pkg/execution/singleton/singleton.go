@@ -0,0 +1,95 @@
+// Package singleton manages locks for singleton functions, ensuring that only a
+// single run is in progress for each function and singleton key.
+package singleton
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/rueidis"
+)
+
+const (
+	// replaceScript sets the lock to a new run if the lock is unset or held by
+	// the expected run.
+	replaceScript = `
+local v = redis.call('get', KEYS[1])
+if v ~= false and v ~= ARGV[1] then
+  return 0
+end
+redis.call('set', KEYS[1], ARGV[2])
+return 1
+`
+	// releaseScript deletes the lock if held by the given run.
+	releaseScript = `
+if redis.call('get', KEYS[1]) == ARGV[1] then
+  return redis.call('del', KEYS[1])
+end
+return 0
+`
+)
+
+// Locker manages singleton locks.  Locks are held by a run until the run is
+// finalized, and have no expiry as runs may last for long periods of time.
+type Locker interface {
+	// Acquire attempts to lock the given key for the run.  If the lock is held
+	// by another run, this returns the ID of the run holding the lock.
+	Acquire(ctx context.Context, key string, runID ulid.ULID) (*ulid.ULID, error)
+	// Replace transfers the lock from the expected run to the given run,
+	// returning false if the lock is held by a different run.  This is used
+	// when the expected run is cancelled or no longer exists.
+	Replace(ctx context.Context, key string, expected, runID ulid.ULID) (bool, error)
+	// Release releases the lock if held by the given run.
+	Release(ctx context.Context, key string, runID ulid.ULID) error
+}
+
+// New returns a new Redis-backed Locker, storing locks with the given key prefix.
+func New(r rueidis.Client, prefix string) Locker {
+	return &redisLocker{
+		r:       r,
+		prefix:  prefix,
+		replace: rueidis.NewLuaScript(replaceScript),
+		release: rueidis.NewLuaScript(releaseScript),
+	}
+}
+
+type redisLocker struct {
+	r      rueidis.Client
+	prefix string
+
+	replace *rueidis.Lua
+	release *rueidis.Lua
+}
+
+func (l *redisLocker) Acquire(ctx context.Context, key string, runID ulid.ULID) (*ulid.ULID, error) {
+	cmd := l.r.B().Set().Key(l.prefix + key).Value(runID.String()).Nx().Get().Build()
+	existing, err := l.r.Do(ctx, cmd).ToString()
+	if rueidis.IsRedisNil(err) {
+		// The lock was acquired.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error acquiring singleton lock: %w", err)
+	}
+	held, err := ulid.Parse(existing)
+	if err != nil {
+		return nil, fmt.Errorf("invalid singleton lock value %q: %w", existing, err)
+	}
+	return &held, nil
+}
+
+func (l *redisLocker) Replace(ctx context.Context, key string, expected, runID ulid.ULID) (bool, error) {
+	ok, err := l.replace.Exec(ctx, l.r, []string{l.prefix + key}, []string{expected.String(), runID.String()}).AsInt64()
+	if err != nil {
+		return false, fmt.Errorf("error replacing singleton lock: %w", err)
+	}
+	return ok == 1, nil
+}
+
+func (l *redisLocker) Release(ctx context.Context, key string, runID ulid.ULID) error {
+	if err := l.release.Exec(ctx, l.r, []string{l.prefix + key}, []string{runID.String()}).Error(); err != nil {
+		return fmt.Errorf("error releasing singleton lock: %w", err)
+	}
+	return nil
+}
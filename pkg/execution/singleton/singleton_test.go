@@ -0,0 +1,66 @@
+package singleton
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocker(t *testing.T) {
+	ctx := context.Background()
+	r := miniredis.RunT(t)
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:  []string{r.Addr()},
+		DisableCache: true,
+	})
+	require.NoError(t, err)
+	defer rc.Close()
+
+	l := New(rc, "{singleton}:")
+	a := ulid.MustNew(ulid.Now(), rand.Reader)
+	b := ulid.MustNew(ulid.Now(), rand.Reader)
+
+	held, err := l.Acquire(ctx, "fn-key", a)
+	require.NoError(t, err)
+	require.Nil(t, held)
+
+	t.Run("it returns the run holding the lock", func(t *testing.T) {
+		held, err := l.Acquire(ctx, "fn-key", b)
+		require.NoError(t, err)
+		require.NotNil(t, held)
+		require.Equal(t, a, *held)
+	})
+
+	t.Run("it only releases the lock for the holder", func(t *testing.T) {
+		require.NoError(t, l.Release(ctx, "fn-key", b))
+		held, err := l.Acquire(ctx, "fn-key", b)
+		require.NoError(t, err)
+		require.Equal(t, a, *held)
+	})
+
+	t.Run("it replaces the expected holder", func(t *testing.T) {
+		ok, err := l.Replace(ctx, "fn-key", b, b)
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		ok, err = l.Replace(ctx, "fn-key", a, b)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		held, err := l.Acquire(ctx, "fn-key", a)
+		require.NoError(t, err)
+		require.Equal(t, b, *held)
+	})
+
+	t.Run("it acquires the lock after release", func(t *testing.T) {
+		require.NoError(t, l.Release(ctx, "fn-key", b))
+		held, err := l.Acquire(ctx, "fn-key", a)
+		require.NoError(t, err)
+		require.Nil(t, held)
+	})
+}
@@ -3,12 +3,14 @@ package state
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/inngest/inngest/pkg/consts"
 	"github.com/inngest/inngest/pkg/dateutil"
 	"github.com/inngest/inngest/pkg/enums"
 	"github.com/inngest/inngest/pkg/event"
+	"github.com/inngest/inngest/pkg/inngest"
 	"github.com/inngest/inngest/pkg/util/aigateway"
 	"github.com/inngest/inngest/pkg/util/gateway"
 	"github.com/xhit/go-str2duration/v2"
@@ -167,6 +169,62 @@ func (g GeneratorOpcode) WaitForEventOpts() (*WaitForEventOpts, error) {
 	return opts, nil
 }
 
+func (g GeneratorOpcode) WaitForSignalOpts() (*WaitForSignalOpts, error) {
+	opts := &WaitForSignalOpts{}
+	if err := opts.UnmarshalAny(g.Opts); err != nil {
+		return nil, err
+	}
+	if opts.Signal == "" {
+		return nil, fmt.Errorf("A signal name must be provided when waiting for a signal")
+	}
+	return opts, nil
+}
+
+func (g GeneratorOpcode) WaitForEventsOpts() (*WaitForEventsOpts, error) {
+	if opts, ok := g.Opts.(*WaitForEventsOpts); ok && opts != nil {
+		return opts, nil
+	}
+
+	opts := &WaitForEventsOpts{}
+	if err := opts.UnmarshalAny(g.Opts); err != nil {
+		return nil, err
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// WaitTarget returns the event name and expression that a wait step waits
+// for.  Signal names are returned as the event name, and the event names of a
+// wait for events step are joined by commas.
+func (g GeneratorOpcode) WaitTarget() (string, *string, error) {
+	switch g.Op {
+	case enums.OpcodeWaitForSignal:
+		opts, err := g.WaitForSignalOpts()
+		if err != nil {
+			return "", nil, err
+		}
+		return opts.Signal, nil, nil
+	case enums.OpcodeWaitForEvents:
+		opts, err := g.WaitForEventsOpts()
+		if err != nil {
+			return "", nil, err
+		}
+		names := make([]string, len(opts.Events))
+		for n, m := range opts.Events {
+			names[n] = m.Event
+		}
+		return strings.Join(names, ","), nil, nil
+	default:
+		opts, err := g.WaitForEventOpts()
+		if err != nil {
+			return "", nil, err
+		}
+		return opts.Event, opts.If, nil
+	}
+}
+
 func (g GeneratorOpcode) SleepDuration() (time.Duration, error) {
 	if g.Op != enums.OpcodeSleep {
 		return 0, fmt.Errorf("unable to return sleep duration for opcode %s", g.Op.String())
@@ -272,6 +330,8 @@ func (s *SleepOpts) UnmarshalAny(a any) error {
 type RunOpts struct {
 	Type  string          `json:"type,omitempty"`
 	Input json.RawMessage `json:"input"`
+	// RetryPolicy overrides the function's retry policy for this step.
+	RetryPolicy *inngest.RetryPolicy `json:"retryPolicy,omitempty"`
 }
 
 func (r *RunOpts) UnmarshalAny(a any) error {
@@ -340,6 +400,131 @@ func (w WaitForEventOpts) Expires() (time.Time, error) {
 	return time.Now().Add(dur), nil
 }
 
+// WaitMode determines how many events a wait for events step requires.
+type WaitMode string
+
+const (
+	// WaitModeAll requires an event matching every matcher.
+	WaitModeAll WaitMode = "all"
+	// WaitModeAny requires a single event matching any matcher.
+	WaitModeAny WaitMode = "any"
+	// WaitModeCount requires a number of events matching any matcher.
+	WaitModeCount WaitMode = "count"
+)
+
+// EventMatcher matches a single event within a wait for events step.
+type EventMatcher struct {
+	Event string  `json:"event"`
+	If    *string `json:"if"`
+}
+
+type WaitForEventsOpts struct {
+	Timeout string         `json:"timeout"`
+	Events  []EventMatcher `json:"events"`
+	// Mode is the wait mode, defaulting to WaitModeAll.
+	Mode WaitMode `json:"mode"`
+	// Count is the number of events required when using WaitModeCount.
+	Count int `json:"count"`
+}
+
+func (w *WaitForEventsOpts) UnmarshalAny(a any) error {
+	opts := WaitForEventsOpts{}
+	var mappedByt []byte
+	switch typ := a.(type) {
+	case []byte:
+		mappedByt = typ
+	default:
+		byt, err := json.Marshal(a)
+		if err != nil {
+			return err
+		}
+		mappedByt = byt
+	}
+	if err := json.Unmarshal(mappedByt, &opts); err != nil {
+		return err
+	}
+	*w = opts
+	return nil
+}
+
+func (w WaitForEventsOpts) GetMode() WaitMode {
+	if w.Mode == "" {
+		return WaitModeAll
+	}
+	return w.Mode
+}
+
+func (w WaitForEventsOpts) Validate() error {
+	if len(w.Events) == 0 {
+		return fmt.Errorf("At least one event must be provided when waiting for events")
+	}
+	for _, m := range w.Events {
+		if m.Event == "" {
+			return fmt.Errorf("An event name must be provided for every event when waiting for events")
+		}
+	}
+	switch w.GetMode() {
+	case WaitModeAll, WaitModeAny:
+	case WaitModeCount:
+		if w.Count < 1 {
+			return fmt.Errorf("A count of at least 1 must be provided when waiting for a count of events")
+		}
+	default:
+		return fmt.Errorf("Invalid wait mode '%s': must be one of 'all', 'any' or 'count'", w.Mode)
+	}
+	return nil
+}
+
+func (w WaitForEventsOpts) Expires() (time.Time, error) {
+	if w.Timeout == "" {
+		return time.Now(), nil
+	}
+
+	dur, err := str2duration.ParseDuration(w.Timeout)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(dur), nil
+}
+
+type WaitForSignalOpts struct {
+	// Signal is the unique name of the signal to wait for.
+	Signal  string `json:"signal"`
+	Timeout string `json:"timeout"`
+}
+
+func (w *WaitForSignalOpts) UnmarshalAny(a any) error {
+	opts := WaitForSignalOpts{}
+	var mappedByt []byte
+	switch typ := a.(type) {
+	case []byte:
+		mappedByt = typ
+	default:
+		byt, err := json.Marshal(a)
+		if err != nil {
+			return err
+		}
+		mappedByt = byt
+	}
+	if err := json.Unmarshal(mappedByt, &opts); err != nil {
+		return err
+	}
+	*w = opts
+	return nil
+}
+
+func (w WaitForSignalOpts) Expires() (time.Time, error) {
+	if w.Timeout == "" {
+		return time.Now(), nil
+	}
+
+	dur, err := str2duration.ParseDuration(w.Timeout)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(dur), nil
+}
+
 // GatewayOpts returns the gateway options within the driver.
 func (g *GeneratorOpcode) GatewayOpts() (gateway.Request, error) {
 	req := gateway.Request{}
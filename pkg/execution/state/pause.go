@@ -3,6 +3,7 @@ package state
 import (
 	"context"
 	"regexp"
+	"sort"
 
 	"github.com/google/uuid"
 	"github.com/inngest/inngest/pkg/enums"
@@ -42,6 +43,12 @@ type PauseMutater interface {
 
 	// DeletePause permanently deletes a pause.
 	DeletePause(ctx context.Context, p Pause) error
+
+	// AddMultiWaitEvent atomically records an event matched by a child pause
+	// within the given parent pause of a wait for events step, returning the
+	// updated parent pause.  This returns ErrPauseNotFound if the parent pause
+	// has been consumed.
+	AddMultiWaitEvent(ctx context.Context, parentID uuid.UUID, evt MultiWaitEvent) (*Pause, error)
 }
 
 // PauseGetter allows a runner to return all existing pauses by event or by outgoing ID.  This
@@ -77,6 +84,12 @@ type PauseGetter interface {
 	//
 	// This should not return consumed pauses.
 	PauseByInvokeCorrelationID(ctx context.Context, wsID uuid.UUID, correlationID string) (*Pause, error)
+
+	// PauseBySignalID returns the pause waiting for the given signal, or
+	// ErrSignalPauseNotFound if no run is waiting for the signal.
+	//
+	// This should not return consumed pauses.
+	PauseBySignalID(ctx context.Context, wsID uuid.UUID, signal string) (*Pause, error)
 }
 
 type ConsumePauseResult struct {
@@ -172,6 +185,12 @@ type Pause struct {
 	// This is used to be able to accurately reconstruct the entire invocation
 	// span.
 	InvokeTargetFnID *string `json:"itFnID,omitempty"`
+	// SignalID is the unique signal name that resumes this pause, if the
+	// pause was created by a wait for signal step.
+	SignalID *string `json:"sID,omitempty"`
+	// MultiWait stores the state of a wait for events step, if the pause was
+	// created by a wait for events step.
+	MultiWait *MultiWait `json:"multi,omitempty"`
 	// OnTimeout indicates that this incoming edge should only be ran
 	// when the pause times out, if set to true.
 	OnTimeout bool `json:"onTimeout"`
@@ -246,6 +265,104 @@ func (p Pause) IsInvoke() bool {
 	return p.Opcode != nil && *p.Opcode == enums.OpcodeInvokeFunction.String()
 }
 
+func (p Pause) IsSignal() bool {
+	return p.SignalID != nil && *p.SignalID != ""
+}
+
+// IsMultiWait returns whether this is the parent pause of a wait for events
+// step, which is resumed once enough events are collected.
+func (p Pause) IsMultiWait() bool {
+	return p.MultiWait != nil && p.MultiWait.ParentID == nil
+}
+
+// MultiWait stores the state of a wait for events step.  The step creates a
+// parent pause which collects matched events, plus a child pause for each
+// event matcher which is matched against incoming events.  Child pauses are
+// never consumed directly;  instead, matched events are added to the parent
+// pause which is consumed once enough events are collected.
+type MultiWait struct {
+	// ParentID is the ID of the parent pause.  This is only set on child pauses.
+	ParentID *uuid.UUID `json:"pid,omitempty"`
+	// Index is the index of the event matcher for a child pause.
+	Index int `json:"i,omitempty"`
+
+	// Mode is the wait mode for the step.
+	Mode WaitMode `json:"mode,omitempty"`
+	// Count is the number of events required when using WaitModeCount.
+	Count int `json:"n,omitempty"`
+	// Children stores the IDs of every child pause.
+	Children []uuid.UUID `json:"c,omitempty"`
+	// Events stores the events matched so far.
+	Events []MultiWaitEvent `json:"evts,omitempty"`
+	// Version is incremented every time an event is added, allowing the
+	// parent pause to be updated atomically.
+	Version int `json:"v"`
+}
+
+// MultiWaitEvent is an event matched by a child pause.
+type MultiWaitEvent struct {
+	// Index is the index of the event matcher that matched the event.
+	Index int            `json:"i"`
+	ID    ulid.ULID      `json:"id"`
+	Data  map[string]any `json:"data"`
+}
+
+// Required returns the number of events required to resume the step.
+func (m MultiWait) Required() int {
+	switch m.Mode {
+	case WaitModeAny:
+		return 1
+	case WaitModeCount:
+		return m.Count
+	default:
+		return len(m.Children)
+	}
+}
+
+// Done returns whether enough events have been collected to resume the step.
+func (m MultiWait) Done() bool {
+	return len(m.Events) >= m.Required()
+}
+
+// Add adds a matched event, returning false if the event is not needed.  In
+// WaitModeAll only the first event for each matcher is kept;  otherwise each
+// event is only counted once, even if it matches multiple matchers.
+func (m *MultiWait) Add(evt MultiWaitEvent) bool {
+	if m.Done() {
+		return false
+	}
+	for _, existing := range m.Events {
+		if m.Mode == WaitModeAll {
+			if existing.Index == evt.Index {
+				return false
+			}
+			continue
+		}
+		if existing.ID == evt.ID {
+			return false
+		}
+	}
+	m.Events = append(m.Events, evt)
+	return true
+}
+
+// Result returns the collected events used to resume the step.  In
+// WaitModeAll the events are ordered by matcher;  otherwise the events are
+// in the order they were received.
+func (m MultiWait) Result() []map[string]any {
+	evts := make([]MultiWaitEvent, len(m.Events))
+	copy(evts, m.Events)
+	if m.Mode == WaitModeAll {
+		sort.SliceStable(evts, func(i, j int) bool { return evts[i].Index < evts[j].Index })
+	}
+
+	result := make([]map[string]any, len(evts))
+	for n, e := range evts {
+		result[n] = e.Data
+	}
+	return result
+}
+
 type ResumeData struct {
 	// If non-nil, RunID is the ID of the run that completed to cause this
 	// resume.
@@ -0,0 +1,59 @@
+package state
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiWait(t *testing.T) {
+	a := ulid.MustNew(ulid.Now(), rand.Reader)
+	b := ulid.MustNew(ulid.Now(), rand.Reader)
+	c := ulid.MustNew(ulid.Now(), rand.Reader)
+	children := []uuid.UUID{uuid.New(), uuid.New()}
+
+	t.Run("all mode requires an event for every matcher", func(t *testing.T) {
+		m := MultiWait{Mode: WaitModeAll, Children: children}
+		require.True(t, m.Add(MultiWaitEvent{Index: 1, ID: a, Data: map[string]any{"name": "a"}}))
+		require.False(t, m.Add(MultiWaitEvent{Index: 1, ID: b}), "only the first event for a matcher is kept")
+		require.False(t, m.Done())
+
+		require.True(t, m.Add(MultiWaitEvent{Index: 0, ID: c, Data: map[string]any{"name": "c"}}))
+		require.True(t, m.Done())
+		require.False(t, m.Add(MultiWaitEvent{Index: 0, ID: b}))
+
+		// Events are ordered by matcher.
+		require.Equal(t, []map[string]any{{"name": "c"}, {"name": "a"}}, m.Result())
+	})
+
+	t.Run("any mode requires a single event", func(t *testing.T) {
+		m := MultiWait{Mode: WaitModeAny, Children: children}
+		require.True(t, m.Add(MultiWaitEvent{Index: 1, ID: a}))
+		require.True(t, m.Done())
+		require.Len(t, m.Result(), 1)
+	})
+
+	t.Run("count mode counts each event once", func(t *testing.T) {
+		m := MultiWait{Mode: WaitModeCount, Count: 3, Children: children}
+		require.True(t, m.Add(MultiWaitEvent{Index: 0, ID: a, Data: map[string]any{"name": "a"}}))
+		require.False(t, m.Add(MultiWaitEvent{Index: 1, ID: a}))
+		require.True(t, m.Add(MultiWaitEvent{Index: 0, ID: c, Data: map[string]any{"name": "c"}}))
+		require.False(t, m.Done())
+		require.True(t, m.Add(MultiWaitEvent{Index: 1, ID: b, Data: map[string]any{"name": "b"}}))
+		require.True(t, m.Done())
+
+		// Events are in the order they were received.
+		require.Equal(t, []map[string]any{{"name": "a"}, {"name": "c"}, {"name": "b"}}, m.Result())
+	})
+}
+
+func TestWaitForEventsOptsValidate(t *testing.T) {
+	require.ErrorContains(t, WaitForEventsOpts{}.Validate(), "At least one event")
+	require.ErrorContains(t, WaitForEventsOpts{Events: []EventMatcher{{}}}.Validate(), "An event name must be provided")
+	require.ErrorContains(t, WaitForEventsOpts{Events: []EventMatcher{{Event: "a"}}, Mode: WaitModeCount}.Validate(), "A count of at least 1")
+	require.ErrorContains(t, WaitForEventsOpts{Events: []EventMatcher{{Event: "a"}}, Mode: "some"}.Validate(), "Invalid wait mode")
+	require.NoError(t, WaitForEventsOpts{Events: []EventMatcher{{Event: "a"}, {Event: "b"}}}.Validate())
+}
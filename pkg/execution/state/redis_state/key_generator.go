@@ -111,6 +111,8 @@ func (s runStateKeyGenerator) Pending(ctx context.Context, isSharded bool, ident
 type GlobalKeyGenerator interface {
 	// Invoke returns the key used to store the correlation key associated with invoke functions
 	Invoke(ctx context.Context, wsID uuid.UUID) string
+	// Signal returns the key used to store the pause IDs waiting for each signal
+	Signal(ctx context.Context, wsID uuid.UUID) string
 }
 
 type globalKeyGenerator struct {
@@ -121,6 +123,10 @@ func (u globalKeyGenerator) Invoke(ctx context.Context, wsID uuid.UUID) string {
 	return fmt.Sprintf("{%s}:invoke:%s", u.stateDefaultKey, wsID)
 }
 
+func (u globalKeyGenerator) Signal(ctx context.Context, wsID uuid.UUID) string {
+	return fmt.Sprintf("{%s}:signal:%s", u.stateDefaultKey, wsID)
+}
+
 type QueueKeyGenerator interface {
 	// QueueItem returns the key for the hash containing all items within a
 	// queue for a function.
@@ -350,6 +356,9 @@ type BatchKeyGenerator interface {
 	// BatchMetadata returns the key used to store the metadata related
 	// to a batch
 	BatchMetadata(ctx context.Context, functionId uuid.UUID, batchId ulid.ULID) string
+	// BatchIndex returns the key used to store a sorted set of open batches
+	// for a function, scored by creation time.
+	BatchIndex(ctx context.Context, functionId uuid.UUID) string
 }
 
 type batchKeyGenerator struct {
@@ -384,6 +393,10 @@ func (u batchKeyGenerator) BatchMetadata(ctx context.Context, functionId uuid.UU
 	return fmt.Sprintf("%s:metadata", u.Batch(ctx, functionId, batchID))
 }
 
+func (u batchKeyGenerator) BatchIndex(ctx context.Context, functionId uuid.UUID) string {
+	return fmt.Sprintf("{%s}:workflows:%s:batches", u.PrefixByFunctionId(ctx, u.queueDefaultKey, true, functionId), functionId)
+}
+
 type DebounceKeyGenerator interface {
 	// QueueItem returns the key for the hash containing all items within a
 	// queue for a function.  This is used to check leases on debounce jobs.
@@ -16,6 +16,7 @@ local keyStepsPending = KEYS[4]
 
 local pauseDataKey = ARGV[1] -- used to set data in run state store
 local pauseDataVal = ARGV[2] -- data to set
+local pauseDataSize = tonumber(ARGV[3]) -- size of the data before encryption
 
 if actionKey ~= nil and pauseDataKey ~= "" then
   -- idempotency check: only ever consume a pause once
@@ -26,7 +27,7 @@ if actionKey ~= nil and pauseDataKey ~= "" then
   redis.call("RPUSH", stackKey, pauseDataKey)
   redis.call("HSET", actionKey, pauseDataKey, pauseDataVal)
   redis.call("HINCRBY", keyMetadata, "step_count", 1)
-  redis.call("HINCRBY", keyMetadata, "state_size", #pauseDataVal)
+  redis.call("HINCRBY", keyMetadata, "state_size", pauseDataSize)
   redis.call("SREM", keyStepsPending, pauseDataKey)
 end
 
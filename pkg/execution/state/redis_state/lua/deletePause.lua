@@ -15,9 +15,11 @@ local keyPauseAddIdx = KEYS[5]
 local keyPauseExpIdx = KEYS[6]
 local keyRunPauses   = KEYS[7]
 local keyPausesIdx   = KEYS[8]
+local pauseSignalKey = KEYS[9]
 
 local pauseID       = ARGV[1]
 local invokeCorrelationId = ARGV[2]
+local signal        = ARGV[3]
 
 redis.call("HDEL", pauseEventKey, pauseID)
 redis.call("DEL", pauseKey)
@@ -32,6 +34,11 @@ if invokeCorrelationId ~= false and invokeCorrelationId ~= "" and invokeCorrelat
   redis.call("HDEL", pauseInvokeKey, invokeCorrelationId)
 end
 
+-- Only remove the signal if it still points to this pause.
+if signal ~= false and signal ~= "" and signal ~= nil and redis.call("HGET", pauseSignalKey, signal) == pauseID then
+  redis.call("HDEL", pauseSignalKey, signal)
+end
+
 -- Add an index of when the pause was added.
 redis.call("ZREM", keyPauseAddIdx, pauseID)
 -- Add an index of when the pause expires.  This lets us manually
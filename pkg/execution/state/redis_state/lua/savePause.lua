@@ -3,6 +3,7 @@
 -- Output:
 --   0: Successfully saved pause
 --   1: Pause already exists
+--   2: Another pause is already waiting for the signal
 -- ]]
 
 local pauseKey    = KEYS[1]
@@ -12,6 +13,7 @@ local keyPauseAddIdx = KEYS[4]
 local keyPauseExpIdx = KEYS[5]
 local keyRunPauses   = KEYS[6]
 local keyPausesIdx   = KEYS[7]
+local pauseSignalKey = KEYS[8]
 
 local pause          = ARGV[1]
 local pauseID        = ARGV[2]
@@ -19,7 +21,21 @@ local event          = ARGV[3]
 local invokeCorrelationID = ARGV[4]
 local extendedExpiry = tonumber(ARGV[5])
 local nowUnixSeconds = tonumber(ARGV[6])
+local signal         = ARGV[7]
 
+local hasSignal = signal ~= false and signal ~= "" and signal ~= nil
+if hasSignal then
+	local existingID = redis.call("HGET", pauseSignalKey, signal)
+	if existingID ~= false and existingID ~= pauseID then
+		-- Pause keys share a prefix, ending with the pause ID.  Mappings to
+		-- pauses which expired without being consumed are stale, and are
+		-- overwritten below.
+		local existingKey = string.sub(pauseKey, 1, #pauseKey - #pauseID) .. existingID
+		if redis.call("EXISTS", existingKey) == 1 then
+			return 2
+		end
+	end
+end
 
 if redis.call("SETNX", pauseKey, pause) == 0 then
 	return 1
@@ -47,4 +63,8 @@ if invokeCorrelationID ~= false and invokeCorrelationID ~= "" and invokeCorrelat
 	redis.call("HSETNX", pauseInvokeKey, invokeCorrelationID, pauseID)
 end
 
+if hasSignal then
+	redis.call("HSET", pauseSignalKey, signal, pauseID)
+end
+
 return 0
@@ -18,6 +18,8 @@ local keyStepsPending = KEYS[5]
 
 local stepID = ARGV[1]
 local outputData = ARGV[2]
+local outputSize = tonumber(ARGV[3]) -- size of the output before encryption
+local inputSize = tonumber(ARGV[4])  -- size of any step input before encryption, if encrypted
 
 if redis.call("HEXISTS", keyStep, stepID) == 1 then
   return -1
@@ -26,9 +28,9 @@ end
 -- If we're saving a response for a step that previously had input, remove the
 -- input from the state size in order to keep it as accurate as possible.
 local inputData = redis.call("HGET", keyStepInputs, stepID)
-local stateSizeDelta = #outputData
+local stateSizeDelta = outputSize
 if inputData then
-  stateSizeDelta = stateSizeDelta - #inputData
+  stateSizeDelta = stateSizeDelta - (inputSize or #inputData)
 end
 redis.call("HINCRBY", keyMetadata, "state_size", stateSizeDelta)
 redis.call("HINCRBY", keyMetadata, "step_count", 1)
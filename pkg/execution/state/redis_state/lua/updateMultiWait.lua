@@ -0,0 +1,33 @@
+--[[
+
+Updates the parent pause of a wait for events step if the stored pause has the
+expected version, ensuring that concurrently matched events are never lost.
+
+Output:
+  0: Successfully updated
+  1: Version mismatch
+  2: Pause not found
+
+]]
+
+local pauseKey = KEYS[1]
+
+local expectedVersion = tonumber(ARGV[1])
+local pause           = ARGV[2]
+
+local existing = redis.call("GET", pauseKey)
+if existing == false or existing == nil then
+	return 2
+end
+
+local decoded = cjson.decode(existing)
+local version = 0
+if decoded.multi ~= nil and decoded.multi.v ~= nil then
+	version = tonumber(decoded.multi.v)
+end
+if version ~= expectedVersion then
+	return 1
+end
+
+redis.call("SET", pauseKey, pause, "KEEPTTL")
+return 0
@@ -21,6 +21,7 @@ import (
 	"github.com/inngest/expr"
 	"github.com/inngest/inngest/pkg/config/registration"
 	"github.com/inngest/inngest/pkg/consts"
+	"github.com/inngest/inngest/pkg/encryption"
 	"github.com/inngest/inngest/pkg/enums"
 	osqueue "github.com/inngest/inngest/pkg/execution/queue"
 	"github.com/inngest/inngest/pkg/execution/state"
@@ -181,11 +182,13 @@ func New(ctx context.Context, opts ...Opt) (state.Manager, error) {
 	}
 
 	m.shardedMgr = shardedMgr{
-		s: m.unsafeShardedClientDoNotUse,
+		s:   m.unsafeShardedClientDoNotUse,
+		enc: m.enc,
 	}
 
 	m.unshardedMgr = unshardedMgr{
-		u: m.unsafeUnshardedClientDoNotUse,
+		u:   m.unsafeUnshardedClientDoNotUse,
+		enc: m.enc,
 	}
 
 	return m, nil
@@ -205,7 +208,17 @@ func WithUnshardedClient(u *UnshardedClient) Opt {
 	}
 }
 
+// WithEncryptor encrypts events, step inputs and step outputs at rest using
+// the given encryptor.  Data is decrypted when loaded.
+func WithEncryptor(enc encryption.Encryptor) Opt {
+	return func(m *mgr) {
+		m.enc = enc
+	}
+}
+
 type mgr struct {
+	enc encryption.Encryptor
+
 	// unsafe: Operate on sharded manager instead.
 	unsafeShardedClientDoNotUse *ShardedClient
 
@@ -217,11 +230,141 @@ type mgr struct {
 }
 
 type shardedMgr struct {
-	s *ShardedClient
+	s   *ShardedClient
+	enc encryption.Encryptor
+}
+
+// encrypt encrypts run state prior to storing it, if an encryptor is set.
+func (m shardedMgr) encrypt(ctx context.Context, byt []byte) ([]byte, error) {
+	if m.enc == nil {
+		return byt, nil
+	}
+	return m.enc.Encrypt(ctx, byt)
+}
+
+// decrypt decrypts run state after loading it, if an encryptor is set.
+func (m shardedMgr) decrypt(ctx context.Context, byt []byte) ([]byte, error) {
+	if m.enc == nil {
+		return byt, nil
+	}
+	return m.enc.Decrypt(ctx, byt)
+}
+
+// encryptSteps returns a copy of the given memoized steps with each step's
+// data encrypted.
+func (m shardedMgr) encryptSteps(ctx context.Context, steps []state.MemoizedStep) ([]state.MemoizedStep, error) {
+	if m.enc == nil {
+		return steps, nil
+	}
+	encrypted := make([]state.MemoizedStep, len(steps))
+	for n, step := range steps {
+		byt, err := json.Marshal(step.Data)
+		if err != nil {
+			return nil, err
+		}
+		if byt, err = m.enc.Encrypt(ctx, byt); err != nil {
+			return nil, err
+		}
+		encrypted[n] = state.MemoizedStep{ID: step.ID, Data: json.RawMessage(byt)}
+	}
+	return encrypted, nil
+}
+
+// decryptMap decrypts each value of a map loaded from run state.
+func (m shardedMgr) decryptMap(ctx context.Context, data map[string]string) (map[string]string, error) {
+	if m.enc == nil {
+		return data, nil
+	}
+	for k, v := range data {
+		byt, err := m.enc.Decrypt(ctx, []byte(v))
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting %q: %w", k, err)
+		}
+		data[k] = string(byt)
+	}
+	return data, nil
+}
+
+// storedPause is the stored representation of a pause.  When encryption is
+// enabled, the event data used to evaluate the pause's expression and the
+// events matched by a wait for events step are sealed within Sealed, leaving
+// the fields read by Lua scripts in plaintext.
+type storedPause struct {
+	state.Pause
+	Sealed []byte `json:"enc,omitempty"`
+}
+
+// sealedPauseData is the data sealed within a stored pause.
+type sealedPauseData struct {
+	ExpressionData map[string]any         `json:"data,omitempty"`
+	Events         []state.MultiWaitEvent `json:"evts,omitempty"`
+}
+
+// marshalPause marshals a pause for storage, sealing its event data if an
+// encryptor is set.
+func marshalPause(ctx context.Context, enc encryption.Encryptor, p state.Pause) ([]byte, error) {
+	if enc == nil || (len(p.ExpressionData) == 0 && (p.MultiWait == nil || len(p.MultiWait.Events) == 0)) {
+		return json.Marshal(p)
+	}
+
+	data := sealedPauseData{ExpressionData: p.ExpressionData}
+	p.ExpressionData = nil
+	if p.MultiWait != nil {
+		// Copy the wait so that the caller's pause is left unchanged.
+		mw := *p.MultiWait
+		data.Events, mw.Events = mw.Events, nil
+		p.MultiWait = &mw
+	}
+
+	byt, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := enc.Encrypt(ctx, byt)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting pause: %w", err)
+	}
+	return json.Marshal(storedPause{Pause: p, Sealed: sealed})
+}
+
+// unmarshalPause unmarshals a stored pause, unsealing its event data.
+func unmarshalPause(ctx context.Context, enc encryption.Encryptor, byt []byte) (*state.Pause, error) {
+	stored := storedPause{}
+	if err := json.Unmarshal(byt, &stored); err != nil {
+		return nil, err
+	}
+	if len(stored.Sealed) == 0 {
+		return &stored.Pause, nil
+	}
+	if enc == nil {
+		return nil, fmt.Errorf("pause %s is encrypted, but no encryption keys are configured", stored.ID)
+	}
+
+	byt, err := enc.Decrypt(ctx, stored.Sealed)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting pause: %w", err)
+	}
+	data := sealedPauseData{}
+	if err := json.Unmarshal(byt, &data); err != nil {
+		return nil, err
+	}
+	stored.ExpressionData = data.ExpressionData
+	if stored.MultiWait != nil {
+		stored.MultiWait.Events = data.Events
+	}
+	return &stored.Pause, nil
+}
+
+// marshalledSize returns the size of the given memoized steps when marshalled
+// without encryption.
+func marshalledSize(steps []state.MemoizedStep) (int, error) {
+	byt, err := json.Marshal(steps)
+	return len(byt), err
 }
 
 type unshardedMgr struct {
-	u *UnshardedClient
+	u   *UnshardedClient
+	enc encryption.Encryptor
 }
 
 type CompositePauseID struct {
@@ -259,18 +402,44 @@ func (m shardedMgr) New(ctx context.Context, input state.Input) (state.State, er
 	if err != nil {
 		return nil, err
 	}
+	// State size limits apply to the plaintext, so that enabling encryption
+	// doesn't change which runs exceed them.
+	eventSize := len(events)
+	if events, err = m.encrypt(ctx, events); err != nil {
+		return nil, fmt.Errorf("error encrypting events: %w", err)
+	}
 
-	var stepsByt []byte
+	var (
+		stepsByt  []byte
+		stepsSize int
+	)
 	if len(input.Steps) > 0 {
-		stepsByt, err = json.Marshal(input.Steps)
+		if stepsSize, err = marshalledSize(input.Steps); err != nil {
+			return nil, fmt.Errorf("error storing run state in redis when marshalling steps: %w", err)
+		}
+		steps, err := m.encryptSteps(ctx, input.Steps)
+		if err != nil {
+			return nil, fmt.Errorf("error encrypting steps: %w", err)
+		}
+		stepsByt, err = json.Marshal(steps)
 		if err != nil {
 			return nil, fmt.Errorf("error storing run state in redis when marshalling steps: %w", err)
 		}
 	}
 
-	var stepInputsByt []byte
+	var (
+		stepInputsByt  []byte
+		stepInputsSize int
+	)
 	if len(input.StepInputs) > 0 {
-		stepInputsByt, err = json.Marshal(input.StepInputs)
+		if stepInputsSize, err = marshalledSize(input.StepInputs); err != nil {
+			return nil, fmt.Errorf("error storing run state in redis when marshalling step inputs: %w", err)
+		}
+		stepInputs, err := m.encryptSteps(ctx, input.StepInputs)
+		if err != nil {
+			return nil, fmt.Errorf("error encrypting step inputs: %w", err)
+		}
+		stepInputsByt, err = json.Marshal(stepInputs)
 		if err != nil {
 			return nil, fmt.Errorf("error storing run state in redis when marshalling step inputs: %w", err)
 		}
@@ -284,8 +453,8 @@ func (m shardedMgr) New(ctx context.Context, input state.Input) (state.State, er
 		Context:        input.Context,
 		Status:         enums.RunStatusScheduled,
 		SpanID:         input.SpanID,
-		EventSize:      len(events),
-		StateSize:      len(events) + len(stepsByt) + len(stepInputsByt),
+		EventSize:      eventSize,
+		StateSize:      eventSize + stepsSize + stepInputsSize,
 		StepCount:      len(input.Steps),
 	}
 	if input.RunType != nil {
@@ -499,6 +668,9 @@ func (m shardedMgr) LoadEvents(ctx context.Context, accountId uuid.UUID, fnID uu
 		return client.B().Get().Key(fnRunState.kg.Events(ctx, isSharded, v1id)).Build()
 	}).AsBytes()
 	if err == nil {
+		if byt, err = m.decrypt(ctx, byt); err != nil {
+			return nil, fmt.Errorf("failed to decrypt batch; %w", err)
+		}
 		if err := json.Unmarshal(byt, &events); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal batch; %w", err)
 		}
@@ -541,6 +713,9 @@ func (m shardedMgr) LoadSteps(ctx context.Context, accountId uuid.UUID, fnID uui
 	if err != nil {
 		return nil, fmt.Errorf("failed loading action inputs; %w", err)
 	}
+	if inputMap, err = m.decryptMap(ctx, inputMap); err != nil {
+		return nil, fmt.Errorf("failed decrypting action inputs; %w", err)
+	}
 	for stepID, marshalled := range inputMap {
 		wrapper := map[string]json.RawMessage{
 			"input": json.RawMessage(marshalled),
@@ -559,6 +734,9 @@ func (m shardedMgr) LoadSteps(ctx context.Context, accountId uuid.UUID, fnID uui
 	if err != nil {
 		return nil, fmt.Errorf("failed loading actions; %w", err)
 	}
+	if rmap, err = m.decryptMap(ctx, rmap); err != nil {
+		return nil, fmt.Errorf("failed decrypting actions; %w", err)
+	}
 	for stepID, marshalled := range rmap {
 		steps[stepID] = json.RawMessage(marshalled)
 	}
@@ -594,6 +772,9 @@ func (m shardedMgr) Load(ctx context.Context, accountId uuid.UUID, runID ulid.UL
 			}
 			return nil, fmt.Errorf("failed to get event; %w", err)
 		}
+		if byt, err = m.decrypt(ctx, byt); err != nil {
+			return nil, fmt.Errorf("failed to decrypt event; %w", err)
+		}
 		event := map[string]any{}
 		if err := json.Unmarshal(byt, &event); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal event; %w", err)
@@ -607,6 +788,9 @@ func (m shardedMgr) Load(ctx context.Context, accountId uuid.UUID, runID ulid.UL
 		if err != nil {
 			return nil, fmt.Errorf("failed to get batch; %w", err)
 		}
+		if byt, err = m.decrypt(ctx, byt); err != nil {
+			return nil, fmt.Errorf("failed to decrypt batch; %w", err)
+		}
 		if err := json.Unmarshal(byt, &events); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal batch; %w", err)
 		}
@@ -621,6 +805,9 @@ func (m shardedMgr) Load(ctx context.Context, accountId uuid.UUID, runID ulid.UL
 	if err != nil {
 		return nil, fmt.Errorf("failed loading action inputs; %w", err)
 	}
+	if inputMap, err = m.decryptMap(ctx, inputMap); err != nil {
+		return nil, fmt.Errorf("failed decrypting action inputs; %w", err)
+	}
 	for stepID, marshalled := range inputMap {
 		wrapper := map[string]json.RawMessage{
 			"input": json.RawMessage(marshalled),
@@ -642,6 +829,9 @@ func (m shardedMgr) Load(ctx context.Context, accountId uuid.UUID, runID ulid.UL
 	if err != nil {
 		return nil, fmt.Errorf("failed loading actions; %w", err)
 	}
+	if rmap, err = m.decryptMap(ctx, rmap); err != nil {
+		return nil, fmt.Errorf("failed decrypting actions; %w", err)
+	}
 
 	for stepID, marshalled := range rmap {
 		var data any
@@ -718,7 +908,26 @@ func (m shardedMgr) SaveResponse(ctx context.Context, i state.Identifier, stepID
 		fnRunState.kg.ActionInputs(ctx, isSharded, i),
 		fnRunState.kg.Pending(ctx, isSharded, i),
 	}
-	args := []string{stepID, marshalledOuptut}
+	output, err := m.encrypt(ctx, []byte(marshalledOuptut))
+	if err != nil {
+		return false, fmt.Errorf("error encrypting response: %w", err)
+	}
+
+	// The state size tracks plaintext, so pass the size of any stored step
+	// input, which is removed from the state size, when it's encrypted.
+	inputSize := ""
+	if m.enc != nil {
+		input, err := r.Do(ctx, func(client rueidis.Client) rueidis.Completed {
+			return client.B().Hget().Key(keys[3]).Field(stepID).Build()
+		}).AsBytes()
+		if err != nil && !rueidis.IsRedisNil(err) {
+			return false, fmt.Errorf("error loading step input: %w", err)
+		}
+		if err == nil {
+			inputSize = strconv.Itoa(encryption.PlaintextSize(input))
+		}
+	}
+	args := []string{stepID, string(output), strconv.Itoa(len(marshalledOuptut)), inputSize}
 
 	index, err := retriableScripts["saveResponse"].Exec(
 		redis_telemetry.WithScriptName(ctx, "saveResponse"),
@@ -772,7 +981,7 @@ func (m shardedMgr) SavePending(ctx context.Context, i state.Identifier, pending
 }
 
 func (m unshardedMgr) SavePause(ctx context.Context, p state.Pause) error {
-	packed, err := json.Marshal(p)
+	packed, err := marshalPause(ctx, m.enc, p)
 	if err != nil {
 		return err
 	}
@@ -793,6 +1002,11 @@ func (m unshardedMgr) SavePause(ctx context.Context, p state.Pause) error {
 		corrId = *p.InvokeCorrelationID
 	}
 
+	signal := ""
+	if p.SignalID != nil {
+		signal = *p.SignalID
+	}
+
 	extendedExpiry := time.Until(p.Expires.Time().Add(10 * time.Minute)).Seconds()
 	nowUnixSeconds := time.Now().Unix()
 
@@ -809,6 +1023,7 @@ func (m unshardedMgr) SavePause(ctx context.Context, p state.Pause) error {
 		pause.kg.PauseIndex(ctx, "exp", p.WorkspaceID, evt),
 		pause.kg.RunPauses(ctx, p.Identifier.RunID),
 		pause.kg.GlobalPauseIndex(ctx),
+		global.kg.Signal(ctx, p.WorkspaceID),
 	}
 
 	args, err := StrSlice([]any{
@@ -820,6 +1035,7 @@ func (m unshardedMgr) SavePause(ctx context.Context, p state.Pause) error {
 		// pause by ID for 10 minutes past expiry.
 		int(extendedExpiry),
 		nowUnixSeconds,
+		signal,
 	})
 	if err != nil {
 		return err
@@ -840,6 +1056,8 @@ func (m unshardedMgr) SavePause(ctx context.Context, p state.Pause) error {
 		return nil
 	case 1:
 		return state.ErrPauseAlreadyExists
+	case 2:
+		return state.ErrSignalConflict
 	}
 	return fmt.Errorf("unknown response saving pause: %d", status)
 }
@@ -977,6 +1195,57 @@ func (m unshardedMgr) deletePausesForRun(ctx context.Context, callCtx context.Co
 	return pause.Client().Do(callCtx, pause.Client().B().Del().Key(pause.kg.RunPauses(ctx, i.RunID)).Build()).Error()
 }
 
+func (m unshardedMgr) AddMultiWaitEvent(ctx context.Context, parentID uuid.UUID, evt state.MultiWaitEvent) (*state.Pause, error) {
+	ctx = redis_telemetry.WithScope(redis_telemetry.WithOpName(ctx, "AddMultiWaitEvent"), redis_telemetry.ScopePauses)
+
+	pause := m.u.Pauses()
+
+	// Retry a small number of times, as concurrently matched events may
+	// update the parent pause between reading and writing.
+	for i := 0; i < 10; i++ {
+		p, err := m.PauseByID(ctx, parentID)
+		if err != nil {
+			return nil, err
+		}
+		if p.MultiWait == nil {
+			return nil, fmt.Errorf("pause is not a wait for events pause: %s", parentID)
+		}
+
+		version := p.MultiWait.Version
+		if !p.MultiWait.Add(evt) {
+			return p, nil
+		}
+		p.MultiWait.Version++
+
+		packed, err := marshalPause(ctx, m.enc, *p)
+		if err != nil {
+			return nil, err
+		}
+
+		status, err := scripts["updateMultiWait"].Exec(
+			redis_telemetry.WithScriptName(ctx, "updateMultiWait"),
+			pause.Client(),
+			[]string{pause.kg.Pause(ctx, parentID)},
+			[]string{strconv.Itoa(version), string(packed)},
+		).AsInt64()
+		if err != nil {
+			return nil, fmt.Errorf("error updating wait for events pause: %w", err)
+		}
+
+		switch status {
+		case 0:
+			return p, nil
+		case 1:
+			continue
+		case 2:
+			return nil, state.ErrPauseNotFound
+		default:
+			return nil, fmt.Errorf("unknown response updating wait for events pause: %d", status)
+		}
+	}
+	return nil, fmt.Errorf("unable to update wait for events pause: too many concurrent updates")
+}
+
 func (m unshardedMgr) DeletePauseByID(ctx context.Context, pauseID uuid.UUID) error {
 	// Attempt to fetch this pause.
 	pause, err := m.PauseByID(ctx, pauseID)
@@ -1014,6 +1283,11 @@ func (m unshardedMgr) DeletePause(ctx context.Context, p state.Pause) error {
 		corrId = *p.InvokeCorrelationID
 	}
 
+	signal := ""
+	if p.SignalID != nil {
+		signal = *p.SignalID
+	}
+
 	pauseKey := pause.kg.Pause(ctx, p.ID)
 	pauseStepKey := pause.kg.PauseStep(ctx, p.Identifier, p.Incoming)
 	runPausesKey := pause.kg.RunPauses(ctx, p.Identifier.RunID)
@@ -1028,6 +1302,7 @@ func (m unshardedMgr) DeletePause(ctx context.Context, p state.Pause) error {
 		pause.kg.PauseIndex(ctx, "exp", p.WorkspaceID, evt),
 		runPausesKey,
 		pause.kg.GlobalPauseIndex(ctx),
+		global.kg.Signal(ctx, p.WorkspaceID),
 	}
 
 	status, err := scripts["deletePause"].Exec(
@@ -1037,6 +1312,7 @@ func (m unshardedMgr) DeletePause(ctx context.Context, p state.Pause) error {
 		[]string{
 			p.ID.String(),
 			corrId,
+			signal,
 		},
 	).AsInt64()
 	if err != nil {
@@ -1077,6 +1353,10 @@ func (m shardedMgr) consumePause(ctx context.Context, p *state.Pause, data any)
 	if err != nil {
 		return state.ConsumePauseResult{}, fmt.Errorf("cannot marshal data to store in state: %w", err)
 	}
+	dataSize := len(marshalledData)
+	if marshalledData, err = m.encrypt(ctx, marshalledData); err != nil {
+		return state.ConsumePauseResult{}, fmt.Errorf("cannot encrypt data to store in state: %w", err)
+	}
 
 	keys := []string{
 		fnRunState.kg.Actions(ctx, isSharded, p.Identifier),
@@ -1088,6 +1368,7 @@ func (m shardedMgr) consumePause(ctx context.Context, p *state.Pause, data any)
 	args, err := StrSlice([]any{
 		p.DataKey,
 		string(marshalledData),
+		dataSize,
 	})
 	if err != nil {
 		return state.ConsumePauseResult{}, err
@@ -1135,9 +1416,7 @@ func (m unshardedMgr) PauseByID(ctx context.Context, pauseID uuid.UUID) (*state.
 	if err != nil {
 		return nil, err
 	}
-	pause := &state.Pause{}
-	err = json.Unmarshal([]byte(str), pause)
-	return pause, err
+	return unmarshalPause(ctx, m.enc, []byte(str))
 }
 
 func (m unshardedMgr) PauseByInvokeCorrelationID(ctx context.Context, wsID uuid.UUID, correlationID string) (*state.Pause, error) {
@@ -1161,6 +1440,31 @@ func (m unshardedMgr) PauseByInvokeCorrelationID(ctx context.Context, wsID uuid.
 	return m.PauseByID(ctx, pauseID)
 }
 
+func (m unshardedMgr) PauseBySignalID(ctx context.Context, wsID uuid.UUID, signal string) (*state.Pause, error) {
+	ctx = redis_telemetry.WithScope(redis_telemetry.WithOpName(ctx, "PauseBySignalID"), redis_telemetry.ScopePauses)
+
+	global := m.u.Global()
+	key := global.kg.Signal(ctx, wsID)
+	cmd := global.Client().B().Hget().Key(key).Field(signal).Build()
+	pauseIDstr, err := global.Client().Do(ctx, cmd).ToString()
+	if err == rueidis.Nil {
+		return nil, state.ErrSignalPauseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pauseID, err := uuid.Parse(pauseIDstr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pauseID UUID: %w", err)
+	}
+	pause, err := m.PauseByID(ctx, pauseID)
+	if err == state.ErrPauseNotFound {
+		return nil, state.ErrSignalPauseNotFound
+	}
+	return pause, err
+}
+
 func (m unshardedMgr) PausesByID(ctx context.Context, ids ...uuid.UUID) ([]*state.Pause, error) {
 	ctx = redis_telemetry.WithScope(redis_telemetry.WithOpName(ctx, "PausesByID"), redis_telemetry.ScopePauses)
 
@@ -1191,8 +1495,7 @@ func (m unshardedMgr) PausesByID(ctx context.Context, ids ...uuid.UUID) ([]*stat
 			continue
 		}
 
-		pause := &state.Pause{}
-		err = json.Unmarshal([]byte(item), pause)
+		pause, err := unmarshalPause(ctx, m.enc, []byte(item))
 		if err != nil {
 			merr = errors.Join(merr, err)
 			continue
@@ -1240,9 +1543,7 @@ func (m unshardedMgr) PauseByStep(ctx context.Context, i state.Identifier, actio
 		return nil, err
 	}
 
-	pause := &state.Pause{}
-	err = json.Unmarshal(byt, pause)
-	return pause, err
+	return unmarshalPause(ctx, m.enc, byt)
 }
 
 // PausesByEvent returns all pauses for a given event within a workspace.
@@ -1267,6 +1568,7 @@ func (m unshardedMgr) pausesByEvent(ctx context.Context, workspaceID uuid.UUID,
 		iter := &scanIter{
 			count:          cnt,
 			r:              pauses.Client(),
+			enc:            m.enc,
 			aggregateStart: aggregateStart,
 		}
 		err := iter.init(ctx, key, 1000)
@@ -1275,7 +1577,7 @@ func (m unshardedMgr) pausesByEvent(ctx context.Context, workspaceID uuid.UUID,
 
 	// If there are less than a thousand items, query the keys
 	// for iteration.
-	iter := &bufIter{r: pauses.Client(), aggregateStart: aggregateStart}
+	iter := &bufIter{r: pauses.Client(), enc: m.enc, aggregateStart: aggregateStart}
 	err = iter.init(ctx, key)
 	return iter, err
 }
@@ -1305,6 +1607,7 @@ func (m unshardedMgr) PausesByEventSince(ctx context.Context, workspaceID uuid.U
 
 	iter := &keyIter{
 		r:     pauses.Client(),
+		enc:   m.enc,
 		kf:    pauses.kg,
 		start: start,
 	}
@@ -1369,6 +1672,7 @@ func (m unshardedMgr) LoadEvaluablesSince(ctx context.Context, workspaceID uuid.
 
 type bufIter struct {
 	r     rueidis.Client
+	enc   encryption.Encryptor
 	items []string
 	idx   int64
 
@@ -1413,9 +1717,7 @@ func (i *bufIter) Next(ctx context.Context) bool {
 		return false
 	}
 
-	pause := &state.Pause{}
-	i.err = json.Unmarshal([]byte(i.items[0]), pause)
-	i.val = pause
+	i.val, i.err = unmarshalPause(ctx, i.enc, []byte(i.items[0]))
 	// Remove one from the slice.
 	i.items = i.items[1:]
 	i.idx++
@@ -1435,6 +1737,7 @@ var scanDoneErr = fmt.Errorf("scan done")
 
 type scanIter struct {
 	r   rueidis.Client
+	enc encryption.Encryptor
 	key string
 	// chunk is the size of scans to load in one.
 	chunk int64
@@ -1558,8 +1861,7 @@ func (i *scanIter) Val(ctx context.Context) *state.Pause {
 		return nil
 	}
 
-	pause := &state.Pause{}
-	err := json.Unmarshal([]byte(val), pause)
+	pause, err := unmarshalPause(ctx, i.enc, []byte(val))
 	if err != nil {
 		return nil
 	}
@@ -1678,8 +1980,9 @@ func newRunMetadata(data map[string]string) (*runMetadata, error) {
 
 // keyIter loads all pauses in batches given a list of IDs
 type keyIter struct {
-	r  rueidis.Client
-	kf PauseKeyGenerator
+	r   rueidis.Client
+	enc encryption.Encryptor
+	kf  PauseKeyGenerator
 	// chunk is the size of scans to load in one.
 	chunk int64
 	// keys stores pause IDs to fetch in batches
@@ -1771,8 +2074,7 @@ func (i *keyIter) Val(ctx context.Context) *state.Pause {
 		return nil
 	}
 
-	pause := &state.Pause{}
-	err := json.Unmarshal([]byte(val), pause)
+	pause, err := unmarshalPause(ctx, i.enc, []byte(val))
 	if err != nil {
 		return nil
 	}
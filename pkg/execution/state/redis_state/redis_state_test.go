@@ -5,11 +5,15 @@ import (
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/encryption"
 	"github.com/inngest/inngest/pkg/enums"
 	"github.com/inngest/inngest/pkg/event"
 	"github.com/inngest/inngest/pkg/execution/state"
@@ -101,6 +105,119 @@ func TestStateHarness(t *testing.T) {
 	testharness.CheckState(t, create)
 }
 
+func TestStateHarnessEncrypted(t *testing.T) {
+	ctx := context.Background()
+	r := miniredis.RunT(t)
+
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:  []string{r.Addr()},
+		DisableCache: true,
+	})
+	require.NoError(t, err)
+
+	key, err := encryption.GenerateKey()
+	require.NoError(t, err)
+	byt, err := json.Marshal(encryption.Keyfile{Primary: "test", Keys: map[string]string{"test": key}})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(path, byt, 0600))
+
+	kp, err := encryption.NewKeyfileProvider(ctx, path)
+	require.NoError(t, err)
+
+	unshardedClient := NewUnshardedClient(rc, StateDefaultKey, QueueDefaultKey)
+	shardedClient := NewShardedClient(ShardedClientOpts{
+		UnshardedClient:        unshardedClient,
+		FunctionRunStateClient: rc,
+		BatchClient:            rc,
+		StateDefaultKey:        StateDefaultKey,
+		QueueDefaultKey:        QueueDefaultKey,
+		FnRunIsSharded:         AlwaysShardOnRun,
+	})
+
+	sm, err := New(
+		ctx,
+		WithUnshardedClient(unshardedClient),
+		WithShardedClient(shardedClient),
+		WithEncryptor(encryption.NewEnvelopeEncryptor(kp)),
+	)
+	require.NoError(t, err)
+
+	t.Run("it stores events encrypted", func(t *testing.T) {
+		id := state.Identifier{
+			WorkflowID: uuid.New(),
+			RunID:      ulid.MustNew(ulid.Now(), rand.Reader),
+			AccountID:  uuid.New(),
+		}
+		_, err := sm.New(ctx, state.Input{
+			Identifier:     id,
+			EventBatchData: []map[string]any{{"name": "test/event", "data": map[string]any{"secret": "plaintext-value"}}},
+		})
+		require.NoError(t, err)
+
+		fnRunState := shardedClient.FunctionRunState()
+		stored, err := r.Get(fnRunState.kg.Events(ctx, true, id))
+		require.NoError(t, err)
+		require.NotContains(t, stored, "plaintext-value")
+		require.True(t, encryption.IsEncrypted([]byte(stored)))
+
+		loaded, err := sm.Load(ctx, id.AccountID, id.RunID)
+		require.NoError(t, err)
+		require.Equal(t, "plaintext-value", loaded.Event()["data"].(map[string]any)["secret"])
+		r.FlushAll()
+	})
+
+	t.Run("it measures the plaintext state size", func(t *testing.T) {
+		id := state.Identifier{
+			WorkflowID: uuid.New(),
+			RunID:      ulid.MustNew(ulid.Now(), rand.Reader),
+			AccountID:  uuid.New(),
+		}
+		_, err := sm.New(ctx, state.Input{Identifier: id, EventBatchData: []map[string]any{{"name": "test/event"}}})
+		require.NoError(t, err)
+		_, err = sm.SaveResponse(ctx, id, "step", `{"data":"output"}`)
+		require.NoError(t, err)
+
+		size := r.HGet(shardedClient.FunctionRunState().kg.RunMetadata(ctx, true, id.RunID), "state_size")
+		require.Equal(t, strconv.Itoa(len(`{"data":"output"}`)), size)
+		r.FlushAll()
+	})
+
+	t.Run("it stores pause data encrypted", func(t *testing.T) {
+		p := state.Pause{
+			ID:             uuid.New(),
+			WorkspaceID:    uuid.New(),
+			Identifier:     state.Identifier{RunID: ulid.MustNew(ulid.Now(), rand.Reader), AccountID: uuid.New()},
+			Expires:        state.Time(time.Now().Add(time.Hour)),
+			ExpressionData: map[string]any{"event.data.secret": "plaintext-value"},
+			MultiWait: &state.MultiWait{
+				Mode:   state.WaitModeAll,
+				Events: []state.MultiWaitEvent{{ID: ulid.Make(), Data: map[string]any{"secret": "plaintext-event"}}},
+			},
+		}
+		require.NoError(t, sm.SavePause(ctx, p))
+
+		stored, err := r.Get(unshardedClient.Pauses().kg.Pause(ctx, p.ID))
+		require.NoError(t, err)
+		require.NotContains(t, stored, "plaintext-value")
+		require.NotContains(t, stored, "plaintext-event")
+
+		loaded, err := sm.PauseByID(ctx, p.ID)
+		require.NoError(t, err)
+		require.Equal(t, p.ExpressionData, loaded.ExpressionData)
+		require.Equal(t, "plaintext-event", loaded.MultiWait.Events[0].Data["secret"])
+		r.FlushAll()
+	})
+
+	create := func() (state.Manager, func()) {
+		return sm, func() {
+			r.FlushAll()
+		}
+	}
+
+	testharness.CheckState(t, create)
+}
+
 func TestScanIter(t *testing.T) {
 	ctx := context.Background()
 	redis := miniredis.RunT(t)
@@ -233,3 +350,39 @@ func BenchmarkNew(b *testing.B) {
 	}
 
 }
+
+func TestSavePauseStaleSignal(t *testing.T) {
+	ctx := context.Background()
+	r := miniredis.RunT(t)
+
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:  []string{r.Addr()},
+		DisableCache: true,
+	})
+	require.NoError(t, err)
+
+	unshardedClient := NewUnshardedClient(rc, StateDefaultKey, QueueDefaultKey)
+	sm, err := New(ctx, WithUnshardedClient(unshardedClient))
+	require.NoError(t, err)
+
+	signal := "signal-" + uuid.NewString()
+	pause := state.Pause{
+		ID:         uuid.New(),
+		Identifier: state.Identifier{RunID: ulid.MustNew(ulid.Now(), rand.Reader)},
+		Expires:    state.Time(time.Now().Add(time.Minute)),
+		SignalID:   &signal,
+	}
+	require.NoError(t, sm.SavePause(ctx, pause))
+
+	other := pause
+	other.ID = uuid.New()
+	require.Equal(t, state.ErrSignalConflict, sm.SavePause(ctx, other))
+
+	// The pause expires without being consumed, leaving its signal mapped.
+	r.Del(unshardedClient.Pauses().kg.Pause(ctx, pause.ID))
+
+	require.NoError(t, sm.SavePause(ctx, other))
+	found, err := sm.PauseBySignalID(ctx, other.WorkspaceID, signal)
+	require.NoError(t, err)
+	require.Equal(t, other.ID, found.ID)
+}
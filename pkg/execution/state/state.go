@@ -27,11 +27,13 @@ var (
 	// that doesn't exist within the backing state store.
 	ErrPauseNotFound       = fmt.Errorf("pause not found")
 	ErrInvokePauseNotFound = fmt.Errorf("invoke pause not found")
+	ErrSignalPauseNotFound = fmt.Errorf("signal pause not found")
 	ErrRunNotFound         = fmt.Errorf("run not found in state store")
 	// ErrPauseLeased is returned when attempting to lease a pause that is
 	// already leased by another event.
 	ErrPauseLeased        = fmt.Errorf("pause already leased")
 	ErrPauseAlreadyExists = fmt.Errorf("pause already exists")
+	ErrSignalConflict     = fmt.Errorf("a run is already waiting for this signal")
 	ErrIdentifierExists   = fmt.Errorf("identifier already exists")
 	ErrFunctionCancelled  = fmt.Errorf("function cancelled")
 	ErrFunctionComplete   = fmt.Errorf("function completed")
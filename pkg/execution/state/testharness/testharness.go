@@ -100,6 +100,8 @@ func CheckState(t *testing.T, gen Generator) {
 		"PausesByEvent/Consumed":           checkPausesByEvent_consumed,
 		"PauseByID":                        checkPauseByID,
 		"PausesByID":                       checkPausesByID,
+		"PauseBySignalID":                  checkPauseBySignalID,
+		"AddMultiWaitEvent":                checkAddMultiWaitEvent,
 		"Idempotency":                      checkIdempotency,
 		"SetStatus":                        checkSetStatus,
 		"Cancel":                           checkCancel,
@@ -1326,6 +1328,95 @@ func checkPauseByID(t *testing.T, m state.Manager) {
 	require.Error(t, state.ErrPauseNotFound, err)
 }
 
+func checkPauseBySignalID(t *testing.T, m state.Manager) {
+	ctx := context.Background()
+	s := setup(t, m)
+
+	signal := "signal-" + uuid.NewString()
+	pause := state.Pause{
+		ID:         uuid.New(),
+		Identifier: s.Identifier(),
+		Outgoing:   inngest.TriggerName,
+		Incoming:   w.Steps[0].ID,
+		Expires:    state.Time(time.Now().Add(time.Minute).Truncate(time.Millisecond).UTC()),
+		SignalID:   &signal,
+	}
+	err := m.SavePause(ctx, pause)
+	require.NoError(t, err)
+
+	found, err := m.PauseBySignalID(ctx, pause.WorkspaceID, signal)
+	require.NoError(t, err)
+	require.EqualValues(t, pause, *found)
+
+	// Saving the same pause again is idempotent.
+	err = m.SavePause(ctx, pause)
+	require.Equal(t, state.ErrPauseAlreadyExists, err)
+
+	// Another pause cannot wait for the same signal.
+	other := pause
+	other.ID = uuid.New()
+	err = m.SavePause(ctx, other)
+	require.Equal(t, state.ErrSignalConflict, err)
+
+	_, err = m.ConsumePause(ctx, pause.ID, nil)
+	require.NoError(t, err)
+
+	found, err = m.PauseBySignalID(ctx, pause.WorkspaceID, signal)
+	require.Nil(t, found, "PauseBySignalID should not return consumed pauses")
+	require.Equal(t, state.ErrSignalPauseNotFound, err)
+
+	// The signal can be reused once the pause is consumed.
+	err = m.SavePause(ctx, other)
+	require.NoError(t, err)
+}
+
+func checkAddMultiWaitEvent(t *testing.T, m state.Manager) {
+	ctx := context.Background()
+	s := setup(t, m)
+
+	pause := state.Pause{
+		ID:         uuid.New(),
+		Identifier: s.Identifier(),
+		Outgoing:   inngest.TriggerName,
+		Incoming:   w.Steps[0].ID,
+		Expires:    state.Time(time.Now().Add(time.Minute).Truncate(time.Millisecond).UTC()),
+		MultiWait: &state.MultiWait{
+			Mode:     state.WaitModeCount,
+			Count:    5,
+			Children: []uuid.UUID{uuid.New()},
+		},
+	}
+	err := m.SavePause(ctx, pause)
+	require.NoError(t, err)
+
+	// Concurrently add events, ensuring that no events are lost.
+	wg := sync.WaitGroup{}
+	for n := 0; n < 5; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := m.AddMultiWaitEvent(ctx, pause.ID, state.MultiWaitEvent{
+				ID:   ulid.MustNew(ulid.Now(), rand.Reader),
+				Data: map[string]any{"n": n},
+			})
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	found, err := m.PauseByID(ctx, pause.ID)
+	require.NoError(t, err)
+	require.Len(t, found.MultiWait.Events, 5)
+	require.Equal(t, 5, found.MultiWait.Version)
+	require.True(t, found.MultiWait.Done())
+
+	_, err = m.ConsumePause(ctx, pause.ID, found.MultiWait.Result())
+	require.NoError(t, err)
+
+	_, err = m.AddMultiWaitEvent(ctx, pause.ID, state.MultiWaitEvent{ID: ulid.MustNew(ulid.Now(), rand.Reader)})
+	require.Equal(t, state.ErrPauseNotFound, err)
+}
+
 func checkPausesByID(t *testing.T, m state.Manager) {
 	ctx := context.Background()
 	s := setup(t, m)
@@ -22,6 +22,7 @@ const (
 	traceLinkKey    = "__tracelink"
 	debounceKey     = "__debounce"
 	evtmapKey       = "__evtmap"
+	singletonKey    = "__singleton"
 )
 
 type ID struct {
@@ -250,6 +251,34 @@ func (c *Config) TraceLink() *string {
 	return nil
 }
 
+// SetSingletonKey stores the singleton lock key held by the run, which is
+// released when the run finishes.
+func (c *Config) SetSingletonKey(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.initContext()
+	c.Context[singletonKey] = key
+}
+
+// SingletonKey retrieves the singleton lock key held by the run, if any.
+func (c *Config) SingletonKey() *string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.Context == nil {
+		return nil
+	}
+
+	if v, ok := c.Context[singletonKey]; ok {
+		if key, ok := v.(string); ok {
+			return &key
+		}
+	}
+
+	return nil
+}
+
 func (c *Config) SetDebounceFlag(flag bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -0,0 +1,98 @@
+package expressions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+)
+
+// Explanation is the result of evaluating an expression for debugging, eg.
+// when testing an `if` or `key` expression before deploying a function.
+type Explanation struct {
+	// Result is the value the expression evaluated to, with attributes missing
+	// from the input data treated as null.
+	Result any `json:"result"`
+	// Type is the CEL type of the result, eg. "bool" or "string".
+	Type string `json:"type"`
+	// Residual is the expression remaining after substituting all attributes
+	// present in the input data.  This is a literal when the input contains
+	// every attribute referenced, or a partial expression otherwise, eg. when
+	// testing a wait expression that references `async` with only `event`.
+	Residual string `json:"residual"`
+	// Attributes lists the attributes referenced within the expression.
+	Attributes [][]string `json:"attributes"`
+}
+
+// Explain validates and evaluates the given expression against the input data,
+// returning the result, its type, and the residual expression.
+func Explain(ctx context.Context, expression string, input map[string]any) (*Explanation, error) {
+	if err := Validate(ctx, expression); err != nil {
+		return nil, err
+	}
+
+	e, err := cachedCompile(ctx, expression)
+	if err != nil {
+		return nil, err
+	}
+
+	prog, act, err := program(ctx, e.ast, e.env, NewData(input), true, e.attrs)
+	if err != nil {
+		return nil, err
+	}
+	val, _, err := prog.Eval(act)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidResult, err)
+	}
+	if val == nil {
+		return nil, ErrNoResult
+	}
+
+	ast, err := residual(ctx, e.ast, e.env, input)
+	if err != nil {
+		return nil, fmt.Errorf("error computing residual: %w", err)
+	}
+	res, err := cel.AstToString(ast)
+	if err != nil {
+		return nil, fmt.Errorf("error computing residual: %w", err)
+	}
+
+	return &Explanation{
+		Result:     native(val),
+		Type:       val.Type().TypeName(),
+		Residual:   res,
+		Attributes: e.attrs.FullPaths(),
+	}, nil
+}
+
+// native converts a CEL value to a value which can be marshalled to JSON.
+func native(v ref.Val) any {
+	switch t := v.(type) {
+	case types.Null:
+		return nil
+	case types.Timestamp:
+		return t.Time
+	case types.Duration:
+		return t.Duration.String()
+	case traits.Lister:
+		out := []any{}
+		for _, item := range listValues(t) {
+			out = append(out, native(item))
+		}
+		return out
+	case traits.Mapper:
+		out := map[string]any{}
+		for it := t.Iterator(); it.HasNext() == types.True; {
+			k := it.Next()
+			out[fmt.Sprintf("%v", native(k))] = native(t.Get(k))
+		}
+		return out
+	}
+	if types.IsUnknownOrError(v) {
+		return nil
+	}
+	return v.Value()
+}
@@ -0,0 +1,474 @@
+package expressions
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"math"
+	"net/netip"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/decls"
+	"github.com/google/cel-go/common/functions"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"github.com/xhit/go-str2duration/v2"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	// maxCachedRegexps is the maximum number of compiled regular expressions
+	// cached by regex functions.
+	maxCachedRegexps = 1_000
+	// earthRadiusKm is the mean radius of the earth, used in geo_distance.
+	earthRadiusKm = 6371.0
+)
+
+var (
+	listType = types.NewListType(types.DynType)
+
+	regexpLock  sync.RWMutex
+	regexpCache = map[string]*regexp.Regexp{}
+)
+
+// libraryDeclarations declares the standard library of helper functions available
+// within every expression, in addition to those in celDeclarations.
+func libraryDeclarations() []cel.EnvOption {
+	fn := func(name string, args []*types.Type, result *types.Type) cel.EnvOption {
+		return newFunctionEnvOption(name, decls.Overload(name, args, result))
+	}
+
+	return []cel.EnvOption{
+		// Strings
+		fn("regex_match", []*types.Type{types.StringType, types.StringType}, types.BoolType),
+		fn("regex_extract", []*types.Type{types.StringType, types.StringType}, types.StringType),
+		fn("regex_replace", []*types.Type{types.StringType, types.StringType, types.StringType}, types.StringType),
+		fn("glob_match", []*types.Type{types.StringType, types.StringType}, types.BoolType),
+		fn("trim", []*types.Type{types.StringType}, types.StringType),
+		fn("split", []*types.Type{types.StringType, types.StringType}, types.NewListType(types.StringType)),
+		fn("join", []*types.Type{listType, types.StringType}, types.StringType),
+
+		// Lists and sets
+		fn("has_any", []*types.Type{listType, listType}, types.BoolType),
+		fn("has_all", []*types.Type{listType, listType}, types.BoolType),
+		fn("unique", []*types.Type{listType}, listType),
+		fn("union", []*types.Type{listType, listType}, listType),
+		fn("intersect", []*types.Type{listType, listType}, listType),
+		fn("difference", []*types.Type{listType, listType}, listType),
+
+		// Hashing
+		fn("md5", []*types.Type{types.StringType}, types.StringType),
+		fn("sha1", []*types.Type{types.StringType}, types.StringType),
+		fn("sha256", []*types.Type{types.StringType}, types.StringType),
+		fn("hash_mod", []*types.Type{types.StringType, types.IntType}, types.IntType),
+
+		// Durations
+		fn("parse_duration", []*types.Type{types.StringType}, types.DurationType),
+		fn("duration_seconds", []*types.Type{types.StringType}, types.IntType),
+
+		// Versions
+		fn("semver_compare", []*types.Type{types.StringType, types.StringType}, types.IntType),
+		fn("semver_valid", []*types.Type{types.StringType}, types.BoolType),
+
+		// Networks and geo
+		fn("cidr_match", []*types.Type{types.StringType, types.AnyType}, types.BoolType),
+		fn("geo_distance", []*types.Type{types.AnyType, types.AnyType, types.AnyType, types.AnyType}, types.DoubleType),
+	}
+}
+
+func libraryOverloads() []*functions.Overload {
+	return []*functions.Overload{
+		{
+			Operator: "regex_match",
+			Binary: stringBinary(func(str, pattern string) ref.Val {
+				re, err := compileRegexp(pattern)
+				if err != nil {
+					return types.WrapErr(err)
+				}
+				return types.Bool(re.MatchString(str))
+			}),
+		},
+		{
+			// regex_extract returns the first capture group of the first match,
+			// or the entire match if the pattern has no groups.
+			Operator: "regex_extract",
+			Binary: stringBinary(func(str, pattern string) ref.Val {
+				re, err := compileRegexp(pattern)
+				if err != nil {
+					return types.WrapErr(err)
+				}
+				match := re.FindStringSubmatch(str)
+				switch len(match) {
+				case 0:
+					return types.String("")
+				case 1:
+					return types.String(match[0])
+				default:
+					return types.String(match[1])
+				}
+			}),
+		},
+		{
+			Operator: "regex_replace",
+			Function: func(args ...ref.Val) ref.Val {
+				if len(args) != 3 {
+					return types.NewErr("regex_replace requires 3 arguments")
+				}
+				str, ok1 := args[0].Value().(string)
+				pattern, ok2 := args[1].Value().(string)
+				repl, ok3 := args[2].Value().(string)
+				if !ok1 || !ok2 || !ok3 {
+					return types.NewErr("regex_replace requires string arguments")
+				}
+				re, err := compileRegexp(pattern)
+				if err != nil {
+					return types.WrapErr(err)
+				}
+				return types.String(re.ReplaceAllString(str, repl))
+			},
+		},
+		{
+			Operator: "glob_match",
+			Binary: stringBinary(func(str, pattern string) ref.Val {
+				ok, err := path.Match(pattern, str)
+				if err != nil {
+					return types.NewErr("invalid glob pattern %q", pattern)
+				}
+				return types.Bool(ok)
+			}),
+		},
+		{
+			Operator: "trim",
+			Unary: func(i ref.Val) ref.Val {
+				str, _ := i.Value().(string)
+				return types.String(strings.TrimSpace(str))
+			},
+		},
+		{
+			Operator: "split",
+			Binary: stringBinary(func(str, sep string) ref.Val {
+				return types.NewStringList(types.DefaultTypeAdapter, strings.Split(str, sep))
+			}),
+		},
+		{
+			Operator: "join",
+			Binary: func(lhs, rhs ref.Val) ref.Val {
+				list, ok := lhs.(traits.Lister)
+				sep, sok := rhs.Value().(string)
+				if !ok || !sok {
+					return types.MaybeNoSuchOverloadErr(lhs)
+				}
+				parts := []string{}
+				for _, v := range listValues(list) {
+					str := v.ConvertToType(types.StringType)
+					if types.IsError(str) {
+						return str
+					}
+					parts = append(parts, string(str.(types.String)))
+				}
+				return types.String(strings.Join(parts, sep))
+			},
+		},
+		{
+			Operator: "has_any",
+			Binary: listBinary(func(a, b []ref.Val) ref.Val {
+				for _, v := range b {
+					if contains(a, v) {
+						return types.True
+					}
+				}
+				return types.False
+			}),
+		},
+		{
+			Operator: "has_all",
+			Binary: listBinary(func(a, b []ref.Val) ref.Val {
+				for _, v := range b {
+					if !contains(a, v) {
+						return types.False
+					}
+				}
+				return types.True
+			}),
+		},
+		{
+			Operator: "unique",
+			Unary: func(i ref.Val) ref.Val {
+				list, ok := i.(traits.Lister)
+				if !ok {
+					return types.MaybeNoSuchOverloadErr(i)
+				}
+				return newList(unique(listValues(list)))
+			},
+		},
+		{
+			Operator: "union",
+			Binary: listBinary(func(a, b []ref.Val) ref.Val {
+				return newList(unique(append(append([]ref.Val{}, a...), b...)))
+			}),
+		},
+		{
+			Operator: "intersect",
+			Binary: listBinary(func(a, b []ref.Val) ref.Val {
+				out := []ref.Val{}
+				for _, v := range unique(a) {
+					if contains(b, v) {
+						out = append(out, v)
+					}
+				}
+				return newList(out)
+			}),
+		},
+		{
+			Operator: "difference",
+			Binary: listBinary(func(a, b []ref.Val) ref.Val {
+				out := []ref.Val{}
+				for _, v := range unique(a) {
+					if !contains(b, v) {
+						out = append(out, v)
+					}
+				}
+				return newList(out)
+			}),
+		},
+		{
+			Operator: "md5",
+			Unary: func(i ref.Val) ref.Val {
+				str, _ := i.Value().(string)
+				sum := md5.Sum([]byte(str))
+				return types.String(hex.EncodeToString(sum[:]))
+			},
+		},
+		{
+			Operator: "sha1",
+			Unary: func(i ref.Val) ref.Val {
+				str, _ := i.Value().(string)
+				sum := sha1.Sum([]byte(str))
+				return types.String(hex.EncodeToString(sum[:]))
+			},
+		},
+		{
+			Operator: "sha256",
+			Unary: func(i ref.Val) ref.Val {
+				str, _ := i.Value().(string)
+				sum := sha256.Sum256([]byte(str))
+				return types.String(hex.EncodeToString(sum[:]))
+			},
+		},
+		{
+			// hash_mod consistently buckets a string into [0, n), eg. for
+			// sampling a percentage of events by user ID.
+			Operator: "hash_mod",
+			Binary: func(lhs, rhs ref.Val) ref.Val {
+				str, _ := lhs.Value().(string)
+				n, ok := rhs.(types.Int)
+				if !ok || n < 1 {
+					return types.NewErr("hash_mod requires a positive modulus")
+				}
+				h := fnv.New64a()
+				_, _ = h.Write([]byte(str))
+				return types.Int(h.Sum64() % uint64(n))
+			},
+		},
+		{
+			Operator: "parse_duration",
+			Unary: func(i ref.Val) ref.Val {
+				str, _ := i.Value().(string)
+				d, err := str2duration.ParseDuration(str)
+				if err != nil {
+					return types.NewErr("invalid duration %q", str)
+				}
+				return types.Duration{Duration: d}
+			},
+		},
+		{
+			Operator: "duration_seconds",
+			Unary: func(i ref.Val) ref.Val {
+				str, _ := i.Value().(string)
+				d, err := str2duration.ParseDuration(str)
+				if err != nil {
+					return types.NewErr("invalid duration %q", str)
+				}
+				return types.Int(d.Seconds())
+			},
+		},
+		{
+			Operator: "semver_compare",
+			Binary: stringBinary(func(a, b string) ref.Val {
+				a, b = canonicalSemver(a), canonicalSemver(b)
+				if !semver.IsValid(a) || !semver.IsValid(b) {
+					return types.NewErr("invalid semantic version")
+				}
+				return types.Int(semver.Compare(a, b))
+			}),
+		},
+		{
+			Operator: "semver_valid",
+			Unary: func(i ref.Val) ref.Val {
+				str, _ := i.Value().(string)
+				return types.Bool(semver.IsValid(canonicalSemver(str)))
+			},
+		},
+		{
+			// cidr_match returns whether the IP is within the given CIDR, or
+			// any CIDR within a list.
+			Operator: "cidr_match",
+			Binary: func(lhs, rhs ref.Val) ref.Val {
+				str, _ := lhs.Value().(string)
+				ip, err := netip.ParseAddr(str)
+				if err != nil {
+					return types.False
+				}
+				ip = ip.Unmap()
+
+				cidrs := []ref.Val{rhs}
+				if list, ok := rhs.(traits.Lister); ok {
+					cidrs = listValues(list)
+				}
+				for _, c := range cidrs {
+					str, _ := c.Value().(string)
+					prefix, err := netip.ParsePrefix(str)
+					if err != nil {
+						return types.NewErr("invalid cidr %q", str)
+					}
+					if prefix.Contains(ip) {
+						return types.True
+					}
+				}
+				return types.False
+			},
+		},
+		{
+			// geo_distance returns the great-circle distance in kilometers
+			// between two lat/lon pairs.
+			Operator: "geo_distance",
+			Function: func(args ...ref.Val) ref.Val {
+				if len(args) != 4 {
+					return types.NewErr("geo_distance requires 4 arguments")
+				}
+				coords := make([]float64, 4)
+				for n, a := range args {
+					f, ok := toFloat(a)
+					if !ok {
+						return types.NewErr("geo_distance requires numeric arguments")
+					}
+					coords[n] = f * math.Pi / 180
+				}
+				dlat := coords[2] - coords[0]
+				dlon := coords[3] - coords[1]
+				h := math.Pow(math.Sin(dlat/2), 2) + math.Cos(coords[0])*math.Cos(coords[2])*math.Pow(math.Sin(dlon/2), 2)
+				return types.Double(2 * earthRadiusKm * math.Asin(math.Sqrt(h)))
+			},
+		},
+	}
+}
+
+func stringBinary(f func(a, b string) ref.Val) functions.BinaryOp {
+	return func(lhs, rhs ref.Val) ref.Val {
+		a, ok := lhs.Value().(string)
+		if !ok {
+			return types.MaybeNoSuchOverloadErr(lhs)
+		}
+		b, ok := rhs.Value().(string)
+		if !ok {
+			return types.MaybeNoSuchOverloadErr(rhs)
+		}
+		return f(a, b)
+	}
+}
+
+func listBinary(f func(a, b []ref.Val) ref.Val) functions.BinaryOp {
+	return func(lhs, rhs ref.Val) ref.Val {
+		a, ok := lhs.(traits.Lister)
+		if !ok {
+			return types.MaybeNoSuchOverloadErr(lhs)
+		}
+		b, ok := rhs.(traits.Lister)
+		if !ok {
+			return types.MaybeNoSuchOverloadErr(rhs)
+		}
+		return f(listValues(a), listValues(b))
+	}
+}
+
+func listValues(l traits.Lister) []ref.Val {
+	vals := []ref.Val{}
+	for it := l.Iterator(); it.HasNext() == types.True; {
+		vals = append(vals, it.Next())
+	}
+	return vals
+}
+
+func newList(vals []ref.Val) ref.Val {
+	return types.NewRefValList(types.DefaultTypeAdapter, vals)
+}
+
+func contains(list []ref.Val, v ref.Val) bool {
+	for _, item := range list {
+		if item.Equal(v) == types.True {
+			return true
+		}
+	}
+	return false
+}
+
+func unique(list []ref.Val) []ref.Val {
+	out := []ref.Val{}
+	for _, v := range list {
+		if !contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func toFloat(v ref.Val) (float64, bool) {
+	switch n := v.(type) {
+	case types.Double:
+		return float64(n), true
+	case types.Int:
+		return float64(n), true
+	case types.Uint:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// canonicalSemver prefixes versions with "v", as required by the semver
+// package.
+func canonicalSemver(v string) string {
+	if !strings.HasPrefix(v, "v") {
+		return "v" + v
+	}
+	return v
+}
+
+// compileRegexp compiles the given pattern, caching up to maxCachedRegexps
+// patterns.
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	regexpLock.RLock()
+	re, ok := regexpCache[pattern]
+	regexpLock.RUnlock()
+	if ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	regexpLock.Lock()
+	if len(regexpCache) < maxCachedRegexps {
+		regexpCache[pattern] = re
+	}
+	regexpLock.Unlock()
+	return re, nil
+}
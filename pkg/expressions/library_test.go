@@ -0,0 +1,119 @@
+package expressions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibrary(t *testing.T) {
+	ctx := context.Background()
+	data := map[string]any{
+		"event": map[string]any{
+			"name": "app/user.created",
+			"data": map[string]any{
+				"email":   "tester@example.com",
+				"tags":    []any{"a", "b", "b", "c"},
+				"ip":      "10.1.2.3",
+				"version": "1.12.0",
+				"lat":     51.5074,
+				"lon":     -0.1278,
+			},
+		},
+	}
+
+	tests := []struct {
+		expr     string
+		expected any
+	}{
+		{`regex_match(event.data.email, "^[a-z]+@example\\.com$")`, true},
+		{`regex_extract(event.data.email, "@(.+)$")`, "example.com"},
+		{`regex_replace(event.data.email, "@.+$", "@redacted")`, "tester@redacted"},
+		{`glob_match(event.name, "app/user.*")`, true},
+		{`trim("  hi  ")`, "hi"},
+		{`split(event.name, "/")[1]`, "user.created"},
+		{`join(unique(event.data.tags), ",")`, "a,b,c"},
+		{`has_any(event.data.tags, ["c", "z"])`, true},
+		{`has_any(event.data.tags, ["y", "z"])`, false},
+		{`has_all(event.data.tags, ["a", "c"])`, true},
+		{`has_all(event.data.tags, ["a", "z"])`, false},
+		{`size(union(event.data.tags, ["c", "d"]))`, int64(4)},
+		{`intersect(event.data.tags, ["b", "d"])`, []any{"b"}},
+		{`difference(event.data.tags, ["b"])`, []any{"a", "c"}},
+		{`sha256("inngest")`, "169a73498a428a01a294f2fd52ca24c4694b3b5dbafc6d56bba9ba62e650f21f"},
+		{`md5("inngest")`, "00404e16679faf54a493795ba85e265d"},
+		{`hash_mod(event.data.email, 100) == hash_mod("tester@example.com", 100)`, true},
+		{`duration_seconds("1d2h")`, int64(93600)},
+		{`parse_duration("90m") == duration("1h30m")`, true},
+		{`semver_compare(event.data.version, "1.2.0")`, int64(1)},
+		{`semver_compare("v1.2.0", "1.2.0")`, int64(0)},
+		{`semver_valid("nope")`, false},
+		{`cidr_match(event.data.ip, "10.0.0.0/8")`, true},
+		{`cidr_match(event.data.ip, ["192.168.0.0/16", "172.16.0.0/12"])`, false},
+		{`cidr_match("::ffff:10.0.0.1", "10.0.0.0/8")`, true},
+		{`geo_distance(event.data.lat, event.data.lon, 48.8566, 2.3522) < 350.0`, true},
+	}
+
+	for _, test := range tests {
+		// Ensure that the expression type checks.
+		require.NoError(t, Validate(ctx, test.expr), test.expr)
+
+		ex, err := Explain(ctx, test.expr, data)
+		require.NoError(t, err, test.expr)
+		if test.expected == nil {
+			continue
+		}
+		require.EqualValues(t, test.expected, ex.Result, test.expr)
+	}
+
+	t.Run("it errors with invalid arguments", func(t *testing.T) {
+		for _, expr := range []string{
+			`regex_match("a", "(")`,
+			`semver_compare("a", "1.0.0")`,
+			`cidr_match("10.0.0.1", "nope")`,
+			`hash_mod("a", 0)`,
+		} {
+			_, err := Explain(ctx, expr, data)
+			require.ErrorIs(t, err, ErrInvalidResult, expr)
+		}
+	})
+}
+
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it returns the result and type", func(t *testing.T) {
+		ex, err := Explain(ctx, `event.data.plan == "pro" && event.data.seats > 10`, map[string]any{
+			"event": map[string]any{"data": map[string]any{"plan": "pro", "seats": 12}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, true, ex.Result)
+		require.Equal(t, "bool", ex.Type)
+		require.Equal(t, "true", ex.Residual)
+		require.ElementsMatch(t, [][]string{{"event", "data", "plan"}, {"event", "data", "seats"}}, ex.Attributes)
+	})
+
+	t.Run("it returns the residual with missing data", func(t *testing.T) {
+		ex, err := Explain(ctx, `event.data.id == async.data.id`, map[string]any{
+			"event": map[string]any{"data": map[string]any{"id": "u_1"}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, false, ex.Result)
+		require.Equal(t, `"u_1" == async.data.id`, ex.Residual)
+	})
+
+	t.Run("it returns keys", func(t *testing.T) {
+		ex, err := Explain(ctx, `event.data.user + "-" + string(event.data.n)`, map[string]any{
+			"event": map[string]any{"data": map[string]any{"user": "u_1", "n": 2}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "u_1-2", ex.Result)
+		require.Equal(t, "string", ex.Type)
+	})
+
+	t.Run("it errors with invalid expressions", func(t *testing.T) {
+		_, err := Explain(ctx, `event.data.id ==`, nil)
+		require.ErrorIs(t, err, &CompileError{})
+	})
+}
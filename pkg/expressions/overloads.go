@@ -262,11 +262,11 @@ func celDeclarations() []cel.EnvOption {
 	}
 
 	// return append(filtered, custom...)
-	return custom
+	return append(custom, libraryDeclarations()...)
 }
 
 func celOverloads() []*functions.Overload {
-	return []*functions.Overload{
+	return append(libraryOverloads(), []*functions.Overload{
 		{
 			Operator: "date",
 			Unary: func(i ref.Val) ref.Val {
@@ -356,5 +356,5 @@ func celOverloads() []*functions.Overload {
 				return types.Timestamp{Time: t}
 			},
 		},
-	}
+	}...)
 }
@@ -18,6 +18,14 @@ const (
 
 	HeaderKeySignature = "X-Inngest-Signature"
 
+	// HeaderKeySyncKind requests an in-band sync, where the SDK returns its
+	// registration within the response rather than sending it to the server.
+	// SDKs set this on the response to confirm the kind of sync performed.
+	HeaderKeySyncKind = "X-Inngest-Sync-Kind"
+
+	// HeaderKeyEnv selects the environment for a request by name.
+	HeaderKeyEnv = "X-Inngest-Env"
+
 	HeaderAuthorization = "Authorization"
 	HeaderContentType   = "Content-Type"
 	HeaderUserAgent     = "User-Agent"
@@ -235,6 +235,10 @@ func toRunHistory(item history.History) (*history_reader.RunHistory, error) {
 			Expression: item.Cancel.Expression,
 			UserID:     item.Cancel.UserID,
 		}
+		if item.Cancel.Reason != "" {
+			reason := string(item.Cancel.Reason)
+			cancel.Reason = &reason
+		}
 	}
 
 	historyType, err := enums.HistoryTypeString(item.Type)
@@ -308,6 +308,7 @@ type RunHistoryCancel struct {
 	EventID    *ulid.ULID `json:"eventID"`
 	Expression *string    `json:"expression"`
 	UserID     *uuid.UUID `json:"userID"`
+	Reason     *string    `json:"reason"`
 }
 
 type RunHistoryResult struct {
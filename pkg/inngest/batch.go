@@ -38,6 +38,7 @@ func NewEventBatchConfig(conf map[string]any) (*EventBatchConfig, error) {
 // A batch of events will be invoked if one of the following
 // is fulfilled
 // - The batch is full
+// - The batch reaches its maximum size in bytes
 // - The time to wait is up
 type EventBatchConfig struct {
 	Key *string `json:"key,omitempty"`
@@ -46,6 +47,13 @@ type EventBatchConfig struct {
 	// included in a batch
 	MaxSize int `json:"maxSize"`
 
+	// MaxBytes is the maximum total size of events, in bytes, that
+	// can be included in a batch.  The batch is consumed as soon as
+	// this size is reached, or once the next event would exceed it,
+	// in which case the event starts a new batch.  Zero means there
+	// is no limit.
+	MaxBytes int `json:"maxBytes,omitempty"`
+
 	// Timeout is the maximum number of time the batch will
 	// wait before being consumed.
 	Timeout string `json:"timeout"`
@@ -64,6 +72,13 @@ func (c EventBatchConfig) IsValid(ctx context.Context) error {
 		}
 	}
 
+	if c.MaxBytes < 0 {
+		return syscode.Error{
+			Code:    syscode.CodeBatchSizeInvalid,
+			Message: fmt.Sprintf("batch max bytes cannot be negative: %d", c.MaxBytes),
+		}
+	}
+
 	dur, err := time.ParseDuration(c.Timeout)
 	if err != nil {
 		return syscode.Error{
@@ -126,6 +126,15 @@ func TestEventBatchConfigIsValid(t *testing.T) {
 			},
 			expected: errors.New("batch timeout should be more than 1s"),
 		},
+		{
+			name: "should return error if MaxBytes is negative",
+			config: &EventBatchConfig{
+				MaxSize:  10,
+				MaxBytes: -1,
+				Timeout:  "10s",
+			},
+			expected: errors.New("batch max bytes cannot be negative"),
+		},
 	}
 
 	for _, test := range tests {
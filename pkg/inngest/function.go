@@ -79,6 +79,14 @@ type Function struct {
 	// will never run.
 	RateLimit *RateLimit `json:"rateLimit,omitempty"`
 
+	// Idempotency ensures that the function runs at most once for each unique key
+	// within the idempotency period.  Any duplicate invocations are skipped.
+	Idempotency *Idempotency `json:"idempotency,omitempty"`
+
+	// Singleton ensures that only one run of the function is in progress at a time,
+	// optionally for each unique key.
+	Singleton *Singleton `json:"singleton,omitempty"`
+
 	// Throttle represents a soft rate limit for gating function starts.  Any function runs
 	// over the throttle period will be enqueued in the backlog to run at the next available
 	// time.
@@ -87,6 +95,10 @@ type Function struct {
 	// Cancel specifies cancellation signals for the function
 	Cancel []Cancel `json:"cancel,omitempty"`
 
+	// RetryPolicy configures the delay between retries of the function's steps.
+	// If nil, the default backoff table is used.
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+
 	// Actions represents the actions to take for this function.  If empty, this assumes
 	// that we have a single action specified in the current directory using
 	Steps []Step `json:"steps,omitempty"`
@@ -129,6 +141,63 @@ func (r RateLimit) IsValid(ctx context.Context) error {
 	return nil
 }
 
+// Idempotency represents a function's idempotency configuration.
+type Idempotency struct {
+	// Key is an expression evaluated using the triggering event, eg.
+	// "event.data.order_id".  The function runs at most once for each unique
+	// result within the period.
+	Key string `json:"key"`
+	// Period is how long each key remains idempotent.  This defaults to, and
+	// must be at most, consts.FunctionIdempotencyPeriod.
+	Period *string `json:"period,omitempty"`
+}
+
+// PeriodDuration returns the idempotency period, or the default period if
+// unset or invalid.
+func (i Idempotency) PeriodDuration() time.Duration {
+	if i.Period == nil || *i.Period == "" {
+		return consts.FunctionIdempotencyPeriod
+	}
+	dur, err := str2duration.ParseDuration(*i.Period)
+	if err != nil || dur <= 0 {
+		return consts.FunctionIdempotencyPeriod
+	}
+	return dur
+}
+
+// RateLimit returns the rate limit used to enforce idempotency: a single run per
+// key within the idempotency period.
+func (i Idempotency) RateLimit() RateLimit {
+	key := i.Key
+	return RateLimit{
+		Limit:  1,
+		Period: i.PeriodDuration().String(),
+		Key:    &key,
+	}
+}
+
+func (i Idempotency) IsValid(ctx context.Context) error {
+	if i.Key == "" {
+		return errors.New("idempotency key must be specified")
+	}
+	if err := expressions.Validate(ctx, i.Key); err != nil {
+		return fmt.Errorf("idempotency key is invalid: %w", err)
+	}
+	if i.Period != nil && *i.Period != "" {
+		dur, err := str2duration.ParseDuration(*i.Period)
+		if err != nil {
+			return fmt.Errorf("failed to parse idempotency period: %w", err)
+		}
+		if dur <= 0 {
+			return errors.New("idempotency period must be greater than 0")
+		}
+		if dur > consts.FunctionIdempotencyPeriod {
+			return fmt.Errorf("idempotency period must be less than %s", consts.FunctionIdempotencyPeriod)
+		}
+	}
+	return nil
+}
+
 // DeterministicUUID returns a deterministic V3 UUID based off of the SHA1
 // hash of the function's name.
 func (f *Function) DeterministicUUID() uuid.UUID {
@@ -236,10 +305,43 @@ type Priority struct {
 	Run *string `json:"run"`
 }
 
+// DebounceMode determines when a debounced function runs within the debounce
+// period.
+type DebounceMode string
+
+const (
+	// DebounceModeTrailing runs the function once the debounce period passes
+	// without any new events.  This is the default.
+	DebounceModeTrailing DebounceMode = "trailing"
+	// DebounceModeLeading runs the function immediately on the first event,
+	// suppressing any further events until the debounce period passes without
+	// any new events.
+	DebounceModeLeading DebounceMode = "leading"
+	// DebounceModeBoth runs the function immediately on the first event, then
+	// runs the function again once the debounce period passes if any further
+	// events were received.
+	DebounceModeBoth DebounceMode = "both"
+)
+
 type Debounce struct {
 	Key     *string `json:"key,omitempty"`
 	Period  string  `json:"period"`
 	Timeout *string `json:"timeout,omitempty"`
+	// Mode determines whether the function runs on the leading or trailing
+	// edge of the debounce period, or both.  This defaults to
+	// DebounceModeTrailing.
+	Mode DebounceMode `json:"mode,omitempty"`
+	// UseFirstEvent runs trailing debounces with the first event received
+	// within the debounce period, instead of the last.
+	UseFirstEvent bool `json:"useFirstEvent,omitempty"`
+}
+
+// GetMode returns the debounce mode, defaulting to DebounceModeTrailing.
+func (d Debounce) GetMode() DebounceMode {
+	if d.Mode == "" {
+		return DebounceModeTrailing
+	}
+	return d.Mode
 }
 
 func (d Debounce) TimeoutDuration() *time.Duration {
@@ -344,7 +446,7 @@ func (f Function) Validate(ctx context.Context) error {
 			err = multierror.Append(err, fmt.Errorf("Steps must have a valid URI"))
 		}
 		switch uri.Scheme {
-		case "http", "https", "ws", "wss":
+		case "http", "https", "ws", "wss", "wasm", "wasm+http", "wasm+https":
 			continue
 		default:
 			err = multierror.Append(err, fmt.Errorf("Non-supported step schema: %s", uri.Scheme))
@@ -401,6 +503,12 @@ func (f Function) Validate(ctx context.Context) error {
 		if period > consts.MaxDebouncePeriod {
 			err = multierror.Append(err, fmt.Errorf("The debounce period of '%s' is greater than the max of: %s", f.Debounce.Period, consts.MaxDebouncePeriod))
 		}
+
+		switch f.Debounce.GetMode() {
+		case DebounceModeTrailing, DebounceModeLeading, DebounceModeBoth:
+		default:
+			err = multierror.Append(err, fmt.Errorf("Invalid debounce mode '%s': must be one of 'leading', 'trailing' or 'both'", f.Debounce.Mode))
+		}
 	}
 
 	// Validate rate limit expression
@@ -410,6 +518,27 @@ func (f Function) Validate(ctx context.Context) error {
 		}
 	}
 
+	if f.Idempotency != nil {
+		if idempotencyErr := f.Idempotency.IsValid(ctx); idempotencyErr != nil {
+			err = multierror.Append(err, idempotencyErr)
+		}
+		if f.IsBatchEnabled() {
+			err = multierror.Append(err, fmt.Errorf("A function cannot specify Idempotency and Batch together"))
+		}
+	}
+
+	if f.Singleton != nil {
+		if singletonErr := f.Singleton.IsValid(ctx); singletonErr != nil {
+			err = multierror.Append(err, singletonErr)
+		}
+	}
+
+	if f.RetryPolicy != nil {
+		if retryErr := f.RetryPolicy.IsValid(); retryErr != nil {
+			err = multierror.Append(err, retryErr)
+		}
+	}
+
 	return err
 }
 
@@ -45,6 +45,8 @@ func SchemeDriver(scheme string) string {
 		return "http"
 	case "ws", "wss":
 		return "connect"
+	case "wasm", "wasm+http", "wasm+https":
+		return "wasm"
 	default:
 		return ""
 	}
@@ -119,9 +119,56 @@ func TestValidate(t *testing.T) {
 			require.NotNil(t, err)
 			require.Contains(t, err.Error(), "Functions must contain one step")
 		})
+
+		t.Run("With invalid idempotency", func(t *testing.T) {
+			f := Function{
+				Name: "hi",
+				Triggers: []Trigger{
+					{
+						EventTrigger: &EventTrigger{
+							Event: "fail",
+						},
+					},
+				},
+				Idempotency: &Idempotency{
+					Key:    "event.data.id",
+					Period: strptr("48h"),
+				},
+				Steps: []Step{
+					{
+						ID:   "step",
+						Name: "Function body",
+						URI:  "http://lol/what.xml.api",
+					},
+				},
+			}
+
+			err := f.Validate(context.Background())
+			require.NotNil(t, err)
+			require.Contains(t, err.Error(), "idempotency period must be less than 24h0m0s")
+
+			f.Idempotency = &Idempotency{Key: "event.data.id =="}
+			err = f.Validate(context.Background())
+			require.NotNil(t, err)
+			require.Contains(t, err.Error(), "idempotency key is invalid")
+		})
 	})
 }
 
+func TestIdempotency(t *testing.T) {
+	i := Idempotency{Key: "event.data.id"}
+	require.NoError(t, i.IsValid(context.Background()))
+	require.Equal(t, consts.FunctionIdempotencyPeriod, i.PeriodDuration())
+
+	i.Period = strptr("1h")
+	require.NoError(t, i.IsValid(context.Background()))
+	rl := i.RateLimit()
+	require.EqualValues(t, 1, rl.Limit)
+	require.Equal(t, "1h0m0s", rl.Period)
+	require.Equal(t, "event.data.id", *rl.Key)
+	require.NoError(t, rl.IsValid(context.Background()))
+}
+
 func TestRunPriorityFactor(t *testing.T) {
 	ctx := context.Background()
 	f := Function{}
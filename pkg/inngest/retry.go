@@ -0,0 +1,167 @@
+package inngest
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/inngest/inngest/pkg/backoff"
+	"github.com/xhit/go-str2duration/v2"
+)
+
+// RetryStrategy determines how the delay between retries is calculated.
+type RetryStrategy string
+
+const (
+	// RetryStrategyTable uses the default backoff table, from 15 seconds up to
+	// 2 hours.
+	RetryStrategyTable RetryStrategy = "table"
+	// RetryStrategyExponential doubles the base delay on each retry.
+	RetryStrategyExponential RetryStrategy = "exponential"
+	// RetryStrategyLinear increases the delay by the base delay on each retry.
+	RetryStrategyLinear RetryStrategy = "linear"
+	// RetryStrategyFixed uses the base delay for every retry.
+	RetryStrategyFixed RetryStrategy = "fixed"
+)
+
+// defaultTableJitter is the jitter used by the table strategy if no jitter is
+// specified, matching backoff.TableBackoff.
+const defaultTableJitter = 30 * time.Second
+
+// RetryPolicy configures the delay between retries of a function's steps.  The
+// delay specified by an SDK via a Retry-After header always takes precedence.
+type RetryPolicy struct {
+	// Strategy is the backoff strategy, defaulting to RetryStrategyTable.
+	Strategy RetryStrategy `json:"strategy,omitempty"`
+	// Base is the base delay used by the exponential, linear, and fixed
+	// strategies, eg. "10s".
+	Base *string `json:"base,omitempty"`
+	// Max is the maximum delay between any two attempts.
+	Max *string `json:"max,omitempty"`
+	// Jitter is the maximum random delay added to each retry.  This defaults
+	// to 30 seconds for the table strategy, and no jitter otherwise.
+	Jitter *string `json:"jitter,omitempty"`
+	// MaxDuration is the maximum total delay across all retries of a step.
+	// Once the next retry would exceed this, the step fails permanently.
+	MaxDuration *string `json:"maxDuration,omitempty"`
+}
+
+// GetStrategy returns the retry strategy, defaulting to RetryStrategyTable.
+func (r RetryPolicy) GetStrategy() RetryStrategy {
+	if r.Strategy == "" {
+		return RetryStrategyTable
+	}
+	return r.Strategy
+}
+
+func (r RetryPolicy) IsValid() error {
+	switch r.GetStrategy() {
+	case RetryStrategyTable:
+	case RetryStrategyExponential, RetryStrategyLinear, RetryStrategyFixed:
+		if r.Base == nil {
+			return fmt.Errorf("A retry policy using the '%s' strategy must specify a base delay", r.Strategy)
+		}
+	default:
+		return fmt.Errorf("Invalid retry strategy '%s': must be one of 'table', 'exponential', 'linear' or 'fixed'", r.Strategy)
+	}
+
+	for name, val := range map[string]*string{
+		"base":        r.Base,
+		"max":         r.Max,
+		"jitter":      r.Jitter,
+		"maxDuration": r.MaxDuration,
+	} {
+		if val == nil {
+			continue
+		}
+		dur, err := str2duration.ParseDuration(*val)
+		if err != nil {
+			return fmt.Errorf("The retry policy %s of '%s' is invalid: %w", name, *val, err)
+		}
+		if dur < 0 {
+			return fmt.Errorf("The retry policy %s of '%s' must not be negative", name, *val)
+		}
+	}
+	return nil
+}
+
+// Delay returns the delay before retrying the given attempt, without jitter.
+// Attempts are zero-indexed, so the delay before the first retry is Delay(0).
+// Delays which would overflow a time.Duration are capped at its maximum.
+func (r RetryPolicy) Delay(attempt int) time.Duration {
+	if attempt < 0 {
+		attempt = 0
+	}
+
+	base := parseDuration(r.Base)
+
+	var delay time.Duration
+	switch r.GetStrategy() {
+	case RetryStrategyExponential:
+		delay = base
+		for n := 0; n < attempt && (r.Max == nil || delay < parseDuration(r.Max)); n++ {
+			if delay > math.MaxInt64/2 {
+				delay = math.MaxInt64
+				break
+			}
+			delay *= 2
+		}
+	case RetryStrategyLinear:
+		delay = math.MaxInt64
+		if base == 0 || int64(attempt+1) <= math.MaxInt64/int64(base) {
+			delay = base * time.Duration(attempt+1)
+		}
+	case RetryStrategyFixed:
+		delay = base
+	default:
+		delay = backoff.BackoffTable[min(attempt, len(backoff.BackoffTable)-1)]
+	}
+
+	if r.Max != nil && delay > parseDuration(r.Max) {
+		delay = parseDuration(r.Max)
+	}
+	return delay
+}
+
+// NextRetryAt returns the time to retry the given attempt, including jitter.
+func (r RetryPolicy) NextRetryAt(attempt int) time.Time {
+	at := time.Now().Add(r.Delay(attempt))
+
+	jitter := parseDuration(r.Jitter)
+	if r.Jitter == nil && r.GetStrategy() == RetryStrategyTable {
+		jitter = defaultTableJitter
+	}
+	if jitter > 0 {
+		at = at.Add(time.Duration(rand.Int63n(int64(jitter))))
+	}
+	return at
+}
+
+// Exhausted returns whether retrying the given attempt would exceed the max
+// total retry duration, in which case the step should not be retried.
+func (r RetryPolicy) Exhausted(attempt int) bool {
+	if r.MaxDuration == nil {
+		return false
+	}
+	max := parseDuration(r.MaxDuration)
+
+	var total time.Duration
+	for n := 0; n <= attempt; n++ {
+		// Compare against the remaining duration to avoid overflowing total.
+		delay := r.Delay(n)
+		if delay > max-total {
+			return true
+		}
+		total += delay
+	}
+	return false
+}
+
+func parseDuration(s *string) time.Duration {
+	if s == nil {
+		return 0
+	}
+	dur, _ := str2duration.ParseDuration(*s)
+	return dur
+}
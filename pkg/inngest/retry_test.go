@@ -0,0 +1,87 @@
+package inngest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/inngest/inngest/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		expected []time.Duration
+	}{
+		{
+			name:     "table",
+			policy:   RetryPolicy{},
+			expected: []time.Duration{15 * time.Second, 30 * time.Second, time.Minute},
+		},
+		{
+			name:     "table with max",
+			policy:   RetryPolicy{Max: util.StrPtr("20s")},
+			expected: []time.Duration{15 * time.Second, 20 * time.Second, 20 * time.Second},
+		},
+		{
+			name:     "exponential",
+			policy:   RetryPolicy{Strategy: RetryStrategyExponential, Base: util.StrPtr("1s"), Max: util.StrPtr("5s")},
+			expected: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second},
+		},
+		{
+			name:     "linear",
+			policy:   RetryPolicy{Strategy: RetryStrategyLinear, Base: util.StrPtr("10s")},
+			expected: []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second},
+		},
+		{
+			name:     "fixed",
+			policy:   RetryPolicy{Strategy: RetryStrategyFixed, Base: util.StrPtr("500ms")},
+			expected: []time.Duration{500 * time.Millisecond, 500 * time.Millisecond},
+		},
+	}
+
+	t.Run("it saturates instead of overflowing", func(t *testing.T) {
+		exponential := RetryPolicy{Strategy: RetryStrategyExponential, Base: util.StrPtr("1h")}
+		require.Equal(t, time.Duration(math.MaxInt64), exponential.Delay(100))
+		require.Equal(t, time.Duration(math.MaxInt64), exponential.Delay(math.MaxInt32))
+
+		linear := RetryPolicy{Strategy: RetryStrategyLinear, Base: util.StrPtr("1h")}
+		require.Equal(t, time.Duration(math.MaxInt64), linear.Delay(math.MaxInt-1))
+
+		exhausted := RetryPolicy{Strategy: RetryStrategyExponential, Base: util.StrPtr("1h"), MaxDuration: util.StrPtr("8760h")}
+		require.True(t, exhausted.Exhausted(100))
+	})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.NoError(t, test.policy.IsValid())
+			for attempt, expected := range test.expected {
+				require.Equal(t, expected, test.policy.Delay(attempt), "attempt %d", attempt)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyNextRetryAt(t *testing.T) {
+	p := RetryPolicy{Strategy: RetryStrategyFixed, Base: util.StrPtr("10s"), Jitter: util.StrPtr("5s")}
+	now := time.Now()
+	at := p.NextRetryAt(0)
+	require.WithinRange(t, at, now.Add(10*time.Second), now.Add(16*time.Second))
+}
+
+func TestRetryPolicyExhausted(t *testing.T) {
+	p := RetryPolicy{Strategy: RetryStrategyFixed, Base: util.StrPtr("10s"), MaxDuration: util.StrPtr("25s")}
+	require.False(t, p.Exhausted(0))
+	require.False(t, p.Exhausted(1))
+	require.True(t, p.Exhausted(2))
+
+	require.False(t, RetryPolicy{}.Exhausted(100))
+}
+
+func TestRetryPolicyIsValid(t *testing.T) {
+	require.ErrorContains(t, RetryPolicy{Strategy: "random"}.IsValid(), "Invalid retry strategy")
+	require.ErrorContains(t, RetryPolicy{Strategy: RetryStrategyExponential}.IsValid(), "must specify a base delay")
+	require.ErrorContains(t, RetryPolicy{Max: util.StrPtr("soon")}.IsValid(), "The retry policy max of 'soon' is invalid")
+}
@@ -0,0 +1,69 @@
+package inngest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/expressions"
+	"github.com/inngest/inngest/pkg/util"
+)
+
+// SingletonMode determines how new runs are handled when a singleton function is
+// already running for the same key.
+type SingletonMode string
+
+const (
+	// SingletonModeSkip skips new runs while a run is in progress.
+	SingletonModeSkip SingletonMode = "skip"
+	// SingletonModeCancel cancels the in-progress run, replacing it with the new
+	// run.
+	SingletonModeCancel SingletonMode = "cancel"
+)
+
+// Singleton ensures that only a single run of a function is in progress at any
+// time, optionally for each unique key.  Unlike a concurrency limit of 1, new runs
+// are never queued:  they're either skipped, or replace the in-progress run.
+type Singleton struct {
+	// Key is an optional expression evaluated using the triggering event, eg.
+	// "event.data.user_id".  If unset, the singleton applies to every run.
+	Key *string `json:"key,omitempty"`
+	// Mode determines what happens to new runs when a run is in progress for
+	// the same key.  This defaults to SingletonModeSkip.
+	Mode SingletonMode `json:"mode,omitempty"`
+}
+
+// GetMode returns the singleton mode, defaulting to SingletonModeSkip.
+func (s Singleton) GetMode() SingletonMode {
+	if s.Mode == "" {
+		return SingletonModeSkip
+	}
+	return s.Mode
+}
+
+func (s Singleton) IsValid(ctx context.Context) error {
+	switch s.GetMode() {
+	case SingletonModeSkip, SingletonModeCancel:
+	default:
+		return fmt.Errorf("Invalid singleton mode '%s': must be one of 'skip' or 'cancel'", s.Mode)
+	}
+	if s.Key != nil {
+		if err := expressions.Validate(ctx, *s.Key); err != nil {
+			return fmt.Errorf("Invalid singleton key '%s': %w", *s.Key, err)
+		}
+	}
+	return nil
+}
+
+// Evaluate returns the singleton key for the given function and input event.
+func (s Singleton) Evaluate(ctx context.Context, fnID uuid.UUID, input map[string]any) (string, error) {
+	if s.Key == nil {
+		return fnID.String(), nil
+	}
+	// The input data is always wrapped in an event variable, for event.data.foo
+	val, _, err := expressions.Evaluate(ctx, *s.Key, map[string]any{"event": input})
+	if err != nil {
+		return "", fmt.Errorf("error evaluating singleton key '%s': %w", *s.Key, err)
+	}
+	return fmt.Sprintf("%s-%s", fnID, util.XXHash(val)), nil
+}
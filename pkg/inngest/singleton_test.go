@@ -0,0 +1,49 @@
+package inngest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSingleton(t *testing.T) {
+	ctx := context.Background()
+	fnID := uuid.New()
+
+	t.Run("it validates the mode and key", func(t *testing.T) {
+		require.NoError(t, Singleton{}.IsValid(ctx))
+		require.Equal(t, SingletonModeSkip, Singleton{}.GetMode())
+		require.NoError(t, Singleton{Key: strptr("event.data.user_id"), Mode: SingletonModeCancel}.IsValid(ctx))
+		require.ErrorContains(t, Singleton{Mode: "queue"}.IsValid(ctx), "Invalid singleton mode")
+		require.ErrorContains(t, Singleton{Key: strptr("event.data.user_id ==")}.IsValid(ctx), "Invalid singleton key")
+	})
+
+	t.Run("it evaluates keys per function", func(t *testing.T) {
+		key, err := Singleton{}.Evaluate(ctx, fnID, nil)
+		require.NoError(t, err)
+		require.Equal(t, fnID.String(), key)
+
+		evaluate := func(s Singleton, fnID uuid.UUID, userID string) string {
+			key, err := s.Evaluate(ctx, fnID, map[string]any{"data": map[string]any{"user_id": userID}})
+			require.NoError(t, err)
+			return key
+		}
+
+		s := Singleton{Key: strptr("event.data.user_id")}
+		a := evaluate(s, fnID, "a")
+		require.NotEqual(t, a, evaluate(s, fnID, "b"))
+		require.Equal(t, a, evaluate(s, fnID, "a"))
+		require.NotEqual(t, a, evaluate(s, uuid.New(), "a"))
+
+		// Keys for events without the field are still evaluated.
+		_, err = s.Evaluate(ctx, fnID, map[string]any{"data": map[string]any{}})
+		require.NoError(t, err)
+	})
+
+	t.Run("it returns expression errors", func(t *testing.T) {
+		_, err := Singleton{Key: strptr("event.data.user_id ==")}.Evaluate(ctx, fnID, nil)
+		require.ErrorContains(t, err, "error evaluating singleton key")
+	})
+}
@@ -0,0 +1,220 @@
+// Package keyring holds the signing and event keys used by self-hosted
+// services, allowing keys to be rotated without downtime.
+//
+// Signing keys consist of a primary key and zero or more fallback keys.
+// Outbound requests are always signed with the primary key, whereas inbound
+// SDK responses and worker handshakes are accepted when signed with any active
+// key.  Event keys have no primary: every active event key is accepted.
+package keyring
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/inngest/inngest/pkg/logger"
+	"github.com/inngest/inngestgo"
+)
+
+var prefixRegexp = regexp.MustCompile(`^signkey-\w+-`)
+
+// New returns a new keyring with the given primary signing key, fallback
+// signing keys, and event keys.  Empty keys are ignored.
+func New(signingKey string, fallbackKeys []string, eventKeys []string) *Keyring {
+	k := &Keyring{}
+	k.signing, k.event = normalize(signingKey, fallbackKeys, eventKeys)
+	return k
+}
+
+// Keyring stores the active signing and event keys.  It's safe for concurrent
+// use, and keys may be updated at any time via Update.
+type Keyring struct {
+	mu sync.RWMutex
+	// signing lists all active signing keys, with the primary key first.
+	signing []string
+	// event lists all active event keys.
+	event []string
+}
+
+// SigningKey returns the primary signing key, used to sign outgoing requests.
+// This returns an empty string if no signing keys are configured.
+func (k *Keyring) SigningKey() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if len(k.signing) == 0 {
+		return ""
+	}
+	return k.signing[0]
+}
+
+// SigningKeys returns all active signing keys, with the primary key first.
+func (k *Keyring) SigningKeys() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return slices.Clone(k.signing)
+}
+
+// EventKeys returns all active event keys.
+func (k *Keyring) EventKeys() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return slices.Clone(k.event)
+}
+
+// HasSigningKey returns whether a primary signing key is configured.
+func (k *Keyring) HasSigningKey() bool {
+	return k.SigningKey() != ""
+}
+
+// HasEventKeys returns whether any event keys are configured.
+func (k *Keyring) HasEventKeys() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.event) > 0
+}
+
+// ValidEventKey returns whether the given key matches any active event key.
+func (k *Keyring) ValidEventKey(key string) bool {
+	for _, ek := range k.EventKeys() {
+		if subtle.ConstantTimeCompare([]byte(ek), []byte(key)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidHashedSigningKey returns whether the given hashed signing key, as sent by
+// SDKs and connect workers in the Authorization header, matches any active
+// signing key.
+func (k *Keyring) ValidHashedSigningKey(hashed string) bool {
+	for _, sk := range k.SigningKeys() {
+		h, err := HashSigningKey(sk)
+		if err != nil {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hashed)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidResponseSignature returns whether the given SDK response signature is
+// valid for the body under any active signing key.
+func (k *Keyring) ValidResponseSignature(ctx context.Context, sig string, body []byte) bool {
+	for _, sk := range k.SigningKeys() {
+		if ok, _ := inngestgo.ValidateResponseSignature(ctx, sig, []byte(sk), body); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Update replaces the active keys, logging any rotation of the primary signing
+// key and any added or removed keys.  Keys are only logged via fingerprints.
+func (k *Keyring) Update(ctx context.Context, signingKey string, fallbackKeys []string, eventKeys []string) {
+	signing, event := normalize(signingKey, fallbackKeys, eventKeys)
+
+	k.mu.Lock()
+	prevSigning, prevEvent := k.signing, k.event
+	k.signing, k.event = signing, event
+	k.mu.Unlock()
+
+	l := logger.From(ctx)
+
+	var prevPrimary, primary string
+	if len(prevSigning) > 0 {
+		prevPrimary = prevSigning[0]
+	}
+	if len(signing) > 0 {
+		primary = signing[0]
+	}
+	if prevPrimary != primary {
+		l.Info().
+			Str("previous", Fingerprint(prevPrimary)).
+			Str("primary", Fingerprint(primary)).
+			Msg("rotated primary signing key")
+	}
+
+	for _, key := range added(prevSigning, signing) {
+		l.Info().Str("key", Fingerprint(key)).Msg("added signing key")
+	}
+	for _, key := range added(signing, prevSigning) {
+		l.Info().Str("key", Fingerprint(key)).Msg("removed signing key")
+	}
+	for _, key := range added(prevEvent, event) {
+		l.Info().Str("key", Fingerprint(key)).Msg("added event key")
+	}
+	for _, key := range added(event, prevEvent) {
+		l.Info().Str("key", Fingerprint(key)).Msg("removed event key")
+	}
+}
+
+// HashSigningKey hashes the given signing key in the same manner as SDKs,
+// retaining any "signkey-*-" prefix.
+func HashSigningKey(key string) (string, error) {
+	prefix := prefixRegexp.FindString(key)
+	decoded, err := hex.DecodeString(prefixRegexp.ReplaceAllString(key, ""))
+	if err != nil {
+		return "", fmt.Errorf("error decoding signing key: %w", err)
+	}
+	sum := sha256.Sum256(decoded)
+	return prefix + hex.EncodeToString(sum[:]), nil
+}
+
+// DeriveSecret derives a secret for the given purpose, eg. signing realtime
+// tokens, from a signing key.  This allows services to use independent secrets
+// without each being configured separately:  secrets derived for different
+// purposes are unrelated, and don't reveal the signing key.
+func DeriveSecret(signingKey, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	_, _ = mac.Write([]byte("inngest/" + purpose))
+	return mac.Sum(nil)
+}
+
+// Fingerprint returns a short, non-reversible identifier for a key, safe for
+// use within logs.
+func Fingerprint(key string) string {
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func normalize(signingKey string, fallbackKeys []string, eventKeys []string) ([]string, []string) {
+	signing := []string{}
+	if signingKey != "" {
+		signing = append(signing, signingKey)
+	}
+	for _, key := range fallbackKeys {
+		if key != "" && !slices.Contains(signing, key) {
+			signing = append(signing, key)
+		}
+	}
+
+	event := []string{}
+	for _, key := range eventKeys {
+		if key != "" && !slices.Contains(event, key) {
+			event = append(event, key)
+		}
+	}
+	return signing, event
+}
+
+// added returns the keys in next which aren't in prev.
+func added(prev, next []string) []string {
+	result := []string{}
+	for _, key := range next {
+		if !slices.Contains(prev, key) {
+			result = append(result, key)
+		}
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+package keyring
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/inngest/inngestgo"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	oldKey = "signkey-test-6c6f6e672d6c6976652d746865206f6c64206b6579"
+	newKey = "signkey-test-7468652d6e65772d6b65792d69732d68657265"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	ctx := context.Background()
+	k := New(oldKey, nil, []string{"event-1"})
+
+	require.Equal(t, oldKey, k.SigningKey())
+	require.True(t, k.ValidEventKey("event-1"))
+	require.False(t, k.ValidEventKey("event-2"))
+
+	// Rotate both keys, keeping the old keys active as fallbacks.
+	k.Update(ctx, newKey, []string{oldKey}, []string{"event-2", "event-1"})
+	require.Equal(t, newKey, k.SigningKey())
+	require.Equal(t, []string{newKey, oldKey}, k.SigningKeys())
+	require.True(t, k.ValidEventKey("event-1"))
+	require.True(t, k.ValidEventKey("event-2"))
+
+	t.Run("it accepts worker keys hashed with any active key", func(t *testing.T) {
+		for _, key := range []string{oldKey, newKey} {
+			hashed, err := HashSigningKey(key)
+			require.NoError(t, err)
+			require.Contains(t, hashed, "signkey-test-")
+			require.True(t, k.ValidHashedSigningKey(hashed))
+		}
+		require.False(t, k.ValidHashedSigningKey("signkey-test-abc"))
+		require.False(t, k.ValidHashedSigningKey(""))
+	})
+
+	t.Run("it accepts responses signed with any active key", func(t *testing.T) {
+		body := []byte(`{"ok":true}`)
+		for _, key := range []string{oldKey, newKey} {
+			sig, err := inngestgo.Sign(ctx, time.Now(), []byte(key), body)
+			require.NoError(t, err)
+			require.True(t, k.ValidResponseSignature(ctx, sig, body))
+		}
+
+		sig, err := inngestgo.Sign(ctx, time.Now(), []byte("signkey-test-0123"), body)
+		require.NoError(t, err)
+		require.False(t, k.ValidResponseSignature(ctx, sig, body))
+	})
+
+	t.Run("it rejects keys once fallbacks are removed", func(t *testing.T) {
+		k.Update(ctx, newKey, nil, []string{"event-2"})
+		require.Equal(t, []string{newKey}, k.SigningKeys())
+		require.False(t, k.ValidEventKey("event-1"))
+
+		hashed, err := HashSigningKey(oldKey)
+		require.NoError(t, err)
+		require.False(t, k.ValidHashedSigningKey(hashed))
+	})
+}
+
+func TestDeriveSecret(t *testing.T) {
+	realtime := DeriveSecret(oldKey, "realtime")
+	require.Len(t, realtime, 32)
+	require.Equal(t, realtime, DeriveSecret(oldKey, "realtime"))
+	require.NotEqual(t, realtime, DeriveSecret(oldKey, "connect"))
+	require.NotEqual(t, realtime, DeriveSecret(newKey, "realtime"))
+}
@@ -0,0 +1,178 @@
+package lite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/inngest/inngest/pkg/logger"
+	"github.com/inngest/inngest/pkg/service"
+)
+
+const healthCheckTimeout = 5 * time.Second
+
+// health reports the liveness and readiness of a process.  A process is ready
+// when each of its dependencies is reachable and it isn't draining.
+type health struct {
+	role     Role
+	checks   map[string]func(ctx context.Context) error
+	draining atomic.Bool
+}
+
+func newHealth(role Role, checks map[string]func(ctx context.Context) error) *health {
+	return &health{role: role, checks: checks}
+}
+
+// drain marks the process as draining, failing readiness checks so that load
+// balancers stop routing new traffic to the process.
+func (h *health) drain() {
+	h.draining.Store(true)
+}
+
+// Router returns a handler serving "/health" for liveness checks and "/ready"
+// for readiness checks.
+func (h *health) Router() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/health", h.live)
+	r.Get("/ready", h.ready)
+	return r
+}
+
+func (h *health) live(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, map[string]any{"status": "ok", "role": h.role})
+}
+
+func (h *health) ready(w http.ResponseWriter, r *http.Request) {
+	if h.draining.Load() {
+		writeHealth(w, http.StatusServiceUnavailable, map[string]any{"status": "draining", "role": h.role})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	status, failed := http.StatusOK, map[string]string{}
+	for name, check := range h.checks {
+		if err := check(ctx); err != nil {
+			status = http.StatusServiceUnavailable
+			failed[name] = err.Error()
+		}
+	}
+	if status != http.StatusOK {
+		writeHealth(w, status, map[string]any{"status": "unavailable", "role": h.role, "errors": failed})
+		return
+	}
+	writeHealth(w, status, map[string]any{"status": "ok", "role": h.role})
+}
+
+func writeHealth(w http.ResponseWriter, status int, body map[string]any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// healthService serves health endpoints for roles which don't run the API.
+type healthService struct {
+	health *health
+	addr   string
+	// drainPeriod is how long health endpoints continue to be served after a
+	// shutdown signal.
+	drainPeriod time.Duration
+	server      *http.Server
+}
+
+func (h *healthService) Name() string {
+	return "health"
+}
+
+func (h *healthService) Pre(ctx context.Context) error {
+	h.server = &http.Server{
+		Addr:    h.addr,
+		Handler: h.health.Router(),
+	}
+	return nil
+}
+
+func (h *healthService) Run(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		logger.StdlibLogger(ctx).Info("starting health server", "addr", h.addr, "role", h.health.role)
+		errCh <- h.server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("error serving health endpoints: %w", err)
+	case <-ctx.Done():
+	}
+
+	// Continue reporting that we're draining while other services stop.
+	h.health.drain()
+	<-time.After(h.drainPeriod)
+	return nil
+}
+
+func (h *healthService) RunTimeout() time.Duration {
+	return h.drainPeriod + healthCheckTimeout
+}
+
+func (h *healthService) Stop(ctx context.Context) error {
+	if h.server == nil {
+		return nil
+	}
+	return h.server.Shutdown(ctx)
+}
+
+// drainService wraps a service which receives traffic, eg. the API.  After a
+// shutdown signal readiness checks fail immediately, but the service continues
+// to run for the drain period, allowing load balancers to stop routing traffic
+// to the process before the service stops.
+type drainService struct {
+	service.Service
+	health      *health
+	drainPeriod time.Duration
+}
+
+func (d drainService) Run(ctx context.Context) error {
+	// Run the service with a context which isn't cancelled by the shutdown
+	// signal, so that it continues to serve requests while draining.
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- d.Service.Run(runCtx)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	d.health.drain()
+	if d.drainPeriod > 0 {
+		logger.StdlibLogger(ctx).Info("draining", "service", d.Name(), "period", d.drainPeriod)
+		select {
+		case err := <-errCh:
+			return err
+		case <-time.After(d.drainPeriod):
+		}
+	}
+
+	cancel()
+	return <-errCh
+}
+
+func (d drainService) RunTimeout() time.Duration {
+	// Allow the default run timeout once draining completes.
+	return d.drainPeriod + 30*time.Second
+}
@@ -0,0 +1,81 @@
+package lite
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/inngest/inngest/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRole(t *testing.T) {
+	inmemory := config.Config{}
+	inmemory.EventStream.Service.Set(config.InMemoryMessaging{Topic: "events"})
+	nats := config.Config{}
+	nats.EventStream.Service.Set(config.NATSMessaging{Topic: "events", ServerURL: "nats://localhost:4222"})
+
+	require.NoError(t, validateRole(StartOpts{Role: RoleAll, Config: inmemory}))
+	require.ErrorContains(t, validateRole(StartOpts{Role: "worker"}), "invalid role 'worker'")
+	require.ErrorContains(t, validateRole(StartOpts{Role: RoleExecutor, Config: nats}), "--redis-uri is required")
+	require.ErrorContains(t, validateRole(StartOpts{Role: RoleAPI, Config: inmemory, RedisURI: "redis://redis"}), "in-memory event stream")
+	require.NoError(t, validateRole(StartOpts{Role: RoleRunner, Config: nats, RedisURI: "redis://redis"}))
+}
+
+func TestHealth(t *testing.T) {
+	var redisErr error
+	h := newHealth(RoleExecutor, map[string]func(ctx context.Context) error{
+		"redis": func(ctx context.Context) error { return redisErr },
+	})
+
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		h.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	require.Equal(t, 200, get("/health"))
+	require.Equal(t, 200, get("/ready"))
+
+	redisErr = errors.New("connection refused")
+	require.Equal(t, 503, get("/ready"))
+	require.Equal(t, 200, get("/health"))
+
+	redisErr = nil
+	h.drain()
+	require.Equal(t, 503, get("/ready"))
+	require.Equal(t, 200, get("/health"))
+}
+
+type blockingService struct {
+	stopped chan time.Time
+}
+
+func (blockingService) Name() string                   { return "blocking" }
+func (blockingService) Pre(ctx context.Context) error  { return nil }
+func (blockingService) Stop(ctx context.Context) error { return nil }
+func (b blockingService) Run(ctx context.Context) error {
+	<-ctx.Done()
+	b.stopped <- time.Now()
+	return nil
+}
+
+func TestDrainService(t *testing.T) {
+	h := newHealth(RoleAPI, nil)
+	svc := blockingService{stopped: make(chan time.Time, 1)}
+	d := drainService{Service: svc, health: h, drainPeriod: 200 * time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- d.Run(ctx) }()
+
+	cancelled := time.Now()
+	cancel()
+	require.NoError(t, <-errCh)
+	require.True(t, h.draining.Load())
+	// The wrapped service runs until the drain period ends.
+	require.GreaterOrEqual(t, (<-svc.stopped).Sub(cancelled), 200*time.Millisecond)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
+	"path/filepath"
 	"time"
 
 	"github.com/inngest/inngest/pkg/connect"
@@ -33,6 +35,9 @@ import (
 	"github.com/inngest/inngest/pkg/cqrs/base_cqrs"
 	"github.com/inngest/inngest/pkg/deploy"
 	"github.com/inngest/inngest/pkg/devserver"
+	"github.com/inngest/inngest/pkg/devserver/wal"
+	"github.com/inngest/inngest/pkg/encryption"
+	"github.com/inngest/inngest/pkg/environment"
 	"github.com/inngest/inngest/pkg/event"
 	"github.com/inngest/inngest/pkg/execution"
 	"github.com/inngest/inngest/pkg/execution/batch"
@@ -43,14 +48,18 @@ import (
 	"github.com/inngest/inngest/pkg/execution/history"
 	"github.com/inngest/inngest/pkg/execution/queue"
 	"github.com/inngest/inngest/pkg/execution/ratelimit"
+	"github.com/inngest/inngest/pkg/execution/realtime"
 	"github.com/inngest/inngest/pkg/execution/runner"
+	"github.com/inngest/inngest/pkg/execution/singleton"
 	"github.com/inngest/inngest/pkg/execution/state"
 	"github.com/inngest/inngest/pkg/execution/state/redis_state"
 	sv2 "github.com/inngest/inngest/pkg/execution/state/v2"
 	"github.com/inngest/inngest/pkg/expressions"
 	"github.com/inngest/inngest/pkg/headers"
+	"github.com/inngest/inngest/pkg/keyring"
 	"github.com/inngest/inngest/pkg/logger"
 	"github.com/inngest/inngest/pkg/pubsub"
+	"github.com/inngest/inngest/pkg/rbac"
 	"github.com/inngest/inngest/pkg/run"
 	"github.com/inngest/inngest/pkg/service"
 	itrace "github.com/inngest/inngest/pkg/telemetry/trace"
@@ -79,11 +88,61 @@ type StartOpts struct {
 	SigningKey string `json:"signing_key"`
 	SQLiteDir  string `json:"sqlite-dir"`
 
+	// SigningKeyFallback lists previous signing keys which are still
+	// accepted when validating SDK responses and worker connections, allowing
+	// the signing key to be rotated without downtime.
+	SigningKeyFallback []string `json:"signing_key_fallback"`
+
 	// EventKey is used to authorize incoming events, ensuring they match the
 	// given key.
 	EventKey []string `json:"event_key"`
 
+	// RealtimeSecret signs realtime subscription tokens.  If empty, a secret
+	// is derived from the signing key.
+	RealtimeSecret string `json:"-"`
+	// ConnectSecret signs connect worker session tokens, verified by the
+	// connect gateway.  If empty, a secret is derived from the signing key.
+	ConnectSecret string `json:"-"`
+
 	ConnectGatewayPort int `json:"connect-gateway-port"`
+
+	// EncryptionKeyfile is the path to a keyfile used to encrypt event and
+	// step data at rest.  If empty, data is stored in plaintext.
+	EncryptionKeyfile string `json:"encryption-keyfile"`
+	// EncryptionReencrypt re-encrypts all stored history using the primary
+	// key within the keyfile on startup, eg. after rotating keys.
+	EncryptionReencrypt bool `json:"encryption-reencrypt"`
+
+	// Keyring, if set, stores the active signing and event keys and is used
+	// in place of SigningKey, SigningKeyFallback and EventKey.  Updating the
+	// keyring rotates keys without a restart.
+	Keyring *keyring.Keyring `json:"-"`
+	// Environments, if set, stores named environments in addition to the
+	// default environment, which uses Keyring.  Each environment has its own
+	// keys, apps and history.
+	Environments *environment.Registry `json:"-"`
+
+	// Auth configures authentication and role-based access for the UI,
+	// GraphQL and REST APIs.  If no auth methods are configured, all requests
+	// are allowed.
+	Auth rbac.Config `json:"-"`
+	// EnableDebug exposes pprof profiling endpoints at /debug.  When auth is
+	// enabled, these require the admin role.
+	EnableDebug bool `json:"enable-debug"`
+
+	// RedisWALFsync configures how often the embedded Redis's write-ahead
+	// log is synced to disk: "always", "everysec" or "no".  Defaults to
+	// "everysec".  This is unused with an external Redis.
+	RedisWALFsync string `json:"redis-wal-fsync"`
+
+	// Role selects the services to run in this process.  Defaults to RoleAll.
+	Role Role `json:"role"`
+	// DrainPeriod is how long the API continues to serve requests after a
+	// shutdown signal, while readiness checks fail.
+	DrainPeriod time.Duration `json:"drain-period"`
+	// APIURL is the URL of the API, used by the connect gateway to sync apps.
+	// Defaults to the host and port of this process.
+	APIURL string `json:"api-url"`
 }
 
 // Create and start a new dev server.  The dev server is used during (surprise surprise)
@@ -97,9 +156,20 @@ func New(ctx context.Context, opts StartOpts) error {
 		opts.Config.Execution.LogOutput = true
 	}
 
+	if opts.Keyring == nil {
+		opts.Keyring = keyring.New(opts.SigningKey, opts.SigningKeyFallback, opts.EventKey)
+	}
+	if opts.Environments == nil {
+		envs, err := environment.New(opts.Keyring, nil)
+		if err != nil {
+			return err
+		}
+		opts.Environments = envs
+	}
+
 	// Ensure that if we've been given a signing key, that cloud mode is
 	// enabled appropriately in config.
-	if opts.SigningKey != "" {
+	if opts.Keyring.HasSigningKey() {
 		opts.Config.ServerKind = headers.ServerKindCloud
 	}
 
@@ -110,13 +180,30 @@ func New(ctx context.Context, opts StartOpts) error {
 	//
 	// We also make sure to allow local requests.
 	httpdriver.DefaultTransport.DialContext = httpdriver.Dialer.DialContext
+	httpdriver.ProxyHostValidator = nil
 	httpdriver.DefaultExecutor.Client.Transport = awsgateway.NewTransformTripper(httpdriver.DefaultExecutor.Client.Transport)
 	deploy.Client.Transport = awsgateway.NewTransformTripper(deploy.Client.Transport)
+	httpdriver.WrapTransport = awsgateway.NewTransformTripper
 
 	return start(ctx, opts)
 }
 
 func start(ctx context.Context, opts StartOpts) error {
+	if opts.Role == "" {
+		opts.Role = RoleAll
+	}
+	if err := validateRole(opts); err != nil {
+		return err
+	}
+	if opts.Role.IsSplit() && opts.PostgresURI == "" {
+		logger.StdlibLogger(ctx).Warn("using SQLite with split roles; all processes must share the same SQLite directory on a single host", "role", opts.Role)
+	}
+
+	realtimeSecret, connectSecret, err := secrets(ctx, opts)
+	if err != nil {
+		return err
+	}
+
 	db, err := base_cqrs.New(base_cqrs.BaseCQRSOptions{
 		InMemory:    false,
 		PostgresURI: opts.PostgresURI,
@@ -136,9 +223,38 @@ func start(ctx context.Context, opts StartOpts) error {
 	if opts.PostgresURI != "" {
 		dbDriver = "postgres"
 	}
-	dbcqrs := base_cqrs.NewCQRS(db, dbDriver)
-	hd := base_cqrs.NewHistoryDriver(db, dbDriver)
-	hr := base_cqrs.NewHistoryReader(db, dbDriver)
+
+	var (
+		cqrsOpts  []base_cqrs.Opt
+		stateOpts []redis_state.Opt
+		enc       encryption.Encryptor
+	)
+	if opts.EncryptionKeyfile != "" {
+		kp, err := encryption.NewKeyfileProvider(ctx, opts.EncryptionKeyfile)
+		if err != nil {
+			return fmt.Errorf("failed to load encryption keys: %w", err)
+		}
+		go kp.Watch(ctx, consts.StartEncryptionKeyReloadInterval)
+
+		enc = encryption.NewEnvelopeEncryptor(kp)
+		cqrsOpts = append(cqrsOpts, base_cqrs.WithEncryptor(enc))
+		stateOpts = append(stateOpts, redis_state.WithEncryptor(enc))
+
+		if opts.EncryptionReencrypt {
+			go func() {
+				n, err := base_cqrs.Reencrypt(ctx, db, dbDriver, enc)
+				if err != nil {
+					logger.From(ctx).Error().Err(err).Int("rows", n).Msg("error re-encrypting stored data")
+					return
+				}
+				logger.From(ctx).Info().Int("rows", n).Msg("re-encrypted stored data")
+			}()
+		}
+	}
+
+	dbcqrs := base_cqrs.NewCQRS(db, dbDriver, cqrsOpts...)
+	hd := base_cqrs.NewHistoryDriver(db, dbDriver, cqrsOpts...)
+	hr := base_cqrs.NewHistoryReader(db, dbDriver, cqrsOpts...)
 	loader := dbcqrs.(state.FunctionLoader)
 
 	stepLimitOverrides := make(map[string]int)
@@ -164,6 +280,18 @@ func start(ctx context.Context, opts StartOpts) error {
 		QueueDefaultKey:        redis_state.QueueDefaultKey,
 	})
 
+	// Realtime messages are broadcast via Redis so that subscribers connected
+	// to any API process receive messages published by every process.
+	var broadcaster realtime.Broadcaster
+	if realtimeSecret != nil {
+		// Clients used for subscriptions can't be used for publishing.
+		realtimeSubRc, err := connectToOrCreateRedis(opts.RedisURI)
+		if err != nil {
+			return err
+		}
+		broadcaster = realtime.NewRedisBroadcaster(unshardedRc, realtimeSubRc, realtime.WithHistory(consts.StartRealtimeHistorySize, realtime.DefaultHistoryTTL))
+	}
+
 	connectRcOpt, err := connectToOrCreateRedisOption(opts.RedisURI)
 	if err != nil {
 		return err
@@ -178,8 +306,11 @@ func start(ctx context.Context, opts StartOpts) error {
 	t := runner.NewTracker()
 	sm, err = redis_state.New(
 		ctx,
-		redis_state.WithShardedClient(shardedClient),
-		redis_state.WithUnshardedClient(unshardedClient),
+		append(
+			stateOpts,
+			redis_state.WithShardedClient(shardedClient),
+			redis_state.WithUnshardedClient(unshardedClient),
+		)...,
 	)
 	if err != nil {
 		return err
@@ -268,8 +399,19 @@ func start(ctx context.Context, opts StartOpts) error {
 
 	rl := ratelimit.New(ctx, unshardedRc, "{ratelimit}:")
 
-	batcher := batch.NewRedisBatchManager(shardedClient.Batch(), rq)
-	debouncer := debounce.NewRedisDebouncer(unshardedClient.Debounce(), queueShard, rq)
+	batcher := batch.NewRedisBatchManager(shardedClient.Batch(), rq, batch.WithEncryptor(enc))
+	debouncer, err := debounce.NewRedisDebouncerWithMigration(debounce.DebouncerOpts{
+		PrimaryDebounceClient: unshardedClient.Debounce(),
+		PrimaryQueue:          rq,
+		PrimaryQueueShard:     queueShard,
+		ShouldMigrate: func(ctx context.Context, accountID uuid.UUID) bool {
+			return false
+		},
+		Encryptor: enc,
+	})
+	if err != nil {
+		return err
+	}
 
 	// Create a new expression aggregator, using Redis to load evaluables.
 	agg := expressions.NewAggregator(ctx, 100, 100, sm.(expressions.EvaluableLoader), nil)
@@ -278,11 +420,6 @@ func start(ctx context.Context, opts StartOpts) error {
 
 	connectionManager := connstate.NewRedisConnectionStateManager(connectRc)
 
-	var sk *string
-	if opts.SigningKey != "" {
-		sk = &opts.SigningKey
-	}
-
 	connectPubSubLogger := logger.StdlibLoggerWithCustomVarName(ctx, "CONNECT_PUBSUB_LOG_LEVEL")
 
 	executorProxy, err := connectpubsub.NewConnector(ctx, connectpubsub.WithRedis(connectRcOpt, connectPubSubLogger.With("svc", "executor"), conditionalTracer, connectionManager, true))
@@ -294,7 +431,8 @@ func start(ctx context.Context, opts StartOpts) error {
 	for _, driverConfig := range opts.Config.Execution.Drivers {
 		d, err := driverConfig.NewDriver(registration.NewDriverOpts{
 			RequireLocalSigningKey: true,
-			LocalSigningKey:        sk,
+			Keyring:                opts.Keyring,
+			EnvKeyring:             opts.Environments.Keyring,
 			ConnectForwarder:       executorProxy,
 			ConditionalTracer:      conditionalTracer,
 		})
@@ -302,6 +440,11 @@ func start(ctx context.Context, opts StartOpts) error {
 			return err
 		}
 		drivers = append(drivers, d)
+
+		// Sync apps using the same transport config as function calls.
+		if c, ok := driverConfig.(*httpdriver.Config); ok {
+			deploy.Clients = c.ClientPool(&deploy.Client)
+		}
 	}
 	pb, err := pubsub.NewPublisher(ctx, opts.Config.EventStream.Service)
 	if err != nil {
@@ -350,8 +493,10 @@ func start(ctx context.Context, opts StartOpts) error {
 		executor.WithSendingEventHandler(getSendingEventHandler(pb, opts.Config.EventStream.Service.Concrete.TopicName())),
 		executor.WithDebouncer(debouncer),
 		executor.WithBatcher(batcher),
+		executor.WithSingletonLocker(singleton.New(unshardedRc, "{singleton}:")),
 		executor.WithAssignedQueueShard(queueShard),
 		executor.WithShardSelector(shardSelector),
+		executor.WithRealtimePublisher(broadcaster),
 	)
 	if err != nil {
 		return err
@@ -385,7 +530,26 @@ func start(ctx context.Context, opts StartOpts) error {
 	// The devserver embeds the event API.
 	pi := consts.StartDefaultPersistenceInterval
 	persistenceInterval := &pi
-	if opts.RedisURI != "" {
+	var redisWAL *wal.Log
+	if opts.RedisURI == "" {
+		// Log writes to the in-memory Redis between snapshots, so that
+		// they're replayed after a crash.
+		fsync, err := wal.ParseFsyncPolicy(opts.RedisWALFsync)
+		if err != nil {
+			return err
+		}
+		dir := opts.SQLiteDir
+		if dir == "" {
+			dir = consts.DefaultInngestConfigDir
+		}
+		redisWAL, err = wal.Open(redisSingleton, wal.Options{
+			Dir:   filepath.Join(dir, consts.StartRedisWALDir),
+			Fsync: fsync,
+		})
+		if err != nil {
+			return err
+		}
+	} else {
 		// If we're using an external Redis, we rely on that to persist and
 		// manage snapshotting
 		persistenceInterval = nil
@@ -403,8 +567,8 @@ func start(ctx context.Context, opts StartOpts) error {
 		RootDir:            opts.RootDir,
 		URLs:               opts.URLs,
 		Tick:               tick,
-		SigningKey:         sk,
-		EventKeys:          opts.EventKey,
+		Keyring:            opts.Keyring,
+		Environments:       opts.Environments,
 		RequireKeys:        true,
 		ConnectGatewayPort: opts.ConnectGatewayPort,
 		ConnectGatewayHost: opts.Config.CoreAPI.Addr,
@@ -418,6 +582,7 @@ func start(ctx context.Context, opts StartOpts) error {
 	// The devserver embeds the event API.
 	ds := devserver.NewService(dsOpts, runner, dbcqrs, pb, stepLimitOverrides, stateSizeLimitOverrides, unshardedRc, hd, &devserver.SingleNodeServiceOpts{
 		PersistenceInterval: persistenceInterval,
+		WAL:                 redisWAL,
 	})
 	// embed the tracker
 	ds.Tracker = t
@@ -429,19 +594,38 @@ func start(ctx context.Context, opts StartOpts) error {
 	// registering functions.
 	devAPI := devserver.NewDevAPI(ds)
 
+	// SDKs authenticate with their signing key, eg. to publish realtime
+	// messages, when auth is enabled.  Keys for any environment are valid.
+	opts.Auth.SigningKeys = opts.Environments
+	authn, err := rbac.New(opts.Auth)
+	if err != nil {
+		return fmt.Errorf("error configuring auth: %w", err)
+	}
+	var authMiddleware func(http.Handler) http.Handler
+	if authn.Enabled() {
+		authMiddleware = authn.Middleware
+	} else {
+		logger.StdlibLogger(ctx).Warn("no auth configured; the UI and APIs are accessible to anyone who can reach the server")
+	}
+
 	devAPI.Route("/v1", func(r chi.Router) {
 		// Add the V1 API to our dev server API.
 		cache := cache.New[[]byte](freecachestore.NewFreecache(freecache.NewCache(1024 * 1024)))
 		caching := apiv1.NewCacheMiddleware(cache)
 
 		apiv1.AddRoutes(r, apiv1.Opts{
+			AuthMiddleware:     authMiddleware,
 			CachingMiddleware:  caching,
 			EventReader:        ds.Data,
 			FunctionReader:     ds.Data,
 			FunctionRunReader:  ds.Data,
 			JobQueueReader:     ds.Queue.(queue.JobQueueReader),
 			Executor:           ds.Executor,
+			BatchManager:       batcher,
 			QueueShardSelector: shardSelector,
+			AuditLog:           ds.Data,
+			Broadcaster:        broadcaster,
+			RealtimeJWTSecret:  realtimeSecret,
 		})
 	})
 
@@ -451,23 +635,23 @@ func start(ctx context.Context, opts StartOpts) error {
 	}
 
 	core, err := coreapi.NewCoreApi(coreapi.Options{
-		Data:            ds.Data,
-		Config:          ds.Opts.Config,
-		Logger:          logger.From(ctx),
-		Runner:          ds.Runner,
-		Tracker:         ds.Tracker,
-		State:           ds.State,
-		Queue:           ds.Queue,
-		EventHandler:    ds.HandleEvent,
-		Executor:        ds.Executor,
-		HistoryReader:   hr,
-		LocalSigningKey: opts.SigningKey,
-		RequireKeys:     true,
+		Data:          ds.Data,
+		Config:        ds.Opts.Config,
+		Logger:        logger.From(ctx),
+		Runner:        ds.Runner,
+		Tracker:       ds.Tracker,
+		State:         ds.State,
+		Queue:         ds.Queue,
+		EventHandler:  ds.HandleEvent,
+		Executor:      ds.Executor,
+		HistoryReader: hr,
+		Keyring:       opts.Keyring,
+		RequireKeys:   true,
 		ConnectOpts: connectv0.Opts{
 			GroupManager:            connectionManager,
 			ConnectManager:          connectionManager,
 			ConnectResponseNotifier: apiConnectProxy,
-			Signer:                  auth.NewJWTSessionTokenSigner(consts.DevServerConnectJwtSecret),
+			Signer:                  auth.NewJWTSessionTokenSigner(connectSecret),
 			RequestAuther:           ds,
 			ConnectGatewayRetriever: ds,
 			EntitlementProvider:     ds,
@@ -478,6 +662,11 @@ func start(ctx context.Context, opts StartOpts) error {
 		return err
 	}
 
+	apiURL := opts.APIURL
+	if apiURL == "" {
+		apiURL = fmt.Sprintf("http://%s:%d", opts.Config.CoreAPI.Addr, opts.Config.CoreAPI.Port)
+	}
+
 	gatewayRequestReceiver, err := connectpubsub.NewConnector(ctx, connectpubsub.WithRedis(connectRcOpt, connectPubSubLogger.With("svc", "connect-gateway"), conditionalTracer, connectionManager, false))
 	if err != nil {
 		return fmt.Errorf("failed to create connect pubsub connector: %w", err)
@@ -486,10 +675,10 @@ func start(ctx context.Context, opts StartOpts) error {
 	connGateway := connect.NewConnectGatewayService(
 		connect.WithConnectionStateManager(connectionManager),
 		connect.WithRequestReceiver(gatewayRequestReceiver),
-		connect.WithGatewayAuthHandler(auth.NewJWTAuthHandler(consts.DevServerConnectJwtSecret)),
+		connect.WithGatewayAuthHandler(auth.NewJWTAuthHandler(connectSecret)),
 		connect.WithDev(),
 		connect.WithGatewayPublicPort(opts.ConnectGatewayPort),
-		connect.WithApiBaseUrl(fmt.Sprintf("http://%s:%d", opts.Config.CoreAPI.Addr, opts.Config.CoreAPI.Port)),
+		connect.WithApiBaseUrl(apiURL),
 		connect.WithLifeCycles(
 			[]connect.ConnectGatewayLifecycleListener{
 				lifecycles.NewHistoryLifecycle(dbcqrs),
@@ -502,18 +691,86 @@ func start(ctx context.Context, opts StartOpts) error {
 	//
 	// Merge the dev server API (for handling files & registration) with the data
 	// API into the event API router.
-	ds.Apiservice = api.NewService(api.APIServiceOptions{
-		Config: ds.Opts.Config,
-		Mounts: []api.Mount{
-			{At: "/", Router: devAPI},
-			{At: "/v0", Router: core.Router},
-			{At: "/debug", Handler: middleware.Profiler()},
+	mounts := []api.Mount{
+		{At: "/", Router: devAPI},
+		{At: "/v0", Router: core.Router},
+	}
+	// Scope each UI and API request to its environment.
+	for n, m := range mounts {
+		h := m.Handler
+		if h == nil {
+			h = m.Router
+		}
+		mounts[n] = api.Mount{At: m.At, Handler: opts.Environments.Middleware(h)}
+	}
+	if opts.EnableDebug {
+		mounts = append(mounts, api.Mount{At: "/debug", Handler: middleware.Profiler()})
+	}
+	if authn.Enabled() {
+		for n, m := range mounts {
+			h := m.Handler
+			if h == nil {
+				h = m.Router
+			}
+			mounts[n] = api.Mount{At: m.At, Handler: authn.Middleware(h)}
+		}
+		if h := authn.Handler(); h != nil {
+			mounts = append(mounts, api.Mount{At: "/auth", Handler: h})
+		}
+	}
+
+	// Each role reports its health, with readiness checks failing if Redis or
+	// the database are unreachable, or while draining.
+	health := newHealth(opts.Role, map[string]func(ctx context.Context) error{
+		"redis": func(ctx context.Context) error {
+			return unshardedRc.Do(ctx, unshardedRc.B().Ping().Build()).Error()
 		},
-		LocalEventKeys: opts.EventKey,
+		"database": db.PingContext,
+	})
+	mounts = append(mounts, api.Mount{At: "/ready", Handler: http.HandlerFunc(health.ready)})
+
+	ds.Apiservice = api.NewService(api.APIServiceOptions{
+		Config:         ds.Opts.Config,
+		Mounts:         mounts,
+		Keyring:        opts.Keyring,
+		Environments:   opts.Environments,
 		RequireKeys:    true,
+		AuthMiddleware: authMiddleware,
+		AuditLog:       ds.Data,
 	})
 
-	return service.StartAll(ctx, ds, runner, executorSvc, ds.Apiservice, connGateway)
+	services := []service.Service{}
+	if opts.Role.Runs(RoleAPI) {
+		services = append(services, ds, drainService{
+			Service:     ds.Apiservice,
+			health:      health,
+			drainPeriod: opts.DrainPeriod,
+		})
+	} else {
+		// The API serves health endpoints itself;  all other roles serve them
+		// on the API's port.
+		services = append(services, &healthService{
+			health:      health,
+			addr:        fmt.Sprintf("%s:%d", opts.Config.EventAPI.Addr, opts.Config.EventAPI.Port),
+			drainPeriod: opts.DrainPeriod,
+		})
+	}
+	if opts.Role.Runs(RoleRunner) {
+		services = append(services, runner)
+	}
+	if opts.Role.Runs(RoleExecutor) {
+		services = append(services, executorSvc)
+	}
+	if opts.Role.Runs(RoleConnectGateway) {
+		services = append(services, connGateway)
+	}
+
+	names := make([]string, len(services))
+	for n, svc := range services {
+		names[n] = svc.Name()
+	}
+	logger.StdlibLogger(ctx).Info("starting services", "role", opts.Role, "services", names)
+	return service.StartAll(ctx, services...)
 }
 
 func connectToOrCreateRedis(redisURI string) (rueidis.Client, error) {
@@ -575,7 +832,7 @@ func createInmemoryRedisConnectionOpt() (rueidis.ClientOption, error) {
 
 func getSendingEventHandler(pb pubsub.Publisher, topic string) execution.HandleSendingEvent {
 	return func(ctx context.Context, evt event.Event, item queue.Item) error {
-		trackedEvent := event.NewOSSTrackedEvent(evt, nil)
+		trackedEvent := event.NewOSSTrackedEventInWorkspace(item.WorkspaceID, evt, nil)
 		byt, err := json.Marshal(trackedEvent)
 		if err != nil {
 			return fmt.Errorf("error marshalling invocation event: %w", err)
@@ -611,7 +868,7 @@ func getInvokeFailHandler(ctx context.Context, pb pubsub.Publisher, topic string
 		for _, e := range evts {
 			evt := e
 			eg.Go(func() error {
-				trackedEvent := event.NewOSSTrackedEvent(evt, nil)
+				trackedEvent := event.NewOSSTrackedEventInWorkspace(opts.OriginalEvent.GetWorkspaceID(), evt, nil)
 				byt, err := json.Marshal(trackedEvent)
 				if err != nil {
 					return fmt.Errorf("error marshalling function finished event: %w", err)
@@ -0,0 +1,77 @@
+package lite
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/inngest/inngest/pkg/config"
+)
+
+// Role selects the services run by a single `inngest start` process, allowing
+// each service to be scaled independently.  For example, many executor
+// processes can run against the same Redis and Postgres instances while the
+// API is run separately.
+type Role string
+
+const (
+	// RoleAll runs every service in a single process.  This is the default.
+	RoleAll Role = "all"
+	// RoleAPI runs the event API, REST and GraphQL APIs and the UI.
+	RoleAPI Role = "api"
+	// RoleRunner consumes the event stream, scheduling new runs, resuming
+	// paused runs and handling cancellations.
+	RoleRunner Role = "runner"
+	// RoleExecutor runs the queue workers which execute function steps.
+	RoleExecutor Role = "executor"
+	// RoleConnectGateway accepts WebSocket connections from connect workers.
+	RoleConnectGateway Role = "connect-gateway"
+)
+
+// Roles lists all available roles.
+var Roles = []Role{RoleAll, RoleAPI, RoleRunner, RoleExecutor, RoleConnectGateway}
+
+// IsValid returns whether the role is a known role.
+func (r Role) IsValid() bool {
+	for _, role := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Runs returns whether a process with this role runs the services for the
+// given role.
+func (r Role) Runs(role Role) bool {
+	return r == RoleAll || r == role
+}
+
+// IsSplit returns whether the role runs a subset of services, requiring other
+// processes to run the remaining services.
+func (r Role) IsSplit() bool {
+	return r != RoleAll && r != ""
+}
+
+// validateRole ensures that the given options can be used with the role.  When
+// roles are split across processes, all state must be shared via external
+// services.
+func validateRole(opts StartOpts) error {
+	if !opts.Role.IsValid() {
+		roles := make([]string, len(Roles))
+		for n, r := range Roles {
+			roles[n] = string(r)
+		}
+		return fmt.Errorf("invalid role '%s': must be one of %s", opts.Role, strings.Join(roles, ", "))
+	}
+	if !opts.Role.IsSplit() {
+		return nil
+	}
+
+	if opts.RedisURI == "" {
+		return fmt.Errorf("--redis-uri is required with role '%s': the in-memory Redis server can't be shared between processes", opts.Role)
+	}
+	if opts.Config.EventStream.Service.Backend == config.MessagingInMemory {
+		return fmt.Errorf("an event stream is required with role '%s': the in-memory event stream can't be shared between processes.  Configure 'event_stream' within the config file, eg. to use NATS", opts.Role)
+	}
+	return nil
+}
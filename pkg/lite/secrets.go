@@ -0,0 +1,48 @@
+package lite
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/inngest/inngest/pkg/keyring"
+	"github.com/inngest/inngest/pkg/logger"
+)
+
+// secrets returns the secrets used to sign realtime subscription tokens and
+// connect worker session tokens.  Secrets which aren't configured are derived
+// from the signing key, so that every process sharing a signing key shares the
+// same secrets.
+//
+// Without a signing key realtime is disabled, returning a nil realtime
+// secret, and connect uses a random secret local to this process.
+func secrets(ctx context.Context, opts StartOpts) (realtimeSecret []byte, connectSecret []byte, err error) {
+	l := logger.StdlibLogger(ctx)
+	signingKey := opts.Keyring.SigningKey()
+
+	switch {
+	case opts.RealtimeSecret != "":
+		realtimeSecret = []byte(opts.RealtimeSecret)
+	case signingKey != "":
+		realtimeSecret = keyring.DeriveSecret(signingKey, "realtime")
+	default:
+		l.Warn("realtime is disabled: set a signing key or --realtime-secret to enable realtime")
+	}
+
+	switch {
+	case opts.ConnectSecret != "":
+		connectSecret = []byte(opts.ConnectSecret)
+	case signingKey != "":
+		connectSecret = keyring.DeriveSecret(signingKey, "connect")
+	default:
+		connectSecret = make([]byte, 32)
+		if _, err := rand.Read(connectSecret); err != nil {
+			return nil, nil, fmt.Errorf("error generating connect secret: %w", err)
+		}
+		if opts.Role.IsSplit() {
+			l.Warn("using a random connect secret; set a signing key or --connect-secret so that the API and connect gateway share the same secret", "role", opts.Role)
+		}
+	}
+
+	return realtimeSecret, connectSecret, nil
+}
@@ -0,0 +1,29 @@
+package lite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/inngest/inngest/pkg/keyring"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecrets(t *testing.T) {
+	ctx := context.Background()
+
+	rt, conn, err := secrets(ctx, StartOpts{Keyring: keyring.New("", nil, nil)})
+	require.NoError(t, err)
+	require.Nil(t, rt)
+	require.Len(t, conn, 32)
+
+	kr := keyring.New("signkey-test-12345678", nil, nil)
+	rt, conn, err = secrets(ctx, StartOpts{Keyring: kr})
+	require.NoError(t, err)
+	require.Equal(t, keyring.DeriveSecret(kr.SigningKey(), "realtime"), rt)
+	require.Equal(t, keyring.DeriveSecret(kr.SigningKey(), "connect"), conn)
+
+	rt, conn, err = secrets(ctx, StartOpts{Keyring: kr, RealtimeSecret: "rt", ConnectSecret: "conn"})
+	require.NoError(t, err)
+	require.Equal(t, []byte("rt"), rt)
+	require.Equal(t, []byte("conn"), conn)
+}
@@ -0,0 +1,97 @@
+package rbac
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// maxGraphQLBodySize is the maximum size of a GraphQL request body read when
+// authorizing the request.
+const maxGraphQLBodySize = 1024 * 1024
+
+// mutationRoles lists the roles required for each GraphQL mutation.  Mutations
+// not listed require the operator role.
+var mutationRoles = map[string]Role{
+	"createApp":       RoleAdmin,
+	"updateApp":       RoleAdmin,
+	"deleteApp":       RoleAdmin,
+	"deleteAppByName": RoleAdmin,
+}
+
+// graphQLRole returns the role required to run the GraphQL request: viewer for
+// queries, and the highest role required by any mutation otherwise.  The request
+// body is read and replaced so that it can be read again by the GraphQL handler.
+func graphQLRole(r *http.Request) (Role, error) {
+	query := r.URL.Query().Get("query")
+
+	if r.Body != nil && r.Method == http.MethodPost {
+		byt, err := io.ReadAll(io.LimitReader(r.Body, maxGraphQLBodySize))
+		if err != nil {
+			return "", err
+		}
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(byt))
+
+		params := struct {
+			Query string `json:"query"`
+		}{}
+		if len(byt) > 0 {
+			if err := json.Unmarshal(byt, &params); err != nil {
+				return "", err
+			}
+		}
+		query = params.Query
+	}
+
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		// Invalid queries are rejected by the GraphQL handler without being
+		// executed.
+		return RoleViewer, nil
+	}
+
+	role := RoleViewer
+	for _, op := range doc.Operations {
+		if op.Operation != ast.Mutation {
+			continue
+		}
+		for _, name := range mutationFields(doc, op.SelectionSet, map[string]bool{}) {
+			required := RoleOperator
+			if r, ok := mutationRoles[name]; ok {
+				required = r
+			}
+			if !role.Allows(required) {
+				role = required
+			}
+		}
+	}
+	return role, nil
+}
+
+// mutationFields returns the names of all top-level fields within a mutation's
+// selection set, including those selected via fragments.
+func mutationFields(doc *ast.QueryDocument, set ast.SelectionSet, visited map[string]bool) []string {
+	names := []string{}
+	for _, sel := range set {
+		switch s := sel.(type) {
+		case *ast.Field:
+			names = append(names, s.Name)
+		case *ast.InlineFragment:
+			names = append(names, mutationFields(doc, s.SelectionSet, visited)...)
+		case *ast.FragmentSpread:
+			if visited[s.Name] {
+				continue
+			}
+			visited[s.Name] = true
+			if f := doc.Fragments.ForName(s.Name); f != nil {
+				names = append(names, mutationFields(doc, f.SelectionSet, visited)...)
+			}
+		}
+	}
+	return names
+}
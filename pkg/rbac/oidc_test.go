@@ -0,0 +1,158 @@
+package rbac
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCLogin(t *testing.T) {
+	var groups []string
+
+	var idp *httptest.Server
+	idp = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"authorization_endpoint": idp.URL + "/authorize",
+				"token_endpoint":         idp.URL + "/token",
+			})
+		case "/token":
+			require.NoError(t, r.ParseForm())
+			require.Equal(t, "code-123", r.Form.Get("code"))
+			idToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"iss":    idp.URL,
+				"aud":    "client",
+				"exp":    time.Now().Add(time.Minute).Unix(),
+				"email":  "jane@example.com",
+				"groups": groups,
+			}).SignedString([]byte("idp-secret"))
+			require.NoError(t, err)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"access_token": "access",
+				"token_type":   "Bearer",
+				"id_token":     idToken,
+			})
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer idp.Close()
+
+	a, err := New(Config{OIDC: &OIDCConfig{
+		Issuer:      idp.URL,
+		ClientID:    "client",
+		RedirectURL: "http://inngest.test/auth/callback",
+		Roles:       map[string]Role{"eng": RoleOperator, "platform": RoleAdmin},
+	}})
+	require.NoError(t, err)
+
+	login := func() *http.Response {
+		// Navigating to the UI redirects to the login page.
+		r := httptest.NewRequest(http.MethodGet, "/runs", nil)
+		r.Header.Set("Accept", "text/html")
+		w := httptest.NewRecorder()
+		a.Middleware(http.NotFoundHandler()).ServeHTTP(w, r)
+		require.Equal(t, http.StatusFound, w.Code)
+		require.Equal(t, "/auth/login?redirect=%2Fruns", w.Header().Get("Location"))
+
+		w = httptest.NewRecorder()
+		a.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login?redirect=%2Fruns", nil))
+		require.Equal(t, http.StatusFound, w.Code)
+		authURL, err := url.Parse(w.Header().Get("Location"))
+		require.NoError(t, err)
+		require.Equal(t, idp.URL+"/authorize", authURL.Scheme+"://"+authURL.Host+authURL.Path)
+		state := authURL.Query().Get("state")
+
+		r = httptest.NewRequest(http.MethodGet, "/callback?code=code-123&state="+state, nil)
+		for _, c := range w.Result().Cookies() {
+			r.AddCookie(c)
+		}
+		w = httptest.NewRecorder()
+		a.Handler().ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	t.Run("users without a role are denied", func(t *testing.T) {
+		groups = []string{"sales"}
+		resp := login()
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("users are mapped to their highest role", func(t *testing.T) {
+		groups = []string{"eng", "platform"}
+		resp := login()
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		require.Equal(t, "/runs", resp.Header.Get("Location"))
+
+		var session *http.Cookie
+		for _, c := range resp.Cookies() {
+			if c.Name == sessionCookie {
+				session = c
+			}
+		}
+		require.NotNil(t, session)
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/runs/123", nil)
+		r.AddCookie(session)
+		p, err := a.Authenticate(r)
+		require.NoError(t, err)
+		require.Equal(t, &Principal{Name: "jane@example.com", Role: RoleAdmin, Method: "oidc"}, p)
+	})
+
+	t.Run("redirects off of this server are ignored", func(t *testing.T) {
+		for redirect, expected := range map[string]string{
+			"/runs?id=1":       "/runs?id=1",
+			"//evil.com":       "/",
+			"/\\evil.com":      "/",
+			"https://evil.com": "/",
+			"evil.com":         "/",
+			"/\t/evil.com":     "/",
+			"":                 "/",
+		} {
+			w := httptest.NewRecorder()
+			a.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login?redirect="+url.QueryEscape(redirect), nil))
+			require.Equal(t, http.StatusFound, w.Code)
+
+			var value string
+			for _, c := range w.Result().Cookies() {
+				if c.Name == stateCookie {
+					value = c.Value
+				}
+			}
+			_, actual, _ := strings.Cut(value, "|")
+			require.Equal(t, expected, actual, redirect)
+		}
+	})
+
+	t.Run("invalid state is rejected", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		a.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/callback?code=code-123&state=nope", nil))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("forged sessions are rejected", func(t *testing.T) {
+		forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, sessionClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    sessionIssuer,
+				Subject:   "mallory",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+			Role: RoleAdmin,
+		}).SignedString([]byte("guess"))
+		require.NoError(t, err)
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(&http.Cookie{Name: sessionCookie, Value: forged})
+		_, err = a.Authenticate(r)
+		require.ErrorIs(t, err, ErrUnauthorized)
+	})
+}
@@ -0,0 +1,312 @@
+// Package rbac authenticates requests to the self-hosted UI, GraphQL and REST
+// APIs, and authorizes them using roles.
+//
+// Requests are authenticated using static API tokens, HTTP basic auth, or an
+// OIDC login session for the UI.  Each authenticated principal has a single
+// role:
+//
+//   - viewer may read data via queries and GET requests.
+//   - operator may additionally run mutations such as cancelling, rerunning
+//     and invoking functions, and any other REST verb.
+//   - admin may additionally manage apps and access debugging endpoints.
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/inngest/inngest/pkg/logger"
+	"github.com/inngest/inngest/pkg/publicerr"
+)
+
+// Role is the level of access granted to a principal.
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"
+	RoleOperator Role = "operator"
+	RoleAdmin    Role = "admin"
+)
+
+var roleRank = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// IsValid returns whether the role is a known role.
+func (r Role) IsValid() bool {
+	_, ok := roleRank[r]
+	return ok
+}
+
+// Allows returns whether the role grants access to actions requiring the
+// given role.
+func (r Role) Allows(required Role) bool {
+	return roleRank[r] >= roleRank[required]
+}
+
+var (
+	// ErrUnauthorized is returned when credentials are invalid.
+	ErrUnauthorized = errors.New("invalid credentials")
+)
+
+// Principal is an authenticated user or API token.
+type Principal struct {
+	// Name identifies the principal, eg. the token name, username or email.
+	Name string
+	// Role is the principal's role.
+	Role Role
+	// Method is the authentication method used, eg. "token", "basic" or "oidc".
+	Method string
+}
+
+type principalCtxKey struct{}
+
+// WithPrincipal returns a context storing the given principal.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalCtxKey{}, p)
+}
+
+// PrincipalFrom returns the principal authenticated for the current request, or
+// nil if the request is not authenticated.
+func PrincipalFrom(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalCtxKey{}).(*Principal)
+	return p
+}
+
+// Authenticator authenticates a request using a single method.  Authenticate
+// returns nil without an error if the request doesn't contain credentials for
+// the method, and ErrUnauthorized if the credentials are invalid.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Config configures authentication for the self-hosted server.  Auth is enabled
+// if any tokens, users or an OIDC provider are configured.
+type Config struct {
+	// Tokens lists static API tokens, sent as "Authorization: Bearer <token>".
+	Tokens []TokenConfig `json:"tokens,omitempty"`
+	// Users lists users which may log in using HTTP basic auth.
+	Users []UserConfig `json:"users,omitempty"`
+	// OIDC configures login to the UI via an OpenID Connect provider.
+	OIDC *OIDCConfig `json:"oidc,omitempty"`
+	// SigningKeys, if set, allows SDKs to authenticate using their hashed
+	// signing key as a bearer token with the operator role, eg. to publish
+	// realtime messages or create realtime tokens.  This doesn't enable auth
+	// by itself.
+	SigningKeys SigningKeyValidator `json:"-"`
+}
+
+// Enabled returns whether any auth methods are configured.
+func (c Config) Enabled() bool {
+	return len(c.Tokens) > 0 || len(c.Users) > 0 || c.OIDC != nil
+}
+
+// Auth authenticates and authorizes requests using the configured methods.
+type Auth struct {
+	authenticators []Authenticator
+	basic          bool
+	oidc           *OIDC
+}
+
+// New returns an Auth for the given config.
+func New(c Config) (*Auth, error) {
+	a := &Auth{}
+
+	if len(c.Tokens) > 0 {
+		t, err := NewTokenAuthenticator(c.Tokens)
+		if err != nil {
+			return nil, err
+		}
+		a.authenticators = append(a.authenticators, t)
+	}
+
+	if len(c.Users) > 0 {
+		b, err := NewBasicAuthenticator(c.Users)
+		if err != nil {
+			return nil, err
+		}
+		a.authenticators = append(a.authenticators, b)
+		a.basic = true
+	}
+
+	if c.OIDC != nil {
+		o, err := NewOIDC(*c.OIDC)
+		if err != nil {
+			return nil, err
+		}
+		a.authenticators = append(a.authenticators, o)
+		a.oidc = o
+	}
+
+	// Signing keys are checked first, as the token authenticator rejects
+	// unknown bearer tokens.
+	if c.SigningKeys != nil && len(a.authenticators) > 0 {
+		a.authenticators = append([]Authenticator{NewSigningKeyAuthenticator(c.SigningKeys)}, a.authenticators...)
+	}
+
+	return a, nil
+}
+
+// Enabled returns whether any auth methods are configured.  If not, the
+// middleware allows all requests.
+func (a *Auth) Enabled() bool {
+	return a != nil && len(a.authenticators) > 0
+}
+
+// Authenticate authenticates the request using each configured method in turn.
+func (a *Auth) Authenticate(r *http.Request) (*Principal, error) {
+	for _, au := range a.authenticators {
+		p, err := au.Authenticate(r)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// Handler returns the handler for login routes, which must be mounted at
+// "/auth".  This returns nil if OIDC is not configured.
+func (a *Auth) Handler() http.Handler {
+	if a == nil || a.oidc == nil {
+		return nil
+	}
+	return a.oidc.Handler()
+}
+
+// Middleware authenticates each request and ensures that the principal's role
+// allows the request, responding with a 401 or 403 otherwise.  Requests to
+// public paths used by SDKs are always allowed.
+func (a *Auth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.Enabled() || r.Method == http.MethodOptions || IsPublicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		l := logger.StdlibLogger(ctx).With(
+			"method", r.Method,
+			"path", r.URL.Path,
+			"remote_addr", r.RemoteAddr,
+		)
+
+		// Middleware may be applied to both a router and its subrouters, in
+		// which case the request is already authenticated.
+		p := PrincipalFrom(ctx)
+		if p == nil {
+			var err error
+			p, err = a.Authenticate(r)
+			if err != nil {
+				l.Warn("authentication failed", "error", err)
+				a.unauthorized(w, r)
+				return
+			}
+			if p == nil {
+				a.unauthorized(w, r)
+				return
+			}
+			r = r.WithContext(WithPrincipal(ctx, p))
+		}
+
+		required, err := RequiredRole(r)
+		if err != nil {
+			_ = publicerr.WriteHTTP(w, publicerr.Wrap(err, 400, "Invalid request"))
+			return
+		}
+		if !p.Role.Allows(required) {
+			l.Warn("authorization failed",
+				"principal", p.Name,
+				"auth_method", p.Method,
+				"role", p.Role,
+				"required_role", required,
+			)
+			_ = publicerr.WriteHTTP(w, publicerr.Errorf(403, "The %s role is required for this request", required))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *Auth) unauthorized(w http.ResponseWriter, r *http.Request) {
+	// Send browsers to the login page when navigating to the UI.
+	if a.oidc != nil && r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), "text/html") {
+		http.Redirect(w, r, "/auth/login?redirect="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+		return
+	}
+	if a.basic {
+		w.Header().Set("WWW-Authenticate", `Basic realm="Inngest", charset="UTF-8"`)
+	}
+	_ = publicerr.WriteHTTP(w, publicerr.Errorf(401, "Authentication required"))
+}
+
+// publicPaths are path prefixes used by SDKs and the server itself, which are
+// authenticated using signing keys rather than user credentials.
+var publicPaths = []string{
+	"/auth/",
+	"/dev/traces",
+	"/fn/register",
+	"/v0/connect/",
+	"/v0/telemetry",
+	// Realtime subscriptions are authenticated using realtime tokens, which
+	// are scoped to specific channels and topics.
+	"/v1/realtime/connect",
+	"/v1/realtime/sse",
+}
+
+// IsPublicPath returns whether the path is used by SDKs or the server itself,
+// and so doesn't require user credentials.  SDK routes which return run data,
+// such as /v0/runs/{runID}/actions, aren't public:  SDKs authenticate using
+// their signing key instead.
+func IsPublicPath(path string) bool {
+	for _, prefix := range publicPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// adminPaths are path prefixes which require the admin role for all requests.
+var adminPaths = []string{
+	"/debug",
+	"/fn/remove",
+	"/fn/step-limit",
+	"/fn/state-size-limit",
+	"/v1/audit",
+}
+
+// RequiredRole returns the role required to make the given request.  GraphQL
+// requests are authorized by their mutations, and all other requests by their
+// HTTP method.
+func RequiredRole(r *http.Request) (Role, error) {
+	for _, prefix := range adminPaths {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return RoleAdmin, nil
+		}
+	}
+
+	if r.URL.Path == "/v0/gql" {
+		role, err := graphQLRole(r)
+		if err != nil {
+			return "", fmt.Errorf("error reading graphql request: %w", err)
+		}
+		return role, nil
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		return RoleViewer, nil
+	default:
+		return RoleOperator, nil
+	}
+}
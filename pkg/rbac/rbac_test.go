@@ -0,0 +1,190 @@
+package rbac
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/inngest/inngest/pkg/keyring"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestRoleAllows(t *testing.T) {
+	require.True(t, RoleAdmin.Allows(RoleOperator))
+	require.True(t, RoleOperator.Allows(RoleOperator))
+	require.False(t, RoleViewer.Allows(RoleOperator))
+	require.False(t, Role("").Allows(RoleViewer))
+}
+
+func TestRequiredRole(t *testing.T) {
+	gql := func(query string) *http.Request {
+		byt, _ := json.Marshal(map[string]any{"query": query})
+		return httptest.NewRequest(http.MethodPost, "/v0/gql", strings.NewReader(string(byt)))
+	}
+
+	tests := []struct {
+		name     string
+		req      *http.Request
+		expected Role
+	}{
+		{"get", httptest.NewRequest(http.MethodGet, "/v1/runs/123", nil), RoleViewer},
+		{"delete", httptest.NewRequest(http.MethodDelete, "/v1/runs/123", nil), RoleOperator},
+		{"debug", httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil), RoleAdmin},
+		{"audit", httptest.NewRequest(http.MethodGet, "/v1/audit", nil), RoleAdmin},
+		{"gql query", gql(`query { functions { id } }`), RoleViewer},
+		{"gql cancel", gql(`mutation { cancelRun(runID: "1") { id } }`), RoleOperator},
+		{"gql delete app", gql(`mutation { deleteApp(id: "1") }`), RoleAdmin},
+		{"gql fragment", gql(`mutation { ...F } fragment F on Mutation { deleteAppByName(name: "a") }`), RoleAdmin},
+		{"gql mixed", gql(`query Q { apps { id } } mutation M { rerun(runID: "1") }`), RoleOperator},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			role, err := RequiredRole(test.req)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, role)
+		})
+	}
+
+	// The body must still be readable after authorizing.
+	r := gql(`query { functions { id } }`)
+	_, err := RequiredRole(r)
+	require.NoError(t, err)
+	byt, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(byt), "functions")
+}
+
+func TestMiddleware(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	a, err := New(Config{
+		Tokens: []TokenConfig{
+			{Name: "ci", Token: "viewer-token"},
+			{Name: "ops", Token: "operator-token", Role: RoleOperator},
+		},
+		Users: []UserConfig{
+			{Username: "admin", Password: string(hash), Role: RoleAdmin},
+			{Username: "plain", Password: "plaintext"},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, a.Enabled())
+
+	var principal *Principal
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal = PrincipalFrom(r.Context())
+	}))
+
+	do := func(method, path string, auth func(r *http.Request)) int {
+		principal = nil
+		r := httptest.NewRequest(method, path, nil)
+		if auth != nil {
+			auth(r)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	bearer := func(token string) func(r *http.Request) {
+		return func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }
+	}
+	basic := func(user, pass string) func(r *http.Request) {
+		return func(r *http.Request) { r.SetBasicAuth(user, pass) }
+	}
+
+	require.Equal(t, 401, do(http.MethodGet, "/v1/runs/123", nil))
+	require.Equal(t, 401, do(http.MethodGet, "/v1/runs/123", bearer("nope")))
+	require.Equal(t, 401, do(http.MethodGet, "/v1/runs/123", basic("admin", "nope")))
+
+	require.Equal(t, 200, do(http.MethodGet, "/v1/runs/123", bearer("viewer-token")))
+	require.Equal(t, "ci", principal.Name)
+	require.Equal(t, 403, do(http.MethodDelete, "/v1/runs/123", bearer("viewer-token")))
+	require.Equal(t, 200, do(http.MethodDelete, "/v1/runs/123", bearer("operator-token")))
+	require.Equal(t, 403, do(http.MethodGet, "/debug/pprof/", bearer("operator-token")))
+
+	require.Equal(t, 200, do(http.MethodGet, "/debug/pprof/", basic("admin", "hunter2")))
+	require.Equal(t, RoleAdmin, principal.Role)
+	require.Equal(t, 200, do(http.MethodGet, "/", basic("plain", "plaintext")))
+	require.Equal(t, RoleViewer, principal.Role)
+
+	// SDK routes are public.
+	require.Equal(t, 200, do(http.MethodPost, "/fn/register", nil))
+	require.Equal(t, 401, do(http.MethodGet, "/v0/runs/123/batch", nil))
+	require.Equal(t, 401, do(http.MethodGet, "/v0/runs/123/actions", nil))
+	require.Equal(t, 401, do(http.MethodDelete, "/v0/runs/123", nil))
+}
+
+func TestSigningKeyAuth(t *testing.T) {
+	kr := keyring.New("signkey-test-12345678", nil, nil)
+	hashed, err := keyring.HashSigningKey(kr.SigningKey())
+	require.NoError(t, err)
+
+	// Signing keys alone don't enable auth.
+	a, err := New(Config{SigningKeys: kr})
+	require.NoError(t, err)
+	require.False(t, a.Enabled())
+
+	a, err = New(Config{
+		Tokens:      []TokenConfig{{Name: "ci", Token: "viewer-token"}},
+		SigningKeys: kr,
+	})
+	require.NoError(t, err)
+
+	auth := func(token string) (*Principal, error) {
+		r := httptest.NewRequest(http.MethodPost, "/v1/realtime/token", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		return a.Authenticate(r)
+	}
+
+	p, err := auth(hashed)
+	require.NoError(t, err)
+	require.Equal(t, RoleOperator, p.Role)
+	require.Equal(t, "signing_key", p.Method)
+
+	p, err = auth("viewer-token")
+	require.NoError(t, err)
+	require.Equal(t, "ci", p.Name)
+
+	_, err = auth(kr.SigningKey())
+	require.ErrorIs(t, err, ErrUnauthorized)
+
+	// SDKs load run data using their signing key.
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, path := range []string{"/v0/runs/123/batch", "/v0/runs/123/actions"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, 401, w.Code)
+
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Authorization", "Bearer "+hashed)
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		require.Equal(t, 200, w.Code)
+	}
+
+	// Subscriptions are authenticated via realtime tokens.
+	require.True(t, IsPublicPath("/v1/realtime/sse"))
+	require.False(t, IsPublicPath("/v1/realtime/token"))
+}
+
+func TestMiddlewareDisabled(t *testing.T) {
+	a, err := New(Config{})
+	require.NoError(t, err)
+	require.False(t, a.Enabled())
+
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/v1/runs/123", nil))
+	require.Equal(t, 200, w.Code)
+}
+
+func TestNewInvalidRole(t *testing.T) {
+	_, err := New(Config{Tokens: []TokenConfig{{Token: "x", Role: "root"}}})
+	require.ErrorContains(t, err, "invalid role 'root'")
+}
@@ -0,0 +1,162 @@
+package rbac
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TokenConfig configures a static API token.
+type TokenConfig struct {
+	// Name identifies the token in logs, eg. "ci".
+	Name string `json:"name"`
+	// Token is the secret token.
+	Token string `json:"token"`
+	// Role is the token's role, defaulting to viewer.
+	Role Role `json:"role,omitempty"`
+}
+
+// UserConfig configures a user which logs in via HTTP basic auth.
+type UserConfig struct {
+	Username string `json:"username"`
+	// Password is either a bcrypt hash, or the plaintext password.
+	Password string `json:"password"`
+	// Role is the user's role, defaulting to viewer.
+	Role Role `json:"role,omitempty"`
+}
+
+// NewTokenAuthenticator returns an authenticator which accepts the given static
+// tokens as bearer tokens.
+func NewTokenAuthenticator(tokens []TokenConfig) (Authenticator, error) {
+	t := tokenAuthenticator{}
+	for n, tc := range tokens {
+		if tc.Token == "" {
+			return nil, fmt.Errorf("auth token %d has no token", n)
+		}
+		role, err := defaultRole(tc.Role)
+		if err != nil {
+			return nil, fmt.Errorf("auth token %q: %w", tc.Name, err)
+		}
+		name := tc.Name
+		if name == "" {
+			name = fmt.Sprintf("token-%d", n)
+		}
+		t = append(t, token{
+			hash:      sha256.Sum256([]byte(tc.Token)),
+			principal: Principal{Name: name, Role: role, Method: "token"},
+		})
+	}
+	return t, nil
+}
+
+// SigningKeyValidator validates hashed signing keys sent by SDKs.
+type SigningKeyValidator interface {
+	ValidHashedSigningKey(hashed string) bool
+}
+
+// NewSigningKeyAuthenticator returns an authenticator which accepts hashed
+// signing keys as bearer tokens, granting SDKs the operator role.  Unknown
+// bearer tokens are left to other authenticators.
+func NewSigningKeyAuthenticator(keys SigningKeyValidator) Authenticator {
+	return signingKeyAuthenticator{keys: keys}
+}
+
+type signingKeyAuthenticator struct {
+	keys SigningKeyValidator
+}
+
+func (s signingKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, nil
+	}
+	if !s.keys.ValidHashedSigningKey(strings.TrimPrefix(header, "Bearer ")) {
+		return nil, nil
+	}
+	return &Principal{Name: "signing-key", Role: RoleOperator, Method: "signing_key"}, nil
+}
+
+type token struct {
+	hash      [32]byte
+	principal Principal
+}
+
+type tokenAuthenticator []token
+
+func (t tokenAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, nil
+	}
+
+	// Compare hashes so that comparisons are constant time regardless of the
+	// token's length.
+	hash := sha256.Sum256([]byte(strings.TrimPrefix(header, "Bearer ")))
+	for _, tok := range t {
+		if subtle.ConstantTimeCompare(hash[:], tok.hash[:]) == 1 {
+			p := tok.principal
+			return &p, nil
+		}
+	}
+	return nil, ErrUnauthorized
+}
+
+// NewBasicAuthenticator returns an authenticator which accepts the given users
+// via HTTP basic auth.
+func NewBasicAuthenticator(users []UserConfig) (Authenticator, error) {
+	b := basicAuthenticator{}
+	for _, uc := range users {
+		if uc.Username == "" || uc.Password == "" {
+			return nil, fmt.Errorf("auth users must have a username and password")
+		}
+		role, err := defaultRole(uc.Role)
+		if err != nil {
+			return nil, fmt.Errorf("auth user %q: %w", uc.Username, err)
+		}
+		uc.Role = role
+		b[uc.Username] = uc
+	}
+	return b, nil
+}
+
+type basicAuthenticator map[string]UserConfig
+
+func (b basicAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, nil
+	}
+
+	user, ok := b[username]
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	if strings.HasPrefix(user.Password, "$2") {
+		if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+			return nil, ErrUnauthorized
+		}
+	} else {
+		expected := sha256.Sum256([]byte(user.Password))
+		actual := sha256.Sum256([]byte(password))
+		if subtle.ConstantTimeCompare(expected[:], actual[:]) != 1 {
+			return nil, ErrUnauthorized
+		}
+	}
+
+	return &Principal{Name: username, Role: user.Role, Method: "basic"}, nil
+}
+
+func defaultRole(r Role) (Role, error) {
+	if r == "" {
+		return RoleViewer, nil
+	}
+	if !r.IsValid() {
+		return "", fmt.Errorf("invalid role '%s': must be one of 'viewer', 'operator' or 'admin'", r)
+	}
+	return r, nil
+}
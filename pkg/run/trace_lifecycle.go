@@ -1088,16 +1088,12 @@ func (l traceLifecycle) OnWaitForEvent(
 	ctx = l.extractTraceCtx(ctx, md, false)
 
 	runID := md.ID.RunID
-	opts, err := gen.WaitForEventOpts()
+	eventName, expr, err := gen.WaitTarget()
 	if err != nil {
 		l.log.Error("error retrieving wait opts", "error", err, "meta", md, "lifecycle", "OnWaitForEvent")
 		return
 	}
-	expires, err := opts.Expires()
-	if err != nil {
-		l.log.Error("error retrieving expiration for wait", "error", err, "meta", md, "lifecycle", "OnWaitForEvent")
-		return
-	}
+	expires := pause.Expires.Time()
 
 	v, ok := pause.Metadata[consts.OtelPropagationKey]
 	if !ok {
@@ -1127,15 +1123,15 @@ func (l traceLifecycle) OnWaitForEvent(
 			attribute.Int(consts.OtelSysStepAttempt, 0),
 			attribute.Int(consts.OtelSysStepMaxAttempt, 1),
 			attribute.String(consts.OtelSysStepGroupID, item.GroupID),
-			attribute.String(consts.OtelSysStepWaitEventName, opts.Event),
+			attribute.String(consts.OtelSysStepWaitEventName, eventName),
 			attribute.Int64(consts.OtelSysStepWaitExpires, expires.UnixMilli()),
 			attribute.String(consts.OtelSysStepDisplayName, gen.UserDefinedName()),
 		),
 	)
 	defer span.End()
 
-	if opts.If != nil {
-		span.SetAttributes(attribute.String(consts.OtelSysStepWaitExpression, *opts.If))
+	if expr != nil {
+		span.SetAttributes(attribute.String(consts.OtelSysStepWaitExpression, *expr))
 	}
 }
 
@@ -1184,7 +1180,7 @@ func (l traceLifecycle) OnWaitForEventResumed(
 					attribute.String(consts.OtelSysStepDisplayName, pause.StepName),
 					attribute.String(consts.OtelSysStepOpcode, enums.OpcodeWaitForEvent.String()),
 					attribute.Int64(consts.OtelSysStepWaitExpires, pause.Expires.Time().UnixMilli()),
-					attribute.Bool(consts.OtelSysStepWaitExpired, r.EventID == nil),
+					attribute.Bool(consts.OtelSysStepWaitExpired, r.IsTimeout),
 					attribute.String(consts.OtelSysStepWaitMatchedEventID, returnedEventID),
 				),
 			)
@@ -1192,6 +1188,8 @@ func (l traceLifecycle) OnWaitForEventResumed(
 
 			if pause.Event != nil {
 				span.SetAttributes(attribute.String(consts.OtelSysStepWaitEventName, *pause.Event))
+			} else if pause.IsSignal() {
+				span.SetAttributes(attribute.String(consts.OtelSysStepWaitEventName, *pause.SignalID))
 			}
 			if pause.Expression != nil {
 				span.SetAttributes(attribute.String(consts.OtelSysStepWaitExpression, *pause.Expression))
@@ -38,9 +38,17 @@ type SDKFunction struct {
 	// key.
 	Idempotency *string `json:"idempotency,omitempty"`
 
+	// IdempotencyPeriod optionally shortens the period in which the idempotency key is
+	// unique, eg. "1h".
+	IdempotencyPeriod *string `json:"idempotencyPeriod,omitempty"`
+
 	// RateLimit allows specifying custom rate limiting for the function.
 	RateLimit *inngest.RateLimit `json:"rateLimit,omitempty"`
 
+	// Singleton ensures that only one run of the function is in progress at a time,
+	// optionally for each unique key.
+	Singleton *inngest.Singleton `json:"singleton,omitempty"`
+
 	// Throttle represents a soft rate limit for gating function starts.  Any function runs
 	// over the throttle period will be enqueued in the backlog to run at the next available
 	// time.
@@ -50,6 +58,9 @@ type SDKFunction struct {
 	// function.
 	Retries *int `json:"retries,omitempty"`
 
+	// RetryPolicy configures the delay between retries of the function's steps.
+	RetryPolicy *inngest.RetryPolicy `json:"retryPolicy,omitempty"`
+
 	Debounce *inngest.Debounce `json:"debounce,omitempty"`
 
 	Timeouts *inngest.Timeouts `json:"timeouts,omitempty"`
@@ -68,10 +79,12 @@ func (s SDKFunction) Function() (*inngest.Function, error) {
 		Triggers:    s.Triggers,
 		Priority:    s.Priority,
 		RateLimit:   s.RateLimit,
+		Singleton:   s.Singleton,
 		Throttle:    s.Throttle,
 		Cancel:      s.Cancel,
 		Debounce:    s.Debounce,
 		Timeouts:    s.Timeouts,
+		RetryPolicy: s.RetryPolicy,
 	}
 	// Ensure we set the slug here if s.ID is nil.  This defaults to using
 	// the slugged version of the function name.
@@ -83,10 +96,9 @@ func (s SDKFunction) Function() (*inngest.Function, error) {
 	}
 	f.EventBatch = eventbatch
 	if s.Idempotency != nil {
-		f.RateLimit = &inngest.RateLimit{
-			Limit:  1,
-			Period: consts.FunctionIdempotencyPeriod.String(),
-			Key:    s.Idempotency,
+		f.Idempotency = &inngest.Idempotency{
+			Key:    *s.Idempotency,
+			Period: s.IdempotencyPeriod,
 		}
 	}
 
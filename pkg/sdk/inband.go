@@ -0,0 +1,63 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SyncKindInBand is the value of the sync kind header for in-band syncs.
+const SyncKindInBand = "in_band"
+
+// InBandSyncResponse is the registration returned by an SDK within the response
+// to an in-band sync.
+type InBandSyncResponse struct {
+	AppID        string        `json:"app_id"`
+	AppVersion   string        `json:"appVersion,omitempty"`
+	Capabilities Capabilities  `json:"capabilities"`
+	Env          string        `json:"env"`
+	Framework    string        `json:"framework"`
+	Functions    []SDKFunction `json:"functions"`
+	Platform     string        `json:"platform"`
+	SDKLanguage  string        `json:"sdk_language"`
+	SDKVersion   string        `json:"sdk_version"`
+	URL          string        `json:"url"`
+}
+
+// RegisterRequest returns the equivalent out-of-band registration.
+func (r InBandSyncResponse) RegisterRequest() RegisterRequest {
+	return RegisterRequest{
+		V:            "1",
+		URL:          r.URL,
+		DeployType:   DeployTypePing,
+		SDK:          fmt.Sprintf("%s:%s", r.SDKLanguage, r.SDKVersion),
+		Framework:    r.Framework,
+		AppName:      r.AppID,
+		AppVersion:   r.AppVersion,
+		Functions:    r.Functions,
+		Headers:      Headers{Env: r.Env, Platform: r.Platform},
+		Capabilities: r.Capabilities,
+	}
+}
+
+// ParseRegistration parses either a RegisterRequest sent by an SDK during an
+// out-of-band sync, or the response to an in-band sync.
+func ParseRegistration(byt []byte) (RegisterRequest, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(byt, &fields); err != nil {
+		return RegisterRequest{}, fmt.Errorf("invalid registration: %w", err)
+	}
+
+	if _, ok := fields["app_id"]; ok {
+		r := InBandSyncResponse{}
+		if err := json.Unmarshal(byt, &r); err != nil {
+			return RegisterRequest{}, fmt.Errorf("invalid registration: %w", err)
+		}
+		return r.RegisterRequest(), nil
+	}
+
+	r := RegisterRequest{}
+	if err := json.Unmarshal(byt, &r); err != nil {
+		return RegisterRequest{}, fmt.Errorf("invalid registration: %w", err)
+	}
+	return r, nil
+}
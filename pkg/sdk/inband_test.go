@@ -0,0 +1,32 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRegistration(t *testing.T) {
+	t.Run("out of band", func(t *testing.T) {
+		r, err := ParseRegistration([]byte(`{"appName":"app","sdk":"go:v0.7.0","functions":[{"id":"app-fn","name":"fn"}]}`))
+		require.NoError(t, err)
+		require.Equal(t, "app", r.AppName)
+		require.Equal(t, "go", r.SDKLanguage())
+		require.Len(t, r.Functions, 1)
+	})
+
+	t.Run("in band", func(t *testing.T) {
+		r, err := ParseRegistration([]byte(`{"app_id":"app","sdk_language":"js","sdk_version":"3.30.0","env":"prod","functions":[{"id":"app-fn","name":"fn"}]}`))
+		require.NoError(t, err)
+		require.Equal(t, "app", r.AppName)
+		require.Equal(t, "js", r.SDKLanguage())
+		require.Equal(t, "3.30.0", r.SDKVersion())
+		require.Equal(t, "prod", r.Headers.Env)
+		require.Len(t, r.Functions, 1)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ParseRegistration([]byte(`[]`))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,39 @@
+package testkit
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+)
+
+// Clock is a clockwork.Clock whose time can be moved forward.  Timers and
+// tickers fire in real time, so that the queue continues to poll, while any
+// work scheduled in the future becomes due as soon as the clock passes it.
+type Clock struct {
+	clockwork.Clock
+
+	offset atomic.Int64
+}
+
+// NewClock returns a Clock set to the current time.
+func NewClock() *Clock {
+	return &Clock{Clock: clockwork.NewRealClock()}
+}
+
+// Now returns the current virtual time.
+func (c *Clock) Now() time.Time {
+	return c.Clock.Now().Add(time.Duration(c.offset.Load()))
+}
+
+// Since returns the virtual time elapsed since t.
+func (c *Clock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	if d > 0 {
+		c.offset.Add(int64(d))
+	}
+}
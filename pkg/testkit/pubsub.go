@@ -0,0 +1,71 @@
+package testkit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/inngest/inngest/pkg/pubsub"
+)
+
+// handled is a pubsub.PublishSubscriber which records when each published
+// message has been handled by a subscriber, so that Send can return once the
+// runner has scheduled every function and debounce.  Without this, moving the
+// clock forward immediately after sending an event races with the runner.
+type handled struct {
+	pubsub.PublishSubscriber
+
+	mu      sync.Mutex
+	pending map[string]chan struct{}
+}
+
+func newHandled(ps pubsub.PublishSubscriber) *handled {
+	return &handled{PublishSubscriber: ps, pending: map[string]chan struct{}{}}
+}
+
+func (h *handled) Publish(ctx context.Context, topic string, m pubsub.Message) error {
+	_, err := h.publish(ctx, topic, m)
+	return err
+}
+
+// publish publishes the message, returning a channel which is closed once a
+// subscriber has handled the message.
+func (h *handled) publish(ctx context.Context, topic string, m pubsub.Message) (<-chan struct{}, error) {
+	done := make(chan struct{})
+	h.mu.Lock()
+	h.pending[m.Data] = done
+	h.mu.Unlock()
+
+	if err := h.PublishSubscriber.Publish(ctx, topic, m); err != nil {
+		h.forget(m.Data)
+		return nil, err
+	}
+	return done, nil
+}
+
+func (h *handled) forget(data string) {
+	h.mu.Lock()
+	delete(h.pending, data)
+	h.mu.Unlock()
+}
+
+func (h *handled) Subscribe(ctx context.Context, topic string, run pubsub.PerformFunc) error {
+	return h.PublishSubscriber.Subscribe(ctx, topic, h.wrap(run))
+}
+
+func (h *handled) SubscribeN(ctx context.Context, topic string, run pubsub.PerformFunc, concurrency int64) error {
+	return h.PublishSubscriber.SubscribeN(ctx, topic, h.wrap(run), concurrency)
+}
+
+func (h *handled) wrap(run pubsub.PerformFunc) pubsub.PerformFunc {
+	return func(ctx context.Context, m pubsub.Message) error {
+		defer func() {
+			h.mu.Lock()
+			if done, ok := h.pending[m.Data]; ok {
+				close(done)
+				delete(h.pending, m.Data)
+			}
+			h.mu.Unlock()
+		}()
+		return run(ctx, m)
+	}
+}
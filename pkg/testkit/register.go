@@ -0,0 +1,99 @@
+package testkit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/inngest/inngest/pkg/cqrs/sync"
+	"github.com/inngest/inngest/pkg/deploy"
+	"github.com/inngest/inngest/pkg/devserver"
+	"github.com/inngest/inngest/pkg/event"
+	"github.com/inngest/inngest/pkg/headers"
+	"github.com/inngest/inngest/pkg/publicerr"
+	"github.com/inngest/inngest/pkg/sdk"
+)
+
+// Register registers the app and functions within the given request, as the
+// dev server does when an SDK syncs.  Steps are invoked using the URLs within
+// the request.
+func (k *Kit) Register(ctx context.Context, r sdk.RegisterRequest) error {
+	_, err := devserver.SyncApp(ctx, k.data, r)
+	return err
+}
+
+// RegisterHandler serves the given SDK handler and syncs it, as the dev
+// server does when adding an app.  The SDK must be configured to register
+// with the kit's URL.
+func (k *Kit) RegisterHandler(ctx context.Context, h http.Handler) error {
+	app := httptest.NewServer(h)
+	k.mu.Lock()
+	k.apps = append(k.apps, app)
+	k.mu.Unlock()
+
+	res := deploy.Ping(ctx, app.URL, headers.ServerKindDev, k.signingKey, false)
+	if res.Err != nil {
+		return fmt.Errorf("error syncing app: %w", res.Err)
+	}
+	return nil
+}
+
+// router returns the kit's API, handling the subset of the dev server's API
+// used by SDKs.
+func (k *Kit) router() http.Handler {
+	r := chi.NewRouter()
+	r.Post("/fn/register", func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		req, err := sdk.FromReadCloser(r.Body, sdk.FromReadCloserOpts{})
+		if err != nil {
+			_ = publicerr.WriteHTTP(w, publicerr.Wrap(err, 400, "Invalid request"))
+			return
+		}
+		if err := k.Register(r.Context(), req); err != nil {
+			_ = publicerr.WriteHTTP(w, publicerr.Wrap(err, 400, "Error registering functions"))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(sync.Reply{OK: true, Modified: true})
+	})
+	r.Post("/e/{key}", func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		var raw json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			_ = publicerr.WriteHTTP(w, publicerr.Wrap(err, 400, "Invalid request"))
+			return
+		}
+
+		evts := []event.Event{}
+		if len(raw) > 0 && raw[0] == '[' {
+			err := json.Unmarshal(raw, &evts)
+			if err != nil {
+				_ = publicerr.WriteHTTP(w, publicerr.Wrap(err, 400, "Invalid request"))
+				return
+			}
+		} else {
+			evt := event.Event{}
+			if err := json.Unmarshal(raw, &evt); err != nil {
+				_ = publicerr.WriteHTTP(w, publicerr.Wrap(err, 400, "Invalid request"))
+				return
+			}
+			evts = append(evts, evt)
+		}
+
+		ids, err := k.Send(r.Context(), evts...)
+		if err != nil {
+			_ = publicerr.WriteHTTP(w, publicerr.Wrap(err, 400, err.Error()))
+			return
+		}
+		strs := make([]string, len(ids))
+		for n, id := range ids {
+			strs[n] = id.String()
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"ids": strs, "status": 200})
+	})
+	return r
+}
@@ -0,0 +1,268 @@
+package testkit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/execution"
+	"github.com/inngest/inngest/pkg/execution/history"
+	"github.com/oklog/ulid/v2"
+)
+
+// DefaultWaitTimeout bounds waits when the context has no deadline.
+const DefaultWaitTimeout = 10 * time.Second
+
+// Run is a snapshot of a function run.
+type Run struct {
+	ID         ulid.ULID
+	FunctionID uuid.UUID
+	// EventID is the internal ID of the event which triggered the run.
+	EventID ulid.ULID
+	Status  enums.RunStatus
+	// SkipReason is the reason the run was skipped, if skipped.
+	SkipReason enums.SkipReason
+	// CancelReason is the reason for cancellations made by the system, eg.
+	// when replaced by a new run of a singleton function.
+	CancelReason execution.CancelReason
+	// Output is the function's output or error once the run has ended.
+	Output json.RawMessage
+	// Steps lists the run's steps in the order they started.
+	Steps []Step
+}
+
+// Ended returns whether the run has completed, failed, or been cancelled.
+func (r Run) Ended() bool {
+	return enums.RunStatusEnded(r.Status)
+}
+
+// Step returns the step with the given name.
+func (r Run) Step(name string) (Step, bool) {
+	for _, s := range r.Steps {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Step{}, false
+}
+
+// Step is a snapshot of a single step within a run.
+type Step struct {
+	ID   string
+	Name string
+	// Status is the step's latest history entry, eg. StepCompleted, or
+	// StepSleeping and StepWaiting while the run is paused.
+	Status enums.HistoryType
+	// Output is the step's output, once the step has completed.
+	Output json.RawMessage
+	// Error is the step's error, if the step errored or failed.
+	Error json.RawMessage
+	// Timeout is set when a wait step times out.
+	Timeout bool
+}
+
+// Runs returns the runs triggered by the given event.
+func (k *Kit) Runs(eventID ulid.ULID) []Run {
+	return k.history.runs(func(r *Run) bool { return r.EventID == eventID })
+}
+
+// Run returns the run with the given ID.
+func (k *Kit) Run(runID ulid.ULID) (Run, bool) {
+	runs := k.history.runs(func(r *Run) bool { return r.ID == runID })
+	if len(runs) == 0 {
+		return Run{}, false
+	}
+	return runs[0], true
+}
+
+// WaitForRuns waits until the given event triggers n runs, returning the runs.
+func (k *Kit) WaitForRuns(ctx context.Context, eventID ulid.ULID, n int) ([]Run, error) {
+	var runs []Run
+	err := k.wait(ctx, func() bool {
+		runs = k.Runs(eventID)
+		return len(runs) >= n
+	})
+	if err != nil {
+		return runs, fmt.Errorf("waiting for %d runs of event %s, found %d: %w", n, eventID, len(runs), err)
+	}
+	return runs, nil
+}
+
+// WaitUntil waits until f returns true for the given run, returning the run.
+func (k *Kit) WaitUntil(ctx context.Context, runID ulid.ULID, f func(r Run) bool) (Run, error) {
+	var run Run
+	err := k.wait(ctx, func() bool {
+		var ok bool
+		run, ok = k.Run(runID)
+		return ok && f(run)
+	})
+	if err != nil {
+		return run, fmt.Errorf("waiting for run %s: %w", runID, err)
+	}
+	return run, nil
+}
+
+// WaitForEnd waits until the given run ends, returning the run.
+func (k *Kit) WaitForEnd(ctx context.Context, runID ulid.ULID) (Run, error) {
+	return k.WaitUntil(ctx, runID, Run.Ended)
+}
+
+// WaitForStep waits until the named step of the given run has the given
+// status, eg. to wait for a run to sleep prior to calling FastForward.
+func (k *Kit) WaitForStep(ctx context.Context, runID ulid.ULID, name string, status enums.HistoryType) (Run, error) {
+	return k.WaitUntil(ctx, runID, func(r Run) bool {
+		s, ok := r.Step(name)
+		return ok && s.Status == status
+	})
+}
+
+func (k *Kit) wait(ctx context.Context, f func() bool) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultWaitTimeout)
+		defer cancel()
+	}
+
+	t := time.NewTicker(pollTick)
+	defer t.Stop()
+	for !f() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	return nil
+}
+
+// recorder is a history.Driver which records the state of each run.
+type recorder struct {
+	mu    sync.Mutex
+	byID  map[ulid.ULID]*Run
+	order []*Run
+}
+
+func newRecorder() *recorder {
+	return &recorder{byID: map[ulid.ULID]*Run{}}
+}
+
+func (r *recorder) Close(context.Context) error {
+	return nil
+}
+
+func (r *recorder) Write(_ context.Context, h history.History) error {
+	typ, err := enums.HistoryTypeString(h.Type)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run, ok := r.byID[h.RunID]
+	if !ok {
+		run = &Run{
+			ID:         h.RunID,
+			FunctionID: h.FunctionID,
+			EventID:    h.EventID,
+			Status:     enums.RunStatusScheduled,
+		}
+		r.byID[h.RunID] = run
+		r.order = append(r.order, run)
+	}
+
+	switch typ {
+	case enums.HistoryTypeFunctionScheduled:
+		return nil
+	case enums.HistoryTypeFunctionStarted:
+		run.Status = enums.RunStatusRunning
+		return nil
+	case enums.HistoryTypeFunctionCompleted:
+		run.Status = enums.RunStatusCompleted
+		run.Output = output(h)
+		return nil
+	case enums.HistoryTypeFunctionFailed:
+		run.Status = enums.RunStatusFailed
+		run.Output = output(h)
+		return nil
+	case enums.HistoryTypeFunctionCancelled:
+		run.Status = enums.RunStatusCancelled
+		if h.Cancel != nil {
+			run.CancelReason = h.Cancel.Reason
+		}
+		return nil
+	case enums.HistoryTypeFunctionSkipped:
+		run.Status = enums.RunStatusSkipped
+		if h.SkipReason != nil {
+			run.SkipReason = *h.SkipReason
+		}
+		return nil
+	}
+
+	if h.StepID == nil {
+		return nil
+	}
+
+	var step *Step
+	for n := range run.Steps {
+		if run.Steps[n].ID == *h.StepID {
+			step = &run.Steps[n]
+		}
+	}
+	if step == nil {
+		run.Steps = append(run.Steps, Step{ID: *h.StepID})
+		step = &run.Steps[len(run.Steps)-1]
+	}
+	if h.StepName != nil {
+		step.Name = *h.StepName
+	}
+	step.Status = typ
+	if h.WaitResult != nil {
+		step.Timeout = h.WaitResult.Timeout
+	}
+
+	// Step output is wrapped as either {"data": ...} or {"error": ...}.
+	wrapped := struct {
+		Data  json.RawMessage `json:"data"`
+		Error json.RawMessage `json:"error"`
+	}{}
+	if out := output(h); out != nil {
+		step.Output = out
+		if json.Unmarshal(out, &wrapped) == nil && (wrapped.Data != nil || wrapped.Error != nil) {
+			step.Output, step.Error = wrapped.Data, wrapped.Error
+		}
+	}
+	return nil
+}
+
+// runs returns a copy of every run matching f.
+func (r *recorder) runs(f func(r *Run) bool) []Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := []Run{}
+	for _, run := range r.order {
+		if !f(run) {
+			continue
+		}
+		copied := *run
+		copied.Steps = append([]Step(nil), run.Steps...)
+		result = append(result, copied)
+	}
+	return result
+}
+
+func output(h history.History) json.RawMessage {
+	if h.Result == nil || h.Result.Output == "" {
+		return nil
+	}
+	if json.Valid([]byte(h.Result.Output)) {
+		return json.RawMessage(h.Result.Output)
+	}
+	byt, _ := json.Marshal(h.Result.Output)
+	return byt
+}
@@ -0,0 +1,497 @@
+// Package testkit runs the executor, runner and queue in-process, allowing
+// functions to be tested within Go tests without a running server.
+//
+// Each Kit stores state in its own miniredis instance and in-memory database,
+// and uses a virtual clock:  FastForward moves the clock forward to resolve
+// sleeps, waitForEvent timeouts, and debounce, batch and throttle windows
+// without waiting.
+package testkit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/inngest/inngest/pkg/config"
+	"github.com/inngest/inngest/pkg/consts"
+	"github.com/inngest/inngest/pkg/cqrs"
+	"github.com/inngest/inngest/pkg/cqrs/base_cqrs"
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/event"
+	"github.com/inngest/inngest/pkg/execution"
+	"github.com/inngest/inngest/pkg/execution/batch"
+	"github.com/inngest/inngest/pkg/execution/debounce"
+	"github.com/inngest/inngest/pkg/execution/driver/httpdriver"
+	"github.com/inngest/inngest/pkg/execution/executor"
+	"github.com/inngest/inngest/pkg/execution/history"
+	"github.com/inngest/inngest/pkg/execution/queue"
+	"github.com/inngest/inngest/pkg/execution/ratelimit"
+	"github.com/inngest/inngest/pkg/execution/runner"
+	"github.com/inngest/inngest/pkg/execution/singleton"
+	"github.com/inngest/inngest/pkg/execution/state"
+	"github.com/inngest/inngest/pkg/execution/state/redis_state"
+	sv2 "github.com/inngest/inngest/pkg/execution/state/v2"
+	"github.com/inngest/inngest/pkg/expressions"
+	"github.com/inngest/inngest/pkg/logger"
+	"github.com/inngest/inngest/pkg/pubsub"
+	"github.com/inngest/inngest/pkg/service"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/rueidis"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// pollTick is the interval at which the queue polls for work.
+	pollTick = 10 * time.Millisecond
+
+	// readyEvent is sent when starting a kit to wait for the runner.
+	readyEvent = "testkit/ready"
+)
+
+// Opt configures a Kit.
+type Opt func(k *Kit)
+
+// WithSigningKey signs requests to apps using the given signing key.
+func WithSigningKey(key string) Opt {
+	return func(k *Kit) {
+		k.signingKey = key
+	}
+}
+
+// WithLogger logs from every service using the given logger.  By default,
+// logs are discarded.
+func WithLogger(l zerolog.Logger) Opt {
+	return func(k *Kit) {
+		k.log = l
+	}
+}
+
+// Kit runs functions in-process.  Functions are registered via Register or
+// RegisterHandler, and are triggered by events sent via Send.
+type Kit struct {
+	signingKey string
+	log        zerolog.Logger
+
+	clock   *Clock
+	redis   *miniredis.Miniredis
+	rc      rueidis.Client
+	data    cqrs.Manager
+	ps      *handled
+	topic   string
+	history *recorder
+	exec    execution.Executor
+
+	// server hosts the kit's API, used by SDKs to register and send events.
+	server *httptest.Server
+
+	// apps lists servers started by RegisterHandler.
+	apps []*httptest.Server
+	mu   sync.Mutex
+
+	db     *sql.DB
+	cancel context.CancelFunc
+	// done receives the result of the running services, and is nil if the
+	// services aren't running.
+	done  chan error
+	close sync.Once
+}
+
+// New starts a Kit, stopping it when the test completes.
+func New(t testing.TB, opts ...Opt) *Kit {
+	t.Helper()
+
+	k, err := start(opts...)
+	if err != nil {
+		t.Fatalf("error starting test kit: %s", err)
+	}
+	t.Cleanup(k.Close)
+	return k
+}
+
+func start(opts ...Opt) (*Kit, error) {
+	k := &Kit{
+		log:     zerolog.Nop(),
+		clock:   NewClock(),
+		topic:   fmt.Sprintf("testkit-%s", ulid.Make()),
+		history: newRecorder(),
+	}
+	for _, o := range opts {
+		o(k)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	k.cancel = cancel
+	ctx = logger.With(ctx, k.log)
+	ctx = logger.WithStdlib(ctx, logger.VoidLogger())
+
+	if err := k.boot(ctx); err != nil {
+		k.Close()
+		return nil, err
+	}
+	if err := k.ready(ctx); err != nil {
+		k.Close()
+		return nil, err
+	}
+	return k, nil
+}
+
+// boot creates and starts every service, using a single Redis instance for
+// state and the queue.
+func (k *Kit) boot(ctx context.Context) error {
+	var err error
+	k.db, err = base_cqrs.NewIsolated(k.topic)
+	if err != nil {
+		return fmt.Errorf("error creating database: %w", err)
+	}
+	k.data = base_cqrs.NewCQRS(k.db, "sqlite")
+
+	k.redis = miniredis.NewMiniRedis()
+	if err := k.redis.Start(); err != nil {
+		return fmt.Errorf("error starting redis: %w", err)
+	}
+	k.rc, err = rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:       []string{k.redis.Addr()},
+		DisableCache:      true,
+		ForceSingleClient: true,
+	})
+	if err != nil {
+		return fmt.Errorf("error connecting to redis: %w", err)
+	}
+
+	// Expire keys in real time, as the dev server does.
+	go func() {
+		t := time.NewTicker(50 * time.Millisecond)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				k.redis.FastForward(50 * time.Millisecond)
+			}
+		}
+	}()
+
+	unshardedClient := redis_state.NewUnshardedClient(k.rc, redis_state.StateDefaultKey, redis_state.QueueDefaultKey)
+	shardedClient := redis_state.NewShardedClient(redis_state.ShardedClientOpts{
+		UnshardedClient:        unshardedClient,
+		FunctionRunStateClient: k.rc,
+		StateDefaultKey:        redis_state.StateDefaultKey,
+		FnRunIsSharded:         redis_state.AlwaysShardOnRun,
+		BatchClient:            k.rc,
+		QueueDefaultKey:        redis_state.QueueDefaultKey,
+	})
+
+	queueShard := redis_state.QueueShard{Name: consts.DefaultQueueShardName, RedisClient: unshardedClient.Queue(), Kind: string(enums.QueueShardKindRedis)}
+	shardSelector := func(ctx context.Context, _ uuid.UUID, _ *string) (redis_state.QueueShard, error) {
+		return queueShard, nil
+	}
+
+	sm, err := redis_state.New(
+		ctx,
+		redis_state.WithShardedClient(shardedClient),
+		redis_state.WithUnshardedClient(unshardedClient),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating state store: %w", err)
+	}
+
+	rq := redis_state.NewQueue(
+		queueShard,
+		redis_state.WithRunMode(redis_state.QueueRunMode{
+			Sequential:    true,
+			Scavenger:     true,
+			Partition:     true,
+			Continuations: true,
+		}),
+		redis_state.WithIdempotencyTTL(time.Hour),
+		redis_state.WithPollTick(pollTick),
+		redis_state.WithClock(k.clock),
+		redis_state.WithConcurrencyLimitGetter(k.concurrencyLimits),
+		redis_state.WithShardSelector(shardSelector),
+		redis_state.WithQueueShardClients(map[string]redis_state.QueueShard{
+			consts.DefaultQueueShardName: queueShard,
+		}),
+	)
+
+	batcher := batch.NewRedisBatchManager(shardedClient.Batch(), rq)
+	debouncer, err := debounce.NewRedisDebouncerWithMigration(debounce.DebouncerOpts{
+		PrimaryDebounceClient: unshardedClient.Debounce(),
+		PrimaryQueue:          rq,
+		PrimaryQueueShard:     queueShard,
+		ShouldMigrate: func(ctx context.Context, accountID uuid.UUID) bool {
+			return false
+		},
+		Clock: k.clock,
+	})
+	if err != nil {
+		return fmt.Errorf("error creating debouncer: %w", err)
+	}
+
+	conf := config.Config{}
+	conf.EventStream.Service.Set(config.InMemoryMessaging{Topic: k.topic})
+
+	ps, err := pubsub.NewPublishSubscriber(ctx, conf.EventStream.Service)
+	if err != nil {
+		return fmt.Errorf("failed to create publisher: %w", err)
+	}
+	k.ps = newHandled(ps)
+
+	client := &http.Client{
+		Timeout:       consts.MaxFunctionTimeout,
+		CheckRedirect: httpdriver.CheckRedirect,
+	}
+
+	exec, err := executor.NewExecutor(
+		executor.WithStateManager(redis_state.MustRunServiceV2(sm)),
+		executor.WithPauseManager(sm),
+		executor.WithRuntimeDrivers(httpdriver.NewExecutor(client, []byte(k.signingKey))),
+		executor.WithExpressionAggregator(expressions.NewAggregator(ctx, 100, 100, sm.(expressions.EvaluableLoader), nil)),
+		executor.WithQueue(rq),
+		executor.WithLogger(logger.From(ctx)),
+		executor.WithFunctionLoader(k.data.(state.FunctionLoader)),
+		executor.WithLifecycleListeners(
+			history.NewLifecycleListener(logger.VoidLogger(), k.history),
+		),
+		executor.WithStepLimits(func(id sv2.ID) int {
+			return consts.DefaultMaxStepLimit
+		}),
+		executor.WithStateSizeLimits(func(id sv2.ID) int {
+			return consts.DefaultMaxStateSizeLimit
+		}),
+		executor.WithInvokeFailHandler(func(ctx context.Context, opts execution.InvokeFailHandlerOpts, evts []event.Event) error {
+			for _, evt := range evts {
+				if _, _, err := k.publish(ctx, opts.OriginalEvent.GetWorkspaceID(), evt); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+		executor.WithSendingEventHandler(func(ctx context.Context, evt event.Event, item queue.Item) error {
+			_, _, err := k.publish(ctx, item.WorkspaceID, evt)
+			return err
+		}),
+		executor.WithDebouncer(debouncer),
+		executor.WithBatcher(batcher),
+		executor.WithSingletonLocker(singleton.New(k.rc, "{singleton}:")),
+		executor.WithAssignedQueueShard(queueShard),
+		executor.WithShardSelector(shardSelector),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating executor: %w", err)
+	}
+	k.exec = exec
+
+	executorSvc := executor.NewService(
+		conf,
+		executor.WithExecutionManager(k.data),
+		executor.WithState(sm),
+		executor.WithServiceQueue(rq),
+		executor.WithServiceExecutor(exec),
+		executor.WithServiceBatcher(batcher),
+		executor.WithServiceDebouncer(debouncer),
+	)
+
+	runnerSvc := runner.NewService(
+		conf,
+		runner.WithCQRS(k.data),
+		runner.WithExecutor(exec),
+		runner.WithExecutionManager(k.data),
+		runner.WithEventManager(event.NewManager()),
+		runner.WithStateManager(sm),
+		runner.WithRunnerQueue(rq),
+		runner.WithTracker(runner.NewTracker()),
+		runner.WithRateLimiter(ratelimit.New(ctx, k.rc, "{ratelimit}:")),
+		runner.WithBatchManager(batcher),
+		runner.WithPublisher(k.ps),
+		runner.WithPublishSubscriber(k.ps),
+	)
+
+	k.server = httptest.NewServer(k.router())
+
+	k.done = make(chan error, 1)
+	go func() {
+		k.done <- service.StartAll(ctx, runnerSvc, executorSvc)
+	}()
+	return nil
+}
+
+// ready blocks until the runner receives events.  Events published prior to
+// the runner subscribing are dropped.
+func (k *Kit) ready(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	t := time.NewTicker(pollTick)
+	defer t.Stop()
+
+	for {
+		_, done, err := k.publish(ctx, consts.DevServerEnvID, event.Event{Name: readyEvent})
+		if err != nil {
+			return err
+		}
+
+		select {
+		case err := <-k.done:
+			k.done = nil
+			return fmt.Errorf("error starting services: %w", err)
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for services to start")
+		case <-done:
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// concurrencyLimits returns each partition's concurrency limits from the
+// function's latest config.  Accounts are never limited.
+func (k *Kit) concurrencyLimits(ctx context.Context, p redis_state.QueuePartition) redis_state.PartitionConcurrencyLimits {
+	limits := redis_state.PartitionConcurrencyLimits{
+		AccountLimit:   redis_state.NoConcurrencyLimit,
+		FunctionLimit:  consts.DefaultConcurrencyLimit,
+		CustomKeyLimit: consts.DefaultConcurrencyLimit,
+	}
+	if p.FunctionID != nil {
+		if fn, err := k.data.GetFunctionByInternalUUID(ctx, consts.DevServerEnvID, *p.FunctionID); err == nil {
+			if f, err := fn.InngestFunction(); err == nil && f.Concurrency != nil && f.Concurrency.PartitionConcurrency() > 0 {
+				limits.FunctionLimit = f.Concurrency.PartitionConcurrency()
+			}
+		}
+	}
+	if p.EvaluatedConcurrencyKey != "" {
+		limits.CustomKeyLimit = p.ConcurrencyLimit
+	}
+	return limits
+}
+
+// URL returns the URL of the kit's API.  SDKs served via RegisterHandler must
+// use this URL to register functions and send events, as they would with the
+// dev server.
+func (k *Kit) URL() string {
+	return k.server.URL
+}
+
+// Now returns the kit's current time.
+func (k *Kit) Now() time.Time {
+	return k.clock.Now()
+}
+
+// FastForward moves the kit's clock forward by d, running any work which is
+// scheduled within d, such as sleeps, waitForEvent timeouts, debounces, and
+// batch or throttle windows.
+//
+// Keys in Redis continue to expire in real time:  state such as pauses must
+// outlive the queue items which consume them.
+func (k *Kit) FastForward(d time.Duration) {
+	k.clock.Advance(d)
+}
+
+// Send sends events to the kit, triggering functions.  It returns the internal
+// ID of each event, used to find the runs that each event triggers.
+//
+// Send returns once every event has been handled, ie. once runs, debounces and
+// batches have been scheduled and pauses have been resumed.
+func (k *Kit) Send(ctx context.Context, evts ...event.Event) ([]ulid.ULID, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultWaitTimeout)
+		defer cancel()
+	}
+
+	ids := make([]ulid.ULID, len(evts))
+	pending := make([]<-chan struct{}, len(evts))
+	for n, evt := range evts {
+		if evt.Timestamp == 0 {
+			evt.Timestamp = k.clock.Now().UnixMilli()
+		}
+		if err := evt.Validate(ctx); err != nil {
+			return nil, fmt.Errorf("invalid event: %w", err)
+		}
+
+		id, done, err := k.publish(ctx, consts.DevServerEnvID, evt)
+		if err != nil {
+			return nil, err
+		}
+		ids[n], pending[n] = id, done
+	}
+
+	for n, done := range pending {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ids, fmt.Errorf("waiting for event %s to be handled: %w", ids[n], ctx.Err())
+		}
+	}
+	return ids, nil
+}
+
+// Signal resumes the run waiting for the given signal with data as the wait
+// step's output, returning the ID of the resumed run.
+func (k *Kit) Signal(ctx context.Context, signal string, data any) (ulid.ULID, error) {
+	runID, err := k.exec.ResumeSignal(ctx, consts.DevServerEnvID, signal, data)
+	if err != nil {
+		return ulid.ULID{}, fmt.Errorf("error resuming signal %q: %w", signal, err)
+	}
+	return *runID, nil
+}
+
+// publish publishes the event, returning its internal ID and a channel which
+// is closed once the runner has handled the event.
+func (k *Kit) publish(ctx context.Context, envID uuid.UUID, evt event.Event) (ulid.ULID, <-chan struct{}, error) {
+	tracked := event.NewOSSTrackedEventInWorkspace(envID, evt, nil)
+	byt, err := json.Marshal(tracked)
+	if err != nil {
+		return ulid.ULID{}, nil, fmt.Errorf("error marshalling event: %w", err)
+	}
+
+	done, err := k.ps.publish(ctx, k.topic, pubsub.Message{
+		Name:      event.EventReceivedName,
+		Data:      string(byt),
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		return ulid.ULID{}, nil, fmt.Errorf("error publishing event: %w", err)
+	}
+	return tracked.GetInternalID(), done, nil
+}
+
+// Close stops every service and app started by the kit.
+func (k *Kit) Close() {
+	k.close.Do(func() {
+		k.cancel()
+		if k.done != nil {
+			select {
+			case <-k.done:
+			case <-time.After(10 * time.Second):
+			}
+		}
+
+		k.mu.Lock()
+		for _, app := range k.apps {
+			app.Close()
+		}
+		k.mu.Unlock()
+
+		if k.server != nil {
+			k.server.Close()
+		}
+		if k.rc != nil {
+			k.rc.Close()
+		}
+		if k.redis != nil {
+			k.redis.Close()
+		}
+		if k.db != nil {
+			_ = k.db.Close()
+		}
+	})
+}
@@ -0,0 +1,248 @@
+package testkit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/event"
+	"github.com/inngest/inngest/pkg/execution/state"
+	"github.com/inngest/inngest/pkg/headers"
+	"github.com/inngest/inngest/pkg/inngest"
+	"github.com/inngest/inngest/pkg/sdk"
+	"github.com/inngest/inngest/pkg/testkit"
+	"github.com/stretchr/testify/require"
+)
+
+// handler runs a function given the memoized output of each step, returning
+// the status code and response body.
+type handler func(steps map[string]json.RawMessage) (int, any)
+
+// app is a minimal SDK, serving functions triggered by each event name.
+type app struct {
+	kit       *testkit.Kit
+	functions []sdk.SDKFunction
+	handlers  map[string]handler
+}
+
+func newApp(k *testkit.Kit) *app {
+	return &app{kit: k, handlers: map[string]handler{}}
+}
+
+func (a *app) add(fn sdk.SDKFunction, h handler) {
+	a.functions = append(a.functions, fn)
+	a.handlers[fn.Slug] = h
+}
+
+func (a *app) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPut {
+		a.register(w, r)
+		return
+	}
+
+	req := struct {
+		Steps map[string]json.RawMessage `json:"steps"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(400)
+		return
+	}
+	status, body := a.handlers[r.URL.Query().Get("fnId")](req.Steps)
+	byt, _ := json.Marshal(body)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(headers.HeaderKeySDK, "go:v0.0.1")
+	w.WriteHeader(status)
+	_, _ = w.Write(byt)
+}
+
+func (a *app) register(w http.ResponseWriter, r *http.Request) {
+	url := "http://" + r.Host + "/"
+	req := sdk.RegisterRequest{
+		URL:     url,
+		V:       "1",
+		SDK:     "go:v0.0.1",
+		AppName: "app",
+	}
+	for _, fn := range a.functions {
+		fn.Steps = map[string]sdk.SDKStep{
+			"step": {
+				ID:   "step",
+				Name: "step",
+				Runtime: map[string]any{
+					"type": "http",
+					"url":  fmt.Sprintf("%s?fnId=%s&step=step", url, fn.Slug),
+				},
+			},
+		}
+		req.Functions = append(req.Functions, fn)
+	}
+
+	byt, _ := json.Marshal(req)
+	resp, err := http.Post(a.kit.URL()+"/fn/register", "application/json", bytes.NewReader(byt))
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+	_ = resp.Body.Close()
+	w.WriteHeader(resp.StatusCode)
+}
+
+func opcodes(ops ...state.GeneratorOpcode) (int, any) {
+	return 206, ops
+}
+
+func TestSleep(t *testing.T) {
+	ctx := context.Background()
+	k := testkit.New(t)
+
+	a := newApp(k)
+	a.add(sdk.SDKFunction{
+		Name:     "sleep",
+		Slug:     "app-sleep",
+		Triggers: []inngest.Trigger{{EventTrigger: &inngest.EventTrigger{Event: "test/sleep"}}},
+	}, func(steps map[string]json.RawMessage) (int, any) {
+		if _, ok := steps["a"]; !ok {
+			return opcodes(state.GeneratorOpcode{Op: enums.OpcodeStepRun, ID: "a", Name: "a", Data: json.RawMessage(`{"n":1}`)})
+		}
+		if _, ok := steps["s"]; !ok {
+			return opcodes(state.GeneratorOpcode{Op: enums.OpcodeSleep, ID: "s", Name: "1h"})
+		}
+		return 200, "done"
+	})
+	require.NoError(t, k.RegisterHandler(ctx, a))
+
+	ids, err := k.Send(ctx, event.Event{Name: "test/sleep"})
+	require.NoError(t, err)
+	runs, err := k.WaitForRuns(ctx, ids[0], 1)
+	require.NoError(t, err)
+
+	run, err := k.WaitForStep(ctx, runs[0].ID, "1h", enums.HistoryTypeStepSleeping)
+	require.NoError(t, err)
+	require.False(t, run.Ended())
+	step, ok := run.Step("a")
+	require.True(t, ok)
+	require.Equal(t, enums.HistoryTypeStepCompleted, step.Status)
+	require.JSONEq(t, `{"n":1}`, string(step.Output))
+
+	k.FastForward(time.Hour)
+	run, err = k.WaitForEnd(ctx, run.ID)
+	require.NoError(t, err)
+	require.Equal(t, enums.RunStatusCompleted, run.Status)
+	require.JSONEq(t, `"done"`, string(run.Output))
+}
+
+func TestWaitForEventTimeout(t *testing.T) {
+	ctx := context.Background()
+	k := testkit.New(t)
+
+	a := newApp(k)
+	a.add(sdk.SDKFunction{
+		Name:     "wait",
+		Slug:     "app-wait",
+		Triggers: []inngest.Trigger{{EventTrigger: &inngest.EventTrigger{Event: "test/wait"}}},
+	}, func(steps map[string]json.RawMessage) (int, any) {
+		data, ok := steps["w"]
+		if !ok {
+			return opcodes(state.GeneratorOpcode{
+				Op:   enums.OpcodeWaitForEvent,
+				ID:   "w",
+				Name: "test/approved",
+				Opts: map[string]any{"event": "test/approved", "timeout": "24h"},
+			})
+		}
+		if string(data) == "null" {
+			return 200, "timeout"
+		}
+		return 200, "approved"
+	})
+	require.NoError(t, k.RegisterHandler(ctx, a))
+
+	ids, err := k.Send(ctx, event.Event{Name: "test/wait"})
+	require.NoError(t, err)
+	runs, err := k.WaitForRuns(ctx, ids[0], 1)
+	require.NoError(t, err)
+	_, err = k.WaitForStep(ctx, runs[0].ID, "test/approved", enums.HistoryTypeStepWaiting)
+	require.NoError(t, err)
+
+	k.FastForward(25 * time.Hour)
+	run, err := k.WaitForEnd(ctx, runs[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, enums.RunStatusCompleted, run.Status)
+	require.JSONEq(t, `"timeout"`, string(run.Output))
+}
+
+func TestDebounce(t *testing.T) {
+	ctx := context.Background()
+	k := testkit.New(t)
+
+	a := newApp(k)
+	a.add(sdk.SDKFunction{
+		Name:     "debounce",
+		Slug:     "app-debounce",
+		Triggers: []inngest.Trigger{{EventTrigger: &inngest.EventTrigger{Event: "test/debounce"}}},
+		Debounce: &inngest.Debounce{Period: "5m"},
+	}, func(steps map[string]json.RawMessage) (int, any) {
+		return 200, "ok"
+	})
+	require.NoError(t, k.RegisterHandler(ctx, a))
+
+	first, err := k.Send(ctx, event.Event{Name: "test/debounce"})
+	require.NoError(t, err)
+
+	// The second event, within the period, extends the debounce.
+	k.FastForward(4 * time.Minute)
+	last, err := k.Send(ctx, event.Event{Name: "test/debounce"})
+	require.NoError(t, err)
+
+	k.FastForward(4 * time.Minute)
+	<-time.After(100 * time.Millisecond)
+	require.Empty(t, k.Runs(first[0]))
+	require.Empty(t, k.Runs(last[0]))
+
+	k.FastForward(2 * time.Minute)
+	runs, err := k.WaitForRuns(ctx, last[0], 1)
+	require.NoError(t, err)
+	run, err := k.WaitForEnd(ctx, runs[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, enums.RunStatusCompleted, run.Status)
+	require.Empty(t, k.Runs(first[0]))
+}
+
+func TestBatch(t *testing.T) {
+	ctx := context.Background()
+	k := testkit.New(t)
+
+	a := newApp(k)
+	a.add(sdk.SDKFunction{
+		Name:       "batch",
+		Slug:       "app-batch",
+		Triggers:   []inngest.Trigger{{EventTrigger: &inngest.EventTrigger{Event: "test/batch"}}},
+		EventBatch: map[string]any{"maxSize": 10, "timeout": "1m"},
+	}, func(steps map[string]json.RawMessage) (int, any) {
+		return 200, "ok"
+	})
+	require.NoError(t, k.RegisterHandler(ctx, a))
+
+	// Events are sent separately, as events sent together are appended to the
+	// batch in any order.
+	first, err := k.Send(ctx, event.Event{Name: "test/batch"})
+	require.NoError(t, err)
+	second, err := k.Send(ctx, event.Event{Name: "test/batch"})
+	require.NoError(t, err)
+	<-time.After(100 * time.Millisecond)
+	require.Empty(t, k.Runs(first[0]))
+
+	// The run is triggered by the first event in the batch.
+	k.FastForward(time.Minute)
+	runs, err := k.WaitForRuns(ctx, first[0], 1)
+	require.NoError(t, err)
+	run, err := k.WaitForEnd(ctx, runs[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, enums.RunStatusCompleted, run.Status)
+	require.Empty(t, k.Runs(second[0]))
+}
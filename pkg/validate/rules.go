@@ -0,0 +1,96 @@
+package validate
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/inngest/inngest/pkg/inngest"
+	cron "github.com/robfig/cron/v3"
+)
+
+// Rule is a lint rule, flagging configuration which is valid but likely to
+// cause problems in production.
+type Rule struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	// Check returns a message for each problem found within the function.
+	Check func(fn *inngest.Function, opts Options) []string `json:"-"`
+}
+
+// Rules lists every lint rule.
+var Rules = []Rule{
+	{
+		ID:          "hot-event-concurrency",
+		Description: "Functions triggered by hot events should limit concurrency using a key, so that a single user or tenant can't use every worker.",
+		Check:       checkHotEventConcurrency,
+	},
+	{
+		ID:          "cron-frequency",
+		Description: "Cron schedules shouldn't fire more often than the configured maximum per minute.",
+		Check:       checkCronFrequency,
+	},
+}
+
+func checkHotEventConcurrency(fn *inngest.Function, opts Options) []string {
+	if fn.Concurrency != nil {
+		for _, l := range fn.Concurrency.Limits {
+			if l.Key != nil {
+				return nil
+			}
+		}
+	}
+
+	msgs := []string{}
+	for _, t := range fn.Triggers {
+		if t.EventTrigger == nil {
+			continue
+		}
+		for _, pattern := range opts.HotEvents {
+			if ok, _ := path.Match(pattern, t.Event); ok {
+				msgs = append(msgs, fmt.Sprintf("Triggered by hot event '%s' without a keyed concurrency limit", t.Event))
+				break
+			}
+		}
+	}
+	return msgs
+}
+
+// cronParser parses schedules as the runner does.
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+func checkCronFrequency(fn *inngest.Function, opts Options) []string {
+	msgs := []string{}
+	for _, t := range fn.Triggers {
+		if t.CronTrigger == nil {
+			continue
+		}
+		sched, err := cronParser.Parse(t.Cron)
+		if err != nil {
+			// Invalid schedules fail validation.
+			continue
+		}
+		if rate := peakPerMinute(sched); rate > opts.MaxCronPerMinute {
+			msgs = append(msgs, fmt.Sprintf("Cron '%s' fires %.2g times per minute at its busiest, more than the maximum of %.2g", t.Cron, rate, opts.MaxCronPerMinute))
+		}
+	}
+	return msgs
+}
+
+// peakPerMinute returns the average number of times the schedule fires per
+// minute during its busiest hour within a week.
+func peakPerMinute(sched cron.Schedule) float64 {
+	var (
+		start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		end   = start.AddDate(0, 0, 7)
+		peak  = 0
+	)
+	for hour := start; hour.Before(end); hour = hour.Add(time.Hour) {
+		n := 0
+		for next := sched.Next(hour.Add(-time.Second)); next.Before(hour.Add(time.Hour)); next = sched.Next(next) {
+			n++
+		}
+		peak = max(peak, n)
+	}
+	return float64(peak) / 60
+}
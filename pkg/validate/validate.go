@@ -0,0 +1,165 @@
+// Package validate checks an app's function configurations offline, without a
+// running server.  Each function is validated exactly as it is when syncing,
+// then checked against lint rules which flag valid but likely problematic
+// configuration.
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/inngest/inngest/pkg/inngest"
+	"github.com/inngest/inngest/pkg/sdk"
+)
+
+// Severity is the severity of a finding.
+type Severity string
+
+const (
+	// SeverityError indicates that the app would fail to sync.
+	SeverityError Severity = "error"
+	// SeverityWarning indicates a lint rule violation.
+	SeverityWarning Severity = "warning"
+)
+
+// RuleInvalid is the rule reported for functions which fail validation.
+const RuleInvalid = "invalid"
+
+// DefaultMaxCronPerMinute is the default limit for the cron-frequency rule,
+// flagging crons which fire every minute.
+const DefaultMaxCronPerMinute = 0.5
+
+// Options configures lint rules.
+type Options struct {
+	// HotEvents lists events which are sent at a high volume, eg.
+	// "api/request.received".  Patterns such as "api/*" are supported.
+	HotEvents []string
+	// MaxCronPerMinute is the maximum average number of times a cron may fire
+	// per minute during its busiest hour.  Defaults to
+	// DefaultMaxCronPerMinute.
+	MaxCronPerMinute float64
+	// Disabled lists the IDs of lint rules which aren't run.
+	Disabled []string
+}
+
+// Finding is a single problem within an app.
+type Finding struct {
+	// Function is the slug of the function, or empty for problems with the
+	// app itself.
+	Function string   `json:"function,omitempty"`
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// Report is the result of validating an app.
+type Report struct {
+	App       string    `json:"app"`
+	Functions int       `json:"functions"`
+	Errors    int       `json:"errors"`
+	Warnings  int       `json:"warnings"`
+	Findings  []Finding `json:"findings"`
+}
+
+// Failed returns whether the report contains errors, or warnings if strict.
+func (r Report) Failed(strict bool) bool {
+	return r.Errors > 0 || (strict && r.Warnings > 0)
+}
+
+func (r *Report) add(f Finding) {
+	switch f.Severity {
+	case SeverityError:
+		r.Errors++
+	case SeverityWarning:
+		r.Warnings++
+	}
+	r.Findings = append(r.Findings, f)
+}
+
+// Validate validates each function within the registration and runs every
+// enabled lint rule against the valid functions.
+func Validate(ctx context.Context, r sdk.RegisterRequest, opts Options) Report {
+	if opts.MaxCronPerMinute <= 0 {
+		opts.MaxCronPerMinute = DefaultMaxCronPerMinute
+	}
+
+	report := Report{App: r.AppName, Functions: len(r.Functions), Findings: []Finding{}}
+	if len(r.Functions) == 0 {
+		report.add(Finding{Rule: RuleInvalid, Severity: SeverityError, Message: sdk.ErrNoFunctions.Error()})
+		return report
+	}
+
+	slugs := map[string]bool{}
+	for _, sdkFn := range r.Functions {
+		fn, errs := parse(ctx, sdkFn)
+		slug := sdkFn.Slug
+		if fn != nil {
+			slug = fn.Slug
+		}
+		for _, err := range errs {
+			report.add(Finding{Function: slug, Rule: RuleInvalid, Severity: SeverityError, Message: err.Error()})
+		}
+
+		if slugs[slug] {
+			report.add(Finding{Function: slug, Rule: RuleInvalid, Severity: SeverityError, Message: "Function IDs must be unique within an app"})
+		}
+		slugs[slug] = true
+
+		if fn == nil || len(errs) > 0 {
+			continue
+		}
+		for _, rule := range Rules {
+			if disabled(opts, rule.ID) {
+				continue
+			}
+			for _, msg := range rule.Check(fn, opts) {
+				report.add(Finding{Function: slug, Rule: rule.ID, Severity: SeverityWarning, Message: msg})
+			}
+		}
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return report.Findings[i].Function < report.Findings[j].Function
+	})
+	return report
+}
+
+// parse converts and validates the function as when syncing, returning each
+// validation error separately.
+func parse(ctx context.Context, sdkFn sdk.SDKFunction) (*inngest.Function, []error) {
+	if len(sdkFn.Steps) == 0 {
+		return nil, []error{fmt.Errorf("Function has no steps: %s", sdkFn.Name)}
+	}
+	fn, err := sdkFn.Function()
+	if err != nil {
+		return nil, []error{err}
+	}
+	return fn, flatten(fn.Validate(ctx))
+}
+
+func flatten(err error) []error {
+	if err == nil {
+		return nil
+	}
+	var merr *multierror.Error
+	if errors.As(err, &merr) {
+		errs := []error{}
+		for _, err := range merr.Errors {
+			errs = append(errs, flatten(err)...)
+		}
+		return errs
+	}
+	return []error{err}
+}
+
+func disabled(opts Options, id string) bool {
+	for _, d := range opts.Disabled {
+		if d == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package validate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/inngest/inngest/pkg/inngest"
+	"github.com/inngest/inngest/pkg/sdk"
+	"github.com/stretchr/testify/require"
+)
+
+func newFunction(slug string, triggers ...inngest.Trigger) sdk.SDKFunction {
+	return sdk.SDKFunction{
+		Name:     slug,
+		Slug:     slug,
+		Triggers: triggers,
+		Steps: map[string]sdk.SDKStep{
+			"step": {
+				ID:      "step",
+				Name:    "step",
+				Runtime: map[string]any{"url": "http://localhost:3000/api/inngest?fnId=" + slug},
+			},
+		},
+	}
+}
+
+func event(name string) inngest.Trigger {
+	return inngest.Trigger{EventTrigger: &inngest.EventTrigger{Event: name}}
+}
+
+func cronTrigger(schedule string) inngest.Trigger {
+	return inngest.Trigger{CronTrigger: &inngest.CronTrigger{Cron: schedule}}
+}
+
+func TestValidate(t *testing.T) {
+	ctx := context.Background()
+	key := "event.data.user_id"
+
+	keyed := newFunction("keyed", event("api/request"))
+	keyed.Concurrency = &inngest.ConcurrencyLimits{Limits: []inngest.Concurrency{{Limit: 5, Key: &key}}}
+	unkeyed := newFunction("unkeyed", event("api/request"))
+	unkeyed.Concurrency = &inngest.ConcurrencyLimits{Limits: []inngest.Concurrency{{Limit: 5}}}
+
+	run := "event.data.priority"
+	priority := newFunction("priority", event("app/batch"))
+	priority.Priority = &inngest.Priority{Run: &run}
+	priority.EventBatch = map[string]any{"maxSize": 10, "timeout": "5s"}
+
+	r := sdk.RegisterRequest{
+		AppName: "app",
+		Functions: []sdk.SDKFunction{
+			keyed,
+			unkeyed,
+			newFunction("cold", event("app/signup")),
+			newFunction("hourly", cronTrigger("0 * * * *")),
+			newFunction("minutely", cronTrigger("* * * * *")),
+			newFunction("business-hours", cronTrigger("TZ=Europe/Paris */2 9-17 * * 1-5")),
+			newFunction("invalid", cronTrigger("not a cron")),
+			priority,
+		},
+	}
+
+	report := Validate(ctx, r, Options{HotEvents: []string{"api/*"}})
+	require.Equal(t, 8, report.Functions)
+	require.Equal(t, 2, report.Errors)
+	require.Equal(t, 2, report.Warnings)
+
+	byFunction := map[string][]string{}
+	for _, f := range report.Findings {
+		byFunction[f.Function] = append(byFunction[f.Function], f.Rule)
+	}
+	require.Equal(t, map[string][]string{
+		"invalid":  {RuleInvalid},
+		"minutely": {"cron-frequency"},
+		"priority": {RuleInvalid},
+		"unkeyed":  {"hot-event-concurrency"},
+	}, byFunction)
+	require.True(t, report.Failed(false))
+
+	t.Run("options", func(t *testing.T) {
+		r := sdk.RegisterRequest{Functions: []sdk.SDKFunction{
+			unkeyed,
+			newFunction("minutely", cronTrigger("* * * * *")),
+			newFunction("business-hours", cronTrigger("TZ=Europe/Paris */2 9-17 * * 1-5")),
+		}}
+
+		// Without hot events, concurrency isn't checked.
+		report := Validate(ctx, r, Options{MaxCronPerMinute: 1})
+		require.Empty(t, report.Findings)
+		require.False(t, report.Failed(true))
+
+		report = Validate(ctx, r, Options{MaxCronPerMinute: 0.25, HotEvents: []string{"api/request"}, Disabled: []string{"hot-event-concurrency"}})
+		require.Equal(t, 2, report.Warnings)
+		require.False(t, report.Failed(false))
+		require.True(t, report.Failed(true))
+	})
+
+	t.Run("duplicate functions", func(t *testing.T) {
+		r := sdk.RegisterRequest{Functions: []sdk.SDKFunction{newFunction("fn", event("a")), newFunction("fn", event("b"))}}
+		report := Validate(ctx, r, Options{})
+		require.Equal(t, 1, report.Errors)
+	})
+
+	t.Run("no functions", func(t *testing.T) {
+		report := Validate(ctx, sdk.RegisterRequest{}, Options{})
+		require.Equal(t, 1, report.Errors)
+	})
+}
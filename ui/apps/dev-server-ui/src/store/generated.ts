@@ -489,6 +489,7 @@ export type RunHistoryCancel = {
   __typename?: 'RunHistoryCancel';
   eventID: Maybe<Scalars['ULID']>;
   expression: Maybe<Scalars['String']>;
+  reason: Maybe<Scalars['String']>;
   userID: Maybe<Scalars['UUID']>;
 };
 